@@ -217,8 +217,12 @@ func (hs *HTTPServer) registerRoutes() {
 				orgRoute.Get("/list/", routing.Wrap(hs.StorageService.List))
 				orgRoute.Get("/list/*", routing.Wrap(hs.StorageService.List))
 				orgRoute.Get("/read/*", routing.Wrap(hs.StorageService.Read))
+				orgRoute.Get("/history/*", routing.Wrap(hs.StorageService.History))
+				orgRoute.Get("/version/*", routing.Wrap(hs.StorageService.ReadVersion))
+				orgRoute.Get("/diff/*", routing.Wrap(hs.StorageService.Diff))
 
 				if hs.Features.IsEnabled(featuremgmt.FlagStorageLocalUpload) {
+					orgRoute.Post("/restore/*", reqEditorRole, routing.Wrap(hs.StorageService.Restore))
 					orgRoute.Delete("/delete/*", reqSignedIn, routing.Wrap(hs.StorageService.Delete))
 					orgRoute.Post("/upload", reqSignedIn, routing.Wrap(hs.StorageService.Upload))
 				}
@@ -528,6 +532,7 @@ func (hs *HTTPServer) registerRoutes() {
 		if hs.Features.IsEnabled(featuremgmt.FlagExport) {
 			adminRoute.Get("/export", reqGrafanaAdmin, routing.Wrap(hs.ExportService.HandleGetStatus))
 			adminRoute.Post("/export", reqGrafanaAdmin, routing.Wrap(hs.ExportService.HandleRequestExport))
+			adminRoute.Post("/export/import", reqGrafanaAdmin, routing.Wrap(hs.ExportService.HandleRequestImport))
 		}
 
 		adminRoute.Post("/provisioning/dashboards/reload", authorize(reqGrafanaAdmin, ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersDashboards)), routing.Wrap(hs.AdminProvisioningReloadDashboards))
@@ -195,6 +195,26 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:   "import-prometheus-rules",
+		Usage:  "import-prometheus-rules --folder-uid <folder uid> --datasource-uid <data source uid> <rule file>...",
+		Action: runDbCommand(importPrometheusRulesCommand),
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "org-id",
+				Usage: "Organization of the folder and the data source",
+				Value: 1,
+			},
+			&cli.StringFlag{
+				Name:  "folder-uid",
+				Usage: "UID of the folder the rules are imported in",
+			},
+			&cli.StringFlag{
+				Name:  "datasource-uid",
+				Usage: "UID of the Prometheus or Loki data source the expressions of the rules are queried on",
+			},
+		},
+	},
 }
 
 var cueCommands = []*cli.Command{
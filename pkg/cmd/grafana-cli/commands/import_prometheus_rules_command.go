@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/prom"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// importPrometheusRulesCommand imports Prometheus or Loki rule files as Grafana managed rules of a folder. The imported rules are
+// identified by the group, name and labels of the rule of the file, therefore importing a file again updates the rules that were
+// imported before.
+func importPrometheusRulesCommand(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	ctx := context.Background()
+	orgID := int64(c.Int("org-id"))
+	folderUID := c.String("folder-uid")
+	datasourceUID := c.String("datasource-uid")
+	if folderUID == "" || datasourceUID == "" {
+		return fmt.Errorf("the flags --folder-uid and --datasource-uid are required")
+	}
+	if c.Args().Len() == 0 {
+		return fmt.Errorf("at least one rule file is required")
+	}
+
+	folderQuery := models.GetDashboardQuery{Uid: folderUID, OrgId: orgID}
+	if err := sqlStore.GetDashboard(ctx, &folderQuery); err != nil || !folderQuery.Result.IsFolder {
+		return fmt.Errorf("could not find folder %s in organization %d", folderUID, orgID)
+	}
+
+	dsQuery := models.GetDataSourceQuery{Uid: datasourceUID, OrgId: orgID}
+	if err := sqlStore.GetDataSource(ctx, &dsQuery); err != nil {
+		return fmt.Errorf("could not find data source %s in organization %d: %w", datasourceUID, orgID, err)
+	}
+
+	cfg := sqlStore.Cfg.UnifiedAlerting
+	converter, err := prom.NewConverter(prom.Config{
+		DatasourceUID:   dsQuery.Result.Uid,
+		DatasourceType:  dsQuery.Result.Type,
+		BaseInterval:    cfg.BaseInterval,
+		DefaultInterval: cfg.DefaultRuleEvaluationInterval,
+		RecordingRules:  cfg.RecordingRules.Enabled,
+	})
+	if err != nil {
+		return err
+	}
+
+	st := &store.DBstore{
+		SQLStore:        sqlStore,
+		BaseInterval:    cfg.BaseInterval,
+		DefaultInterval: cfg.DefaultRuleEvaluationInterval,
+		Logger:          log.New("ngalert.import"),
+	}
+	// the sessions are not counted, the quota service does not need the token service
+	quotaService := quota.ProvideService(sqlStore.Cfg, nil, sqlStore)
+
+	for _, file := range c.Args().Slice() {
+		// nolint:gosec
+		// the file is given by the user running the command
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("could not read rule file %s: %w", file, err)
+		}
+		groups, err := converter.Parse(content)
+		if err != nil {
+			return fmt.Errorf("could not parse rule file %s: %w", file, err)
+		}
+		result, err := converter.Convert(orgID, folderUID, groups)
+		if err != nil {
+			return fmt.Errorf("could not convert rule file %s: %w", file, err)
+		}
+		if err := result.Validate(datasourceConditionValidator(ctx, sqlStore, orgID)); err != nil {
+			return fmt.Errorf("invalid rule file %s: %w", file, err)
+		}
+
+		imported, err := importPrometheusRules(ctx, st, quotaService, orgID, folderUID, result)
+		if err != nil {
+			return fmt.Errorf("could not import rule file %s: %w", file, err)
+		}
+
+		logger.Info("\n")
+		for _, group := range imported {
+			logger.Infof("%s Imported rule group %s of %s: %d added, %d updated, %d deleted\n", color.GreenString("✔"), group.Group, file, len(group.New), len(group.Update), len(group.Delete))
+		}
+		for _, skipped := range result.Skipped {
+			logger.Warnf("Skipped rule %s of group %s: %s\n", skipped.Name, skipped.Group, skipped.Reason)
+		}
+	}
+	return nil
+}
+
+// datasourceConditionValidator returns a validator of the conditions of the imported rules that, like the ruler API,
+// requires the data sources of the queries to exist in the organization and the condition to be one of the queries.
+func datasourceConditionValidator(ctx context.Context, sqlStore *sqlstore.SQLStore, orgID int64) func(ngmodels.Condition) error {
+	return func(c ngmodels.Condition) error {
+		refIDs := make(map[string]struct{}, len(c.Data))
+		for _, query := range c.Data {
+			isExpression, err := query.IsExpression()
+			if err != nil {
+				return err
+			}
+			if !isExpression {
+				dsQuery := models.GetDataSourceQuery{Uid: query.DatasourceUID, OrgId: orgID}
+				if err := sqlStore.GetDataSource(ctx, &dsQuery); err != nil {
+					return fmt.Errorf("invalid query %s: %w: %s", query.RefID, err, query.DatasourceUID)
+				}
+			}
+			refIDs[query.RefID] = struct{}{}
+		}
+		if _, ok := refIDs[c.Condition]; !ok {
+			return fmt.Errorf("condition %s not found in any query or expression", c.Condition)
+		}
+		return nil
+	}
+}
+
+// importPrometheusRules saves the converted rules in the folder, see prom.Import. The import fails when the rules it adds exceed
+// the alert rule quota of the organization, it is checked before the rules are inserted.
+func importPrometheusRules(ctx context.Context, st *store.DBstore, quotaService *quota.QuotaService, orgID int64, folderUID string, result *prom.Result) ([]*prom.GroupChanges, error) {
+	var imported []*prom.GroupChanges
+	err := st.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		imported, err = prom.Import(ctx, st, st, orgID, folderUID, result, prom.ImportOptions{
+			CheckQuota: func(ctx context.Context, count int) error {
+				limitReached, err := quotaService.CheckQuotaReachedFor(ctx, "alert_rule", &quota.ScopeParameters{OrgId: orgID}, int64(count)) // alert rule is table name
+				if err != nil {
+					return fmt.Errorf("failed to get alert rules quota: %w", err)
+				}
+				if limitReached {
+					return errors.New("the alert rule quota has been exceeded")
+				}
+				return nil
+			},
+		})
+		return err
+	})
+	return imported, err
+}
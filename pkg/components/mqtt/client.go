@@ -0,0 +1,536 @@
+// Package mqtt implements the subset of the MQTT 3.1.1 protocol that Grafana needs to publish messages to a broker
+// and to receive the messages of subscribed topics.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// QoS is the quality of service of the delivery of a message.
+type QoS byte
+
+const (
+	AtMostOnce  QoS = 0
+	AtLeastOnce QoS = 1
+	ExactlyOnce QoS = 2
+)
+
+const (
+	defaultKeepAlive     = 30 * time.Second
+	defaultMaxPacketSize = 1 << 20
+)
+
+var (
+	ErrClientClosed      = errors.New("mqtt client is closed")
+	ErrUnsupportedScheme = errors.New("unsupported broker URL scheme, expected tcp, mqtt, ssl, tls or mqtts")
+	ErrInvalidQoS        = errors.New("invalid QoS, expected 0, 1 or 2")
+	ErrPacketTooLarge    = errors.New("packet exceeds the maximum packet size")
+	ErrFieldTooLong      = errors.New("string exceeds the maximum length of 65535 bytes")
+)
+
+// connectReturnCodes are the reasons the broker refuses a connection.
+var connectReturnCodes = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Message is a message received from a subscribed topic.
+type Message struct {
+	Topic   string
+	Payload []byte
+	QoS     QoS
+	Retain  bool
+}
+
+// ConnectError is returned when the broker refuses the connection.
+type ConnectError struct {
+	Code byte
+}
+
+func (e ConnectError) Error() string {
+	if reason, ok := connectReturnCodes[e.Code]; ok {
+		return "connection refused: " + reason
+	}
+	return fmt.Sprintf("connection refused: return code %d", e.Code)
+}
+
+// Options configures the connection to the broker.
+type Options struct {
+	// BrokerURL is the URL of the broker, e.g. tcp://localhost:1883 or ssl://localhost:8883.
+	BrokerURL string
+	// ClientID identifies the client to the broker, a random one is used when empty.
+	ClientID  string
+	Username  string
+	Password  string
+	TLSConfig *tls.Config
+	// KeepAlive is the interval of the pings to the broker, 30 seconds when zero.
+	KeepAlive time.Duration
+	// MaxPacketSize is the largest packet accepted from the broker, 1 MiB when zero. The connection is closed
+	// with ErrPacketTooLarge when the broker sends a larger packet.
+	MaxPacketSize int
+	// OnMessage is called with the messages of the subscribed topics. It is called from the goroutine reading
+	// the connection, so it must not block.
+	OnMessage func(Message)
+}
+
+// Client is a connection to an MQTT broker. It is safe for concurrent use.
+type Client struct {
+	conn          net.Conn
+	maxPacketSize int
+
+	writeMtx sync.Mutex
+
+	mtx      sync.Mutex
+	lastID   uint16
+	inflight map[uint16]chan packet
+
+	onMessage func(Message)
+	// received are the identifiers of the QoS 2 messages delivered to onMessage and not released by the broker yet,
+	// only used by the read loop.
+	received map[uint16]struct{}
+
+	done      chan struct{}
+	err       error
+	closeOnce sync.Once
+}
+
+// Connect opens a connection with a clean session to the broker. The context bounds the connection handshake.
+func Connect(ctx context.Context, opts Options) (*Client, error) {
+	addr, useTLS, err := brokerAddress(opts.BrokerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	if useTLS {
+		cfg := &tls.Config{}
+		if opts.TLSConfig != nil {
+			cfg = opts.TLSConfig.Clone()
+		}
+		if cfg.ServerName == "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			cfg.ServerName = host
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	keepAlive := opts.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = defaultKeepAlive
+	}
+	maxPacketSize := opts.MaxPacketSize
+	if maxPacketSize <= 0 {
+		maxPacketSize = defaultMaxPacketSize
+	}
+	clientID := opts.ClientID
+	if clientID == "" {
+		clientID = "grafana-" + util.GenerateShortUID()
+	}
+
+	r := bufio.NewReader(conn)
+	connect, err := connectMessage(clientID, opts.Username, opts.Password, keepAlive)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err := writePacket(conn, connect); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	ack, err := readPacket(r, maxPacketSize)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if ack.typ != connackPacket || len(ack.body) < 2 {
+		_ = conn.Close()
+		return nil, fmt.Errorf("expected CONNACK, got packet of type %d", ack.typ)
+	}
+	if code := ack.body[1]; code != 0 {
+		_ = conn.Close()
+		return nil, ConnectError{Code: code}
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	c := &Client{
+		conn:          conn,
+		maxPacketSize: maxPacketSize,
+		inflight:      map[uint16]chan packet{},
+		onMessage:     opts.OnMessage,
+		received:      map[uint16]struct{}{},
+		done:          make(chan struct{}),
+	}
+	go c.readLoop(r)
+	go c.pingLoop(keepAlive)
+	return c, nil
+}
+
+// Publish sends the message to the topic. It returns once the broker acknowledged the message, for QoS 1 and 2,
+// or once the message is written, for QoS 0.
+func (c *Client) Publish(ctx context.Context, topic string, payload []byte, qos QoS, retain bool) error {
+	if qos > ExactlyOnce {
+		return ErrInvalidQoS
+	}
+	if topic == "" {
+		return errors.New("topic must not be empty")
+	}
+
+	flags := byte(qos) << 1
+	if retain {
+		flags |= 0x01
+	}
+	body, err := appendString(nil, topic)
+	if err != nil {
+		return err
+	}
+
+	if qos == AtMostOnce {
+		return c.write(packet{typ: publishPacket, flags: flags, body: append(body, payload...)})
+	}
+
+	id, acks := c.register()
+	defer c.unregister(id)
+
+	body = appendUint16(body, id)
+	if err := c.write(packet{typ: publishPacket, flags: flags, body: append(body, payload...)}); err != nil {
+		return err
+	}
+
+	if qos == AtLeastOnce {
+		_, err := c.await(ctx, acks, pubackPacket)
+		return err
+	}
+
+	if _, err := c.await(ctx, acks, pubrecPacket); err != nil {
+		return err
+	}
+	if err := c.write(ackPacket(pubrelPacket, id)); err != nil {
+		return err
+	}
+	_, err = c.await(ctx, acks, pubcompPacket)
+	return err
+}
+
+// Subscribe subscribes to the topic filters with the maximum QoS of the messages the broker sends. The messages are
+// passed to Options.OnMessage. It returns once the broker acknowledged the subscription.
+func (c *Client) Subscribe(ctx context.Context, qos QoS, topics ...string) error {
+	if qos > ExactlyOnce {
+		return ErrInvalidQoS
+	}
+	if len(topics) == 0 {
+		return errors.New("no topics to subscribe to")
+	}
+
+	id, acks := c.register()
+	defer c.unregister(id)
+
+	body := appendUint16(nil, id)
+	for _, topic := range topics {
+		if topic == "" {
+			return errors.New("topic must not be empty")
+		}
+		var err error
+		if body, err = appendString(body, topic); err != nil {
+			return err
+		}
+		body = append(body, byte(qos))
+	}
+	if err := c.write(packet{typ: subscribePacket, flags: 0x02, body: body}); err != nil {
+		return err
+	}
+
+	ack, err := c.await(ctx, acks, subackPacket)
+	if err != nil {
+		return err
+	}
+	codes := ack.body[2:]
+	if len(codes) != len(topics) {
+		return errMalformedPacket
+	}
+	for i, code := range codes {
+		if code == 0x80 {
+			return fmt.Errorf("subscription to %s refused", topics[i])
+		}
+	}
+	return nil
+}
+
+// Done returns a channel that is closed when the connection is closed, by Close or because of an error.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the connection is closed, it must only be called once Done is closed.
+func (c *Client) Err() error {
+	return c.err
+}
+
+// Close disconnects from the broker.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		// the broker closes the connection when it receives DISCONNECT, the error of the write is not relevant then
+		_ = c.write(packet{typ: disconnectPacket})
+		c.err = ErrClientClosed
+		close(c.done)
+		err = c.conn.Close()
+	})
+	return err
+}
+
+func (c *Client) write(p packet) error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+	}
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+	return writePacket(c.conn, p)
+}
+
+// register reserves a packet identifier, the acknowledgements of the packet are sent to the channel.
+func (c *Client) register() (uint16, chan packet) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for {
+		c.lastID++
+		if c.lastID == 0 {
+			c.lastID = 1
+		}
+		if _, ok := c.inflight[c.lastID]; !ok {
+			break
+		}
+	}
+	ch := make(chan packet, 2)
+	c.inflight[c.lastID] = ch
+	return c.lastID, ch
+}
+
+func (c *Client) unregister(id uint16) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	delete(c.inflight, id)
+}
+
+func (c *Client) await(ctx context.Context, acks chan packet, typ byte) (packet, error) {
+	select {
+	case p := <-acks:
+		if p.typ != typ {
+			return packet{}, fmt.Errorf("expected packet of type %d, got %d", typ, p.typ)
+		}
+		return p, nil
+	case <-c.done:
+		return packet{}, c.err
+	case <-ctx.Done():
+		return packet{}, ctx.Err()
+	}
+}
+
+func (c *Client) readLoop(r *bufio.Reader) {
+	for {
+		p, err := readPacket(r, c.maxPacketSize)
+		if err != nil {
+			c.closeWithError(err)
+			return
+		}
+		switch p.typ {
+		case pubackPacket, pubrecPacket, pubcompPacket, subackPacket:
+			id, err := p.packetID()
+			if err != nil {
+				c.closeWithError(err)
+				return
+			}
+			c.mtx.Lock()
+			acks, ok := c.inflight[id]
+			c.mtx.Unlock()
+			if ok {
+				select {
+				case acks <- p:
+				default:
+				}
+			}
+		case publishPacket:
+			if err := c.receive(p); err != nil {
+				c.closeWithError(err)
+				return
+			}
+		case pubrelPacket:
+			id, err := p.packetID()
+			if err != nil {
+				c.closeWithError(err)
+				return
+			}
+			delete(c.received, id)
+			if err := c.write(ackPacket(pubcompPacket, id)); err != nil {
+				c.closeWithError(err)
+				return
+			}
+		case pingrespPacket:
+		default:
+			c.closeWithError(fmt.Errorf("unexpected packet of type %d", p.typ))
+			return
+		}
+	}
+}
+
+// receive passes a message sent by the broker to onMessage and acknowledges it according to its QoS.
+// A QoS 2 message is passed once even if the broker sends it again before releasing it.
+func (c *Client) receive(p packet) error {
+	qos := QoS(p.flags >> 1 & 0x03)
+	if qos > ExactlyOnce {
+		return ErrInvalidQoS
+	}
+	topic, payload, err := readString(p.body)
+	if err != nil {
+		return err
+	}
+	var id uint16
+	if qos > AtMostOnce {
+		if len(payload) < 2 {
+			return errMalformedPacket
+		}
+		id = binary.BigEndian.Uint16(payload)
+		payload = payload[2:]
+	}
+
+	msg := Message{Topic: topic, Payload: payload, QoS: qos, Retain: p.flags&0x01 == 1}
+	switch qos {
+	case AtLeastOnce:
+		c.deliver(msg)
+		return c.write(ackPacket(pubackPacket, id))
+	case ExactlyOnce:
+		if _, ok := c.received[id]; !ok {
+			c.received[id] = struct{}{}
+			c.deliver(msg)
+		}
+		return c.write(ackPacket(pubrecPacket, id))
+	default:
+		c.deliver(msg)
+		return nil
+	}
+}
+
+func (c *Client) deliver(msg Message) {
+	if c.onMessage != nil {
+		c.onMessage(msg)
+	}
+}
+
+func (c *Client) pingLoop(keepAlive time.Duration) {
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.write(packet{typ: pingreqPacket}); err != nil {
+				c.closeWithError(err)
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// closeWithError closes the connection without DISCONNECT, the pending and later calls return the error.
+func (c *Client) closeWithError(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.done)
+		_ = c.conn.Close()
+	})
+}
+
+func connectMessage(clientID, username, password string, keepAlive time.Duration) (packet, error) {
+	// clean session
+	flags := byte(0x02)
+	if username != "" {
+		flags |= 0x80
+	}
+	if password != "" {
+		flags |= 0x40
+	}
+
+	body, _ := appendString(nil, "MQTT")
+	body = append(body, 4, flags)
+	body = appendUint16(body, uint16(keepAlive/time.Second))
+	fields := []string{clientID}
+	if username != "" {
+		fields = append(fields, username)
+	}
+	if password != "" {
+		fields = append(fields, password)
+	}
+	for _, field := range fields {
+		var err error
+		if body, err = appendString(body, field); err != nil {
+			return packet{}, err
+		}
+	}
+	return packet{typ: connectPacket, body: body}, nil
+}
+
+// brokerAddress returns the address of the broker and whether the connection uses TLS.
+func brokerAddress(brokerURL string) (string, bool, error) {
+	u, err := url.Parse(brokerURL)
+	if err != nil {
+		return "", false, err
+	}
+
+	var useTLS bool
+	port := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		useTLS = true
+		port = "8883"
+	default:
+		return "", false, ErrUnsupportedScheme
+	}
+	if u.Hostname() == "" {
+		return "", false, errors.New("broker URL has no host")
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	return net.JoinHostPort(u.Hostname(), port), useTLS, nil
+}
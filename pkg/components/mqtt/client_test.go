@@ -0,0 +1,328 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type message struct {
+	topic   string
+	payload string
+	qos     QoS
+	retain  bool
+}
+
+// fakeBroker accepts connections and records the published messages, it acknowledges them according to their QoS.
+// Once a client subscribes, the broker sends it the retained messages.
+type fakeBroker struct {
+	t          *testing.T
+	listener   net.Listener
+	returnCode byte
+	retained   []message
+
+	mtx           sync.Mutex
+	connects      []packet
+	messages      []message
+	subscriptions []string
+	acks          []byte
+	received      chan struct{}
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	b := &fakeBroker{t: t, listener: l, received: make(chan struct{}, 10)}
+	t.Cleanup(func() { _ = l.Close() })
+	go b.serve()
+	return b
+}
+
+func (b *fakeBroker) URL() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *fakeBroker) Messages() []message {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return append([]message{}, b.messages...)
+}
+
+func (b *fakeBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *fakeBroker) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	released := map[uint16]struct{}{}
+	for {
+		p, err := readPacket(r, maxRemainingLength)
+		if err != nil {
+			return
+		}
+		switch p.typ {
+		case connectPacket:
+			b.mtx.Lock()
+			b.connects = append(b.connects, p)
+			b.mtx.Unlock()
+			if err := writePacket(conn, packet{typ: connackPacket, body: []byte{0, b.returnCode}}); err != nil {
+				return
+			}
+		case publishPacket:
+			qos := QoS(p.flags >> 1 & 0x03)
+			topic, rest, err := readString(p.body)
+			if err != nil {
+				return
+			}
+			var id uint16
+			if qos > AtMostOnce {
+				id = uint16(rest[0])<<8 | uint16(rest[1])
+				rest = rest[2:]
+			}
+			b.mtx.Lock()
+			b.messages = append(b.messages, message{topic: topic, payload: string(rest), qos: qos, retain: p.flags&0x01 == 1})
+			b.mtx.Unlock()
+			b.received <- struct{}{}
+			switch qos {
+			case AtLeastOnce:
+				_ = writePacket(conn, ackPacket(pubackPacket, id))
+			case ExactlyOnce:
+				_ = writePacket(conn, ackPacket(pubrecPacket, id))
+			}
+		case pubrelPacket:
+			id, _ := p.packetID()
+			_ = writePacket(conn, ackPacket(pubcompPacket, id))
+		case subscribePacket:
+			id, _ := p.packetID()
+			rest := p.body[2:]
+			suback := appendUint16(nil, id)
+			b.mtx.Lock()
+			for len(rest) > 0 {
+				topic, r, err := readString(rest)
+				if err != nil || len(r) == 0 {
+					b.mtx.Unlock()
+					return
+				}
+				b.subscriptions = append(b.subscriptions, topic)
+				code := r[0]
+				if topic == "forbidden" {
+					code = 0x80
+				}
+				suback = append(suback, code)
+				rest = r[1:]
+			}
+			b.mtx.Unlock()
+			_ = writePacket(conn, packet{typ: subackPacket, body: suback})
+			for i, m := range b.retained {
+				body, _ := appendString(nil, m.topic)
+				if m.qos > AtMostOnce {
+					body = appendUint16(body, uint16(i+1))
+				}
+				p := packet{typ: publishPacket, flags: byte(m.qos)<<1 | 0x01, body: append(body, m.payload...)}
+				_ = writePacket(conn, p)
+				if m.qos == ExactlyOnce {
+					// sent again as a duplicate before the release
+					p.flags |= 0x08
+					_ = writePacket(conn, p)
+				}
+			}
+		case pubackPacket, pubcompPacket:
+			id, _ := p.packetID()
+			b.mtx.Lock()
+			b.acks = append(b.acks, p.typ<<4|byte(id))
+			b.mtx.Unlock()
+			b.received <- struct{}{}
+		case pubrecPacket:
+			// the duplicate of a message is acknowledged again but released once
+			id, _ := p.packetID()
+			if _, ok := released[id]; !ok {
+				released[id] = struct{}{}
+				_ = writePacket(conn, ackPacket(pubrelPacket, id))
+			}
+		case pingreqPacket:
+			_ = writePacket(conn, packet{typ: pingrespPacket})
+		case disconnectPacket:
+			return
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Run("publishes with every QoS", func(t *testing.T) {
+		broker := newFakeBroker(t)
+		c, err := Connect(ctx, Options{BrokerURL: broker.URL(), ClientID: "test", Username: "user", Password: "pass"})
+		require.NoError(t, err)
+
+		require.NoError(t, c.Publish(ctx, "alerts/0", []byte("at most once"), AtMostOnce, false))
+		<-broker.received
+		require.NoError(t, c.Publish(ctx, "alerts/1", []byte("at least once"), AtLeastOnce, true))
+		require.NoError(t, c.Publish(ctx, "alerts/2", []byte("exactly once"), ExactlyOnce, false))
+		require.NoError(t, c.Close())
+
+		require.Equal(t, []message{
+			{topic: "alerts/0", payload: "at most once", qos: AtMostOnce},
+			{topic: "alerts/1", payload: "at least once", qos: AtLeastOnce, retain: true},
+			{topic: "alerts/2", payload: "exactly once", qos: ExactlyOnce},
+		}, broker.Messages())
+
+		broker.mtx.Lock()
+		require.Len(t, broker.connects, 1)
+		connect := broker.connects[0].body
+		broker.mtx.Unlock()
+		require.True(t, bytes.HasPrefix(connect, []byte("\x00\x04MQTT\x04\xc2")))
+		require.True(t, bytes.HasSuffix(connect, []byte("\x00\x04test\x00\x04user\x00\x04pass")))
+
+		require.ErrorIs(t, c.Publish(ctx, "alerts/1", []byte("closed"), AtLeastOnce, false), ErrClientClosed)
+	})
+
+	t.Run("receives the messages of subscribed topics", func(t *testing.T) {
+		broker := newFakeBroker(t)
+		broker.retained = []message{
+			{topic: "sensors/0", payload: "at most once", qos: AtMostOnce},
+			{topic: "sensors/1", payload: "at least once", qos: AtLeastOnce},
+			{topic: "sensors/2", payload: "exactly once", qos: ExactlyOnce},
+		}
+		messages := make(chan Message, 10)
+		c, err := Connect(ctx, Options{BrokerURL: broker.URL(), OnMessage: func(m Message) { messages <- m }})
+		require.NoError(t, err)
+		defer func() { require.NoError(t, c.Close()) }()
+
+		require.NoError(t, c.Subscribe(ctx, ExactlyOnce, "sensors/#", "status"))
+		for i := 0; i < 2; i++ {
+			<-broker.received
+		}
+
+		require.Len(t, messages, 3)
+		require.Equal(t, Message{Topic: "sensors/0", Payload: []byte("at most once"), QoS: AtMostOnce, Retain: true}, <-messages)
+		require.Equal(t, Message{Topic: "sensors/1", Payload: []byte("at least once"), QoS: AtLeastOnce, Retain: true}, <-messages)
+		require.Equal(t, Message{Topic: "sensors/2", Payload: []byte("exactly once"), QoS: ExactlyOnce, Retain: true}, <-messages)
+
+		broker.mtx.Lock()
+		defer broker.mtx.Unlock()
+		require.Equal(t, []string{"sensors/#", "status"}, broker.subscriptions)
+		require.Equal(t, []byte{pubackPacket<<4 | 2, pubcompPacket<<4 | 3}, broker.acks)
+	})
+
+	t.Run("returns an error when the subscription is refused", func(t *testing.T) {
+		broker := newFakeBroker(t)
+		c, err := Connect(ctx, Options{BrokerURL: broker.URL()})
+		require.NoError(t, err)
+		defer func() { require.NoError(t, c.Close()) }()
+		require.EqualError(t, c.Subscribe(ctx, AtMostOnce, "sensors/#", "forbidden"), "subscription to forbidden refused")
+	})
+
+	t.Run("closes done when the broker closes the connection", func(t *testing.T) {
+		broker := newFakeBroker(t)
+		c, err := Connect(ctx, Options{BrokerURL: broker.URL()})
+		require.NoError(t, err)
+		require.NoError(t, broker.listener.Close())
+		// the fake broker closes the connection on DISCONNECT
+		require.NoError(t, c.write(packet{typ: disconnectPacket}))
+		<-c.Done()
+		require.Error(t, c.Err())
+		require.NotErrorIs(t, c.Err(), ErrClientClosed)
+	})
+
+	t.Run("returns the reason the connection is refused", func(t *testing.T) {
+		broker := newFakeBroker(t)
+		broker.returnCode = 4
+		_, err := Connect(ctx, Options{BrokerURL: broker.URL()})
+		require.EqualError(t, err, "connection refused: bad user name or password")
+	})
+
+	t.Run("closes the connection when the broker sends a packet larger than the maximum size", func(t *testing.T) {
+		broker := newFakeBroker(t)
+		broker.retained = []message{{topic: "sensors/0", payload: strings.Repeat("x", 64), qos: AtMostOnce}}
+		c, err := Connect(ctx, Options{BrokerURL: broker.URL(), MaxPacketSize: 32, OnMessage: func(Message) {}})
+		require.NoError(t, err)
+		require.NoError(t, c.Subscribe(ctx, AtMostOnce, "sensors/#"))
+		<-c.Done()
+		require.ErrorIs(t, c.Err(), ErrPacketTooLarge)
+	})
+
+	t.Run("returns an error for strings that cannot be encoded", func(t *testing.T) {
+		broker := newFakeBroker(t)
+		_, err := Connect(ctx, Options{BrokerURL: broker.URL(), Username: strings.Repeat("u", maxStringLength+1)})
+		require.ErrorIs(t, err, ErrFieldTooLong)
+
+		c, err := Connect(ctx, Options{BrokerURL: broker.URL()})
+		require.NoError(t, err)
+		defer func() { require.NoError(t, c.Close()) }()
+		require.ErrorIs(t, c.Publish(ctx, strings.Repeat("t", maxStringLength+1), nil, AtMostOnce, false), ErrFieldTooLong)
+	})
+
+	t.Run("returns an error for invalid options", func(t *testing.T) {
+		_, err := Connect(ctx, Options{BrokerURL: "ws://localhost:1883"})
+		require.ErrorIs(t, err, ErrUnsupportedScheme)
+
+		broker := newFakeBroker(t)
+		c, err := Connect(ctx, Options{BrokerURL: broker.URL()})
+		require.NoError(t, err)
+		defer func() { require.NoError(t, c.Close()) }()
+		require.ErrorIs(t, c.Publish(ctx, "alerts", nil, QoS(3), false), ErrInvalidQoS)
+	})
+}
+
+func TestReadPacket(t *testing.T) {
+	t.Run("refuses a packet larger than the maximum length before reading its body", func(t *testing.T) {
+		// PUBLISH declaring the maximum remaining length of 256 MB
+		r := bufio.NewReader(bytes.NewReader([]byte{publishPacket << 4, 0xff, 0xff, 0xff, 0x7f}))
+		_, err := readPacket(r, defaultMaxPacketSize)
+		require.ErrorIs(t, err, ErrPacketTooLarge)
+	})
+
+	t.Run("reads a packet up to the maximum length", func(t *testing.T) {
+		r := bufio.NewReader(bytes.NewReader([]byte{pubackPacket << 4, 2, 0, 7}))
+		p, err := readPacket(r, 2)
+		require.NoError(t, err)
+		id, err := p.packetID()
+		require.NoError(t, err)
+		require.Equal(t, uint16(7), id)
+	})
+}
+
+func TestBrokerAddress(t *testing.T) {
+	testCases := []struct {
+		url    string
+		addr   string
+		useTLS bool
+		err    string
+	}{
+		{url: "tcp://localhost", addr: "localhost:1883"},
+		{url: "mqtt://localhost:1884", addr: "localhost:1884"},
+		{url: "ssl://broker.example.com", addr: "broker.example.com:8883", useTLS: true},
+		{url: "mqtts://[::1]:8884", addr: "[::1]:8884", useTLS: true},
+		{url: "http://localhost", err: ErrUnsupportedScheme.Error()},
+		{url: "tcp://", err: "broker URL has no host"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			addr, useTLS, err := brokerAddress(tc.url)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.addr, addr)
+			require.Equal(t, tc.useTLS, useTLS)
+		})
+	}
+}
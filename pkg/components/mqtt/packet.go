@@ -0,0 +1,140 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The types of the MQTT 3.1.1 control packets.
+const (
+	connectPacket    byte = 1
+	connackPacket    byte = 2
+	publishPacket    byte = 3
+	pubackPacket     byte = 4
+	pubrecPacket     byte = 5
+	pubrelPacket     byte = 6
+	pubcompPacket    byte = 7
+	subscribePacket  byte = 8
+	subackPacket     byte = 9
+	pingreqPacket    byte = 12
+	pingrespPacket   byte = 13
+	disconnectPacket byte = 14
+)
+
+// maxRemainingLength is the largest remaining length that can be encoded in the fixed header.
+const maxRemainingLength = 268435455
+
+// maxStringLength is the largest string that can be encoded with its length prefix.
+const maxStringLength = 65535
+
+var errMalformedPacket = errors.New("malformed packet")
+
+// packet is a control packet, the body is everything after the fixed header.
+type packet struct {
+	typ   byte
+	flags byte
+	body  []byte
+}
+
+// packetID returns the identifier of the acknowledgement packets.
+func (p packet) packetID() (uint16, error) {
+	if len(p.body) < 2 {
+		return 0, errMalformedPacket
+	}
+	return binary.BigEndian.Uint16(p.body), nil
+}
+
+// readPacket reads the next packet, the packets with a remaining length larger than maxLength are refused
+// before their body is read.
+func readPacket(r *bufio.Reader, maxLength int) (packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return packet{}, errMalformedPacket
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+
+	if length > maxLength {
+		return packet{}, fmt.Errorf("%w: %d bytes", ErrPacketTooLarge, length)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+	return packet{typ: header >> 4, flags: header & 0x0f, body: body}, nil
+}
+
+func writePacket(w io.Writer, p packet) error {
+	length := len(p.body)
+	if length > maxRemainingLength {
+		return fmt.Errorf("packet of %d bytes is too large", length)
+	}
+
+	b := make([]byte, 0, 5+length)
+	b = append(b, p.typ<<4|p.flags)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if length == 0 {
+			break
+		}
+	}
+	b = append(b, p.body...)
+
+	_, err := w.Write(b)
+	return err
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendString(b []byte, s string) ([]byte, error) {
+	if len(s) > maxStringLength {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFieldTooLong, len(s))
+	}
+	b = appendUint16(b, uint16(len(s)))
+	return append(b, s...), nil
+}
+
+// readString reads a length-prefixed string and returns the rest of the buffer.
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errMalformedPacket
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, errMalformedPacket
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
+
+func ackPacket(typ byte, id uint16) packet {
+	var flags byte
+	if typ == pubrelPacket {
+		flags = 0x02
+	}
+	return packet{typ: typ, flags: flags, body: appendUint16(nil, id)}
+}
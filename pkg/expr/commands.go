@@ -70,24 +70,27 @@ func (gm *MathCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathexp.
 
 // ReduceCommand is an expression command for reduction of a timeseries such as a min, mean, or max.
 type ReduceCommand struct {
-	Reducer      string
-	VarToReduce  string
-	refID        string
-	seriesMapper mathexp.ReduceMapper
+	Reducer       string
+	ReducerParams []float64
+	VarToReduce   string
+	refID         string
+	seriesMapper  mathexp.ReduceMapper
 }
 
 // NewReduceCommand creates a new ReduceCMD.
-func NewReduceCommand(refID, reducer, varToReduce string, mapper mathexp.ReduceMapper) (*ReduceCommand, error) {
-	_, err := mathexp.GetReduceFunc(reducer)
+// params are passed to reducers that take parameters, such as percentile.
+func NewReduceCommand(refID, reducer, varToReduce string, mapper mathexp.ReduceMapper, params ...float64) (*ReduceCommand, error) {
+	err := mathexp.CheckReducer(reducer, params...)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ReduceCommand{
-		Reducer:      reducer,
-		VarToReduce:  varToReduce,
-		refID:        refID,
-		seriesMapper: mapper,
+		Reducer:       reducer,
+		ReducerParams: params,
+		VarToReduce:   varToReduce,
+		refID:         refID,
+		seriesMapper:  mapper,
 	}, nil
 }
 
@@ -112,6 +115,21 @@ func UnmarshalReduceCommand(rn *rawNode) (*ReduceCommand, error) {
 		return nil, fmt.Errorf("expected reducer to be a string, got %T for refId %v", rawReducer, rn.RefID)
 	}
 
+	var params []float64
+	if rawParams, ok := rn.Query["reducerParams"]; ok {
+		paramList, ok := rawParams.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected reducerParams to be an array, got %T for refId %v", rawParams, rn.RefID)
+		}
+		for _, rawParam := range paramList {
+			param, ok := rawParam.(float64)
+			if !ok {
+				return nil, fmt.Errorf("expected reducerParams to be numbers, got %T for refId %v", rawParam, rn.RefID)
+			}
+			params = append(params, param)
+		}
+	}
+
 	var mapper mathexp.ReduceMapper = nil
 	settings, ok := rn.Query["settings"]
 	if ok {
@@ -141,7 +159,7 @@ func UnmarshalReduceCommand(rn *rawNode) (*ReduceCommand, error) {
 			return nil, fmt.Errorf("expected settings to be an object, got %T for refId %v", s, rn.RefID)
 		}
 	}
-	return NewReduceCommand(rn.RefID, redFunc, varToReduce, mapper)
+	return NewReduceCommand(rn.RefID, redFunc, varToReduce, mapper, params...)
 }
 
 // NeedsVars returns the variable names (refIds) that are dependencies
@@ -159,7 +177,7 @@ func (gr *ReduceCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathex
 		if !ok {
 			return newRes, fmt.Errorf("can only reduce type series, got type %v", val.Type())
 		}
-		num, err := series.Reduce(gr.refID, gr.Reducer, gr.seriesMapper)
+		num, err := series.Reduce(gr.refID, gr.Reducer, gr.seriesMapper, gr.ReducerParams...)
 		if err != nil {
 			return newRes, err
 		}
@@ -89,3 +89,69 @@ func Test_UnmarshalReduceCommand_Settings(t *testing.T) {
 		})
 	}
 }
+
+func Test_UnmarshalReduceCommand_ReducerParams(t *testing.T) {
+	var tests = []struct {
+		name           string
+		reducer        string
+		reducerParams  string
+		isError        bool
+		expectedParams []float64
+	}{
+		{
+			name:           "no params when reducerParams is not specified",
+			reducer:        "sum",
+			reducerParams:  ``,
+			expectedParams: nil,
+		},
+		{
+			name:           "percentile with a single param",
+			reducer:        "percentile",
+			reducerParams:  `, "reducerParams" : [ 95 ]`,
+			expectedParams: []float64{95},
+		},
+		{
+			name:          "error when percentile has no params",
+			reducer:       "percentile",
+			reducerParams: ``,
+			isError:       true,
+		},
+		{
+			name:          "error when reducerParams is not an array",
+			reducer:       "percentile",
+			reducerParams: `, "reducerParams" : 95`,
+			isError:       true,
+		},
+		{
+			name:          "error when reducerParams contains a non number",
+			reducer:       "percentile",
+			reducerParams: `, "reducerParams" : [ "95" ]`,
+			isError:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := fmt.Sprintf(`{ "expression" : "$A", "reducer": "%s"%s }`, test.reducer, test.reducerParams)
+			var qmap = make(map[string]interface{})
+			require.NoError(t, json.Unmarshal([]byte(q), &qmap))
+
+			cmd, err := UnmarshalReduceCommand(&rawNode{
+				RefID:      "A",
+				Query:      qmap,
+				QueryType:  "",
+				TimeRange:  TimeRange{},
+				DataSource: nil,
+			})
+
+			if test.isError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NotNil(t, cmd)
+
+			require.Equal(t, test.expectedParams, cmd.ReducerParams)
+		})
+	}
+}
@@ -238,6 +238,114 @@ func union(aResults, bResults Results) []*Union {
 	return unions
 }
 
+// matchUnion creates Union objects like union, but matches the Series or Numbers
+// of each side using the explicit vector matching of the binary operation
+// (e.g. A / on(host) group_left B). Items that do not match are dropped.
+// An error is returned when a match is ambiguous for the requested cardinality.
+func matchUnion(aResults, bResults Results, m *parse.VectorMatching) ([]*Union, error) {
+	unions := []*Union{}
+	if len(aResults.Values) == 0 || len(bResults.Values) == 0 {
+		return unions, nil
+	}
+
+	// For group_right the right side is the "many" side, so swap the sides
+	// to only deal with many-to-one below.
+	many, one := aResults, bResults
+	if m.Card == parse.CardOneToMany {
+		many, one = bResults, aResults
+	}
+
+	oneBySig := make(map[string]Value, len(one.Values))
+	for _, v := range one.Values {
+		sig := matchSignature(v.GetLabels(), m)
+		if _, ok := oneBySig[sig]; ok {
+			side := "right"
+			if m.Card == parse.CardOneToMany {
+				side = "left"
+			}
+			if m.Card == parse.CardOneToOne {
+				return nil, fmt.Errorf("found duplicate items for the match group %s on the %s side of the operation, use group_left or group_right for many-to-one matching", sig, side)
+			}
+			return nil, fmt.Errorf("found duplicate items for the match group %s on the %s side of the operation, the matching labels must identify a single item on the %s side", sig, side, side)
+		}
+		oneBySig[sig] = v
+	}
+
+	matchedSigs := make(map[string]struct{}, len(many.Values))
+	resultLabels := make(map[string]struct{}, len(many.Values))
+	for _, v := range many.Values {
+		sig := matchSignature(v.GetLabels(), m)
+		o, ok := oneBySig[sig]
+		if !ok {
+			continue
+		}
+		if m.Card == parse.CardOneToOne {
+			if _, dup := matchedSigs[sig]; dup {
+				return nil, fmt.Errorf("found duplicate items for the match group %s on the left side of the operation, use group_left or group_right for many-to-one matching", sig)
+			}
+			matchedSigs[sig] = struct{}{}
+		}
+		labels := matchResultLabels(v.GetLabels(), o.GetLabels(), m)
+		if _, dup := resultLabels[labels.String()]; dup {
+			return nil, fmt.Errorf("multiple matches for the labels %s, the matching labels must result in unique label sets", labels)
+		}
+		resultLabels[labels.String()] = struct{}{}
+
+		u := &Union{Labels: labels, A: v, B: o}
+		if m.Card == parse.CardOneToMany {
+			u.A, u.B = o, v
+		}
+		unions = append(unions, u)
+	}
+	return unions, nil
+}
+
+// matchSignature returns a string that identifies the labels used for vector matching.
+func matchSignature(labels data.Labels, m *parse.VectorMatching) string {
+	return matchLabels(labels, m).String()
+}
+
+// matchLabels returns the subset of labels used for vector matching.
+func matchLabels(labels data.Labels, m *parse.VectorMatching) data.Labels {
+	matching := data.Labels{}
+	if m.On {
+		for _, name := range m.MatchingLabels {
+			if v, ok := labels[name]; ok {
+				matching[name] = v
+			}
+		}
+		return matching
+	}
+	for name, v := range labels {
+		matching[name] = v
+	}
+	for _, name := range m.MatchingLabels {
+		delete(matching, name)
+	}
+	return matching
+}
+
+// matchResultLabels returns the labels of the result of a vector matched binary operation.
+// For one-to-one matching these are the matching labels. For many-to-one and one-to-many
+// matching these are the labels of the "many" side plus the included labels of the "one" side.
+func matchResultLabels(manyLabels, oneLabels data.Labels, m *parse.VectorMatching) data.Labels {
+	if m.Card == parse.CardOneToOne {
+		return matchLabels(manyLabels, m)
+	}
+	labels := manyLabels.Copy()
+	if labels == nil {
+		labels = data.Labels{}
+	}
+	for _, name := range m.Include {
+		if v, ok := oneLabels[name]; ok {
+			labels[name] = v
+		} else {
+			delete(labels, name)
+		}
+	}
+	return labels
+}
+
 func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
 	res := Results{Values{}}
 	ar, err := e.walk(node.Args[0])
@@ -248,7 +356,15 @@ func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
 	if err != nil {
 		return res, err
 	}
-	unions := union(ar, br)
+	var unions []*Union
+	if node.Matching != nil {
+		unions, err = matchUnion(ar, br, node.Matching)
+		if err != nil {
+			return res, err
+		}
+	} else {
+		unions = union(ar, br)
+	}
 	for _, uni := range unions {
 		var value Value
 		switch at := uni.A.(type) {
@@ -1,9 +1,14 @@
 package mathexp
 
 import (
+	"fmt"
 	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 
 	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 var builtins = map[string]parse.Func{
@@ -68,6 +73,54 @@ var builtins = map[string]parse.Func{
 		VariantReturn: true,
 		F:             floor,
 	},
+	"clamp_min": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet, parse.TypeScalar},
+		VariantReturn: true,
+		F:             clampMin,
+	},
+	"clamp_max": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet, parse.TypeScalar},
+		VariantReturn: true,
+		F:             clampMax,
+	},
+	"rate": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      rate,
+	},
+	"increase": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      increase,
+	},
+	"delta": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      delta,
+	},
+	"cumulative_sum": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      cumulativeSum,
+	},
+	"moving_avg": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeString},
+		Return: parse.TypeSeriesSet,
+		F:      movingAvg,
+		Check:  checkWindowArg(1),
+	},
+	"moving_sum": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeString},
+		Return: parse.TypeSeriesSet,
+		F:      movingSum,
+		Check:  checkWindowArg(1),
+	},
+	"percentile_over_time": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeScalar, parse.TypeString},
+		Return: parse.TypeSeriesSet,
+		F:      percentileOverTime,
+		Check:  checkWindowArg(2),
+	},
 }
 
 // abs returns the absolute value for each result in NumberSet, SeriesSet, or Scalar
@@ -303,3 +356,264 @@ func floor(e *State, varSet Results) (Results, error) {
 	}
 	return newRes, nil
 }
+
+// clampMin returns the value for each result in NumberSet, SeriesSet, or Scalar, or min if the value is lower than min.
+func clampMin(e *State, varSet Results, minSet Results) (Results, error) {
+	minVal, err := scalarArg("clamp_min", minSet)
+	if err != nil {
+		return Results{}, err
+	}
+	newRes := Results{}
+	for _, res := range varSet.Values {
+		newVal, err := perFloat(e, res, func(f float64) float64 {
+			if math.IsNaN(f) {
+				return f
+			}
+			return math.Max(f, minVal)
+		})
+		if err != nil {
+			return newRes, err
+		}
+		newRes.Values = append(newRes.Values, newVal)
+	}
+	return newRes, nil
+}
+
+// clampMax returns the value for each result in NumberSet, SeriesSet, or Scalar, or max if the value is greater than max.
+func clampMax(e *State, varSet Results, maxSet Results) (Results, error) {
+	maxVal, err := scalarArg("clamp_max", maxSet)
+	if err != nil {
+		return Results{}, err
+	}
+	newRes := Results{}
+	for _, res := range varSet.Values {
+		newVal, err := perFloat(e, res, func(f float64) float64 {
+			if math.IsNaN(f) {
+				return f
+			}
+			return math.Min(f, maxVal)
+		})
+		if err != nil {
+			return newRes, err
+		}
+		newRes.Values = append(newRes.Values, newVal)
+	}
+	return newRes, nil
+}
+
+// rate returns the per-second rate of increase between consecutive points of each series in SeriesSet.
+// A decrease in value is treated as a counter reset. The first point of each series is dropped.
+func rate(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "rate", varSet, func(s Series) (Series, error) {
+		return counterDiff(e, s, true), nil
+	})
+}
+
+// increase returns the increase between consecutive points of each series in SeriesSet.
+// A decrease in value is treated as a counter reset. The first point of each series is dropped.
+func increase(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "increase", varSet, func(s Series) (Series, error) {
+		return counterDiff(e, s, false), nil
+	})
+}
+
+// delta returns the difference between consecutive points of each series in SeriesSet.
+// Unlike increase, negative differences are kept. The first point of each series is dropped.
+func delta(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "delta", varSet, func(s Series) (Series, error) {
+		newSeries := NewSeries(e.RefID, s.GetLabels(), 0)
+		for i := 1; i < s.Len(); i++ {
+			t, cur := s.GetPoint(i)
+			prev := s.GetValue(i - 1)
+			if cur == nil || prev == nil {
+				newSeries.AppendPoint(t, nil)
+				continue
+			}
+			d := *cur - *prev
+			newSeries.AppendPoint(t, &d)
+		}
+		return newSeries, nil
+	})
+}
+
+// cumulativeSum returns the running total of each series in SeriesSet.
+// Null points stay null and do not contribute to the total.
+func cumulativeSum(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "cumulative_sum", varSet, func(s Series) (Series, error) {
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		var sum float64
+		for i := 0; i < s.Len(); i++ {
+			t, f := s.GetPoint(i)
+			if f == nil {
+				newSeries.SetPoint(i, t, nil)
+				continue
+			}
+			sum += *f
+			nF := sum
+			newSeries.SetPoint(i, t, &nF)
+		}
+		return newSeries, nil
+	})
+}
+
+// movingAvg returns, for each point of each series in SeriesSet, the mean of the
+// non-null points within the trailing window ending at that point.
+func movingAvg(e *State, varSet Results, window string) (Results, error) {
+	return perWindow(e, "moving_avg", varSet, window, func(vals []float64) float64 {
+		var sum float64
+		for _, v := range vals {
+			sum += v
+		}
+		return sum / float64(len(vals))
+	})
+}
+
+// movingSum returns, for each point of each series in SeriesSet, the sum of the
+// non-null points within the trailing window ending at that point.
+func movingSum(e *State, varSet Results, window string) (Results, error) {
+	return perWindow(e, "moving_sum", varSet, window, func(vals []float64) float64 {
+		var sum float64
+		for _, v := range vals {
+			sum += v
+		}
+		return sum
+	})
+}
+
+// percentileOverTime returns, for each point of each series in SeriesSet, the percentile (0-100)
+// of the non-null points within the trailing window ending at that point.
+func percentileOverTime(e *State, varSet Results, pSet Results, window string) (Results, error) {
+	p, err := scalarArg("percentile_over_time", pSet)
+	if err != nil {
+		return Results{}, err
+	}
+	if p < 0 || p > 100 {
+		return Results{}, fmt.Errorf("percentile_over_time: percentile must be between 0 and 100, got %v", p)
+	}
+	return perWindow(e, "percentile_over_time", varSet, window, func(vals []float64) float64 {
+		return util.Percentile(vals, p)
+	})
+}
+
+// counterDiff calculates the increase between consecutive points of the series, treating
+// any decrease as a counter reset. If perSecond is true the increase is divided by the
+// number of seconds between the points.
+func counterDiff(e *State, s Series, perSecond bool) Series {
+	newSeries := NewSeries(e.RefID, s.GetLabels(), 0)
+	for i := 1; i < s.Len(); i++ {
+		t, cur := s.GetPoint(i)
+		prevT, prev := s.GetPoint(i - 1)
+		if cur == nil || prev == nil {
+			newSeries.AppendPoint(t, nil)
+			continue
+		}
+		d := *cur - *prev
+		if d < 0 {
+			d = *cur
+		}
+		if perSecond {
+			seconds := t.Sub(prevT).Seconds()
+			if seconds <= 0 {
+				newSeries.AppendPoint(t, nil)
+				continue
+			}
+			d /= seconds
+		}
+		newSeries.AppendPoint(t, &d)
+	}
+	return newSeries
+}
+
+// perSeries calls seriesF for each Series in varSet and returns the collected results.
+// The series are expected to be sorted by time in ascending order.
+func perSeries(e *State, name string, varSet Results, seriesF func(s Series) (Series, error)) (Results, error) {
+	newRes := Results{}
+	for _, res := range varSet.Values {
+		s, ok := res.(Series)
+		if !ok {
+			return newRes, fmt.Errorf("%s: expected %v, got %v", name, parse.TypeSeriesSet, res.Type())
+		}
+		newSeries, err := seriesF(s)
+		if err != nil {
+			return newRes, err
+		}
+		newRes.Values = append(newRes.Values, newSeries)
+	}
+	return newRes, nil
+}
+
+// perWindow passes the non-null, non-NaN values of the trailing window ending at each point
+// of each series in varSet to windowF. Points without any values in the window are null.
+func perWindow(e *State, name string, varSet Results, window string, windowF func(vals []float64) float64) (Results, error) {
+	w, err := parseWindow(window)
+	if err != nil {
+		return Results{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return perSeries(e, name, varSet, func(s Series) (Series, error) {
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		start := 0
+		for i := 0; i < s.Len(); i++ {
+			t := s.GetTime(i)
+			for start < i && !s.GetTime(start).After(t.Add(-w)) {
+				start++
+			}
+			vals := make([]float64, 0, i-start+1)
+			for j := start; j <= i; j++ {
+				f := s.GetValue(j)
+				if f == nil || math.IsNaN(*f) {
+					continue
+				}
+				vals = append(vals, *f)
+			}
+			if len(vals) == 0 {
+				newSeries.SetPoint(i, t, nil)
+				continue
+			}
+			nF := windowF(vals)
+			newSeries.SetPoint(i, t, &nF)
+		}
+		return newSeries, nil
+	})
+}
+
+// checkWindowArg returns a parse time check that the argument at idx is a valid window duration.
+func checkWindowArg(idx int) func(*parse.Tree, *parse.FuncNode) error {
+	return func(t *parse.Tree, f *parse.FuncNode) error {
+		s, ok := f.Args[idx].(*parse.StringNode)
+		if !ok {
+			return nil
+		}
+		if _, err := parseWindow(s.Text); err != nil {
+			return fmt.Errorf("parse: %s: %w", f.Name, err)
+		}
+		return nil
+	}
+}
+
+// parseWindow parses a window duration such as "5m" and ensures it is positive.
+func parseWindow(window string) (time.Duration, error) {
+	w, err := gtime.ParseDuration(window)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse window duration %q: %w", window, err)
+	}
+	if w <= 0 {
+		return 0, fmt.Errorf("window duration must be greater than zero, got %q", window)
+	}
+	return w, nil
+}
+
+// scalarArg returns the float value of a scalar function argument.
+func scalarArg(name string, res Results) (float64, error) {
+	if len(res.Values) != 1 {
+		return 0, fmt.Errorf("%s: expected a single scalar argument, got %v values", name, len(res.Values))
+	}
+	s, ok := res.Values[0].(Scalar)
+	if !ok {
+		return 0, fmt.Errorf("%s: expected %v argument, got %v", name, parse.TypeScalar, res.Values[0].Type())
+	}
+	f := s.GetFloat64Value()
+	if f == nil {
+		return 0, fmt.Errorf("%s: scalar argument must not be null", name)
+	}
+	return *f, nil
+}
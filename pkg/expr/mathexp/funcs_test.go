@@ -159,3 +159,251 @@ func TestIsNumberFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestSeriesFuncs(t *testing.T) {
+	var tests = []struct {
+		name      string
+		expr      string
+		vars      Vars
+		newErrIs  require.ErrorAssertionFunc
+		execErrIs require.ErrorAssertionFunc
+		results   Results
+	}{
+		{
+			name: "rate handles counter reset",
+			expr: "rate($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil,
+							tp{time.Unix(0, 0), float64Pointer(10)},
+							tp{time.Unix(10, 0), float64Pointer(30)},
+							tp{time.Unix(20, 0), float64Pointer(5)},
+							tp{time.Unix(30, 0), nil}),
+					},
+				},
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: Results{
+				[]Value{
+					makeSeries("", nil,
+						tp{time.Unix(10, 0), float64Pointer(2)},
+						tp{time.Unix(20, 0), float64Pointer(0.5)},
+						tp{time.Unix(30, 0), nil}),
+				},
+			},
+		},
+		{
+			name: "increase handles counter reset",
+			expr: "increase($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil,
+							tp{time.Unix(0, 0), float64Pointer(10)},
+							tp{time.Unix(10, 0), float64Pointer(30)},
+							tp{time.Unix(20, 0), float64Pointer(5)}),
+					},
+				},
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: Results{
+				[]Value{
+					makeSeries("", nil,
+						tp{time.Unix(10, 0), float64Pointer(20)},
+						tp{time.Unix(20, 0), float64Pointer(5)}),
+				},
+			},
+		},
+		{
+			name: "delta keeps negative differences",
+			expr: "delta($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil,
+							tp{time.Unix(0, 0), float64Pointer(10)},
+							tp{time.Unix(10, 0), float64Pointer(30)},
+							tp{time.Unix(20, 0), float64Pointer(5)}),
+					},
+				},
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: Results{
+				[]Value{
+					makeSeries("", nil,
+						tp{time.Unix(10, 0), float64Pointer(20)},
+						tp{time.Unix(20, 0), float64Pointer(-25)}),
+				},
+			},
+		},
+		{
+			name: "cumulative_sum skips nulls",
+			expr: "cumulative_sum($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil,
+							tp{time.Unix(0, 0), float64Pointer(1)},
+							tp{time.Unix(10, 0), nil},
+							tp{time.Unix(20, 0), float64Pointer(2)}),
+					},
+				},
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: Results{
+				[]Value{
+					makeSeries("", nil,
+						tp{time.Unix(0, 0), float64Pointer(1)},
+						tp{time.Unix(10, 0), nil},
+						tp{time.Unix(20, 0), float64Pointer(3)}),
+				},
+			},
+		},
+		{
+			name: "moving_avg over trailing window",
+			expr: `moving_avg($A, "20s")`,
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil,
+							tp{time.Unix(0, 0), float64Pointer(2)},
+							tp{time.Unix(10, 0), float64Pointer(4)},
+							tp{time.Unix(20, 0), float64Pointer(6)},
+							tp{time.Unix(30, 0), nil}),
+					},
+				},
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: Results{
+				[]Value{
+					makeSeries("", nil,
+						tp{time.Unix(0, 0), float64Pointer(2)},
+						tp{time.Unix(10, 0), float64Pointer(3)},
+						tp{time.Unix(20, 0), float64Pointer(5)},
+						tp{time.Unix(30, 0), float64Pointer(6)}),
+				},
+			},
+		},
+		{
+			name: "moving_sum over trailing window",
+			expr: `moving_sum($A, "20s")`,
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil,
+							tp{time.Unix(0, 0), float64Pointer(2)},
+							tp{time.Unix(10, 0), float64Pointer(4)},
+							tp{time.Unix(20, 0), float64Pointer(6)}),
+					},
+				},
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: Results{
+				[]Value{
+					makeSeries("", nil,
+						tp{time.Unix(0, 0), float64Pointer(2)},
+						tp{time.Unix(10, 0), float64Pointer(6)},
+						tp{time.Unix(20, 0), float64Pointer(10)}),
+				},
+			},
+		},
+		{
+			name: "percentile_over_time over trailing window",
+			expr: `percentile_over_time($A, 50, "1m")`,
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil,
+							tp{time.Unix(0, 0), float64Pointer(4)},
+							tp{time.Unix(10, 0), float64Pointer(2)},
+							tp{time.Unix(20, 0), float64Pointer(9)}),
+					},
+				},
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: Results{
+				[]Value{
+					makeSeries("", nil,
+						tp{time.Unix(0, 0), float64Pointer(4)},
+						tp{time.Unix(10, 0), float64Pointer(3)},
+						tp{time.Unix(20, 0), float64Pointer(4)}),
+				},
+			},
+		},
+		{
+			name: "clamp_min and clamp_max on series",
+			expr: "clamp_max(clamp_min($A, 0), 10)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil,
+							tp{time.Unix(0, 0), float64Pointer(-5)},
+							tp{time.Unix(10, 0), float64Pointer(5)},
+							tp{time.Unix(20, 0), float64Pointer(15)}),
+					},
+				},
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: Results{
+				[]Value{
+					makeSeries("", nil,
+						tp{time.Unix(0, 0), float64Pointer(0)},
+						tp{time.Unix(10, 0), float64Pointer(5)},
+						tp{time.Unix(20, 0), float64Pointer(10)}),
+				},
+			},
+		},
+		{
+			name: "clamp_min on number",
+			expr: "clamp_min($A, 0)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeNumber("", nil, float64Pointer(-3)),
+					},
+				},
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results:   Results{[]Value{makeNumber("", nil, float64Pointer(0))}},
+		},
+		{
+			name:     "moving_avg with invalid window - should error",
+			expr:     `moving_avg($A, "abc")`,
+			vars:     Vars{},
+			newErrIs: require.Error,
+		},
+		{
+			name:     "moving_avg without window - should error",
+			expr:     `moving_avg($A)`,
+			vars:     Vars{},
+			newErrIs: require.Error,
+		},
+		{
+			name:     "rate on scalar - should error",
+			expr:     `rate(1)`,
+			vars:     Vars{},
+			newErrIs: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.expr)
+			tt.newErrIs(t, err)
+			if e != nil {
+				res, err := e.Execute("", tt.vars)
+				tt.execErrIs(t, err)
+				require.Equal(t, tt.results, res)
+			}
+		})
+	}
+}
@@ -56,10 +56,22 @@ const (
 	itemRightParen
 	itemString
 	itemFunc
-	itemVar // e.g. $A
-	itemPow // '**'
+	itemVar        // e.g. $A
+	itemPow        // '**'
+	itemOn         // 'on'
+	itemIgnoring   // 'ignoring'
+	itemGroupLeft  // 'group_left'
+	itemGroupRight // 'group_right'
 )
 
+// keywords are the identifiers that are lexed as keywords instead of function names.
+var keywords = map[string]itemType{
+	"on":          itemOn,
+	"ignoring":    itemIgnoring,
+	"group_left":  itemGroupLeft,
+	"group_right": itemGroupRight,
+}
+
 const eof = -1
 
 // stateFn represents the state of the scanner as a function that returns the next state.
@@ -276,10 +288,14 @@ func lexSymbol(l *lexer) stateFn {
 func lexFunc(l *lexer) stateFn {
 	for {
 		switch r := l.next(); {
-		case unicode.IsLetter(r) || r == '_':
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
 			// absorb
 		default:
 			l.backup()
+			if kw, ok := keywords[l.input[l.start:l.pos]]; ok {
+				l.emit(kw)
+				return lexItem
+			}
 			l.emit(itemFunc)
 			return lexItem
 		}
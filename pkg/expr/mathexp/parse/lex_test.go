@@ -33,6 +33,12 @@ var itemName = map[itemType]string{
 	itemRightParen: ")",
 	itemString:     "string",
 	itemFunc:       "func",
+	itemVar:        "var",
+	itemPow:        "**",
+	itemOn:         "on",
+	itemIgnoring:   "ignoring",
+	itemGroupLeft:  "group_left",
+	itemGroupRight: "group_right",
 }
 
 func (i itemType) String() string {
@@ -113,6 +119,33 @@ var lexTests = []lexTest{
 		{itemVar, 0, "$A"},
 		tEOF,
 	}},
+	{"vector matching", "$A / on(host, dc) group_left(k8s_pod) $B", []item{
+		{itemVar, 0, "$A"},
+		tDiv,
+		{itemOn, 0, "on"},
+		{itemLeftParen, 0, "("},
+		{itemFunc, 0, "host"},
+		{itemComma, 0, ","},
+		{itemFunc, 0, "dc"},
+		{itemRightParen, 0, ")"},
+		{itemGroupLeft, 0, "group_left"},
+		{itemLeftParen, 0, "("},
+		{itemFunc, 0, "k8s_pod"},
+		{itemRightParen, 0, ")"},
+		{itemVar, 0, "$B"},
+		tEOF,
+	}},
+	{"vector matching ignoring group_right", "$A * ignoring(status) group_right $B", []item{
+		{itemVar, 0, "$A"},
+		tMult,
+		{itemIgnoring, 0, "ignoring"},
+		{itemLeftParen, 0, "("},
+		{itemFunc, 0, "status"},
+		{itemRightParen, 0, ")"},
+		{itemGroupRight, 0, "group_right"},
+		{itemVar, 0, "$B"},
+		tEOF,
+	}},
 	// errors
 	{"unclosed quote", "\"", []item{
 		{itemError, 0, "unterminated string"},
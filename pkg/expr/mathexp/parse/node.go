@@ -9,6 +9,7 @@ package parse
 import (
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // A Node is an element in the parse tree. The interface is trivial.
@@ -298,24 +299,43 @@ type BinaryNode struct {
 	Args     [2]Node
 	Operator item
 	OpStr    string
+	Matching *VectorMatching // nil unless the operator has an on or ignoring clause
 }
 
 func newBinary(operator item, arg1, arg2 Node) *BinaryNode {
 	return &BinaryNode{NodeType: NodeBinary, Pos: operator.pos, Args: [2]Node{arg1, arg2}, Operator: operator, OpStr: operator.val}
 }
 
+// opString returns the operator with its vector matching clause, if any.
+func (b *BinaryNode) opString() string {
+	if b.Matching == nil {
+		return b.Operator.val
+	}
+	return b.Operator.val + " " + b.Matching.String()
+}
+
 // String returns the string representation of the BinaryNode so it fulfills the Node interface.
 func (b *BinaryNode) String() string {
-	return fmt.Sprintf("%s %s %s", b.Args[0], b.Operator.val, b.Args[1])
+	return fmt.Sprintf("%s %s %s", b.Args[0], b.opString(), b.Args[1])
 }
 
 // StringAST returns the string representation of abstract syntax tree of the BinaryNode so it fulfills the Node interface.
 func (b *BinaryNode) StringAST() string {
-	return fmt.Sprintf("%s(%s, %s)", b.Operator.val, b.Args[0], b.Args[1])
+	return fmt.Sprintf("%s(%s, %s)", b.opString(), b.Args[0], b.Args[1])
 }
 
 // Check performs parse time checking on the BinaryNode so it fulfills the Node interface.
 func (b *BinaryNode) Check(t *Tree) error {
+	for _, arg := range b.Args {
+		if b.Matching != nil {
+			if rt := arg.Return(); rt != TypeNumberSet && rt != TypeSeriesSet {
+				return fmt.Errorf("parse: vector matching in %s is only allowed between %v or %v, got %v", b, TypeNumberSet, TypeSeriesSet, rt)
+			}
+		}
+		if err := arg.Check(t); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -329,6 +349,53 @@ func (b *BinaryNode) Return() ReturnType {
 	return t0
 }
 
+// VectorMatchCardinality describes the cardinality relationship
+// of two sets of results in a binary operation.
+type VectorMatchCardinality int
+
+const (
+	// CardOneToOne requires each item on either side to match at most one item on the other side.
+	CardOneToOne VectorMatchCardinality = iota
+	// CardManyToOne allows many items on the left side to match one item on the right side (group_left).
+	CardManyToOne
+	// CardOneToMany allows one item on the left side to match many items on the right side (group_right).
+	CardOneToMany
+)
+
+// VectorMatching describes how the items of the two sides of a binary operation
+// are matched by their labels, e.g. "on(host) group_left(dc)".
+type VectorMatching struct {
+	// On is true if only MatchingLabels are used for matching (on),
+	// and false if all labels but MatchingLabels are used (ignoring).
+	On             bool
+	MatchingLabels []string
+	Card           VectorMatchCardinality
+	// Include holds the labels that are copied from the "one" side
+	// to the result for CardManyToOne and CardOneToMany.
+	Include []string
+}
+
+// String returns the string representation of the VectorMatching.
+func (m *VectorMatching) String() string {
+	s := "ignoring"
+	if m.On {
+		s = "on"
+	}
+	s += "(" + strings.Join(m.MatchingLabels, ", ") + ")"
+	switch m.Card {
+	case CardManyToOne:
+		s += " group_left"
+	case CardOneToMany:
+		s += " group_right"
+	default:
+		return s
+	}
+	if len(m.Include) > 0 {
+		s += "(" + strings.Join(m.Include, ", ") + ")"
+	}
+	return s
+}
+
 // UnaryNode holds one argument and an operator.
 type UnaryNode struct {
 	NodeType
@@ -165,98 +165,173 @@ func (t *Tree) parse() {
 }
 
 /* Grammar:
-O -> A {"||" A}
-A -> C {"&&" C}
-C -> P {( "==" | "!=" | ">" | ">=" | "<" | "<=") P}
-P -> M {( "+" | "-" ) M}
-M -> E {( "*" | "/" ) F}
-E -> F {( "**" ) F}
+O -> A {"||" [match] A}
+A -> C {"&&" [match] C}
+C -> P {( "==" | "!=" | ">" | ">=" | "<" | "<=") [match] P}
+P -> M {( "+" | "-" ) [match] M}
+M -> E {( "*" | "/" ) [match] F}
+E -> F {( "**" ) [match] F}
 F -> v | "(" O ")" | "!" O | "-" O
 v -> number | func(..) | queryVar
 Func -> name "(" param {"," param} ")"
 param -> number | "string" | queryVar
+match -> ( "on" | "ignoring" ) labels [( "group_left" | "group_right" ) [labels]]
+labels -> "(" [label {"," label}] ")"
 */
 
 // expr:
 
-// O is A {"||" A} in the grammar.
+// O is A {"||" [match] A} in the grammar.
 func (t *Tree) O() Node {
 	n := t.A()
 	for {
 		switch t.peek().typ {
 		case itemOr:
-			n = newBinary(t.next(), n, t.A())
+			n = t.binary(t.next(), n, t.A)
 		default:
 			return n
 		}
 	}
 }
 
-// A is C {"&&" C} in the grammar.
+// A is C {"&&" [match] C} in the grammar.
 func (t *Tree) A() Node {
 	n := t.C()
 	for {
 		switch t.peek().typ {
 		case itemAnd:
-			n = newBinary(t.next(), n, t.C())
+			n = t.binary(t.next(), n, t.C)
 		default:
 			return n
 		}
 	}
 }
 
-// C is C -> P {( "==" | "!=" | ">" | ">=" | "<" | "<=") P} in the grammar.
+// C is C -> P {( "==" | "!=" | ">" | ">=" | "<" | "<=") [match] P} in the grammar.
 func (t *Tree) C() Node {
 	n := t.P()
 	for {
 		switch t.peek().typ {
 		case itemEq, itemNotEq, itemGreater, itemGreaterEq, itemLess, itemLessEq:
-			n = newBinary(t.next(), n, t.P())
+			n = t.binary(t.next(), n, t.P)
 		default:
 			return n
 		}
 	}
 }
 
-// P is  M {( "+" | "-" ) M} in the grammar.
+// P is  M {( "+" | "-" ) [match] M} in the grammar.
 func (t *Tree) P() Node {
 	n := t.M()
 	for {
 		switch t.peek().typ {
 		case itemPlus, itemMinus:
-			n = newBinary(t.next(), n, t.M())
+			n = t.binary(t.next(), n, t.M)
 		default:
 			return n
 		}
 	}
 }
 
-// M is E {( "*" | "/" ) F} in the grammar.
+// M is E {( "*" | "/" ) [match] F} in the grammar.
 func (t *Tree) M() Node {
 	n := t.E()
 	for {
 		switch t.peek().typ {
 		case itemMult, itemDiv, itemMod:
-			n = newBinary(t.next(), n, t.E())
+			n = t.binary(t.next(), n, t.E)
 		default:
 			return n
 		}
 	}
 }
 
-// E is F {( "**" ) F} in the grammar.
+// E is F {( "**" ) [match] F} in the grammar.
 func (t *Tree) E() Node {
 	n := t.F()
 	for {
 		switch t.peek().typ {
 		case itemPow:
-			n = newBinary(t.next(), n, t.F())
+			n = t.binary(t.next(), n, t.F)
 		default:
 			return n
 		}
 	}
 }
 
+// binary parses the optional vector matching clause that follows the operator
+// and then the right hand side of the binary expression using rhs.
+func (t *Tree) binary(operator item, lhs Node, rhs func() Node) Node {
+	matching := t.vectorMatching()
+	b := newBinary(operator, lhs, rhs())
+	b.Matching = matching
+	return b
+}
+
+// vectorMatching is match in the grammar. It returns nil if the next token
+// does not start a vector matching clause.
+func (t *Tree) vectorMatching() *VectorMatching {
+	var m *VectorMatching
+	switch t.peek().typ {
+	case itemOn:
+		t.next()
+		m = &VectorMatching{On: true, MatchingLabels: t.labels("on")}
+	case itemIgnoring:
+		t.next()
+		m = &VectorMatching{MatchingLabels: t.labels("ignoring")}
+	case itemGroupLeft, itemGroupRight:
+		t.errorf("%s must be preceded by on or ignoring", t.peek().val)
+	default:
+		return nil
+	}
+	switch t.peek().typ {
+	case itemGroupLeft:
+		m.Card = CardManyToOne
+	case itemGroupRight:
+		m.Card = CardOneToMany
+	default:
+		return m
+	}
+	token := t.next()
+	if t.peek().typ == itemLeftParen {
+		m.Include = t.labels(token.val)
+	}
+	for _, l := range m.Include {
+		for _, ml := range m.MatchingLabels {
+			if m.On && l == ml {
+				t.errorf("label %q must not occur in both on and %s clause", l, token.val)
+			}
+		}
+	}
+	return m
+}
+
+// labels is labels in the grammar.
+func (t *Tree) labels(context string) []string {
+	labels := []string{}
+	t.expect(itemLeftParen, context)
+	for {
+		switch token := t.next(); token.typ {
+		case itemFunc, itemOn, itemIgnoring, itemGroupLeft, itemGroupRight:
+			labels = append(labels, token.val)
+			switch next := t.next(); next.typ {
+			case itemComma:
+			case itemRightParen:
+				return labels
+			default:
+				t.unexpected(next, context)
+			}
+		case itemRightParen:
+			if len(labels) > 0 {
+				t.unexpected(token, context)
+			}
+			return labels
+		default:
+			t.unexpected(token, context)
+		}
+	}
+}
+
 // F is v | "(" O ")" | "!" O | "-" O in the grammar.
 func (t *Tree) F() Node {
 	switch token := t.peek(); token.typ {
@@ -329,6 +404,8 @@ func (t *Tree) Func() (f *FuncNode) {
 				t.errorf("Unquoting error: %s", err)
 			}
 			f.append(newString(token.pos, token.val, s))
+		case itemComma:
+			// continue
 		case itemRightParen:
 			return
 		}
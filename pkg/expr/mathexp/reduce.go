@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/util"
 )
 
 type ReducerFunc = func(fv *Float64Field) *float64
@@ -81,7 +83,153 @@ func Last(fv *Float64Field) *float64 {
 	return fv.GetValue(fv.Len() - 1)
 }
 
-func GetReduceFunc(rFunc string) (ReducerFunc, error) {
+func First(fv *Float64Field) *float64 {
+	var f float64
+	if fv.Len() == 0 {
+		f = math.NaN()
+		return &f
+	}
+	return fv.GetValue(0)
+}
+
+func Median(fv *Float64Field) *float64 {
+	return percentileReducer(50)(fv)
+}
+
+func Variance(fv *Float64Field) *float64 {
+	f := math.NaN()
+	vals, ok := numberValues(fv)
+	if !ok || len(vals) == 0 {
+		return &f
+	}
+	var sum float64
+	for _, v := range vals {
+		sum += v
+	}
+	mean := sum / float64(len(vals))
+	var squares float64
+	for _, v := range vals {
+		squares += (v - mean) * (v - mean)
+	}
+	f = squares / float64(len(vals))
+	return &f
+}
+
+func StdDev(fv *Float64Field) *float64 {
+	f := math.Sqrt(*Variance(fv))
+	return &f
+}
+
+func Range(fv *Float64Field) *float64 {
+	f := *Max(fv) - *Min(fv)
+	return &f
+}
+
+func Diff(fv *Float64Field) *float64 {
+	f := math.NaN()
+	first, last := First(fv), Last(fv)
+	if first == nil || last == nil {
+		return &f
+	}
+	f = *last - *first
+	return &f
+}
+
+func CountNonNull(fv *Float64Field) *float64 {
+	var f float64
+	for i := 0; i < fv.Len(); i++ {
+		v := fv.GetValue(i)
+		if v == nil || math.IsNaN(*v) {
+			continue
+		}
+		f++
+	}
+	return &f
+}
+
+// Rate returns the per-second rate of increase of a counter: the increase between the first and the
+// last point divided by the time between them. A decrease of the value is a counter reset, so the
+// value after the reset is the increase since the previous point.
+func Rate(s Series) *float64 {
+	f := math.NaN()
+	if s.Len() < 2 {
+		return &f
+	}
+	var increase float64
+	prev := s.GetValue(0)
+	for i := 0; i < s.Len(); i++ {
+		v := s.GetValue(i)
+		if v == nil || math.IsNaN(*v) || prev == nil || math.IsNaN(*prev) {
+			return &f
+		}
+		if *v < *prev {
+			increase += *v
+		} else {
+			increase += *v - *prev
+		}
+		prev = v
+	}
+	duration := s.GetTime(s.Len() - 1).Sub(s.GetTime(0)).Seconds()
+	if duration <= 0 {
+		return &f
+	}
+	f = increase / duration
+	return &f
+}
+
+// percentileReducer returns a ReducerFunc that calculates the p-th percentile (0-100) of the values.
+func percentileReducer(p float64) ReducerFunc {
+	return func(fv *Float64Field) *float64 {
+		f := math.NaN()
+		vals, ok := numberValues(fv)
+		if !ok || len(vals) == 0 {
+			return &f
+		}
+		f = util.Percentile(vals, p)
+		return &f
+	}
+}
+
+// numberValues returns the values of the field as a slice of floats.
+// ok is false if any of the values is null or NaN.
+func numberValues(fv *Float64Field) (vals []float64, ok bool) {
+	vals = make([]float64, 0, fv.Len())
+	for i := 0; i < fv.Len(); i++ {
+		v := fv.GetValue(i)
+		if v == nil || math.IsNaN(*v) {
+			return nil, false
+		}
+		vals = append(vals, *v)
+	}
+	return vals, true
+}
+
+// SeriesReducerFunc reduces a series using the time of its points as well as their values.
+type SeriesReducerFunc = func(s Series) *float64
+
+// getSeriesReduceFunc returns the SeriesReducerFunc for the reduction function rFunc,
+// ok is false if rFunc only needs the values of the series.
+func getSeriesReduceFunc(rFunc string) (f SeriesReducerFunc, ok bool) {
+	switch strings.ToLower(rFunc) {
+	case "rate":
+		return Rate, true
+	default:
+		return nil, false
+	}
+}
+
+// CheckReducer returns an error if rFunc is not a reduction function or params are not valid for it.
+func CheckReducer(rFunc string, params ...float64) error {
+	if _, ok := getSeriesReduceFunc(rFunc); ok {
+		return nil
+	}
+	_, err := GetReduceFunc(rFunc, params...)
+	return err
+}
+
+// GetReduceFunc returns the ReducerFunc for the reduction function rFunc.
+// Reducers that take parameters, such as percentile, read them from params.
+func GetReduceFunc(rFunc string, params ...float64) (ReducerFunc, error) {
 	switch strings.ToLower(rFunc) {
 	case "sum":
 		return Sum, nil
@@ -95,6 +243,36 @@ func GetReduceFunc(rFunc string) (ReducerFunc, error) {
 		return Count, nil
 	case "last":
 		return Last, nil
+	case "first":
+		return First, nil
+	case "median":
+		return Median, nil
+	case "stddev":
+		return StdDev, nil
+	case "variance":
+		return Variance, nil
+	case "range":
+		return Range, nil
+	case "diff":
+		return Diff, nil
+	case "count_non_null":
+		return CountNonNull, nil
+	case "p50":
+		return percentileReducer(50), nil
+	case "p90":
+		return percentileReducer(90), nil
+	case "p95":
+		return percentileReducer(95), nil
+	case "p99":
+		return percentileReducer(99), nil
+	case "percentile":
+		if len(params) != 1 {
+			return nil, fmt.Errorf("reduction %v requires exactly one parameter, got %v", rFunc, len(params))
+		}
+		if params[0] < 0 || params[0] > 100 {
+			return nil, fmt.Errorf("reduction %v parameter must be between 0 and 100, got %v", rFunc, params[0])
+		}
+		return percentileReducer(params[0]), nil
 	default:
 		return nil, fmt.Errorf("reduction %v not implemented", rFunc)
 	}
@@ -103,7 +281,8 @@ func GetReduceFunc(rFunc string) (ReducerFunc, error) {
 // Reduce turns the Series into a Number based on the given reduction function
 // if ReduceMapper is defined it applies it to the provided series and performs reduction of the resulting series.
 // Otherwise, the reduction operation is done against the original series.
-func (s Series) Reduce(refID, rFunc string, mapper ReduceMapper) (Number, error) {
+// params are passed to reduction functions that take parameters, such as percentile.
+func (s Series) Reduce(refID, rFunc string, mapper ReduceMapper, params ...float64) (Number, error) {
 	var l data.Labels
 	if s.GetLabels() != nil {
 		l = s.GetLabels().Copy()
@@ -114,13 +293,17 @@ func (s Series) Reduce(refID, rFunc string, mapper ReduceMapper) (Number, error)
 	if mapper != nil {
 		series = mapSeries(s, mapper)
 	}
-	fVec := series.Frame.Fields[seriesTypeValIdx]
-	floatField := Float64Field(*fVec)
-	reduceFunc, err := GetReduceFunc(rFunc)
-	if err != nil {
-		return number, err
+	if seriesReduceFunc, ok := getSeriesReduceFunc(rFunc); ok {
+		f = seriesReduceFunc(series)
+	} else {
+		fVec := series.Frame.Fields[seriesTypeValIdx]
+		floatField := Float64Field(*fVec)
+		reduceFunc, err := GetReduceFunc(rFunc, params...)
+		if err != nil {
+			return number, err
+		}
+		f = reduceFunc(&floatField)
 	}
-	f = reduceFunc(&floatField)
 	if f != nil && mapper != nil {
 		f = mapper.MapOutput(f)
 	}
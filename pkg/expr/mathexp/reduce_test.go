@@ -514,3 +514,192 @@ func TestSeriesReduceReplaceNN(t *testing.T) {
 		})
 	}
 }
+
+var seriesForStats = Vars{
+	"A": Results{
+		[]Value{
+			makeSeries("temp", nil,
+				tp{time.Unix(5, 0), float64Pointer(4)},
+				tp{time.Unix(10, 0), float64Pointer(2)},
+				tp{time.Unix(15, 0), float64Pointer(8)},
+				tp{time.Unix(20, 0), float64Pointer(6)}),
+		},
+	},
+}
+
+var seriesForStatsWithNil = Vars{
+	"A": Results{
+		[]Value{
+			makeSeries("temp", nil,
+				tp{time.Unix(5, 0), float64Pointer(4)},
+				tp{time.Unix(10, 0), nil},
+				tp{time.Unix(15, 0), float64Pointer(8)},
+				tp{time.Unix(20, 0), NaN}),
+		},
+	},
+}
+
+func TestSeriesReduceStatistics(t *testing.T) {
+	var tests = []struct {
+		name    string
+		red     string
+		params  []float64
+		mapper  ReduceMapper
+		vars    Vars
+		errIs   require.ErrorAssertionFunc
+		results Results
+	}{
+		{
+			name:    "first series",
+			red:     "first",
+			vars:    seriesForStats,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(4))}},
+		},
+		{
+			name:    "median series",
+			red:     "median",
+			vars:    seriesForStats,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(5))}},
+		},
+		{
+			name:    "variance series",
+			red:     "variance",
+			vars:    seriesForStats,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(5))}},
+		},
+		{
+			name:    "stdDev series",
+			red:     "stdDev",
+			vars:    seriesForStats,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(math.Sqrt(5)))}},
+		},
+		{
+			name:    "range series",
+			red:     "range",
+			vars:    seriesForStats,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(6))}},
+		},
+		{
+			name:    "diff series",
+			red:     "diff",
+			vars:    seriesForStats,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(2))}},
+		},
+		{
+			name:    "p90 series",
+			red:     "p90",
+			vars:    seriesForStats,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(7.4))}},
+		},
+		{
+			name:    "percentile series with parameter",
+			red:     "percentile",
+			params:  []float64{25},
+			vars:    seriesForStats,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(3.5))}},
+		},
+		{
+			name:  "percentile without parameter will error",
+			red:   "percentile",
+			vars:  seriesForStats,
+			errIs: require.Error,
+		},
+		{
+			name:   "percentile with parameter out of range will error",
+			red:    "percentile",
+			params: []float64{101},
+			vars:   seriesForStats,
+			errIs:  require.Error,
+		},
+		{
+			name:    "rate series with counter resets",
+			red:     "rate",
+			vars:    seriesForStats,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(14.0/15))}},
+		},
+		{
+			name:    "rate series with nil and NaN",
+			red:     "rate",
+			vars:    seriesForStatsWithNil,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, NaN)}},
+		},
+		{
+			name:    "dropNN: rate series with nil and NaN",
+			red:     "rate",
+			mapper:  DropNonNumber{},
+			vars:    seriesForStatsWithNil,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(0.4))}},
+		},
+		{
+			name:    "count_non_null series with nil and NaN",
+			red:     "count_non_null",
+			vars:    seriesForStatsWithNil,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(2))}},
+		},
+		{
+			name:    "median series with nil and NaN",
+			red:     "median",
+			vars:    seriesForStatsWithNil,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, NaN)}},
+		},
+		{
+			name:    "dropNN: median series with nil and NaN",
+			red:     "median",
+			mapper:  DropNonNumber{},
+			vars:    seriesForStatsWithNil,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(6))}},
+		},
+		{
+			name:    "dropNN: stdDev empty series",
+			red:     "stdDev",
+			mapper:  DropNonNumber{},
+			vars:    seriesEmpty,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, nil)}},
+		},
+		{
+			name:    "replaceNN: p50 series with nil and NaN",
+			red:     "p50",
+			mapper:  ReplaceNonNumberWithValue{Value: 0},
+			vars:    seriesForStatsWithNil,
+			errIs:   require.NoError,
+			results: Results{[]Value{makeNumber("", nil, float64Pointer(2))}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Results{}
+			seriesSet := tt.vars["A"]
+			for _, series := range seriesSet.Values {
+				ns, err := series.Value().(*Series).Reduce("", tt.red, tt.mapper, tt.params...)
+				tt.errIs(t, err)
+				if err != nil {
+					return
+				}
+				results.Values = append(results.Values, ns)
+			}
+			opt := cmp.Comparer(func(x, y float64) bool {
+				return (math.IsNaN(x) && math.IsNaN(y)) || math.Abs(x-y) < 1e-9
+			})
+			options := append([]cmp.Option{opt}, data.FrameTestCompareOptions()...)
+			if diff := cmp.Diff(tt.results, results, options...); diff != "" {
+				t.Errorf("Result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
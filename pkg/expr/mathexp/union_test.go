@@ -5,6 +5,8 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
 )
 
 func Test_union(t *testing.T) {
@@ -242,3 +244,228 @@ func Test_union(t *testing.T) {
 		})
 	}
 }
+
+func Test_matchUnion(t *testing.T) {
+	var tests = []struct {
+		name     string
+		aResults Results
+		bResults Results
+		matching *parse.VectorMatching
+		errIs    assert.ErrorAssertionFunc
+		unions   []*Union
+	}{
+		{
+			name: "on with one-to-one matching keeps only the matching labels",
+			aResults: Results{
+				Values: Values{
+					makeSeries("a", data.Labels{"host": "a", "job": "x"}),
+					makeSeries("a", data.Labels{"host": "b", "job": "x"}),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeSeries("b", data.Labels{"host": "a", "env": "prod"}),
+				},
+			},
+			matching: &parse.VectorMatching{On: true, MatchingLabels: []string{"host"}},
+			errIs:    assert.NoError,
+			unions: []*Union{
+				{
+					Labels: data.Labels{"host": "a"},
+					A:      makeSeries("a", data.Labels{"host": "a", "job": "x"}),
+					B:      makeSeries("b", data.Labels{"host": "a", "env": "prod"}),
+				},
+			},
+		},
+		{
+			name: "ignoring with group_left keeps the labels of the left side",
+			aResults: Results{
+				Values: Values{
+					makeNumber("errors", data.Labels{"host": "a", "status": "500"}, nil),
+					makeNumber("errors", data.Labels{"host": "a", "status": "502"}, nil),
+					makeNumber("errors", data.Labels{"host": "b", "status": "500"}, nil),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeNumber("requests", data.Labels{"host": "a"}, nil),
+				},
+			},
+			matching: &parse.VectorMatching{MatchingLabels: []string{"status"}, Card: parse.CardManyToOne},
+			errIs:    assert.NoError,
+			unions: []*Union{
+				{
+					Labels: data.Labels{"host": "a", "status": "500"},
+					A:      makeNumber("errors", data.Labels{"host": "a", "status": "500"}, nil),
+					B:      makeNumber("requests", data.Labels{"host": "a"}, nil),
+				},
+				{
+					Labels: data.Labels{"host": "a", "status": "502"},
+					A:      makeNumber("errors", data.Labels{"host": "a", "status": "502"}, nil),
+					B:      makeNumber("requests", data.Labels{"host": "a"}, nil),
+				},
+			},
+		},
+		{
+			name: "on with group_right includes labels from the left side",
+			aResults: Results{
+				Values: Values{
+					makeNumber("info", data.Labels{"host": "a", "version": "1.0"}, nil),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeNumber("cpu", data.Labels{"host": "a", "core": "0"}, nil),
+					makeNumber("cpu", data.Labels{"host": "a", "core": "1"}, nil),
+				},
+			},
+			matching: &parse.VectorMatching{On: true, MatchingLabels: []string{"host"}, Card: parse.CardOneToMany, Include: []string{"version"}},
+			errIs:    assert.NoError,
+			unions: []*Union{
+				{
+					Labels: data.Labels{"host": "a", "core": "0", "version": "1.0"},
+					A:      makeNumber("info", data.Labels{"host": "a", "version": "1.0"}, nil),
+					B:      makeNumber("cpu", data.Labels{"host": "a", "core": "0"}, nil),
+				},
+				{
+					Labels: data.Labels{"host": "a", "core": "1", "version": "1.0"},
+					A:      makeNumber("info", data.Labels{"host": "a", "version": "1.0"}, nil),
+					B:      makeNumber("cpu", data.Labels{"host": "a", "core": "1"}, nil),
+				},
+			},
+		},
+		{
+			name: "one-to-one matching with duplicates on the left side is an error",
+			aResults: Results{
+				Values: Values{
+					makeNumber("errors", data.Labels{"host": "a", "status": "500"}, nil),
+					makeNumber("errors", data.Labels{"host": "a", "status": "502"}, nil),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeNumber("requests", data.Labels{"host": "a"}, nil),
+				},
+			},
+			matching: &parse.VectorMatching{On: true, MatchingLabels: []string{"host"}},
+			errIs:    assert.Error,
+		},
+		{
+			name: "group_left with duplicates on the right side is an error",
+			aResults: Results{
+				Values: Values{
+					makeNumber("errors", data.Labels{"host": "a", "status": "500"}, nil),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeNumber("requests", data.Labels{"host": "a", "method": "GET"}, nil),
+					makeNumber("requests", data.Labels{"host": "a", "method": "POST"}, nil),
+				},
+			},
+			matching: &parse.VectorMatching{On: true, MatchingLabels: []string{"host"}, Card: parse.CardManyToOne},
+			errIs:    assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unions, err := matchUnion(tt.aResults, tt.bResults, tt.matching)
+			tt.errIs(t, err)
+			if err != nil {
+				return
+			}
+			assert.ElementsMatch(t, tt.unions, unions)
+		})
+	}
+}
+
+func TestVectorMatchingExpr(t *testing.T) {
+	var tests = []struct {
+		name      string
+		expr      string
+		vars      Vars
+		newErrIs  assert.ErrorAssertionFunc
+		execErrIs assert.ErrorAssertionFunc
+		results   Results
+	}{
+		{
+			name: "errors divided by requests with an extra status label",
+			expr: "$A / ignoring(status) group_left $B",
+			vars: Vars{
+				"A": Results{
+					Values: Values{
+						makeNumber("", data.Labels{"host": "a", "status": "500"}, float64Pointer(5)),
+					},
+				},
+				"B": Results{
+					Values: Values{
+						makeNumber("", data.Labels{"host": "a"}, float64Pointer(50)),
+						makeNumber("", data.Labels{"host": "b"}, float64Pointer(20)),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			results: Results{
+				Values: Values{
+					makeNumber("", data.Labels{"host": "a", "status": "500"}, float64Pointer(0.1)),
+				},
+			},
+		},
+		{
+			name: "ambiguous one-to-one match",
+			expr: "$A / on(host) $B",
+			vars: Vars{
+				"A": Results{
+					Values: Values{
+						makeNumber("", data.Labels{"host": "a", "status": "500"}, float64Pointer(5)),
+						makeNumber("", data.Labels{"host": "a", "status": "502"}, float64Pointer(5)),
+					},
+				},
+				"B": Results{
+					Values: Values{
+						makeNumber("", data.Labels{"host": "a"}, float64Pointer(50)),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.Error,
+		},
+		{
+			name:     "group_left without on or ignoring",
+			expr:     "$A / group_left $B",
+			newErrIs: assert.Error,
+		},
+		{
+			name:     "vector matching with a scalar",
+			expr:     "$A / on(host) 2",
+			newErrIs: assert.Error,
+		},
+		{
+			name:     "unterminated label list",
+			expr:     "$A / on(host $B",
+			newErrIs: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.expr)
+			tt.newErrIs(t, err)
+			if e == nil {
+				return
+			}
+			res, err := e.Execute("", tt.vars)
+			tt.execErrIs(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.results, res)
+		})
+	}
+}
+
+func TestVectorMatchingString(t *testing.T) {
+	e, err := New("$A / on(host, dc) group_left(version) $B")
+	assert.NoError(t, err)
+	assert.Equal(t, "$A / on(host, dc) group_left(version) $B", e.Root.String())
+}
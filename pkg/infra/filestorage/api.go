@@ -58,6 +58,10 @@ type UpsertFileCommand struct {
 	Contents []byte
 	// Properties of an existing file won't be modified if cmd.Properties is nil
 	Properties map[string]string
+
+	// Author and Message are kept with the revision by versioned storage
+	Author  string
+	Message string
 }
 
 func toLower(list []string) []string {
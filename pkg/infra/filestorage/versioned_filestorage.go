@@ -0,0 +1,376 @@
+package filestorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+var (
+	ErrVersionNotFound = errors.New("version not found")
+
+	// revisions are kept next to the files, hidden from the public API
+	historyFolder = "/.history"
+)
+
+const (
+	versionPropertyVersion  = "version"
+	versionPropertyAuthor   = "author"
+	versionPropertyMessage  = "message"
+	versionPropertyCreated  = "created"
+	versionPropertyMimeType = "mimetype"
+)
+
+type FileVersion struct {
+	Path     string    `json:"path"`
+	Version  int64     `json:"version"`
+	Author   string    `json:"author,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Created  time.Time `json:"created"`
+	Size     int64     `json:"size"`
+	MimeType string    `json:"mimeType"`
+}
+
+type FileDiff struct {
+	Path string `json:"path"`
+	From int64  `json:"from"`
+	To   int64  `json:"to"`
+	// Diff is a unified diff of the contents
+	Diff string `json:"diff"`
+}
+
+// RetentionPolicy limits the revisions kept for each file, the latest revision is always kept
+type RetentionPolicy struct {
+	// MaxVersions of 0 keeps all revisions
+	MaxVersions int
+	// MaxAge of 0 keeps revisions forever
+	MaxAge time.Duration
+}
+
+// VersionedFileStorage keeps a revision for every change of the contents of a file
+type VersionedFileStorage interface {
+	FileStorage
+
+	// ListVersions returns the revisions of a file, newest first
+	ListVersions(ctx context.Context, path string) ([]*FileVersion, error)
+	GetVersion(ctx context.Context, path string, version int64) (*File, error)
+	DiffVersions(ctx context.Context, path string, from int64, to int64) (*FileDiff, error)
+
+	// RestoreVersion writes the contents of the revision as a new revision
+	RestoreVersion(ctx context.Context, path string, version int64, author string, message string) error
+}
+
+type versionedStorage struct {
+	FileStorage
+	log       log.Logger
+	retention RetentionPolicy
+
+	// Serializes the numbering of revisions.
+	mu sync.Mutex
+}
+
+var (
+	_ VersionedFileStorage = (*versionedStorage)(nil)
+)
+
+// NewVersionedStorage keeps the revisions in the wrapped storage. Deleted files keep their revisions
+// until the retention policy removes them, so they can be restored.
+func NewVersionedStorage(log log.Logger, storage FileStorage, retention RetentionPolicy) VersionedFileStorage {
+	return &versionedStorage{
+		FileStorage: storage,
+		log:         log,
+		retention:   retention,
+	}
+}
+
+func isHistoryPath(path string) bool {
+	lower := strings.ToLower(path)
+	return lower == historyFolder || strings.HasPrefix(lower, historyFolder+Delimiter)
+}
+
+func historyFolderPath(path string) string {
+	return Join(historyFolder, path)
+}
+
+// versions are padded so that they are listed in order
+func historyFilePath(path string, version int64) string {
+	return Join(historyFolder, path, fmt.Sprintf("%010d", version))
+}
+
+func (s *versionedStorage) Get(ctx context.Context, path string) (*File, error) {
+	if isHistoryPath(path) {
+		return nil, nil
+	}
+	return s.FileStorage.Get(ctx, path)
+}
+
+func (s *versionedStorage) Delete(ctx context.Context, path string) error {
+	if isHistoryPath(path) {
+		return nil
+	}
+	return s.FileStorage.Delete(ctx, path)
+}
+
+func (s *versionedStorage) CreateFolder(ctx context.Context, path string) error {
+	if isHistoryPath(path) {
+		return ErrPathInvalid
+	}
+	return s.FileStorage.CreateFolder(ctx, path)
+}
+
+func (s *versionedStorage) DeleteFolder(ctx context.Context, path string) error {
+	if isHistoryPath(path) {
+		return nil
+	}
+	return s.FileStorage.DeleteFolder(ctx, path)
+}
+
+func (s *versionedStorage) List(ctx context.Context, folderPath string, paging *Paging, options *ListOptions) (*ListResponse, error) {
+	if isHistoryPath(folderPath) {
+		return &ListResponse{Files: []*File{}}, nil
+	}
+
+	resp, err := s.FileStorage.List(ctx, folderPath, paging, options)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	files := make([]*File, 0, len(resp.Files))
+	for _, f := range resp.Files {
+		if !isHistoryPath(f.FullPath) {
+			files = append(files, f)
+		}
+	}
+	resp.Files = files
+	return resp, nil
+}
+
+func (s *versionedStorage) Upsert(ctx context.Context, cmd *UpsertFileCommand) error {
+	if isHistoryPath(cmd.Path) {
+		return ErrPathInvalid
+	}
+	if cmd.Contents == nil {
+		// only the properties change
+		return s.FileStorage.Upsert(ctx, cmd)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions, err := s.ListVersions(ctx, cmd.Path)
+	if err != nil {
+		return err
+	}
+
+	var last int64
+	if len(versions) > 0 {
+		last = versions[0].Version
+	} else {
+		// The file was written before versioning was enabled.
+		existing, err := s.FileStorage.Get(ctx, cmd.Path)
+		if err != nil {
+			return err
+		}
+		if existing != nil && !existing.IsFolder() {
+			last = 1
+			if err := s.saveVersion(ctx, cmd.Path, last, existing.Contents, existing.MimeType, "", "", existing.Modified); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.FileStorage.Upsert(ctx, cmd); err != nil {
+		return err
+	}
+	if err := s.saveVersion(ctx, cmd.Path, last+1, cmd.Contents, cmd.MimeType, cmd.Author, cmd.Message, time.Now()); err != nil {
+		return err
+	}
+	return s.applyRetention(ctx, cmd.Path)
+}
+
+func (s *versionedStorage) saveVersion(ctx context.Context, path string, version int64, contents []byte, mimeType string, author string, message string, created time.Time) error {
+	return s.FileStorage.Upsert(ctx, &UpsertFileCommand{
+		Path:     historyFilePath(path, version),
+		MimeType: mimeType,
+		Contents: contents,
+		Properties: map[string]string{
+			versionPropertyVersion:  strconv.FormatInt(version, 10),
+			versionPropertyAuthor:   author,
+			versionPropertyMessage:  message,
+			versionPropertyCreated:  created.UTC().Format(time.RFC3339Nano),
+			versionPropertyMimeType: mimeType,
+		},
+	})
+}
+
+// applyRetention deletes the revisions that are not kept by the policy
+func (s *versionedStorage) applyRetention(ctx context.Context, path string) error {
+	if s.retention.MaxVersions <= 0 && s.retention.MaxAge <= 0 {
+		return nil
+	}
+
+	versions, err := s.ListVersions(ctx, path)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i, v := range versions {
+		if i == 0 {
+			continue
+		}
+		tooMany := s.retention.MaxVersions > 0 && i >= s.retention.MaxVersions
+		tooOld := s.retention.MaxAge > 0 && now.Sub(v.Created) > s.retention.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := s.FileStorage.Delete(ctx, historyFilePath(path, v.Version)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *versionedStorage) ListVersions(ctx context.Context, path string) ([]*FileVersion, error) {
+	if isHistoryPath(path) {
+		return nil, ErrPathInvalid
+	}
+
+	versions := make([]*FileVersion, 0)
+	paging := &Paging{First: 100}
+	for {
+		resp, err := s.FileStorage.List(ctx, historyFolderPath(path), paging, &ListOptions{WithFiles: true})
+		if err != nil {
+			return nil, err
+		}
+		if resp == nil {
+			break
+		}
+		for _, f := range resp.Files {
+			if v := toFileVersion(path, f); v != nil {
+				versions = append(versions, v)
+			}
+		}
+		if !resp.HasMore || resp.LastPath == "" {
+			break
+		}
+		paging = &Paging{First: 100, After: resp.LastPath}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version > versions[j].Version
+	})
+	return versions, nil
+}
+
+// toFileVersion returns nil for files in the history folder which are not revisions
+func toFileVersion(path string, f *File) *FileVersion {
+	if f.IsFolder() || f.Properties == nil {
+		return nil
+	}
+	version, err := strconv.ParseInt(f.Properties[versionPropertyVersion], 10, 64)
+	if err != nil {
+		return nil
+	}
+	created, err := time.Parse(time.RFC3339Nano, f.Properties[versionPropertyCreated])
+	if err != nil {
+		created = f.Created
+	}
+	mimeType := f.Properties[versionPropertyMimeType]
+	if mimeType == "" {
+		mimeType = f.MimeType
+	}
+	return &FileVersion{
+		Path:     path,
+		Version:  version,
+		Author:   f.Properties[versionPropertyAuthor],
+		Message:  f.Properties[versionPropertyMessage],
+		Created:  created,
+		Size:     f.Size,
+		MimeType: mimeType,
+	}
+}
+
+func (s *versionedStorage) GetVersion(ctx context.Context, path string, version int64) (*File, error) {
+	if isHistoryPath(path) {
+		return nil, ErrPathInvalid
+	}
+
+	f, err := s.FileStorage.Get(ctx, historyFilePath(path, version))
+	if err != nil || f == nil {
+		return nil, err
+	}
+	v := toFileVersion(path, f)
+	if v == nil {
+		return nil, nil
+	}
+	return &File{
+		Contents: f.Contents,
+		FileMetadata: FileMetadata{
+			Name:       getName(path),
+			FullPath:   path,
+			MimeType:   v.MimeType,
+			Modified:   v.Created,
+			Created:    v.Created,
+			Size:       f.Size,
+			Properties: f.Properties,
+		},
+	}, nil
+}
+
+func (s *versionedStorage) DiffVersions(ctx context.Context, path string, from int64, to int64) (*FileDiff, error) {
+	a, err := s.GetVersion(ctx, path, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.GetVersion(ctx, path, to)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil || b == nil {
+		return nil, ErrVersionNotFound
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a.Contents)),
+		B:        difflib.SplitLines(string(b.Contents)),
+		FromFile: fmt.Sprintf("%s@%d", path, from),
+		ToFile:   fmt.Sprintf("%s@%d", path, to),
+		Context:  3,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &FileDiff{
+		Path: path,
+		From: from,
+		To:   to,
+		Diff: diff,
+	}, nil
+}
+
+func (s *versionedStorage) RestoreVersion(ctx context.Context, path string, version int64, author string, message string) error {
+	f, err := s.GetVersion(ctx, path, version)
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return ErrVersionNotFound
+	}
+	if message == "" {
+		message = fmt.Sprintf("Restored version %d", version)
+	}
+	return s.Upsert(ctx, &UpsertFileCommand{
+		Path:     path,
+		MimeType: f.MimeType,
+		Contents: f.Contents,
+		Author:   author,
+		Message:  message,
+	})
+}
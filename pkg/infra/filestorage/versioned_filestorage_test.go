@@ -0,0 +1,110 @@
+package filestorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob"
+)
+
+func newTestVersionedStorage(t *testing.T, retention RetentionPolicy) (VersionedFileStorage, FileStorage) {
+	t.Helper()
+	bucket, err := blob.OpenBucket(context.Background(), "mem://")
+	require.NoError(t, err)
+	logger := log.New("testVersionedStorage")
+	storage := NewCdkBlobStorage(logger, bucket, "", nil)
+	return NewVersionedStorage(logger, storage, retention), storage
+}
+
+func TestVersionedStorage(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestVersionedStorage(t, RetentionPolicy{})
+
+	// written before versioning was enabled
+	require.NoError(t, raw.Upsert(ctx, &UpsertFileCommand{Path: "/dash/a.json", Contents: []byte("a\nb\n")}))
+
+	require.NoError(t, s.Upsert(ctx, &UpsertFileCommand{
+		Path:     "/dash/a.json",
+		Contents: []byte("a\nc\n"),
+		Author:   "admin",
+		Message:  "replace b",
+	}))
+
+	versions, err := s.ListVersions(ctx, "/dash/a.json")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.Equal(t, int64(2), versions[0].Version)
+	require.Equal(t, "admin", versions[0].Author)
+	require.Equal(t, "replace b", versions[0].Message)
+	require.Equal(t, int64(1), versions[1].Version)
+	require.Empty(t, versions[1].Author)
+
+	file, err := s.GetVersion(ctx, "/dash/a.json", 1)
+	require.NoError(t, err)
+	require.Equal(t, "a\nb\n", string(file.Contents))
+	require.Equal(t, "/dash/a.json", file.FullPath)
+
+	diff, err := s.DiffVersions(ctx, "/dash/a.json", 1, 2)
+	require.NoError(t, err)
+	require.Contains(t, diff.Diff, "-b\n+c\n")
+
+	require.NoError(t, s.RestoreVersion(ctx, "/dash/a.json", 1, "editor", ""))
+	file, err = s.Get(ctx, "/dash/a.json")
+	require.NoError(t, err)
+	require.Equal(t, "a\nb\n", string(file.Contents))
+	versions, err = s.ListVersions(ctx, "/dash/a.json")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	require.Equal(t, "Restored version 1", versions[0].Message)
+
+	err = s.RestoreVersion(ctx, "/dash/a.json", 10, "editor", "")
+	require.ErrorIs(t, err, ErrVersionNotFound)
+
+	t.Run("history is hidden", func(t *testing.T) {
+		resp, err := s.List(ctx, "/", nil, &ListOptions{Recursive: true, WithFiles: true, WithFolders: true})
+		require.NoError(t, err)
+		paths := make([]string, 0, len(resp.Files))
+		for _, f := range resp.Files {
+			require.False(t, isHistoryPath(f.FullPath), f.FullPath)
+			paths = append(paths, f.FullPath)
+		}
+		require.Contains(t, paths, "/dash/a.json")
+
+		file, err := s.Get(ctx, "/.history/dash/a.json/0000000001")
+		require.NoError(t, err)
+		require.Nil(t, file)
+		require.ErrorIs(t, s.Upsert(ctx, &UpsertFileCommand{Path: "/.history/a", Contents: []byte{}}), ErrPathInvalid)
+	})
+
+	t.Run("deleted files keep their history", func(t *testing.T) {
+		require.NoError(t, s.Delete(ctx, "/dash/a.json"))
+		versions, err := s.ListVersions(ctx, "/dash/a.json")
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+	})
+}
+
+func TestVersionedStorageRetention(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestVersionedStorage(t, RetentionPolicy{MaxVersions: 2})
+
+	for _, body := range []string{"1", "2", "3"} {
+		require.NoError(t, s.Upsert(ctx, &UpsertFileCommand{Path: "/a.txt", Contents: []byte(body)}))
+	}
+	versions, err := s.ListVersions(ctx, "/a.txt")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.Equal(t, int64(3), versions[0].Version)
+	require.Equal(t, int64(2), versions[1].Version)
+
+	s, _ = newTestVersionedStorage(t, RetentionPolicy{MaxAge: time.Nanosecond})
+	for _, body := range []string{"1", "2"} {
+		require.NoError(t, s.Upsert(ctx, &UpsertFileCommand{Path: "/a.txt", Contents: []byte(body)}))
+	}
+	versions, err = s.ListVersions(ctx, "/a.txt")
+	require.NoError(t, err)
+	require.Len(t, versions, 1, "the latest revision is always kept")
+}
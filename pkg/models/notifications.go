@@ -1,10 +1,23 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var ErrInvalidEmailCode = errors.New("invalid or expired email code")
 var ErrSmtpNotEnabled = errors.New("SMTP not configured, check your grafana.ini config file's [smtp] section")
 
+// WebhookResponseError is returned when a webhook responds with a status code other than 2xx.
+type WebhookResponseError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e WebhookResponseError) Error() string {
+	return fmt.Sprintf("Webhook response status %v", e.Status)
+}
+
 // SendEmailAttachFile is a definition of the attached files without path
 type SendEmailAttachFile struct {
 	Name    string
@@ -56,7 +56,10 @@ import (
 	"github.com/grafana/grafana/pkg/services/libraryelements"
 	"github.com/grafana/grafana/pkg/services/librarypanels"
 	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
 	"github.com/grafana/grafana/pkg/services/live/pushhttp"
+	"github.com/grafana/grafana/pkg/services/live/pushmqtt"
+	"github.com/grafana/grafana/pkg/services/live/pushstatsd"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/login/authinfoservice"
 	authinfodatabase "github.com/grafana/grafana/pkg/services/login/authinfoservice/database"
@@ -178,7 +181,10 @@ var wireBasicSet = wire.NewSet(
 	store.ProvideHTTPService,
 	export.ProvideService,
 	live.ProvideService,
+	managedstream.ProvideFrameCache,
 	pushhttp.ProvideService,
+	pushmqtt.ProvideService,
+	pushstatsd.ProvideService,
 	plugincontext.ProvideService,
 	contexthandler.ProvideService,
 	jwt.ProvideService,
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func newTestCommitHelper(t *testing.T) *commitHelper {
+	t.Helper()
+	helper, err := newCommitHelper(context.Background(), log.New("test"), t.TempDir(), fallbackAuthor)
+	require.NoError(t, err)
+	return helper
+}
+
+func TestCommitHelper(t *testing.T) {
+	t.Run("commits the files with the author and date of the change", func(t *testing.T) {
+		helper := newTestCommitHelper(t)
+		helper.users[2] = &userInfo{ID: 2, Login: "editor", Email: "editor@example.com", Name: "Editor"}
+		require.NoError(t, helper.initOrg(1))
+
+		when := time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC)
+		err := helper.add(commitOptions{
+			body: []commitBody{
+				{fpath: filepath.Join(helper.orgDir, "dashboards", "a-dash.json"), body: []byte(`{"uid":"a"}`)},
+				{fpath: filepath.Join(helper.orgDir, "dashboards", "b-dash.json"), body: []byte(`{"uid":"b"}`)},
+			},
+			when:    when,
+			userID:  2,
+			comment: "saved dashboards",
+		})
+		require.NoError(t, err)
+
+		body, err := os.ReadFile(filepath.Join(helper.repoDir, "org_1", "dashboards", "a-dash.json"))
+		require.NoError(t, err)
+		require.Equal(t, `{"uid":"a"}`, string(body))
+
+		out, err := helper.git(nil, "log", "--format=%an <%ae>|%at|%cn|%s")
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("Editor <editor@example.com>|%d|Grafana|saved dashboards", when.Unix()), strings.TrimSpace(out))
+
+		out, err = helper.git(nil, "show", "--name-only", "--format=", "HEAD")
+		require.NoError(t, err)
+		require.Equal(t, []string{"org_1/dashboards/a-dash.json", "org_1/dashboards/b-dash.json"}, strings.Fields(out))
+	})
+
+	t.Run("uses the fallback author for unknown users", func(t *testing.T) {
+		helper := newTestCommitHelper(t)
+		helper.users[3] = &userInfo{ID: 3, Login: "viewer"}
+
+		require.Equal(t, fallbackAuthor, helper.getAuthor(0))
+		require.Equal(t, fallbackAuthor, helper.getAuthor(42))
+		require.Equal(t, "viewer <viewer@localhost>", helper.getAuthor(3))
+
+		require.NoError(t, helper.add(commitOptions{}))
+		out, err := helper.git(nil, "log", "--format=%an <%ae>|%s")
+		require.NoError(t, err)
+		require.Equal(t, fallbackAuthor+"|exported from grafana", strings.TrimSpace(out))
+	})
+
+	t.Run("does not write outside of the repository", func(t *testing.T) {
+		helper := newTestCommitHelper(t)
+		outside := filepath.Join(t.TempDir(), "outside.json")
+
+		err := helper.add(commitOptions{body: []commitBody{{fpath: outside, body: []byte("{}")}}})
+		require.Error(t, err)
+		_, err = os.Stat(outside)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
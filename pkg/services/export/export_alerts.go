@@ -0,0 +1,37 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func exportAlertRules(helper *commitHelper, job *gitExportJob) error {
+	rows := make([]*ngmodels.AlertRule, 0)
+	err := job.sql.WithDbSession(helper.ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("alert_rule").Where("org_id = ?", helper.orgID).Asc("id").Find(&rows)
+	})
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	job.addCount(int64(len(rows)))
+
+	opts := commitOptions{comment: "exported alert rules"}
+	for _, rule := range rows {
+		body, err := prettyJSON(rule)
+		if err != nil {
+			return err
+		}
+		opts.body = append(opts.body, commitBody{
+			fpath: filepath.Join(helper.orgDir, "alerting", "rules", fmt.Sprintf("%s-rule.json", rule.UID)),
+			body:  body,
+		})
+		job.progress(rule.Title)
+	}
+	return helper.add(opts)
+}
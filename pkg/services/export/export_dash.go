@@ -0,0 +1,226 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+const (
+	folderFileName      = "__folder.json"
+	generalFolderName   = "General"
+	dashboardFileSuffix = "-dash.json"
+	versionBatchSize    = 100
+)
+
+type dashboardRow struct {
+	ID        int64     `xorm:"id"`
+	UID       string    `xorm:"uid"`
+	IsFolder  bool      `xorm:"is_folder"`
+	FolderID  int64     `xorm:"folder_id"`
+	Title     string    `xorm:"title"`
+	Slug      string    `xorm:"slug"`
+	Data      []byte    `xorm:"data"`
+	Updated   time.Time `xorm:"updated"`
+	UpdatedBy int64     `xorm:"updated_by"`
+}
+
+type dashboardVersionRow struct {
+	ID          int64     `xorm:"id"`
+	DashboardID int64     `xorm:"dashboard_id"`
+	Version     int       `xorm:"version"`
+	Created     time.Time `xorm:"created"`
+	CreatedBy   int64     `xorm:"created_by"`
+	Message     string    `xorm:"message"`
+	Data        []byte    `xorm:"data"`
+}
+
+// folderInfo is written to __folder.json in each folder directory
+type folderInfo struct {
+	UID   string `json:"uid"`
+	Title string `json:"title"`
+}
+
+func exportDashboards(helper *commitHelper, job *gitExportJob) error {
+	ctx := helper.ctx
+	rows, err := loadDashboards(ctx, job.sql, helper.orgID)
+	if err != nil {
+		return err
+	}
+
+	root := filepath.Join(helper.orgDir, "dashboards")
+	folderPaths := make(map[int64]string)
+	if job.cfg.Git.GeneralAtRoot {
+		folderPaths[0] = root
+	} else {
+		folderPaths[0] = filepath.Join(root, generalFolderName)
+	}
+
+	usedFolderNames := map[string]bool{generalFolderName: !job.cfg.Git.GeneralAtRoot}
+	folders := commitOptions{comment: "exported folders"}
+	for _, row := range rows {
+		if !row.IsFolder {
+			continue
+		}
+		name := models.SlugifyTitle(row.Title)
+		if name == "" || usedFolderNames[name] {
+			name = fmt.Sprintf("%s-%s", name, row.UID)
+		}
+		usedFolderNames[name] = true
+		folderPaths[row.ID] = filepath.Join(root, name)
+
+		body, err := prettyJSON(folderInfo{UID: row.UID, Title: row.Title})
+		if err != nil {
+			return err
+		}
+		folders.body = append(folders.body, commitBody{
+			fpath: filepath.Join(folderPaths[row.ID], folderFileName),
+			body:  body,
+		})
+	}
+	if len(folders.body) > 0 {
+		if err := helper.add(folders); err != nil {
+			return err
+		}
+	}
+
+	dashboards := make(map[int64]*dashboardRow)
+	dashboardPaths := make(map[int64]string)
+	usedDashboardPaths := make(map[string]bool)
+	for _, row := range rows {
+		if row.IsFolder {
+			continue
+		}
+		dir, ok := folderPaths[row.FolderID]
+		if !ok {
+			dir = folderPaths[0]
+		}
+		fpath := filepath.Join(dir, row.Slug+dashboardFileSuffix)
+		if usedDashboardPaths[fpath] {
+			fpath = filepath.Join(dir, fmt.Sprintf("%s-%s%s", row.Slug, row.UID, dashboardFileSuffix))
+		}
+		usedDashboardPaths[fpath] = true
+		dashboards[row.ID] = row
+		dashboardPaths[row.ID] = fpath
+	}
+	job.addCount(int64(len(dashboards)))
+
+	if job.cfg.Git.ExcludeHistory {
+		current := commitOptions{comment: "exported dashboards"}
+		for id, row := range dashboards {
+			current.body = append(current.body, commitBody{
+				fpath: dashboardPaths[id],
+				body:  prettyDashboardJSON(row.Data),
+			})
+			job.progress(row.Title)
+		}
+		if len(current.body) == 0 {
+			return nil
+		}
+		return helper.add(current)
+	}
+
+	// Replay every saved version in the order they were saved
+	exported := make(map[int64]bool, len(dashboards))
+	var lastID int64
+	for {
+		versions, err := loadDashboardVersions(ctx, job.sql, helper.orgID, lastID)
+		if err != nil {
+			return err
+		}
+		for _, v := range versions {
+			lastID = v.ID
+			row, ok := dashboards[v.DashboardID]
+			if !ok {
+				continue
+			}
+			comment := v.Message
+			if comment == "" {
+				comment = fmt.Sprintf("%s (version %d)", row.Title, v.Version)
+			}
+			err := helper.add(commitOptions{
+				body: []commitBody{{
+					fpath: dashboardPaths[v.DashboardID],
+					body:  prettyDashboardJSON(v.Data),
+				}},
+				when:    v.Created,
+				userID:  v.CreatedBy,
+				comment: comment,
+			})
+			if err != nil {
+				return err
+			}
+			if !exported[v.DashboardID] {
+				exported[v.DashboardID] = true
+				job.progress(row.Title)
+			} else {
+				job.setLast(row.Title)
+			}
+		}
+		if len(versions) < versionBatchSize {
+			break
+		}
+	}
+
+	// Make sure the latest state is exported, even when the history is incomplete
+	for id, row := range dashboards {
+		if exported[id] {
+			continue
+		}
+		err := helper.add(commitOptions{
+			body: []commitBody{{
+				fpath: dashboardPaths[id],
+				body:  prettyDashboardJSON(row.Data),
+			}},
+			when:    row.Updated,
+			userID:  row.UpdatedBy,
+			comment: row.Title,
+		})
+		if err != nil {
+			return err
+		}
+		job.progress(row.Title)
+	}
+	return nil
+}
+
+func loadDashboards(ctx context.Context, sql *sqlstore.SQLStore, orgID int64) ([]*dashboardRow, error) {
+	rows := make([]*dashboardRow, 0)
+	err := sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("dashboard").
+			Where("org_id = ?", orgID).
+			Cols("id", "uid", "is_folder", "folder_id", "title", "slug", "data", "updated", "updated_by").
+			Asc("id").
+			Find(&rows)
+	})
+	return rows, err
+}
+
+func loadDashboardVersions(ctx context.Context, sql *sqlstore.SQLStore, orgID int64, afterID int64) ([]*dashboardVersionRow, error) {
+	rows := make([]*dashboardVersionRow, 0, versionBatchSize)
+	err := sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := `SELECT dashboard_version.id, dashboard_version.dashboard_id, dashboard_version.version,
+			dashboard_version.created, dashboard_version.created_by, dashboard_version.message, dashboard_version.data
+			FROM dashboard_version
+			INNER JOIN dashboard ON dashboard.id = dashboard_version.dashboard_id
+			WHERE dashboard.org_id = ? AND dashboard.is_folder = ` + sql.Dialect.BooleanStr(false) + ` AND dashboard_version.id > ?
+			ORDER BY dashboard_version.id ASC ` + sql.Dialect.Limit(versionBatchSize)
+		return sess.SQL(rawSQL, orgID, afterID).Find(&rows)
+	})
+	return rows, err
+}
+
+// prettyDashboardJSON indents the stored dashboard JSON so versions are easy to diff
+func prettyDashboardJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// dataSourceExport is the exported data source, without any secrets
+type dataSourceExport struct {
+	UID             string           `json:"uid"`
+	Name            string           `json:"name"`
+	Type            string           `json:"type"`
+	Access          models.DsAccess  `json:"access"`
+	URL             string           `json:"url"`
+	User            string           `json:"user,omitempty"`
+	Database        string           `json:"database,omitempty"`
+	BasicAuth       bool             `json:"basicAuth"`
+	BasicAuthUser   string           `json:"basicAuthUser,omitempty"`
+	WithCredentials bool             `json:"withCredentials"`
+	IsDefault       bool             `json:"isDefault"`
+	JSONData        *simplejson.Json `json:"jsonData,omitempty"`
+	ReadOnly        bool             `json:"readOnly"`
+
+	// Names of the secure fields that have a value, the values are never exported
+	SecureJSONFields []string `json:"secureJsonFields,omitempty"`
+}
+
+func exportDataSources(helper *commitHelper, job *gitExportJob) error {
+	rows := make([]*models.DataSource, 0)
+	err := job.sql.WithDbSession(helper.ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("data_source").Where("org_id = ?", helper.orgID).Asc("id").Find(&rows)
+	})
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	job.addCount(int64(len(rows)))
+
+	opts := commitOptions{comment: "exported data sources"}
+	for _, ds := range rows {
+		export := dataSourceExport{
+			UID:             ds.Uid,
+			Name:            ds.Name,
+			Type:            ds.Type,
+			Access:          ds.Access,
+			URL:             ds.Url,
+			User:            ds.User,
+			Database:        ds.Database,
+			BasicAuth:       ds.BasicAuth,
+			BasicAuthUser:   ds.BasicAuthUser,
+			WithCredentials: ds.WithCredentials,
+			IsDefault:       ds.IsDefault,
+			JSONData:        ds.JsonData,
+			ReadOnly:        ds.ReadOnly,
+		}
+		for k := range ds.SecureJsonData {
+			export.SecureJSONFields = append(export.SecureJSONFields, k)
+		}
+		sort.Strings(export.SecureJSONFields)
+
+		body, err := prettyJSON(export)
+		if err != nil {
+			return err
+		}
+		opts.body = append(opts.body, commitBody{
+			fpath: filepath.Join(helper.orgDir, "datasources", fmt.Sprintf("%s-ds.json", ds.Uid)),
+			body:  body,
+		})
+		job.progress(ds.Name)
+	}
+	return helper.add(opts)
+}
@@ -0,0 +1,89 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func newTestExportJob(sqlStore *sqlstore.SQLStore) *gitExportJob {
+	return &gitExportJob{
+		logger:      log.New("test"),
+		sql:         sqlStore,
+		broadcaster: func(s ExportStatus) {},
+	}
+}
+
+func TestExportDataSources(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	err := sqlStore.AddDataSource(context.Background(), &models.AddDataSourceCommand{
+		OrgId:             1,
+		Uid:               "prom",
+		Name:              "Prometheus",
+		Type:              models.DS_PROMETHEUS,
+		Access:            models.DS_ACCESS_PROXY,
+		Url:               "http://prometheus:9090",
+		Password:          "legacy-password",
+		BasicAuth:         true,
+		BasicAuthUser:     "admin",
+		BasicAuthPassword: "legacy-basic-auth-password",
+		JsonData:          simplejson.NewFromAny(map[string]interface{}{"httpMethod": "POST"}),
+		EncryptedSecureJsonData: map[string][]byte{
+			"basicAuthPassword": []byte("encrypted-basic-auth-password"),
+			"httpHeaderValue1":  []byte("encrypted-header"),
+		},
+	})
+	require.NoError(t, err)
+	// data sources of other orgs are not exported
+	err = sqlStore.AddDataSource(context.Background(), &models.AddDataSourceCommand{
+		OrgId:  2,
+		Uid:    "loki",
+		Name:   "Loki",
+		Type:   models.DS_LOKI,
+		Access: models.DS_ACCESS_PROXY,
+	})
+	require.NoError(t, err)
+
+	helper := newTestCommitHelper(t)
+	require.NoError(t, helper.initOrg(1))
+	job := newTestExportJob(sqlStore)
+	require.NoError(t, exportDataSources(helper, job))
+
+	entries, err := os.ReadDir(filepath.Join(helper.orgDir, "datasources"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "prom-ds.json", entries[0].Name())
+
+	body, err := os.ReadFile(filepath.Join(helper.orgDir, "datasources", "prom-ds.json"))
+	require.NoError(t, err)
+	for _, secret := range []string{"legacy-password", "legacy-basic-auth-password", "encrypted-basic-auth-password", "encrypted-header", "password\":"} {
+		require.NotContains(t, string(body), secret)
+	}
+
+	exported := dataSourceExport{}
+	require.NoError(t, json.Unmarshal(body, &exported))
+	require.Equal(t, "prom", exported.UID)
+	require.Equal(t, "Prometheus", exported.Name)
+	require.Equal(t, "http://prometheus:9090", exported.URL)
+	require.Equal(t, "admin", exported.BasicAuthUser)
+	require.Equal(t, "POST", exported.JSONData.Get("httpMethod").MustString())
+	require.Equal(t, []string{"basicAuthPassword", "httpHeaderValue1"}, exported.SecureJSONFields)
+
+	status := job.getStatus()
+	require.EqualValues(t, 1, status.Count)
+	require.EqualValues(t, 1, status.Current)
+
+	out, err := helper.git(nil, "log", "--format=%s")
+	require.NoError(t, err)
+	require.Equal(t, "exported data sources", strings.TrimSpace(out))
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// libraryElementExport is the exported library element, the folder is referenced by UID
+type libraryElementExport struct {
+	UID         string          `json:"uid" xorm:"uid"`
+	FolderUID   string          `json:"folderUid" xorm:"folder_uid"`
+	Name        string          `json:"name" xorm:"name"`
+	Kind        int64           `json:"kind" xorm:"kind"`
+	Type        string          `json:"type" xorm:"type"`
+	Description string          `json:"description" xorm:"description"`
+	Model       json.RawMessage `json:"model" xorm:"model"`
+	Version     int64           `json:"version" xorm:"version"`
+}
+
+func exportLibraryPanels(helper *commitHelper, job *gitExportJob) error {
+	rows := make([]*libraryElementExport, 0)
+	err := job.sql.WithDbSession(helper.ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := `SELECT le.uid, COALESCE(d.uid, '') AS folder_uid, le.name, le.kind, le.type, le.description, le.model, le.version
+			FROM library_element AS le
+			LEFT JOIN dashboard AS d ON d.id = le.folder_id
+			WHERE le.org_id = ?
+			ORDER BY le.id ASC`
+		return sess.SQL(rawSQL, helper.orgID).Find(&rows)
+	})
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	job.addCount(int64(len(rows)))
+
+	opts := commitOptions{comment: "exported library panels"}
+	for _, row := range rows {
+		body, err := prettyJSON(row)
+		if err != nil {
+			return err
+		}
+		opts.body = append(opts.body, commitBody{
+			fpath: filepath.Join(helper.orgDir, "library-panels", fmt.Sprintf("%s-lib.json", row.UID)),
+			body:  body,
+		})
+		job.progress(row.Name)
+	}
+	return helper.add(opts)
+}
@@ -0,0 +1,73 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// playlistExport is the exported playlist with its items
+type playlistExport struct {
+	Name     string               `json:"name"`
+	Interval string               `json:"interval"`
+	Items    []playlistItemExport `json:"items"`
+}
+
+type playlistItemExport struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Order int    `json:"order"`
+	Title string `json:"title"`
+}
+
+func exportPlaylists(helper *commitHelper, job *gitExportJob) error {
+	playlists := make([]*models.Playlist, 0)
+	items := make([]*models.PlaylistItem, 0)
+	err := job.sql.WithDbSession(helper.ctx, func(sess *sqlstore.DBSession) error {
+		if err := sess.Table("playlist").Where("org_id = ?", helper.orgID).Asc("id").Find(&playlists); err != nil {
+			return err
+		}
+		return sess.Table("playlist_item").
+			Join("INNER", "playlist", "playlist.id = playlist_item.playlist_id").
+			Where("playlist.org_id = ?", helper.orgID).
+			Asc("playlist_item.order").
+			Find(&items)
+	})
+	if err != nil {
+		return err
+	}
+	if len(playlists) == 0 {
+		return nil
+	}
+	job.addCount(int64(len(playlists)))
+
+	byPlaylist := make(map[int64][]playlistItemExport)
+	for _, item := range items {
+		byPlaylist[item.PlaylistId] = append(byPlaylist[item.PlaylistId], playlistItemExport{
+			Type:  item.Type,
+			Value: item.Value,
+			Order: item.Order,
+			Title: item.Title,
+		})
+	}
+
+	opts := commitOptions{comment: "exported playlists"}
+	for _, playlist := range playlists {
+		body, err := prettyJSON(playlistExport{
+			Name:     playlist.Name,
+			Interval: playlist.Interval,
+			Items:    byPlaylist[playlist.Id],
+		})
+		if err != nil {
+			return err
+		}
+		opts.body = append(opts.body, commitBody{
+			fpath: filepath.Join(helper.orgDir, "playlists", fmt.Sprintf("%s-%d-playlist.json", models.SlugifyTitle(playlist.Name), playlist.Id)),
+			body:  body,
+		})
+		job.progress(playlist.Name)
+	}
+	return helper.add(opts)
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// preferencesExport references the home dashboard by UID so it survives an import
+type preferencesExport struct {
+	HomeDashboardUID string                      `json:"homeDashboardUid,omitempty"`
+	Timezone         string                      `json:"timezone,omitempty"`
+	WeekStart        string                      `json:"weekStart,omitempty"`
+	Theme            string                      `json:"theme,omitempty"`
+	JSONData         *models.PreferencesJsonData `json:"jsonData,omitempty"`
+}
+
+type preferencesRow struct {
+	UserID           int64                       `xorm:"user_id"`
+	TeamID           int64                       `xorm:"team_id"`
+	TeamName         string                      `xorm:"team_name"`
+	HomeDashboardUID string                      `xorm:"home_dashboard_uid"`
+	Timezone         string                      `xorm:"timezone"`
+	WeekStart        string                      `xorm:"week_start"`
+	Theme            string                      `xorm:"theme"`
+	JSONData         *models.PreferencesJsonData `xorm:"json_data"`
+}
+
+func exportPreferences(helper *commitHelper, job *gitExportJob) error {
+	rows := make([]*preferencesRow, 0)
+	err := job.sql.WithDbSession(helper.ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := `SELECT p.user_id, p.team_id, COALESCE(t.name, '') AS team_name, COALESCE(d.uid, '') AS home_dashboard_uid,
+				p.timezone, p.week_start, p.theme, p.json_data
+			FROM preferences AS p
+			LEFT JOIN team AS t ON t.id = p.team_id
+			LEFT JOIN dashboard AS d ON d.id = p.home_dashboard_id
+			WHERE p.org_id = ?
+			ORDER BY p.id ASC`
+		return sess.SQL(rawSQL, helper.orgID).Find(&rows)
+	})
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	job.addCount(int64(len(rows)))
+
+	opts := commitOptions{comment: "exported preferences"}
+	for _, row := range rows {
+		fpath := filepath.Join(helper.orgDir, "preferences", "org.json")
+		switch {
+		case row.TeamID > 0:
+			fpath = filepath.Join(helper.orgDir, "preferences", "teams", fmt.Sprintf("%s-%d.json", models.SlugifyTitle(row.TeamName), row.TeamID))
+		case row.UserID > 0:
+			login := fmt.Sprintf("user-%d", row.UserID)
+			if user, ok := helper.users[row.UserID]; ok {
+				login = user.Login
+			}
+			fpath = filepath.Join(helper.orgDir, "preferences", "users", fmt.Sprintf("%s-%d.json", models.SlugifyTitle(login), row.UserID))
+		}
+
+		body, err := prettyJSON(preferencesExport{
+			HomeDashboardUID: row.HomeDashboardUID,
+			Timezone:         row.Timezone,
+			WeekStart:        row.WeekStart,
+			Theme:            row.Theme,
+			JSONData:         row.JSONData,
+		})
+		if err != nil {
+			return err
+		}
+		opts.body = append(opts.body, commitBody{fpath: fpath, body: body})
+		job.progress(fpath)
+	}
+	return helper.add(opts)
+}
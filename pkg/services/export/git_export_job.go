@@ -0,0 +1,205 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+var _ Job = new(gitExportJob)
+
+type gitExportJob struct {
+	logger  log.Logger
+	sql     *sqlstore.SQLStore
+	rootDir string
+	user    *models.SignedInUser
+
+	statusMu      sync.Mutex
+	status        ExportStatus
+	cfg           ExportConfig
+	broadcaster   statusBroadcaster
+	lastBroadcast time.Time
+}
+
+type simpleExporter = func(helper *commitHelper, job *gitExportJob) error
+
+func startGitExportJob(cfg ExportConfig, sql *sqlstore.SQLStore, rootDir string, user *models.SignedInUser, broadcaster statusBroadcaster) (Job, error) {
+	job := &gitExportJob{
+		logger:      log.New("git_export_job"),
+		cfg:         cfg,
+		sql:         sql,
+		rootDir:     rootDir,
+		user:        user,
+		broadcaster: broadcaster,
+		status: ExportStatus{
+			Running: true,
+			Target:  "git export",
+			Started: time.Now().UnixMilli(),
+			Current: 0,
+		},
+	}
+
+	broadcaster(job.status)
+	go job.start()
+	return job, nil
+}
+
+func (e *gitExportJob) getStatus() ExportStatus {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+
+	return e.status
+}
+
+func (e *gitExportJob) getConfig() ExportConfig {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+
+	return e.cfg
+}
+
+func (e *gitExportJob) start() {
+	defer func() {
+		e.logger.Info("Finished git export job")
+
+		e.statusMu.Lock()
+		defer e.statusMu.Unlock()
+		s := e.status
+		if err := recover(); err != nil {
+			e.logger.Error("export panic", "error", err)
+			s.Status = fmt.Sprintf("ERROR: %v", err)
+		}
+		// Make sure it finishes OK
+		if s.Finished < 10 {
+			s.Finished = time.Now().UnixMilli()
+		}
+		s.Running = false
+		if s.Status == "" {
+			s.Status = "done"
+		}
+		e.status = s
+		e.broadcaster(s)
+	}()
+
+	e.logger.Info("Starting git export job", "dir", e.rootDir)
+	err := e.doExportWithHistory()
+	if err != nil {
+		e.logger.Error("git export job failed", "error", err)
+		e.statusMu.Lock()
+		e.status.Status = fmt.Sprintf("ERROR: %s", err.Error())
+		e.statusMu.Unlock()
+	}
+}
+
+func (e *gitExportJob) doExportWithHistory() error {
+	ctx := context.Background()
+
+	author := fallbackAuthor
+	if e.user != nil && e.user.Email != "" {
+		author = fmt.Sprintf("%s <%s>", e.user.Login, e.user.Email)
+	}
+	helper, err := newCommitHelper(ctx, e.logger, e.rootDir, author)
+	if err != nil {
+		return err
+	}
+
+	if err := e.loadUsers(ctx, helper); err != nil {
+		return err
+	}
+
+	orgs, err := e.getOrgs(ctx)
+	if err != nil {
+		return err
+	}
+
+	exporters := []simpleExporter{
+		exportDashboards,
+		exportDataSources,
+		exportLibraryPanels,
+		exportAlertRules,
+		exportPlaylists,
+		exportPreferences,
+	}
+
+	for _, org := range orgs {
+		if err := helper.initOrg(org.ID); err != nil {
+			return err
+		}
+		e.setLast(fmt.Sprintf("org: %s", org.Name))
+
+		for _, exporter := range exporters {
+			if err := exporter(helper, e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type orgInfo struct {
+	ID   int64  `xorm:"id"`
+	Name string `xorm:"name"`
+}
+
+func (e *gitExportJob) getOrgs(ctx context.Context) ([]orgInfo, error) {
+	rows := make([]orgInfo, 0)
+	err := e.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("org").Cols("id", "name").Asc("id").Find(&rows)
+	})
+	return rows, err
+}
+
+type userInfo struct {
+	ID    int64  `xorm:"id"`
+	Login string `xorm:"login"`
+	Email string `xorm:"email"`
+	Name  string `xorm:"name"`
+}
+
+func (e *gitExportJob) loadUsers(ctx context.Context, helper *commitHelper) error {
+	rows := make([]*userInfo, 0)
+	err := e.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("user").Cols("id", "login", "email", "name").Find(&rows)
+	})
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		helper.users[row.ID] = row
+	}
+	return nil
+}
+
+// addCount increases the number of items the job will export
+func (e *gitExportJob) addCount(count int64) {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	e.status.Count += count
+}
+
+// setLast reports progress on the current item, broadcasting at most every 100ms
+func (e *gitExportJob) setLast(last string) {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+
+	now := time.Now()
+	e.status.Last = last
+	e.status.Changed = now.UnixMilli()
+	if now.Sub(e.lastBroadcast) > 100*time.Millisecond {
+		e.lastBroadcast = now
+		e.broadcaster(e.status)
+	}
+}
+
+// progress marks one more item as exported
+func (e *gitExportJob) progress(last string) {
+	e.statusMu.Lock()
+	e.status.Current++
+	e.statusMu.Unlock()
+	e.setLast(last)
+}
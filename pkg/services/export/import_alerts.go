@@ -0,0 +1,125 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/quota"
+)
+
+func alertRuleKey(namespaceUID string, title string) string {
+	return fmt.Sprintf("%s/%s", namespaceUID, title)
+}
+
+// planAlertRules checks the rules like the alert rule store does when they are saved. The titles are checked
+// against the rules of the org as they will be when the previous rules of the plan are applied, so that the
+// plan applies without conflicts.
+func planAlertRules(ctx context.Context, job *importJob) ([]*plannedImport, error) {
+	exported := make([]*ngmodels.AlertRule, 0)
+	paths := make([]string, 0)
+	err := readJSONFiles(filepath.Join(job.srcDir, "alerting", "rules"), "-rule.json", func(fpath string) interface{} {
+		rule := &ngmodels.AlertRule{}
+		exported = append(exported, rule)
+		paths = append(paths, job.relPath(fpath))
+		return rule
+	})
+	if err != nil || len(exported) == 0 {
+		return nil, err
+	}
+	if job.ruleStore == nil {
+		plan := make([]*plannedImport, 0, len(exported))
+		for i, rule := range exported {
+			item := ImportItem{Kind: "alert-rule", UID: rule.UID, Name: rule.Title, Path: paths[i]}
+			plan = append(plan, conflict(item, "unified alerting is disabled"))
+		}
+		return plan, nil
+	}
+
+	q := ngmodels.ListAlertRulesQuery{OrgID: job.orgID}
+	if err := job.ruleStore.ListAlertRules(ctx, &q); err != nil {
+		return nil, err
+	}
+	byUID := make(map[string]*ngmodels.AlertRule, len(q.Result))
+	// folder and title -> UID of the rule that has the title once the planned rules are applied
+	titles := make(map[string]string, len(q.Result))
+	for _, rule := range q.Result {
+		byUID[rule.UID] = rule
+		titles[alertRuleKey(rule.NamespaceUID, rule.Title)] = rule.UID
+	}
+
+	plan := make([]*plannedImport, 0, len(exported))
+	planned := make(map[string]bool, len(exported))
+	var creates int64
+	for i, rule := range exported {
+		item := ImportItem{Kind: "alert-rule", UID: rule.UID, Name: rule.Title, Path: paths[i]}
+		if rule.UID == "" {
+			plan = append(plan, conflict(item, "alert rule has no UID"))
+			continue
+		}
+		if planned[rule.UID] {
+			plan = append(plan, conflict(item, "another alert rule of the export has the same UID"))
+			continue
+		}
+		if rule.NamespaceUID == "" {
+			plan = append(plan, conflict(item, "alert rule has no folder"))
+			continue
+		}
+		if problem := job.folderProblem(rule.NamespaceUID); problem != "" {
+			plan = append(plan, conflict(item, "%s", problem))
+			continue
+		}
+		r := *rule
+		r.ID = 0
+		r.OrgID = job.orgID
+		if err := job.ruleStore.ValidateAlertRule(r); err != nil {
+			plan = append(plan, conflict(item, "%s", err.Error()))
+			continue
+		}
+		key := alertRuleKey(rule.NamespaceUID, rule.Title)
+		if other, ok := titles[key]; ok && other != rule.UID {
+			plan = append(plan, conflict(item, "an alert rule with the same title exists in the folder with UID %s", other))
+			continue
+		}
+
+		cur, exists := byUID[rule.UID]
+		action := importCreate
+		if exists {
+			action = importUpdate
+		} else {
+			reached, err := job.quotaService.CheckQuotaReachedFor(ctx, "alert_rule", &quota.ScopeParameters{OrgId: job.orgID}, creates+1)
+			if err != nil {
+				return nil, err
+			}
+			if reached {
+				plan = append(plan, conflict(item, "the alert rule quota is reached"))
+				continue
+			}
+			creates++
+		}
+
+		planned[rule.UID] = true
+		if exists {
+			delete(titles, alertRuleKey(cur.NamespaceUID, cur.Title))
+		}
+		titles[key] = rule.UID
+		plan = append(plan, &plannedImport{
+			item:   item,
+			action: action,
+			apply:  job.applyAlertRule(r, cur),
+		})
+	}
+	return plan, nil
+}
+
+// applyAlertRule saves the rule with the alert rule store, cur is nil for new rules
+func (e *importJob) applyAlertRule(rule ngmodels.AlertRule, cur *ngmodels.AlertRule) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if cur == nil {
+			return e.ruleStore.InsertAlertRules(ctx, []ngmodels.AlertRule{rule})
+		}
+		return e.ruleStore.UpdateAlertRules(ctx, []store.UpdateRule{{Existing: cur, New: rule}})
+	}
+}
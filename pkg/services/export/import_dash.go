@@ -0,0 +1,209 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+type targetDashboard struct {
+	ID       int64  `xorm:"id"`
+	UID      string `xorm:"uid"`
+	IsFolder bool   `xorm:"is_folder"`
+	FolderID int64  `xorm:"folder_id"`
+	Title    string `xorm:"title"`
+}
+
+// targetDashboards indexes the dashboards and folders of the target org
+type targetDashboards struct {
+	byUID   map[string]*targetDashboard
+	byTitle map[string]*targetDashboard
+}
+
+func titleKey(folderID int64, isFolder bool, title string) string {
+	return fmt.Sprintf("%d/%t/%s", folderID, isFolder, strings.ToLower(title))
+}
+
+func planDashboards(ctx context.Context, job *importJob) ([]*plannedImport, error) {
+	root := filepath.Join(job.srcDir, "dashboards")
+
+	rows := make([]*targetDashboard, 0)
+	err := job.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("dashboard").
+			Where("org_id = ?", job.orgID).
+			Cols("id", "uid", "is_folder", "folder_id", "title").
+			Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	target := targetDashboards{
+		byUID:   make(map[string]*targetDashboard, len(rows)),
+		byTitle: make(map[string]*targetDashboard, len(rows)),
+	}
+	for _, row := range rows {
+		target.byUID[row.UID] = row
+		target.byTitle[titleKey(row.FolderID, row.IsFolder, row.Title)] = row
+		if row.IsFolder {
+			job.folderIDs[row.UID] = row.ID
+		}
+	}
+
+	if _, err := os.Stat(root); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	// Each directory is a folder when it has a folder file, otherwise it holds general dashboards
+	folders := make([]*plannedImport, 0)
+	dashes := make([]*plannedImport, 0)
+	err = filepath.WalkDir(root, func(dir string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		folderUID := ""
+		body, err := os.ReadFile(filepath.Join(dir, folderFileName))
+		switch {
+		case err == nil:
+			folder := folderInfo{}
+			if err := json.Unmarshal(body, &folder); err != nil {
+				return fmt.Errorf("invalid folder %s: %w", job.relPath(dir), err)
+			}
+			folderUID = folder.UID
+			folders = append(folders, job.planFolder(folder, job.relPath(dir), target))
+		case !errors.Is(err, fs.ErrNotExist):
+			return err
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name() < entries[j].Name()
+		})
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), dashboardFileSuffix) {
+				continue
+			}
+			p, err := job.planDashboard(filepath.Join(dir, entry.Name()), folderUID, target)
+			if err != nil {
+				return err
+			}
+			dashes = append(dashes, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(folders, dashes...), nil
+}
+
+func (e *importJob) planFolder(folder folderInfo, path string, target targetDashboards) *plannedImport {
+	item := ImportItem{Kind: "folder", UID: folder.UID, Name: folder.Title, Path: path}
+
+	p := &plannedImport{item: item, action: importCreate}
+	cur, exists := target.byUID[folder.UID]
+	switch {
+	case folder.UID == "":
+		p = conflict(item, "folder has no UID")
+	case exists && !cur.IsFolder:
+		p = conflict(item, "UID is used by dashboard %q", cur.Title)
+	case exists:
+		p.action = importUpdate
+	default:
+		if other, ok := target.byTitle[titleKey(0, true, folder.Title)]; ok {
+			p = conflict(item, "a folder with the same title exists with UID %s", other.UID)
+		}
+	}
+
+	switch p.action {
+	case importConflict:
+		e.folderConflicts[folder.UID] = true
+		return p
+	case importCreate:
+		e.newFolders[folder.UID] = true
+	}
+
+	p.apply = func(ctx context.Context) error {
+		dash := models.NewDashboardFolder(folder.Title)
+		dash.SetUid(folder.UID)
+		saved, err := e.saveDashboard(ctx, dash)
+		if err != nil {
+			return err
+		}
+		e.folderIDs[folder.UID] = saved.Id
+		return nil
+	}
+	return p
+}
+
+func (e *importJob) planDashboard(fpath string, folderUID string, target targetDashboards) (*plannedImport, error) {
+	body, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := simplejson.NewJson(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dashboard %s: %w", e.relPath(fpath), err)
+	}
+	// IDs are not portable between instances
+	data.Del("id")
+	dash := models.NewDashboardFromJson(data)
+	item := ImportItem{Kind: "dashboard", UID: dash.Uid, Name: dash.Title, Path: e.relPath(fpath)}
+
+	if dash.Uid == "" {
+		return conflict(item, "dashboard has no UID"), nil
+	}
+	if problem := e.folderProblem(folderUID); problem != "" {
+		return conflict(item, "%s", problem), nil
+	}
+
+	p := &plannedImport{item: item, action: importCreate}
+	cur, exists := target.byUID[dash.Uid]
+	switch {
+	case exists && cur.IsFolder:
+		return conflict(item, "UID is used by folder %q", cur.Title), nil
+	case exists:
+		p.action = importUpdate
+	default:
+		// New folders can not have a dashboard with the same title yet
+		if folderID, ok := e.folderIDs[folderUID]; ok {
+			if other, ok := target.byTitle[titleKey(folderID, false, dash.Title)]; ok {
+				return conflict(item, "a dashboard with the same title exists in the folder with UID %s", other.UID), nil
+			}
+		}
+	}
+
+	p.apply = func(ctx context.Context) error {
+		dash.FolderId = e.folderIDs[folderUID]
+		_, err := e.saveDashboard(ctx, dash)
+		return err
+	}
+	return p, nil
+}
+
+func (e *importJob) saveDashboard(ctx context.Context, dash *models.Dashboard) (*models.Dashboard, error) {
+	return e.dashboardService.SaveDashboard(ctx, &dashboards.SaveDashboardDTO{
+		OrgId:     e.orgID,
+		User:      e.user,
+		Message:   "imported",
+		Overwrite: true,
+		Dashboard: dash,
+	}, true)
+}
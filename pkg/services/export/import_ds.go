@@ -0,0 +1,115 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func planDataSources(ctx context.Context, job *importJob) ([]*plannedImport, error) {
+	exported := make([]*dataSourceExport, 0)
+	paths := make([]string, 0)
+	err := readJSONFiles(filepath.Join(job.srcDir, "datasources"), "-ds.json", func(fpath string) interface{} {
+		ds := &dataSourceExport{}
+		exported = append(exported, ds)
+		paths = append(paths, job.relPath(fpath))
+		return ds
+	})
+	if err != nil || len(exported) == 0 {
+		return nil, err
+	}
+
+	query := &models.GetDataSourcesQuery{OrgId: job.orgID}
+	if err := job.dataSourceService.GetDataSources(ctx, query); err != nil {
+		return nil, err
+	}
+	byUID := make(map[string]*models.DataSource, len(query.Result))
+	byName := make(map[string]*models.DataSource, len(query.Result))
+	for _, row := range query.Result {
+		byUID[row.Uid] = row
+		byName[row.Name] = row
+	}
+
+	plan := make([]*plannedImport, 0, len(exported))
+	for i, ds := range exported {
+		item := ImportItem{Kind: "datasource", UID: ds.UID, Name: ds.Name, Path: paths[i]}
+		cur, exists := byUID[ds.UID]
+		other, nameUsed := byName[ds.Name]
+
+		switch {
+		case ds.UID == "":
+			plan = append(plan, conflict(item, "data source has no UID"))
+		case nameUsed && other.Uid != ds.UID:
+			plan = append(plan, conflict(item, "a data source with the same name exists with UID %s", other.Uid))
+		case exists && cur.Type != ds.Type:
+			plan = append(plan, conflict(item, "data source exists with type %s", cur.Type))
+		case exists:
+			plan = append(plan, job.planDataSourceUpdate(item, ds, cur))
+		default:
+			plan = append(plan, job.planDataSourceCreate(item, ds))
+		}
+	}
+	return plan, nil
+}
+
+func (e *importJob) planDataSourceCreate(item ImportItem, ds *dataSourceExport) *plannedImport {
+	if len(ds.SecureJSONFields) > 0 {
+		item.Message = fmt.Sprintf("secrets are not exported and must be set again: %s", strings.Join(ds.SecureJSONFields, ", "))
+	}
+	return &plannedImport{
+		item:   item,
+		action: importCreate,
+		apply: func(ctx context.Context) error {
+			return e.dataSourceService.AddDataSource(ctx, &models.AddDataSourceCommand{
+				OrgId:           e.orgID,
+				UserId:          e.user.UserId,
+				Uid:             ds.UID,
+				Name:            ds.Name,
+				Type:            ds.Type,
+				Access:          ds.Access,
+				Url:             ds.URL,
+				User:            ds.User,
+				Database:        ds.Database,
+				BasicAuth:       ds.BasicAuth,
+				BasicAuthUser:   ds.BasicAuthUser,
+				WithCredentials: ds.WithCredentials,
+				IsDefault:       ds.IsDefault,
+				JsonData:        ds.JSONData,
+				ReadOnly:        ds.ReadOnly,
+			})
+		},
+	}
+}
+
+// planDataSourceUpdate keeps the secrets of the existing data source, the data source service keeps
+// the encrypted secure JSON data that the update does not set
+func (e *importJob) planDataSourceUpdate(item ImportItem, ds *dataSourceExport, cur *models.DataSource) *plannedImport {
+	return &plannedImport{
+		item:   item,
+		action: importUpdate,
+		apply: func(ctx context.Context) error {
+			return e.dataSourceService.UpdateDataSource(ctx, &models.UpdateDataSourceCommand{
+				OrgId:             e.orgID,
+				Id:                cur.Id,
+				Uid:               ds.UID,
+				Name:              ds.Name,
+				Type:              ds.Type,
+				Access:            ds.Access,
+				Url:               ds.URL,
+				User:              ds.User,
+				Password:          cur.Password,
+				Database:          ds.Database,
+				BasicAuth:         ds.BasicAuth,
+				BasicAuthUser:     ds.BasicAuthUser,
+				BasicAuthPassword: cur.BasicAuthPassword,
+				WithCredentials:   ds.WithCredentials,
+				IsDefault:         ds.IsDefault,
+				JsonData:          ds.JSONData,
+				ReadOnly:          ds.ReadOnly,
+			})
+		},
+	}
+}
@@ -0,0 +1,275 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+var _ Job = new(importJob)
+
+type importAction int
+
+const (
+	importCreate importAction = iota
+	importUpdate
+	importConflict
+)
+
+// plannedImport is a single item of the import, apply is only called for creates and updates
+type plannedImport struct {
+	item   ImportItem
+	action importAction
+	apply  func(ctx context.Context) error
+}
+
+type importPlanner = func(ctx context.Context, job *importJob) ([]*plannedImport, error)
+
+type importJob struct {
+	logger            log.Logger
+	sql               *sqlstore.SQLStore
+	dashboardService  dashboards.DashboardService
+	dataSourceService datasources.DataSourceService
+	libraryElements   libraryelements.Service
+	ruleStore         *store.DBstore // nil when unified alerting is disabled
+	quotaService      *quota.QuotaService
+	srcDir            string // the org directory of the export
+	orgID             int64
+	user              *models.SignedInUser
+
+	// Folder UID -> folder ID in the target org, updated as folders are created
+	folderIDs map[string]int64
+	// Folders that will be created by the import
+	newFolders map[string]bool
+	// Folders that can not be imported
+	folderConflicts map[string]bool
+
+	statusMu      sync.Mutex
+	status        ExportStatus
+	cfg           ImportConfig
+	broadcaster   statusBroadcaster
+	lastBroadcast time.Time
+}
+
+func startImportJob(cfg ImportConfig, sql *sqlstore.SQLStore, dashboardService dashboards.DashboardService, dataSourceService datasources.DataSourceService, libraryElements libraryelements.Service, ruleStore *store.DBstore, quotaService *quota.QuotaService, srcDir string, user *models.SignedInUser, broadcaster statusBroadcaster) (Job, error) {
+	info, err := os.Stat(srcDir)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("export directory not found: %s", cfg.Dir)
+	}
+
+	target := "import"
+	if cfg.DryRun {
+		target = "import (dry run)"
+	}
+	job := &importJob{
+		logger:            log.New("import_job"),
+		cfg:               cfg,
+		sql:               sql,
+		dashboardService:  dashboardService,
+		dataSourceService: dataSourceService,
+		libraryElements:   libraryElements,
+		ruleStore:         ruleStore,
+		quotaService:      quotaService,
+		srcDir:            srcDir,
+		orgID:             user.OrgId,
+		user:              user,
+		folderIDs:         map[string]int64{"": 0},
+		newFolders:        make(map[string]bool),
+		folderConflicts:   make(map[string]bool),
+		broadcaster:       broadcaster,
+		status: ExportStatus{
+			Running: true,
+			Target:  target,
+			Started: time.Now().UnixMilli(),
+			Current: 0,
+		},
+	}
+
+	broadcaster(job.status)
+	go job.start()
+	return job, nil
+}
+
+func (e *importJob) getStatus() ExportStatus {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+
+	return e.status
+}
+
+// imports are not configured with an export config
+func (e *importJob) getConfig() ExportConfig {
+	return ExportConfig{}
+}
+
+func (e *importJob) start() {
+	defer func() {
+		e.logger.Info("Finished import job")
+
+		e.statusMu.Lock()
+		defer e.statusMu.Unlock()
+		s := e.status
+		if err := recover(); err != nil {
+			e.logger.Error("import panic", "error", err)
+			s.Status = fmt.Sprintf("ERROR: %v", err)
+		}
+		// Make sure it finishes OK
+		if s.Finished < 10 {
+			s.Finished = time.Now().UnixMilli()
+		}
+		s.Running = false
+		if s.Status == "" {
+			s.Status = "done"
+		}
+		e.status = s
+		e.broadcaster(s)
+	}()
+
+	e.logger.Info("Starting import job", "dir", e.srcDir, "dryRun", e.cfg.DryRun)
+	report, err := e.doImport()
+	e.statusMu.Lock()
+	e.status.Report = report
+	if err != nil {
+		e.logger.Error("import job failed", "error", err)
+		e.status.Status = fmt.Sprintf("ERROR: %s", err.Error())
+	}
+	e.statusMu.Unlock()
+}
+
+func (e *importJob) doImport() (*ImportReport, error) {
+	ctx := context.Background()
+	report := &ImportReport{
+		DryRun:   e.cfg.DryRun,
+		Create:   []ImportItem{},
+		Update:   []ImportItem{},
+		Conflict: []ImportItem{},
+	}
+
+	// Folders are planned with the dashboards, and must come before everything that lives in a folder
+	planners := []importPlanner{
+		planDashboards,
+		planDataSources,
+		planLibraryPanels,
+		planAlertRules,
+	}
+
+	plan := make([]*plannedImport, 0)
+	for _, planner := range planners {
+		items, err := planner(ctx, e)
+		if err != nil {
+			return report, err
+		}
+		plan = append(plan, items...)
+	}
+	e.addCount(int64(len(plan)))
+
+	for _, p := range plan {
+		switch p.action {
+		case importCreate:
+			report.Create = append(report.Create, p.item)
+		case importUpdate:
+			report.Update = append(report.Update, p.item)
+		case importConflict:
+			report.Conflict = append(report.Conflict, p.item)
+		}
+		if !e.cfg.DryRun && p.action != importConflict {
+			if err := p.apply(ctx); err != nil {
+				return report, fmt.Errorf("failed to import %s %s: %w", p.item.Kind, p.item.UID, err)
+			}
+		}
+		e.progress(fmt.Sprintf("%s: %s", p.item.Kind, p.item.Name))
+	}
+	return report, nil
+}
+
+// addCount increases the number of items the job will import
+func (e *importJob) addCount(count int64) {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+	e.status.Count += count
+}
+
+// progress marks one more item as imported, broadcasting at most every 100ms
+func (e *importJob) progress(last string) {
+	e.statusMu.Lock()
+	defer e.statusMu.Unlock()
+
+	now := time.Now()
+	e.status.Current++
+	e.status.Last = last
+	e.status.Changed = now.UnixMilli()
+	if now.Sub(e.lastBroadcast) > 100*time.Millisecond {
+		e.lastBroadcast = now
+		e.broadcaster(e.status)
+	}
+}
+
+// folderProblem returns why items in the folder can not be imported, or an empty string
+func (e *importJob) folderProblem(folderUID string) string {
+	if e.folderConflicts[folderUID] {
+		return fmt.Sprintf("folder %s has a conflict", folderUID)
+	}
+	if _, ok := e.folderIDs[folderUID]; !ok && !e.newFolders[folderUID] {
+		return fmt.Sprintf("folder %s not found", folderUID)
+	}
+	return ""
+}
+
+// relPath returns the path relative to the org directory for the report
+func (e *importJob) relPath(fpath string) string {
+	rel, err := filepath.Rel(e.srcDir, fpath)
+	if err != nil {
+		return fpath
+	}
+	return filepath.ToSlash(rel)
+}
+
+// readJSONFiles unmarshals all files with the suffix in a directory of the export
+func readJSONFiles(dir string, suffix string, newValue func(fpath string) interface{}) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), suffix) {
+			continue
+		}
+		fpath := filepath.Join(dir, entry.Name())
+		body, err := os.ReadFile(fpath)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(body, newValue(fpath)); err != nil {
+			return fmt.Errorf("invalid file %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+func conflict(item ImportItem, format string, args ...interface{}) *plannedImport {
+	item.Message = fmt.Sprintf(format, args...)
+	return &plannedImport{item: item, action: importConflict}
+}
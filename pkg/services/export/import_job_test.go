@@ -0,0 +1,379 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboards/database"
+	datasourceservice "github.com/grafana/grafana/pkg/services/datasources/service"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretskvs "github.com/grafana/grafana/pkg/services/secrets/kvstore"
+	secretsmanager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// storeDashboardService saves the imported dashboards with the dashboard store
+type storeDashboardService struct {
+	dashboards.DashboardService
+	store *database.DashboardStore
+}
+
+func (s *storeDashboardService) SaveDashboard(ctx context.Context, dto *dashboards.SaveDashboardDTO, allowUiUpdate bool) (*models.Dashboard, error) {
+	dto.Dashboard.OrgId = dto.OrgId
+	if _, err := s.store.ValidateDashboardBeforeSave(dto.Dashboard, dto.Overwrite); err != nil {
+		return nil, err
+	}
+	return s.store.SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     dto.OrgId,
+		UserId:    dto.User.UserId,
+		Dashboard: dto.Dashboard.Data,
+		FolderId:  dto.Dashboard.FolderId,
+		IsFolder:  dto.Dashboard.IsFolder,
+		Overwrite: dto.Overwrite,
+		Message:   dto.Message,
+	})
+}
+
+func newTestDataSourceService(t *testing.T, sqlStore *sqlstore.SQLStore) *datasourceservice.Service {
+	t.Helper()
+	secretsService := secretsmanager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	ac := acmock.New()
+	ac.IsDisabledFunc = func() bool { return true }
+	return datasourceservice.ProvideService(sqlStore, secretsService, secretskvs.ProvideService(sqlStore, secretsService), sqlStore.Cfg,
+		featuremgmt.WithFeatures(), ac, acmock.NewPermissionsServicesMock())
+}
+
+func newTestImportJob(t *testing.T, sqlStore *sqlstore.SQLStore, dataSourceService *datasourceservice.Service, srcDir string, dryRun bool) *importJob {
+	t.Helper()
+	if dataSourceService == nil {
+		dataSourceService = newTestDataSourceService(t, sqlStore)
+	}
+	return &importJob{
+		logger:            log.New("test"),
+		sql:               sqlStore,
+		dashboardService:  &storeDashboardService{store: database.ProvideDashboardStore(sqlStore)},
+		dataSourceService: dataSourceService,
+		ruleStore:         &store.DBstore{SQLStore: sqlStore, BaseInterval: 10 * time.Second, Logger: log.New("test")},
+		quotaService:      quota.ProvideService(sqlStore.Cfg, nil, sqlStore),
+		srcDir:            srcDir,
+		orgID:             1,
+		user:              &models.SignedInUser{OrgId: 1, UserId: 1},
+		cfg:               ImportConfig{DryRun: dryRun},
+		folderIDs:         map[string]int64{"": 0},
+		newFolders:        make(map[string]bool),
+		folderConflicts:   make(map[string]bool),
+		broadcaster:       func(s ExportStatus) {},
+	}
+}
+
+func writeExportFile(t *testing.T, dir string, path string, v interface{}) {
+	t.Helper()
+	body, err := prettyJSON(v)
+	require.NoError(t, err)
+	fpath := filepath.Join(dir, filepath.FromSlash(path))
+	require.NoError(t, os.MkdirAll(filepath.Dir(fpath), 0750))
+	require.NoError(t, os.WriteFile(fpath, body, 0600))
+}
+
+func saveTestDashboard(t *testing.T, sqlStore *sqlstore.SQLStore, folderID int64, isFolder bool, uid string, title string) *models.Dashboard {
+	t.Helper()
+	dash, err := database.ProvideDashboardStore(sqlStore).SaveDashboard(models.SaveDashboardCommand{
+		OrgId:     1,
+		UserId:    1,
+		FolderId:  folderID,
+		IsFolder:  isFolder,
+		Dashboard: simplejson.NewFromAny(map[string]interface{}{"uid": uid, "title": title}),
+	})
+	require.NoError(t, err)
+	return dash
+}
+
+func addTestDataSource(t *testing.T, sqlStore *sqlstore.SQLStore, cmd models.AddDataSourceCommand) {
+	t.Helper()
+	cmd.OrgId = 1
+	if cmd.Access == "" {
+		cmd.Access = models.DS_ACCESS_PROXY
+	}
+	require.NoError(t, sqlStore.AddDataSource(context.Background(), &cmd))
+}
+
+func getTestDataSource(t *testing.T, sqlStore *sqlstore.SQLStore, uid string) *models.DataSource {
+	t.Helper()
+	query := &models.GetDataSourceQuery{OrgId: 1, Uid: uid}
+	err := sqlStore.GetDataSource(context.Background(), query)
+	if err == models.ErrDataSourceNotFound {
+		return nil
+	}
+	require.NoError(t, err)
+	return query.Result
+}
+
+// reportItems returns the UIDs of the items of a kind, with the message of the item if there is one
+func reportItems(items []ImportItem, kind string) []string {
+	res := make([]string, 0)
+	for _, item := range items {
+		if item.Kind != kind {
+			continue
+		}
+		if item.Message != "" {
+			res = append(res, item.UID+": "+item.Message)
+		} else {
+			res = append(res, item.UID)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestImportDryRun(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	addTestDataSource(t, sqlStore, models.AddDataSourceCommand{Uid: "prom", Name: "Prometheus", Type: models.DS_PROMETHEUS})
+	addTestDataSource(t, sqlStore, models.AddDataSourceCommand{Uid: "other", Name: "Loki", Type: models.DS_LOKI})
+	addTestDataSource(t, sqlStore, models.AddDataSourceCommand{Uid: "graphite", Name: "Graphite", Type: models.DS_GRAPHITE})
+	saveTestDashboard(t, sqlStore, 0, false, "taken", "Taken")
+	saveTestDashboard(t, sqlStore, 0, false, "existing", "Existing")
+	saveTestDashboard(t, sqlStore, 0, false, "dup", "Duplicate")
+	saveTestDashboard(t, sqlStore, 0, true, "ops", "Ops")
+
+	srcDir := t.TempDir()
+	writeExportFile(t, srcDir, "datasources/prom-ds.json", dataSourceExport{UID: "prom", Name: "Prometheus", Type: models.DS_PROMETHEUS})
+	writeExportFile(t, srcDir, "datasources/loki-ds.json", dataSourceExport{UID: "loki", Name: "Loki", Type: models.DS_LOKI})
+	writeExportFile(t, srcDir, "datasources/graphite-ds.json", dataSourceExport{UID: "graphite", Name: "Graphite", Type: models.DS_INFLUXDB})
+	writeExportFile(t, srcDir, "datasources/new-ds.json", dataSourceExport{UID: "new", Name: "New", Type: models.DS_MYSQL, SecureJSONFields: []string{"password"}})
+	writeExportFile(t, srcDir, "dashboards/taken/__folder.json", folderInfo{UID: "taken", Title: "Taken"})
+	writeExportFile(t, srcDir, "dashboards/taken/a-dash.json", map[string]interface{}{"uid": "a", "title": "A"})
+	writeExportFile(t, srcDir, "dashboards/ops/__folder.json", folderInfo{UID: "ops", Title: "Ops"})
+	writeExportFile(t, srcDir, "dashboards/ops/b-dash.json", map[string]interface{}{"uid": "b", "title": "B"})
+	writeExportFile(t, srcDir, "dashboards/General/existing-dash.json", map[string]interface{}{"id": 42, "uid": "existing", "title": "Existing"})
+	writeExportFile(t, srcDir, "dashboards/General/duplicate-dash.json", map[string]interface{}{"uid": "dup2", "title": "Duplicate"})
+	writeExportFile(t, srcDir, "dashboards/General/folder-dash.json", map[string]interface{}{"uid": "ops", "title": "Folder"})
+
+	job := newTestImportJob(t, sqlStore, nil, srcDir, true)
+	report, err := job.doImport()
+	require.NoError(t, err)
+	require.True(t, report.DryRun)
+
+	require.Equal(t, []string{"new: secrets are not exported and must be set again: password"}, reportItems(report.Create, "datasource"))
+	require.Equal(t, []string{"prom"}, reportItems(report.Update, "datasource"))
+	require.Equal(t, []string{
+		"graphite: data source exists with type graphite",
+		"loki: a data source with the same name exists with UID other",
+	}, reportItems(report.Conflict, "datasource"))
+
+	require.Empty(t, reportItems(report.Create, "folder"))
+	require.Equal(t, []string{"ops"}, reportItems(report.Update, "folder"))
+	require.Equal(t, []string{`taken: UID is used by dashboard "Taken"`}, reportItems(report.Conflict, "folder"))
+
+	require.Equal(t, []string{"b"}, reportItems(report.Create, "dashboard"))
+	require.Equal(t, []string{"existing"}, reportItems(report.Update, "dashboard"))
+	require.Equal(t, []string{
+		"a: folder taken has a conflict",
+		"dup2: a dashboard with the same title exists in the folder with UID dup",
+		`ops: UID is used by folder "Ops"`,
+	}, reportItems(report.Conflict, "dashboard"))
+
+	for _, item := range report.Conflict {
+		if item.UID == "a" {
+			require.Equal(t, "dashboards/taken/a-dash.json", item.Path)
+		}
+	}
+	status := job.getStatus()
+	require.EqualValues(t, 11, status.Count)
+	require.EqualValues(t, 11, status.Current)
+
+	// nothing is written by a dry run
+	require.Nil(t, getTestDataSource(t, sqlStore, "new"))
+	rows, err := loadDashboards(context.Background(), sqlStore, 1)
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+}
+
+func TestImportDataSourceUpdate(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	dataSourceService := newTestDataSourceService(t, sqlStore)
+	require.NoError(t, dataSourceService.AddDataSource(context.Background(), &models.AddDataSourceCommand{
+		OrgId:             1,
+		Uid:               "prom",
+		Name:              "Prometheus",
+		Type:              models.DS_PROMETHEUS,
+		Access:            models.DS_ACCESS_PROXY,
+		Url:               "http://old:9090",
+		Password:          "legacy-password",
+		BasicAuthPassword: "legacy-basic-auth-password",
+		SecureJsonData:    map[string]string{"basicAuthPassword": "basic-auth-password"},
+	}))
+
+	srcDir := t.TempDir()
+	writeExportFile(t, srcDir, "datasources/prom-ds.json", dataSourceExport{
+		UID:              "prom",
+		Name:             "Prometheus",
+		Type:             models.DS_PROMETHEUS,
+		Access:           models.DS_ACCESS_PROXY,
+		URL:              "http://new:9090",
+		BasicAuth:        true,
+		BasicAuthUser:    "admin",
+		JSONData:         simplejson.NewFromAny(map[string]interface{}{"httpMethod": "POST"}),
+		SecureJSONFields: []string{"basicAuthPassword"},
+	})
+
+	report, err := newTestImportJob(t, sqlStore, dataSourceService, srcDir, false).doImport()
+	require.NoError(t, err)
+	require.Equal(t, []string{"prom"}, reportItems(report.Update, "datasource"))
+
+	ds := getTestDataSource(t, sqlStore, "prom")
+	require.Equal(t, "http://new:9090", ds.Url)
+	require.True(t, ds.BasicAuth)
+	require.Equal(t, "admin", ds.BasicAuthUser)
+	require.Equal(t, "POST", ds.JsonData.Get("httpMethod").MustString())
+	// the secrets of the existing data source are kept
+	require.Equal(t, "legacy-password", ds.Password)
+	require.Equal(t, "legacy-basic-auth-password", ds.BasicAuthPassword)
+	secrets, err := dataSourceService.DecryptedValues(context.Background(), ds)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"basicAuthPassword": "basic-auth-password"}, secrets)
+}
+
+func TestImportRoundTrip(t *testing.T) {
+	source := sqlstore.InitTestDB(t)
+	folder := saveTestDashboard(t, source, 0, true, "ops", "Ops")
+	saveTestDashboard(t, source, folder.Id, false, "cpu", "CPU")
+	saveTestDashboard(t, source, 0, false, "home", "Home")
+	addTestDataSource(t, source, models.AddDataSourceCommand{
+		Uid:                     "prom",
+		Name:                    "Prometheus",
+		Type:                    models.DS_PROMETHEUS,
+		Url:                     "http://prometheus:9090",
+		EncryptedSecureJsonData: map[string][]byte{"httpHeaderValue1": []byte("encrypted-header")},
+	})
+
+	helper := newTestCommitHelper(t)
+	require.NoError(t, helper.initOrg(1))
+	exportJob := newTestExportJob(source)
+	require.NoError(t, exportDashboards(helper, exportJob))
+	require.NoError(t, exportDataSources(helper, exportJob))
+
+	target := sqlstore.InitTestDB(t)
+	report, err := newTestImportJob(t, target, nil, helper.orgDir, false).doImport()
+	require.NoError(t, err)
+	require.Empty(t, report.Update)
+	require.Empty(t, report.Conflict)
+	require.Equal(t, []string{"ops"}, reportItems(report.Create, "folder"))
+	require.Equal(t, []string{"cpu", "home"}, reportItems(report.Create, "dashboard"))
+	require.Equal(t, []string{"prom: secrets are not exported and must be set again: httpHeaderValue1"}, reportItems(report.Create, "datasource"))
+
+	rows, err := loadDashboards(context.Background(), target, 1)
+	require.NoError(t, err)
+	byUID := make(map[string]*dashboardRow, len(rows))
+	for _, row := range rows {
+		byUID[row.UID] = row
+	}
+	require.Len(t, byUID, 3)
+	require.True(t, byUID["ops"].IsFolder)
+	require.Equal(t, byUID["ops"].ID, byUID["cpu"].FolderID)
+	require.Equal(t, "CPU", byUID["cpu"].Title)
+	require.EqualValues(t, 0, byUID["home"].FolderID)
+
+	ds := getTestDataSource(t, target, "prom")
+	require.Equal(t, "Prometheus", ds.Name)
+	require.Equal(t, "http://prometheus:9090", ds.Url)
+	require.Empty(t, ds.SecureJsonData)
+
+	// importing the same export again only updates
+	report, err = newTestImportJob(t, target, nil, helper.orgDir, true).doImport()
+	require.NoError(t, err)
+	require.Empty(t, report.Create)
+	require.Empty(t, report.Conflict)
+	require.Len(t, report.Update, 4)
+}
+
+func testAlertRule(uid string, folderUID string, title string) ngmodels.AlertRule {
+	return ngmodels.AlertRule{
+		UID:       uid,
+		OrgID:     1,
+		Title:     title,
+		Condition: "A",
+		Data: []ngmodels.AlertQuery{{
+			RefID:             "A",
+			RelativeTimeRange: ngmodels.RelativeTimeRange{From: ngmodels.Duration(time.Hour)},
+			Model:             json.RawMessage(`{"datasourceUid": "-100", "type": "math", "expression": "2 + 2 > 1"}`),
+		}},
+		IntervalSeconds: 60,
+		NamespaceUID:    folderUID,
+		RuleGroup:       "group",
+		NoDataState:     ngmodels.NoData,
+		ExecErrState:    ngmodels.AlertingErrState,
+	}
+}
+
+func TestImportAlertRules(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	saveTestDashboard(t, sqlStore, 0, true, "ops", "Ops")
+	job := newTestImportJob(t, sqlStore, nil, "", true)
+	require.NoError(t, job.ruleStore.InsertAlertRules(context.Background(), []ngmodels.AlertRule{
+		testAlertRule("cpu", "ops", "CPU"),
+		testAlertRule("disk", "ops", "Disk"),
+	}))
+
+	srcDir := t.TempDir()
+	writeExportFile(t, srcDir, "dashboards/ops/__folder.json", folderInfo{UID: "ops", Title: "Ops"})
+	cpu := testAlertRule("cpu", "ops", "CPU usage")
+	writeExportFile(t, srcDir, "alerting/rules/a-cpu-rule.json", cpu)
+	// takes the previous title of the cpu rule, which is renamed before
+	writeExportFile(t, srcDir, "alerting/rules/b-load-rule.json", testAlertRule("load", "ops", "CPU"))
+	writeExportFile(t, srcDir, "alerting/rules/c-memory-rule.json", testAlertRule("memory", "ops", "Memory"))
+	// the title of a rule that is not renamed by the import
+	writeExportFile(t, srcDir, "alerting/rules/d-storage-rule.json", testAlertRule("storage", "ops", "Disk"))
+	// the title of a rule planned before
+	writeExportFile(t, srcDir, "alerting/rules/e-mem-rule.json", testAlertRule("mem", "ops", "Memory"))
+	invalid := testAlertRule("net", "ops", "Network")
+	invalid.IntervalSeconds = 15
+	writeExportFile(t, srcDir, "alerting/rules/f-net-rule.json", invalid)
+	writeExportFile(t, srcDir, "alerting/rules/g-swap-rule.json", testAlertRule("swap", "ops", "Swap"))
+
+	job = newTestImportJob(t, sqlStore, nil, srcDir, true)
+	job.quotaService.Cfg = &setting.Cfg{Quota: setting.QuotaSettings{
+		Enabled: true,
+		Org:     &setting.OrgQuota{AlertRule: 4},
+		Global:  &setting.GlobalQuota{AlertRule: -1},
+	}}
+	report, err := job.doImport()
+	require.NoError(t, err)
+	require.Equal(t, []string{"load", "memory"}, reportItems(report.Create, "alert-rule"))
+	require.Equal(t, []string{"cpu"}, reportItems(report.Update, "alert-rule"))
+	require.Equal(t, []string{
+		"mem: an alert rule with the same title exists in the folder with UID memory",
+		"net: invalid alert rule: interval (15s) should be non-zero and divided exactly by scheduler interval: 10s",
+		"storage: an alert rule with the same title exists in the folder with UID disk",
+		"swap: the alert rule quota is reached",
+	}, reportItems(report.Conflict, "alert-rule"))
+
+	job = newTestImportJob(t, sqlStore, nil, srcDir, false)
+	report, err = job.doImport()
+	require.NoError(t, err)
+	require.Equal(t, []string{"load", "memory", "swap"}, reportItems(report.Create, "alert-rule"))
+
+	q := ngmodels.GetAlertRuleByUIDQuery{OrgID: 1, UID: "cpu"}
+	require.NoError(t, job.ruleStore.GetAlertRuleByUID(context.Background(), &q))
+	require.Equal(t, "CPU usage", q.Result.Title)
+	require.EqualValues(t, 2, q.Result.Version)
+	q = ngmodels.GetAlertRuleByUIDQuery{OrgID: 1, UID: "load"}
+	require.NoError(t, job.ruleStore.GetAlertRuleByUID(context.Background(), &q))
+	require.Equal(t, "CPU", q.Result.Title)
+}
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+type targetLibraryElement struct {
+	ID       int64  `xorm:"id"`
+	UID      string `xorm:"uid"`
+	FolderID int64  `xorm:"folder_id"`
+	Name     string `xorm:"name"`
+	Kind     int64  `xorm:"kind"`
+}
+
+// libraryElementKey matches the unique index of library elements
+func libraryElementKey(folderID int64, kind int64, name string) string {
+	return fmt.Sprintf("%d/%d/%s", folderID, kind, name)
+}
+
+func planLibraryPanels(ctx context.Context, job *importJob) ([]*plannedImport, error) {
+	exported := make([]*libraryElementExport, 0)
+	paths := make([]string, 0)
+	err := readJSONFiles(filepath.Join(job.srcDir, "library-panels"), "-lib.json", func(fpath string) interface{} {
+		element := &libraryElementExport{}
+		exported = append(exported, element)
+		paths = append(paths, job.relPath(fpath))
+		return element
+	})
+	if err != nil || len(exported) == 0 {
+		return nil, err
+	}
+
+	rows := make([]*targetLibraryElement, 0)
+	err = job.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("library_element").
+			Where("org_id = ?", job.orgID).
+			Cols("id", "uid", "folder_id", "name", "kind").
+			Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	byUID := make(map[string]*targetLibraryElement, len(rows))
+	byName := make(map[string]*targetLibraryElement, len(rows))
+	for _, row := range rows {
+		byUID[row.UID] = row
+		byName[libraryElementKey(row.FolderID, row.Kind, row.Name)] = row
+	}
+
+	plan := make([]*plannedImport, 0, len(exported))
+	for i, element := range exported {
+		item := ImportItem{Kind: "library-panel", UID: element.UID, Name: element.Name, Path: paths[i]}
+		if element.UID == "" {
+			plan = append(plan, conflict(item, "library element has no UID"))
+			continue
+		}
+		if problem := job.folderProblem(element.FolderUID); problem != "" {
+			plan = append(plan, conflict(item, "%s", problem))
+			continue
+		}
+
+		cur, exists := byUID[element.UID]
+		if folderID, ok := job.folderIDs[element.FolderUID]; ok {
+			other, nameUsed := byName[libraryElementKey(folderID, element.Kind, element.Name)]
+			if nameUsed && other.UID != element.UID {
+				plan = append(plan, conflict(item, "a library element with the same name exists in the folder with UID %s", other.UID))
+				continue
+			}
+		}
+
+		if exists {
+			plan = append(plan, job.planLibraryElementUpdate(item, element, cur))
+			continue
+		}
+		plan = append(plan, job.planLibraryElementCreate(item, element))
+	}
+	return plan, nil
+}
+
+func (e *importJob) planLibraryElementCreate(item ImportItem, element *libraryElementExport) *plannedImport {
+	return &plannedImport{
+		item:   item,
+		action: importCreate,
+		apply: func(ctx context.Context) error {
+			_, err := e.libraryElements.CreateElement(ctx, e.user, libraryelements.CreateLibraryElementCommand{
+				FolderID: e.folderIDs[element.FolderUID],
+				Name:     element.Name,
+				Model:    element.Model,
+				Kind:     element.Kind,
+				UID:      element.UID,
+			})
+			return err
+		},
+	}
+}
+
+// planLibraryElementUpdate overwrites the existing element, keeping its connections to dashboards
+func (e *importJob) planLibraryElementUpdate(item ImportItem, element *libraryElementExport, cur *targetLibraryElement) *plannedImport {
+	return &plannedImport{
+		item:   item,
+		action: importUpdate,
+		apply: func(ctx context.Context) error {
+			return e.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+				rawSQL := `UPDATE library_element
+					SET folder_id = ?, name = ?, type = ?, description = ?, model = ?, version = version + 1, updated = ?, updated_by = ?
+					WHERE id = ?`
+				_, err := sess.Exec(rawSQL, e.folderIDs[element.FolderUID], element.Name, element.Type, element.Description,
+					[]byte(element.Model), time.Now(), e.user.UserId, cur.ID)
+				return err
+			})
+		},
+	}
+}
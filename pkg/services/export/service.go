@@ -2,15 +2,25 @@ package export
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
 	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/services/ngalert"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 type ExportService interface {
@@ -19,19 +29,31 @@ type ExportService interface {
 
 	// Read raw file contents out of the store
 	HandleRequestExport(c *models.ReqContext) response.Response
+
+	// Load a previous export into the current org
+	HandleRequestImport(c *models.ReqContext) response.Response
 }
 
 type StandardExport struct {
-	logger log.Logger
-	sql    *sqlstore.SQLStore
-	glive  *live.GrafanaLive
-	mutex  sync.Mutex
+	logger  log.Logger
+	sql     *sqlstore.SQLStore
+	glive   *live.GrafanaLive
+	mutex   sync.Mutex
+	dataDir string
+
+	dashboardService  dashboards.DashboardService
+	dataSourceService datasources.DataSourceService
+	libraryElements   libraryelements.Service
+	ruleStore         *store.DBstore
+	quotaService      *quota.QuotaService
 
 	// updated with mutex
 	exportJob Job
 }
 
-func ProvideService(sql *sqlstore.SQLStore, features featuremgmt.FeatureToggles, gl *live.GrafanaLive) ExportService {
+func ProvideService(sql *sqlstore.SQLStore, features featuremgmt.FeatureToggles, gl *live.GrafanaLive, cfg *setting.Cfg,
+	dashboardService dashboards.DashboardService, dataSourceService datasources.DataSourceService, libraryElements libraryelements.Service,
+	alertNG *ngalert.AlertNG, quotaService *quota.QuotaService) ExportService {
 	if !features.IsEnabled(featuremgmt.FlagExport) {
 		return &StubExport{}
 	}
@@ -41,6 +63,13 @@ func ProvideService(sql *sqlstore.SQLStore, features featuremgmt.FeatureToggles,
 		glive:     gl,
 		logger:    log.New("export_service"),
 		exportJob: &stoppedJob{},
+		dataDir:   cfg.DataPath,
+
+		dashboardService:  dashboardService,
+		dataSourceService: dataSourceService,
+		libraryElements:   libraryElements,
+		ruleStore:         alertNG.RuleStore(),
+		quotaService:      quotaService,
 	}
 }
 
@@ -67,9 +96,19 @@ func (ex *StandardExport) HandleRequestExport(c *models.ReqContext) response.Res
 		return response.Error(http.StatusLocked, "export already running", nil)
 	}
 
-	job, err := startDummyExportJob(cfg, func(s ExportStatus) {
+	broadcast := func(s ExportStatus) {
 		ex.broadcastStatus(c.OrgId, s)
-	})
+	}
+	var job Job
+	switch cfg.Format {
+	case "dummy":
+		job, err = startDummyExportJob(cfg, broadcast)
+	case "git":
+		dir := filepath.Join(ex.dataDir, "export", fmt.Sprintf("git_%d", time.Now().Unix()))
+		job, err = startGitExportJob(cfg, ex.sql, dir, c.SignedInUser, broadcast)
+	default:
+		return response.Error(http.StatusBadRequest, "Unsupported format", nil)
+	}
 	if err != nil {
 		ex.logger.Error("failed to start export job", "err", err)
 		return response.Error(http.StatusBadRequest, "failed to start export job", err)
@@ -79,6 +118,46 @@ func (ex *StandardExport) HandleRequestExport(c *models.ReqContext) response.Res
 	return response.JSON(http.StatusOK, ex.exportJob.getStatus())
 }
 
+func (ex *StandardExport) HandleRequestImport(c *models.ReqContext) response.Response {
+	// Only report what would change unless the request asks to apply the import
+	cfg := ImportConfig{DryRun: true}
+	err := json.NewDecoder(c.Req.Body).Decode(&cfg)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "unable to read config", err)
+	}
+
+	// Only exports written to the data path can be imported
+	exportDir := filepath.Join(ex.dataDir, "export")
+	dir := filepath.Join(exportDir, filepath.Clean("/"+cfg.Dir))
+	if cfg.Dir == "" || dir == exportDir {
+		return response.Error(http.StatusBadRequest, "missing export directory", nil)
+	}
+	if cfg.OrgID < 1 {
+		cfg.OrgID = 1
+	}
+
+	ex.mutex.Lock()
+	defer ex.mutex.Unlock()
+
+	status := ex.exportJob.getStatus()
+	if status.Running {
+		ex.logger.Error("export already running")
+		return response.Error(http.StatusLocked, "export already running", nil)
+	}
+
+	srcDir := filepath.Join(dir, fmt.Sprintf("org_%d", cfg.OrgID))
+	job, err := startImportJob(cfg, ex.sql, ex.dashboardService, ex.dataSourceService, ex.libraryElements, ex.ruleStore, ex.quotaService, srcDir, c.SignedInUser, func(s ExportStatus) {
+		ex.broadcastStatus(c.OrgId, s)
+	})
+	if err != nil {
+		ex.logger.Error("failed to start import job", "err", err)
+		return response.Error(http.StatusBadRequest, "failed to start import job", err)
+	}
+
+	ex.exportJob = job
+	return response.JSON(http.StatusOK, ex.exportJob.getStatus())
+}
+
 func (ex *StandardExport) broadcastStatus(orgID int64, s ExportStatus) {
 	msg, err := json.Marshal(s)
 	if err != nil {
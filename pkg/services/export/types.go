@@ -11,6 +11,9 @@ type ExportStatus struct {
 	Current  int64  `json:"current,omitempty"`
 	Last     string `json:"last,omitempty"`
 	Status   string `json:"status"` // ERROR, SUCCESS, ETC
+
+	// Set when an import job finished
+	Report *ImportReport `json:"report,omitempty"`
 }
 
 // Basic export config (for now)
@@ -34,3 +37,31 @@ type Job interface {
 
 // Will broadcast the live status
 type statusBroadcaster func(s ExportStatus)
+
+// Import config, reads a directory written by the git export
+type ImportConfig struct {
+	// Directory of the export, relative to the export folder in the data path
+	Dir string `json:"dir"`
+
+	// The exported org to read, defaults to the main org
+	OrgID int64 `json:"orgId"`
+
+	// Only report what would change, true unless the request sets it to false
+	DryRun bool `json:"dryRun"`
+}
+
+// ImportReport lists the changes of an import by UID
+type ImportReport struct {
+	DryRun   bool         `json:"dryRun"`
+	Create   []ImportItem `json:"create"`
+	Update   []ImportItem `json:"update"`
+	Conflict []ImportItem `json:"conflict"`
+}
+
+type ImportItem struct {
+	Kind    string `json:"kind"`
+	UID     string `json:"uid"`
+	Name    string `json:"name"`
+	Path    string `json:"path"` // relative to the org directory
+	Message string `json:"message,omitempty"`
+}
@@ -2,6 +2,7 @@ package live
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -28,6 +29,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/comments/commentmodel"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/live/convert"
 	"github.com/grafana/grafana/pkg/services/live/database"
 	"github.com/grafana/grafana/pkg/services/live/features"
 	"github.com/grafana/grafana/pkg/services/live/livecontext"
@@ -71,7 +73,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 	pluginStore plugins.Store, cacheService *localcache.CacheService,
 	dataSourceCache datasources.CacheService, sqlStore *sqlstore.SQLStore, secretsService secrets.Service,
 	usageStatsService usagestats.Service, queryDataService *query.Service, toggles featuremgmt.FeatureToggles,
-	accessControl accesscontrol.AccessControl) (*GrafanaLive, error) {
+	accessControl accesscontrol.AccessControl, frameCache managedstream.FrameCache) (*GrafanaLive, error) {
 	g := &GrafanaLive{
 		Cfg:                   cfg,
 		Features:              toggles,
@@ -88,6 +90,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 			Features: make(map[string]models.ChannelHandlerFactory),
 		},
 		usageStatsService: usageStatsService,
+		pushConverter:     convert.NewConverter(),
 	}
 
 	logger.Debug("GrafanaLive initialization", "ha", g.IsHA())
@@ -156,30 +159,23 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 
 	channelLocalPublisher := liveplugin.NewChannelLocalPublisher(node, nil)
 
-	var managedStreamRunner *managedstream.Runner
-	if g.IsHA() {
-		redisClient := redis.NewClient(&redis.Options{
-			Addr: g.Cfg.LiveHAEngineAddress,
-		})
-		cmd := redisClient.Ping(context.Background())
-		if _, err := cmd.Result(); err != nil {
-			return nil, fmt.Errorf("error pinging Redis: %v", err)
-		}
-		managedStreamRunner = managedstream.NewRunner(
-			g.Publish,
-			channelLocalPublisher,
-			managedstream.NewRedisFrameCache(redisClient),
-		)
-	} else {
-		managedStreamRunner = managedstream.NewRunner(
-			g.Publish,
-			channelLocalPublisher,
-			managedstream.NewMemoryFrameCache(),
-		)
-	}
+	managedStreamRunner := managedstream.NewRunner(
+		g.Publish,
+		channelLocalPublisher,
+		frameCache,
+	)
 
 	g.ManagedStreamRunner = managedStreamRunner
 	if g.Features.IsEnabled(featuremgmt.FlagLivePipeline) {
+		// Windows of frame processors must be shared by all nodes in HA setup
+		// since data of a channel can be pushed to any of them.
+		var windowStorage pipeline.WindowStorage = pipeline.NewMemoryWindowStorage()
+		if g.IsHA() {
+			windowStorage = pipeline.NewRedisWindowStorage(redis.NewClient(&redis.Options{
+				Addr: g.Cfg.LiveHAEngineAddress,
+			}))
+		}
+
 		var builder pipeline.RuleBuilder
 		if os.Getenv("GF_LIVE_DEV_BUILDER") != "" {
 			builder = &pipeline.DevRuleBuilder{
@@ -198,6 +194,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 				Node:                 node,
 				ManagedStream:        g.ManagedStreamRunner,
 				FrameStorage:         pipeline.NewFrameStorage(),
+				WindowStorage:        windowStorage,
 				Storage:              storage,
 				ChannelHandlerGetter: g,
 				SecretsService:       g.SecretsService,
@@ -426,6 +423,7 @@ type GrafanaLive struct {
 	ManagedStreamRunner *managedstream.Runner
 	Pipeline            *pipeline.Pipeline
 	pipelineStorage     pipeline.Storage
+	pushConverter       *convert.Converter
 
 	contextGetter    *liveplugin.ContextGetter
 	runStreamManager *runstream.Manager
@@ -952,6 +950,40 @@ func (g *GrafanaLive) ClientCount(orgID int64, channel string) (int, error) {
 	return len(p.Presence), nil
 }
 
+// PushData processes data pushed into a stream channel by the Live listeners. The data is processed by
+// the channel rule when the pipeline has one for the channel, otherwise it is converted from Influx line
+// protocol and pushed to stream/<namespace>/<measurement> channels, like data pushed over HTTP.
+func (g *GrafanaLive) PushData(ctx context.Context, orgID int64, channel string, body []byte) error {
+	if g.Pipeline != nil {
+		ruleFound, err := g.Pipeline.ProcessInput(ctx, orgID, channel, body)
+		if err != nil || ruleFound {
+			return err
+		}
+	}
+
+	addr, err := live.ParseChannel(channel)
+	if err != nil {
+		return err
+	}
+	if addr.Scope != live.ScopeStream {
+		return fmt.Errorf("%w: data can only be pushed to stream channels", live.ErrInvalidChannelID)
+	}
+	stream, err := g.ManagedStreamRunner.GetOrCreateStream(orgID, live.ScopeStream, addr.Namespace)
+	if err != nil {
+		return err
+	}
+	metricFrames, err := g.pushConverter.Convert(body, "labels_column")
+	if err != nil {
+		return err
+	}
+	for _, mf := range metricFrames {
+		if err := stream.Push(ctx, mf.Key(), mf.Frame()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (g *GrafanaLive) HandleHTTPPublish(ctx *models.ReqContext) response.Response {
 	cmd := dtos.LivePublishCmd{}
 	if err := web.Bind(ctx.Req, &cmd); err != nil {
@@ -1073,6 +1105,9 @@ type ConvertDryRunRequest struct {
 	ChannelRules []pipeline.ChannelRule `json:"channelRules"`
 	Channel      string                 `json:"channel"`
 	Data         string                 `json:"data"`
+	// Encoding of the data, base64 allows to test converters of binary formats
+	// like OTLP protobuf. Data is used as is when empty.
+	Encoding string `json:"encoding,omitempty"`
 }
 
 type ConvertDryRunResponse struct {
@@ -1137,6 +1172,7 @@ func (g *GrafanaLive) HandlePipelineConvertTestHTTP(c *models.ReqContext) respon
 		Node:                 g.node,
 		ManagedStream:        g.ManagedStreamRunner,
 		FrameStorage:         pipeline.NewFrameStorage(),
+		WindowStorage:        pipeline.NewMemoryWindowStorage(),
 		Storage:              storage,
 		ChannelHandlerGetter: g,
 	}
@@ -1155,7 +1191,18 @@ func (g *GrafanaLive) HandlePipelineConvertTestHTTP(c *models.ReqContext) respon
 	if rule.Converter == nil {
 		return response.Error(http.StatusNotFound, "No converter found", nil)
 	}
-	channelFrames, err := pipe.DataToChannelFrames(c.Req.Context(), *rule, c.OrgId, req.Channel, []byte(req.Data))
+	data := []byte(req.Data)
+	switch req.Encoding {
+	case "":
+	case "base64":
+		data, err = base64.StdEncoding.DecodeString(req.Data)
+		if err != nil {
+			return response.Error(http.StatusBadRequest, "Error decoding base64 data", err)
+		}
+	default:
+		return response.Error(http.StatusBadRequest, "Unsupported data encoding", nil)
+	}
+	channelFrames, err := pipe.DataToChannelFrames(c.Req.Context(), *rule, c.OrgId, req.Channel, data)
 	if err != nil {
 		return response.Error(http.StatusInternalServerError, "Error converting data", err)
 	}
@@ -3,8 +3,13 @@ package managedstream
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 // FrameCache allows updating frame schema. Returns true is schema not changed.
@@ -13,6 +18,159 @@ type FrameCache interface {
 	GetActiveChannels(orgID int64) (map[string]json.RawMessage, error)
 	// GetFrame returns full JSON frame for a channel in org.
 	GetFrame(ctx context.Context, orgID int64, channel string) (json.RawMessage, bool, error)
-	// Update updates frame cache and returns true if schema changed.
+	// GetHistory returns full JSON frame with the rows of the frames kept in the
+	// history of a channel in org, oldest first.
+	GetHistory(ctx context.Context, orgID int64, channel string) (json.RawMessage, bool, error)
+	// Update updates frame cache and returns true if schema changed. The frame is
+	// also added to the history of the channel, which is reset when schema changed.
 	Update(ctx context.Context, orgID int64, channel string, frameJson data.FrameJSONCache) (bool, error)
 }
+
+// HistoryConfig limits the history of the frames of each channel.
+type HistoryConfig struct {
+	// MaxPoints is a maximum number of points (frame rows) of the history, 0 disables the history.
+	MaxPoints int
+	// MaxAge is a maximum age of the frames of the history, 0 means no limit.
+	MaxAge time.Duration
+}
+
+func (c HistoryConfig) enabled() bool {
+	return c.MaxPoints > 0
+}
+
+// ProvideFrameCache returns the frame cache of the managed streams. The frames
+// are kept in Redis when Live uses the Redis HA engine, in memory otherwise.
+func ProvideFrameCache(cfg *setting.Cfg) (FrameCache, error) {
+	historyConfig := HistoryConfig{
+		MaxPoints: cfg.LiveHistoryMaxPoints,
+		MaxAge:    cfg.LiveHistoryMaxAge,
+	}
+	if cfg.LiveHAEngine == "" {
+		return NewMemoryFrameCache(historyConfig), nil
+	}
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: cfg.LiveHAEngineAddress,
+	})
+	cmd := redisClient.Ping(context.Background())
+	if _, err := cmd.Result(); err != nil {
+		return nil, fmt.Errorf("error pinging Redis: %v", err)
+	}
+	return NewRedisFrameCache(redisClient, historyConfig), nil
+}
+
+// historyEntry is a frame of the history of a channel.
+type historyEntry struct {
+	// Time is the time the frame was pushed at, in Unix milliseconds.
+	Time int64 `json:"time"`
+	// Rows is the number of rows of the frame.
+	Rows  int             `json:"rows"`
+	Frame json.RawMessage `json:"frame"`
+}
+
+func newHistoryEntry(now time.Time, frameJson data.FrameJSONCache) (historyEntry, error) {
+	rows, err := frameRows(frameJson)
+	if err != nil {
+		return historyEntry{}, err
+	}
+	return historyEntry{
+		Time:  now.UnixNano() / int64(time.Millisecond),
+		Rows:  rows,
+		Frame: frameJson.Bytes(data.IncludeAll),
+	}, nil
+}
+
+// points returns the number of points the entry counts for in the history. A frame
+// without rows counts for one, so that the number of frames is limited as well.
+func (e historyEntry) points() int {
+	if e.Rows < 1 {
+		return 1
+	}
+	return e.Rows
+}
+
+// frameRows returns the number of rows of a frame, the length of the values of
+// its first field.
+func frameRows(frameJson data.FrameJSONCache) (int, error) {
+	var frame struct {
+		Data struct {
+			Values []json.RawMessage `json:"values"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(frameJson.Bytes(data.IncludeDataOnly), &frame); err != nil {
+		return 0, err
+	}
+	if len(frame.Data.Values) == 0 {
+		return 0, nil
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(frame.Data.Values[0], &values); err != nil {
+		return 0, err
+	}
+	return len(values), nil
+}
+
+func (e historyEntry) expired(config HistoryConfig, now time.Time) bool {
+	return config.MaxAge > 0 && e.Time < now.Add(-config.MaxAge).UnixNano()/int64(time.Millisecond)
+}
+
+// mergeHistory returns a frame with the rows of the frames of the history which
+// are not expired. The oldest rows are dropped when there are more than the
+// maximum number of points, and only the last frames are merged if the schema
+// changed.
+func mergeHistory(entries []historyEntry, config HistoryConfig, now time.Time) (json.RawMessage, bool, error) {
+	var frames []*data.Frame
+	for _, entry := range entries {
+		if entry.expired(config, now) {
+			continue
+		}
+		var frame data.Frame
+		if err := json.Unmarshal(entry.Frame, &frame); err != nil {
+			return nil, false, err
+		}
+		if len(frames) > 0 && !sameFields(frames[0], &frame) {
+			frames = frames[:0]
+		}
+		frames = append(frames, &frame)
+	}
+	if len(frames) == 0 {
+		return nil, false, nil
+	}
+
+	rows := 0
+	for _, frame := range frames {
+		rows += frame.Rows()
+	}
+	skip := rows - config.MaxPoints
+
+	merged := frames[len(frames)-1].EmptyCopy()
+	for _, frame := range frames {
+		for i := 0; i < frame.Rows(); i++ {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			for j, field := range frame.Fields {
+				merged.Fields[j].Append(field.CopyAt(i))
+			}
+		}
+	}
+
+	frameJSON, err := data.FrameToJSON(merged, data.IncludeAll)
+	if err != nil {
+		return nil, false, err
+	}
+	return frameJSON, true, nil
+}
+
+func sameFields(a, b *data.Frame) bool {
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Name != b.Fields[i].Name || a.Fields[i].Type() != b.Fields[i].Type() {
+			return false
+		}
+	}
+	return true
+}
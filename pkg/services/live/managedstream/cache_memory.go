@@ -4,20 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"sync"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
 // MemoryFrameCache ...
 type MemoryFrameCache struct {
-	mu     sync.RWMutex
-	frames map[int64]map[string]data.FrameJSONCache
+	mu            sync.RWMutex
+	frames        map[int64]map[string]data.FrameJSONCache
+	history       map[int64]map[string]*historyFrames
+	historyConfig HistoryConfig
 }
 
 // NewMemoryFrameCache ...
-func NewMemoryFrameCache() *MemoryFrameCache {
+func NewMemoryFrameCache(historyConfig HistoryConfig) *MemoryFrameCache {
 	return &MemoryFrameCache{
-		frames: map[int64]map[string]data.FrameJSONCache{},
+		frames:        map[int64]map[string]data.FrameJSONCache{},
+		history:       map[int64]map[string]*historyFrames{},
+		historyConfig: historyConfig,
 	}
 }
 
@@ -42,6 +47,21 @@ func (c *MemoryFrameCache) GetFrame(ctx context.Context, orgID int64, channel st
 	return cachedFrame.Bytes(data.IncludeAll), ok, nil
 }
 
+func (c *MemoryFrameCache) GetHistory(ctx context.Context, orgID int64, channel string) (json.RawMessage, bool, error) {
+	if !c.historyConfig.enabled() {
+		return nil, false, nil
+	}
+	c.mu.RLock()
+	history, ok := c.history[orgID][channel]
+	if !ok {
+		c.mu.RUnlock()
+		return nil, false, nil
+	}
+	entries := append([]historyEntry(nil), history.entries...)
+	c.mu.RUnlock()
+	return mergeHistory(entries, c.historyConfig, time.Now())
+}
+
 func (c *MemoryFrameCache) Update(ctx context.Context, orgID int64, channel string, jsonFrame data.FrameJSONCache) (bool, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -51,5 +71,46 @@ func (c *MemoryFrameCache) Update(ctx context.Context, orgID int64, channel stri
 	cachedJsonFrame, exists := c.frames[orgID][channel]
 	schemaUpdated := !exists || !cachedJsonFrame.SameSchema(&jsonFrame)
 	c.frames[orgID][channel] = jsonFrame
+	if c.historyConfig.enabled() {
+		if err := c.addHistory(orgID, channel, jsonFrame, schemaUpdated, time.Now()); err != nil {
+			return schemaUpdated, err
+		}
+	}
 	return schemaUpdated, nil
 }
+
+// addHistory adds a frame to the history of a channel, the oldest frames are
+// dropped when the others have at least MaxPoints rows.
+func (c *MemoryFrameCache) addHistory(orgID int64, channel string, jsonFrame data.FrameJSONCache, schemaUpdated bool, now time.Time) error {
+	entry, err := newHistoryEntry(now, jsonFrame)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.history[orgID]; !ok {
+		c.history[orgID] = map[string]*historyFrames{}
+	}
+	history, ok := c.history[orgID][channel]
+	if !ok || schemaUpdated {
+		history = &historyFrames{}
+		c.history[orgID][channel] = history
+	}
+	history.add(entry, c.historyConfig.MaxPoints)
+	return nil
+}
+
+// historyFrames are the last frames of a channel, oldest first.
+type historyFrames struct {
+	entries []historyEntry
+	points  int
+}
+
+// add adds an entry and drops the oldest ones as long as the others have at least maxPoints points.
+func (h *historyFrames) add(entry historyEntry, maxPoints int) {
+	h.entries = append(h.entries, entry)
+	h.points += entry.points()
+	for h.points-h.entries[0].points() >= maxPoints {
+		h.points -= h.entries[0].points()
+		h.entries[0] = historyEntry{}
+		h.entries = h.entries[1:]
+	}
+}
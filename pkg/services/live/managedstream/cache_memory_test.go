@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
@@ -63,8 +64,156 @@ func testFrameCache(t *testing.T, c FrameCache) {
 	require.NotEqual(t, string(channels["test"]), string(schema))
 }
 
+func testFrameCacheHistory(t *testing.T, c FrameCache) {
+	// Push frames of two points to the channel.
+	for i := 0; i < 3; i++ {
+		frame := data.NewFrame("hello",
+			data.NewField("time", nil, []time.Time{time.Unix(int64(2*i), 0).UTC(), time.Unix(int64(2*i+1), 0).UTC()}),
+			data.NewField("value", nil, []float64{float64(2 * i), float64(2*i + 1)}),
+		)
+		frameJsonCache, err := data.FrameToJSONCache(frame)
+		require.NoError(t, err)
+		_, err = c.Update(context.Background(), 1, "history", frameJsonCache)
+		require.NoError(t, err)
+	}
+
+	// Make sure the history has the last points of the frames.
+	frameJSON, ok, err := c.GetHistory(context.Background(), 1, "history")
+	require.NoError(t, err)
+	require.True(t, ok)
+	var f data.Frame
+	err = json.Unmarshal(frameJSON, &f)
+	require.NoError(t, err)
+	require.Equal(t, 3, f.Rows())
+	require.Equal(t, []interface{}{time.Unix(3, 0).UTC(), 3.}, f.RowCopy(0))
+	require.Equal(t, []interface{}{time.Unix(5, 0).UTC(), 5.}, f.RowCopy(2))
+
+	// Push a frame of three points, the history keeps only its points.
+	frame := data.NewFrame("hello",
+		data.NewField("time", nil, []time.Time{time.Unix(6, 0).UTC(), time.Unix(7, 0).UTC(), time.Unix(8, 0).UTC()}),
+		data.NewField("value", nil, []float64{6, 7, 8}),
+	)
+	frameJsonCache, err := data.FrameToJSONCache(frame)
+	require.NoError(t, err)
+	_, err = c.Update(context.Background(), 1, "history", frameJsonCache)
+	require.NoError(t, err)
+	frameJSON, ok, err = c.GetHistory(context.Background(), 1, "history")
+	require.NoError(t, err)
+	require.True(t, ok)
+	f = data.Frame{}
+	require.NoError(t, json.Unmarshal(frameJSON, &f))
+	require.Equal(t, 3, f.Rows())
+	require.Equal(t, []interface{}{time.Unix(6, 0).UTC(), 6.}, f.RowCopy(0))
+
+	// Make sure history is reset when schema changed.
+	newFrame := data.NewFrame("hello", data.NewField("new_field", nil, []int64{1}))
+	frameJsonCache, err = data.FrameToJSONCache(newFrame)
+	require.NoError(t, err)
+	_, err = c.Update(context.Background(), 1, "history", frameJsonCache)
+	require.NoError(t, err)
+
+	frameJSON, ok, err = c.GetHistory(context.Background(), 1, "history")
+	require.NoError(t, err)
+	require.True(t, ok)
+	f = data.Frame{}
+	err = json.Unmarshal(frameJSON, &f)
+	require.NoError(t, err)
+	require.Equal(t, 1, f.Rows())
+	require.Equal(t, "new_field", f.Fields[0].Name)
+
+	// No history in other org.
+	_, ok, err = c.GetHistory(context.Background(), 2, "history")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
 func TestMemoryFrameCache(t *testing.T) {
-	c := NewMemoryFrameCache()
+	c := NewMemoryFrameCache(HistoryConfig{})
 	require.NotNil(t, c)
 	testFrameCache(t, c)
 }
+
+func TestMemoryFrameCacheHistory(t *testing.T) {
+	c := NewMemoryFrameCache(HistoryConfig{MaxPoints: 3, MaxAge: time.Minute})
+	testFrameCacheHistory(t, c)
+
+	t.Run("disabled", func(t *testing.T) {
+		c := NewMemoryFrameCache(HistoryConfig{})
+		frameJsonCache, err := data.FrameToJSONCache(data.NewFrame("hello"))
+		require.NoError(t, err)
+		_, err = c.Update(context.Background(), 1, "test", frameJsonCache)
+		require.NoError(t, err)
+		_, ok, err := c.GetHistory(context.Background(), 1, "test")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
+
+func TestHistoryFrames(t *testing.T) {
+	entry := func(rows int) historyEntry {
+		return historyEntry{Rows: rows}
+	}
+	rows := func(h *historyFrames) []int {
+		result := make([]int, 0, len(h.entries))
+		for _, e := range h.entries {
+			result = append(result, e.Rows)
+		}
+		return result
+	}
+
+	h := &historyFrames{}
+	for _, r := range []int{2, 2, 2} {
+		h.add(entry(r), 3)
+	}
+	// the last frames have at least the maximum number of points
+	require.Equal(t, []int{2, 2}, rows(h))
+	h.add(entry(5), 3)
+	require.Equal(t, []int{5}, rows(h))
+
+	// frames without rows count for one point
+	for i := 0; i < 10; i++ {
+		h.add(entry(0), 3)
+	}
+	require.Equal(t, []int{0, 0, 0}, rows(h))
+}
+
+func TestFrameRows(t *testing.T) {
+	for _, frame := range []*data.Frame{
+		data.NewFrame("empty"),
+		data.NewFrame("fields", data.NewField("value", nil, []float64{})),
+		data.NewFrame("rows", data.NewField("value", nil, []float64{1, 2, 3}), data.NewField("name", nil, []string{"a", "b", "c"})),
+	} {
+		frameJsonCache, err := data.FrameToJSONCache(frame)
+		require.NoError(t, err)
+		rows, err := frameRows(frameJsonCache)
+		require.NoError(t, err)
+		require.Equal(t, frame.Rows(), rows, frame.Name)
+	}
+}
+
+func TestMergeHistory(t *testing.T) {
+	now := time.Now()
+	entry := func(age time.Duration, values ...float64) historyEntry {
+		frameJsonCache, err := data.FrameToJSONCache(data.NewFrame("hello", data.NewField("value", nil, values)))
+		require.NoError(t, err)
+		e, err := newHistoryEntry(now.Add(-age), frameJsonCache)
+		require.NoError(t, err)
+		return e
+	}
+	config := HistoryConfig{MaxPoints: 10, MaxAge: time.Minute}
+
+	t.Run("drops expired frames", func(t *testing.T) {
+		frameJSON, ok, err := mergeHistory([]historyEntry{entry(2*time.Minute, 1), entry(time.Second, 2, 3)}, config, now)
+		require.NoError(t, err)
+		require.True(t, ok)
+		var f data.Frame
+		require.NoError(t, json.Unmarshal(frameJSON, &f))
+		require.Equal(t, []float64{2, 3}, []float64{f.Fields[0].At(0).(float64), f.Fields[0].At(1).(float64)})
+	})
+
+	t.Run("no frames", func(t *testing.T) {
+		_, ok, err := mergeHistory([]historyEntry{entry(2*time.Minute, 1)}, config, now)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
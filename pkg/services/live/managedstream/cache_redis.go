@@ -15,16 +15,18 @@ import (
 
 // RedisFrameCache ...
 type RedisFrameCache struct {
-	mu          sync.RWMutex
-	redisClient *redis.Client
-	frames      map[int64]map[string]data.FrameJSONCache
+	mu            sync.RWMutex
+	redisClient   *redis.Client
+	frames        map[int64]map[string]data.FrameJSONCache
+	historyConfig HistoryConfig
 }
 
 // NewRedisFrameCache ...
-func NewRedisFrameCache(redisClient *redis.Client) *RedisFrameCache {
+func NewRedisFrameCache(redisClient *redis.Client, historyConfig HistoryConfig) *RedisFrameCache {
 	return &RedisFrameCache{
-		frames:      map[int64]map[string]data.FrameJSONCache{},
-		redisClient: redisClient,
+		frames:        map[int64]map[string]data.FrameJSONCache{},
+		redisClient:   redisClient,
+		historyConfig: historyConfig,
 	}
 }
 
@@ -55,10 +57,59 @@ func (c *RedisFrameCache) GetFrame(ctx context.Context, orgID int64, channel str
 	return json.RawMessage(result["frame"]), true, nil
 }
 
+func (c *RedisFrameCache) GetHistory(ctx context.Context, orgID int64, channel string) (json.RawMessage, bool, error) {
+	if !c.historyConfig.enabled() {
+		return nil, false, nil
+	}
+	key := getHistoryKey(orgchannel.PrependOrgID(orgID, channel))
+	result, err := c.redisClient.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	entries := make([]historyEntry, 0, len(result))
+	for _, value := range result {
+		var entry historyEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, entry)
+	}
+	return mergeHistory(entries, c.historyConfig, time.Now())
+}
+
 const (
 	frameCacheTTL = 7 * 24 * time.Hour
 )
 
+// addHistoryScript pushes an entry to the history list of a channel (KEYS[1]) and its points
+// to the list of the points of the entries (KEYS[2]), whose sum is kept in KEYS[3]. The oldest
+// entries are dropped as long as the others have at least the maximum number of points.
+// The history is deleted first when ARGV[5] is 1, i.e. the schema changed.
+var addHistoryScript = redis.NewScript(`
+if ARGV[5] == '1' then
+	redis.call('DEL', KEYS[1], KEYS[2], KEYS[3])
+end
+local maxPoints = tonumber(ARGV[3])
+redis.call('RPUSH', KEYS[1], ARGV[1])
+redis.call('RPUSH', KEYS[2], ARGV[2])
+local points = tonumber(redis.call('INCRBY', KEYS[3], ARGV[2]))
+while true do
+	local first = tonumber(redis.call('LINDEX', KEYS[2], 0))
+	if not first or points - first < maxPoints then
+		break
+	end
+	redis.call('LPOP', KEYS[1])
+	redis.call('LPOP', KEYS[2])
+	points = tonumber(redis.call('DECRBY', KEYS[3], first))
+end
+-- entries pushed without their points are dropped
+redis.call('LTRIM', KEYS[1], -redis.call('LLEN', KEYS[2]), -1)
+for i = 1, 3 do
+	redis.call('PEXPIRE', KEYS[i], ARGV[4])
+end
+return points
+`)
+
 func (c *RedisFrameCache) Update(ctx context.Context, orgID int64, channel string, jsonFrame data.FrameJSONCache) (bool, error) {
 	c.mu.Lock()
 	if _, ok := c.frames[orgID]; !ok {
@@ -99,14 +150,45 @@ func (c *RedisFrameCache) Update(ctx context.Context, orgID int64, channel strin
 		if err != nil {
 			return false, err
 		}
-		if len(result) == 0 {
-			return true, nil
-		}
-		return result["schema"] != stringSchema, nil
+		schemaUpdated := len(result) == 0 || result["schema"] != stringSchema
+		return schemaUpdated, c.addHistory(ctx, orgID, channel, jsonFrame, schemaUpdated)
+	}
+	return true, c.addHistory(ctx, orgID, channel, jsonFrame, true)
+}
+
+// addHistory pushes a frame to the list of the history of a channel, the oldest
+// frames are dropped when the others have at least MaxPoints rows.
+func (c *RedisFrameCache) addHistory(ctx context.Context, orgID int64, channel string, jsonFrame data.FrameJSONCache, schemaUpdated bool) error {
+	if !c.historyConfig.enabled() {
+		return nil
+	}
+	historyEntry, err := newHistoryEntry(time.Now(), jsonFrame)
+	if err != nil {
+		return err
+	}
+	entry, err := json.Marshal(historyEntry)
+	if err != nil {
+		return err
 	}
-	return true, nil
+
+	ttl := c.historyConfig.MaxAge
+	if ttl == 0 {
+		ttl = frameCacheTTL
+	}
+
+	key := getHistoryKey(orgchannel.PrependOrgID(orgID, channel))
+	reset := 0
+	if schemaUpdated {
+		reset = 1
+	}
+	return addHistoryScript.Run(ctx, c.redisClient, []string{key, key + ".points", key + ".total"},
+		entry, historyEntry.points(), c.historyConfig.MaxPoints, ttl.Milliseconds(), reset).Err()
 }
 
 func getCacheKey(channelID string) string {
 	return "gf_live.managed_stream." + channelID
 }
+
+func getHistoryKey(channelID string) string {
+	return "gf_live.managed_stream_history." + channelID
+}
@@ -4,7 +4,9 @@
 package managedstream
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/require"
@@ -14,7 +16,18 @@ func TestRedisCacheStorage(t *testing.T) {
 	redisClient := redis.NewClient(&redis.Options{
 		Addr: "localhost:6379",
 	})
-	c := NewRedisFrameCache(redisClient)
+	c := NewRedisFrameCache(redisClient, HistoryConfig{})
 	require.NotNil(t, c)
 	testFrameCache(t, c)
 }
+
+func TestRedisCacheStorageHistory(t *testing.T) {
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	c := NewRedisFrameCache(redisClient, HistoryConfig{MaxPoints: 3, MaxAge: time.Minute})
+	require.NotNil(t, c)
+	key := getHistoryKey("1/history")
+	_ = redisClient.Del(context.Background(), key, key+".points", key+".total")
+	testFrameCacheHistory(t, c)
+}
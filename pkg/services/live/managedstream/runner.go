@@ -240,10 +240,18 @@ func (s *NamespaceStream) GetHandlerForPath(_ string) (models.ChannelHandler, er
 
 func (s *NamespaceStream) OnSubscribe(ctx context.Context, u *models.SignedInUser, e models.SubscribeEvent) (models.SubscribeReply, backend.SubscribeStreamStatus, error) {
 	reply := models.SubscribeReply{}
-	frameJSON, ok, err := s.frameCache.GetFrame(ctx, u.OrgId, e.Channel)
+	// Send the history of the channel so that late subscribers get the recent
+	// points, or the last frame when the history is disabled.
+	frameJSON, ok, err := s.frameCache.GetHistory(ctx, u.OrgId, e.Channel)
 	if err != nil {
 		return reply, 0, err
 	}
+	if !ok {
+		frameJSON, ok, err = s.frameCache.GetFrame(ctx, u.OrgId, e.Channel)
+		if err != nil {
+			return reply, 0, err
+		}
+	}
 	if ok {
 		reply.Data = frameJSON
 	}
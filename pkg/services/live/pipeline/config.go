@@ -34,11 +34,14 @@ type ChannelRule struct {
 }
 
 type ConverterConfig struct {
-	Type                      string                     `json:"type" ts_type:"Omit<keyof ConverterConfig, 'type'>"`
-	AutoJsonConverterConfig   *AutoJsonConverterConfig   `json:"jsonAuto,omitempty"`
-	ExactJsonConverterConfig  *ExactJsonConverterConfig  `json:"jsonExact,omitempty"`
-	AutoInfluxConverterConfig *AutoInfluxConverterConfig `json:"influxAuto,omitempty"`
-	JsonFrameConverterConfig  *JsonFrameConverterConfig  `json:"jsonFrame,omitempty"`
+	Type                          string                         `json:"type" ts_type:"Omit<keyof ConverterConfig, 'type'>"`
+	AutoJsonConverterConfig       *AutoJsonConverterConfig       `json:"jsonAuto,omitempty"`
+	ExactJsonConverterConfig      *ExactJsonConverterConfig      `json:"jsonExact,omitempty"`
+	AutoInfluxConverterConfig     *AutoInfluxConverterConfig     `json:"influxAuto,omitempty"`
+	JsonFrameConverterConfig      *JsonFrameConverterConfig      `json:"jsonFrame,omitempty"`
+	AutoPrometheusConverterConfig *AutoPrometheusConverterConfig `json:"prometheusAuto,omitempty"`
+	AutoCsvConverterConfig        *AutoCsvConverterConfig        `json:"csvAuto,omitempty"`
+	AutoOtlpConverterConfig       *AutoOtlpConverterConfig       `json:"otlpAuto,omitempty"`
 }
 
 type DropFieldsFrameProcessorConfig struct {
@@ -49,11 +52,33 @@ type KeepFieldsFrameProcessorConfig struct {
 	FieldNames []string `json:"fieldNames"`
 }
 
+// WindowAggregation describes a value computed for a field in each window.
+type WindowAggregation struct {
+	FieldName string        `json:"fieldName"`
+	Reducer   WindowReducer `json:"reducer"`
+	// Percentile from 0 to 100, used by percentile reducer.
+	Percentile float64 `json:"percentile,omitempty"`
+}
+
+// WindowFrameProcessorConfig ...
+type WindowFrameProcessorConfig struct {
+	// SizeMilliseconds is the size of the windows.
+	SizeMilliseconds int64 `json:"sizeMilliseconds"`
+	// SlideMilliseconds is the interval between the starts of sliding windows,
+	// size must be a multiple of it. Windows are tumbling when not set.
+	SlideMilliseconds int64 `json:"slideMilliseconds,omitempty"`
+	// LabelFields are the fields identifying the series aggregated separately,
+	// all string fields when not set.
+	LabelFields  []string            `json:"labelFields,omitempty"`
+	Aggregations []WindowAggregation `json:"aggregations"`
+}
+
 type FrameProcessorConfig struct {
 	Type                      string                          `json:"type" ts_type:"Omit<keyof FrameProcessorConfig, 'type'>"`
 	DropFieldsProcessorConfig *DropFieldsFrameProcessorConfig `json:"dropFields,omitempty"`
 	KeepFieldsProcessorConfig *KeepFieldsFrameProcessorConfig `json:"keepFields,omitempty"`
 	MultipleProcessorConfig   *MultipleFrameProcessorConfig   `json:"multiple,omitempty"`
+	WindowProcessorConfig     *WindowFrameProcessorConfig     `json:"window,omitempty"`
 }
 
 type MultipleFrameProcessorConfig struct {
@@ -151,4 +176,20 @@ type AutoInfluxConverterConfig struct {
 
 type JsonFrameConverterConfig struct{}
 
+type AutoPrometheusConverterConfig struct{}
+
+// AutoCsvConverterConfig ...
+type AutoCsvConverterConfig struct {
+	// FieldNames are the names of the columns, the first line is
+	// the header with the names of the columns when not set.
+	FieldNames []string `json:"fieldNames,omitempty"`
+	// TimeField is the column with the time of the lines (unix milliseconds
+	// or RFC3339), current time is used when not set.
+	TimeField string `json:"timeField,omitempty"`
+	// Delimiter is the separator of the values, comma when not set.
+	Delimiter string `json:"delimiter,omitempty"`
+}
+
+type AutoOtlpConverterConfig struct{}
+
 type ManagedStreamOutputConfig struct{}
@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// AutoCsvConverter decodes CSV lines into a single frame. Columns whose values
+// are all numbers become number fields, other columns become string fields.
+type AutoCsvConverter struct {
+	config      AutoCsvConverterConfig
+	nowTimeFunc func() time.Time
+}
+
+// NewAutoCsvConverter creates new AutoCsvConverter.
+func NewAutoCsvConverter(c AutoCsvConverterConfig) *AutoCsvConverter {
+	return &AutoCsvConverter{config: c}
+}
+
+const ConverterTypeCsvAuto = "csvAuto"
+
+func (c *AutoCsvConverter) Type() string {
+	return ConverterTypeCsvAuto
+}
+
+// Convert works this way:
+// * Field names are taken from config or from the header line when not set
+// * Time taken from the time field (unix milliseconds or RFC3339) or added automatically
+// * Empty values are nulls.
+func (c *AutoCsvConverter) Convert(_ context.Context, vars Vars, body []byte) ([]*ChannelFrame, error) {
+	nowTimeFunc := c.nowTimeFunc
+	if nowTimeFunc == nil {
+		nowTimeFunc = time.Now
+	}
+
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.TrimLeadingSpace = true
+	if c.config.Delimiter != "" {
+		delimiter, size := utf8.DecodeRuneInString(c.config.Delimiter)
+		if size != len(c.config.Delimiter) {
+			return nil, fmt.Errorf("invalid delimiter: %q", c.config.Delimiter)
+		}
+		reader.Comma = delimiter
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	fieldNames := c.config.FieldNames
+	if len(fieldNames) == 0 {
+		if len(records) == 0 {
+			return nil, fmt.Errorf("no header line")
+		}
+		fieldNames, records = records[0], records[1:]
+	}
+	if len(records) > 0 && len(records[0]) != len(fieldNames) {
+		return nil, fmt.Errorf("expected %d values per line, got %d", len(fieldNames), len(records[0]))
+	}
+
+	timeIndex := -1
+	if c.config.TimeField != "" {
+		for i, name := range fieldNames {
+			if name == c.config.TimeField {
+				timeIndex = i
+				break
+			}
+		}
+		if timeIndex < 0 {
+			return nil, fmt.Errorf("time field %s not found", c.config.TimeField)
+		}
+	}
+
+	fields := make([]*data.Field, 0, len(fieldNames)+1)
+	if timeIndex < 0 {
+		timeField := data.NewFieldFromFieldType(data.FieldTypeTime, len(records))
+		timeField.Name = "time"
+		now := nowTimeFunc()
+		for i := range records {
+			timeField.Set(i, now)
+		}
+		fields = append(fields, timeField)
+	}
+	for i, name := range fieldNames {
+		var field *data.Field
+		if i == timeIndex {
+			field, err = csvTimeField(name, records, i)
+		} else {
+			field = csvValueField(name, records, i)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+
+	return []*ChannelFrame{
+		{Channel: "", Frame: data.NewFrame(vars.Path, fields...)},
+	}, nil
+}
+
+func csvTimeField(name string, records [][]string, index int) (*data.Field, error) {
+	field := data.NewFieldFromFieldType(data.FieldTypeTime, len(records))
+	field.Name = name
+	for i, record := range records {
+		if ms, err := strconv.ParseInt(record[index], 10, 64); err == nil {
+			field.Set(i, time.Unix(0, ms*int64(time.Millisecond)))
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, record[index])
+		if err != nil {
+			return nil, fmt.Errorf("invalid time in row %d: %q", i+1, record[index])
+		}
+		field.Set(i, t)
+	}
+	return field, nil
+}
+
+func csvValueField(name string, records [][]string, index int) *data.Field {
+	numbers := make([]*float64, len(records))
+	isNumber := true
+	for i, record := range records {
+		if record[index] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(record[index], 64)
+		if err != nil {
+			isNumber = false
+			break
+		}
+		numbers[i] = &v
+	}
+	if isNumber {
+		return data.NewField(name, nil, numbers)
+	}
+
+	values := make([]*string, len(records))
+	for i, record := range records {
+		if record[index] != "" {
+			v := record[index]
+			values[i] = &v
+		}
+	}
+	return data.NewField(name, nil, values)
+}
@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoCsvConverter_Convert(t *testing.T) {
+	now := time.Date(2021, 01, 01, 12, 12, 12, 0, time.UTC)
+
+	t.Run("header line", func(t *testing.T) {
+		converter := NewAutoCsvConverter(AutoCsvConverterConfig{})
+		converter.nowTimeFunc = func() time.Time {
+			return now
+		}
+		channelFrames, err := converter.Convert(context.Background(), Vars{Path: "sensors"}, []byte("host,value,status\nhost1,1.5,ok\nhost2,,\n"))
+		require.NoError(t, err)
+		require.Len(t, channelFrames, 1)
+		require.Empty(t, channelFrames[0].Channel)
+
+		frame := channelFrames[0].Frame
+		require.Equal(t, "sensors", frame.Name)
+		require.Len(t, frame.Fields, 4)
+		require.Equal(t, "time", frame.Fields[0].Name)
+		require.Equal(t, now, frame.Fields[0].At(1))
+		require.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		require.Equal(t, "host2", *frame.Fields[1].At(1).(*string))
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[2].Type())
+		require.Equal(t, 1.5, *frame.Fields[2].At(0).(*float64))
+		require.Nil(t, frame.Fields[2].At(1))
+		require.Equal(t, data.FieldTypeNullableString, frame.Fields[3].Type())
+		require.Nil(t, frame.Fields[3].At(1))
+	})
+
+	t.Run("configured fields and time field", func(t *testing.T) {
+		converter := NewAutoCsvConverter(AutoCsvConverterConfig{
+			FieldNames: []string{"ts", "host", "value"},
+			TimeField:  "ts",
+			Delimiter:  ";",
+		})
+		channelFrames, err := converter.Convert(context.Background(), Vars{}, []byte("1609503132000;host1;1\n2021-01-01T12:12:13Z;host2;2\n"))
+		require.NoError(t, err)
+
+		frame := channelFrames[0].Frame
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, "ts", frame.Fields[0].Name)
+		require.Equal(t, time.Unix(1609503132, 0), frame.Fields[0].At(0))
+		require.True(t, now.Add(time.Second).Equal(frame.Fields[0].At(1).(time.Time)))
+		require.Equal(t, 2.0, *frame.Fields[2].At(1).(*float64))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := NewAutoCsvConverter(AutoCsvConverterConfig{}).Convert(context.Background(), Vars{}, nil)
+		require.EqualError(t, err, "no header line")
+
+		_, err = NewAutoCsvConverter(AutoCsvConverterConfig{TimeField: "ts"}).Convert(context.Background(), Vars{}, []byte("time,value\n1,2\n"))
+		require.EqualError(t, err, "time field ts not found")
+
+		_, err = NewAutoCsvConverter(AutoCsvConverterConfig{TimeField: "time"}).Convert(context.Background(), Vars{}, []byte("time,value\nnow,2\n"))
+		require.EqualError(t, err, `invalid time in row 1: "now"`)
+
+		_, err = NewAutoCsvConverter(AutoCsvConverterConfig{FieldNames: []string{"value"}}).Convert(context.Background(), Vars{}, []byte("1,2\n"))
+		require.EqualError(t, err, "expected 1 values per line, got 2")
+
+		_, err = NewAutoCsvConverter(AutoCsvConverterConfig{Delimiter: "::"}).Convert(context.Background(), Vars{}, []byte("1,2\n"))
+		require.EqualError(t, err, `invalid delimiter: "::"`)
+	})
+}
@@ -0,0 +1,289 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/model/otlp"
+	"go.opentelemetry.io/collector/model/pdata"
+	tracetranslator "go.opentelemetry.io/collector/translator/trace"
+)
+
+const (
+	// otlpDeltaSeriesTTL is the time after which the total of a delta series
+	// that was not updated is deleted.
+	otlpDeltaSeriesTTL = time.Hour
+	// otlpMaxDeltaSeries is the number of delta series a converter keeps
+	// the total of at most.
+	otlpMaxDeltaSeries = 10000
+	// otlpDeltaExpiryInterval is the interval the expired totals are deleted at.
+	otlpDeltaExpiryInterval = time.Minute
+)
+
+var errOtlpTooManyDeltaSeries = errors.New("too many OTLP series with delta temporality")
+
+// AutoOtlpConverter decodes OTLP/HTTP metrics (ExportMetricsServiceRequest in
+// protobuf or JSON encoding) and transforms them to several ChannelFrame objects
+// where Channel is constructed from original channel + / + <metric_name>.
+// Resource attributes and data point labels become string fields. Histograms and
+// summaries are split the same way as Prometheus ones. Sums and histograms with
+// delta temporality are added up to cumulative ones, like Prometheus expects them.
+type AutoOtlpConverter struct {
+	config      AutoOtlpConverterConfig
+	nowTimeFunc func() time.Time
+
+	mu sync.Mutex
+	// deltaTotals are the totals of the delta series by channel, metric and labels.
+	deltaTotals map[string]*otlpDeltaTotal
+	lastExpiry  time.Time
+}
+
+type otlpDeltaTotal struct {
+	value   float64
+	updated time.Time
+}
+
+// NewAutoOtlpConverter creates new AutoOtlpConverter.
+func NewAutoOtlpConverter(c AutoOtlpConverterConfig) *AutoOtlpConverter {
+	return &AutoOtlpConverter{config: c, deltaTotals: map[string]*otlpDeltaTotal{}}
+}
+
+const ConverterTypeOtlpAuto = "otlpAuto"
+
+func (c *AutoOtlpConverter) Type() string {
+	return ConverterTypeOtlpAuto
+}
+
+func (c *AutoOtlpConverter) Convert(_ context.Context, vars Vars, body []byte) ([]*ChannelFrame, error) {
+	nowTimeFunc := c.nowTimeFunc
+	if nowTimeFunc == nil {
+		nowTimeFunc = time.Now
+	}
+
+	var metrics pdata.Metrics
+	var err error
+	// A protobuf request starts with the tag of the resource metrics,
+	// so the body is JSON only when it starts with an object.
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		metrics, err = otlp.NewJSONMetricsUnmarshaler().UnmarshalMetrics(trimmed)
+	} else {
+		metrics, err = otlp.NewProtobufMetricsUnmarshaler().UnmarshalMetrics(body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := nowTimeFunc()
+	pointTime := func(ts pdata.Timestamp) time.Time {
+		if ts == 0 {
+			return now
+		}
+		return time.Unix(0, int64(ts))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleteExpiredDeltaTotals(now)
+
+	samples := newLabeledSamples()
+	// add adds a sample, the value of a delta sample is added to the total of its series.
+	add := func(name string, t time.Time, labels map[string]string, value float64, delta bool) error {
+		if delta {
+			var err error
+			if value, err = c.addDelta(vars.Channel, name, labels, value, now); err != nil {
+				return err
+			}
+		}
+		samples.add(name, t, labels, value)
+		return nil
+	}
+
+	resourceMetrics := metrics.ResourceMetrics()
+	for i := 0; i < resourceMetrics.Len(); i++ {
+		rm := resourceMetrics.At(i)
+		resourceLabels := otlpResourceLabels(rm.Resource())
+		libraryMetrics := rm.InstrumentationLibraryMetrics()
+		for j := 0; j < libraryMetrics.Len(); j++ {
+			ms := libraryMetrics.At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				m := ms.At(k)
+				var err error
+				switch m.DataType() {
+				case pdata.MetricDataTypeIntGauge:
+					err = addOtlpIntDataPoints(add, m.Name(), resourceLabels, m.IntGauge().DataPoints(), false, pointTime)
+				case pdata.MetricDataTypeIntSum:
+					delta := m.IntSum().AggregationTemporality() == pdata.AggregationTemporalityDelta
+					err = addOtlpIntDataPoints(add, m.Name(), resourceLabels, m.IntSum().DataPoints(), delta, pointTime)
+				case pdata.MetricDataTypeGauge:
+					err = addOtlpNumberDataPoints(add, m.Name(), resourceLabels, m.Gauge().DataPoints(), false, pointTime)
+				case pdata.MetricDataTypeSum:
+					delta := m.Sum().AggregationTemporality() == pdata.AggregationTemporalityDelta
+					err = addOtlpNumberDataPoints(add, m.Name(), resourceLabels, m.Sum().DataPoints(), delta, pointTime)
+				case pdata.MetricDataTypeHistogram:
+					delta := m.Histogram().AggregationTemporality() == pdata.AggregationTemporalityDelta
+					err = addOtlpHistogramDataPoints(add, m.Name(), resourceLabels, m.Histogram().DataPoints(), delta, pointTime)
+				case pdata.MetricDataTypeSummary:
+					err = addOtlpSummaryDataPoints(add, m.Name(), resourceLabels, m.Summary().DataPoints(), pointTime)
+				}
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return samples.channelFrames(vars.Channel), nil
+}
+
+type otlpAddFunc func(name string, t time.Time, labels map[string]string, value float64, delta bool) error
+
+func addOtlpIntDataPoints(add otlpAddFunc, name string, resourceLabels map[string]string, points pdata.IntDataPointSlice, delta bool, pointTime func(pdata.Timestamp) time.Time) error {
+	for i := 0; i < points.Len(); i++ {
+		p := points.At(i)
+		labels := otlpLabels(resourceLabels, p.LabelsMap())
+		if err := add(name, pointTime(p.Timestamp()), labels, float64(p.Value()), delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addOtlpNumberDataPoints(add otlpAddFunc, name string, resourceLabels map[string]string, points pdata.NumberDataPointSlice, delta bool, pointTime func(pdata.Timestamp) time.Time) error {
+	for i := 0; i < points.Len(); i++ {
+		p := points.At(i)
+		labels := otlpLabels(resourceLabels, p.LabelsMap())
+		value := p.DoubleVal()
+		if p.Type() == pdata.MetricValueTypeInt {
+			value = float64(p.IntVal())
+		}
+		if err := add(name, pointTime(p.Timestamp()), labels, value, delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addOtlpHistogramDataPoints(add otlpAddFunc, name string, resourceLabels map[string]string, points pdata.HistogramDataPointSlice, delta bool, pointTime func(pdata.Timestamp) time.Time) error {
+	for i := 0; i < points.Len(); i++ {
+		p := points.At(i)
+		t := pointTime(p.Timestamp())
+		labels := otlpLabels(resourceLabels, p.LabelsMap())
+		// OTLP bucket counts are not cumulative, the last bucket has no explicit bound.
+		bounds := p.ExplicitBounds()
+		var cumulativeCount uint64
+		for j, count := range p.BucketCounts() {
+			cumulativeCount += count
+			upperBound := math.Inf(1)
+			if j < len(bounds) {
+				upperBound = bounds[j]
+			}
+			if err := add(name+"_bucket", t, withLabel(labels, "le", formatFloatLabel(upperBound)), float64(cumulativeCount), delta); err != nil {
+				return err
+			}
+		}
+		if err := add(name+"_sum", t, labels, p.Sum(), delta); err != nil {
+			return err
+		}
+		if err := add(name+"_count", t, labels, float64(p.Count()), delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addOtlpSummaryDataPoints(add otlpAddFunc, name string, resourceLabels map[string]string, points pdata.SummaryDataPointSlice, pointTime func(pdata.Timestamp) time.Time) error {
+	for i := 0; i < points.Len(); i++ {
+		p := points.At(i)
+		t := pointTime(p.Timestamp())
+		labels := otlpLabels(resourceLabels, p.LabelsMap())
+		quantiles := p.QuantileValues()
+		for j := 0; j < quantiles.Len(); j++ {
+			q := quantiles.At(j)
+			if err := add(name, t, withLabel(labels, "quantile", formatFloatLabel(q.Quantile())), q.Value(), false); err != nil {
+				return err
+			}
+		}
+		if err := add(name+"_sum", t, labels, p.Sum(), false); err != nil {
+			return err
+		}
+		if err := add(name+"_count", t, labels, float64(p.Count()), false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addDelta adds the value to the total of the series and returns the total.
+func (c *AutoOtlpConverter) addDelta(channel string, name string, labels map[string]string, value float64, now time.Time) (float64, error) {
+	key := otlpDeltaKey(channel, name, labels)
+	total, ok := c.deltaTotals[key]
+	if !ok {
+		if len(c.deltaTotals) >= otlpMaxDeltaSeries {
+			return 0, errOtlpTooManyDeltaSeries
+		}
+		total = &otlpDeltaTotal{}
+		c.deltaTotals[key] = total
+	}
+	total.value += value
+	total.updated = now
+	return total.value, nil
+}
+
+func (c *AutoOtlpConverter) deleteExpiredDeltaTotals(now time.Time) {
+	if now.Sub(c.lastExpiry) < otlpDeltaExpiryInterval {
+		return
+	}
+	c.lastExpiry = now
+	for key, total := range c.deltaTotals {
+		if now.Sub(total.updated) >= otlpDeltaSeriesTTL {
+			delete(c.deltaTotals, key)
+		}
+	}
+}
+
+func otlpDeltaKey(channel string, name string, labels map[string]string) string {
+	labelNames := make([]string, 0, len(labels))
+	for label := range labels {
+		labelNames = append(labelNames, label)
+	}
+	sort.Strings(labelNames)
+	var sb strings.Builder
+	sb.WriteString(channel)
+	sb.WriteByte(0)
+	sb.WriteString(name)
+	for _, label := range labelNames {
+		sb.WriteByte(0)
+		sb.WriteString(label)
+		sb.WriteByte(0)
+		sb.WriteString(labels[label])
+	}
+	return sb.String()
+}
+
+func otlpResourceLabels(resource pdata.Resource) map[string]string {
+	labels := map[string]string{}
+	resource.Attributes().Range(func(k string, v pdata.AttributeValue) bool {
+		labels[k] = tracetranslator.AttributeValueToString(v)
+		return true
+	})
+	return labels
+}
+
+// otlpLabels returns the resource labels with the data point labels added,
+// the labels of data points take precedence over resource attributes.
+func otlpLabels(resourceLabels map[string]string, pointLabels pdata.StringMap) map[string]string {
+	l := make(map[string]string, len(resourceLabels)+pointLabels.Len())
+	for k, v := range resourceLabels {
+		l[k] = v
+	}
+	pointLabels.Range(func(k, v string) bool {
+		l[k] = v
+		return true
+	})
+	return l
+}
@@ -0,0 +1,218 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/model/otlp"
+	"go.opentelemetry.io/collector/model/pdata"
+)
+
+const otlpJsonInput = `{
+  "resourceMetrics": [{
+    "resource": {
+      "attributes": [{"key": "service.name", "value": {"stringValue": "sensor"}}]
+    },
+    "instrumentationLibraryMetrics": [{
+      "metrics": [{
+        "name": "temperature",
+        "gauge": {
+          "dataPoints": [
+            {"labels": [{"key": "room", "value": "kitchen"}], "timeUnixNano": "1609503132000000000", "asDouble": 21.5},
+            {"labels": [{"key": "floor", "value": "2"}], "asInt": "19"}
+          ]
+        }
+      }, {
+        "name": "request.duration",
+        "histogram": {
+          "dataPoints": [{
+            "timeUnixNano": "1609503132000000000",
+            "count": "6",
+            "sum": 3.5,
+            "bucketCounts": ["2", "3", "1"],
+            "explicitBounds": [0.1, 1]
+          }]
+        }
+      }, {
+        "name": "rpc.duration",
+        "summary": {
+          "dataPoints": [{
+            "timeUnixNano": "1609503132000000000",
+            "count": "30",
+            "sum": 17,
+            "quantileValues": [{"quantile": 0.5, "value": 0.2}]
+          }]
+        }
+      }]
+    }]
+  }]
+}`
+
+func TestAutoOtlpConverter_Convert_Json(t *testing.T) {
+	now := time.Date(2021, 01, 01, 12, 12, 12, 0, time.UTC)
+	converter := NewAutoOtlpConverter(AutoOtlpConverterConfig{})
+	converter.nowTimeFunc = func() time.Time {
+		return now
+	}
+	channelFrames, err := converter.Convert(context.Background(), Vars{Channel: "stream/test/otlp"}, []byte(otlpJsonInput))
+	require.NoError(t, err)
+
+	channels := make([]string, 0, len(channelFrames))
+	for _, cf := range channelFrames {
+		channels = append(channels, cf.Channel)
+	}
+	require.Equal(t, []string{
+		"stream/test/otlp/temperature",
+		"stream/test/otlp/request.duration_bucket",
+		"stream/test/otlp/request.duration_sum",
+		"stream/test/otlp/request.duration_count",
+		"stream/test/otlp/rpc.duration",
+		"stream/test/otlp/rpc.duration_sum",
+		"stream/test/otlp/rpc.duration_count",
+	}, channels)
+
+	gauge := channelFrames[0].Frame
+	require.Len(t, gauge.Fields, 5)
+	require.Equal(t, "time", gauge.Fields[0].Name)
+	require.Equal(t, "floor", gauge.Fields[1].Name)
+	require.Equal(t, "room", gauge.Fields[2].Name)
+	require.Equal(t, "service.name", gauge.Fields[3].Name)
+	require.Equal(t, "value", gauge.Fields[4].Name)
+	require.Equal(t, time.Unix(1609503132, 0), gauge.Fields[0].At(0))
+	require.Equal(t, now, gauge.Fields[0].At(1))
+	require.Equal(t, "", gauge.Fields[1].At(0))
+	require.Equal(t, "2", gauge.Fields[1].At(1))
+	require.Equal(t, "kitchen", gauge.Fields[2].At(0))
+	require.Equal(t, "sensor", gauge.Fields[3].At(1))
+	require.Equal(t, 21.5, gauge.Fields[4].At(0))
+	require.Equal(t, 19.0, gauge.Fields[4].At(1))
+
+	buckets := channelFrames[1].Frame
+	require.Equal(t, "le", buckets.Fields[1].Name)
+	require.Equal(t, 3, buckets.Rows())
+	require.Equal(t, []interface{}{"0.1", "1", "+Inf"}, []interface{}{buckets.Fields[1].At(0), buckets.Fields[1].At(1), buckets.Fields[1].At(2)})
+	require.Equal(t, []interface{}{2.0, 5.0, 6.0}, []interface{}{buckets.Fields[3].At(0), buckets.Fields[3].At(1), buckets.Fields[3].At(2)})
+	require.Equal(t, 3.5, channelFrames[2].Frame.Fields[2].At(0))
+	require.Equal(t, 6.0, channelFrames[3].Frame.Fields[2].At(0))
+
+	quantiles := channelFrames[4].Frame
+	require.Equal(t, "quantile", quantiles.Fields[1].Name)
+	require.Equal(t, "0.5", quantiles.Fields[1].At(0))
+	require.Equal(t, 0.2, quantiles.Fields[3].At(0))
+}
+
+func TestAutoOtlpConverter_Convert_Protobuf(t *testing.T) {
+	metrics := pdata.NewMetrics()
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().InsertString("service.name", "sensor")
+	ms := rm.InstrumentationLibraryMetrics().AppendEmpty().Metrics()
+
+	gauge := ms.AppendEmpty()
+	gauge.SetName("temperature")
+	gauge.SetDataType(pdata.MetricDataTypeGauge)
+	gaugePoint := gauge.Gauge().DataPoints().AppendEmpty()
+	gaugePoint.LabelsMap().Insert("room", "kitchen")
+	gaugePoint.SetTimestamp(pdata.Timestamp(1609503132000000000))
+	gaugePoint.SetDoubleVal(21.5)
+
+	histogram := ms.AppendEmpty()
+	histogram.SetName("duration")
+	histogram.SetDataType(pdata.MetricDataTypeHistogram)
+	histogram.Histogram().SetAggregationTemporality(pdata.AggregationTemporalityCumulative)
+	histogramPoint := histogram.Histogram().DataPoints().AppendEmpty()
+	histogramPoint.SetTimestamp(pdata.Timestamp(1609503132000000000))
+	histogramPoint.SetCount(3)
+	histogramPoint.SetSum(1.2)
+	histogramPoint.SetBucketCounts([]uint64{2, 1})
+	histogramPoint.SetExplicitBounds([]float64{0.1})
+
+	body, err := otlp.NewProtobufMetricsMarshaler().MarshalMetrics(metrics)
+	require.NoError(t, err)
+
+	converter := NewAutoOtlpConverter(AutoOtlpConverterConfig{})
+	channelFrames, err := converter.Convert(context.Background(), Vars{Channel: "stream/test/otlp"}, body)
+	require.NoError(t, err)
+	require.Len(t, channelFrames, 4)
+
+	gaugeFrame := channelFrames[0].Frame
+	require.Equal(t, "stream/test/otlp/temperature", channelFrames[0].Channel)
+	require.Len(t, gaugeFrame.Fields, 4)
+	require.Equal(t, time.Unix(1609503132, 0), gaugeFrame.Fields[0].At(0))
+	require.Equal(t, "room", gaugeFrame.Fields[1].Name)
+	require.Equal(t, "kitchen", gaugeFrame.Fields[1].At(0))
+	require.Equal(t, "service.name", gaugeFrame.Fields[2].Name)
+	require.Equal(t, "sensor", gaugeFrame.Fields[2].At(0))
+	require.Equal(t, 21.5, gaugeFrame.Fields[3].At(0))
+
+	buckets := channelFrames[1].Frame
+	require.Equal(t, "stream/test/otlp/duration_bucket", channelFrames[1].Channel)
+	require.Equal(t, 2, buckets.Rows())
+	require.Equal(t, "+Inf", buckets.Fields[1].At(1))
+	require.Equal(t, 3.0, buckets.Fields[3].At(1))
+	require.Equal(t, 1.2, channelFrames[2].Frame.Fields[2].At(0))
+
+	_, err = converter.Convert(context.Background(), Vars{Channel: "stream/test/otlp"}, []byte{0x0a, 0xff})
+	require.Error(t, err)
+}
+
+func TestAutoOtlpConverter_Convert_DeltaTemporality(t *testing.T) {
+	request := func(value float64, bucketCounts []uint64) []byte {
+		metrics := pdata.NewMetrics()
+		ms := metrics.ResourceMetrics().AppendEmpty().InstrumentationLibraryMetrics().AppendEmpty().Metrics()
+
+		sum := ms.AppendEmpty()
+		sum.SetName("requests")
+		sum.SetDataType(pdata.MetricDataTypeSum)
+		sum.Sum().SetAggregationTemporality(pdata.AggregationTemporalityDelta)
+		sum.Sum().SetIsMonotonic(true)
+		sumPoint := sum.Sum().DataPoints().AppendEmpty()
+		sumPoint.LabelsMap().Insert("code", "200")
+		sumPoint.SetDoubleVal(value)
+
+		histogram := ms.AppendEmpty()
+		histogram.SetName("duration")
+		histogram.SetDataType(pdata.MetricDataTypeHistogram)
+		histogram.Histogram().SetAggregationTemporality(pdata.AggregationTemporalityDelta)
+		histogramPoint := histogram.Histogram().DataPoints().AppendEmpty()
+		histogramPoint.SetCount(bucketCounts[0] + bucketCounts[1])
+		histogramPoint.SetBucketCounts(bucketCounts)
+		histogramPoint.SetExplicitBounds([]float64{1})
+
+		body, err := otlp.NewProtobufMetricsMarshaler().MarshalMetrics(metrics)
+		require.NoError(t, err)
+		return body
+	}
+	values := func(channelFrames []*ChannelFrame, channel string) []interface{} {
+		for _, cf := range channelFrames {
+			if cf.Channel == channel {
+				valueField := cf.Frame.Fields[len(cf.Frame.Fields)-1]
+				result := make([]interface{}, 0, valueField.Len())
+				for i := 0; i < valueField.Len(); i++ {
+					result = append(result, valueField.At(i))
+				}
+				return result
+			}
+		}
+		return nil
+	}
+
+	converter := NewAutoOtlpConverter(AutoOtlpConverterConfig{})
+	channelFrames, err := converter.Convert(context.Background(), Vars{Channel: "stream/test/otlp"}, request(3, []uint64{1, 2}))
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{3.0}, values(channelFrames, "stream/test/otlp/requests"))
+	require.Equal(t, []interface{}{1.0, 3.0}, values(channelFrames, "stream/test/otlp/duration_bucket"))
+
+	// Delta points are added to the previous ones.
+	channelFrames, err = converter.Convert(context.Background(), Vars{Channel: "stream/test/otlp"}, request(2, []uint64{0, 1}))
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{5.0}, values(channelFrames, "stream/test/otlp/requests"))
+	require.Equal(t, []interface{}{1.0, 4.0}, values(channelFrames, "stream/test/otlp/duration_bucket"))
+	require.Equal(t, []interface{}{4.0}, values(channelFrames, "stream/test/otlp/duration_count"))
+
+	// Totals are kept by channel.
+	channelFrames, err = converter.Convert(context.Background(), Vars{Channel: "stream/test/other"}, request(2, []uint64{0, 1}))
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{2.0}, values(channelFrames, "stream/test/other/requests"))
+}
@@ -0,0 +1,105 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// AutoPrometheusConverter decodes Prometheus text exposition format input and
+// transforms it to several ChannelFrame objects where Channel is constructed
+// from original channel + / + <metric_name>. Histograms and summaries are
+// split into <metric_name>_bucket (or <metric_name> with quantile field),
+// <metric_name>_sum and <metric_name>_count.
+type AutoPrometheusConverter struct {
+	config      AutoPrometheusConverterConfig
+	nowTimeFunc func() time.Time
+}
+
+// NewAutoPrometheusConverter creates new AutoPrometheusConverter.
+func NewAutoPrometheusConverter(c AutoPrometheusConverterConfig) *AutoPrometheusConverter {
+	return &AutoPrometheusConverter{config: c}
+}
+
+const ConverterTypePrometheusAuto = "prometheusAuto"
+
+func (c *AutoPrometheusConverter) Type() string {
+	return ConverterTypePrometheusAuto
+}
+
+func (c *AutoPrometheusConverter) Convert(_ context.Context, vars Vars, body []byte) ([]*ChannelFrame, error) {
+	nowTimeFunc := c.nowTimeFunc
+	if nowTimeFunc == nil {
+		nowTimeFunc = time.Now
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := nowTimeFunc()
+	samples := newLabeledSamples()
+	for _, name := range names {
+		family := families[name]
+		for _, m := range family.GetMetric() {
+			t := now
+			if m.TimestampMs != nil {
+				t = time.Unix(0, m.GetTimestampMs()*int64(time.Millisecond))
+			}
+			labels := make(map[string]string, len(m.GetLabel()))
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				samples.add(name, t, labels, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				samples.add(name, t, labels, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				samples.add(name, t, labels, m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				for _, q := range m.GetSummary().GetQuantile() {
+					samples.add(name, t, withLabel(labels, "quantile", formatFloatLabel(q.GetQuantile())), q.GetValue())
+				}
+				samples.add(name+"_sum", t, labels, m.GetSummary().GetSampleSum())
+				samples.add(name+"_count", t, labels, float64(m.GetSummary().GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				for _, b := range m.GetHistogram().GetBucket() {
+					samples.add(name+"_bucket", t, withLabel(labels, "le", formatFloatLabel(b.GetUpperBound())), float64(b.GetCumulativeCount()))
+				}
+				samples.add(name+"_sum", t, labels, m.GetHistogram().GetSampleSum())
+				samples.add(name+"_count", t, labels, float64(m.GetHistogram().GetSampleCount()))
+			}
+		}
+	}
+	return samples.channelFrames(vars.Channel), nil
+}
+
+// withLabel returns a copy of labels with one more label.
+func withLabel(labels map[string]string, name, value string) map[string]string {
+	l := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		l[k] = v
+	}
+	l[name] = value
+	return l
+}
+
+// formatFloatLabel formats the bounds of the buckets and the quantiles
+// the way Prometheus does, e.g. 0.5 or +Inf.
+func formatFloatLabel(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
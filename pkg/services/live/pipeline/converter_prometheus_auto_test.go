@@ -0,0 +1,91 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const prometheusTextInput = `# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="post",code="400"} 3 1395066363000
+# TYPE temperature gauge
+temperature 21.5
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 2
+request_duration_seconds_bucket{le="1"} 5
+request_duration_seconds_bucket{le="+Inf"} 6
+request_duration_seconds_sum 3.5
+request_duration_seconds_count 6
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{service="api",quantile="0.5"} 0.2
+rpc_duration_seconds{service="api",quantile="0.99"} 0.9
+rpc_duration_seconds_sum{service="api"} 17
+rpc_duration_seconds_count{service="api"} 30
+`
+
+func TestAutoPrometheusConverter_Convert(t *testing.T) {
+	now := time.Date(2021, 01, 01, 12, 12, 12, 0, time.UTC)
+	converter := NewAutoPrometheusConverter(AutoPrometheusConverterConfig{})
+	converter.nowTimeFunc = func() time.Time {
+		return now
+	}
+	channelFrames, err := converter.Convert(context.Background(), Vars{Channel: "stream/test/prom"}, []byte(prometheusTextInput))
+	require.NoError(t, err)
+
+	channels := make([]string, 0, len(channelFrames))
+	for _, cf := range channelFrames {
+		channels = append(channels, cf.Channel)
+	}
+	require.Equal(t, []string{
+		"stream/test/prom/http_requests_total",
+		"stream/test/prom/request_duration_seconds_bucket",
+		"stream/test/prom/request_duration_seconds_sum",
+		"stream/test/prom/request_duration_seconds_count",
+		"stream/test/prom/rpc_duration_seconds",
+		"stream/test/prom/rpc_duration_seconds_sum",
+		"stream/test/prom/rpc_duration_seconds_count",
+		"stream/test/prom/temperature",
+	}, channels)
+
+	counter := channelFrames[0].Frame
+	require.Equal(t, "http_requests_total", counter.Name)
+	require.Len(t, counter.Fields, 4)
+	require.Equal(t, "time", counter.Fields[0].Name)
+	require.Equal(t, "code", counter.Fields[1].Name)
+	require.Equal(t, "method", counter.Fields[2].Name)
+	require.Equal(t, "value", counter.Fields[3].Name)
+	require.Equal(t, 2, counter.Rows())
+	require.Equal(t, time.Unix(1395066363, 0), counter.Fields[0].At(0))
+	require.Equal(t, "400", counter.Fields[1].At(1))
+	require.Equal(t, "post", counter.Fields[2].At(1))
+	require.Equal(t, 3.0, counter.Fields[3].At(1))
+
+	buckets := channelFrames[1].Frame
+	require.Equal(t, "le", buckets.Fields[1].Name)
+	require.Equal(t, 3, buckets.Rows())
+	require.Equal(t, "+Inf", buckets.Fields[1].At(2))
+	require.Equal(t, 6.0, buckets.Fields[2].At(2))
+	require.Equal(t, 3.5, channelFrames[2].Frame.Fields[1].At(0))
+
+	quantiles := channelFrames[4].Frame
+	require.Equal(t, "quantile", quantiles.Fields[1].Name)
+	require.Equal(t, "service", quantiles.Fields[2].Name)
+	require.Equal(t, "0.99", quantiles.Fields[1].At(1))
+	require.Equal(t, 0.9, quantiles.Fields[3].At(1))
+	require.Equal(t, 30.0, channelFrames[6].Frame.Fields[2].At(0))
+
+	gauge := channelFrames[7].Frame
+	require.Len(t, gauge.Fields, 2)
+	require.Equal(t, now, gauge.Fields[0].At(0))
+	require.Equal(t, 21.5, gauge.Fields[1].At(0))
+}
+
+func TestAutoPrometheusConverter_Convert_Error(t *testing.T) {
+	converter := NewAutoPrometheusConverter(AutoPrometheusConverterConfig{})
+	_, err := converter.Convert(context.Background(), Vars{Channel: "stream/test/prom"}, []byte("metric{label=} 1\n"))
+	require.Error(t, err)
+}
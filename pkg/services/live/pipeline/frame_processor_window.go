@@ -0,0 +1,345 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// WindowFrameProcessor aggregates frames over tumbling or sliding windows,
+// separately for each label set. Windows are closed by time of incoming rows:
+// when a row belongs to a bucket after the end of a window the processor emits
+// a frame with a row per window and label set, until then frames are dropped.
+// Rows arriving after the windows they belong to were emitted are dropped.
+type WindowFrameProcessor struct {
+	storage WindowStorage
+	config  WindowFrameProcessorConfig
+	size    int64
+	slide   int64
+}
+
+// NewWindowFrameProcessor creates new WindowFrameProcessor. Samples are kept in
+// the storage, which must be shared between Grafana instances in HA setup.
+func NewWindowFrameProcessor(storage WindowStorage, config WindowFrameProcessorConfig) (*WindowFrameProcessor, error) {
+	size, slide := config.SizeMilliseconds, config.SlideMilliseconds
+	if slide == 0 {
+		slide = size
+	}
+	if size <= 0 || slide <= 0 {
+		return nil, errors.New("window size and slide must be positive")
+	}
+	if size%slide != 0 {
+		return nil, errors.New("window size must be a multiple of slide")
+	}
+	if size > windowStorageTTL.Milliseconds() {
+		return nil, fmt.Errorf("window size must be at most %s", windowStorageTTL)
+	}
+	if len(config.Aggregations) == 0 {
+		return nil, errors.New("no aggregations")
+	}
+	for _, a := range config.Aggregations {
+		switch a.Reducer {
+		case WindowReducerMean, WindowReducerMin, WindowReducerMax, WindowReducerLast, WindowReducerCount:
+		case WindowReducerPercentile:
+			if a.Percentile < 0 || a.Percentile > 100 {
+				return nil, fmt.Errorf("invalid percentile: %v", a.Percentile)
+			}
+		default:
+			return nil, fmt.Errorf("unknown reducer: %s", a.Reducer)
+		}
+	}
+	return &WindowFrameProcessor{storage: storage, config: config, size: size, slide: slide}, nil
+}
+
+const FrameProcessorTypeWindow = "window"
+
+func (p *WindowFrameProcessor) Type() string {
+	return FrameProcessorTypeWindow
+}
+
+func (p *WindowFrameProcessor) ProcessFrame(ctx context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	buckets, minBucket, maxBucket, err := p.frameToBuckets(frame)
+	if err != nil {
+		return nil, err
+	}
+	if len(buckets) == 0 {
+		return nil, nil
+	}
+
+	key := orgchannel.PrependOrgID(vars.OrgID, vars.Channel) + "." + strconv.FormatInt(p.size, 10) + "." + strconv.FormatInt(p.slide, 10)
+	if err := p.storage.AddSamples(ctx, key, buckets); err != nil {
+		return nil, err
+	}
+
+	// The window [end-size, end) is closed once a row of the bucket starting
+	// at end is received. Only the instance which advanced the watermark emits
+	// the windows closed by this advance.
+	previous, ok, err := p.storage.AdvanceWatermark(ctx, key, maxBucket)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if previous == 0 {
+		previous = minBucket
+	}
+
+	samples, err := p.storage.GetSamples(ctx, key, previous+p.slide-p.size, maxBucket)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.storage.DeleteSamples(ctx, key, maxBucket+p.slide-p.size); err != nil {
+		return nil, err
+	}
+
+	// Only the closed windows which contain a non-empty bucket are visited, the
+	// watermark may jump over any number of empty windows.
+	endSet := map[int64]struct{}{}
+	for bucket, bucketSamples := range samples {
+		if len(bucketSamples) == 0 {
+			continue
+		}
+		for end := bucket + p.slide; end <= bucket+p.size; end += p.slide {
+			if end > previous && end <= maxBucket {
+				endSet[end] = struct{}{}
+			}
+		}
+	}
+	ends := make([]int64, 0, len(endSet))
+	for end := range endSet {
+		ends = append(ends, end)
+	}
+	sort.Slice(ends, func(i, j int) bool { return ends[i] < ends[j] })
+
+	windows := make([]windowRows, 0, len(ends))
+	for _, end := range ends {
+		var windowSamples []WindowSample
+		for bucket := end - p.size; bucket < end; bucket += p.slide {
+			windowSamples = append(windowSamples, samples[bucket]...)
+		}
+		windows = append(windows, windowRows{start: end - p.size, samples: windowSamples})
+	}
+	if len(windows) == 0 {
+		return nil, nil
+	}
+	return p.windowsToFrame(frame.Name, windows), nil
+}
+
+// frameToBuckets converts frame rows to samples grouped by bucket. Time is
+// taken from the first time field of the frame, current time is used when
+// there is no time field.
+func (p *WindowFrameProcessor) frameToBuckets(frame *data.Frame) (map[int64][]WindowSample, int64, int64, error) {
+	var timeField *data.Field
+	var labelFields []*data.Field
+	for _, f := range frame.Fields {
+		switch f.Type() {
+		case data.FieldTypeTime, data.FieldTypeNullableTime:
+			if timeField == nil {
+				timeField = f
+			}
+		case data.FieldTypeString, data.FieldTypeNullableString:
+			if len(p.config.LabelFields) == 0 || stringInSlice(f.Name, p.config.LabelFields) {
+				labelFields = append(labelFields, f)
+			}
+		}
+	}
+
+	valueFields := map[string]*data.Field{}
+	for _, a := range p.config.Aggregations {
+		for _, f := range frame.Fields {
+			if f.Name == a.FieldName {
+				if !f.Type().Numeric() {
+					return nil, 0, 0, fmt.Errorf("field %s is not a number", f.Name)
+				}
+				valueFields[f.Name] = f
+			}
+		}
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	buckets := map[int64][]WindowSample{}
+	minBucket, maxBucket := int64(math.MaxInt64), int64(math.MinInt64)
+	for i := 0; i < frame.Rows(); i++ {
+		sample := WindowSample{Time: now}
+		if timeField != nil {
+			t, ok := timeField.ConcreteAt(i)
+			if !ok {
+				continue
+			}
+			sample.Time = t.(time.Time).UnixNano() / int64(time.Millisecond)
+		}
+		if len(labelFields) > 0 {
+			sample.Labels = make(map[string]string, len(labelFields))
+			for _, f := range labelFields {
+				if v, ok := f.ConcreteAt(i); ok {
+					sample.Labels[f.Name] = v.(string)
+				}
+			}
+		}
+		sample.Values = make(map[string]float64, len(valueFields))
+		for name, f := range valueFields {
+			v, err := f.NullableFloatAt(i)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			if v != nil && !math.IsNaN(*v) {
+				sample.Values[name] = *v
+			}
+		}
+
+		bucket := sample.Time - mod(sample.Time, p.slide)
+		buckets[bucket] = append(buckets[bucket], sample)
+		if bucket < minBucket {
+			minBucket = bucket
+		}
+		if bucket > maxBucket {
+			maxBucket = bucket
+		}
+	}
+	return buckets, minBucket, maxBucket, nil
+}
+
+func mod(a, b int64) int64 {
+	m := a % b
+	if m < 0 {
+		m += b
+	}
+	return m
+}
+
+type windowRows struct {
+	start   int64
+	samples []WindowSample
+}
+
+// windowsToFrame returns a frame with a row per window and label set. The frame
+// has time field (start of a window), a string field per label and a field per
+// aggregation named <field>_<reducer>, e.g. value_mean or value_p99.
+func (p *WindowFrameProcessor) windowsToFrame(name string, windows []windowRows) *data.Frame {
+	var labelNames []string
+	seen := map[string]struct{}{}
+	for _, w := range windows {
+		for _, s := range w.samples {
+			for label := range s.Labels {
+				if _, ok := seen[label]; !ok {
+					seen[label] = struct{}{}
+					labelNames = append(labelNames, label)
+				}
+			}
+		}
+	}
+	sort.Strings(labelNames)
+
+	timeField := data.NewFieldFromFieldType(data.FieldTypeTime, 0)
+	timeField.Name = "time"
+	labelFields := make([]*data.Field, len(labelNames))
+	for i, label := range labelNames {
+		labelFields[i] = data.NewFieldFromFieldType(data.FieldTypeString, 0)
+		labelFields[i].Name = label
+	}
+	valueFields := make([]*data.Field, len(p.config.Aggregations))
+	for i, a := range p.config.Aggregations {
+		valueFields[i] = data.NewFieldFromFieldType(data.FieldTypeNullableFloat64, 0)
+		valueFields[i].Name = a.fieldName()
+	}
+
+	for _, w := range windows {
+		series := map[string][]WindowSample{}
+		var seriesKeys []string
+		for _, s := range w.samples {
+			key := seriesKey(labelNames, s.Labels)
+			if _, ok := series[key]; !ok {
+				seriesKeys = append(seriesKeys, key)
+			}
+			series[key] = append(series[key], s)
+		}
+		sort.Strings(seriesKeys)
+
+		for _, key := range seriesKeys {
+			samples := series[key]
+			// Stable sort keeps the order of rows with the same time for last reducer.
+			sort.SliceStable(samples, func(i, j int) bool {
+				return samples[i].Time < samples[j].Time
+			})
+			timeField.Append(time.Unix(0, w.start*int64(time.Millisecond)))
+			for i, label := range labelNames {
+				labelFields[i].Append(samples[0].Labels[label])
+			}
+			for i, a := range p.config.Aggregations {
+				valueFields[i].Append(a.reduce(samples))
+			}
+		}
+	}
+
+	fields := make([]*data.Field, 0, len(labelFields)+len(valueFields)+1)
+	fields = append(fields, timeField)
+	fields = append(fields, labelFields...)
+	fields = append(fields, valueFields...)
+	return data.NewFrame(name, fields...)
+}
+
+func seriesKey(labelNames []string, labels map[string]string) string {
+	var sb strings.Builder
+	for _, label := range labelNames {
+		sb.WriteString(labels[label])
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
+
+func (a WindowAggregation) fieldName() string {
+	if a.Reducer == WindowReducerPercentile {
+		return a.FieldName + "_p" + strconv.FormatFloat(a.Percentile, 'g', -1, 64)
+	}
+	return a.FieldName + "_" + string(a.Reducer)
+}
+
+// reduce aggregates the values of samples sorted by time,
+// returns nil if there are no values (except for count).
+func (a WindowAggregation) reduce(samples []WindowSample) *float64 {
+	values := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		if v, ok := s.Values[a.FieldName]; ok {
+			values = append(values, v)
+		}
+	}
+	if a.Reducer == WindowReducerCount {
+		count := float64(len(values))
+		return &count
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	var result float64
+	switch a.Reducer {
+	case WindowReducerMean:
+		for _, v := range values {
+			result += v
+		}
+		result /= float64(len(values))
+	case WindowReducerMin:
+		result = values[0]
+		for _, v := range values {
+			result = math.Min(result, v)
+		}
+	case WindowReducerMax:
+		result = values[0]
+		for _, v := range values {
+			result = math.Max(result, v)
+		}
+	case WindowReducerLast:
+		result = values[len(values)-1]
+	case WindowReducerPercentile:
+		result = util.Percentile(values, a.Percentile)
+	}
+	return &result
+}
@@ -0,0 +1,230 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func windowTestFrame(start time.Time, offsets []time.Duration, hosts []string, values []float64) *data.Frame {
+	times := make([]time.Time, len(offsets))
+	for i, offset := range offsets {
+		times[i] = start.Add(offset)
+	}
+	return data.NewFrame("test",
+		data.NewField("time", nil, times),
+		data.NewField("host", nil, hosts),
+		data.NewField("value", nil, values),
+	)
+}
+
+func TestWindowFrameProcessor_Tumbling(t *testing.T) {
+	start := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	p, err := NewWindowFrameProcessor(NewMemoryWindowStorage(), WindowFrameProcessorConfig{
+		SizeMilliseconds: 1000,
+		Aggregations: []WindowAggregation{
+			{FieldName: "value", Reducer: WindowReducerMean},
+			{FieldName: "value", Reducer: WindowReducerMin},
+			{FieldName: "value", Reducer: WindowReducerMax},
+			{FieldName: "value", Reducer: WindowReducerLast},
+			{FieldName: "value", Reducer: WindowReducerCount},
+			{FieldName: "value", Reducer: WindowReducerPercentile, Percentile: 50},
+		},
+	})
+	require.NoError(t, err)
+	vars := Vars{OrgID: 1, Channel: "stream/test/window"}
+
+	frame, err := p.ProcessFrame(context.Background(), vars, windowTestFrame(start,
+		[]time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond},
+		[]string{"a", "a", "a", "b"},
+		[]float64{1, 3, 2, 10},
+	))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	frame, err = p.ProcessFrame(context.Background(), vars, windowTestFrame(start,
+		[]time.Duration{1100 * time.Millisecond},
+		[]string{"a"},
+		[]float64{5},
+	))
+	require.NoError(t, err)
+	require.NotNil(t, frame)
+	require.Equal(t, "test", frame.Name)
+	require.Equal(t, 2, frame.Rows())
+
+	names := make([]string, 0, len(frame.Fields))
+	for _, f := range frame.Fields {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"time", "host", "value_mean", "value_min", "value_max", "value_last", "value_count", "value_p50"}, names)
+
+	require.True(t, start.Equal(frame.Fields[0].At(0).(time.Time)))
+	require.Equal(t, "a", frame.Fields[1].At(0))
+	expected := []float64{2, 1, 3, 2, 3, 2}
+	for i, v := range expected {
+		require.Equal(t, v, *frame.Fields[i+2].At(0).(*float64), frame.Fields[i+2].Name)
+	}
+	require.Equal(t, "b", frame.Fields[1].At(1))
+	require.Equal(t, 10.0, *frame.Fields[2].At(1).(*float64))
+
+	// Late rows of the emitted window are dropped.
+	frame, err = p.ProcessFrame(context.Background(), vars, windowTestFrame(start,
+		[]time.Duration{500 * time.Millisecond},
+		[]string{"a"},
+		[]float64{100},
+	))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	frame, err = p.ProcessFrame(context.Background(), vars, windowTestFrame(start,
+		[]time.Duration{2500 * time.Millisecond},
+		[]string{"a"},
+		[]float64{7},
+	))
+	require.NoError(t, err)
+	require.Equal(t, 1, frame.Rows())
+	require.True(t, start.Add(time.Second).Equal(frame.Fields[0].At(0).(time.Time)))
+	require.Equal(t, 5.0, *frame.Fields[2].At(0).(*float64))
+}
+
+func TestWindowFrameProcessor_Sliding(t *testing.T) {
+	start := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	p, err := NewWindowFrameProcessor(NewMemoryWindowStorage(), WindowFrameProcessorConfig{
+		SizeMilliseconds:  2000,
+		SlideMilliseconds: 1000,
+		LabelFields:       []string{"unknown"},
+		Aggregations: []WindowAggregation{
+			{FieldName: "value", Reducer: WindowReducerCount},
+			{FieldName: "value", Reducer: WindowReducerMax},
+		},
+	})
+	require.NoError(t, err)
+	vars := Vars{OrgID: 1, Channel: "stream/test/window"}
+
+	// Windows ending at 1s, 2s and 3s are closed by the row at 3.5s.
+	frame, err := p.ProcessFrame(context.Background(), vars, windowTestFrame(start,
+		[]time.Duration{0, 1500 * time.Millisecond, 2500 * time.Millisecond, 3500 * time.Millisecond},
+		[]string{"a", "b", "c", "d"},
+		[]float64{1, 2, 3, 4},
+	))
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 3)
+	require.Equal(t, 3, frame.Rows())
+	require.True(t, start.Add(-time.Second).Equal(frame.Fields[0].At(0).(time.Time)))
+	require.True(t, start.Add(time.Second).Equal(frame.Fields[0].At(2).(time.Time)))
+	require.Equal(t, []float64{1, 2, 2}, []float64{*frame.Fields[1].At(0).(*float64), *frame.Fields[1].At(1).(*float64), *frame.Fields[1].At(2).(*float64)})
+	require.Equal(t, []float64{1, 2, 3}, []float64{*frame.Fields[2].At(0).(*float64), *frame.Fields[2].At(1).(*float64), *frame.Fields[2].At(2).(*float64)})
+}
+
+func TestWindowFrameProcessor_SharedStorage(t *testing.T) {
+	start := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	config := WindowFrameProcessorConfig{
+		SizeMilliseconds: 1000,
+		Aggregations: []WindowAggregation{
+			{FieldName: "value", Reducer: WindowReducerCount},
+		},
+	}
+	// Processors of two Grafana instances in HA setup.
+	storage := NewMemoryWindowStorage()
+	p1, err := NewWindowFrameProcessor(storage, config)
+	require.NoError(t, err)
+	p2, err := NewWindowFrameProcessor(storage, config)
+	require.NoError(t, err)
+	vars := Vars{OrgID: 1, Channel: "stream/test/window"}
+
+	frame, err := p1.ProcessFrame(context.Background(), vars, windowTestFrame(start, []time.Duration{0}, []string{"a"}, []float64{1}))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+	frame, err = p2.ProcessFrame(context.Background(), vars, windowTestFrame(start, []time.Duration{100 * time.Millisecond}, []string{"a"}, []float64{1}))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	frame, err = p2.ProcessFrame(context.Background(), vars, windowTestFrame(start, []time.Duration{1100 * time.Millisecond}, []string{"a"}, []float64{1}))
+	require.NoError(t, err)
+	require.Equal(t, 2.0, *frame.Fields[2].At(0).(*float64))
+	frame, err = p1.ProcessFrame(context.Background(), vars, windowTestFrame(start, []time.Duration{1200 * time.Millisecond}, []string{"a"}, []float64{1}))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+}
+
+func TestNewWindowFrameProcessor_Errors(t *testing.T) {
+	aggregations := []WindowAggregation{{FieldName: "value", Reducer: WindowReducerMean}}
+	for _, c := range []struct {
+		config WindowFrameProcessorConfig
+		err    string
+	}{
+		{WindowFrameProcessorConfig{Aggregations: aggregations}, "window size and slide must be positive"},
+		{WindowFrameProcessorConfig{SizeMilliseconds: 1000, SlideMilliseconds: 300, Aggregations: aggregations}, "window size must be a multiple of slide"},
+		{WindowFrameProcessorConfig{SizeMilliseconds: 1000}, "no aggregations"},
+		{WindowFrameProcessorConfig{SizeMilliseconds: 1000, Aggregations: []WindowAggregation{{FieldName: "value", Reducer: "sum"}}}, "unknown reducer: sum"},
+		{WindowFrameProcessorConfig{SizeMilliseconds: 1000, Aggregations: []WindowAggregation{{FieldName: "value", Reducer: WindowReducerPercentile, Percentile: 101}}}, "invalid percentile: 101"},
+	} {
+		_, err := NewWindowFrameProcessor(NewMemoryWindowStorage(), c.config)
+		require.EqualError(t, err, c.err)
+	}
+}
+
+func TestWindowFrameProcessor_WatermarkJump(t *testing.T) {
+	start := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	p, err := NewWindowFrameProcessor(NewMemoryWindowStorage(), WindowFrameProcessorConfig{
+		SizeMilliseconds:  2,
+		SlideMilliseconds: 1,
+		Aggregations: []WindowAggregation{
+			{FieldName: "value", Reducer: WindowReducerCount},
+		},
+	})
+	require.NoError(t, err)
+	vars := Vars{OrgID: 1, Channel: "stream/test/window"}
+
+	frame, err := p.ProcessFrame(context.Background(), vars, windowTestFrame(start, []time.Duration{0}, []string{"a"}, []float64{1}))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	// The row a year later closes only the two windows containing the first row,
+	// without visiting the empty windows in between.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		frame, err = p.ProcessFrame(context.Background(), vars, windowTestFrame(start, []time.Duration{365 * 24 * time.Hour}, []string{"a"}, []float64{1}))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("processing the frame took too long")
+	}
+	require.NoError(t, err)
+	require.Equal(t, 2, frame.Rows())
+	require.True(t, start.Add(-time.Millisecond).Equal(frame.Fields[0].At(0).(time.Time)))
+	require.True(t, start.Equal(frame.Fields[0].At(1).(time.Time)))
+}
+
+func TestMemoryWindowStorage_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	storage := NewMemoryWindowStorage()
+	storage.nowTimeFunc = func() time.Time { return now }
+
+	require.NoError(t, storage.AddSamples(ctx, "idle", map[int64][]WindowSample{0: {{Time: 1}}}))
+	require.NoError(t, storage.AddSamples(ctx, "active", map[int64][]WindowSample{0: {{Time: 1}}}))
+	_, _, err := storage.AdvanceWatermark(ctx, "idle", 1000)
+	require.NoError(t, err)
+
+	now = now.Add(windowStorageTTL - time.Minute)
+	require.NoError(t, storage.AddSamples(ctx, "active", map[int64][]WindowSample{0: {{Time: 2}}}))
+
+	// the idle window expired, its samples and watermark are deleted
+	now = now.Add(time.Minute)
+	samples, err := storage.GetSamples(ctx, "idle", 0, 1000)
+	require.NoError(t, err)
+	require.Empty(t, samples)
+	previous, _, err := storage.AdvanceWatermark(ctx, "idle", 2000)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), previous)
+
+	samples, err = storage.GetSamples(ctx, "active", 0, 1000)
+	require.NoError(t, err)
+	require.Len(t, samples[0], 2)
+}
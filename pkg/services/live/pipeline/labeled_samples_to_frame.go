@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// labeledSample is a sample of a metric, the labels of the sample
+// become string fields of the frame so that channel rules can use them.
+type labeledSample struct {
+	time   time.Time
+	labels map[string]string
+	value  float64
+}
+
+// labeledSamples groups samples by metric name keeping the order
+// in which the metrics appear in the input.
+type labeledSamples struct {
+	names   []string
+	samples map[string][]labeledSample
+}
+
+func newLabeledSamples() *labeledSamples {
+	return &labeledSamples{samples: map[string][]labeledSample{}}
+}
+
+func (s *labeledSamples) add(name string, t time.Time, labels map[string]string, value float64) {
+	if _, ok := s.samples[name]; !ok {
+		s.names = append(s.names, name)
+	}
+	s.samples[name] = append(s.samples[name], labeledSample{time: t, labels: labels, value: value})
+}
+
+// channelFrames returns a frame per metric sent to channel + / + <metric_name>.
+// A frame has time field, a string field per label (sorted by label name)
+// and value field. Samples without a label have an empty string value.
+func (s *labeledSamples) channelFrames(channel string) []*ChannelFrame {
+	channelFrames := make([]*ChannelFrame, 0, len(s.names))
+	for _, name := range s.names {
+		channelFrames = append(channelFrames, &ChannelFrame{
+			Channel: channel + "/" + name,
+			Frame:   labeledSamplesToFrame(name, s.samples[name]),
+		})
+	}
+	return channelFrames
+}
+
+func labeledSamplesToFrame(name string, samples []labeledSample) *data.Frame {
+	labelNames := make([]string, 0)
+	seen := map[string]struct{}{}
+	for _, sample := range samples {
+		for label := range sample.labels {
+			if _, ok := seen[label]; !ok {
+				seen[label] = struct{}{}
+				labelNames = append(labelNames, label)
+			}
+		}
+	}
+	sort.Strings(labelNames)
+
+	timeField := data.NewFieldFromFieldType(data.FieldTypeTime, len(samples))
+	timeField.Name = "time"
+	valueField := data.NewFieldFromFieldType(data.FieldTypeFloat64, len(samples))
+	valueField.Name = "value"
+	labelFields := make([]*data.Field, len(labelNames))
+	for i, label := range labelNames {
+		labelFields[i] = data.NewFieldFromFieldType(data.FieldTypeString, len(samples))
+		labelFields[i].Name = label
+	}
+
+	for i, sample := range samples {
+		timeField.Set(i, sample.time)
+		valueField.Set(i, sample.value)
+		for j, label := range labelNames {
+			labelFields[j].Set(i, sample.labels[label])
+		}
+	}
+
+	fields := make([]*data.Field, 0, len(labelFields)+2)
+	fields = append(fields, timeField)
+	fields = append(fields, labelFields...)
+	fields = append(fields, valueField)
+	return data.NewFrame(name, fields...)
+}
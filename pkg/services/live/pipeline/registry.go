@@ -70,6 +70,22 @@ var ConvertersRegistry = []EntityInfo{
 		Type:        ConverterTypeJsonFrame,
 		Description: "JSON-encoded Grafana data frame",
 	},
+	{
+		Type:        ConverterTypePrometheusAuto,
+		Description: "accept Prometheus text exposition format",
+	},
+	{
+		Type:        ConverterTypeCsvAuto,
+		Description: "accept CSV lines",
+		Example: AutoCsvConverterConfig{
+			FieldNames: []string{"time", "host", "value"},
+			TimeField:  "time",
+		},
+	},
+	{
+		Type:        ConverterTypeOtlpAuto,
+		Description: "accept OTLP/HTTP metrics in protobuf or JSON encoding",
+	},
 }
 
 var FrameProcessorsRegistry = []EntityInfo{
@@ -83,6 +99,17 @@ var FrameProcessorsRegistry = []EntityInfo{
 		Description: "list the fields that should be removed",
 		Example:     DropFieldsFrameProcessorConfig{},
 	},
+	{
+		Type:        FrameProcessorTypeWindow,
+		Description: "aggregate fields over tumbling or sliding time windows",
+		Example: WindowFrameProcessorConfig{
+			SizeMilliseconds: 10000,
+			Aggregations: []WindowAggregation{
+				{FieldName: "value", Reducer: WindowReducerMean},
+				{FieldName: "value", Reducer: WindowReducerPercentile, Percentile: 95},
+			},
+		},
+	},
 }
 
 var DataOutputsRegistry = []EntityInfo{
@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/centrifugal/centrifuge"
@@ -13,6 +14,7 @@ type StorageRuleBuilder struct {
 	Node                 *centrifuge.Node
 	ManagedStream        *managedstream.Runner
 	FrameStorage         *FrameStorage
+	WindowStorage        WindowStorage
 	Storage              Storage
 	ChannelHandlerGetter ChannelHandlerGetter
 	SecretsService       secrets.Service
@@ -73,6 +75,21 @@ func (f *StorageRuleBuilder) extractConverter(config *ConverterConfig) (Converte
 			return nil, missingConfiguration
 		}
 		return NewAutoInfluxConverter(*config.AutoInfluxConverterConfig), nil
+	case ConverterTypePrometheusAuto:
+		if config.AutoPrometheusConverterConfig == nil {
+			config.AutoPrometheusConverterConfig = &AutoPrometheusConverterConfig{}
+		}
+		return NewAutoPrometheusConverter(*config.AutoPrometheusConverterConfig), nil
+	case ConverterTypeCsvAuto:
+		if config.AutoCsvConverterConfig == nil {
+			config.AutoCsvConverterConfig = &AutoCsvConverterConfig{}
+		}
+		return NewAutoCsvConverter(*config.AutoCsvConverterConfig), nil
+	case ConverterTypeOtlpAuto:
+		if config.AutoOtlpConverterConfig == nil {
+			config.AutoOtlpConverterConfig = &AutoOtlpConverterConfig{}
+		}
+		return NewAutoOtlpConverter(*config.AutoOtlpConverterConfig), nil
 	default:
 		return nil, fmt.Errorf("unknown converter type: %s", config.Type)
 	}
@@ -108,6 +125,14 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 			processors = append(processors, proc)
 		}
 		return NewMultipleFrameProcessor(processors...), nil
+	case FrameProcessorTypeWindow:
+		if config.WindowProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		if f.WindowStorage == nil {
+			return nil, errors.New("window storage is not configured")
+		}
+		return NewWindowFrameProcessor(f.WindowStorage, *config.WindowProcessorConfig)
 	default:
 		return nil, fmt.Errorf("unknown processor type: %s", config.Type)
 	}
@@ -0,0 +1,14 @@
+package pipeline
+
+// WindowReducer is a function aggregating the values of a field in a window.
+type WindowReducer string
+
+// Known window reducers.
+const (
+	WindowReducerMean       WindowReducer = "mean"
+	WindowReducerMin        WindowReducer = "min"
+	WindowReducerMax        WindowReducer = "max"
+	WindowReducerLast       WindowReducer = "last"
+	WindowReducerCount      WindowReducer = "count"
+	WindowReducerPercentile WindowReducer = "percentile"
+)
@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryWindowExpiryInterval is the interval the windows that were not updated
+// for windowStorageTTL are deleted at, like the keys of RedisWindowStorage expire.
+const memoryWindowExpiryInterval = time.Minute
+
+// WindowSample is a row of a frame kept by WindowFrameProcessor until the
+// windows it belongs to are emitted.
+type WindowSample struct {
+	// Time in milliseconds.
+	Time   int64              `json:"t"`
+	Labels map[string]string  `json:"l,omitempty"`
+	Values map[string]float64 `json:"v,omitempty"`
+}
+
+// WindowStorage keeps the samples of WindowFrameProcessor grouped by bucket
+// (start time of a bucket in milliseconds). In HA setup the storage must be
+// shared by all Grafana instances: the instance which advances the watermark
+// of a key emits the windows closed by this advance.
+type WindowStorage interface {
+	// AddSamples appends the samples to the buckets of a key.
+	AddSamples(ctx context.Context, key string, buckets map[int64][]WindowSample) error
+	// AdvanceWatermark sets the watermark of a key when it is greater than the current
+	// one. Returns the previous watermark (0 when not set) and true when it was set.
+	AdvanceWatermark(ctx context.Context, key string, watermark int64) (int64, bool, error)
+	// GetSamples returns the samples of the buckets of a key in [from, to).
+	GetSamples(ctx context.Context, key string, from, to int64) (map[int64][]WindowSample, error)
+	// DeleteSamples deletes the buckets of a key before a time.
+	DeleteSamples(ctx context.Context, key string, before int64) error
+}
+
+type memoryWindow struct {
+	watermark int64
+	buckets   map[int64][]WindowSample
+	// updated is the time samples were added or the watermark was advanced.
+	updated time.Time
+}
+
+// MemoryWindowStorage keeps window samples in memory. Not usable in HA setup.
+// The windows that are not updated for windowStorageTTL are deleted.
+type MemoryWindowStorage struct {
+	nowTimeFunc func() time.Time
+
+	mu         sync.Mutex
+	windows    map[string]*memoryWindow
+	lastExpiry time.Time
+}
+
+func NewMemoryWindowStorage() *MemoryWindowStorage {
+	return &MemoryWindowStorage{
+		nowTimeFunc: time.Now,
+		windows:     map[string]*memoryWindow{},
+	}
+}
+
+// window returns the window of a key, it is created when there is none or it
+// expired. Must be called with the lock held.
+func (s *MemoryWindowStorage) window(key string) *memoryWindow {
+	now := s.nowTimeFunc()
+	if now.Sub(s.lastExpiry) >= memoryWindowExpiryInterval {
+		s.lastExpiry = now
+		for k, w := range s.windows {
+			if now.Sub(w.updated) >= windowStorageTTL {
+				delete(s.windows, k)
+			}
+		}
+	}
+	w, ok := s.windows[key]
+	if !ok || now.Sub(w.updated) >= windowStorageTTL {
+		w = &memoryWindow{buckets: map[int64][]WindowSample{}, updated: now}
+		s.windows[key] = w
+	}
+	return w
+}
+
+func (s *MemoryWindowStorage) AddSamples(_ context.Context, key string, buckets map[int64][]WindowSample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.window(key)
+	for bucket, samples := range buckets {
+		w.buckets[bucket] = append(w.buckets[bucket], samples...)
+	}
+	w.updated = s.nowTimeFunc()
+	return nil
+}
+
+func (s *MemoryWindowStorage) AdvanceWatermark(_ context.Context, key string, watermark int64) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.window(key)
+	previous := w.watermark
+	if previous >= watermark {
+		return previous, false, nil
+	}
+	w.watermark = watermark
+	w.updated = s.nowTimeFunc()
+	return previous, true, nil
+}
+
+func (s *MemoryWindowStorage) GetSamples(_ context.Context, key string, from, to int64) (map[int64][]WindowSample, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buckets := map[int64][]WindowSample{}
+	for bucket, samples := range s.window(key).buckets {
+		if bucket >= from && bucket < to {
+			buckets[bucket] = append([]WindowSample(nil), samples...)
+		}
+	}
+	return buckets, nil
+}
+
+func (s *MemoryWindowStorage) DeleteSamples(_ context.Context, key string, before int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.window(key)
+	for bucket := range w.buckets {
+		if bucket < before {
+			delete(w.buckets, bucket)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// windowStorageTTL is the expiration of the keys of a window, which is
+// refreshed with every sample. Limits the size of the windows.
+const windowStorageTTL = 24 * time.Hour
+
+// advanceWatermarkScript sets the watermark only if it is greater than the
+// current one, so that a single Grafana instance emits each window.
+var advanceWatermarkScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if current >= tonumber(ARGV[1]) then
+	return {current, 0}
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return {current, 1}
+`)
+
+// RedisWindowStorage keeps window samples in Redis, usable in HA setup.
+// The buckets of a key are in a sorted set, the samples of a bucket in a list.
+type RedisWindowStorage struct {
+	redisClient *redis.Client
+}
+
+func NewRedisWindowStorage(redisClient *redis.Client) *RedisWindowStorage {
+	return &RedisWindowStorage{redisClient: redisClient}
+}
+
+func (s *RedisWindowStorage) AddSamples(ctx context.Context, key string, buckets map[int64][]WindowSample) error {
+	pipe := s.redisClient.TxPipeline()
+	defer func() { _ = pipe.Close() }()
+
+	for bucket, samples := range buckets {
+		values := make([]interface{}, 0, len(samples))
+		for _, sample := range samples {
+			value, err := json.Marshal(sample)
+			if err != nil {
+				return err
+			}
+			values = append(values, value)
+		}
+		bucketKey := getWindowBucketKey(key, bucket)
+		pipe.RPush(ctx, bucketKey, values...)
+		pipe.Expire(ctx, bucketKey, windowStorageTTL)
+		pipe.ZAdd(ctx, getWindowBucketsKey(key), &redis.Z{Score: float64(bucket), Member: bucket})
+	}
+	pipe.Expire(ctx, getWindowBucketsKey(key), windowStorageTTL)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisWindowStorage) AdvanceWatermark(ctx context.Context, key string, watermark int64) (int64, bool, error) {
+	result, err := advanceWatermarkScript.Run(ctx, s.redisClient, []string{getWindowWatermarkKey(key)}, watermark, windowStorageTTL.Milliseconds()).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, false, fmt.Errorf("unexpected watermark reply: %v", result)
+	}
+	previous, ok := values[0].(int64)
+	if !ok {
+		return 0, false, fmt.Errorf("unexpected watermark reply: %v", result)
+	}
+	return previous, values[1] == int64(1), nil
+}
+
+func (s *RedisWindowStorage) GetSamples(ctx context.Context, key string, from, to int64) (map[int64][]WindowSample, error) {
+	members, err := s.redisClient.ZRangeByScore(ctx, getWindowBucketsKey(key), &redis.ZRangeBy{
+		Min: strconv.FormatInt(from, 10),
+		Max: "(" + strconv.FormatInt(to, 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return map[int64][]WindowSample{}, nil
+	}
+
+	bucketStarts := make([]int64, 0, len(members))
+	pipe := s.redisClient.Pipeline()
+	defer func() { _ = pipe.Close() }()
+	cmds := make([]*redis.StringSliceCmd, 0, len(members))
+	for _, member := range members {
+		bucket, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		bucketStarts = append(bucketStarts, bucket)
+		cmds = append(cmds, pipe.LRange(ctx, getWindowBucketKey(key, bucket), 0, -1))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	buckets := make(map[int64][]WindowSample, len(members))
+	for i, cmd := range cmds {
+		values, err := cmd.Result()
+		if err != nil {
+			return nil, err
+		}
+		samples := make([]WindowSample, 0, len(values))
+		for _, value := range values {
+			var sample WindowSample
+			if err := json.Unmarshal([]byte(value), &sample); err != nil {
+				return nil, err
+			}
+			samples = append(samples, sample)
+		}
+		buckets[bucketStarts[i]] = samples
+	}
+	return buckets, nil
+}
+
+func (s *RedisWindowStorage) DeleteSamples(ctx context.Context, key string, before int64) error {
+	bucketsKey := getWindowBucketsKey(key)
+	members, err := s.redisClient.ZRangeByScore(ctx, bucketsKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: "(" + strconv.FormatInt(before, 10),
+	}).Result()
+	if err != nil || len(members) == 0 {
+		return err
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	defer func() { _ = pipe.Close() }()
+	for _, member := range members {
+		bucket, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			return err
+		}
+		pipe.Del(ctx, getWindowBucketKey(key, bucket))
+	}
+	pipe.ZRemRangeByScore(ctx, bucketsKey, "-inf", "("+strconv.FormatInt(before, 10))
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func getWindowWatermarkKey(key string) string {
+	return "gf_live.window." + key + ".watermark"
+}
+
+func getWindowBucketsKey(key string) string {
+	return "gf_live.window." + key + ".buckets"
+}
+
+func getWindowBucketKey(key string, bucket int64) string {
+	return getWindowBucketsKey(key) + "." + strconv.FormatInt(bucket, 10)
+}
@@ -0,0 +1,53 @@
+//go:build redis
+// +build redis
+
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisWindowStorage(t *testing.T) {
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	s := NewRedisWindowStorage(redisClient)
+	key := "1/stream/test/window_storage"
+	ctx := context.Background()
+	t.Cleanup(func() {
+		_ = s.DeleteSamples(ctx, key, 1<<62)
+		_ = redisClient.Del(ctx, getWindowWatermarkKey(key)).Err()
+	})
+
+	err := s.AddSamples(ctx, key, map[int64][]WindowSample{
+		1000: {{Time: 1100, Values: map[string]float64{"value": 1}}},
+		2000: {{Time: 2100, Labels: map[string]string{"host": "a"}, Values: map[string]float64{"value": 2}}},
+	})
+	require.NoError(t, err)
+
+	previous, ok, err := s.AdvanceWatermark(ctx, key, 2000)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(0), previous)
+	previous, ok, err = s.AdvanceWatermark(ctx, key, 2000)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, int64(2000), previous)
+
+	samples, err := s.GetSamples(ctx, key, 0, 3000)
+	require.NoError(t, err)
+	require.Equal(t, map[int64][]WindowSample{
+		1000: {{Time: 1100, Values: map[string]float64{"value": 1}}},
+		2000: {{Time: 2100, Labels: map[string]string{"host": "a"}, Values: map[string]float64{"value": 2}}},
+	}, samples)
+
+	require.NoError(t, s.DeleteSamples(ctx, key, 2000))
+	samples, err = s.GetSamples(ctx, key, 0, 3000)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	require.Contains(t, samples, int64(2000))
+}
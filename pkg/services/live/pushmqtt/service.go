@@ -0,0 +1,150 @@
+package pushmqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/mqtt"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	logger = log.New("live.push_mqtt")
+)
+
+const (
+	connectTimeout    = 10 * time.Second
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+	// messageQueueSize is the number of received messages waiting to be pushed,
+	// messages received when the queue is full are dropped.
+	messageQueueSize = 1024
+)
+
+// dataPusher pushes data to Live channels, implemented by live.GrafanaLive.
+type dataPusher interface {
+	PushData(ctx context.Context, orgID int64, channel string, body []byte) error
+}
+
+func ProvideService(cfg *setting.Cfg, live *live.GrafanaLive) *Service {
+	return &Service{
+		settings: cfg.LiveMQTT,
+		pusher:   live,
+	}
+}
+
+// Service subscribes to the topics of an MQTT broker and pushes the received
+// messages to stream/<namespace>/<topic> channels.
+type Service struct {
+	settings setting.LiveMQTTSettings
+	pusher   dataPusher
+}
+
+// IsDisabled returns true when the MQTT listener is not enabled.
+func (s *Service) IsDisabled() bool {
+	return !s.settings.Enabled
+}
+
+// Run keeps a connection to the broker until the context is done, it reconnects
+// with exponential backoff when the connection fails.
+func (s *Service) Run(ctx context.Context) error {
+	messages := make(chan mqtt.Message, messageQueueSize)
+	go s.pushMessages(ctx, messages)
+
+	delay := minReconnectDelay
+	for {
+		connected, err := s.runClient(ctx, messages)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if connected {
+			delay = minReconnectDelay
+		}
+		logger.Error("MQTT connection failed", "error", err, "brokerUrl", s.settings.BrokerURL, "retryIn", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// runClient connects to the broker and subscribes to the topics, it returns once the connection is
+// closed and whether the subscription succeeded.
+func (s *Service) runClient(ctx context.Context, messages chan<- mqtt.Message) (bool, error) {
+	opts := mqtt.Options{
+		BrokerURL: s.settings.BrokerURL,
+		ClientID:  s.settings.ClientID,
+		Username:  s.settings.Username,
+		Password:  s.settings.Password,
+		TLSConfig: &tls.Config{InsecureSkipVerify: s.settings.TLSSkipVerify},
+		// the connection is always open, a broker must not make it read arbitrarily large packets
+		MaxPacketSize: s.settings.MaxPacketSize,
+		OnMessage: func(msg mqtt.Message) {
+			select {
+			case messages <- msg:
+			default:
+				logger.Warn("Dropping MQTT message, too many messages waiting to be pushed", "topic", msg.Topic)
+			}
+		},
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+	client, err := mqtt.Connect(connectCtx, opts)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = client.Close() }()
+
+	if err := client.Subscribe(connectCtx, mqtt.QoS(s.settings.QoS), s.settings.Topics...); err != nil {
+		return false, err
+	}
+	logger.Info("Subscribed to MQTT topics", "brokerUrl", s.settings.BrokerURL, "topics", s.settings.Topics)
+
+	select {
+	case <-client.Done():
+		return true, client.Err()
+	case <-ctx.Done():
+		return true, ctx.Err()
+	}
+}
+
+func (s *Service) pushMessages(ctx context.Context, messages <-chan mqtt.Message) {
+	for {
+		select {
+		case msg := <-messages:
+			channel := topicChannel(s.settings.Namespace, msg.Topic)
+			if err := s.pusher.PushData(ctx, s.settings.OrgID, channel, msg.Payload); err != nil {
+				logger.Error("Error pushing MQTT message", "error", err, "topic", msg.Topic, "channel", channel)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// topicChannel returns the channel of a topic, characters not allowed
+// in a channel path are replaced with underscores.
+func topicChannel(namespace string, topic string) string {
+	path := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.', r == '=', r == '/':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.Trim(topic, "/"))
+	if path == "" {
+		path = "_"
+	}
+	return "stream/" + namespace + "/" + path
+}
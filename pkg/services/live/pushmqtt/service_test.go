@@ -0,0 +1,133 @@
+package pushmqtt
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type pushed struct {
+	orgID   int64
+	channel string
+	body    string
+}
+
+type fakePusher struct {
+	mtx    sync.Mutex
+	pushed []pushed
+}
+
+func (p *fakePusher) PushData(_ context.Context, orgID int64, channel string, body []byte) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.pushed = append(p.pushed, pushed{orgID: orgID, channel: channel, body: string(body)})
+	return nil
+}
+
+func (p *fakePusher) Pushed() []pushed {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return append([]pushed{}, p.pushed...)
+}
+
+// serveFakeBroker accepts a connection, acknowledges the connection and the subscription
+// and then sends a QoS 0 message.
+func serveFakeBroker(l net.Listener, topic string, payload string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+
+	readPacket := func() (byte, []byte) {
+		header, err := r.ReadByte()
+		if err != nil {
+			return 0, nil
+		}
+		length, multiplier := 0, 1
+		for {
+			b, err := r.ReadByte()
+			if err != nil {
+				return 0, nil
+			}
+			length += int(b&0x7f) * multiplier
+			if b&0x80 == 0 {
+				break
+			}
+			multiplier *= 128
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return 0, nil
+		}
+		return header >> 4, body
+	}
+
+	if typ, _ := readPacket(); typ != 1 {
+		return
+	}
+	_, _ = conn.Write([]byte{0x20, 2, 0, 0})
+	typ, body := readPacket()
+	if typ != 8 {
+		return
+	}
+	_, _ = conn.Write([]byte{0x90, 3, body[0], body[1], 0})
+
+	msg := append([]byte{byte(len(topic) >> 8), byte(len(topic))}, topic...)
+	msg = append(msg, payload...)
+	_, _ = conn.Write(append([]byte{0x30, byte(len(msg))}, msg...))
+	for {
+		if typ, _ := readPacket(); typ == 0 || typ == 14 {
+			return
+		}
+	}
+}
+
+func TestService(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	go serveFakeBroker(l, "sensors/room 1/temperature", "temperature value=21")
+
+	pusher := &fakePusher{}
+	s := &Service{
+		settings: setting.LiveMQTTSettings{
+			Enabled:   true,
+			BrokerURL: "tcp://" + l.Addr().String(),
+			Topics:    []string{"sensors/#"},
+			OrgID:     2,
+			Namespace: "mqtt",
+		},
+		pusher: pusher,
+	}
+	require.False(t, s.IsDisabled())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		return len(pusher.Pushed()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []pushed{
+		{orgID: 2, channel: "stream/mqtt/sensors/room_1/temperature", body: "temperature value=21"},
+	}, pusher.Pushed())
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestTopicChannel(t *testing.T) {
+	require.Equal(t, "stream/mqtt/sensors/temperature", topicChannel("mqtt", "sensors/temperature"))
+	require.Equal(t, "stream/mqtt/home/room_1/t_C", topicChannel("mqtt", "/home/room 1/t°C/"))
+	require.Equal(t, "stream/mqtt/_", topicChannel("mqtt", "/"))
+}
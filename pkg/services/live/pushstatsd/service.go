@@ -0,0 +1,156 @@
+package pushstatsd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	logger = log.New("live.push_statsd")
+)
+
+// maxPacketSize is the largest UDP packet the listener reads.
+const maxPacketSize = 65535
+
+// dataPusher pushes data to Live channels, implemented by live.GrafanaLive.
+type dataPusher interface {
+	PushData(ctx context.Context, orgID int64, channel string, body []byte) error
+}
+
+func ProvideService(cfg *setting.Cfg, live *live.GrafanaLive) *Service {
+	return &Service{
+		settings:   cfg.LiveStatsd,
+		pusher:     live,
+		aggregator: newAggregator(cfg.LiveStatsd.MaxSeries, cfg.LiveStatsd.GaugeExpiry),
+	}
+}
+
+// Service is a statsd compatible listener. It aggregates the received metrics and pushes them to
+// stream/<namespace>/<metric> channels every flush interval, encoded as Influx line protocol.
+type Service struct {
+	settings   setting.LiveStatsdSettings
+	pusher     dataPusher
+	aggregator *aggregator
+}
+
+// IsDisabled returns true when the statsd listener is not enabled.
+func (s *Service) IsDisabled() bool {
+	return !s.settings.Enabled
+}
+
+// Run listens for UDP packets and TCP connections until the context is done.
+func (s *Service) Run(ctx context.Context) error {
+	packetConn, err := net.ListenPacket("udp", s.settings.Address)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", s.settings.Address)
+	if err != nil {
+		_ = packetConn.Close()
+		return err
+	}
+	logger.Info("Live statsd listener started", "address", s.settings.Address)
+	return s.serve(ctx, packetConn, listener)
+}
+
+func (s *Service) serve(ctx context.Context, packetConn net.PacketConn, listener net.Listener) error {
+	go s.servePackets(packetConn)
+	go s.serveConnections(ctx, listener)
+
+	ticker := time.NewTicker(s.settings.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.flush(ctx, now)
+		case <-ctx.Done():
+			_ = packetConn.Close()
+			_ = listener.Close()
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) servePackets(packetConn net.PacketConn) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := packetConn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Error("Error reading statsd packet", "error", err)
+			}
+			return
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			s.handleLine(line)
+		}
+	}
+}
+
+func (s *Service) serveConnections(ctx context.Context, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Error("Error accepting statsd connection", "error", err)
+			}
+			return
+		}
+		go s.serveConnection(ctx, conn)
+	}
+}
+
+func (s *Service) serveConnection(ctx context.Context, conn net.Conn) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		s.handleLine(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		logger.Debug("Error reading statsd connection", "error", err)
+	}
+}
+
+func (s *Service) handleLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	sample, err := parseLine(line)
+	if err != nil {
+		// a misbehaving client can send any number of invalid lines
+		logger.Debug("Invalid statsd line", "error", err, "line", line)
+		return
+	}
+	s.aggregator.add(sample)
+}
+
+func (s *Service) flush(ctx context.Context, now time.Time) {
+	lines, dropped := s.aggregator.flush(now)
+	if dropped > 0 {
+		logger.Warn("Dropped statsd samples of new series, the maximum number of series is reached", "dropped", dropped, "max_series", s.settings.MaxSeries)
+	}
+	for name, body := range lines {
+		channel := "stream/" + s.settings.Namespace + "/" + name
+		if err := s.pusher.PushData(ctx, s.settings.OrgID, channel, body); err != nil {
+			logger.Error("Error pushing statsd metrics", "error", err, "channel", channel)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package pushstatsd
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type pushed struct {
+	orgID   int64
+	channel string
+	body    string
+}
+
+type fakePusher struct {
+	mtx    sync.Mutex
+	pushed []pushed
+}
+
+func (p *fakePusher) PushData(_ context.Context, orgID int64, channel string, body []byte) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.pushed = append(p.pushed, pushed{orgID: orgID, channel: channel, body: string(body)})
+	return nil
+}
+
+func (p *fakePusher) Pushed() []pushed {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return append([]pushed{}, p.pushed...)
+}
+
+func TestService(t *testing.T) {
+	pusher := &fakePusher{}
+	s := &Service{
+		settings:   setting.LiveStatsdSettings{Enabled: true, FlushInterval: 10 * time.Millisecond, MaxSeries: 100, GaugeExpiry: 10, OrgID: 2, Namespace: "statsd"},
+		pusher:     pusher,
+		aggregator: newAggregator(100, 10),
+	}
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.serve(ctx, packetConn, listener) }()
+
+	udpConn, err := net.Dial("udp", packetConn.LocalAddr().String())
+	require.NoError(t, err)
+	_, err = udpConn.Write([]byte("requests:1|c\ninvalid\n"))
+	require.NoError(t, err)
+	require.NoError(t, udpConn.Close())
+
+	tcpConn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	_, err = tcpConn.Write([]byte("temperature:20|g\n"))
+	require.NoError(t, err)
+	require.NoError(t, tcpConn.Close())
+
+	require.Eventually(t, func() bool {
+		var requests, temperature bool
+		for _, p := range pusher.Pushed() {
+			if p.orgID != 2 {
+				return false
+			}
+			switch p.channel {
+			case "stream/statsd/requests":
+				requests = strings.HasPrefix(p.body, "requests value=1 ")
+			case "stream/statsd/temperature":
+				temperature = strings.HasPrefix(p.body, "temperature value=20 ")
+			}
+		}
+		return requests && temperature
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	require.False(t, s.IsDisabled())
+}
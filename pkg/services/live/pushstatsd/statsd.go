@@ -0,0 +1,312 @@
+package pushstatsd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// The types of statsd metrics.
+const (
+	metricTypeCounter      = "c"
+	metricTypeGauge        = "g"
+	metricTypeTimer        = "ms"
+	metricTypeHistogram    = "h"
+	metricTypeDistribution = "d"
+	metricTypeSet          = "s"
+)
+
+// sample is a value of a metric received in a statsd line.
+type sample struct {
+	name  string
+	typ   string
+	value float64
+	// member is the value of a set metric.
+	member string
+	// relative is true for gauge updates with a sign, which change the current value.
+	relative bool
+	rate     float64
+	tags     map[string]string
+}
+
+// parseLine parses a statsd line <name>:<value>|<type>[|@<sample rate>][|#<tag>:<value>,...],
+// tags use the DogStatsD format. Characters of the name not allowed in a channel path are
+// replaced with underscores.
+func parseLine(line string) (sample, error) {
+	colon := strings.LastIndex(line, ":")
+	if colon <= 0 {
+		return sample{}, errors.New("no metric name")
+	}
+	if tagsStart := strings.Index(line, "|#"); tagsStart >= 0 && tagsStart < colon {
+		// the colon separates a tag from its value
+		colon = strings.LastIndex(line[:tagsStart], ":")
+		if colon <= 0 {
+			return sample{}, errors.New("no metric name")
+		}
+	}
+	s := sample{name: sanitizeName(line[:colon]), rate: 1}
+
+	parts := strings.Split(line[colon+1:], "|")
+	if len(parts) < 2 {
+		return sample{}, errors.New("no metric type")
+	}
+	value := parts[0]
+	s.typ = parts[1]
+	switch s.typ {
+	case metricTypeSet:
+		s.member = value
+	case metricTypeCounter, metricTypeGauge, metricTypeTimer, metricTypeHistogram, metricTypeDistribution:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return sample{}, fmt.Errorf("invalid value: %q", value)
+		}
+		s.value = v
+		s.relative = s.typ == metricTypeGauge && (value[0] == '+' || value[0] == '-')
+	default:
+		return sample{}, fmt.Errorf("unknown metric type: %q", s.typ)
+	}
+
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			rate, err := strconv.ParseFloat(part[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return sample{}, fmt.Errorf("invalid sample rate: %q", part[1:])
+			}
+			s.rate = rate
+		case strings.HasPrefix(part, "#"):
+			s.tags = parseTags(part[1:])
+		}
+	}
+	return s, nil
+}
+
+// parseTags parses comma-separated tags, a tag without value is skipped
+// since Influx line protocol has no tags without value.
+func parseTags(s string) map[string]string {
+	tags := map[string]string{}
+	for _, tag := range strings.Split(s, ",") {
+		i := strings.Index(tag, ":")
+		if i <= 0 || i == len(tag)-1 {
+			continue
+		}
+		tags[tag[:i]] = tag[i+1:]
+	}
+	return tags
+}
+
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.', r == '=':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// series is the aggregation of the samples of a metric with a tag set.
+type series struct {
+	name string
+	typ  string
+	tags map[string]string
+	// updated is true when the series received samples since the last flush.
+	updated bool
+	// idleFlushes is the number of flushes since a gauge was last updated.
+	idleFlushes int
+	// value is the sum of a counter or the value of a gauge.
+	value float64
+	// values of a timer, histogram or distribution.
+	values []float64
+	// count of the samples of a timer, histogram or distribution adjusted for the sample rate.
+	count   float64
+	members map[string]struct{}
+}
+
+// aggregator aggregates samples between flushes like statsd: counters are summed,
+// the last value of gauges is kept, timers, histograms and distributions are summarized
+// and the unique members of sets are counted. Gauges keep their value across flushes,
+// so that relative updates apply to it, but are only flushed when updated and are deleted
+// after gaugeExpiry flushes without update. Samples of new series are dropped when
+// maxSeries series are aggregated.
+type aggregator struct {
+	mu          sync.Mutex
+	series      map[string]*series
+	maxSeries   int
+	gaugeExpiry int
+	// dropped is the number of samples dropped since the last flush.
+	dropped int
+}
+
+func newAggregator(maxSeries int, gaugeExpiry int) *aggregator {
+	return &aggregator{series: map[string]*series{}, maxSeries: maxSeries, gaugeExpiry: gaugeExpiry}
+}
+
+func seriesKey(s sample) string {
+	typ := s.typ
+	if typ == metricTypeHistogram || typ == metricTypeDistribution {
+		typ = metricTypeTimer
+	}
+	keys := make([]string, 0, len(s.tags))
+	for k := range s.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	sb.WriteString(typ)
+	sb.WriteByte(0)
+	sb.WriteString(s.name)
+	for _, k := range keys {
+		sb.WriteByte(0)
+		sb.WriteString(k)
+		sb.WriteByte(0)
+		sb.WriteString(s.tags[k])
+	}
+	return sb.String()
+}
+
+func (a *aggregator) add(s sample) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := seriesKey(s)
+	ser, ok := a.series[key]
+	if !ok {
+		if len(a.series) >= a.maxSeries {
+			a.dropped++
+			return
+		}
+		ser = &series{name: s.name, typ: s.typ, tags: s.tags}
+		a.series[key] = ser
+	}
+	ser.updated = true
+	ser.idleFlushes = 0
+	switch s.typ {
+	case metricTypeCounter:
+		ser.value += s.value / s.rate
+	case metricTypeGauge:
+		if s.relative {
+			ser.value += s.value
+		} else {
+			ser.value = s.value
+		}
+	case metricTypeTimer, metricTypeHistogram, metricTypeDistribution:
+		ser.values = append(ser.values, s.value)
+		ser.count += 1 / s.rate
+	case metricTypeSet:
+		if ser.members == nil {
+			ser.members = map[string]struct{}{}
+		}
+		ser.members[s.member] = struct{}{}
+	}
+}
+
+// flush returns the series updated since the last flush in Influx line protocol,
+// grouped by metric name, and resets them. It also returns the number of samples
+// dropped since the last flush.
+func (a *aggregator) flush(now time.Time) (map[string][]byte, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	keys := make([]string, 0, len(a.series))
+	for key, ser := range a.series {
+		if ser.updated {
+			keys = append(keys, key)
+			continue
+		}
+		ser.idleFlushes++
+		if ser.idleFlushes >= a.gaugeExpiry {
+			delete(a.series, key)
+		}
+	}
+	sort.Strings(keys)
+
+	lines := map[string][]byte{}
+	for _, key := range keys {
+		ser := a.series[key]
+		lines[ser.name] = appendLine(lines[ser.name], ser, now)
+		if ser.typ == metricTypeGauge {
+			ser.updated = false
+		} else {
+			delete(a.series, key)
+		}
+	}
+	dropped := a.dropped
+	a.dropped = 0
+	return lines, dropped
+}
+
+// appendLine appends the line of a series, its fields are value or, for timers, histograms and
+// distributions, count, sum, mean, min, max, p50, p90 and p99.
+func appendLine(b []byte, ser *series, now time.Time) []byte {
+	b = append(b, escape(ser.name, ", ")...)
+	tagKeys := make([]string, 0, len(ser.tags))
+	for k := range ser.tags {
+		tagKeys = append(tagKeys, k)
+	}
+	sort.Strings(tagKeys)
+	for _, k := range tagKeys {
+		b = append(b, ',')
+		b = append(b, escape(k, ", =")...)
+		b = append(b, '=')
+		b = append(b, escape(ser.tags[k], ", =")...)
+	}
+	b = append(b, ' ')
+
+	switch ser.typ {
+	case metricTypeTimer, metricTypeHistogram, metricTypeDistribution:
+		values := ser.values
+		sort.Float64s(values)
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		b = appendField(b, "count", ser.count, false)
+		b = appendField(b, "sum", sum, true)
+		b = appendField(b, "mean", sum/float64(len(values)), true)
+		b = appendField(b, "min", values[0], true)
+		b = appendField(b, "max", values[len(values)-1], true)
+		b = appendField(b, "p50", util.Percentile(values, 50), true)
+		b = appendField(b, "p90", util.Percentile(values, 90), true)
+		b = appendField(b, "p99", util.Percentile(values, 99), true)
+	case metricTypeSet:
+		b = appendField(b, "value", float64(len(ser.members)), false)
+	default:
+		b = appendField(b, "value", ser.value, false)
+	}
+
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, now.UnixNano(), 10)
+	return append(b, '\n')
+}
+
+func appendField(b []byte, name string, value float64, comma bool) []byte {
+	if comma {
+		b = append(b, ',')
+	}
+	b = append(b, name...)
+	b = append(b, '=')
+	return strconv.AppendFloat(b, value, 'f', -1, 64)
+}
+
+func escape(s string, chars string) string {
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
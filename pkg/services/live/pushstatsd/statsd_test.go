@@ -0,0 +1,140 @@
+package pushstatsd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	testCases := []struct {
+		line   string
+		sample sample
+		err    string
+	}{
+		{line: "requests:1|c", sample: sample{name: "requests", typ: "c", value: 1, rate: 1}},
+		{line: "requests:2|c|@0.5", sample: sample{name: "requests", typ: "c", value: 2, rate: 0.5}},
+		{line: "cpu:-1.5|g", sample: sample{name: "cpu", typ: "g", value: -1.5, relative: true, rate: 1}},
+		{line: "latency:320|ms|@0.1|#host:a,region:eu", sample: sample{name: "latency", typ: "ms", value: 320, rate: 0.1, tags: map[string]string{"host": "a", "region": "eu"}}},
+		{line: "users:bob|s|#canary", sample: sample{name: "users", typ: "s", member: "bob", rate: 1, tags: map[string]string{}}},
+		{line: "api/v1 requests:1|c", sample: sample{name: "api_v1_requests", typ: "c", value: 1, rate: 1}},
+		{line: "requests", err: "no metric name"},
+		{line: "requests:1", err: "no metric type"},
+		{line: "requests:x|c", err: `invalid value: "x"`},
+		{line: "requests:1|q", err: `unknown metric type: "q"`},
+		{line: "requests:1|c|@2", err: `invalid sample rate: "2"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.line, func(t *testing.T) {
+			s, err := parseLine(tc.line)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.sample, s)
+		})
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	now := time.Unix(1, 0)
+	a := newAggregator(100, 2)
+	for _, line := range []string{
+		"requests:1|c|#code:200",
+		"requests:1|c|@0.5|#code:200",
+		"requests:1|c|#code:500",
+		"temperature:20|g",
+		"temperature:+2|g",
+		"latency:10|ms",
+		"latency:20|ms",
+		"latency:30|h",
+		"users:bob|s",
+		"users:bob|s",
+		"users:alice|s",
+	} {
+		s, err := parseLine(line)
+		require.NoError(t, err)
+		a.add(s)
+	}
+
+	require.Equal(t, map[string]string{
+		"requests":    "requests,code=200 value=3 1000000000\nrequests,code=500 value=1 1000000000\n",
+		"temperature": "temperature value=22 1000000000\n",
+		"latency":     "latency count=3,sum=60,mean=20,min=10,max=30,p50=20,p90=28,p99=29.8 1000000000\n",
+		"users":       "users value=2 1000000000\n",
+	}, flushValues(t, a, now))
+
+	// Gauges keep their value but are only flushed when updated.
+	require.Empty(t, flushValues(t, a, now))
+	s, err := parseLine("temperature:-5|g")
+	require.NoError(t, err)
+	a.add(s)
+	require.Equal(t, map[string]string{
+		"temperature": "temperature value=17 1000000000\n",
+	}, flushValues(t, a, now))
+
+	// Gauges are deleted when they are not updated for gaugeExpiry flushes.
+	require.Empty(t, flushValues(t, a, now))
+	require.Empty(t, flushValues(t, a, now))
+	require.Empty(t, a.series)
+	s, err = parseLine("temperature:+1|g")
+	require.NoError(t, err)
+	a.add(s)
+	require.Equal(t, map[string]string{
+		"temperature": "temperature value=1 1000000000\n",
+	}, flushValues(t, a, now))
+}
+
+func TestAggregator_MaxSeries(t *testing.T) {
+	now := time.Unix(1, 0)
+	a := newAggregator(2, 10)
+	for _, line := range []string{
+		"temperature:20|g|#room:a",
+		"requests:1|c",
+		"temperature:21|g|#room:b",
+		"requests:1|c",
+	} {
+		s, err := parseLine(line)
+		require.NoError(t, err)
+		a.add(s)
+	}
+
+	lines, dropped := a.flush(now)
+	require.Equal(t, map[string]string{
+		"requests":    "requests value=2 1000000000\n",
+		"temperature": "temperature,room=a value=20 1000000000\n",
+	}, stringValues(lines))
+	require.Equal(t, 1, dropped)
+
+	// The counter was reset by the flush, which leaves room for a new series.
+	s, err := parseLine("temperature:21|g|#room:b")
+	require.NoError(t, err)
+	a.add(s)
+	lines, dropped = a.flush(now)
+	require.Equal(t, map[string]string{
+		"temperature": "temperature,room=b value=21 1000000000\n",
+	}, stringValues(lines))
+	require.Zero(t, dropped)
+}
+
+func TestAppendLine_Escape(t *testing.T) {
+	ser := &series{name: "requests", typ: "c", value: 1, tags: map[string]string{"path": "/a b,c=d"}}
+	require.Equal(t, "requests,path=/a\\ b\\,c\\=d value=1 1000000000\n", string(appendLine(nil, ser, time.Unix(1, 0))))
+}
+
+func flushValues(t *testing.T, a *aggregator, now time.Time) map[string]string {
+	t.Helper()
+	lines, dropped := a.flush(now)
+	require.Zero(t, dropped)
+	return stringValues(lines)
+}
+
+func stringValues(m map[string][]byte) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = string(v)
+	}
+	return result
+}
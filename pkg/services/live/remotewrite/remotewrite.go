@@ -3,6 +3,7 @@ package remotewrite
 import (
 	"fmt"
 	"hash/fnv"
+	"sort"
 	"strings"
 	"time"
 
@@ -190,6 +191,68 @@ func TimeSeriesFromFramesLabelsColumn(frames ...*data.Frame) []prompb.TimeSeries
 	return promTimeSeriesBatch
 }
 
+// TimeSeriesFromFramesAt converts frames to slice of Prometheus TimeSeries named after the metric,
+// with a single sample at the given time. Every numeric field becomes a series holding its last value,
+// labelled with the labels of the field and the extra labels. Unlike TimeSeriesFromFrames it accepts
+// frames without time field, such as the results of server side expressions.
+func TimeSeriesFromFramesAt(metricName string, tm time.Time, extraLabels map[string]string, frames ...*data.Frame) []prompb.TimeSeries {
+	metricName, ok := sanitizeMetricName(metricName)
+	if !ok {
+		return nil
+	}
+
+	var entries = make(map[metricKey]prompb.TimeSeries)
+	var keys []metricKey // sorted keys.
+
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			if !field.Type().Numeric() || field.Len() == 0 {
+				continue
+			}
+			val, ok := field.ConcreteAt(field.Len() - 1)
+			if !ok {
+				continue
+			}
+			value, ok := sampleValue(val)
+			if !ok {
+				continue
+			}
+
+			fieldLabels := make(map[string]string, len(field.Labels)+len(extraLabels))
+			for k, v := range field.Labels {
+				fieldLabels[k] = v
+			}
+			for k, v := range extraLabels {
+				fieldLabels[k] = v
+			}
+			// the series is renamed after the metric.
+			delete(fieldLabels, "__name__")
+			labels := createLabels(fieldLabels)
+			sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+			key := makeMetricKey(metricName, labels)
+			if _, ok := entries[key]; !ok {
+				keys = append(keys, key)
+			}
+
+			labels = append(labels, prompb.Label{
+				Name:  "__name__",
+				Value: metricName,
+			})
+			entries[key] = prompb.TimeSeries{
+				Labels:  labels,
+				Samples: []prompb.Sample{{Timestamp: toSampleTime(tm), Value: value}},
+			}
+		}
+	}
+
+	var promTimeSeriesBatch = make([]prompb.TimeSeries, 0, len(entries))
+	for _, key := range keys {
+		promTimeSeriesBatch = append(promTimeSeriesBatch, entries[key])
+	}
+
+	return promTimeSeriesBatch
+}
+
 func timeFieldIndex(frame *data.Frame) (int, bool) {
 	timeFieldIndex := -1
 	for i, field := range frame.Fields {
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/require"
 )
 
@@ -85,3 +86,39 @@ func TestSerialize(t *testing.T) {
 	_, err := Serialize(frame)
 	require.NoError(t, err)
 }
+
+func TestTsFromFramesAt(t *testing.T) {
+	now := time.Now()
+	value := 4.0
+	frames := []*data.Frame{
+		data.NewFrame("",
+			data.NewField("time", nil, []time.Time{now.Add(-time.Minute), now}),
+			data.NewField("value", data.Labels{"__name__": "requests_total", "instance": "a"}, []float64{1.0, 2.0}),
+		),
+		data.NewFrame("",
+			data.NewField("B", data.Labels{"instance": "b", "job": "api"}, []*float64{&value}),
+		),
+		data.NewFrame("",
+			data.NewField("B", data.Labels{"instance": "c"}, []*float64{nil}),
+		),
+	}
+
+	ts := TimeSeriesFromFramesAt("job:requests:rate5m", now, map[string]string{"job": "grafana"}, frames...)
+	require.Len(t, ts, 2)
+
+	require.Equal(t, []prompb.Label{
+		{Name: "instance", Value: "a"},
+		{Name: "job", Value: "grafana"},
+		{Name: "__name__", Value: "job:requests:rate5m"},
+	}, ts[0].Labels)
+	require.Equal(t, []prompb.Sample{{Timestamp: toSampleTime(now), Value: 2.0}}, ts[0].Samples)
+
+	require.Equal(t, []prompb.Label{
+		{Name: "instance", Value: "b"},
+		{Name: "job", Value: "grafana"},
+		{Name: "__name__", Value: "job:requests:rate5m"},
+	}, ts[1].Labels)
+	require.Equal(t, []prompb.Sample{{Timestamp: toSampleTime(now), Value: 4.0}}, ts[1].Samples)
+
+	require.Empty(t, TimeSeriesFromFramesAt("", now, nil, frames...))
+}
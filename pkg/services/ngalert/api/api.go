@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/backtesting"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -58,6 +59,12 @@ type AlertingStore interface {
 	GetLatestAlertmanagerConfiguration(ctx context.Context, query *models.GetLatestAlertmanagerConfigurationQuery) error
 }
 
+// DeliveryLog is the log of the attempts to deliver notifications through the contact points.
+type DeliveryLog interface {
+	ListDeliveries(ctx context.Context, query *models.ListNotificationDeliveriesQuery) error
+	GetDeliveryStatus(ctx context.Context, query *models.GetNotificationDeliveryStatusQuery) error
+}
+
 // API handlers.
 type API struct {
 	Cfg                  *setting.Cfg
@@ -75,11 +82,15 @@ type API struct {
 	DataProxy            *datasourceproxy.DataSourceProxyService
 	MultiOrgAlertmanager *notifier.MultiOrgAlertmanager
 	StateManager         *state.Manager
+	StateHistorian       state.Historian
+	DeliveryLog          DeliveryLog
 	SecretsService       secrets.Service
 	AccessControl        accesscontrol.AccessControl
 	Policies             *provisioning.NotificationPolicyService
 	ContactPointService  *provisioning.ContactPointService
 	Templates            *provisioning.TemplateService
+	MuteTimings          *provisioning.MuteTimingService
+	AlertRules           *provisioning.AlertRuleService
 }
 
 // RegisterAPIEndpoints registers API handlers
@@ -93,13 +104,13 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 	api.RegisterAlertmanagerApiEndpoints(NewForkedAM(
 		api.DatasourceCache,
 		NewLotexAM(proxy, logger),
-		&AlertmanagerSrv{crypto: api.MultiOrgAlertmanager.Crypto, log: logger, ac: api.AccessControl, mam: api.MultiOrgAlertmanager},
+		&AlertmanagerSrv{crypto: api.MultiOrgAlertmanager.Crypto, log: logger, ac: api.AccessControl, mam: api.MultiOrgAlertmanager, deliveries: api.DeliveryLog},
 	), m)
 	// Register endpoints for proxying to Prometheus-compatible backends.
 	api.RegisterPrometheusApiEndpoints(NewForkedProm(
 		api.DatasourceCache,
 		NewLotexProm(proxy, logger),
-		&PrometheusSrv{log: logger, manager: api.StateManager, store: api.RuleStore, ac: api.AccessControl},
+		&PrometheusSrv{log: logger, manager: api.StateManager, historian: api.StateHistorian, store: api.RuleStore, ac: api.AccessControl},
 	), m)
 	// Register endpoints for proxying to Cortex Ruler-compatible backends.
 	api.RegisterRulerApiEndpoints(NewForkedRuler(
@@ -117,6 +128,7 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 			ac:              api.AccessControl,
 		},
 	), m)
+	evaluator := eval.NewEvaluator(api.Cfg, log.New("ngalert.eval"), api.DatasourceCache, api.SecretsService)
 	api.RegisterTestingApiEndpoints(NewForkedTestingApi(
 		&TestingApiSrv{
 			AlertingProxy:     proxy,
@@ -124,7 +136,9 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 			DatasourceCache:   api.DatasourceCache,
 			log:               logger,
 			accessControl:     api.AccessControl,
-			evaluator:         eval.NewEvaluator(api.Cfg, log.New("ngalert.eval"), api.DatasourceCache, api.SecretsService),
+			evaluator:         evaluator,
+			backtesting:       backtesting.NewEngine(evaluator, api.ExpressionService, log.New("ngalert.backtesting")),
+			cfg:               &api.Cfg.UnifiedAlerting,
 		}), m)
 	api.RegisterConfigurationApiEndpoints(NewForkedConfiguration(
 		&AdminSrv{
@@ -140,6 +154,10 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 			policies:            api.Policies,
 			contactPointService: api.ContactPointService,
 			templates:           api.Templates,
+			muteTimings:         api.MuteTimings,
+			alertRules:          api.AlertRules,
+			ruleStore:           api.RuleStore,
+			ac:                  api.AccessControl,
 		}), m)
 	}
 }
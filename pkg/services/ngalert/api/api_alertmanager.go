@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/util"
@@ -24,13 +25,17 @@ import (
 const (
 	defaultTestReceiversTimeout = 15 * time.Second
 	maxTestReceiversTimeout     = 30 * time.Second
+
+	defaultNotificationDeliveriesLimit = 100
+	maxNotificationDeliveriesLimit     = 5000
 )
 
 type AlertmanagerSrv struct {
-	log    log.Logger
-	ac     accesscontrol.AccessControl
-	mam    *notifier.MultiOrgAlertmanager
-	crypto notifier.Crypto
+	log        log.Logger
+	ac         accesscontrol.AccessControl
+	mam        *notifier.MultiOrgAlertmanager
+	crypto     notifier.Crypto
+	deliveries DeliveryLog
 }
 
 type UnknownReceiverError struct {
@@ -50,6 +55,116 @@ func (srv AlertmanagerSrv) RouteGetAMStatus(c *models.ReqContext) response.Respo
 	return response.JSON(http.StatusOK, am.GetStatus())
 }
 
+// RouteGetNotificationDeliveries returns the attempts to deliver notifications through the contact points of the organization.
+func (srv AlertmanagerSrv) RouteGetNotificationDeliveries(c *models.ReqContext) response.Response {
+	if srv.deliveries == nil {
+		return ErrResp(http.StatusNotFound, errors.New("the notification delivery log is disabled"), "")
+	}
+
+	query, err := parseNotificationDeliveriesQuery(c)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	if err := srv.deliveries.ListDeliveries(c.Req.Context(), query); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to query the notification deliveries")
+	}
+
+	result := apimodels.NotificationDeliveries{
+		Deliveries: make([]apimodels.NotificationDelivery, 0, len(query.Result)),
+	}
+	for _, d := range query.Result {
+		result.Deliveries = append(result.Deliveries, toNotificationDelivery(d))
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// RouteGetNotificationDeliveryStatus returns the last attempt and the last successful delivery of every integration
+// of the contact points of the organization.
+func (srv AlertmanagerSrv) RouteGetNotificationDeliveryStatus(c *models.ReqContext) response.Response {
+	if srv.deliveries == nil {
+		return ErrResp(http.StatusNotFound, errors.New("the notification delivery log is disabled"), "")
+	}
+
+	query := &ngmodels.GetNotificationDeliveryStatusQuery{
+		OrgID:    c.SignedInUser.OrgId,
+		Receiver: c.Query("receiver"),
+	}
+	if err := srv.deliveries.GetDeliveryStatus(c.Req.Context(), query); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get the status of the notification deliveries")
+	}
+
+	result := apimodels.NotificationDeliveryStatus{
+		Integrations: make([]apimodels.IntegrationDeliveryStatus, 0, len(query.Result)),
+	}
+	for _, s := range query.Result {
+		status := apimodels.IntegrationDeliveryStatus{
+			Receiver:         s.Receiver,
+			Integration:      s.Integration,
+			IntegrationIndex: s.IntegrationIndex,
+			LastAttempt:      toNotificationDelivery(s.LastAttempt),
+		}
+		if s.LastSuccess != nil {
+			lastSuccess := toNotificationDelivery(s.LastSuccess)
+			status.LastSuccess = &lastSuccess
+		}
+		result.Integrations = append(result.Integrations, status)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func parseNotificationDeliveriesQuery(c *models.ReqContext) (*ngmodels.ListNotificationDeliveriesQuery, error) {
+	query := &ngmodels.ListNotificationDeliveriesQuery{
+		OrgID:       c.SignedInUser.OrgId,
+		Receiver:    c.Query("receiver"),
+		Integration: c.Query("integration"),
+		Status:      ngmodels.NotificationDeliveryStatus(c.Query("status")),
+		GroupKey:    c.Query("groupKey"),
+		Limit:       defaultNotificationDeliveriesLimit,
+	}
+
+	switch query.Status {
+	case "", ngmodels.NotificationDeliverySuccess, ngmodels.NotificationDeliveryFailure:
+	default:
+		return nil, fmt.Errorf("invalid status %q: must be %s or %s", query.Status, ngmodels.NotificationDeliverySuccess, ngmodels.NotificationDeliveryFailure)
+	}
+
+	var err error
+	if query.From, err = parseHistoryTime(c.Query("from")); err != nil {
+		return nil, fmt.Errorf("invalid from: %w", err)
+	}
+	if query.To, err = parseHistoryTime(c.Query("to")); err != nil {
+		return nil, fmt.Errorf("invalid to: %w", err)
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		return nil, errors.New("to must not be before from")
+	}
+
+	if s := strings.TrimSpace(c.Query("limit")); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit <= 0 || limit > maxNotificationDeliveriesLimit {
+			return nil, fmt.Errorf("invalid limit %q: must be a positive integer up to %d", s, maxNotificationDeliveriesLimit)
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
+
+func toNotificationDelivery(d *ngmodels.NotificationDelivery) apimodels.NotificationDelivery {
+	return apimodels.NotificationDelivery{
+		Receiver:         d.Receiver,
+		Integration:      d.Integration,
+		IntegrationIndex: d.IntegrationIndex,
+		GroupKey:         d.GroupKey,
+		Status:           string(d.Status),
+		StatusCode:       d.StatusCode,
+		Error:            d.Error,
+		Attempt:          d.Attempt,
+		Alerts:           d.Alerts,
+		Duration:         d.Duration.Milliseconds(),
+		SentAt:           d.SentAt,
+	}
+}
+
 func (srv AlertmanagerSrv) RouteCreateSilence(c *models.ReqContext, postableSilence apimodels.PostableSilence) response.Response {
 	err := postableSilence.Validate(strfmt.Default)
 	if err != nil {
@@ -460,6 +460,103 @@ func TestRouteCreateSilence(t *testing.T) {
 	}
 }
 
+type fakeDeliveryLog struct {
+	listQuery   *ngmodels.ListNotificationDeliveriesQuery
+	statusQuery *ngmodels.GetNotificationDeliveryStatusQuery
+	deliveries  []*ngmodels.NotificationDelivery
+}
+
+func (l *fakeDeliveryLog) ListDeliveries(_ context.Context, query *ngmodels.ListNotificationDeliveriesQuery) error {
+	l.listQuery = query
+	query.Result = l.deliveries
+	return nil
+}
+
+func (l *fakeDeliveryLog) GetDeliveryStatus(_ context.Context, query *ngmodels.GetNotificationDeliveryStatusQuery) error {
+	l.statusQuery = query
+	for _, d := range l.deliveries {
+		status := &ngmodels.IntegrationDeliveryStatus{Receiver: d.Receiver, Integration: d.Integration, IntegrationIndex: d.IntegrationIndex, LastAttempt: d}
+		if d.Status == ngmodels.NotificationDeliverySuccess {
+			status.LastSuccess = d
+		}
+		query.Result = append(query.Result, status)
+	}
+	return nil
+}
+
+func TestRouteGetNotificationDeliveries(t *testing.T) {
+	orgID := int64(1)
+	newContext := func(t *testing.T, query string) *models.ReqContext {
+		req, err := http.NewRequest("GET", "/api/alertmanager/grafana/api/v2/deliveries?"+query, nil)
+		require.NoError(t, err)
+		return &models.ReqContext{Context: &web.Context{Req: req}, SignedInUser: &models.SignedInUser{OrgId: orgID}, IsSignedIn: true}
+	}
+	sentAt := time.Date(2022, 3, 10, 14, 0, 0, 0, time.UTC)
+	deliveries := []*ngmodels.NotificationDelivery{
+		{OrgID: orgID, Receiver: "oncall", Integration: "slack", GroupKey: "{}:{alertname=\"a\"}", Status: ngmodels.NotificationDeliveryFailure, StatusCode: 503, Error: "request to Slack API failed", Attempt: 2, Alerts: 1, Duration: 120 * time.Millisecond, SentAt: sentAt},
+	}
+
+	t.Run("when the delivery log is disabled", func(t *testing.T) {
+		srv := AlertmanagerSrv{}
+		require.Equal(t, http.StatusNotFound, srv.RouteGetNotificationDeliveries(newContext(t, "")).Status())
+		require.Equal(t, http.StatusNotFound, srv.RouteGetNotificationDeliveryStatus(newContext(t, "")).Status())
+	})
+
+	t.Run("with invalid parameters", func(t *testing.T) {
+		srv := AlertmanagerSrv{deliveries: &fakeDeliveryLog{}}
+		for _, query := range []string{"status=sent", "from=yesterday", "limit=0", "limit=5001", "from=200&to=100"} {
+			r := srv.RouteGetNotificationDeliveries(newContext(t, query))
+			require.Equalf(t, http.StatusBadRequest, r.Status(), "query %s", query)
+		}
+	})
+
+	t.Run("should return the delivery attempts", func(t *testing.T) {
+		deliveryLog := &fakeDeliveryLog{deliveries: deliveries}
+		srv := AlertmanagerSrv{deliveries: deliveryLog}
+
+		r := srv.RouteGetNotificationDeliveries(newContext(t, "receiver=oncall&status=failure&from=1646920800&limit=10"))
+		require.Equal(t, http.StatusOK, r.Status())
+		require.JSONEq(t, `
+{
+	"deliveries": [{
+		"receiver": "oncall",
+		"integration": "slack",
+		"integrationIndex": 0,
+		"groupKey": "{}:{alertname=\"a\"}",
+		"status": "failure",
+		"statusCode": 503,
+		"error": "request to Slack API failed",
+		"attempt": 2,
+		"alerts": 1,
+		"duration": 120,
+		"sentAt": "2022-03-10T14:00:00Z"
+	}]
+}
+`, string(r.Body()))
+
+		require.Equal(t, orgID, deliveryLog.listQuery.OrgID)
+		require.Equal(t, "oncall", deliveryLog.listQuery.Receiver)
+		require.Equal(t, ngmodels.NotificationDeliveryFailure, deliveryLog.listQuery.Status)
+		require.Equal(t, time.Unix(1646920800, 0).UTC(), deliveryLog.listQuery.From)
+		require.Equal(t, 10, deliveryLog.listQuery.Limit)
+	})
+
+	t.Run("should return the status of the integrations", func(t *testing.T) {
+		deliveryLog := &fakeDeliveryLog{deliveries: deliveries}
+		srv := AlertmanagerSrv{deliveries: deliveryLog}
+
+		r := srv.RouteGetNotificationDeliveryStatus(newContext(t, "receiver=oncall"))
+		require.Equal(t, http.StatusOK, r.Status())
+		var status apimodels.NotificationDeliveryStatus
+		require.NoError(t, json.Unmarshal(r.Body(), &status))
+		require.Len(t, status.Integrations, 1)
+		require.Equal(t, "slack", status.Integrations[0].Integration)
+		require.Equal(t, 503, status.Integrations[0].LastAttempt.StatusCode)
+		require.Nil(t, status.Integrations[0].LastSuccess)
+		require.Equal(t, "oncall", deliveryLog.statusQuery.Receiver)
+	})
+}
+
 func createSut(t *testing.T, accessControl accesscontrol.AccessControl) AlertmanagerSrv {
 	t.Helper()
 
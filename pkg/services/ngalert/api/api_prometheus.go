@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"sort"
 	"strconv"
@@ -20,17 +21,24 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 
+	"github.com/prometheus/alertmanager/pkg/labels"
 	apiv1 "github.com/prometheus/client_golang/api/prometheus/v1"
 )
 
 type PrometheusSrv struct {
-	log     log.Logger
-	manager state.AlertInstanceManager
-	store   store.RuleStore
-	ac      accesscontrol.AccessControl
+	log       log.Logger
+	manager   state.AlertInstanceManager
+	historian state.Historian
+	store     store.RuleStore
+	ac        accesscontrol.AccessControl
 }
 
-const queryIncludeInternalLabels = "includeInternalLabels"
+const (
+	queryIncludeInternalLabels = "includeInternalLabels"
+
+	defaultStateHistoryLimit = 100
+	maxStateHistoryLimit     = 5000
+)
 
 func (srv PrometheusSrv) RouteGetAlertStatuses(c *models.ReqContext) response.Response {
 	alertResponse := apimodels.AlertResponse{
@@ -194,6 +202,11 @@ func (srv PrometheusSrv) RouteGetRuleStatuses(c *models.ReqContext) response.Res
 			Type:           apiv1.RuleTypeAlerting,
 			LastEvaluation: time.Time{},
 		}
+		if rule.IsRecording() {
+			// recording rules do not produce alerts, they have no state.
+			alertingRule.State = ""
+			newRule.Type = apiv1.RuleTypeRecording
+		}
 
 		for _, alertState := range srv.manager.GetStatesForRuleUID(c.OrgId, rule.UID) {
 			activeAt := alertState.StartsAt
@@ -289,3 +302,156 @@ func encodedQueriesOrError(rules []ngmodels.AlertQuery) string {
 
 	return err.Error()
 }
+
+// RouteGetRuleStateHistory returns the state transitions of the alert instances of the rules visible to the user.
+func (srv PrometheusSrv) RouteGetRuleStateHistory(c *models.ReqContext) response.Response {
+	if srv.historian == nil {
+		return ErrResp(http.StatusNotFound, errors.New("the alert state history is disabled"), "")
+	}
+
+	query, err := parseStateHistoryQuery(c)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+
+	historyResponse := apimodels.StateHistoryResponse{
+		DiscoveryBase: apimodels.DiscoveryBase{
+			Status: "success",
+		},
+		Data: apimodels.StateHistoryDiscovery{
+			Transitions: []*apimodels.StateTransition{},
+		},
+	}
+
+	visible, err := srv.visibleRuleUIDs(c)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get the rules visible to the user")
+	}
+	if query.RuleUID != "" {
+		if _, ok := visible[query.RuleUID]; !ok {
+			return ErrResp(http.StatusNotFound, fmt.Errorf("rule %s not found", query.RuleUID), "")
+		}
+	}
+	if len(visible) == 0 {
+		return response.JSON(http.StatusOK, historyResponse)
+	}
+	if query.RuleUID == "" {
+		// The rules are filtered by the backend so that the limit only counts visible transitions.
+		query.RuleUIDs = visible
+	}
+
+	if err := srv.historian.QueryStates(c.Req.Context(), query); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to query the alert state history")
+	}
+
+	historyResponse.Data.Truncated = query.Truncated
+	for _, t := range query.Result {
+		historyResponse.Data.Transitions = append(historyResponse.Data.Transitions, &apimodels.StateTransition{
+			RuleUID:       t.RuleUID,
+			RuleTitle:     t.RuleTitle,
+			Labels:        map[string]string(t.Labels),
+			Values:        formatTransitionValues(t.Values),
+			PreviousState: t.PreviousState,
+			State:         t.State,
+			EvaluatedAt:   t.EvaluatedAt,
+		})
+	}
+
+	return response.JSON(http.StatusOK, historyResponse)
+}
+
+func parseStateHistoryQuery(c *models.ReqContext) (*ngmodels.ListStateHistoryQuery, error) {
+	query := &ngmodels.ListStateHistoryQuery{
+		OrgID:   c.SignedInUser.OrgId,
+		RuleUID: c.Query("ruleUID"),
+		Limit:   defaultStateHistoryLimit,
+	}
+
+	for _, m := range c.QueryStrings("matcher") {
+		matcher, err := labels.ParseMatcher(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: %w", m, err)
+		}
+		query.Matchers = append(query.Matchers, matcher)
+	}
+
+	var err error
+	if query.From, err = parseHistoryTime(c.Query("from")); err != nil {
+		return nil, fmt.Errorf("invalid from: %w", err)
+	}
+	if query.To, err = parseHistoryTime(c.Query("to")); err != nil {
+		return nil, fmt.Errorf("invalid to: %w", err)
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		return nil, errors.New("to must not be before from")
+	}
+
+	if s := strings.TrimSpace(c.Query("limit")); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit <= 0 || limit > maxStateHistoryLimit {
+			return nil, fmt.Errorf("invalid limit %q: must be a positive integer up to %d", s, maxStateHistoryLimit)
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
+
+// parseHistoryTime accepts the time formats of the Prometheus API, RFC3339 or Unix timestamp in seconds
+func parseHistoryTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		whole, fraction := math.Modf(seconds)
+		return time.Unix(int64(whole), int64(fraction*float64(time.Second))).UTC(), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+func formatTransitionValues(values map[string]*float64) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(values))
+	for k, v := range values {
+		if v != nil {
+			result[k] = strconv.FormatFloat(*v, 'e', -1, 64)
+		}
+	}
+	return result
+}
+
+// visibleRuleUIDs returns the rules in the folders the user can see which only query data sources the user can access
+func (srv PrometheusSrv) visibleRuleUIDs(c *models.ReqContext) (map[string]struct{}, error) {
+	result := make(map[string]struct{})
+
+	namespaceMap, err := srv.store.GetUserVisibleNamespaces(c.Req.Context(), c.OrgId, c.SignedInUser)
+	if err != nil {
+		return nil, err
+	}
+	if len(namespaceMap) == 0 {
+		return result, nil
+	}
+
+	namespaceUIDs := make([]string, 0, len(namespaceMap))
+	for k := range namespaceMap {
+		namespaceUIDs = append(namespaceUIDs, k)
+	}
+	alertRuleQuery := ngmodels.ListAlertRulesQuery{
+		OrgID:         c.SignedInUser.OrgId,
+		NamespaceUIDs: namespaceUIDs,
+	}
+	if err := srv.store.ListAlertRules(c.Req.Context(), &alertRuleQuery); err != nil {
+		return nil, err
+	}
+
+	hasAccess := func(evaluator accesscontrol.Evaluator) bool {
+		return accesscontrol.HasAccess(srv.ac, c)(accesscontrol.ReqSignedIn, evaluator)
+	}
+	for _, rule := range alertRuleQuery.Result {
+		if authorizeDatasourceAccessForRule(rule, hasAccess) {
+			result[rule.UID] = struct{}{}
+		}
+	}
+	return result, nil
+}
@@ -318,6 +318,44 @@ func TestRouteGetRuleStatuses(t *testing.T) {
 `, folder.Title), string(r.Body()))
 	})
 
+	t.Run("with a recording rule", func(t *testing.T) {
+		fakeStore, _, _, api := setupAPI(t)
+		rules := ngmodels.GenerateAlertRules(1, ngmodels.AlertRuleGen(withOrgID(orgID), asFixture(), withClassicConditionSingleQuery(), func(r *ngmodels.AlertRule) {
+			r.Record = &ngmodels.Record{Metric: "job:up:sum"}
+			r.For = 0
+		}))
+		fakeStore.PutRule(context.Background(), rules...)
+		folder := fakeStore.Folders[orgID][0]
+
+		r := api.RouteGetRuleStatuses(c)
+		require.Equal(t, http.StatusOK, r.Status())
+		require.JSONEq(t, fmt.Sprintf(`
+{
+	"status": "success",
+	"data": {
+		"groups": [{
+			"name": "rule-group",
+			"file": "%s",
+			"rules": [{
+				"name": "AlwaysFiring",
+				"query": "vector(1)",
+				"labels": {
+					"__a_private_label_on_the_rule__": "a_value"
+				},
+				"health": "ok",
+				"type": "recording",
+				"lastEvaluation": "0001-01-01T00:00:00Z",
+				"evaluationTime": 0
+			}],
+			"interval": 60,
+			"lastEvaluation": "0001-01-01T00:00:00Z",
+			"evaluationTime": 0
+		}]
+	}
+}
+`, folder.Title), string(r.Body()))
+	})
+
 	t.Run("with the inclusion of internal Labels", func(t *testing.T) {
 		fakeStore, fakeAIM, _, api := setupAPI(t)
 		generateRuleAndInstanceWithQuery(t, orgID, fakeAIM, fakeStore, withClassicConditionSingleQuery())
@@ -575,3 +613,143 @@ func withExpressionsMultiQuery() func(r *ngmodels.AlertRule) {
 		r.Data = queries
 	}
 }
+
+type fakeHistorian struct {
+	query       *ngmodels.ListStateHistoryQuery
+	transitions []*ngmodels.StateTransition
+}
+
+func (h *fakeHistorian) RecordStates(_ context.Context, transitions []*ngmodels.StateTransition) error {
+	h.transitions = append(h.transitions, transitions...)
+	return nil
+}
+
+func (h *fakeHistorian) QueryStates(_ context.Context, query *ngmodels.ListStateHistoryQuery) error {
+	h.query = query
+	query.Result = make([]*ngmodels.StateTransition, 0)
+	for _, t := range h.transitions {
+		if query.RuleUID != "" && t.RuleUID != query.RuleUID || !query.Matches(t) {
+			continue
+		}
+		query.Result = append(query.Result, t)
+		if query.Limit > 0 && len(query.Result) >= query.Limit {
+			break
+		}
+	}
+	return nil
+}
+
+func TestRouteGetRuleStateHistory(t *testing.T) {
+	orgID := int64(1)
+	newContext := func(t *testing.T, query string) *models.ReqContext {
+		req, err := http.NewRequest("GET", "/api/v1/rules/history?"+query, nil)
+		require.NoError(t, err)
+		return &models.ReqContext{Context: &web.Context{Req: req}, SignedInUser: &models.SignedInUser{OrgId: orgID}, IsSignedIn: true}
+	}
+
+	t.Run("when the state history is disabled", func(t *testing.T) {
+		_, _, _, api := setupAPI(t)
+		r := api.RouteGetRuleStateHistory(newContext(t, ""))
+		require.Equal(t, http.StatusNotFound, r.Status())
+	})
+
+	t.Run("with invalid parameters", func(t *testing.T) {
+		_, _, _, api := setupAPI(t)
+		api.historian = &fakeHistorian{}
+		for _, query := range []string{"matcher=%7Bfoo", "from=yesterday", "limit=-1", "limit=5001", "from=200&to=100"} {
+			r := api.RouteGetRuleStateHistory(newContext(t, query))
+			require.Equalf(t, http.StatusBadRequest, r.Status(), "query %s", query)
+		}
+	})
+
+	t.Run("with an unknown rule", func(t *testing.T) {
+		_, _, _, api := setupAPI(t)
+		api.historian = &fakeHistorian{}
+		r := api.RouteGetRuleStateHistory(newContext(t, "ruleUID=unknown"))
+		require.Equal(t, http.StatusNotFound, r.Status())
+	})
+
+	t.Run("should return the transitions of the visible rules", func(t *testing.T) {
+		fakeStore, _, _, api := setupAPI(t)
+		rule := ngmodels.AlertRuleGen(withOrgID(orgID))()
+		fakeStore.PutRule(context.Background(), rule)
+
+		value := 1.5
+		evaluatedAt := time.Date(2022, 3, 10, 14, 0, 0, 0, time.UTC)
+		historian := &fakeHistorian{transitions: []*ngmodels.StateTransition{
+			{
+				OrgID:         orgID,
+				RuleUID:       rule.UID,
+				RuleTitle:     rule.Title,
+				Labels:        data.Labels{"job": "prometheus"},
+				Values:        map[string]*float64{"B": &value},
+				PreviousState: eval.Normal.String(),
+				State:         eval.Alerting.String(),
+				EvaluatedAt:   evaluatedAt,
+			},
+			{
+				OrgID:         orgID,
+				RuleUID:       "deleted-rule",
+				Labels:        data.Labels{"job": "prometheus"},
+				PreviousState: eval.Normal.String(),
+				State:         eval.Alerting.String(),
+				EvaluatedAt:   evaluatedAt,
+			},
+		}}
+		api.historian = historian
+
+		r := api.RouteGetRuleStateHistory(newContext(t, fmt.Sprintf("ruleUID=%s&matcher=job%%3D%%22prometheus%%22&from=1646920800&to=2022-03-10T15:00:00Z&limit=10", rule.UID)))
+		require.Equal(t, http.StatusOK, r.Status())
+		require.JSONEq(t, fmt.Sprintf(`
+{
+	"status": "success",
+	"data": {
+		"transitions": [{
+			"ruleUID": "%s",
+			"ruleTitle": "%s",
+			"labels": {
+				"job": "prometheus"
+			},
+			"values": {
+				"B": "1.5e+00"
+			},
+			"previousState": "Normal",
+			"state": "Alerting",
+			"evaluatedAt": "2022-03-10T14:00:00Z"
+		}]
+	}
+}
+`, rule.UID, rule.Title), string(r.Body()))
+
+		require.Equal(t, orgID, historian.query.OrgID)
+		require.Equal(t, rule.UID, historian.query.RuleUID)
+		require.Len(t, historian.query.Matchers, 1)
+		require.Equal(t, `job="prometheus"`, historian.query.Matchers[0].String())
+		require.Equal(t, time.Unix(1646920800, 0).UTC(), historian.query.From)
+		require.Equal(t, evaluatedAt.Add(time.Hour), historian.query.To)
+		require.Equal(t, 10, historian.query.Limit)
+		require.Nil(t, historian.query.RuleUIDs)
+	})
+
+	t.Run("should apply the limit to the transitions of the visible rules", func(t *testing.T) {
+		fakeStore, _, _, api := setupAPI(t)
+		rule := ngmodels.AlertRuleGen(withOrgID(orgID))()
+		fakeStore.PutRule(context.Background(), rule)
+
+		evaluatedAt := time.Date(2022, 3, 10, 14, 0, 0, 0, time.UTC)
+		historian := &fakeHistorian{transitions: []*ngmodels.StateTransition{
+			{OrgID: orgID, RuleUID: "deleted-rule", State: eval.Alerting.String(), EvaluatedAt: evaluatedAt.Add(time.Minute)},
+			{OrgID: orgID, RuleUID: rule.UID, State: eval.Alerting.String(), EvaluatedAt: evaluatedAt},
+		}}
+		api.historian = historian
+
+		r := api.RouteGetRuleStateHistory(newContext(t, "limit=1"))
+		require.Equal(t, http.StatusOK, r.Status())
+		require.Equal(t, map[string]struct{}{rule.UID: {}}, historian.query.RuleUIDs)
+
+		result := apimodels.StateHistoryResponse{}
+		require.NoError(t, json.Unmarshal(r.Body(), &result))
+		require.Len(t, result.Data.Transitions, 1)
+		require.Equal(t, rule.UID, result.Data.Transitions[0].RuleUID)
+	})
+}
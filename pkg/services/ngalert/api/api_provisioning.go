@@ -3,11 +3,16 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	alerting_models "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
@@ -21,6 +26,10 @@ type ProvisioningSrv struct {
 	policies            NotificationPolicyService
 	contactPointService ContactPointService
 	templates           TemplateService
+	muteTimings         MuteTimingService
+	alertRules          AlertRuleService
+	ruleStore           store.RuleStore
+	ac                  accesscontrol.AccessControl
 }
 
 type ContactPointService interface {
@@ -34,6 +43,22 @@ type TemplateService interface {
 	GetTemplates(ctx context.Context, orgID int64) (map[string]string, error)
 }
 
+type MuteTimingService interface {
+	GetMuteTimings(ctx context.Context, orgID int64) ([]apimodels.MuteTimeInterval, error)
+	GetMuteTiming(ctx context.Context, orgID int64, name string) (apimodels.MuteTimeInterval, error)
+	CreateMuteTiming(ctx context.Context, orgID int64, mt apimodels.MuteTimeInterval, p alerting_models.Provenance) (apimodels.MuteTimeInterval, error)
+	UpdateMuteTiming(ctx context.Context, orgID int64, mt apimodels.MuteTimeInterval, p alerting_models.Provenance) (apimodels.MuteTimeInterval, error)
+	DeleteMuteTiming(ctx context.Context, orgID int64, name string, p alerting_models.Provenance) error
+}
+
+type AlertRuleService interface {
+	GetAlertRule(ctx context.Context, orgID int64, ruleUID string) (alerting_models.AlertRule, alerting_models.Provenance, error)
+	CreateAlertRule(ctx context.Context, rule alerting_models.AlertRule, provenance alerting_models.Provenance) (alerting_models.AlertRule, error)
+	UpdateAlertRule(ctx context.Context, rule alerting_models.AlertRule, provenance alerting_models.Provenance) (alerting_models.AlertRule, error)
+	DeleteAlertRule(ctx context.Context, orgID int64, ruleUID string, provenance alerting_models.Provenance) error
+	UpdateRuleGroup(ctx context.Context, orgID int64, folderUID, ruleGroup string, intervalSeconds int64, provenance alerting_models.Provenance) error
+}
+
 type NotificationPolicyService interface {
 	GetPolicyTree(ctx context.Context, orgID int64) (apimodels.Route, error)
 	UpdatePolicyTree(ctx context.Context, orgID int64, tree apimodels.Route, p alerting_models.Provenance) error
@@ -124,3 +149,193 @@ func (srv *ProvisioningSrv) RouteGetTemplate(c *models.ReqContext) response.Resp
 	}
 	return response.Empty(http.StatusNotFound)
 }
+
+func (srv *ProvisioningSrv) RouteGetMuteTimings(c *models.ReqContext) response.Response {
+	timings, err := srv.muteTimings.GetMuteTimings(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, timings)
+}
+
+func (srv *ProvisioningSrv) RouteGetMuteTiming(c *models.ReqContext) response.Response {
+	name := web.Params(c.Req)[":name"]
+	timing, err := srv.muteTimings.GetMuteTiming(c.Req.Context(), c.OrgId, name)
+	if errors.Is(err, provisioning.ErrMuteTimingNotFound) {
+		return response.Empty(http.StatusNotFound)
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, timing)
+}
+
+func (srv *ProvisioningSrv) RoutePostMuteTiming(c *models.ReqContext, mt apimodels.MuteTimeInterval) response.Response {
+	created, err := srv.muteTimings.CreateMuteTiming(c.Req.Context(), c.OrgId, mt, alerting_models.ProvenanceAPI)
+	if err != nil {
+		return provisioningErrResp(err)
+	}
+	return response.JSON(http.StatusCreated, created)
+}
+
+func (srv *ProvisioningSrv) RoutePutMuteTiming(c *models.ReqContext, mt apimodels.MuteTimeInterval) response.Response {
+	mt.Name = web.Params(c.Req)[":name"]
+	updated, err := srv.muteTimings.UpdateMuteTiming(c.Req.Context(), c.OrgId, mt, alerting_models.ProvenanceAPI)
+	if errors.Is(err, provisioning.ErrMuteTimingNotFound) {
+		return response.Empty(http.StatusNotFound)
+	}
+	if err != nil {
+		return provisioningErrResp(err)
+	}
+	return response.JSON(http.StatusOK, updated)
+}
+
+func (srv *ProvisioningSrv) RouteDeleteMuteTiming(c *models.ReqContext) response.Response {
+	name := web.Params(c.Req)[":name"]
+	if err := srv.muteTimings.DeleteMuteTiming(c.Req.Context(), c.OrgId, name, alerting_models.ProvenanceAPI); err != nil {
+		return provisioningErrResp(err)
+	}
+	return response.JSON(http.StatusNoContent, "")
+}
+
+func (srv *ProvisioningSrv) RouteGetAlertRule(c *models.ReqContext) response.Response {
+	rule, provenance, err := srv.alertRules.GetAlertRule(c.Req.Context(), c.OrgId, web.Params(c.Req)[":UID"])
+	if errors.Is(err, alerting_models.ErrAlertRuleNotFound) {
+		return response.Empty(http.StatusNotFound)
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	namespace, err := srv.ruleStore.GetNamespaceByUID(c.Req.Context(), rule.NamespaceUID, c.OrgId, c.SignedInUser, false)
+	if err != nil {
+		return toNamespaceErrorResponse(err)
+	}
+	hasAccess := func(evaluator accesscontrol.Evaluator) bool {
+		return accesscontrol.HasAccess(srv.ac, c)(accesscontrol.ReqSignedIn, evaluator)
+	}
+	namespaceScope := dashboards.ScopeFoldersProvider.GetResourceScope(strconv.FormatInt(namespace.Id, 10))
+	if !hasAccess(accesscontrol.EvalPermission(accesscontrol.ActionAlertingRuleRead, namespaceScope)) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to read alert rules of the folder %s", ErrAuthorization, namespace.Title), "")
+	}
+	if !srv.canAccessDatasources(c, &rule) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to read alert rule '%s' because the user does not have read permissions for one or many datasources the rule uses", ErrAuthorization, rule.Title), "")
+	}
+	return response.JSON(http.StatusOK, apimodels.NewAlertRule(rule, provenance))
+}
+
+func (srv *ProvisioningSrv) RoutePostAlertRule(c *models.ReqContext, ar apimodels.AlertRule) response.Response {
+	upstreamModel := ar.UpstreamModel()
+	upstreamModel.OrgID = c.OrgId
+	if resp := srv.authorizeRuleChanges(c, upstreamModel.NamespaceUID, &changes{New: []*alerting_models.AlertRule{&upstreamModel}}); resp != nil {
+		return resp
+	}
+	created, err := srv.alertRules.CreateAlertRule(c.Req.Context(), upstreamModel, alerting_models.ProvenanceAPI)
+	if err != nil {
+		return provisioningErrResp(err)
+	}
+	return response.JSON(http.StatusCreated, apimodels.NewAlertRule(created, alerting_models.ProvenanceAPI))
+}
+
+func (srv *ProvisioningSrv) RoutePutAlertRule(c *models.ReqContext, ar apimodels.AlertRule) response.Response {
+	updated := ar.UpstreamModel()
+	updated.OrgID = c.OrgId
+	updated.UID = web.Params(c.Req)[":UID"]
+	existing, _, err := srv.alertRules.GetAlertRule(c.Req.Context(), c.OrgId, updated.UID)
+	if errors.Is(err, alerting_models.ErrAlertRuleNotFound) {
+		return response.Empty(http.StatusNotFound)
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	if resp := srv.authorizeRuleChanges(c, updated.NamespaceUID, &changes{Update: []ruleUpdate{{Existing: &existing, New: &updated}}}); resp != nil {
+		return resp
+	}
+	updated, err = srv.alertRules.UpdateAlertRule(c.Req.Context(), updated, alerting_models.ProvenanceAPI)
+	if errors.Is(err, alerting_models.ErrAlertRuleNotFound) {
+		return response.Empty(http.StatusNotFound)
+	}
+	if err != nil {
+		return provisioningErrResp(err)
+	}
+	return response.JSON(http.StatusOK, apimodels.NewAlertRule(updated, alerting_models.ProvenanceAPI))
+}
+
+func (srv *ProvisioningSrv) RouteDeleteAlertRule(c *models.ReqContext) response.Response {
+	rule, _, err := srv.alertRules.GetAlertRule(c.Req.Context(), c.OrgId, web.Params(c.Req)[":UID"])
+	if errors.Is(err, alerting_models.ErrAlertRuleNotFound) {
+		return response.Empty(http.StatusNotFound)
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	if !srv.canAccessDatasources(c, &rule) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to delete alert rule '%s' because the user does not have read permissions for one or many datasources the rule uses", ErrAuthorization, rule.Title), "")
+	}
+	if resp := srv.authorizeRuleChanges(c, rule.NamespaceUID, &changes{Delete: []*alerting_models.AlertRule{&rule}}); resp != nil {
+		return resp
+	}
+	err = srv.alertRules.DeleteAlertRule(c.Req.Context(), c.OrgId, rule.UID, alerting_models.ProvenanceAPI)
+	if err != nil {
+		return provisioningErrResp(err)
+	}
+	return response.JSON(http.StatusNoContent, "")
+}
+
+func (srv *ProvisioningSrv) RoutePutAlertRuleGroup(c *models.ReqContext, ag apimodels.AlertRuleGroup) response.Response {
+	folderUID := web.Params(c.Req)[":FolderUID"]
+	group := web.Params(c.Req)[":Group"]
+	namespace, err := srv.ruleStore.GetNamespaceByUID(c.Req.Context(), folderUID, c.OrgId, c.SignedInUser, true)
+	if err != nil {
+		return toNamespaceErrorResponse(err)
+	}
+	namespaceScope := dashboards.ScopeFoldersProvider.GetResourceScope(strconv.FormatInt(namespace.Id, 10))
+	if !accesscontrol.HasAccess(srv.ac, c)(accesscontrol.ReqOrgAdminOrEditor, accesscontrol.EvalPermission(accesscontrol.ActionAlertingRuleUpdate, namespaceScope)) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to update alert rules that belong to folder %s", ErrAuthorization, namespace.Title), "")
+	}
+	err = srv.alertRules.UpdateRuleGroup(c.Req.Context(), c.OrgId, folderUID, group, int64(time.Duration(ag.Interval).Seconds()), alerting_models.ProvenanceAPI)
+	if errors.Is(err, alerting_models.ErrAlertRuleNotFound) {
+		return response.Empty(http.StatusNotFound)
+	}
+	if err != nil {
+		return provisioningErrResp(err)
+	}
+	return response.JSON(http.StatusOK, ag)
+}
+
+// authorizeRuleChanges resolves the folder the rules are saved in and checks, like the ruler API, that the user
+// is allowed to change the rules of the folder and to query the data sources of the rules.
+// It returns the error response when the changes are not authorized.
+func (srv *ProvisioningSrv) authorizeRuleChanges(c *models.ReqContext, folderUID string, ch *changes) response.Response {
+	namespace, err := srv.ruleStore.GetNamespaceByUID(c.Req.Context(), folderUID, c.OrgId, c.SignedInUser, true)
+	if errors.Is(err, models.ErrFolderNotFound) {
+		return ErrResp(http.StatusBadRequest, err, "folder %s of the rule does not exist", folderUID)
+	}
+	if err != nil {
+		return toNamespaceErrorResponse(err)
+	}
+	hasAccess := accesscontrol.HasAccess(srv.ac, c)
+	if _, err := authorizeRuleChanges(namespace, ch, func(evaluator accesscontrol.Evaluator) bool {
+		return hasAccess(accesscontrol.ReqOrgAdminOrEditor, evaluator)
+	}); err != nil {
+		return ErrResp(http.StatusUnauthorized, err, "")
+	}
+	return nil
+}
+
+// canAccessDatasources checks that the user can query all data sources of the rule.
+func (srv *ProvisioningSrv) canAccessDatasources(c *models.ReqContext, rule *alerting_models.AlertRule) bool {
+	return authorizeDatasourceAccessForRule(rule, func(evaluator accesscontrol.Evaluator) bool {
+		return accesscontrol.HasAccess(srv.ac, c)(accesscontrol.ReqSignedIn, evaluator)
+	})
+}
+
+// provisioningErrResp maps the errors of the provisioning services to a response.
+func provisioningErrResp(err error) response.Response {
+	if errors.Is(err, provisioning.ErrValidation) ||
+		errors.Is(err, provisioning.ErrProvenanceMismatch) ||
+		errors.Is(err, alerting_models.ErrAlertRuleFailedValidation) ||
+		errors.Is(err, alerting_models.ErrAlertRuleUniqueConstraintViolation) {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	return ErrResp(http.StatusInternalServerError, err, "")
+}
@@ -4,21 +4,28 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	domain "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/web"
+	amconfig "github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 )
 
 func TestProvisioningApi(t *testing.T) {
 	t.Run("successful GET policies returns 200", func(t *testing.T) {
-		sut := createProvisioningSrvSut()
+		sut := createProvisioningSrvSut(t)
 		rc := createTestRequestCtx()
 
 		response := sut.RouteGetPolicyTree(&rc)
@@ -27,7 +34,7 @@ func TestProvisioningApi(t *testing.T) {
 	})
 
 	t.Run("successful POST policies returns 202", func(t *testing.T) {
-		sut := createProvisioningSrvSut()
+		sut := createProvisioningSrvSut(t)
 		rc := createTestRequestCtx()
 		tree := apimodels.Route{}
 
@@ -38,7 +45,7 @@ func TestProvisioningApi(t *testing.T) {
 
 	t.Run("when new policy tree is invalid", func(t *testing.T) {
 		t.Run("POST policies returns 400", func(t *testing.T) {
-			sut := createProvisioningSrvSut()
+			sut := createProvisioningSrvSut(t)
 			sut.policies = &fakeRejectingNotificationPolicyService{}
 			rc := createTestRequestCtx()
 			tree := apimodels.Route{}
@@ -53,7 +60,7 @@ func TestProvisioningApi(t *testing.T) {
 
 	t.Run("when org has no AM config", func(t *testing.T) {
 		t.Run("GET policies returns 404", func(t *testing.T) {
-			sut := createProvisioningSrvSut()
+			sut := createProvisioningSrvSut(t)
 			rc := createTestRequestCtx()
 			rc.SignedInUser.OrgId = 2
 
@@ -63,7 +70,7 @@ func TestProvisioningApi(t *testing.T) {
 		})
 
 		t.Run("POST policies returns 404", func(t *testing.T) {
-			sut := createProvisioningSrvSut()
+			sut := createProvisioningSrvSut(t)
 			rc := createTestRequestCtx()
 			rc.SignedInUser.OrgId = 2
 
@@ -75,7 +82,7 @@ func TestProvisioningApi(t *testing.T) {
 
 	t.Run("when an unspecified error occurrs", func(t *testing.T) {
 		t.Run("GET policies returns 500", func(t *testing.T) {
-			sut := createProvisioningSrvSut()
+			sut := createProvisioningSrvSut(t)
 			sut.policies = &fakeFailingNotificationPolicyService{}
 			rc := createTestRequestCtx()
 
@@ -87,7 +94,7 @@ func TestProvisioningApi(t *testing.T) {
 		})
 
 		t.Run("POST policies returns 500", func(t *testing.T) {
-			sut := createProvisioningSrvSut()
+			sut := createProvisioningSrvSut(t)
 			sut.policies = &fakeFailingNotificationPolicyService{}
 			rc := createTestRequestCtx()
 			tree := apimodels.Route{}
@@ -101,10 +108,303 @@ func TestProvisioningApi(t *testing.T) {
 	})
 }
 
-func createProvisioningSrvSut() ProvisioningSrv {
+func TestProvisioningApiAlertRules(t *testing.T) {
+	rule := apimodels.AlertRule{UID: "rule", Title: "rule", FolderUID: "folder", RuleGroup: "group"}
+
+	t.Run("GET returns the rule with its provenance", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		sut.alertRules.(*fakeAlertRuleService).put(domain.AlertRule{OrgID: 1, UID: "rule", Title: "rule", NamespaceUID: "folder"}, domain.ProvenanceFile)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":UID": "rule"})
+
+		response := sut.RouteGetAlertRule(&rc)
+
+		require.Equal(t, 200, response.Status())
+		require.Contains(t, string(response.Body()), `"provenance":"file"`)
+	})
+
+	t.Run("GET of an unknown rule returns 404", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":UID": "unknown"})
+
+		response := sut.RouteGetAlertRule(&rc)
+
+		require.Equal(t, 404, response.Status())
+	})
+
+	t.Run("POST creates the rule in the org of the user", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		rc := createTestRequestCtx()
+
+		response := sut.RoutePostAlertRule(&rc, rule)
+
+		require.Equal(t, 201, response.Status())
+		created, provenance, err := sut.alertRules.GetAlertRule(context.Background(), 1, "rule")
+		require.NoError(t, err)
+		require.Equal(t, "rule", created.Title)
+		require.Equal(t, domain.ProvenanceAPI, provenance)
+	})
+
+	t.Run("PUT of a rule provisioned from a file returns 400", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		sut.alertRules.(*fakeAlertRuleService).put(domain.AlertRule{OrgID: 1, UID: "rule", Title: "provisioned", NamespaceUID: "folder"}, domain.ProvenanceFile)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":UID": "rule"})
+
+		response := sut.RoutePutAlertRule(&rc, rule)
+
+		require.Equal(t, 400, response.Status())
+		require.Contains(t, string(response.Body()), provisioning.ErrProvenanceMismatch.Error())
+		stored, _, err := sut.alertRules.GetAlertRule(context.Background(), 1, "rule")
+		require.NoError(t, err)
+		require.Equal(t, "provisioned", stored.Title)
+	})
+
+	t.Run("PUT of an unknown rule returns 404", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":UID": "unknown"})
+
+		response := sut.RoutePutAlertRule(&rc, rule)
+
+		require.Equal(t, 404, response.Status())
+	})
+
+	t.Run("DELETE of a rule provisioned from a file returns 400", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		sut.alertRules.(*fakeAlertRuleService).put(domain.AlertRule{OrgID: 1, UID: "rule", NamespaceUID: "folder"}, domain.ProvenanceFile)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":UID": "rule"})
+
+		response := sut.RouteDeleteAlertRule(&rc)
+
+		require.Equal(t, 400, response.Status())
+	})
+
+	t.Run("DELETE of a rule created with the API returns 204", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		sut.alertRules.(*fakeAlertRuleService).put(domain.AlertRule{OrgID: 1, UID: "rule", NamespaceUID: "folder"}, domain.ProvenanceAPI)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":UID": "rule"})
+
+		response := sut.RouteDeleteAlertRule(&rc)
+
+		require.Equal(t, 204, response.Status())
+		_, _, err := sut.alertRules.GetAlertRule(context.Background(), 1, "rule")
+		require.ErrorIs(t, err, domain.ErrAlertRuleNotFound)
+	})
+
+	t.Run("POST in a folder that does not exist returns 400", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		rc := createTestRequestCtx()
+		r := rule
+		r.FolderUID = "unknown"
+
+		response := sut.RoutePostAlertRule(&rc, r)
+
+		require.Equal(t, 400, response.Status())
+		_, _, err := sut.alertRules.GetAlertRule(context.Background(), 1, "rule")
+		require.ErrorIs(t, err, domain.ErrAlertRuleNotFound)
+	})
+
+	t.Run("POST without the permission to create rules in the folder returns 401", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		sut.ac = acmock.New().WithPermissions([]*accesscontrol.Permission{
+			{Action: accesscontrol.ActionAlertingRuleRead, Scope: dashboards.ScopeFoldersProvider.GetResourceScope("1")},
+		})
+		rc := createTestRequestCtx()
+
+		response := sut.RoutePostAlertRule(&rc, rule)
+
+		require.Equal(t, 401, response.Status())
+		_, _, err := sut.alertRules.GetAlertRule(context.Background(), 1, "rule")
+		require.ErrorIs(t, err, domain.ErrAlertRuleNotFound)
+	})
+
+	t.Run("POST of a rule querying a data source the user cannot query returns 401", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		sut.ac = acmock.New().WithPermissions([]*accesscontrol.Permission{
+			{Action: accesscontrol.ActionAlertingRuleCreate, Scope: dashboards.ScopeFoldersProvider.GetResourceScope("1")},
+		})
+		rc := createTestRequestCtx()
+		r := rule
+		r.Data = []domain.AlertQuery{{RefID: "A", DatasourceUID: "prom"}}
+
+		response := sut.RoutePostAlertRule(&rc, r)
+
+		require.Equal(t, 401, response.Status())
+	})
+
+	t.Run("PUT that moves a rule to a folder that does not exist returns 400", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		sut.alertRules.(*fakeAlertRuleService).put(domain.AlertRule{OrgID: 1, UID: "rule", Title: "rule", NamespaceUID: "folder"}, domain.ProvenanceAPI)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":UID": "rule"})
+		r := rule
+		r.FolderUID = "unknown"
+
+		response := sut.RoutePutAlertRule(&rc, r)
+
+		require.Equal(t, 400, response.Status())
+	})
+
+	t.Run("GET without the permission to read the rules of the folder returns 401", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		sut.ac = acmock.New().WithPermissions(nil)
+		sut.alertRules.(*fakeAlertRuleService).put(domain.AlertRule{OrgID: 1, UID: "rule", Title: "rule", NamespaceUID: "folder"}, domain.ProvenanceAPI)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":UID": "rule"})
+
+		response := sut.RouteGetAlertRule(&rc)
+
+		require.Equal(t, 401, response.Status())
+	})
+
+	t.Run("PUT of a group in a folder that does not exist returns 404", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":FolderUID": "unknown", ":Group": "group"})
+
+		response := sut.RoutePutAlertRuleGroup(&rc, apimodels.AlertRuleGroup{Interval: model.Duration(2 * time.Minute)})
+
+		require.Equal(t, 404, response.Status())
+	})
+
+	t.Run("PUT of a group with a rule provisioned from a file returns 400", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		sut.alertRules.(*fakeAlertRuleService).put(domain.AlertRule{OrgID: 1, UID: "rule", NamespaceUID: "folder", RuleGroup: "group"}, domain.ProvenanceFile)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":FolderUID": "folder", ":Group": "group"})
+
+		response := sut.RoutePutAlertRuleGroup(&rc, apimodels.AlertRuleGroup{Interval: model.Duration(2 * time.Minute)})
+
+		require.Equal(t, 400, response.Status())
+		require.Contains(t, string(response.Body()), provisioning.ErrProvenanceMismatch.Error())
+	})
+}
+
+func TestProvisioningApiMuteTimings(t *testing.T) {
+	timing := apimodels.MuteTimeInterval{MuteTimeInterval: amconfig.MuteTimeInterval{Name: "weekends"}}
+
+	t.Run("GET of an unknown mute timing returns 404", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":name": "unknown"})
+
+		response := sut.RouteGetMuteTiming(&rc)
+
+		require.Equal(t, 404, response.Status())
+	})
+
+	t.Run("POST creates the mute timing", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		rc := createTestRequestCtx()
+
+		response := sut.RoutePostMuteTiming(&rc, timing)
+
+		require.Equal(t, 201, response.Status())
+		created, err := sut.muteTimings.GetMuteTiming(context.Background(), 1, "weekends")
+		require.NoError(t, err)
+		require.Equal(t, domain.ProvenanceAPI, created.Provenance)
+	})
+
+	t.Run("POST of an invalid mute timing returns 400", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		rc := createTestRequestCtx()
+
+		response := sut.RoutePostMuteTiming(&rc, apimodels.MuteTimeInterval{})
+
+		require.Equal(t, 400, response.Status())
+	})
+
+	t.Run("PUT of a mute timing provisioned from a file returns 400", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		sut.muteTimings.(*fakeMuteTimingService).put(timing, domain.ProvenanceFile)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":name": "weekends"})
+
+		response := sut.RoutePutMuteTiming(&rc, timing)
+
+		require.Equal(t, 400, response.Status())
+		require.Contains(t, string(response.Body()), provisioning.ErrProvenanceMismatch.Error())
+	})
+
+	t.Run("PUT of an unknown mute timing returns 404", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":name": "weekends"})
+
+		response := sut.RoutePutMuteTiming(&rc, timing)
+
+		require.Equal(t, 404, response.Status())
+	})
+
+	t.Run("DELETE of a mute timing provisioned from a file returns 400", func(t *testing.T) {
+		sut := createProvisioningSrvSut(t)
+		sut.muteTimings.(*fakeMuteTimingService).put(timing, domain.ProvenanceFile)
+		rc := createTestRequestCtx()
+		rc.Req = web.SetURLParams(rc.Req, map[string]string{":name": "weekends"})
+
+		response := sut.RouteDeleteMuteTiming(&rc)
+
+		require.Equal(t, 400, response.Status())
+		_, err := sut.muteTimings.GetMuteTiming(context.Background(), 1, "weekends")
+		require.NoError(t, err)
+	})
+}
+
+func TestProvisioningApiAuthorization(t *testing.T) {
+	api := &API{AccessControl: acmock.New().WithDisabled()}
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/api/provisioning/alert-rules/{UID}"},
+		{http.MethodPost, "/api/provisioning/alert-rules"},
+		{http.MethodPut, "/api/provisioning/alert-rules/{UID}"},
+		{http.MethodDelete, "/api/provisioning/alert-rules/{UID}"},
+		{http.MethodGet, "/api/provisioning/mute-timings"},
+		{http.MethodGet, "/api/provisioning/mute-timings/{name}"},
+		{http.MethodPost, "/api/provisioning/mute-timings"},
+		{http.MethodPut, "/api/provisioning/mute-timings/{name}"},
+		{http.MethodDelete, "/api/provisioning/mute-timings/{name}"},
+	}
+	status := func(method, path string, role models.RoleType) int {
+		handler, ok := api.authorize(method, path).(func(*models.ReqContext))
+		require.True(t, ok)
+		recorder := httptest.NewRecorder()
+		rc := &models.ReqContext{
+			Context:      &web.Context{Req: httptest.NewRequest(method, path, nil), Resp: web.NewResponseWriter(method, recorder)},
+			SignedInUser: &models.SignedInUser{OrgId: 1, OrgRole: role},
+			IsSignedIn:   true,
+			Logger:       log.NewNopLogger(),
+		}
+		handler(rc)
+		return recorder.Code
+	}
+
+	for _, route := range routes {
+		viewerStatus := status(route.method, route.path, models.ROLE_VIEWER)
+		if route.method == http.MethodGet {
+			require.Equalf(t, http.StatusOK, viewerStatus, "%s %s", route.method, route.path)
+		} else {
+			require.Equalf(t, http.StatusForbidden, viewerStatus, "%s %s", route.method, route.path)
+		}
+		require.Equalf(t, http.StatusOK, status(route.method, route.path, models.ROLE_EDITOR), "%s %s", route.method, route.path)
+	}
+}
+
+func createProvisioningSrvSut(t *testing.T) ProvisioningSrv {
+	ruleStore := store.NewFakeRuleStore(t)
+	ruleStore.Folders[1] = []*models.Folder{{Id: 1, Uid: "folder", Title: "Folder"}}
 	return ProvisioningSrv{
-		log:      log.NewNopLogger(),
-		policies: newFakeNotificationPolicyService(),
+		log:         log.NewNopLogger(),
+		policies:    newFakeNotificationPolicyService(),
+		alertRules:  newFakeAlertRuleService(),
+		muteTimings: newFakeMuteTimingService(),
+		ruleStore:   ruleStore,
+		ac:          acmock.New().WithDisabled(),
 	}
 }
 
@@ -114,8 +414,10 @@ func createTestRequestCtx() models.ReqContext {
 			Req: &http.Request{},
 		},
 		SignedInUser: &models.SignedInUser{
-			OrgId: 1,
+			OrgId:   1,
+			OrgRole: models.ROLE_EDITOR,
 		},
+		IsSignedIn: true,
 	}
 }
 
@@ -170,3 +472,138 @@ func (f *fakeRejectingNotificationPolicyService) GetPolicyTree(ctx context.Conte
 func (f *fakeRejectingNotificationPolicyService) UpdatePolicyTree(ctx context.Context, orgID int64, tree apimodels.Route, p domain.Provenance) error {
 	return fmt.Errorf("%w: invalid policy tree", provisioning.ErrValidation)
 }
+
+type fakeAlertRuleService struct {
+	rules       map[string]domain.AlertRule
+	provenances map[string]domain.Provenance
+}
+
+func newFakeAlertRuleService() *fakeAlertRuleService {
+	return &fakeAlertRuleService{
+		rules:       map[string]domain.AlertRule{},
+		provenances: map[string]domain.Provenance{},
+	}
+}
+
+func (f *fakeAlertRuleService) put(rule domain.AlertRule, p domain.Provenance) {
+	f.rules[rule.UID] = rule
+	f.provenances[rule.UID] = p
+}
+
+func (f *fakeAlertRuleService) checkProvenance(uid string, p domain.Provenance) error {
+	if !domain.CanUpdateProvenance(f.provenances[uid], p) {
+		return fmt.Errorf("%w: cannot change provenance from '%s' to '%s'", provisioning.ErrProvenanceMismatch, f.provenances[uid], p)
+	}
+	return nil
+}
+
+func (f *fakeAlertRuleService) GetAlertRule(ctx context.Context, orgID int64, ruleUID string) (domain.AlertRule, domain.Provenance, error) {
+	rule, ok := f.rules[ruleUID]
+	if !ok || rule.OrgID != orgID {
+		return domain.AlertRule{}, domain.ProvenanceNone, domain.ErrAlertRuleNotFound
+	}
+	return rule, f.provenances[ruleUID], nil
+}
+
+func (f *fakeAlertRuleService) CreateAlertRule(ctx context.Context, rule domain.AlertRule, p domain.Provenance) (domain.AlertRule, error) {
+	if _, ok := f.rules[rule.UID]; ok {
+		return domain.AlertRule{}, domain.ErrAlertRuleUniqueConstraintViolation
+	}
+	f.put(rule, p)
+	return rule, nil
+}
+
+func (f *fakeAlertRuleService) UpdateAlertRule(ctx context.Context, rule domain.AlertRule, p domain.Provenance) (domain.AlertRule, error) {
+	if _, _, err := f.GetAlertRule(ctx, rule.OrgID, rule.UID); err != nil {
+		return domain.AlertRule{}, err
+	}
+	if err := f.checkProvenance(rule.UID, p); err != nil {
+		return domain.AlertRule{}, err
+	}
+	f.put(rule, p)
+	return rule, nil
+}
+
+func (f *fakeAlertRuleService) DeleteAlertRule(ctx context.Context, orgID int64, ruleUID string, p domain.Provenance) error {
+	if err := f.checkProvenance(ruleUID, p); err != nil {
+		return err
+	}
+	delete(f.rules, ruleUID)
+	delete(f.provenances, ruleUID)
+	return nil
+}
+
+func (f *fakeAlertRuleService) UpdateRuleGroup(ctx context.Context, orgID int64, folderUID, ruleGroup string, intervalSeconds int64, p domain.Provenance) error {
+	for uid, rule := range f.rules {
+		if rule.OrgID != orgID || rule.NamespaceUID != folderUID || rule.RuleGroup != ruleGroup {
+			continue
+		}
+		if err := f.checkProvenance(uid, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fakeMuteTimingService struct {
+	timings map[string]apimodels.MuteTimeInterval
+}
+
+func newFakeMuteTimingService() *fakeMuteTimingService {
+	return &fakeMuteTimingService{timings: map[string]apimodels.MuteTimeInterval{}}
+}
+
+func (f *fakeMuteTimingService) put(mt apimodels.MuteTimeInterval, p domain.Provenance) {
+	mt.Provenance = p
+	f.timings[mt.Name] = mt
+}
+
+func (f *fakeMuteTimingService) checkProvenance(name string, p domain.Provenance) error {
+	if stored := f.timings[name].Provenance; !domain.CanUpdateProvenance(stored, p) {
+		return fmt.Errorf("%w: cannot change provenance from '%s' to '%s'", provisioning.ErrProvenanceMismatch, stored, p)
+	}
+	return nil
+}
+
+func (f *fakeMuteTimingService) GetMuteTimings(ctx context.Context, orgID int64) ([]apimodels.MuteTimeInterval, error) {
+	result := make([]apimodels.MuteTimeInterval, 0, len(f.timings))
+	for _, mt := range f.timings {
+		result = append(result, mt)
+	}
+	return result, nil
+}
+
+func (f *fakeMuteTimingService) GetMuteTiming(ctx context.Context, orgID int64, name string) (apimodels.MuteTimeInterval, error) {
+	mt, ok := f.timings[name]
+	if !ok {
+		return apimodels.MuteTimeInterval{}, provisioning.ErrMuteTimingNotFound
+	}
+	return mt, nil
+}
+
+func (f *fakeMuteTimingService) CreateMuteTiming(ctx context.Context, orgID int64, mt apimodels.MuteTimeInterval, p domain.Provenance) (apimodels.MuteTimeInterval, error) {
+	if mt.Name == "" {
+		return apimodels.MuteTimeInterval{}, fmt.Errorf("%w: missing name", provisioning.ErrValidation)
+	}
+	f.put(mt, p)
+	return f.timings[mt.Name], nil
+}
+
+func (f *fakeMuteTimingService) UpdateMuteTiming(ctx context.Context, orgID int64, mt apimodels.MuteTimeInterval, p domain.Provenance) (apimodels.MuteTimeInterval, error) {
+	if _, ok := f.timings[mt.Name]; !ok {
+		return apimodels.MuteTimeInterval{}, provisioning.ErrMuteTimingNotFound
+	}
+	if err := f.checkProvenance(mt.Name, p); err != nil {
+		return apimodels.MuteTimeInterval{}, err
+	}
+	f.put(mt, p)
+	return f.timings[mt.Name], nil
+}
+
+func (f *fakeMuteTimingService) DeleteMuteTiming(ctx context.Context, orgID int64, name string, p domain.Provenance) error {
+	if err := f.checkProvenance(name, p); err != nil {
+		return err
+	}
+	delete(f.timings, name)
+	return nil
+}
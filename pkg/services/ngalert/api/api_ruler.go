@@ -83,8 +83,16 @@ func (srv RulerSrv) RouteDeleteAlertRules(c *models.ReqContext) response.Respons
 			return nil
 		}
 
+		provenances, err := srv.provenanceStore.GetProvenances(ctx, c.SignedInUser.OrgId, (&ngmodels.AlertRule{}).ResourceType())
+		if err != nil {
+			return err
+		}
+
 		canDelete = make([]string, 0, len(q.Result))
 		for _, rule := range q.Result {
+			if !ngmodels.CanUpdateProvenance(provenances[rule.UID], ngmodels.ProvenanceNone) {
+				return fmt.Errorf("%w: rule %s was provisioned with provenance '%s'", provisioning.ErrProvenanceMismatch, rule.UID, provenances[rule.UID])
+			}
 			if authorizeDatasourceAccessForRule(rule, hasAccess) {
 				canDelete = append(canDelete, rule.UID)
 				continue
@@ -107,6 +115,9 @@ func (srv RulerSrv) RouteDeleteAlertRules(c *models.ReqContext) response.Respons
 		if errors.Is(err, ErrAuthorization) {
 			return ErrResp(http.StatusUnauthorized, err, "")
 		}
+		if errors.Is(err, provisioning.ErrProvenanceMismatch) {
+			return ErrResp(http.StatusBadRequest, err, "failed to delete rule group")
+		}
 		return ErrResp(http.StatusInternalServerError, err, "failed to delete rule group")
 	}
 
@@ -337,120 +348,140 @@ func (srv RulerSrv) RoutePostNameRulesConfig(c *models.ReqContext, ruleGroupConf
 // All operations are performed in a single transaction
 func (srv RulerSrv) updateAlertRulesInGroup(c *models.ReqContext, namespace *models.Folder, groupName string, rules []*ngmodels.AlertRule) response.Response {
 	var authorizedChanges *changes
-	hasAccess := accesscontrol.HasAccess(srv.ac, c)
 	err := srv.xactManager.InTransaction(c.Req.Context(), func(tranCtx context.Context) error {
-		logger := srv.log.New("namespace_uid", namespace.Uid, "group", groupName, "org_id", c.OrgId, "user_id", c.UserId)
-
 		groupChanges, err := calculateChanges(tranCtx, srv.store, c.SignedInUser.OrgId, namespace, groupName, rules)
 		if err != nil {
 			return err
 		}
+		authorizedChanges, err = srv.applyRuleGroupChanges(tranCtx, c, namespace, groupName, groupChanges)
+		return err
+	})
 
-		if groupChanges.isEmpty() {
-			authorizedChanges = groupChanges
-			logger.Info("no changes detected in the request. Do nothing")
-			return nil
-		}
+	if err != nil {
+		return toRuleGroupUpdateErrorResponse(err)
+	}
 
-		authorizedChanges, err = authorizeRuleChanges(namespace, groupChanges, func(evaluator accesscontrol.Evaluator) bool {
-			return hasAccess(accesscontrol.ReqOrgAdminOrEditor, evaluator)
-		})
-		if err != nil {
-			return err
-		}
+	srv.notifyScheduler(c.SignedInUser.OrgId, authorizedChanges)
 
-		if authorizedChanges.isEmpty() {
-			logger.Info("no authorized changes detected in the request. Do nothing", "not_authorized_add", len(groupChanges.New), "not_authorized_update", len(groupChanges.Update), "not_authorized_delete", len(groupChanges.Delete))
-			return nil
-		}
+	if authorizedChanges.isEmpty() {
+		return response.JSON(http.StatusAccepted, util.DynMap{"message": "no changes detected in the rule group"})
+	}
 
-		if len(groupChanges.Delete) > len(authorizedChanges.Delete) {
-			logger.Info("user is not authorized to delete one or many rules in the group. those rules will be skipped", "expected", len(groupChanges.Delete), "authorized", len(authorizedChanges.Delete))
-		}
+	return response.JSON(http.StatusAccepted, util.DynMap{"message": "rule group updated successfully"})
+}
 
-		logger.Debug("updating database with the authorized changes", "add", len(authorizedChanges.New), "update", len(authorizedChanges.New), "delete", len(authorizedChanges.Delete))
-
-		if len(authorizedChanges.Update) > 0 || len(authorizedChanges.New) > 0 {
-			updates := make([]store.UpdateRule, 0, len(authorizedChanges.Update))
-			inserts := make([]ngmodels.AlertRule, 0, len(authorizedChanges.New))
-			for _, update := range authorizedChanges.Update {
-				logger.Debug("updating rule", "rule_uid", update.New.UID, "diff", update.Diff.String())
-				updates = append(updates, store.UpdateRule{
-					Existing: update.Existing,
-					New:      *update.New,
-				})
-			}
-			for _, rule := range authorizedChanges.New {
-				inserts = append(inserts, *rule)
-			}
-			err = srv.store.InsertAlertRules(tranCtx, inserts)
-			if err != nil {
-				return fmt.Errorf("failed to add rules: %w", err)
-			}
-			err = srv.store.UpdateAlertRules(tranCtx, updates)
-			if err != nil {
-				return fmt.Errorf("failed to update rules: %w", err)
-			}
-		}
+// applyRuleGroupChanges verifies that the user is authorized to do the changes of the group and writes the authorized changes to the database.
+// It must be called in a transaction. Returns the changes that were written.
+func (srv RulerSrv) applyRuleGroupChanges(tranCtx context.Context, c *models.ReqContext, namespace *models.Folder, groupName string, groupChanges *changes) (*changes, error) {
+	logger := srv.log.New("namespace_uid", namespace.Uid, "group", groupName, "org_id", c.OrgId, "user_id", c.UserId)
 
-		if len(authorizedChanges.Delete) > 0 {
-			UIDs := make([]string, 0, len(authorizedChanges.Delete))
-			for _, rule := range authorizedChanges.Delete {
-				UIDs = append(UIDs, rule.UID)
-			}
+	if groupChanges.isEmpty() {
+		logger.Info("no changes detected in the request. Do nothing")
+		return groupChanges, nil
+	}
 
-			if err = srv.store.DeleteAlertRulesByUID(tranCtx, c.SignedInUser.OrgId, UIDs...); err != nil {
-				return fmt.Errorf("failed to delete rules: %w", err)
-			}
+	hasAccess := accesscontrol.HasAccess(srv.ac, c)
+	authorizedChanges, err := authorizeRuleChanges(namespace, groupChanges, func(evaluator accesscontrol.Evaluator) bool {
+		return hasAccess(accesscontrol.ReqOrgAdminOrEditor, evaluator)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if authorizedChanges.isEmpty() {
+		logger.Info("no authorized changes detected in the request. Do nothing", "not_authorized_add", len(groupChanges.New), "not_authorized_update", len(groupChanges.Update), "not_authorized_delete", len(groupChanges.Delete))
+		return authorizedChanges, nil
+	}
+
+	if err := verifyProvisionedRulesNotAffected(tranCtx, srv.provenanceStore, c.SignedInUser.OrgId, authorizedChanges); err != nil {
+		return nil, err
+	}
+
+	if len(groupChanges.Delete) > len(authorizedChanges.Delete) {
+		logger.Info("user is not authorized to delete one or many rules in the group. those rules will be skipped", "expected", len(groupChanges.Delete), "authorized", len(authorizedChanges.Delete))
+	}
+
+	logger.Debug("updating database with the authorized changes", "add", len(authorizedChanges.New), "update", len(authorizedChanges.New), "delete", len(authorizedChanges.Delete))
+
+	if len(authorizedChanges.Update) > 0 || len(authorizedChanges.New) > 0 {
+		updates := make([]store.UpdateRule, 0, len(authorizedChanges.Update))
+		inserts := make([]ngmodels.AlertRule, 0, len(authorizedChanges.New))
+		for _, update := range authorizedChanges.Update {
+			logger.Debug("updating rule", "rule_uid", update.New.UID, "diff", update.Diff.String())
+			updates = append(updates, store.UpdateRule{
+				Existing: update.Existing,
+				New:      *update.New,
+			})
+		}
+		for _, rule := range authorizedChanges.New {
+			inserts = append(inserts, *rule)
 		}
+		err = srv.store.InsertAlertRules(tranCtx, inserts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add rules: %w", err)
+		}
+		err = srv.store.UpdateAlertRules(tranCtx, updates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update rules: %w", err)
+		}
+	}
 
-		if len(authorizedChanges.New) > 0 {
-			limitReached, err := srv.QuotaService.CheckQuotaReached(tranCtx, "alert_rule", &quota.ScopeParameters{
-				OrgId:  c.OrgId,
-				UserId: c.UserId,
-			}) // alert rule is table name
-			if err != nil {
-				return fmt.Errorf("failed to get alert rules quota: %w", err)
-			}
-			if limitReached {
-				return errQuotaReached
-			}
+	if len(authorizedChanges.Delete) > 0 {
+		UIDs := make([]string, 0, len(authorizedChanges.Delete))
+		for _, rule := range authorizedChanges.Delete {
+			UIDs = append(UIDs, rule.UID)
 		}
-		return nil
-	})
 
-	if err != nil {
-		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
-			return ErrResp(http.StatusNotFound, err, "failed to update rule group")
-		} else if errors.Is(err, ngmodels.ErrAlertRuleFailedValidation) {
-			return ErrResp(http.StatusBadRequest, err, "failed to update rule group")
-		} else if errors.Is(err, errQuotaReached) {
-			return ErrResp(http.StatusForbidden, err, "")
-		} else if errors.Is(err, ErrAuthorization) {
-			return ErrResp(http.StatusUnauthorized, err, "")
+		if err = srv.store.DeleteAlertRulesByUID(tranCtx, c.SignedInUser.OrgId, UIDs...); err != nil {
+			return nil, fmt.Errorf("failed to delete rules: %w", err)
 		}
-		return ErrResp(http.StatusInternalServerError, err, "failed to update rule group")
 	}
 
-	for _, rule := range authorizedChanges.Update {
+	if len(authorizedChanges.New) > 0 {
+		limitReached, err := srv.QuotaService.CheckQuotaReached(tranCtx, "alert_rule", &quota.ScopeParameters{
+			OrgId:  c.OrgId,
+			UserId: c.UserId,
+		}) // alert rule is table name
+		if err != nil {
+			return nil, fmt.Errorf("failed to get alert rules quota: %w", err)
+		}
+		if limitReached {
+			return nil, errQuotaReached
+		}
+	}
+	return authorizedChanges, nil
+}
+
+// notifyScheduler lets the scheduler know about the rules that were updated or deleted.
+func (srv RulerSrv) notifyScheduler(orgID int64, ch *changes) {
+	for _, rule := range ch.Update {
 		srv.scheduleService.UpdateAlertRule(ngmodels.AlertRuleKey{
-			OrgID: c.SignedInUser.OrgId,
+			OrgID: orgID,
 			UID:   rule.Existing.UID,
 		})
 	}
 
-	for _, rule := range authorizedChanges.Delete {
+	for _, rule := range ch.Delete {
 		srv.scheduleService.DeleteAlertRule(ngmodels.AlertRuleKey{
-			OrgID: c.SignedInUser.OrgId,
+			OrgID: orgID,
 			UID:   rule.UID,
 		})
 	}
+}
 
-	if authorizedChanges.isEmpty() {
-		return response.JSON(http.StatusAccepted, util.DynMap{"message": "no changes detected in the rule group"})
-	}
-
-	return response.JSON(http.StatusAccepted, util.DynMap{"message": "rule group updated successfully"})
+func toRuleGroupUpdateErrorResponse(err error) response.Response {
+	if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+		return ErrResp(http.StatusNotFound, err, "failed to update rule group")
+	} else if errors.Is(err, ngmodels.ErrAlertRuleFailedValidation) {
+		return ErrResp(http.StatusBadRequest, err, "failed to update rule group")
+	} else if errors.Is(err, errQuotaReached) {
+		return ErrResp(http.StatusForbidden, err, "")
+	} else if errors.Is(err, ErrAuthorization) {
+		return ErrResp(http.StatusUnauthorized, err, "")
+	} else if errors.Is(err, provisioning.ErrProvenanceMismatch) {
+		return ErrResp(http.StatusBadRequest, err, "failed to update rule group")
+	}
+	return ErrResp(http.StatusInternalServerError, err, "failed to update rule group")
 }
 
 func toGettableExtendedRuleNode(r ngmodels.AlertRule, namespaceID int64, provenanceRecords map[string]ngmodels.Provenance) apimodels.GettableExtendedRuleNode {
@@ -475,6 +506,7 @@ func toGettableExtendedRuleNode(r ngmodels.AlertRule, namespaceID int64, provena
 			NoDataState:     apimodels.NoDataState(r.NoDataState),
 			ExecErrState:    apimodels.ExecutionErrorState(r.ExecErrState),
 			Provenance:      provenance,
+			Record:          r.Record,
 		},
 	}
 	gettableExtendedRuleNode.ApiRuleNode = &apimodels.ApiRuleNode{
@@ -584,5 +616,28 @@ func calculateChanges(ctx context.Context, ruleStore store.RuleStore, orgId int6
 	}, nil
 }
 
+// verifyProvisionedRulesNotAffected returns an error if the changes update or delete rules that were provisioned,
+// those can only be changed through the provisioning API or the provisioning files.
+func verifyProvisionedRulesNotAffected(ctx context.Context, provenanceStore provisioning.ProvisioningStore, orgID int64, ch *changes) error {
+	if len(ch.Update) == 0 && len(ch.Delete) == 0 {
+		return nil
+	}
+	provenances, err := provenanceStore.GetProvenances(ctx, orgID, (&ngmodels.AlertRule{}).ResourceType())
+	if err != nil {
+		return err
+	}
+	affected := make([]*ngmodels.AlertRule, 0, len(ch.Update)+len(ch.Delete))
+	for _, update := range ch.Update {
+		affected = append(affected, update.Existing)
+	}
+	affected = append(affected, ch.Delete...)
+	for _, rule := range affected {
+		if p := provenances[rule.UID]; !ngmodels.CanUpdateProvenance(p, ngmodels.ProvenanceNone) {
+			return fmt.Errorf("%w: rule %s was provisioned with provenance '%s'", provisioning.ErrProvenanceMismatch, rule.UID, p)
+		}
+	}
+	return nil
+}
+
 // alertRuleFieldsToIgnoreInDiff contains fields that the AlertRule.Diff should ignore
 var alertRuleFieldsToIgnoreInDiff = []string{"ID", "Version", "Updated"}
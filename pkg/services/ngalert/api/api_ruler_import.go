@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/prom"
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// maxRuleFileSize is the largest rule file accepted by RouteImportPrometheusRules.
+const maxRuleFileSize = 10 << 20
+
+// RouteImportPrometheusRules converts the rule groups of a Prometheus or Loki rule file to Grafana managed rules that query the data source
+// (query parameter datasource_uid, of type prometheus or loki) and saves them in the namespace (request parameter :Namespace).
+// The converted rules are matched with the rules of the namespace that were created by an import by their key, therefore importing a file again updates the rules.
+// Like in Prometheus, a group of the file replaces the rule group of the namespace that has the same name.
+func (srv RulerSrv) RouteImportPrometheusRules(c *models.ReqContext) response.Response {
+	namespaceTitle := web.Params(c.Req)[":Namespace"]
+	namespace, err := srv.store.GetNamespaceByTitle(c.Req.Context(), namespaceTitle, c.SignedInUser.OrgId, c.SignedInUser, true)
+	if err != nil {
+		return toNamespaceErrorResponse(err)
+	}
+
+	datasourceUID := c.Query("datasource_uid")
+	if datasourceUID == "" {
+		return ErrResp(http.StatusBadRequest, errors.New("query parameter datasource_uid is required"), "")
+	}
+	ds, err := srv.DatasourceCache.GetDatasourceByUID(c.Req.Context(), datasourceUID, c.SignedInUser, c.SkipCache)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to get data source %s", datasourceUID)
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Resp, c.Req.Body, maxRuleFileSize))
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to read the rule file")
+	}
+	converter, err := prom.NewConverter(prom.Config{
+		DatasourceUID:   ds.Uid,
+		DatasourceType:  ds.Type,
+		BaseInterval:    srv.cfg.BaseInterval,
+		DefaultInterval: srv.cfg.DefaultRuleEvaluationInterval,
+		RecordingRules:  srv.cfg.RecordingRules.Enabled,
+	})
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	groups, err := converter.Parse(body)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	result, err := converter.Convert(c.SignedInUser.OrgId, namespace.Uid, groups)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	if err := result.Validate(conditionValidator(c, srv.DatasourceCache)); err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+
+	resp := apimodels.ImportPrometheusRulesResponse{
+		Message: "rules imported successfully",
+		Groups:  make([]apimodels.ImportedRuleGroup, 0, len(result.Groups)),
+		Skipped: make([]apimodels.SkippedPrometheusRule, 0, len(result.Skipped)),
+	}
+	hasAccess := accesscontrol.HasAccess(srv.ac, c)
+	var imported []*prom.GroupChanges
+	err = srv.xactManager.InTransaction(c.Req.Context(), func(tranCtx context.Context) error {
+		var err error
+		imported, err = prom.Import(tranCtx, srv.store, srv.provenanceStore, c.SignedInUser.OrgId, namespace.Uid, result, prom.ImportOptions{
+			Authorize: func(ch *prom.GroupChanges) (*prom.GroupChanges, error) {
+				authorized, err := authorizeRuleChanges(namespace, importChanges(ch), func(evaluator accesscontrol.Evaluator) bool {
+					return hasAccess(accesscontrol.ReqOrgAdminOrEditor, evaluator)
+				})
+				if err != nil {
+					return nil, err
+				}
+				// the rules of the group the user cannot query are not deleted
+				ch.Delete = authorized.Delete
+				return ch, nil
+			},
+			CheckQuota: func(ctx context.Context, count int) error {
+				limitReached, err := srv.QuotaService.CheckQuotaReachedFor(ctx, "alert_rule", &quota.ScopeParameters{
+					OrgId:  c.OrgId,
+					UserId: c.UserId,
+				}, int64(count)) // alert rule is table name
+				if err != nil {
+					return fmt.Errorf("failed to get alert rules quota: %w", err)
+				}
+				if limitReached {
+					return errQuotaReached
+				}
+				return nil
+			},
+		})
+		return err
+	})
+	if err != nil {
+		return toRuleGroupUpdateErrorResponse(err)
+	}
+
+	for _, ch := range imported {
+		srv.notifyScheduler(c.SignedInUser.OrgId, importChanges(ch))
+		resp.Groups = append(resp.Groups, apimodels.ImportedRuleGroup{
+			Name:    ch.Group,
+			Added:   len(ch.New),
+			Updated: len(ch.Update),
+			Deleted: len(ch.Delete),
+		})
+	}
+	for _, skipped := range result.Skipped {
+		resp.Skipped = append(resp.Skipped, apimodels.SkippedPrometheusRule{
+			Group:  skipped.Group,
+			Name:   skipped.Name,
+			Reason: skipped.Reason,
+		})
+	}
+	return response.JSON(http.StatusAccepted, resp)
+}
+
+// importChanges returns the changes of an import of a rule group in the form the ruler API authorizes them.
+func importChanges(ch *prom.GroupChanges) *changes {
+	result := &changes{New: ch.New, Delete: ch.Delete}
+	for i := range ch.Update {
+		result.Update = append(result.Update, ruleUpdate{Existing: ch.Update[i].Existing, New: &ch.Update[i].New})
+	}
+	return result
+}
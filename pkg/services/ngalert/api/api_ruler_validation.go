@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/prometheus/common/model"
+
 	"github.com/grafana/grafana/pkg/models"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -97,6 +99,15 @@ func validateRuleNode(
 		}
 	}
 
+	if record := ruleNode.GrafanaManagedAlert.Record; record != nil {
+		if !model.IsValidMetricName(model.LabelValue(record.Metric)) {
+			return nil, fmt.Errorf("%w: invalid metric name %q of recording rule", ngmodels.ErrAlertRuleFailedValidation, record.Metric)
+		}
+		if ruleNode.ApiRuleNode != nil && ruleNode.ApiRuleNode.For != 0 {
+			return nil, fmt.Errorf("%w: recording rules cannot have a pending period", ngmodels.ErrAlertRuleFailedValidation)
+		}
+	}
+
 	newAlertRule := ngmodels.AlertRule{
 		OrgID:           orgId,
 		Title:           ruleNode.GrafanaManagedAlert.Title,
@@ -108,6 +119,7 @@ func validateRuleNode(
 		RuleGroup:       groupName,
 		NoDataState:     noDataState,
 		ExecErrState:    errorState,
+		Record:          ruleNode.GrafanaManagedAlert.Record,
 	}
 
 	if ruleNode.ApiRuleNode != nil {
@@ -297,6 +297,19 @@ func TestValidateRuleNode_NoUID(t *testing.T) {
 				require.Equal(t, int64(panelId), *alert.PanelID)
 			},
 		},
+		{
+			name: "converts recording rule",
+			rule: func() *apimodels.PostableExtendedRuleNode {
+				r := validRule()
+				r.ApiRuleNode.For = 0
+				r.GrafanaManagedAlert.Record = &models.Record{Metric: "job:requests:rate5m"}
+				return &r
+			},
+			assert: func(t *testing.T, api *apimodels.PostableExtendedRuleNode, alert *models.AlertRule) {
+				require.True(t, alert.IsRecording())
+				require.Equal(t, "job:requests:rate5m", alert.Record.Metric)
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -437,6 +450,24 @@ func TestValidateRuleNodeFailures_NoUID(t *testing.T) {
 				return &r
 			},
 		},
+		{
+			name: "fail if the metric name of a recording rule is invalid",
+			rule: func() *apimodels.PostableExtendedRuleNode {
+				r := validRule()
+				r.ApiRuleNode.For = 0
+				r.GrafanaManagedAlert.Record = &models.Record{Metric: "requests per second"}
+				return &r
+			},
+		},
+		{
+			name: "fail if a recording rule has a pending period",
+			rule: func() *apimodels.PostableExtendedRuleNode {
+				r := validRule()
+				r.ApiRuleNode.For = model.Duration(time.Minute)
+				r.GrafanaManagedAlert.Record = &models.Record{Metric: "job:requests:rate5m"}
+				return &r
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
@@ -17,8 +18,10 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/backtesting"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -30,6 +33,8 @@ type TestingApiSrv struct {
 	log               log.Logger
 	accessControl     accesscontrol.AccessControl
 	evaluator         eval.Evaluator
+	backtesting       *backtesting.Engine
+	cfg               *setting.UnifiedAlertingSettings
 }
 
 func (srv TestingApiSrv) RouteTestGrafanaRuleConfig(c *models.ReqContext, body apimodels.TestRulePayload) response.Response {
@@ -134,3 +139,65 @@ func (srv TestingApiSrv) RouteEvalQueries(c *models.ReqContext, cmd apimodels.Ev
 
 	return response.JSONStreaming(http.StatusOK, evalResults)
 }
+
+func (srv TestingApiSrv) RouteBacktestConfig(c *models.ReqContext, cmd apimodels.BacktestConfig) response.Response {
+	if !authorizeDatasourceAccessForRule(&ngmodels.AlertRule{Data: cmd.Data}, func(evaluator accesscontrol.Evaluator) bool {
+		return accesscontrol.HasAccess(srv.accessControl, c)(accesscontrol.ReqSignedIn, evaluator)
+	}) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to query one or many data sources used by the rule", ErrAuthorization), "")
+	}
+
+	evalCond := ngmodels.Condition{
+		Condition: cmd.Condition,
+		OrgID:     c.SignedInUser.OrgId,
+		Data:      cmd.Data,
+	}
+	if err := validateCondition(c.Req.Context(), evalCond, c.SignedInUser, c.SkipCache, srv.DatasourceCache); err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid condition")
+	}
+
+	noDataState := ngmodels.NoData
+	if cmd.NoDataState != "" {
+		var err error
+		noDataState, err = ngmodels.NoDataStateFromString(string(cmd.NoDataState))
+		if err != nil {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+	}
+	errorState := ngmodels.AlertingErrState
+	if cmd.ExecErrState != "" {
+		var err error
+		errorState, err = ngmodels.ErrStateFromString(string(cmd.ExecErrState))
+		if err != nil {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+	}
+
+	interval := time.Duration(cmd.Interval)
+	if interval == 0 {
+		interval = srv.cfg.DefaultRuleEvaluationInterval
+	}
+
+	rule := &ngmodels.AlertRule{
+		OrgID:           c.SignedInUser.OrgId,
+		UID:             "backtesting",
+		Title:           cmd.Title,
+		Condition:       cmd.Condition,
+		Data:            cmd.Data,
+		IntervalSeconds: int64(interval.Seconds()),
+		NoDataState:     noDataState,
+		ExecErrState:    errorState,
+		For:             time.Duration(cmd.For),
+		Annotations:     cmd.Annotations,
+		Labels:          cmd.Labels,
+	}
+
+	result, err := srv.backtesting.Test(c.Req.Context(), rule, cmd.From, cmd.To)
+	if err != nil {
+		if errors.Is(err, backtesting.ErrInvalidInputData) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to backtest the rule")
+	}
+	return response.JSON(http.StatusOK, result)
+}
@@ -9,14 +9,17 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	models2 "github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	acMock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	fakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/backtesting"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -266,6 +269,62 @@ func TestRouteEvalQueries(t *testing.T) {
 	})
 }
 
+func TestRouteBacktestConfig(t *testing.T) {
+	rc := &models2.ReqContext{
+		Context: &web.Context{
+			Req: &http.Request{},
+		},
+		SignedInUser: &models2.SignedInUser{
+			OrgId: 1,
+		},
+	}
+
+	t.Run("should return 401 if user cannot query a data source", func(t *testing.T) {
+		data1 := models.GenerateAlertQuery()
+		data2 := models.GenerateAlertQuery()
+
+		ac := acMock.New().WithPermissions([]*accesscontrol.Permission{
+			{Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceScopeUID(data1.DatasourceUID)},
+		})
+
+		srv := createTestingApiSrv(nil, ac, nil)
+
+		response := srv.RouteBacktestConfig(rc, definitions.BacktestConfig{
+			Condition: data1.RefID,
+			Data:      []models.AlertQuery{data1, data2},
+		})
+
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+	})
+
+	t.Run("should return 400 if the time range is invalid", func(t *testing.T) {
+		data1 := models.GenerateAlertQuery()
+
+		ac := acMock.New().WithPermissions([]*accesscontrol.Permission{
+			{Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceScopeUID(data1.DatasourceUID)},
+		})
+		ds := &fakes.FakeCacheService{DataSources: []*models2.DataSource{
+			{Uid: data1.DatasourceUID},
+		}}
+
+		evaluator := &eval.FakeEvaluator{}
+		srv := createTestingApiSrv(ds, ac, evaluator)
+		srv.cfg = &setting.UnifiedAlertingSettings{DefaultRuleEvaluationInterval: time.Minute}
+		srv.backtesting = backtesting.NewEngine(evaluator, nil, log.New("test"))
+
+		now := time.Now()
+		response := srv.RouteBacktestConfig(rc, definitions.BacktestConfig{
+			From:      now,
+			To:        now.Add(-time.Hour),
+			Condition: data1.RefID,
+			Data:      []models.AlertQuery{data1},
+		})
+
+		require.Equal(t, http.StatusBadRequest, response.Status())
+		evaluator.AssertNotCalled(t, "ConditionEval", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
 func createTestingApiSrv(ds *fakes.FakeCacheService, ac *acMock.Mock, evaluator *eval.FakeEvaluator) *TestingApiSrv {
 	if ac == nil {
 		ac = acMock.New().WithDisabled()
@@ -183,6 +183,14 @@ func (f *ForkedAlertmanagerApi) forkRouteGetGrafanaAlertingConfig(ctx *models.Re
 	return f.GrafanaSvc.RouteGetAlertingConfig(ctx)
 }
 
+func (f *ForkedAlertmanagerApi) forkRouteGetGrafanaNotificationDeliveries(ctx *models.ReqContext) response.Response {
+	return f.GrafanaSvc.RouteGetNotificationDeliveries(ctx)
+}
+
+func (f *ForkedAlertmanagerApi) forkRouteGetGrafanaNotificationDeliveryStatus(ctx *models.ReqContext) response.Response {
+	return f.GrafanaSvc.RouteGetNotificationDeliveryStatus(ctx)
+}
+
 func (f *ForkedAlertmanagerApi) forkRouteGetGrafanaSilence(ctx *models.ReqContext) response.Response {
 	return f.GrafanaSvc.RouteGetSilence(ctx)
 }
@@ -59,3 +59,7 @@ func (f *ForkedPrometheusApi) forkRouteGetGrafanaAlertStatuses(ctx *models.ReqCo
 func (f *ForkedPrometheusApi) forkRouteGetGrafanaRuleStatuses(ctx *models.ReqContext) response.Response {
 	return f.GrafanaSvc.RouteGetRuleStatuses(ctx)
 }
+
+func (f *ForkedPrometheusApi) forkRouteGetGrafanaRuleStateHistory(ctx *models.ReqContext) response.Response {
+	return f.GrafanaSvc.RouteGetRuleStateHistory(ctx)
+}
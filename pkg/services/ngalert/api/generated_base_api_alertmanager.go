@@ -34,6 +34,8 @@ type AlertmanagerApiForkingService interface {
 	RouteGetGrafanaAMAlerts(*models.ReqContext) response.Response
 	RouteGetGrafanaAMStatus(*models.ReqContext) response.Response
 	RouteGetGrafanaAlertingConfig(*models.ReqContext) response.Response
+	RouteGetGrafanaNotificationDeliveries(*models.ReqContext) response.Response
+	RouteGetGrafanaNotificationDeliveryStatus(*models.ReqContext) response.Response
 	RouteGetGrafanaSilence(*models.ReqContext) response.Response
 	RouteGetGrafanaSilences(*models.ReqContext) response.Response
 	RouteGetSilence(*models.ReqContext) response.Response
@@ -110,6 +112,14 @@ func (f *ForkedAlertmanagerApi) RouteGetGrafanaAlertingConfig(ctx *models.ReqCon
 	return f.forkRouteGetGrafanaAlertingConfig(ctx)
 }
 
+func (f *ForkedAlertmanagerApi) RouteGetGrafanaNotificationDeliveries(ctx *models.ReqContext) response.Response {
+	return f.forkRouteGetGrafanaNotificationDeliveries(ctx)
+}
+
+func (f *ForkedAlertmanagerApi) RouteGetGrafanaNotificationDeliveryStatus(ctx *models.ReqContext) response.Response {
+	return f.forkRouteGetGrafanaNotificationDeliveryStatus(ctx)
+}
+
 func (f *ForkedAlertmanagerApi) RouteGetGrafanaSilence(ctx *models.ReqContext) response.Response {
 	return f.forkRouteGetGrafanaSilence(ctx)
 }
@@ -316,6 +326,26 @@ func (api *API) RegisterAlertmanagerApiEndpoints(srv AlertmanagerApiForkingServi
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/alertmanager/grafana/api/v2/deliveries"),
+			api.authorize(http.MethodGet, "/api/alertmanager/grafana/api/v2/deliveries"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/alertmanager/grafana/api/v2/deliveries",
+				srv.RouteGetGrafanaNotificationDeliveries,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/alertmanager/grafana/api/v2/deliveries/status"),
+			api.authorize(http.MethodGet, "/api/alertmanager/grafana/api/v2/deliveries/status"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/alertmanager/grafana/api/v2/deliveries/status",
+				srv.RouteGetGrafanaNotificationDeliveryStatus,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/alertmanager/grafana/api/v2/silence/{SilenceId}"),
 			api.authorize(http.MethodGet, "/api/alertmanager/grafana/api/v2/silence/{SilenceId}"),
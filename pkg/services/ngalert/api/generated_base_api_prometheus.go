@@ -20,6 +20,7 @@ import (
 type PrometheusApiForkingService interface {
 	RouteGetAlertStatuses(*models.ReqContext) response.Response
 	RouteGetGrafanaAlertStatuses(*models.ReqContext) response.Response
+	RouteGetGrafanaRuleStateHistory(*models.ReqContext) response.Response
 	RouteGetGrafanaRuleStatuses(*models.ReqContext) response.Response
 	RouteGetRuleStatuses(*models.ReqContext) response.Response
 }
@@ -32,6 +33,10 @@ func (f *ForkedPrometheusApi) RouteGetGrafanaAlertStatuses(ctx *models.ReqContex
 	return f.forkRouteGetGrafanaAlertStatuses(ctx)
 }
 
+func (f *ForkedPrometheusApi) RouteGetGrafanaRuleStateHistory(ctx *models.ReqContext) response.Response {
+	return f.forkRouteGetGrafanaRuleStateHistory(ctx)
+}
+
 func (f *ForkedPrometheusApi) RouteGetGrafanaRuleStatuses(ctx *models.ReqContext) response.Response {
 	return f.forkRouteGetGrafanaRuleStatuses(ctx)
 }
@@ -62,6 +67,16 @@ func (api *API) RegisterPrometheusApiEndpoints(srv PrometheusApiForkingService,
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/prometheus/grafana/api/v1/rules/history"),
+			api.authorize(http.MethodGet, "/api/prometheus/grafana/api/v1/rules/history"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/prometheus/grafana/api/v1/rules/history",
+				srv.RouteGetGrafanaRuleStateHistory,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/prometheus/grafana/api/v1/rules"),
 			api.authorize(http.MethodGet, "/api/prometheus/grafana/api/v1/rules"),
@@ -86,6 +86,23 @@ import (
 //       200: GettableStatus
 //       400: ValidationError
 
+// swagger:route GET /api/alertmanager/grafana/api/v2/deliveries alertmanager RouteGetGrafanaNotificationDeliveries
+//
+// get the attempts to deliver notifications through the contact points, newest first
+//
+//     Responses:
+//       200: NotificationDeliveries
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:route GET /api/alertmanager/grafana/api/v2/deliveries/status alertmanager RouteGetGrafanaNotificationDeliveryStatus
+//
+// get the last delivery attempt and the last successful delivery of every integration of the contact points
+//
+//     Responses:
+//       200: NotificationDeliveryStatus
+//       404: NotFound
+
 // swagger:route GET /api/alertmanager/grafana/api/v2/alerts alertmanager RouteGetGrafanaAMAlerts
 //
 // get alertmanager alerts
@@ -297,6 +314,108 @@ type GetSilencesParams struct {
 	Filter []string `json:"filter"`
 }
 
+// swagger:parameters RouteGetGrafanaNotificationDeliveries
+type GetNotificationDeliveriesParams struct {
+	// Filter the attempts to those of the contact point with the specified name.
+	// in: query
+	// required: false
+	Receiver string `json:"receiver"`
+
+	// Filter the attempts to those of the integrations of the specified type, e.g. slack or webhook.
+	// in: query
+	// required: false
+	Integration string `json:"integration"`
+
+	// Filter the attempts by result, success or failure.
+	// in: query
+	// required: false
+	Status string `json:"status"`
+
+	// Filter the attempts to those of the alert group with the specified key.
+	// in: query
+	// required: false
+	GroupKey string `json:"groupKey"`
+
+	// Only return the attempts made at or after this time, as RFC3339 or Unix timestamp in seconds.
+	// in: query
+	// required: false
+	From string `json:"from"`
+
+	// Only return the attempts made at or before this time, as RFC3339 or Unix timestamp in seconds.
+	// in: query
+	// required: false
+	To string `json:"to"`
+
+	// The maximum number of attempts to return.
+	// in: query
+	// required: false
+	// default: 100
+	Limit int `json:"limit"`
+}
+
+// swagger:parameters RouteGetGrafanaNotificationDeliveryStatus
+type GetNotificationDeliveryStatusParams struct {
+	// Filter the integrations to those of the contact point with the specified name.
+	// in: query
+	// required: false
+	Receiver string `json:"receiver"`
+}
+
+// swagger:model
+type NotificationDeliveries struct {
+	// required: true
+	Deliveries []NotificationDelivery `json:"deliveries"`
+}
+
+// NotificationDelivery is an attempt to deliver a notification through an integration of a contact point.
+// swagger:model
+type NotificationDelivery struct {
+	// required: true
+	Receiver string `json:"receiver"`
+	// required: true
+	Integration string `json:"integration"`
+	// required: true
+	IntegrationIndex int `json:"integrationIndex"`
+	// required: true
+	GroupKey string `json:"groupKey"`
+	// required: true
+	Status string `json:"status"`
+	// StatusCode is the HTTP status code of the response, when the integration got one.
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Attempt is the number of the attempt, the attempts after the first one are retries.
+	// required: true
+	Attempt int `json:"attempt"`
+	// required: true
+	Alerts int `json:"alerts"`
+	// Duration is the duration of the attempt in milliseconds.
+	// required: true
+	Duration int64 `json:"duration"`
+	// required: true
+	SentAt time.Time `json:"sentAt"`
+}
+
+// swagger:model
+type NotificationDeliveryStatus struct {
+	// required: true
+	Integrations []IntegrationDeliveryStatus `json:"integrations"`
+}
+
+// IntegrationDeliveryStatus is the last delivery attempt and the last successful delivery of an integration of a contact point.
+// swagger:model
+type IntegrationDeliveryStatus struct {
+	// required: true
+	Receiver string `json:"receiver"`
+	// required: true
+	Integration string `json:"integration"`
+	// required: true
+	IntegrationIndex int `json:"integrationIndex"`
+	// required: true
+	LastAttempt NotificationDelivery `json:"lastAttempt"`
+	// LastSuccess is missing when no attempt succeeded during the retention of the delivery log.
+	LastSuccess *NotificationDelivery `json:"lastSuccess,omitempty"`
+}
+
 // swagger:model
 type GettableStatus struct {
 	// cluster
@@ -122,6 +122,23 @@ import (
 //     Responses:
 //       202: Ack
 
+// swagger:route POST /api/ruler/grafana/api/v1/import/prometheus/{Namespace} ruler RouteImportPrometheusRules
+//
+// Imports a Prometheus rule file as Grafana managed rules of the folder.
+//
+// The rules are identified by their name, importing the same file again updates the rules imported before.
+//
+//     Consumes:
+//     - application/json
+//     - application/yaml
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       202: ImportPrometheusRulesResponse
+//       400: ValidationError
+
 // swagger:parameters RoutePostNameRulesConfig RoutePostNameGrafanaRulesConfig
 type NamespaceConfig struct {
 	// in:path
@@ -152,6 +169,53 @@ type PathGetRulesParams struct {
 	PanelID int64
 }
 
+// swagger:parameters RouteImportPrometheusRules
+type ImportPrometheusRulesParams struct {
+	// in: path
+	Namespace string
+	// The UID of the Prometheus data source the expressions of the rules are queried on.
+	// in: query
+	// required: true
+	DatasourceUID string `json:"datasource_uid"`
+	// in: body
+	Body PrometheusRuleFile
+}
+
+// PrometheusRuleFile is a Prometheus rule file.
+// swagger:model
+type PrometheusRuleFile struct {
+	Groups []PrometheusRuleGroup `yaml:"groups" json:"groups"`
+}
+
+// swagger:model
+type PrometheusRuleGroup struct {
+	Name     string         `yaml:"name" json:"name"`
+	Interval model.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Rules    []ApiRuleNode  `yaml:"rules" json:"rules"`
+}
+
+// swagger:model
+type ImportPrometheusRulesResponse struct {
+	Message string `json:"message"`
+	// Groups are the imported rule groups.
+	Groups []ImportedRuleGroup `json:"groups"`
+	// Skipped are the rules of the file that are not imported.
+	Skipped []SkippedPrometheusRule `json:"skipped"`
+}
+
+type ImportedRuleGroup struct {
+	Name    string `json:"name"`
+	Added   int    `json:"added"`
+	Updated int    `json:"updated"`
+	Deleted int    `json:"deleted"`
+}
+
+type SkippedPrometheusRule struct {
+	Group  string `json:"group"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
 // swagger:model
 type RuleGroupConfigResponse struct {
 	GettableRuleGroupConfig
@@ -368,6 +432,7 @@ type PostableGrafanaRule struct {
 	UID          string              `json:"uid" yaml:"uid"`
 	NoDataState  NoDataState         `json:"no_data_state" yaml:"no_data_state"`
 	ExecErrState ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
+	Record       *models.Record      `json:"record,omitempty" yaml:"record,omitempty"`
 }
 
 // swagger:model
@@ -387,4 +452,5 @@ type GettableGrafanaRule struct {
 	NoDataState     NoDataState         `json:"no_data_state" yaml:"no_data_state"`
 	ExecErrState    ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
 	Provenance      models.Provenance   `json:"provenance,omitempty" yaml:"provenance,omitempty"`
+	Record          *models.Record      `json:"record,omitempty" yaml:"record,omitempty"`
 }
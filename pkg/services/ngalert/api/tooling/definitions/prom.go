@@ -13,6 +13,15 @@ import (
 //     Responses:
 //       200: RuleResponse
 
+// swagger:route GET /api/prometheus/grafana/api/v1/rules/history prometheus RouteGetGrafanaRuleStateHistory
+//
+// gets the state transitions of the alert instances, newest first
+//
+//     Responses:
+//       200: StateHistoryResponse
+//       400: ValidationError
+//       404: NotFound
+
 // swagger:route GET /api/prometheus/{DatasourceID}/api/v1/rules prometheus RouteGetRuleStatuses
 //
 // gets the evaluation statuses of all rules
@@ -50,6 +59,14 @@ type AlertResponse struct {
 	Data AlertDiscovery `json:"data"`
 }
 
+// swagger:model
+type StateHistoryResponse struct {
+	// in: body
+	DiscoveryBase
+	// in: body
+	Data StateHistoryDiscovery `json:"data"`
+}
+
 // swagger:model
 type DiscoveryBase struct {
 	// required: true
@@ -73,6 +90,16 @@ type AlertDiscovery struct {
 	Alerts []*Alert `json:"alerts"`
 }
 
+// StateHistoryDiscovery has the state transitions of the alert instances.
+// swagger:model
+type StateHistoryDiscovery struct {
+	// required: true
+	Transitions []*StateTransition `json:"transitions"`
+	// Truncated is true when the transitions were not all read, older transitions are missing.
+	// required: false
+	Truncated bool `json:"truncated,omitempty"`
+}
+
 // swagger:model
 type RuleGroup struct {
 	// required: true
@@ -170,3 +197,51 @@ type GetGrafanaRuleStatusesParams struct {
 	// required: false
 	PanelID int64
 }
+
+// StateTransition is a change of the state of an alert instance.
+// swagger:model
+type StateTransition struct {
+	// required: true
+	RuleUID string `json:"ruleUID"`
+	// required: true
+	RuleTitle string `json:"ruleTitle"`
+	// required: true
+	Labels overrideLabels `json:"labels"`
+	// The values of the expressions at the evaluation that caused the transition.
+	Values map[string]string `json:"values,omitempty"`
+	// required: true
+	PreviousState string `json:"previousState"`
+	// required: true
+	State string `json:"state"`
+	// required: true
+	EvaluatedAt time.Time `json:"evaluatedAt"`
+}
+
+// swagger:parameters RouteGetGrafanaRuleStateHistory
+type GetGrafanaRuleStateHistoryParams struct {
+	// Filter the transitions to those of the rule with the specified UID.
+	// in: query
+	// required: false
+	RuleUID string `json:"ruleUID"`
+
+	// A list of matchers to filter the transitions by the labels of the alert instances.
+	// in: query
+	// required: false
+	Matchers []string `json:"matcher"`
+
+	// Only return the transitions evaluated at or after this time, as RFC3339 or Unix timestamp in seconds.
+	// in: query
+	// required: false
+	From string `json:"from"`
+
+	// Only return the transitions evaluated at or before this time, as RFC3339 or Unix timestamp in seconds.
+	// in: query
+	// required: false
+	To string `json:"to"`
+
+	// The maximum number of transitions to return.
+	// in: query
+	// required: false
+	// default: 100
+	Limit int64 `json:"limit"`
+}
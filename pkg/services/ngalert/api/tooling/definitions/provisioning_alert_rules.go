@@ -0,0 +1,147 @@
+package definitions
+
+import (
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// swagger:route GET /api/provisioning/alert-rules/{UID} provisioning RouteGetAlertRule
+//
+// Get a specific alert rule by UID.
+//
+//     Responses:
+//       200: AlertRule
+//       404: NotFound
+
+// swagger:route POST /api/provisioning/alert-rules provisioning RoutePostAlertRule
+//
+// Create a new alert rule.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       201: AlertRule
+//       400: ValidationError
+
+// swagger:route PUT /api/provisioning/alert-rules/{UID} provisioning RoutePutAlertRule
+//
+// Update an existing alert rule.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       200: AlertRule
+//       400: ValidationError
+
+// swagger:route DELETE /api/provisioning/alert-rules/{UID} provisioning RouteDeleteAlertRule
+//
+// Delete a specific alert rule by UID.
+//
+//     Responses:
+//       204: description: The alert rule was deleted successfully.
+
+// swagger:route PUT /api/provisioning/folder/{FolderUID}/rule-groups/{Group} provisioning RoutePutAlertRuleGroup
+//
+// Update the interval of a rule group.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       200: AlertRuleGroup
+//       400: ValidationError
+
+// swagger:parameters RouteGetAlertRule RoutePutAlertRule RouteDeleteAlertRule
+type AlertRuleUIDReference struct {
+	// in:path
+	UID string
+}
+
+// swagger:parameters RoutePostAlertRule RoutePutAlertRule
+type AlertRulePayload struct {
+	// in:body
+	Body AlertRule
+}
+
+// swagger:parameters RoutePutAlertRuleGroup
+type AlertRuleGroupPayload struct {
+	// in:path
+	FolderUID string
+	// in:path
+	Group string
+	// in:body
+	Body AlertRuleGroup
+}
+
+// AlertRule is a Grafana managed alert rule as it is provisioned.
+type AlertRule struct {
+	ID    int64  `json:"id"`
+	UID   string `json:"uid"`
+	OrgID int64  `json:"orgID"`
+	// required: true
+	FolderUID string `json:"folderUID"`
+	// required: true
+	RuleGroup string `json:"ruleGroup"`
+	// required: true
+	Title string `json:"title"`
+	// required: true
+	Condition string `json:"condition"`
+	// required: true
+	Data         []models.AlertQuery        `json:"data"`
+	Updated      time.Time                  `json:"updated,omitempty"`
+	NoDataState  models.NoDataState         `json:"noDataState"`
+	ExecErrState models.ExecutionErrorState `json:"execErrState"`
+	For          model.Duration             `json:"for"`
+	Annotations  map[string]string          `json:"annotations,omitempty"`
+	Labels       map[string]string          `json:"labels,omitempty"`
+	Provenance   models.Provenance          `json:"provenance,omitempty"`
+}
+
+func (a *AlertRule) UpstreamModel() models.AlertRule {
+	return models.AlertRule{
+		ID:           a.ID,
+		UID:          a.UID,
+		OrgID:        a.OrgID,
+		NamespaceUID: a.FolderUID,
+		RuleGroup:    a.RuleGroup,
+		Title:        a.Title,
+		Condition:    a.Condition,
+		Data:         a.Data,
+		Updated:      a.Updated,
+		NoDataState:  a.NoDataState,
+		ExecErrState: a.ExecErrState,
+		For:          time.Duration(a.For),
+		Annotations:  a.Annotations,
+		Labels:       a.Labels,
+	}
+}
+
+func NewAlertRule(rule models.AlertRule, provenance models.Provenance) AlertRule {
+	return AlertRule{
+		ID:           rule.ID,
+		UID:          rule.UID,
+		OrgID:        rule.OrgID,
+		FolderUID:    rule.NamespaceUID,
+		RuleGroup:    rule.RuleGroup,
+		Title:        rule.Title,
+		Condition:    rule.Condition,
+		Data:         rule.Data,
+		Updated:      rule.Updated,
+		NoDataState:  rule.NoDataState,
+		ExecErrState: rule.ExecErrState,
+		For:          model.Duration(rule.For),
+		Annotations:  rule.Annotations,
+		Labels:       rule.Labels,
+		Provenance:   provenance,
+	}
+}
+
+// AlertRuleGroup holds the settings shared by the rules of a group.
+type AlertRuleGroup struct {
+	Interval model.Duration `json:"interval"`
+}
@@ -85,7 +85,7 @@ func (e *EmbeddedContactPoint) Valid(decryptFunc channels.GetDecryptedValueFn) e
 	cfg, _ := channels.NewFactoryConfig(&channels.NotificationChannelConfig{
 		Settings: e.Settings,
 		Type:     e.Type,
-	}, nil, decryptFunc, nil)
+	}, nil, decryptFunc, nil, nil)
 	if _, err := factory(cfg); err != nil {
 		return err
 	}
@@ -104,10 +104,14 @@ func (e *EmbeddedContactPoint) SecretKeys() ([]string, error) {
 		return []string{}, nil
 	case "googlechat":
 		return []string{}, nil
+	case "http":
+		return []string{"password", "bearerToken", "authHeaderValue"}, nil
 	case "kafka":
 		return []string{}, nil
 	case "line":
 		return []string{"token"}, nil
+	case "mqtt":
+		return []string{"username", "password", "tlsClientCertificate", "tlsClientKey"}, nil
 	case "opsgenie":
 		return []string{"apiKey"}, nil
 	case "pagerduty":
@@ -1,5 +1,7 @@
 package definitions
 
+import "github.com/grafana/grafana/pkg/services/ngalert/models"
+
 // swagger:route GET /api/provisioning/templates provisioning RouteGetTemplates
 //
 // Get all message templates.
@@ -17,8 +19,17 @@ package definitions
 //       404: NotFound
 
 type MessageTemplate struct {
-	Name     string
-	Template string
+	Name       string
+	Template   string
+	Provenance models.Provenance `json:",omitempty"`
+}
+
+func (t *MessageTemplate) ResourceType() string {
+	return "template"
+}
+
+func (t *MessageTemplate) ResourceID() string {
+	return t.Name
 }
 
 type NotFound struct{}
@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/promql"
 
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -52,6 +53,20 @@ import (
 //     Responses:
 //       200: EvalQueriesResponse
 
+// swagger:route Post /api/v1/rule/backtest testing RouteBacktestConfig
+//
+// Test rule against historical data
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: BacktestResult
+//       400: ValidationError
+
 // swagger:parameters RouteTestReceiverConfig
 type TestReceiverRequest struct {
 	// in:body
@@ -84,6 +99,62 @@ type EvalQueriesPayload struct {
 	Now  time.Time           `json:"now"`
 }
 
+// swagger:parameters RouteBacktestConfig
+type BacktestConfigRequest struct {
+	// in:body
+	Body BacktestConfig
+}
+
+// swagger:model
+type BacktestConfig struct {
+	// From is the time of the first evaluation.
+	From time.Time `json:"from"`
+	// To is the time after which the rule is no longer evaluated.
+	To time.Time `json:"to"`
+	// Interval between the evaluations, defaults to the default evaluation interval of the rules.
+	Interval model.Duration `json:"interval,omitempty"`
+
+	Condition    string              `json:"condition"`
+	Data         []models.AlertQuery `json:"data"`
+	For          model.Duration      `json:"for,omitempty"`
+	Title        string              `json:"title"`
+	Labels       map[string]string   `json:"labels,omitempty"`
+	Annotations  map[string]string   `json:"annotations,omitempty"`
+	NoDataState  NoDataState         `json:"no_data_state"`
+	ExecErrState ExecutionErrorState `json:"exec_err_state"`
+}
+
+// swagger:model
+type BacktestResult struct {
+	// Instances are the state timelines of the alert instances.
+	Instances []BacktestInstance `json:"instances"`
+	// Notifications are the alerts that would have been sent to the Alertmanager.
+	Notifications []BacktestNotification `json:"notifications"`
+}
+
+type BacktestInstance struct {
+	// Labels are the labels of the alert sent to the Alertmanager, the labels of the result and of the rule,
+	// and the alertname label with the title of the rule.
+	Labels map[string]string `json:"labels"`
+	// States are the states of the instance after each evaluation that produced it.
+	States []BacktestState `json:"states"`
+}
+
+type BacktestState struct {
+	Time  time.Time `json:"time"`
+	State string    `json:"state"`
+	// Value describes the values of the queries and expressions of the evaluation.
+	Value string `json:"value,omitempty"`
+}
+
+type BacktestNotification struct {
+	Time time.Time `json:"time"`
+	// Status is either firing or resolved.
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
 func (p *TestRulePayload) UnmarshalJSON(b []byte) error {
 	type plain TestRulePayload
 	if err := json.Unmarshal(b, (*plain)(p)); err != nil {
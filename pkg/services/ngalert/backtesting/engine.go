@@ -0,0 +1,127 @@
+package backtesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+// MaxEvaluations is the maximum number of evaluations of a backtest, each evaluation queries the data sources.
+const MaxEvaluations = 1000
+
+var ErrInvalidInputData = errors.New("invalid input data")
+
+// Engine replays the evaluations of an alert rule over a past time range.
+type Engine struct {
+	evaluator         eval.Evaluator
+	expressionService *expr.Service
+	log               log.Logger
+}
+
+func NewEngine(evaluator eval.Evaluator, expressionService *expr.Service, logger log.Logger) *Engine {
+	return &Engine{
+		evaluator:         evaluator,
+		expressionService: expressionService,
+		log:               logger,
+	}
+}
+
+// Test evaluates the rule at its interval from the start to the end of the time range, and processes the
+// results with a state manager of its own. The states of the instances and the alerts that would have been
+// sent to the Alertmanager are returned, nothing is saved.
+func (e *Engine) Test(ctx context.Context, rule *models.AlertRule, from, to time.Time) (*apimodels.BacktestResult, error) {
+	interval := time.Duration(rule.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		return nil, fmt.Errorf("%w: the interval must be positive", ErrInvalidInputData)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: the start of the time range must be before its end", ErrInvalidInputData)
+	}
+	if evaluations := int64(to.Sub(from)/interval) + 1; evaluations > MaxEvaluations {
+		return nil, fmt.Errorf("%w: the time range requires %d evaluations, the maximum is %d", ErrInvalidInputData, evaluations, MaxEvaluations)
+	}
+
+	clk := clock.NewMock()
+	manager := state.NewSandboxManager(e.log, nil, clk)
+	condition := &models.Condition{
+		Condition: rule.Condition,
+		OrgID:     rule.OrgID,
+		Data:      rule.Data,
+	}
+
+	timelines := make(map[string]*apimodels.BacktestInstance)
+	result := &apimodels.BacktestResult{
+		Instances:     make([]apimodels.BacktestInstance, 0),
+		Notifications: make([]apimodels.BacktestNotification, 0),
+	}
+	for now := from; !now.After(to); now = now.Add(interval) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		clk.Set(now)
+
+		results, err := e.evaluator.ConditionEval(condition, now, e.expressionService)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate the rule at %s: %w", now.Format(time.RFC3339), err)
+		}
+
+		previous := make(map[string]eval.State)
+		for _, s := range manager.GetStatesForRuleUID(rule.OrgID, rule.UID) {
+			previous[s.CacheId] = s.State
+		}
+		for _, s := range manager.ProcessEvalResults(ctx, rule, results) {
+			labels := s.GetLabels(models.WithoutInternalLabels())
+			timeline, ok := timelines[s.CacheId]
+			if !ok {
+				timeline = &apimodels.BacktestInstance{Labels: labels}
+				timelines[s.CacheId] = timeline
+			}
+			timeline.States = append(timeline.States, apimodels.BacktestState{
+				Time:  now,
+				State: s.State.String(),
+				Value: s.LastEvaluationString,
+			})
+
+			if status, ok := notificationStatus(previous[s.CacheId], s); ok {
+				result.Notifications = append(result.Notifications, apimodels.BacktestNotification{
+					Time:        now,
+					Status:      status,
+					Labels:      labels,
+					Annotations: s.Annotations,
+				})
+			}
+		}
+	}
+
+	for _, timeline := range timelines {
+		result.Instances = append(result.Instances, *timeline)
+	}
+	sort.Slice(result.Instances, func(i, j int) bool {
+		return data.Labels(result.Instances[i].Labels).String() < data.Labels(result.Instances[j].Labels).String()
+	})
+	return result, nil
+}
+
+// notificationStatus returns the status of the notification sent when the instance changes state, if any.
+// Unlike the scheduler, it does not count the alerts sent again to the Alertmanager after the resend delay.
+func notificationStatus(previous eval.State, s *state.State) (string, bool) {
+	if s.Resolved {
+		return "resolved", true
+	}
+	if s.State == previous || s.State == eval.Normal || s.State == eval.Pending {
+		return "", false
+	}
+	return "firing", true
+}
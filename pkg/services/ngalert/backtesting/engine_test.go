@@ -0,0 +1,111 @@
+package backtesting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// fakeEvaluator returns the results of each evaluation time.
+type fakeEvaluator struct {
+	results map[time.Time]eval.Results
+	err     error
+}
+
+func (f *fakeEvaluator) ConditionEval(_ *models.Condition, now time.Time, _ *expr.Service) (eval.Results, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	results := f.results[now]
+	for i := range results {
+		results[i].EvaluatedAt = now
+	}
+	return results, nil
+}
+
+func (f *fakeEvaluator) QueriesAndExpressionsEval(_ int64, _ []models.AlertQuery, _ time.Time, _ *expr.Service) (*backend.QueryDataResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestEngine(t *testing.T) {
+	from := time.Date(2022, 3, 10, 14, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return from.Add(time.Duration(minutes) * time.Minute)
+	}
+	a := data.Labels{"instance": "a"}
+	b := data.Labels{"instance": "b"}
+	results := func(state eval.State) eval.Results {
+		return eval.Results{{Instance: a, State: state}}
+	}
+
+	rule := &models.AlertRule{
+		OrgID:           1,
+		UID:             "backtest",
+		Title:           "test",
+		Condition:       "A",
+		IntervalSeconds: 60,
+		For:             2 * time.Minute,
+		NoDataState:     models.NoData,
+		ExecErrState:    models.AlertingErrState,
+		Labels:          map[string]string{"team": "x"},
+	}
+
+	t.Run("replays the evaluations of the rule", func(t *testing.T) {
+		evaluator := &fakeEvaluator{results: map[time.Time]eval.Results{
+			at(0): {{Instance: a, State: eval.Normal}, {Instance: b, State: eval.Alerting}},
+			at(1): {{Instance: a, State: eval.Alerting}, {Instance: b, State: eval.Alerting}},
+			at(2): results(eval.Alerting),
+			at(3): results(eval.Alerting),
+			at(4): results(eval.Normal),
+			at(5): results(eval.Normal),
+		}}
+		engine := NewEngine(evaluator, nil, log.New("test"))
+
+		result, err := engine.Test(context.Background(), rule, from, at(5))
+		require.NoError(t, err)
+
+		require.Len(t, result.Instances, 2)
+		require.Equal(t, map[string]string{"alertname": "test", "instance": "a", "team": "x"}, result.Instances[0].Labels)
+		states := make([]string, 0)
+		for _, s := range result.Instances[0].States {
+			states = append(states, s.State)
+		}
+		require.Equal(t, []string{"Normal", "Pending", "Pending", "Alerting", "Normal", "Normal"}, states)
+		require.Equal(t, at(3), result.Instances[0].States[3].Time)
+
+		// the instance b is stale after two intervals without results.
+		require.Equal(t, map[string]string{"alertname": "test", "instance": "b", "team": "x"}, result.Instances[1].Labels)
+		require.Len(t, result.Instances[1].States, 2)
+
+		require.Equal(t, []apimodels.BacktestNotification{
+			{Time: at(3), Status: "firing", Labels: result.Instances[0].Labels, Annotations: map[string]string{}},
+			{Time: at(4), Status: "resolved", Labels: result.Instances[0].Labels, Annotations: map[string]string{}},
+		}, result.Notifications)
+	})
+
+	t.Run("fails if the rule cannot be evaluated", func(t *testing.T) {
+		engine := NewEngine(&fakeEvaluator{err: errors.New("boom")}, nil, log.New("test"))
+		_, err := engine.Test(context.Background(), rule, from, at(5))
+		require.ErrorContains(t, err, "boom")
+	})
+
+	t.Run("rejects invalid time ranges", func(t *testing.T) {
+		engine := NewEngine(&fakeEvaluator{}, nil, log.New("test"))
+		_, err := engine.Test(context.Background(), rule, at(5), from)
+		require.ErrorIs(t, err, ErrInvalidInputData)
+
+		_, err = engine.Test(context.Background(), rule, from, from.Add(MaxEvaluations*time.Minute))
+		require.ErrorIs(t, err, ErrInvalidInputData)
+	})
+}
@@ -0,0 +1,248 @@
+// Package image captures the screenshots of the panels of alert rules that are attached to their notifications.
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/grafana/grafana/pkg/components/imguploader"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// cacheTTL is how long the screenshot of a panel is reused, so that the alerts of a rule
+	// that fire together, or the rules of the same panel, share it. A failed screenshot is not
+	// retried either before the TTL, so that the evaluations do not wait for a failing renderer.
+	cacheTTL = time.Minute
+	// cleanupInterval is how often the expired images are deleted.
+	cleanupInterval = time.Hour
+
+	screenshotWidth  = 1000
+	screenshotHeight = 500
+)
+
+var (
+	// ErrNoDashboard is returned when the rule has no dashboard UID.
+	ErrNoDashboard = errors.New("the rule has no dashboard")
+	// ErrNoPanel is returned when the rule has no panel ID.
+	ErrNoPanel = errors.New("the rule has no panel")
+	// ErrRendererUnavailable is returned when the image renderer is not installed.
+	ErrRendererUnavailable = errors.New("the image renderer is not available")
+)
+
+var timeNow = time.Now
+
+// Service captures the images of the alert rules and deletes them when they expire, it runs in the background.
+type Service interface {
+	state.ImageCapturer
+	Run(ctx context.Context) error
+}
+
+var _ Service = (*ScreenshotImageService)(nil)
+
+// New returns the service configured by the settings, nil when the screenshots are disabled.
+func New(cfg *setting.Cfg, renderer rendering.Service, imageStore store.ImageStore, logger log.Logger) (Service, error) {
+	settings := cfg.UnifiedAlerting.Screenshots
+	if !settings.Capture {
+		return nil, nil
+	}
+	if renderer == nil {
+		return nil, ErrRendererUnavailable
+	}
+
+	var uploader imguploader.ImageUploader
+	if settings.UploadExternalImageStorage {
+		var err error
+		uploader, err = imguploader.NewImageUploader()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the uploader of the external image storage: %w", err)
+		}
+	}
+	// the images are kept as long as their files, which are deleted with the other temporary files.
+	return NewScreenshotImageService(renderer, uploader, imageStore, settings.CaptureTimeout, settings.MaxConcurrentScreenshots, cfg.TempDataLifetime, logger), nil
+}
+
+// ScreenshotImageService captures the panel of a rule with the image renderer. The screenshots are cached,
+// and the time spent waiting for one is bounded by the timeout so that the evaluation of the rule is not stalled.
+type ScreenshotImageService struct {
+	renderer rendering.Service
+	// uploader is nil when the screenshots are not uploaded to the external image storage.
+	uploader imguploader.ImageUploader
+	store    store.ImageStore
+	timeout  time.Duration
+	lifetime time.Duration
+	limit    *semaphore.Weighted
+	log      log.Logger
+
+	group singleflight.Group
+	mtx   sync.Mutex
+	cache map[string]cachedImage
+}
+
+type cachedImage struct {
+	image *ngmodels.Image
+	// err is the error of the screenshot when it failed.
+	err        error
+	capturedAt time.Time
+}
+
+func NewScreenshotImageService(renderer rendering.Service, uploader imguploader.ImageUploader, imageStore store.ImageStore,
+	timeout time.Duration, maxConcurrent int, lifetime time.Duration, logger log.Logger) *ScreenshotImageService {
+	return &ScreenshotImageService{
+		renderer: renderer,
+		uploader: uploader,
+		store:    imageStore,
+		timeout:  timeout,
+		lifetime: lifetime,
+		limit:    semaphore.NewWeighted(int64(maxConcurrent)),
+		log:      logger,
+		cache:    make(map[string]cachedImage),
+	}
+}
+
+// NewImage returns the screenshot of the panel of the rule, ErrNoDashboard or ErrNoPanel when the rule has no panel.
+// Concurrent calls for the same panel share the same screenshot, and the calls that follow a failed screenshot
+// return its error until it expires from the cache.
+func (s *ScreenshotImageService) NewImage(ctx context.Context, r *ngmodels.AlertRule) (*ngmodels.Image, error) {
+	if r.DashboardUID == nil || *r.DashboardUID == "" {
+		return nil, ErrNoDashboard
+	}
+	if r.PanelID == nil || *r.PanelID == 0 {
+		return nil, ErrNoPanel
+	}
+
+	key := fmt.Sprintf("%d/%s/%d", r.OrgID, *r.DashboardUID, *r.PanelID)
+	if cached, ok := s.getCached(key); ok {
+		return cached.image, cached.err
+	}
+
+	// the screenshot is not bound to the context of the caller, which shares it with the other callers.
+	ch := s.group.DoChan(key, func() (interface{}, error) {
+		captureCtx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		img, err := s.capture(captureCtx, r.OrgID, *r.DashboardUID, *r.PanelID)
+		s.setCached(key, img, err)
+		if err != nil {
+			return nil, err
+		}
+		return img, nil
+	})
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*ngmodels.Image), nil
+	case <-timer.C:
+		return nil, rendering.ErrTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *ScreenshotImageService) capture(ctx context.Context, orgID int64, dashboardUID string, panelID int64) (*ngmodels.Image, error) {
+	if !s.renderer.IsAvailable() {
+		return nil, ErrRendererUnavailable
+	}
+	if err := s.limit.Acquire(ctx, 1); err != nil {
+		return nil, fmt.Errorf("failed to wait for the other screenshots: %w", err)
+	}
+	defer s.limit.Release(1)
+
+	opts := rendering.Opts{
+		TimeoutOpts: rendering.TimeoutOpts{
+			Timeout: s.timeout,
+		},
+		AuthOpts: rendering.AuthOpts{
+			OrgID:   orgID,
+			OrgRole: models.ROLE_ADMIN,
+		},
+		Width:           screenshotWidth,
+		Height:          screenshotHeight,
+		Path:            fmt.Sprintf("d-solo/%s?orgId=%d&panelId=%d", dashboardUID, orgID, panelID),
+		ConcurrentLimit: setting.AlertingRenderLimit,
+		Theme:           models.ThemeDark,
+	}
+
+	s.log.Debug("rendering the screenshot of the panel", "dashboard", dashboardUID, "panel", panelID)
+	start := timeNow()
+	result, err := s.renderer.Render(ctx, opts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the panel: %w", err)
+	}
+	s.log.Debug("rendered the screenshot of the panel", "dashboard", dashboardUID, "panel", panelID, "path", result.FilePath, "took", timeNow().Sub(start))
+
+	img := &ngmodels.Image{
+		Path:      result.FilePath,
+		ExpiresAt: timeNow().Add(s.lifetime),
+	}
+	if s.uploader != nil {
+		url, err := s.uploader.Upload(ctx, result.FilePath)
+		if err != nil {
+			// the file can still be attached to the notifications.
+			s.log.Warn("failed to upload the screenshot to the external image storage", "path", result.FilePath, "err", err)
+		}
+		img.URL = url
+	}
+
+	if err := s.store.SaveImage(ctx, img); err != nil {
+		return nil, fmt.Errorf("failed to save the image: %w", err)
+	}
+	return img, nil
+}
+
+func (s *ScreenshotImageService) getCached(key string) (cachedImage, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	cached, ok := s.cache[key]
+	if !ok || timeNow().Sub(cached.capturedAt) >= cacheTTL {
+		return cachedImage{}, false
+	}
+	return cached, true
+}
+
+func (s *ScreenshotImageService) setCached(key string, img *ngmodels.Image, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	now := timeNow()
+	for k, cached := range s.cache {
+		if now.Sub(cached.capturedAt) >= cacheTTL {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = cachedImage{image: img, err: err, capturedAt: now}
+}
+
+// Run deletes the expired images until the context is cancelled.
+func (s *ScreenshotImageService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		n, err := s.store.DeleteExpiredImages(ctx)
+		if err != nil {
+			s.log.Error("failed to delete expired images", "err", err)
+		} else if n > 0 {
+			s.log.Debug("deleted expired images", "count", n)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+type fakeRenderer struct {
+	rendering.Service
+	mtx       sync.Mutex
+	available bool
+	delay     time.Duration
+	err       error
+	calls     int
+	paths     []string
+}
+
+func (r *fakeRenderer) IsAvailable() bool {
+	return r.available
+}
+
+func (r *fakeRenderer) Render(ctx context.Context, opts rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.paths = append(r.paths, opts.Path)
+	return &rendering.RenderResult{FilePath: "/tmp/" + opts.Path + ".png"}, nil
+}
+
+func (r *fakeRenderer) Paths() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]string{}, r.paths...)
+}
+
+type fakeUploader struct{}
+
+func (fakeUploader) Upload(_ context.Context, path string) (string, error) {
+	return "https://images.example.com" + path, nil
+}
+
+func TestScreenshotImageService(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	dashboardUID := "dashboard"
+	panelID := int64(2)
+	rule := &models.AlertRule{OrgID: 1, UID: "rule", DashboardUID: &dashboardUID, PanelID: &panelID}
+
+	t.Run("returns an error when the rule has no panel", func(t *testing.T) {
+		s := NewScreenshotImageService(&fakeRenderer{available: true}, nil, store.NewFakeImageStore(t), time.Second, 1, time.Hour, log.New("test"))
+		_, err := s.NewImage(context.Background(), &models.AlertRule{OrgID: 1})
+		require.ErrorIs(t, err, ErrNoDashboard)
+		_, err = s.NewImage(context.Background(), &models.AlertRule{OrgID: 1, DashboardUID: &dashboardUID})
+		require.ErrorIs(t, err, ErrNoPanel)
+	})
+
+	t.Run("returns an error when the renderer is not available", func(t *testing.T) {
+		s := NewScreenshotImageService(&fakeRenderer{}, nil, store.NewFakeImageStore(t), time.Second, 1, time.Hour, log.New("test"))
+		_, err := s.NewImage(context.Background(), rule)
+		require.ErrorIs(t, err, ErrRendererUnavailable)
+	})
+
+	t.Run("saves the screenshot and caches it", func(t *testing.T) {
+		renderer := &fakeRenderer{available: true}
+		images := store.NewFakeImageStore(t)
+		s := NewScreenshotImageService(renderer, fakeUploader{}, images, time.Second, 1, time.Hour, log.New("test"))
+
+		img, err := s.NewImage(context.Background(), rule)
+		require.NoError(t, err)
+		require.Equal(t, "/tmp/d-solo/dashboard?orgId=1&panelId=2.png", img.Path)
+		require.Equal(t, "https://images.example.com/tmp/d-solo/dashboard?orgId=1&panelId=2.png", img.URL)
+		require.Equal(t, now.Add(time.Hour), img.ExpiresAt)
+		saved, err := images.GetImage(context.Background(), img.Token)
+		require.NoError(t, err)
+		require.Equal(t, img, saved)
+
+		cached, err := s.NewImage(context.Background(), rule)
+		require.NoError(t, err)
+		require.Equal(t, img.Token, cached.Token)
+		require.Len(t, renderer.Paths(), 1)
+
+		now = now.Add(cacheTTL)
+		renewed, err := s.NewImage(context.Background(), rule)
+		require.NoError(t, err)
+		require.NotEqual(t, img.Token, renewed.Token)
+		require.Len(t, renderer.Paths(), 2)
+	})
+
+	t.Run("concurrent calls share the screenshot", func(t *testing.T) {
+		renderer := &fakeRenderer{available: true, delay: 100 * time.Millisecond}
+		s := NewScreenshotImageService(renderer, nil, store.NewFakeImageStore(t), time.Second, 1, time.Hour, log.New("test"))
+
+		var wg sync.WaitGroup
+		tokens := make([]string, 5)
+		for i := range tokens {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				img, err := s.NewImage(context.Background(), rule)
+				require.NoError(t, err)
+				tokens[i] = img.Token
+			}(i)
+		}
+		wg.Wait()
+		require.Len(t, renderer.Paths(), 1)
+		for _, token := range tokens {
+			require.Equal(t, tokens[0], token)
+		}
+	})
+
+	t.Run("does not wait longer than the timeout", func(t *testing.T) {
+		renderer := &fakeRenderer{available: true, delay: time.Minute}
+		s := NewScreenshotImageService(renderer, nil, store.NewFakeImageStore(t), 50*time.Millisecond, 1, time.Hour, log.New("test"))
+
+		start := time.Now()
+		_, err := s.NewImage(context.Background(), rule)
+		require.Error(t, err)
+		require.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("caches errors for the TTL", func(t *testing.T) {
+		renderer := &fakeRenderer{available: true, err: errors.New("failed")}
+		s := NewScreenshotImageService(renderer, nil, store.NewFakeImageStore(t), time.Second, 1, time.Hour, log.New("test"))
+
+		_, err := s.NewImage(context.Background(), rule)
+		require.EqualError(t, err, "failed to render the panel: failed")
+
+		renderer.mtx.Lock()
+		renderer.err = nil
+		renderer.mtx.Unlock()
+		_, err = s.NewImage(context.Background(), rule)
+		require.EqualError(t, err, "failed to render the panel: failed")
+		require.Equal(t, 1, renderer.calls)
+
+		now = now.Add(cacheTTL)
+		img, err := s.NewImage(context.Background(), rule)
+		require.NoError(t, err)
+		require.NotEmpty(t, img.Token)
+	})
+}
@@ -54,6 +54,8 @@ type Scheduler struct {
 	EvalDuration             *prometheus.SummaryVec
 	GetAlertRulesDuration    prometheus.Histogram
 	SchedulePeriodicDuration prometheus.Histogram
+	ShardReplicas            prometheus.Gauge
+	ShardOwnedRules          prometheus.Gauge
 	Ticker                   *legacyMetrics.Ticker
 }
 
@@ -182,6 +184,18 @@ func newSchedulerMetrics(r prometheus.Registerer) *Scheduler {
 				Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 5, 10},
 			},
 		),
+		ShardReplicas: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "scheduler_shard_replicas",
+			Help:      "The number of replicas sharing the evaluation of the alert rules.",
+		}),
+		ShardOwnedRules: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "scheduler_shard_owned_rules",
+			Help:      "The number of alert rules evaluated by this replica when the evaluation is sharded.",
+		}),
 		Ticker: legacyMetrics.NewTickerMetrics(r),
 	}
 }
@@ -111,6 +111,31 @@ type AlertRule struct {
 	For         time.Duration
 	Annotations map[string]string
 	Labels      map[string]string
+	// Record is set for recording rules. Their result is written as a new series instead of producing alerts.
+	Record *Record `xorm:"record"`
+}
+
+// Record describes the series that a recording rule writes. The series are the
+// result of the query or expression referenced by the Condition of the rule.
+type Record struct {
+	// Metric is the name of the written series.
+	Metric string `json:"metric"`
+}
+
+// FromDB is part of the xorm Conversion interface.
+func (r *Record) FromDB(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, r)
+}
+
+// ToDB is part of the xorm Conversion interface.
+func (r *Record) ToDB() ([]byte, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
 }
 
 type LabelOption func(map[string]string)
@@ -182,6 +207,11 @@ func (alertRule *AlertRule) PreSave(timeNow func() time.Time) error {
 	return nil
 }
 
+// IsRecording returns true if the rule is a recording rule.
+func (alertRule *AlertRule) IsRecording() bool {
+	return alertRule.Record != nil
+}
+
 func (alertRule *AlertRule) ResourceType() string {
 	return "alertRule"
 }
@@ -217,6 +247,7 @@ type AlertRuleVersion struct {
 	For         time.Duration
 	Annotations map[string]string
 	Labels      map[string]string
+	Record      *Record `xorm:"record"`
 }
 
 // GetAlertRuleByUIDQuery is the query for retrieving/deleting an alert rule by UID and organisation ID.
@@ -291,7 +322,7 @@ func (c Condition) IsValid() bool {
 
 // PatchPartialAlertRule patches `ruleToPatch` by `existingRule` following the rule that if a field of `ruleToPatch` is empty or has the default value, it is populated by the value of the corresponding field from `existingRule`.
 // There are several exceptions:
-// 1. Following fields are not patched and therefore will be ignored: AlertRule.ID, AlertRule.OrgID, AlertRule.Updated, AlertRule.Version, AlertRule.UID, AlertRule.DashboardUID, AlertRule.PanelID, AlertRule.Annotations and AlertRule.Labels
+// 1. Following fields are not patched and therefore will be ignored: AlertRule.ID, AlertRule.OrgID, AlertRule.Updated, AlertRule.Version, AlertRule.UID, AlertRule.DashboardUID, AlertRule.PanelID, AlertRule.Annotations, AlertRule.Labels and AlertRule.Record
 // 2. There are fields that are patched together:
 //    - AlertRule.Condition and AlertRule.Data
 // If either of the pair is specified, neither is patched.
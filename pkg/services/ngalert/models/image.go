@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ImageTokenAnnotation is the annotation of an alert that holds the token of the image taken when the alert fired.
+// It is a private annotation, it is not shown in the templates of the notifications.
+const ImageTokenAnnotation = "__alertImageToken__"
+
+// ErrImageNotFound is returned when an image does not exist or expired.
+var ErrImageNotFound = errors.New("image not found")
+
+// Image is the screenshot of the panel of an alert rule that is attached to its notifications.
+type Image struct {
+	ID    int64  `xorm:"pk autoincr 'id'"`
+	Token string `xorm:"token"`
+	// Path is the file of the image on the disk of Grafana, it is deleted with the other temporary files.
+	Path string `xorm:"path"`
+	// URL is the address of the image in the external image storage, empty when it was not uploaded.
+	URL       string    `xorm:"url"`
+	CreatedAt time.Time `xorm:"created_at"`
+	ExpiresAt time.Time `xorm:"expires_at"`
+}
+
+func (Image) TableName() string {
+	return "alert_image"
+}
+
+// HasPath returns true if the image has a file on the disk.
+func (i *Image) HasPath() bool {
+	return i.Path != ""
+}
+
+// HasURL returns true if the image was uploaded to the external image storage.
+func (i *Image) HasURL() bool {
+	return i.URL != ""
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationDeliveryStatus is the result of an attempt to deliver a notification.
+type NotificationDeliveryStatus string
+
+const (
+	NotificationDeliverySuccess NotificationDeliveryStatus = "success"
+	NotificationDeliveryFailure NotificationDeliveryStatus = "failure"
+)
+
+// NotificationDelivery is an attempt to deliver a notification through an integration of a contact point.
+type NotificationDelivery struct {
+	OrgID int64
+	// Receiver is the name of the contact point.
+	Receiver string
+	// Integration is the type of the integration, e.g. slack or webhook.
+	Integration string
+	// IntegrationIndex is the position of the integration in the contact point.
+	IntegrationIndex int
+	// GroupKey identifies the alert group the notification is sent for.
+	GroupKey string
+	Status   NotificationDeliveryStatus
+	// StatusCode is the HTTP status code of the response, 0 when the integration did not get one.
+	StatusCode int
+	Error      string
+	// Attempt is the number of the attempt, the attempts after the first one are retries.
+	Attempt int
+	// Alerts is the number of alerts in the notification.
+	Alerts   int
+	Duration time.Duration
+	SentAt   time.Time
+}
+
+// ListNotificationDeliveriesQuery is the query for the delivery attempts of the contact points of an organization.
+type ListNotificationDeliveriesQuery struct {
+	OrgID int64
+	// Receiver, Integration, Status and GroupKey are optional filters.
+	Receiver    string
+	Integration string
+	Status      NotificationDeliveryStatus
+	GroupKey    string
+	From        time.Time
+	To          time.Time
+	// Limit of 0 returns all attempts in the time range.
+	Limit int
+
+	// Result is sorted by the time of the attempt, newest first.
+	Result []*NotificationDelivery
+}
+
+// IntegrationDeliveryStatus is the status of the delivery of the notifications of an integration of a contact point.
+type IntegrationDeliveryStatus struct {
+	Receiver         string
+	Integration      string
+	IntegrationIndex int
+	// LastAttempt is the latest delivery attempt.
+	LastAttempt *NotificationDelivery
+	// LastSuccess is the latest successful delivery attempt, nil when none is kept.
+	LastSuccess *NotificationDelivery
+}
+
+// GetNotificationDeliveryStatusQuery is the query for the status of the integrations of the contact points of an organization.
+// Only the integrations that attempted a delivery during the retention of the log are included.
+type GetNotificationDeliveryStatusQuery struct {
+	OrgID int64
+	// Receiver is optional, all contact points are included when empty.
+	Receiver string
+
+	// Result is sorted by contact point and position of the integration.
+	Result []*IntegrationDeliveryStatus
+}
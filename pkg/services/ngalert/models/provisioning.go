@@ -10,6 +10,13 @@ const (
 	ProvenanceFile Provenance = "file"
 )
 
+// CanUpdateProvenance returns true if a resource stored with the provenance stored can be changed
+// by a request with the provenance p. Resources without provenance can be changed by anyone,
+// provisioned resources only through the mechanism that provisioned them.
+func CanUpdateProvenance(stored, p Provenance) bool {
+	return stored == ProvenanceNone || stored == p
+}
+
 // Provisionable represents a resource that can be created through a provisioning mechanism, such as Terraform or config file.
 type Provisionable interface {
 	ResourceType() string
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/pkg/labels"
+)
+
+// StateTransition is a change of the state of an alert instance.
+type StateTransition struct {
+	OrgID     int64
+	RuleUID   string
+	RuleTitle string
+	// Labels of the alert instance without the private labels.
+	Labels data.Labels
+	// Values of the evaluation that caused the transition, if any.
+	Values        map[string]*float64
+	PreviousState string
+	State         string
+	EvaluatedAt   time.Time
+}
+
+// ListStateHistoryQuery is the query for the state transitions of the alert instances of an organization.
+type ListStateHistoryQuery struct {
+	OrgID int64
+	// RuleUID is optional, all rules are included when empty.
+	RuleUID string
+	// RuleUIDs restricts the transitions to these rules when it is not nil,
+	// the limit then only counts the transitions of these rules.
+	RuleUIDs map[string]struct{}
+	Matchers labels.Matchers
+	From     time.Time
+	To       time.Time
+	// Limit of 0 returns all transitions in the time range.
+	Limit int
+
+	// Result is sorted by the evaluation time, newest first.
+	Result []*StateTransition
+	// Truncated is true when the backend stopped reading the transitions before
+	// the end of the time range, older transitions are then missing from the result.
+	Truncated bool
+}
+
+// Matches returns true if the transition is of one of the rules of the query and
+// its labels match all matchers of the query.
+func (q *ListStateHistoryQuery) Matches(t *StateTransition) bool {
+	if q.RuleUIDs != nil {
+		if _, ok := q.RuleUIDs[t.RuleUID]; !ok {
+			return false
+		}
+	}
+	for _, m := range q.Matchers {
+		if !m.Matches(t.Labels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// SortedRuleUIDs returns the rules the transitions are restricted to in order.
+func (q *ListStateHistoryQuery) SortedRuleUIDs() []string {
+	uids := make([]string, 0, len(q.RuleUIDs))
+	for uid := range q.RuleUIDs {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	return uids
+}
@@ -3,8 +3,10 @@ package ngalert
 import (
 	"context"
 	"net/url"
+	"os"
 
 	"github.com/benbjohnson/clock"
+	"github.com/prometheus/alertmanager/cluster"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/grafana/pkg/api/routing"
@@ -17,23 +19,29 @@ import (
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/ngalert/api"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/image"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/deliverylog"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
 	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/state/historian"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/writer"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 func ProvideService(cfg *setting.Cfg, dataSourceCache datasources.CacheService, routeRegister routing.RouteRegister,
 	sqlStore *sqlstore.SQLStore, kvStore kvstore.KVStore, expressionService *expr.Service, dataProxy *datasourceproxy.DataSourceProxyService,
 	quotaService *quota.QuotaService, secretsService secrets.Service, notificationService notifications.Service, m *metrics.NGAlert,
-	folderService dashboards.FolderService, ac accesscontrol.AccessControl) (*AlertNG, error) {
+	folderService dashboards.FolderService, ac accesscontrol.AccessControl, renderService rendering.Service) (*AlertNG, error) {
 	ng := &AlertNG{
 		Cfg:                 cfg,
 		DataSourceCache:     dataSourceCache,
@@ -49,6 +57,7 @@ func ProvideService(cfg *setting.Cfg, dataSourceCache datasources.CacheService,
 		NotificationService: notificationService,
 		folderService:       folderService,
 		accesscontrol:       ac,
+		renderService:       renderService,
 	}
 
 	if ng.IsDisabled() {
@@ -78,7 +87,13 @@ type AlertNG struct {
 	Log                 log.Logger
 	schedule            schedule.ScheduleService
 	stateManager        *state.Manager
+	stateHistorian      historian.Backend
+	deliveryLog         *deliverylog.SQLLog
+	imageService        image.Service
+	replicaMembership   *schedule.DBMembership
 	folderService       dashboards.FolderService
+	renderService       rendering.Service
+	ruleStore           *store.DBstore
 
 	// Alerting notification services
 	MultiOrgAlertmanager *notifier.MultiOrgAlertmanager
@@ -96,6 +111,7 @@ func (ng *AlertNG) init() error {
 		FolderService:   ng.folderService,
 		AccessControl:   ng.accesscontrol,
 	}
+	ng.ruleStore = store
 
 	decryptFn := ng.SecretsService.GetDecryptedValue
 	multiOrgMetrics := ng.Metrics.GetMultiOrgAlertmanagerMetrics()
@@ -103,6 +119,11 @@ func (ng *AlertNG) init() error {
 	if err != nil {
 		return err
 	}
+	ng.deliveryLog = deliverylog.New(ng.Cfg.UnifiedAlerting.DeliveryLog, ng.SQLStore, log.New("ngalert.notifier.deliveries"))
+	if ng.deliveryLog != nil {
+		ng.MultiOrgAlertmanager.DeliveryRecorder = ng.deliveryLog
+	}
+	ng.MultiOrgAlertmanager.ImageStore = store
 
 	// Let's make sure we're able to complete an initial sync of Alertmanagers before we start the alerting components.
 	if err := ng.MultiOrgAlertmanager.LoadAndSyncAlertmanagersForOrgs(context.Background()); err != nil {
@@ -125,13 +146,38 @@ func (ng *AlertNG) init() error {
 		DisabledOrgs:            ng.Cfg.UnifiedAlerting.DisabledOrgs,
 		MinRuleInterval:         ng.Cfg.UnifiedAlerting.MinInterval,
 	}
+	if ng.Cfg.UnifiedAlerting.HAEvaluationSharding && ng.Cfg.UnifiedAlerting.ExecuteAlerts {
+		schedCfg.Membership = ng.newSchedulerMembership(store)
+	}
+	if recording := ng.Cfg.UnifiedAlerting.RecordingRules; recording.Enabled {
+		recordingWriter, err := writer.NewPrometheusWriter(writer.PrometheusConfig{
+			URL:               recording.URL,
+			BasicAuthUser:     recording.BasicAuthUsername,
+			BasicAuthPassword: recording.BasicAuthPassword,
+			Timeout:           recording.Timeout,
+		}, log.New("ngalert.writer"))
+		if err != nil {
+			return err
+		}
+		schedCfg.RecordingWriter = recordingWriter
+	}
 
 	appUrl, err := url.Parse(ng.Cfg.AppURL)
 	if err != nil {
 		ng.Log.Error("Failed to parse application URL. Continue without it.", "error", err)
 		appUrl = nil
 	}
-	stateManager := state.NewManager(ng.Log, ng.Metrics.GetStateMetrics(), appUrl, store, store, ng.SQLStore)
+	stateHistorian, err := historian.New(ng.Cfg.UnifiedAlerting.StateHistory, ng.SQLStore, log.New("ngalert.state.historian"))
+	if err != nil {
+		return err
+	}
+	ng.stateHistorian = stateHistorian
+	imageService, err := image.New(ng.Cfg, ng.renderService, store, log.New("ngalert.image"))
+	if err != nil {
+		return err
+	}
+	ng.imageService = imageService
+	stateManager := state.NewManager(ng.Log, ng.Metrics.GetStateMetrics(), appUrl, store, store, ng.SQLStore, stateHistorian, imageService)
 	scheduler := schedule.NewScheduler(schedCfg, ng.ExpressionService, appUrl, stateManager)
 
 	ng.stateManager = stateManager
@@ -141,6 +187,8 @@ func (ng *AlertNG) init() error {
 	policyService := provisioning.NewNotificationPolicyService(store, store, store, ng.Log)
 	contactPointService := provisioning.NewContactPointService(store, ng.SecretsService, store, store, ng.Log)
 	templateService := provisioning.NewTemplateService(store, store, store, ng.Log)
+	muteTimingService := provisioning.NewMuteTimingService(store, store, store, ng.Log)
+	alertRuleService := provisioning.NewAlertRuleService(store, store, store, ng.Cfg.UnifiedAlerting.DefaultRuleEvaluationInterval, ng.Log)
 
 	api := api.API{
 		Cfg:                  ng.Cfg,
@@ -159,10 +207,16 @@ func (ng *AlertNG) init() error {
 		ProvenanceStore:      store,
 		MultiOrgAlertmanager: ng.MultiOrgAlertmanager,
 		StateManager:         ng.stateManager,
+		StateHistorian:       stateHistorian,
 		AccessControl:        ng.accesscontrol,
 		Policies:             policyService,
 		ContactPointService:  contactPointService,
 		Templates:            templateService,
+		MuteTimings:          muteTimingService,
+		AlertRules:           alertRuleService,
+	}
+	if ng.deliveryLog != nil {
+		api.DeliveryLog = ng.deliveryLog
 	}
 	api.RegisterAPIEndpoints(ng.Metrics.GetAPIMetrics())
 
@@ -172,6 +226,9 @@ func (ng *AlertNG) init() error {
 // Run starts the scheduler and Alertmanager.
 func (ng *AlertNG) Run(ctx context.Context) error {
 	ng.Log.Debug("ngalert starting")
+	// The states of all rules are restored so that the API serves them. When the evaluation is sharded, the
+	// scheduler restores the states of a rule again when it takes it over, and the states of the rules
+	// evaluated by other replicas are read-only and refreshed from the database at the interval of the rule.
 	ng.stateManager.Warm(ctx)
 
 	children, subCtx := errgroup.WithContext(ctx)
@@ -184,9 +241,53 @@ func (ng *AlertNG) Run(ctx context.Context) error {
 	children.Go(func() error {
 		return ng.MultiOrgAlertmanager.Run(subCtx)
 	})
+	if ng.stateHistorian != nil {
+		children.Go(func() error {
+			return ng.stateHistorian.Run(subCtx)
+		})
+	}
+	if ng.deliveryLog != nil {
+		children.Go(func() error {
+			return ng.deliveryLog.Run(subCtx)
+		})
+	}
+	if ng.imageService != nil {
+		children.Go(func() error {
+			return ng.imageService.Run(subCtx)
+		})
+	}
+	if ng.replicaMembership != nil {
+		children.Go(func() error {
+			return ng.replicaMembership.Run(subCtx)
+		})
+	}
 	return children.Wait()
 }
 
+// newSchedulerMembership returns the replicas of the gossip cluster when high availability is configured,
+// otherwise the replicas which keep a lease in the database.
+func (ng *AlertNG) newSchedulerMembership(store *store.DBstore) schedule.Membership {
+	logger := log.New("ngalert.scheduler.sharding")
+	if peer, ok := ng.MultiOrgAlertmanager.Peer().(*cluster.Peer); ok {
+		logger.Info("sharding the evaluation of alert rules between the peers of the cluster")
+		return schedule.NewPeerMembership(peer)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "grafana"
+	}
+	replicaID := hostname + "-" + util.GenerateShortUID()
+	logger.Info("sharding the evaluation of alert rules between the replicas registered in the database", "replica", replicaID)
+	ng.replicaMembership = schedule.NewDBMembership(store, replicaID, ng.Cfg.UnifiedAlerting.HAReplicaHeartbeat, ng.Cfg.UnifiedAlerting.HAReplicaTimeout, logger)
+	return ng.replicaMembership
+}
+
+// RuleStore returns the store of the alert rules, it is nil when unified alerting is disabled.
+func (ng *AlertNG) RuleStore() *store.DBstore {
+	return ng.ruleStore
+}
+
 // IsDisabled returns true if the alerting service is disable for this instance.
 func (ng *AlertNG) IsDisabled() bool {
 	if ng.Cfg == nil {
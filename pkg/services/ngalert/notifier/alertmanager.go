@@ -12,6 +12,7 @@ import (
 	"regexp"
 	"strconv"
 	"sync"
+	tmpltext "text/template"
 	"time"
 	"unicode/utf8"
 
@@ -126,10 +127,14 @@ type Alertmanager struct {
 	orgID           int64
 
 	decryptFn channels.GetDecryptedValueFn
+	// deliveries records the attempts to deliver notifications, it is nil when the delivery log is disabled.
+	deliveries DeliveryRecorder
+	// images gets the images attached to the notifications, it is nil when there are none.
+	images channels.ImageStore
 }
 
 func newAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store store.AlertingStore, kvStore kvstore.KVStore,
-	peer ClusterPeer, decryptFn channels.GetDecryptedValueFn, ns notifications.Service, m *metrics.Alertmanager) (*Alertmanager, error) {
+	peer ClusterPeer, decryptFn channels.GetDecryptedValueFn, ns notifications.Service, m *metrics.Alertmanager, deliveries DeliveryRecorder, images channels.ImageStore) (*Alertmanager, error) {
 	am := &Alertmanager{
 		Settings:            cfg,
 		stopc:               make(chan struct{}),
@@ -144,6 +149,8 @@ func newAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store s
 		NotificationService: ns,
 		orgID:               orgID,
 		decryptFn:           decryptFn,
+		deliveries:          deliveries,
+		images:              images,
 	}
 
 	am.fileStore = NewFileStore(am.orgID, kvStore, am.WorkingDirPath())
@@ -317,11 +324,13 @@ func (am *Alertmanager) ApplyConfig(dbCfg *ngmodels.AlertConfiguration) error {
 	return nil
 }
 
-func (am *Alertmanager) getTemplate() (*template.Template, error) {
+// getTemplate returns the templates of the configuration, parsed by the Alertmanager and with the functions of the
+// HTTP notifier.
+func (am *Alertmanager) getTemplate() (*template.Template, *tmpltext.Template, error) {
 	am.reloadConfigMtx.RLock()
 	defer am.reloadConfigMtx.RUnlock()
 	if !am.ready() {
-		return nil, errors.New("alertmanager is not initialized")
+		return nil, nil, errors.New("alertmanager is not initialized")
 	}
 	paths := make([]string, 0, len(am.config.TemplateFiles))
 	for name := range am.config.TemplateFiles {
@@ -330,17 +339,21 @@ func (am *Alertmanager) getTemplate() (*template.Template, error) {
 	return am.templateFromPaths(paths...)
 }
 
-func (am *Alertmanager) templateFromPaths(paths ...string) (*template.Template, error) {
+func (am *Alertmanager) templateFromPaths(paths ...string) (*template.Template, *tmpltext.Template, error) {
 	tmpl, err := template.FromGlobs(paths...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	externalURL, err := url.Parse(am.Settings.AppURL)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	tmpl.ExternalURL = externalURL
-	return tmpl, nil
+	textTmpl, err := channels.TextTemplateFromGlobs(paths...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tmpl, textTmpl, nil
 }
 
 func (am *Alertmanager) buildMuteTimesMap(muteTimeIntervals []config.MuteTimeInterval) map[string][]timeinterval.TimeInterval {
@@ -387,13 +400,13 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 	}
 
 	// With the templates persisted, create the template list using the paths.
-	tmpl, err := am.templateFromPaths(paths...)
+	tmpl, textTmpl, err := am.templateFromPaths(paths...)
 	if err != nil {
 		return err
 	}
 
 	// Finally, build the integrations map using the receiver configuration and templates.
-	integrationsMap, err := am.buildIntegrationsMap(cfg.AlertmanagerConfig.Receivers, tmpl)
+	integrationsMap, err := am.buildIntegrationsMap(cfg.AlertmanagerConfig.Receivers, tmpl, textTmpl)
 	if err != nil {
 		return fmt.Errorf("failed to build integration map: %w", err)
 	}
@@ -447,10 +460,10 @@ func (am *Alertmanager) WorkingDirPath() string {
 }
 
 // buildIntegrationsMap builds a map of name to the list of Grafana integration notifiers off of a list of receiver config.
-func (am *Alertmanager) buildIntegrationsMap(receivers []*apimodels.PostableApiReceiver, templates *template.Template) (map[string][]notify.Integration, error) {
+func (am *Alertmanager) buildIntegrationsMap(receivers []*apimodels.PostableApiReceiver, templates *template.Template, textTemplates *tmpltext.Template) (map[string][]notify.Integration, error) {
 	integrationsMap := make(map[string][]notify.Integration, len(receivers))
 	for _, receiver := range receivers {
-		integrations, err := am.buildReceiverIntegrations(receiver, templates)
+		integrations, err := am.buildReceiverIntegrations(receiver, templates, textTemplates)
 		if err != nil {
 			return nil, err
 		}
@@ -461,19 +474,31 @@ func (am *Alertmanager) buildIntegrationsMap(receivers []*apimodels.PostableApiR
 }
 
 // buildReceiverIntegrations builds a list of integration notifiers off of a receiver config.
-func (am *Alertmanager) buildReceiverIntegrations(receiver *apimodels.PostableApiReceiver, tmpl *template.Template) ([]notify.Integration, error) {
+func (am *Alertmanager) buildReceiverIntegrations(receiver *apimodels.PostableApiReceiver, tmpl *template.Template, textTmpl *tmpltext.Template) ([]notify.Integration, error) {
 	var integrations []notify.Integration
 	for i, r := range receiver.GrafanaManagedReceivers {
-		n, err := am.buildReceiverIntegration(r, tmpl)
+		n, err := am.buildReceiverIntegration(r, tmpl, textTmpl)
 		if err != nil {
 			return nil, err
 		}
-		integrations = append(integrations, notify.NewIntegration(n, n, r.Type, i))
+		var integrationNotifier notify.Notifier = n
+		if am.deliveries != nil {
+			integrationNotifier = &deliveryRecordingNotifier{
+				notifier:    n,
+				recorder:    am.deliveries,
+				orgID:       am.orgID,
+				receiver:    receiver.Name,
+				integration: r.Type,
+				index:       i,
+				logger:      am.logger,
+			}
+		}
+		integrations = append(integrations, notify.NewIntegration(integrationNotifier, n, r.Type, i))
 	}
 	return integrations, nil
 }
 
-func (am *Alertmanager) buildReceiverIntegration(r *apimodels.PostableGrafanaReceiver, tmpl *template.Template) (channels.NotificationChannel, error) {
+func (am *Alertmanager) buildReceiverIntegration(r *apimodels.PostableGrafanaReceiver, tmpl *template.Template, textTmpl *tmpltext.Template) (channels.NotificationChannel, error) {
 	// secure settings are already encrypted at this point
 	secureSettings := make(map[string][]byte, len(r.SecureSettings))
 
@@ -499,13 +524,14 @@ func (am *Alertmanager) buildReceiverIntegration(r *apimodels.PostableGrafanaRec
 			SecureSettings:        secureSettings,
 		}
 	)
-	factoryConfig, err := channels.NewFactoryConfig(cfg, am.NotificationService, am.decryptFn, tmpl)
+	factoryConfig, err := channels.NewFactoryConfig(cfg, am.NotificationService, am.decryptFn, tmpl, am.images)
 	if err != nil {
 		return nil, InvalidReceiverError{
 			Receiver: r,
 			Err:      err,
 		}
 	}
+	factoryConfig.TextTemplate = textTmpl
 	receiverFactory, exists := channels.Factory(r.Type)
 	if !exists {
 		return nil, InvalidReceiverError{
@@ -673,6 +699,9 @@ func (am *Alertmanager) createReceiverStage(name string, integrations []notify.I
 		var s notify.MultiStage
 		s = append(s, notify.NewWaitStage(wait))
 		s = append(s, notify.NewDedupStage(&integrations[i], notificationLog, recv))
+		if am.deliveries != nil {
+			s = append(s, notify.StageFunc(deliveryAttemptsStage))
+		}
 		s = append(s, notify.NewRetryStage(integrations[i], name, am.stageMetrics))
 		s = append(s, notify.NewSetNotifiesStage(notificationLog, recv))
 
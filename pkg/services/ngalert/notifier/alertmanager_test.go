@@ -11,16 +11,21 @@ import (
 
 	"github.com/go-openapi/strfmt"
 	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
+	gfmodels "github.com/grafana/grafana/pkg/models"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/notifications"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
@@ -44,7 +49,7 @@ func setupAMTest(t *testing.T) *Alertmanager {
 	kvStore := NewFakeKVStore(t)
 	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
 	decryptFn := secretsService.GetDecryptedValue
-	am, err := newAlertmanager(context.Background(), 1, cfg, s, kvStore, &NilPeer{}, decryptFn, nil, m)
+	am, err := newAlertmanager(context.Background(), 1, cfg, s, kvStore, &NilPeer{}, decryptFn, nil, m, nil, nil)
 	require.NoError(t, err)
 	return am
 }
@@ -412,3 +417,38 @@ func TestSilenceCleanup(t *testing.T) {
 		return len(found) == 2
 	}, 6*time.Second, 150*time.Millisecond)
 }
+
+type fakeNotificationService struct {
+	notifications.Service
+	webhooks []gfmodels.SendWebhookSync
+}
+
+func (s *fakeNotificationService) SendWebhookSync(_ context.Context, cmd *gfmodels.SendWebhookSync) error {
+	s.webhooks = append(s.webhooks, *cmd)
+	return nil
+}
+
+func TestAlertmanager_TemplateFuncs(t *testing.T) {
+	am := setupAMTest(t)
+	ns := &fakeNotificationService{}
+	am.NotificationService = ns
+
+	tmpl, textTmpl, err := am.templateFromPaths()
+	require.NoError(t, err)
+	settings, err := simplejson.NewJson([]byte(`{"url": "http://localhost/alerts", "body": "{\"summary\": {{ .CommonAnnotations.summary | toJson }}}"}`))
+	require.NoError(t, err)
+	n, err := am.buildReceiverIntegration(&apimodels.PostableGrafanaReceiver{UID: "http", Name: "http", Type: "http", Settings: settings}, tmpl, textTmpl)
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "disk"})
+	_, err = n.Notify(ctx, &types.Alert{Alert: model.Alert{
+		Labels:      model.LabelSet{"alertname": "disk"},
+		Annotations: model.LabelSet{"summary": `the "disk" is full`},
+	}})
+	require.NoError(t, err)
+	require.Len(t, ns.webhooks, 1)
+	require.Equal(t, `{"summary": "the \"disk\" is full"}`, ns.webhooks[0].Body)
+	// the functions of the Alertmanager are not changed
+	require.NotContains(t, template.DefaultFuncs, "toJson")
+}
@@ -165,6 +165,127 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 				},
 			},
 		},
+		{
+			Type:        "mqtt",
+			Name:        "MQTT",
+			Description: "Publishes notifications to an MQTT broker",
+			Heading:     "MQTT settings",
+			Options: []alerting.NotifierOption{
+				{
+					Label:        "Broker URL",
+					Description:  "The URL of the MQTT broker, use ssl:// to connect with TLS",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "tcp://localhost:1883",
+					PropertyName: "brokerUrl",
+					Required:     true,
+				},
+				{
+					Label:        "Topic",
+					Description:  "The topic the notifications are published to. You can use template variables.",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "grafana/alerts",
+					PropertyName: "topic",
+					Required:     true,
+				},
+				{
+					Label:        "Client ID",
+					Description:  "The client ID used to connect to the broker, a random one is used when empty",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "clientId",
+				},
+				{
+					Label:        "Message format",
+					Description:  "Publish the JSON payload of the webhook contact point, or the message as text",
+					Element:      alerting.ElementTypeSelect,
+					PropertyName: "messageFormat",
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "json",
+							Label: "JSON",
+						},
+						{
+							Value: "text",
+							Label: "Text",
+						},
+					},
+				},
+				{
+					Label:        "Message",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{{ template "default.message" . }}`,
+					PropertyName: "message",
+				},
+				{
+					Label:        "QoS",
+					Description:  "The quality of service of the delivery of the messages",
+					Element:      alerting.ElementTypeSelect,
+					PropertyName: "qos",
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "0",
+							Label: "At most once (0)",
+						},
+						{
+							Value: "1",
+							Label: "At least once (1)",
+						},
+						{
+							Value: "2",
+							Label: "Exactly once (2)",
+						},
+					},
+				},
+				{
+					Label:        "Retain",
+					Description:  "The broker keeps the last message of the topic for the new subscribers",
+					Element:      alerting.ElementTypeCheckbox,
+					PropertyName: "retain",
+				},
+				{
+					Label:        "Username",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "username",
+					Secure:       true,
+				},
+				{
+					Label:        "Password",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					PropertyName: "password",
+					Secure:       true,
+				},
+				{
+					Label:        "CA certificate",
+					Description:  "The PEM encoded certificate of the CA of the broker, the system CAs are used when empty",
+					Element:      alerting.ElementTypeTextArea,
+					PropertyName: "tlsCACertificate",
+				},
+				{
+					Label:        "Client certificate",
+					Description:  "The PEM encoded client certificate",
+					Element:      alerting.ElementTypeTextArea,
+					PropertyName: "tlsClientCertificate",
+					Secure:       true,
+				},
+				{
+					Label:        "Client key",
+					Description:  "The PEM encoded key of the client certificate",
+					Element:      alerting.ElementTypeTextArea,
+					PropertyName: "tlsClientKey",
+					Secure:       true,
+				},
+				{
+					Label:        "Skip TLS verification",
+					Description:  "Do not verify the certificate of the broker",
+					Element:      alerting.ElementTypeCheckbox,
+					PropertyName: "insecureSkipVerify",
+				},
+			},
+		},
 		{
 			Type:        "email",
 			Name:        "Email",
@@ -666,6 +787,152 @@ func GetAvailableNotifiers() []*alerting.NotifierPlugin {
 				},
 			},
 		},
+		{
+			Type:        "http",
+			Name:        "HTTP",
+			Description: "Sends HTTP requests whose URL, method, headers and body are templates",
+			Heading:     "HTTP settings",
+			Options: []alerting.NotifierOption{
+				{
+					Label:        "Url",
+					Description:  "You can use template variables.",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "https://example.com/api/alerts",
+					PropertyName: "url",
+					Required:     true,
+				},
+				{
+					Label:        "Http Method",
+					Description:  "POST, PUT or PATCH. You can use template variables.",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "POST",
+					PropertyName: "httpMethod",
+				},
+				{
+					Label:        "Headers",
+					Description:  "One header per line, as Name: Value. You can use template variables.",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  "X-Severity: {{ .CommonLabels.severity }}",
+					PropertyName: "headers",
+				},
+				{
+					Label:        "Content Type",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "application/json",
+					PropertyName: "contentType",
+				},
+				{
+					Label:        "Body",
+					Description:  "The body of the request, the body of the webhook contact point is sent when empty. You can use template variables, toJson writes a value as JSON.",
+					Element:      alerting.ElementTypeTextArea,
+					Placeholder:  `{"summary": {{ .CommonAnnotations.summary | toJson }}}`,
+					PropertyName: "body",
+				},
+				{
+					Label:        "Authentication",
+					Element:      alerting.ElementTypeSelect,
+					PropertyName: "authType",
+					SelectOptions: []alerting.SelectOption{
+						{
+							Value: "none",
+							Label: "None",
+						},
+						{
+							Value: "basic",
+							Label: "Basic",
+						},
+						{
+							Value: "bearer",
+							Label: "Bearer token",
+						},
+						{
+							Value: "header",
+							Label: "Header",
+						},
+					},
+				},
+				{
+					Label:        "Username",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "username",
+					ShowWhen: alerting.ShowWhen{
+						Field: "authType",
+						Is:    "basic",
+					},
+				},
+				{
+					Label:        "Password",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					PropertyName: "password",
+					Secure:       true,
+					ShowWhen: alerting.ShowWhen{
+						Field: "authType",
+						Is:    "basic",
+					},
+				},
+				{
+					Label:        "Token",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					PropertyName: "bearerToken",
+					Secure:       true,
+					ShowWhen: alerting.ShowWhen{
+						Field: "authType",
+						Is:    "bearer",
+					},
+				},
+				{
+					Label:        "Header Name",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					Placeholder:  "Authorization",
+					PropertyName: "authHeaderName",
+					ShowWhen: alerting.ShowWhen{
+						Field: "authType",
+						Is:    "header",
+					},
+				},
+				{
+					Label:        "Header Value",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypePassword,
+					PropertyName: "authHeaderValue",
+					Secure:       true,
+					ShowWhen: alerting.ShowWhen{
+						Field: "authType",
+						Is:    "header",
+					},
+				},
+				{
+					Label:        "Resolve Url",
+					Description:  "Optional URL of the request sent when the alerts are resolved, the fields of the resolve request that are empty are the same as the request.",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "resolveUrl",
+				},
+				{
+					Label:        "Resolve Http Method",
+					Element:      alerting.ElementTypeInput,
+					InputType:    alerting.InputTypeText,
+					PropertyName: "resolveHttpMethod",
+				},
+				{
+					Label:        "Resolve Headers",
+					Element:      alerting.ElementTypeTextArea,
+					PropertyName: "resolveHeaders",
+				},
+				{
+					Label:        "Resolve Body",
+					Element:      alerting.ElementTypeTextArea,
+					PropertyName: "resolveBody",
+				},
+			},
+		},
 		{
 			Type:        "wecom",
 			Name:        "WeCom",
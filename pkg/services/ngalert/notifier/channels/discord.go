@@ -1,9 +1,13 @@
 package channels
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
+	"mime/multipart"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -13,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -21,6 +26,7 @@ type DiscordNotifier struct {
 	*Base
 	log                log.Logger
 	ns                 notifications.WebhookSender
+	images             ImageStore
 	tmpl               *template.Template
 	Content            string
 	AvatarURL          string
@@ -58,10 +64,10 @@ func DiscordFactory(fc FactoryConfig) (NotificationChannel, error) {
 			Cfg:    *fc.Config,
 		}
 	}
-	return NewDiscordNotifier(cfg, fc.NotificationService, fc.Template), nil
+	return NewDiscordNotifier(cfg, fc.NotificationService, fc.ImageStore, fc.Template), nil
 }
 
-func NewDiscordNotifier(config *DiscordConfig, ns notifications.WebhookSender, t *template.Template) *DiscordNotifier {
+func NewDiscordNotifier(config *DiscordConfig, ns notifications.WebhookSender, images ImageStore, t *template.Template) *DiscordNotifier {
 	return &DiscordNotifier{
 		Base: NewBase(&models.AlertNotification{
 			Uid:                   config.UID,
@@ -76,6 +82,7 @@ func NewDiscordNotifier(config *DiscordConfig, ns notifications.WebhookSender, t
 		WebhookURL:         config.WebhookURL,
 		log:                log.New("alerting.notifier.discord"),
 		ns:                 ns,
+		images:             images,
 		tmpl:               t,
 		UseDiscordUsername: config.UseDiscordUsername,
 	}
@@ -127,6 +134,7 @@ func (d DiscordNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 	ruleURL := joinUrlPath(d.tmpl.ExternalURL.String(), "/alerting/list", d.log)
 	embed.Set("url", ruleURL)
 
+	attachment := d.addImage(ctx, embed, as...)
 	bodyJSON.Set("embeds", []interface{}{embed})
 
 	if tmplErr != nil {
@@ -150,6 +158,12 @@ func (d DiscordNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 		ContentType: "application/json",
 		Body:        string(body),
 	}
+	if attachment != nil {
+		cmd.Body, cmd.ContentType, err = buildDiscordMultipart(body, attachment)
+		if err != nil {
+			return false, err
+		}
+	}
 
 	if err := d.ns.SendWebhookSync(ctx, cmd); err != nil {
 		d.log.Error("Failed to send notification to Discord", "error", err)
@@ -158,6 +172,71 @@ func (d DiscordNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 	return true, nil
 }
 
+// discordAttachment is a file uploaded with the message.
+type discordAttachment struct {
+	name    string
+	content []byte
+}
+
+// addImage shows the first image of the alerts in the embed. The uploaded images are linked, otherwise the file
+// of the image is returned to be attached to the message.
+func (d DiscordNotifier) addImage(ctx context.Context, embed *simplejson.Json, as ...*types.Alert) *discordAttachment {
+	var attachment *discordAttachment
+	_ = withStoredImages(ctx, d.log, d.images,
+		func(_ int, image *ngmodels.Image) error {
+			if image.HasURL() {
+				embed.Set("image", map[string]interface{}{"url": image.URL})
+				return errImagesDone
+			}
+			f, err := openImage(image)
+			if err != nil {
+				if !errors.Is(err, ngmodels.ErrImageNotFound) {
+					d.log.Warn("failed to open the image", "path", image.Path, "err", err)
+				}
+				return nil
+			}
+			defer func() {
+				if err := f.Close(); err != nil {
+					d.log.Warn("failed to close the image", "path", image.Path, "err", err)
+				}
+			}()
+			content, err := io.ReadAll(f)
+			if err != nil {
+				d.log.Warn("failed to read the image", "path", image.Path, "err", err)
+				return nil
+			}
+			attachment = &discordAttachment{name: filepath.Base(image.Path), content: content}
+			embed.Set("image", map[string]interface{}{"url": "attachment://" + attachment.name})
+			return errImagesDone
+		}, as...)
+	return attachment
+}
+
+// buildDiscordMultipart returns the body of a message with an attachment and its content type.
+func buildDiscordMultipart(payload []byte, attachment *discordAttachment) (string, string, error) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	if boundary := GetBoundary(); boundary != "" {
+		if err := w.SetBoundary(boundary); err != nil {
+			return "", "", err
+		}
+	}
+	if err := w.WriteField("payload_json", string(payload)); err != nil {
+		return "", "", err
+	}
+	fw, err := w.CreateFormFile("file", attachment.name)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := fw.Write(attachment.content); err != nil {
+		return "", "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+	return b.String(), w.FormDataContentType(), nil
+}
+
 func (d DiscordNotifier) SendResolved() bool {
 	return !d.GetDisableResolveMessage()
 }
@@ -3,7 +3,10 @@ package channels
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"mime/multipart"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/prometheus/alertmanager/notify"
@@ -17,6 +20,7 @@ import (
 
 func TestDiscordNotifier(t *testing.T) {
 	tmpl := templateForTests(t)
+	images := newFakeImageStore(t)
 
 	externalURL, err := url.Parse("http://localhost")
 	require.NoError(t, err)
@@ -256,6 +260,36 @@ func TestDiscordNotifier(t *testing.T) {
 			},
 			expMsgError: nil,
 		},
+		{
+			name:     "Default config with uploaded image",
+			settings: `{"url": "http://localhost"}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1", "__alertImageToken__": "test-image-1"},
+					},
+				},
+			},
+			expMsg: map[string]interface{}{
+				"content": "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1\n",
+				"embeds": []interface{}{map[string]interface{}{
+					"color": 1.4037554e+07,
+					"footer": map[string]interface{}{
+						"icon_url": "https://grafana.com/assets/img/fav32.png",
+						"text":     "Grafana v" + setting.BuildVersion,
+					},
+					"image": map[string]interface{}{
+						"url": "https://www.example.com/test-image-1.png",
+					},
+					"title": "[FIRING:1]  (val1)",
+					"url":   "http://localhost/alerting/list",
+					"type":  "rich",
+				}},
+				"username": "Grafana",
+			},
+			expMsgError: nil,
+		},
 	}
 
 	for _, c := range cases {
@@ -279,7 +313,7 @@ func TestDiscordNotifier(t *testing.T) {
 
 			ctx := notify.WithGroupKey(context.Background(), "alertname")
 			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
-			dn := NewDiscordNotifier(cfg, webhookSender, tmpl)
+			dn := NewDiscordNotifier(cfg, webhookSender, images, tmpl)
 			ok, err := dn.Notify(ctx, c.alerts...)
 			if c.expMsgError != nil {
 				require.False(t, ok)
@@ -297,3 +331,55 @@ func TestDiscordNotifier(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscordNotifier_AttachesImageFile(t *testing.T) {
+	tmpl := templateForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	origGetBoundary := GetBoundary
+	boundary := "abcd"
+	GetBoundary = func() string { return boundary }
+	t.Cleanup(func() {
+		GetBoundary = origGetBoundary
+	})
+
+	settingsJson, err := simplejson.NewJson([]byte(`{"url": "http://localhost"}`))
+	require.NoError(t, err)
+	cfg, err := NewDiscordConfig(&NotificationChannelConfig{
+		Name:     "discord_testing",
+		Type:     "discord",
+		Settings: settingsJson,
+	})
+	require.NoError(t, err)
+
+	webhookSender := mockNotificationService()
+	dn := NewDiscordNotifier(cfg, webhookSender, newFakeImageStore(t), tmpl)
+	ok, err := dn.Notify(context.Background(), &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{"__alertImageToken__": "test-image-2"},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Equal(t, "multipart/form-data; boundary="+boundary, webhookSender.Webhook.ContentType)
+	r := multipart.NewReader(strings.NewReader(webhookSender.Webhook.Body), boundary)
+	payload, err := r.NextPart()
+	require.NoError(t, err)
+	require.Equal(t, "payload_json", payload.FormName())
+	var msg map[string]interface{}
+	require.NoError(t, json.NewDecoder(payload).Decode(&msg))
+	embed := msg["embeds"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{"url": "attachment://test-image-2.png"}, embed["image"])
+
+	file, err := r.NextPart()
+	require.NoError(t, err)
+	require.Equal(t, "file", file.FormName())
+	require.Equal(t, "test-image-2.png", file.FileName())
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.Equal(t, "image", string(content))
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"net/url"
+	"os"
 	"path"
+	"path/filepath"
 
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -24,6 +27,7 @@ type EmailNotifier struct {
 	Message     string
 	log         log.Logger
 	ns          notifications.EmailSender
+	images      ImageStore
 	tmpl        *template.Template
 }
 
@@ -42,7 +46,7 @@ func EmailFactory(fc FactoryConfig) (NotificationChannel, error) {
 			Cfg:    *fc.Config,
 		}
 	}
-	return NewEmailNotifier(cfg, fc.NotificationService, fc.Template), nil
+	return NewEmailNotifier(cfg, fc.NotificationService, fc.ImageStore, fc.Template), nil
 }
 
 func NewEmailConfig(config *NotificationChannelConfig) (*EmailConfig, error) {
@@ -62,7 +66,7 @@ func NewEmailConfig(config *NotificationChannelConfig) (*EmailConfig, error) {
 
 // NewEmailNotifier is the constructor function
 // for the EmailNotifier.
-func NewEmailNotifier(config *EmailConfig, ns notifications.EmailSender, t *template.Template) *EmailNotifier {
+func NewEmailNotifier(config *EmailConfig, ns notifications.EmailSender, images ImageStore, t *template.Template) *EmailNotifier {
 	return &EmailNotifier{
 		Base: NewBase(&models.AlertNotification{
 			Uid:                   config.UID,
@@ -76,6 +80,7 @@ func NewEmailNotifier(config *EmailConfig, ns notifications.EmailSender, t *temp
 		Message:     config.Message,
 		log:         log.New("alerting.notifier.email"),
 		ns:          ns,
+		images:      images,
 		tmpl:        t,
 	}
 }
@@ -100,6 +105,26 @@ func (en *EmailNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 		en.log.Debug("failed to parse external URL", "url", en.tmpl.ExternalURL.String(), "err", err.Error())
 	}
 
+	// the uploaded images are linked, the files of the others are embedded in the email.
+	embeddedFiles := []string{}
+	_ = withStoredImages(ctx, en.log, en.images,
+		func(index int, image *ngmodels.Image) error {
+			if image.HasURL() {
+				data.Alerts[index].ImageURL = image.URL
+				return nil
+			}
+			if _, err := os.Stat(image.Path); image.HasPath() && err == nil {
+				data.Alerts[index].EmbeddedImage = filepath.Base(image.Path)
+				for _, f := range embeddedFiles {
+					if f == image.Path {
+						return nil
+					}
+				}
+				embeddedFiles = append(embeddedFiles, image.Path)
+			}
+			return nil
+		}, as...)
+
 	cmd := &models.SendEmailCommandSync{
 		SendEmailCommand: models.SendEmailCommand{
 			Subject: title,
@@ -115,9 +140,10 @@ func (en *EmailNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool,
 				"RuleUrl":           ruleURL,
 				"AlertPageUrl":      alertPageURL,
 			},
-			To:          en.Addresses,
-			SingleEmail: en.SingleEmail,
-			Template:    "ng_alert_notification",
+			EmbeddedFiles: embeddedFiles,
+			To:            en.Addresses,
+			SingleEmail:   en.SingleEmail,
+			Template:      "ng_alert_notification",
 		},
 	}
 
@@ -52,7 +52,7 @@ func TestEmailNotifier(t *testing.T) {
 			Settings: settingsJSON,
 		})
 		require.NoError(t, err)
-		emailNotifier := NewEmailNotifier(cfg, emailSender, tmpl)
+		emailNotifier := NewEmailNotifier(cfg, emailSender, &UnavailableImageStore{}, tmpl)
 
 		alerts := []*types.Alert{
 			{
@@ -103,6 +103,54 @@ func TestEmailNotifier(t *testing.T) {
 			},
 		}, expected)
 	})
+
+	t.Run("links the uploaded images and embeds the others", func(t *testing.T) {
+		settingsJSON, err := simplejson.NewJson([]byte(`{"addresses": "someops@example.com"}`))
+		require.NoError(t, err)
+
+		emailSender := mockNotificationService()
+		cfg, err := NewEmailConfig(&NotificationChannelConfig{
+			Name:     "ops",
+			Type:     "email",
+			Settings: settingsJSON,
+		})
+		require.NoError(t, err)
+		images := newFakeImageStore(t)
+		emailNotifier := NewEmailNotifier(cfg, emailSender, images, tmpl)
+
+		alerts := []*types.Alert{
+			{
+				Alert: model.Alert{
+					Labels:      model.LabelSet{"alertname": "AlwaysFiring", "instance": "1"},
+					Annotations: model.LabelSet{"__alertImageToken__": "test-image-1"},
+				},
+			},
+			{
+				Alert: model.Alert{
+					Labels:      model.LabelSet{"alertname": "AlwaysFiring", "instance": "2"},
+					Annotations: model.LabelSet{"__alertImageToken__": "test-image-2"},
+				},
+			},
+			{
+				Alert: model.Alert{
+					Labels:      model.LabelSet{"alertname": "AlwaysFiring", "instance": "3"},
+					Annotations: model.LabelSet{"__alertImageToken__": "test-image-2"},
+				},
+			},
+		}
+
+		ok, err := emailNotifier.Notify(context.Background(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		extended := emailSender.EmailSync.Data["Alerts"].(ExtendedAlerts)
+		require.Len(t, extended, 3)
+		require.Equal(t, "https://www.example.com/test-image-1.png", extended[0].ImageURL)
+		require.Empty(t, extended[0].EmbeddedImage)
+		require.Equal(t, "test-image-2.png", extended[1].EmbeddedImage)
+		require.Equal(t, "test-image-2.png", extended[2].EmbeddedImage)
+		require.Equal(t, []string{images.images[1].Path}, emailSender.EmailSync.EmbeddedFiles)
+	})
 }
 
 func TestEmailNotifierIntegration(t *testing.T) {
@@ -289,7 +337,7 @@ func createSut(t *testing.T, messageTmpl string, emailTmpl *template.Template, n
 		Settings: settingsJSON,
 	})
 	require.NoError(t, err)
-	emailNotifier := NewEmailNotifier(cfg, ns, emailTmpl)
+	emailNotifier := NewEmailNotifier(cfg, ns, &UnavailableImageStore{}, emailTmpl)
 
 	return emailNotifier
 }
@@ -3,6 +3,7 @@ package channels
 import (
 	"errors"
 	"strings"
+	tmpltext "text/template"
 
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/prometheus/alertmanager/template"
@@ -13,10 +14,13 @@ type FactoryConfig struct {
 	NotificationService notifications.Service
 	DecryptFunc         GetDecryptedValueFn
 	Template            *template.Template
+	// TextTemplate is the templates with the functions that Grafana adds, it templates the requests of the HTTP notifier.
+	TextTemplate *tmpltext.Template
+	ImageStore   ImageStore
 }
 
 func NewFactoryConfig(config *NotificationChannelConfig, notificationService notifications.Service,
-	decryptFunc GetDecryptedValueFn, template *template.Template) (FactoryConfig, error) {
+	decryptFunc GetDecryptedValueFn, template *template.Template, imageStore ImageStore) (FactoryConfig, error) {
 	if config.Settings == nil {
 		return FactoryConfig{}, errors.New("no settings supplied")
 	}
@@ -25,11 +29,15 @@ func NewFactoryConfig(config *NotificationChannelConfig, notificationService not
 	if config.SecureSettings == nil {
 		config.SecureSettings = map[string][]byte{}
 	}
+	if imageStore == nil {
+		imageStore = &UnavailableImageStore{}
+	}
 	return FactoryConfig{
 		Config:              config,
 		NotificationService: notificationService,
 		DecryptFunc:         decryptFunc,
 		Template:            template,
+		ImageStore:          imageStore,
 	}, nil
 }
 
@@ -39,8 +47,10 @@ var receiverFactories = map[string]func(FactoryConfig) (NotificationChannel, err
 	"discord":                 DiscordFactory,
 	"email":                   EmailFactory,
 	"googlechat":              GoogleChatFactory,
+	"http":                    HTTPFactory,
 	"kafka":                   KafkaFactory,
 	"line":                    LineFactory,
+	"mqtt":                    MQTTFactory,
 	"opsgenie":                OpsgenieFactory,
 	"pagerduty":               PagerdutyFactory,
 	"pushover":                PushoverFactory,
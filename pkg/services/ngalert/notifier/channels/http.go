@@ -0,0 +1,310 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	tmpltext "text/template"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	httpAuthNone   = "none"
+	httpAuthBasic  = "basic"
+	httpAuthBearer = "bearer"
+	httpAuthHeader = "header"
+)
+
+// HTTPRequestTemplate is the templates of the request sent by the HTTP notifier.
+type HTTPRequestTemplate struct {
+	URL        string
+	HTTPMethod string
+	// Headers has a header per line, e.g. "X-Priority: {{ .CommonLabels.severity }}".
+	Headers string
+	// Body is the body of the request, the body of the webhook notifier is sent when it is empty.
+	Body string
+}
+
+// HTTPNotifier is responsible for sending alert notifications as HTTP requests, whose method, URL, headers and
+// body are templates.
+type HTTPNotifier struct {
+	*Base
+	Request         HTTPRequestTemplate
+	ResolveRequest  *HTTPRequestTemplate
+	ContentType     string
+	AuthType        string
+	User            string
+	Password        string
+	BearerToken     string
+	AuthHeaderName  string
+	AuthHeaderValue string
+	log             log.Logger
+	ns              notifications.WebhookSender
+	tmpl            *template.Template
+	textTmpl        *tmpltext.Template
+	orgID           int64
+}
+
+type HTTPConfig struct {
+	*NotificationChannelConfig
+	Request HTTPRequestTemplate
+	// ResolveRequest is the request sent when all the alerts are resolved, it is nil when the same request is sent.
+	ResolveRequest  *HTTPRequestTemplate
+	ContentType     string
+	AuthType        string
+	User            string
+	Password        string
+	BearerToken     string
+	AuthHeaderName  string
+	AuthHeaderValue string
+}
+
+func HTTPFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewHTTPConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewHTTPNotifier(cfg, fc.NotificationService, fc.Template, fc.TextTemplate), nil
+}
+
+func NewHTTPConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*HTTPConfig, error) {
+	request := HTTPRequestTemplate{
+		URL:        config.Settings.Get("url").MustString(),
+		HTTPMethod: config.Settings.Get("httpMethod").MustString(http.MethodPost),
+		Headers:    config.Settings.Get("headers").MustString(),
+		Body:       config.Settings.Get("body").MustString(),
+	}
+	if request.URL == "" {
+		return nil, errors.New("could not find url property in settings")
+	}
+	if err := validateHTTPMethod(request.HTTPMethod); err != nil {
+		return nil, err
+	}
+
+	// the fields of the resolve request that are not set are the same as the request
+	var resolveRequest *HTTPRequestTemplate
+	resolveURL := config.Settings.Get("resolveUrl").MustString()
+	resolveHTTPMethod := config.Settings.Get("resolveHttpMethod").MustString()
+	resolveHeaders := config.Settings.Get("resolveHeaders").MustString()
+	resolveBody := config.Settings.Get("resolveBody").MustString()
+	if resolveURL != "" || resolveHTTPMethod != "" || resolveHeaders != "" || resolveBody != "" {
+		resolveRequest = &HTTPRequestTemplate{
+			URL:        valueOrDefault(resolveURL, request.URL),
+			HTTPMethod: valueOrDefault(resolveHTTPMethod, request.HTTPMethod),
+			Headers:    valueOrDefault(resolveHeaders, request.Headers),
+			Body:       valueOrDefault(resolveBody, request.Body),
+		}
+		if err := validateHTTPMethod(resolveRequest.HTTPMethod); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := &HTTPConfig{
+		NotificationChannelConfig: config,
+		Request:                   request,
+		ResolveRequest:            resolveRequest,
+		ContentType:               config.Settings.Get("contentType").MustString("application/json"),
+		AuthType:                  config.Settings.Get("authType").MustString(httpAuthNone),
+	}
+
+	switch cfg.AuthType {
+	case httpAuthNone:
+	case httpAuthBasic:
+		cfg.User = config.Settings.Get("username").MustString()
+		cfg.Password = decryptFunc(context.Background(), config.SecureSettings, "password", config.Settings.Get("password").MustString())
+		if cfg.User == "" || cfg.Password == "" {
+			return nil, errors.New("basic authentication requires a username and a password")
+		}
+	case httpAuthBearer:
+		cfg.BearerToken = decryptFunc(context.Background(), config.SecureSettings, "bearerToken", config.Settings.Get("bearerToken").MustString())
+		if cfg.BearerToken == "" {
+			return nil, errors.New("bearer authentication requires a token")
+		}
+	case httpAuthHeader:
+		cfg.AuthHeaderName = config.Settings.Get("authHeaderName").MustString("Authorization")
+		cfg.AuthHeaderValue = decryptFunc(context.Background(), config.SecureSettings, "authHeaderValue", config.Settings.Get("authHeaderValue").MustString())
+		if cfg.AuthHeaderValue == "" {
+			return nil, errors.New("header authentication requires the value of the header")
+		}
+	default:
+		return nil, fmt.Errorf("invalid authentication type %q, expected %q, %q, %q or %q", cfg.AuthType, httpAuthNone, httpAuthBasic, httpAuthBearer, httpAuthHeader)
+	}
+
+	return cfg, nil
+}
+
+// NewHTTPNotifier is the constructor for the HTTP notifier. The request is templated with textTmpl, which has the
+// functions that Grafana adds to the templates, see TextTemplateFromGlobs.
+func NewHTTPNotifier(config *HTTPConfig, ns notifications.WebhookSender, t *template.Template, textTmpl *tmpltext.Template) *HTTPNotifier {
+	return &HTTPNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+			SecureSettings:        config.SecureSettings,
+		}),
+		orgID:           config.OrgID,
+		Request:         config.Request,
+		ResolveRequest:  config.ResolveRequest,
+		ContentType:     config.ContentType,
+		AuthType:        config.AuthType,
+		User:            config.User,
+		Password:        config.Password,
+		BearerToken:     config.BearerToken,
+		AuthHeaderName:  config.AuthHeaderName,
+		AuthHeaderValue: config.AuthHeaderValue,
+		log:             log.New("alerting.notifier.http"),
+		ns:              ns,
+		tmpl:            t,
+		textTmpl:        textTmpl,
+	}
+}
+
+// Notify sends the request templated with the alerts.
+func (hn *HTTPNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	request := hn.Request
+	if hn.ResolveRequest != nil && types.Alerts(as...).Status() == model.AlertResolved {
+		request = *hn.ResolveRequest
+	}
+
+	var tmplErr error
+	tmpl, data := TmplText(ctx, hn.tmpl, as, hn.log, &tmplErr)
+	requestTmpl := func(text string) (s string) {
+		if tmplErr != nil {
+			return
+		}
+		s, tmplErr = executeTextString(hn.textTmpl, text, data)
+		return s
+	}
+
+	url := strings.TrimSpace(requestTmpl(request.URL))
+	method := strings.ToUpper(strings.TrimSpace(requestTmpl(request.HTTPMethod)))
+	headers := requestTmpl(request.Headers)
+
+	var body string
+	if request.Body != "" {
+		body = requestTmpl(request.Body)
+	} else {
+		groupKey, err := notify.ExtractGroupKey(ctx)
+		if err != nil {
+			return false, err
+		}
+		msg := &webhookMessage{
+			Version:      "1",
+			ExtendedData: data,
+			GroupKey:     groupKey.String(),
+			OrgID:        hn.orgID,
+			Title:        tmpl(DefaultMessageTitleEmbed),
+			Message:      tmpl(`{{ template "default.message" . }}`),
+		}
+		if types.Alerts(as...).Status() == model.AlertFiring {
+			msg.State = string(models.AlertStateAlerting)
+		} else {
+			msg.State = string(models.AlertStateOK)
+		}
+		b, err := json.Marshal(msg)
+		if err != nil {
+			return false, err
+		}
+		body = string(b)
+	}
+
+	// unlike the message of the other notifiers, a request that failed to template cannot be sent
+	if tmplErr != nil {
+		hn.log.Warn("failed to template HTTP request", "err", tmplErr.Error())
+		return false, fmt.Errorf("failed to template the request: %w", tmplErr)
+	}
+	if url == "" {
+		return false, errors.New("the url is empty after templating")
+	}
+	if err := validateHTTPMethod(method); err != nil {
+		return false, err
+	}
+
+	httpHeaders, err := parseHTTPHeaders(headers)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &models.SendWebhookSync{
+		Url:         url,
+		Body:        body,
+		HttpMethod:  method,
+		HttpHeader:  httpHeaders,
+		ContentType: hn.ContentType,
+	}
+	switch hn.AuthType {
+	case httpAuthBasic:
+		cmd.User = hn.User
+		cmd.Password = hn.Password
+	case httpAuthBearer:
+		httpHeaders["Authorization"] = "Bearer " + hn.BearerToken
+	case httpAuthHeader:
+		httpHeaders[http.CanonicalHeaderKey(hn.AuthHeaderName)] = hn.AuthHeaderValue
+	}
+
+	if err := hn.ns.SendWebhookSync(ctx, cmd); err != nil {
+		hn.log.Error("Failed to send HTTP request", "error", err, "url", url, "method", method)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (hn *HTTPNotifier) SendResolved() bool {
+	return !hn.GetDisableResolveMessage()
+}
+
+// validateHTTPMethod checks the methods supported by the notification service, the methods that are templates are
+// checked once they are rendered.
+func validateHTTPMethod(method string) error {
+	if strings.Contains(method, "{{") {
+		return nil
+	}
+	switch strings.ToUpper(strings.TrimSpace(method)) {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return nil
+	}
+	return fmt.Errorf("invalid HTTP method %q, expected POST, PUT or PATCH", method)
+}
+
+// parseHTTPHeaders parses the headers, one "Name: Value" per line.
+func parseHTTPHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" {
+			return nil, fmt.Errorf("invalid header %q, expected Name: Value", line)
+		}
+		headers[http.CanonicalHeaderKey(name)] = strings.TrimSpace(parts[1])
+	}
+	return headers, nil
+}
+
+func valueOrDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,257 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+func TestHTTPNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+	textTmpl, err := TextTemplateFromGlobs()
+	require.NoError(t, err)
+
+	firing := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1", "severity": "critical"},
+				Annotations: model.LabelSet{"summary": `the "disk" is full`},
+			},
+		},
+	}
+	resolved := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1", "severity": "critical"},
+				Annotations: model.LabelSet{"summary": `the "disk" is full`},
+				StartsAt:    time.Now().Add(-time.Hour),
+				EndsAt:      time.Now().Add(-time.Minute),
+			},
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expWebhook   *models.SendWebhookSync
+		expMsg       *webhookMessage
+		expInitError string
+		expMsgError  string
+	}{
+		{
+			name:     "Default config sends the webhook body",
+			settings: `{"url": "http://localhost/test"}`,
+			alerts:   firing,
+			expWebhook: &models.SendWebhookSync{
+				Url:         "http://localhost/test",
+				HttpMethod:  "POST",
+				HttpHeader:  map[string]string{},
+				ContentType: "application/json",
+			},
+			expMsg: &webhookMessage{
+				ExtendedData: &ExtendedData{
+					Receiver: "my_receiver",
+					Status:   "firing",
+					Alerts: ExtendedAlerts{
+						{
+							Status: "firing",
+							Labels: template.KV{
+								"alertname": "alert1",
+								"severity":  "critical",
+							},
+							Annotations: template.KV{
+								"summary": `the "disk" is full`,
+							},
+							Fingerprint: "0223b772b51c29e1",
+							SilenceURL:  "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=severity%3Dcritical",
+						},
+					},
+					GroupLabels: template.KV{
+						"alertname": "",
+					},
+					CommonLabels: template.KV{
+						"alertname": "alert1",
+						"severity":  "critical",
+					},
+					CommonAnnotations: template.KV{
+						"summary": `the "disk" is full`,
+					},
+					ExternalURL: "http://localhost",
+				},
+				Version:  "1",
+				GroupKey: "alertname",
+				Title:    "[FIRING:1]  (critical)",
+				State:    "alerting",
+				Message:  "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - severity = critical\nAnnotations:\n - summary = the \"disk\" is full\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=severity%3Dcritical\n",
+				OrgID:    1,
+			},
+		}, {
+			name: "Templated request with bearer authentication",
+			settings: `{
+				"url": "http://localhost/alerts/{{ .CommonLabels.alertname }}",
+				"httpMethod": "{{ if eq .CommonLabels.severity \"critical\" }}put{{ else }}post{{ end }}",
+				"headers": "x-severity: {{ .CommonLabels.severity }}\n\nX-Source: grafana",
+				"body": "{\"summary\": {{ .CommonAnnotations.summary | toJson }}, \"status\": {{ .Status | toJson }}}",
+				"contentType": "application/vnd.api+json",
+				"authType": "bearer",
+				"bearerToken": "token"
+			}`,
+			alerts: firing,
+			expWebhook: &models.SendWebhookSync{
+				Url:        "http://localhost/alerts/alert1",
+				HttpMethod: "PUT",
+				HttpHeader: map[string]string{
+					"X-Severity":    "critical",
+					"X-Source":      "grafana",
+					"Authorization": "Bearer token",
+				},
+				ContentType: "application/vnd.api+json",
+				Body:        `{"summary": "the \"disk\" is full", "status": "firing"}`,
+			},
+		}, {
+			name: "Resolve request with basic authentication",
+			settings: `{
+				"url": "http://localhost/alerts",
+				"body": "{{ .Status }}",
+				"resolveUrl": "http://localhost/alerts/{{ .CommonLabels.alertname }}",
+				"resolveHttpMethod": "PATCH",
+				"authType": "basic",
+				"username": "user",
+				"password": "pass"
+			}`,
+			alerts: resolved,
+			expWebhook: &models.SendWebhookSync{
+				Url:         "http://localhost/alerts/alert1",
+				HttpMethod:  "PATCH",
+				HttpHeader:  map[string]string{},
+				ContentType: "application/json",
+				User:        "user",
+				Password:    "pass",
+				Body:        "resolved",
+			},
+		}, {
+			name: "Firing alerts do not use the resolve request",
+			settings: `{
+				"url": "http://localhost/alerts",
+				"body": "{{ .Status }}",
+				"resolveUrl": "http://localhost/alerts/{{ .CommonLabels.alertname }}",
+				"resolveHttpMethod": "PATCH",
+				"authType": "header",
+				"authHeaderName": "x-api-key",
+				"authHeaderValue": "key"
+			}`,
+			alerts: firing,
+			expWebhook: &models.SendWebhookSync{
+				Url:         "http://localhost/alerts",
+				HttpMethod:  "POST",
+				HttpHeader:  map[string]string{"X-Api-Key": "key"},
+				ContentType: "application/json",
+				Body:        "firing",
+			},
+		}, {
+			name:         "URL missing",
+			settings:     `{}`,
+			expInitError: `could not find url property in settings`,
+		}, {
+			name:         "Invalid HTTP method",
+			settings:     `{"url": "http://localhost/test", "resolveHttpMethod": "GET"}`,
+			expInitError: `invalid HTTP method "GET", expected POST, PUT or PATCH`,
+		}, {
+			name:         "Invalid authentication type",
+			settings:     `{"url": "http://localhost/test", "authType": "digest"}`,
+			expInitError: `invalid authentication type "digest", expected "none", "basic", "bearer" or "header"`,
+		}, {
+			name:         "Basic authentication without password",
+			settings:     `{"url": "http://localhost/test", "authType": "basic", "username": "user"}`,
+			expInitError: `basic authentication requires a username and a password`,
+		}, {
+			name:         "Bearer authentication without token",
+			settings:     `{"url": "http://localhost/test", "authType": "bearer"}`,
+			expInitError: `bearer authentication requires a token`,
+		}, {
+			name:         "Header authentication without value",
+			settings:     `{"url": "http://localhost/test", "authType": "header"}`,
+			expInitError: `header authentication requires the value of the header`,
+		}, {
+			name:        "Invalid templated HTTP method",
+			settings:    `{"url": "http://localhost/test", "httpMethod": "{{ \"get\" }}"}`,
+			alerts:      firing,
+			expMsgError: `invalid HTTP method "GET", expected POST, PUT or PATCH`,
+		}, {
+			name:        "Invalid header",
+			settings:    `{"url": "http://localhost/test", "headers": "X-Severity {{ .CommonLabels.severity }}"}`,
+			alerts:      firing,
+			expMsgError: `invalid header "X-Severity critical", expected Name: Value`,
+		}, {
+			name:        "Invalid template",
+			settings:    `{"url": "http://localhost/test", "body": "{{ .Unknown }}"}`,
+			alerts:      firing,
+			expMsgError: `failed to template the request: template: :1:3: executing "" at <.Unknown>: can't evaluate field Unknown in type *channels.ExtendedData`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				OrgID:          1,
+				Name:           "http_testing",
+				Type:           "http",
+				Settings:       settingsJSON,
+				SecureSettings: map[string][]byte{},
+			}
+
+			webhookSender := mockNotificationService()
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewHTTPConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ctx = notify.WithReceiverName(ctx, "my_receiver")
+			hn := NewHTTPNotifier(cfg, webhookSender, tmpl, textTmpl)
+			ok, err := hn.Notify(ctx, c.alerts...)
+			if c.expMsgError != "" {
+				require.False(t, ok)
+				require.Error(t, err)
+				require.Equal(t, c.expMsgError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			if c.expMsg != nil {
+				expBody, err := json.Marshal(c.expMsg)
+				require.NoError(t, err)
+				require.JSONEq(t, string(expBody), webhookSender.Webhook.Body)
+				c.expWebhook.Body = webhookSender.Webhook.Body
+			}
+			require.Equal(t, *c.expWebhook, webhookSender.Webhook)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// ImageStore is the store of the images attached to the notifications of the alerts.
+type ImageStore interface {
+	GetImage(ctx context.Context, token string) (*ngmodels.Image, error)
+}
+
+// ErrImagesUnavailable is returned by the UnavailableImageStore.
+var ErrImagesUnavailable = errors.New("images are unavailable")
+
+// UnavailableImageStore is the image store of the notifiers when the images cannot be retrieved, e.g. when testing.
+type UnavailableImageStore struct{}
+
+func (UnavailableImageStore) GetImage(_ context.Context, _ string) (*ngmodels.Image, error) {
+	return nil, ErrImagesUnavailable
+}
+
+// errImagesDone stops withStoredImages, it is not returned.
+var errImagesDone = errors.New("images done")
+
+// forEachImageFunc is called with the index of the alert in the notification and its image.
+type forEachImageFunc func(index int, image *ngmodels.Image) error
+
+// withStoredImages calls the function for every alert that has an image. The alerts without image, or whose image
+// expired, are skipped. The function returns errImagesDone to stop, other errors are returned.
+func withStoredImages(ctx context.Context, l log.Logger, imageStore ImageStore, forEachFunc forEachImageFunc, alerts ...*types.Alert) error {
+	for i, alert := range alerts {
+		token := string(alert.Annotations[ngmodels.ImageTokenAnnotation])
+		if token == "" {
+			continue
+		}
+		img, err := imageStore.GetImage(ctx, token)
+		if err != nil {
+			if !errors.Is(err, ngmodels.ErrImageNotFound) && !errors.Is(err, ErrImagesUnavailable) {
+				l.Warn("failed to get the image of the alert", "alert", alert.String(), "token", token, "err", err)
+			}
+			continue
+		}
+		if err := forEachFunc(i, img); err != nil {
+			if errors.Is(err, errImagesDone) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// openImage opens the file of the image, the file may have been deleted with the other temporary files.
+func openImage(img *ngmodels.Image) (*os.File, error) {
+	if !img.HasPath() {
+		return nil, ngmodels.ErrImageNotFound
+	}
+	f, err := os.Open(img.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ngmodels.ErrImageNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,91 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// fakeImageStore has the images "test-image-1", which is uploaded, and "test-image-2", which is a file.
+type fakeImageStore struct {
+	images []*ngmodels.Image
+}
+
+func newFakeImageStore(t *testing.T) *fakeImageStore {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test-image-2.png")
+	require.NoError(t, os.WriteFile(path, []byte("image"), 0600))
+	return &fakeImageStore{images: []*ngmodels.Image{
+		{Token: "test-image-1", URL: "https://www.example.com/test-image-1.png"},
+		{Token: "test-image-2", Path: path},
+	}}
+}
+
+func (f *fakeImageStore) GetImage(_ context.Context, token string) (*ngmodels.Image, error) {
+	for _, img := range f.images {
+		if img.Token == token {
+			return img, nil
+		}
+	}
+	return nil, ngmodels.ErrImageNotFound
+}
+
+func TestWithStoredImages(t *testing.T) {
+	images := newFakeImageStore(t)
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Annotations: model.LabelSet{ngmodels.ImageTokenAnnotation: "test-image-1"}}},
+		{Alert: model.Alert{Annotations: model.LabelSet{"ann1": "annv1"}}},
+		{Alert: model.Alert{Annotations: model.LabelSet{ngmodels.ImageTokenAnnotation: "expired"}}},
+		{Alert: model.Alert{Annotations: model.LabelSet{ngmodels.ImageTokenAnnotation: "test-image-2"}}},
+	}
+
+	var indices []int
+	err := withStoredImages(context.Background(), log.New("test"), images, func(index int, image *ngmodels.Image) error {
+		indices = append(indices, index)
+		return nil
+	}, alerts...)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 3}, indices)
+
+	indices = nil
+	err = withStoredImages(context.Background(), log.New("test"), images, func(index int, image *ngmodels.Image) error {
+		indices = append(indices, index)
+		return errImagesDone
+	}, alerts...)
+	require.NoError(t, err)
+	require.Equal(t, []int{0}, indices)
+
+	expErr := errors.New("failed")
+	err = withStoredImages(context.Background(), log.New("test"), images, func(index int, image *ngmodels.Image) error {
+		return expErr
+	}, alerts...)
+	require.ErrorIs(t, err, expErr)
+
+	err = withStoredImages(context.Background(), log.New("test"), &UnavailableImageStore{}, func(index int, image *ngmodels.Image) error {
+		return expErr
+	}, alerts...)
+	require.NoError(t, err)
+}
+
+func TestOpenImage(t *testing.T) {
+	images := newFakeImageStore(t)
+
+	_, err := openImage(images.images[0])
+	require.ErrorIs(t, err, ngmodels.ErrImageNotFound)
+
+	f, err := openImage(images.images[1])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = openImage(&ngmodels.Image{Path: filepath.Join(t.TempDir(), "deleted.png")})
+	require.ErrorIs(t, err, ngmodels.ErrImageNotFound)
+}
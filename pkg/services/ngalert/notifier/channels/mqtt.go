@@ -0,0 +1,278 @@
+package channels
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/components/mqtt"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	mqttMessageFormatJSON = "json"
+	mqttMessageFormatText = "text"
+
+	// mqttClientIDMaxLength is the length of the client IDs that MQTT 3.1.1 brokers must accept.
+	mqttClientIDMaxLength    = 23
+	mqttClientIDSuffixLength = 8
+)
+
+// mqttClient is the connection to the broker, it is replaced in tests.
+type mqttClient interface {
+	Publish(ctx context.Context, topic string, payload []byte, qos mqtt.QoS, retain bool) error
+	Close() error
+}
+
+var connectMQTT = func(ctx context.Context, opts mqtt.Options) (mqttClient, error) {
+	return mqtt.Connect(ctx, opts)
+}
+
+// MQTTNotifier is responsible for sending
+// alert notifications to an MQTT broker.
+type MQTTNotifier struct {
+	*Base
+	BrokerURL     string
+	ClientID      string
+	Topic         string
+	MessageFormat string
+	Message       string
+	QoS           mqtt.QoS
+	Retain        bool
+	Username      string
+	Password      string
+	TLSConfig     *tls.Config
+	orgID         int64
+	log           log.Logger
+	tmpl          *template.Template
+}
+
+type MQTTConfig struct {
+	*NotificationChannelConfig
+	BrokerURL     string
+	ClientID      string
+	Topic         string
+	MessageFormat string
+	Message       string
+	QoS           mqtt.QoS
+	Retain        bool
+	Username      string
+	Password      string
+	TLSConfig     *tls.Config
+}
+
+func MQTTFactory(fc FactoryConfig) (NotificationChannel, error) {
+	cfg, err := NewMQTTConfig(fc.Config, fc.DecryptFunc)
+	if err != nil {
+		return nil, receiverInitError{
+			Reason: err.Error(),
+			Cfg:    *fc.Config,
+		}
+	}
+	return NewMQTTNotifier(cfg, fc.Template), nil
+}
+
+func NewMQTTConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*MQTTConfig, error) {
+	brokerURL := config.Settings.Get("brokerUrl").MustString()
+	if brokerURL == "" {
+		return nil, errors.New("could not find broker URL property in settings")
+	}
+	topic := config.Settings.Get("topic").MustString()
+	if topic == "" {
+		return nil, errors.New("could not find topic property in settings")
+	}
+
+	messageFormat := config.Settings.Get("messageFormat").MustString(mqttMessageFormatJSON)
+	if messageFormat != mqttMessageFormatJSON && messageFormat != mqttMessageFormatText {
+		return nil, fmt.Errorf("invalid message format %q, expected %q or %q", messageFormat, mqttMessageFormatJSON, mqttMessageFormatText)
+	}
+
+	// the QoS is a string when it is set from the select of the UI
+	qos, err := config.Settings.Get("qos").Int()
+	if err != nil {
+		qos, err = strconv.Atoi(config.Settings.Get("qos").MustString("0"))
+		if err != nil {
+			return nil, mqtt.ErrInvalidQoS
+		}
+	}
+	if qos < 0 || qos > int(mqtt.ExactlyOnce) {
+		return nil, mqtt.ErrInvalidQoS
+	}
+
+	tlsConfig, err := newMQTTTLSConfig(config, decryptFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MQTTConfig{
+		NotificationChannelConfig: config,
+		BrokerURL:                 brokerURL,
+		ClientID:                  config.Settings.Get("clientId").MustString(),
+		Topic:                     topic,
+		MessageFormat:             messageFormat,
+		Message:                   config.Settings.Get("message").MustString(`{{ template "default.message" . }}`),
+		QoS:                       mqtt.QoS(qos),
+		Retain:                    config.Settings.Get("retain").MustBool(false),
+		Username:                  decryptFunc(context.Background(), config.SecureSettings, "username", config.Settings.Get("username").MustString()),
+		Password:                  decryptFunc(context.Background(), config.SecureSettings, "password", config.Settings.Get("password").MustString()),
+		TLSConfig:                 tlsConfig,
+	}, nil
+}
+
+// newMQTTTLSConfig returns the configuration of the TLS connections to the broker, the client certificate
+// and its key are secure settings.
+func newMQTTTLSConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.Settings.Get("insecureSkipVerify").MustBool(false),
+	}
+
+	if caCert := config.Settings.Get("tlsCACertificate").MustString(); caCert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caCert)) {
+			return nil, errors.New("failed to parse the CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	clientCert := decryptFunc(context.Background(), config.SecureSettings, "tlsClientCertificate", config.Settings.Get("tlsClientCertificate").MustString())
+	clientKey := decryptFunc(context.Background(), config.SecureSettings, "tlsClientKey", config.Settings.Get("tlsClientKey").MustString())
+	if clientCert != "" || clientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// NewMQTTNotifier is the constructor for the MQTT notifier.
+func NewMQTTNotifier(config *MQTTConfig, t *template.Template) *MQTTNotifier {
+	return &MQTTNotifier{
+		Base: NewBase(&models.AlertNotification{
+			Uid:                   config.UID,
+			Name:                  config.Name,
+			Type:                  config.Type,
+			DisableResolveMessage: config.DisableResolveMessage,
+			Settings:              config.Settings,
+			SecureSettings:        config.SecureSettings,
+		}),
+		orgID:         config.OrgID,
+		BrokerURL:     config.BrokerURL,
+		ClientID:      config.ClientID,
+		Topic:         config.Topic,
+		MessageFormat: config.MessageFormat,
+		Message:       config.Message,
+		QoS:           config.QoS,
+		Retain:        config.Retain,
+		Username:      config.Username,
+		Password:      config.Password,
+		TLSConfig:     config.TLSConfig,
+		log:           log.New("alerting.notifier.mqtt"),
+		tmpl:          t,
+	}
+}
+
+// Notify publishes the alert notification to the topic.
+func (mn *MQTTNotifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	var tmplErr error
+	tmpl, data := TmplText(ctx, mn.tmpl, as, mn.log, &tmplErr)
+
+	topic := tmpl(mn.Topic)
+	var payload []byte
+	switch mn.MessageFormat {
+	case mqttMessageFormatText:
+		payload = []byte(tmpl(mn.Message))
+	default:
+		// the JSON message is the same as the message of the webhooks
+		groupKey, err := notify.ExtractGroupKey(ctx)
+		if err != nil {
+			return false, err
+		}
+		msg := &webhookMessage{
+			Version:      "1",
+			ExtendedData: data,
+			GroupKey:     groupKey.String(),
+			OrgID:        mn.orgID,
+			Title:        tmpl(DefaultMessageTitleEmbed),
+			Message:      tmpl(mn.Message),
+		}
+		if types.Alerts(as...).Status() == model.AlertFiring {
+			msg.State = string(models.AlertStateAlerting)
+		} else {
+			msg.State = string(models.AlertStateOK)
+		}
+		payload, err = json.Marshal(msg)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if tmplErr != nil {
+		mn.log.Warn("failed to template MQTT message", "err", tmplErr.Error())
+	}
+	if topic == "" {
+		return false, errors.New("the topic is empty after templating")
+	}
+
+	clientID, err := mqttClientID(mn.ClientID)
+	if err != nil {
+		return false, err
+	}
+	client, err := connectMQTT(ctx, mqtt.Options{
+		BrokerURL: mn.BrokerURL,
+		ClientID:  clientID,
+		Username:  mn.Username,
+		Password:  mn.Password,
+		TLSConfig: mn.TLSConfig,
+	})
+	if err != nil {
+		mn.log.Error("Failed to connect to the MQTT broker", "error", err, "broker", mn.BrokerURL)
+		return false, err
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			mn.log.Warn("failed to disconnect from the MQTT broker", "err", err, "broker", mn.BrokerURL)
+		}
+	}()
+
+	if err := client.Publish(ctx, topic, payload, mn.QoS, mn.Retain); err != nil {
+		mn.log.Error("Failed to publish the MQTT message", "error", err, "broker", mn.BrokerURL, "topic", topic)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// mqttClientID returns the client ID of a connection. The notifications of the integration are sent concurrently and
+// a broker disconnects the client that is connected with the same client ID, so every connection gets a random suffix.
+// The configured client ID is truncated so that the result fits in the length that every broker accepts.
+func mqttClientID(base string) (string, error) {
+	if base == "" {
+		return "", nil
+	}
+	suffix, err := util.GetRandomString(mqttClientIDSuffixLength)
+	if err != nil {
+		return "", err
+	}
+	if maxBase := mqttClientIDMaxLength - mqttClientIDSuffixLength - 1; len(base) > maxBase {
+		base = base[:maxBase]
+	}
+	return base + "-" + suffix, nil
+}
+
+func (mn *MQTTNotifier) SendResolved() bool {
+	return !mn.GetDisableResolveMessage()
+}
@@ -0,0 +1,298 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/mqtt"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+)
+
+type fakeMQTTClient struct {
+	opts    mqtt.Options
+	topic   string
+	payload string
+	qos     mqtt.QoS
+	retain  bool
+	closed  bool
+}
+
+func (c *fakeMQTTClient) Publish(_ context.Context, topic string, payload []byte, qos mqtt.QoS, retain bool) error {
+	c.topic, c.payload, c.qos, c.retain = topic, string(payload), qos, retain
+	return nil
+}
+
+func (c *fakeMQTTClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestMQTTNotifier(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+				Annotations: model.LabelSet{"ann1": "annv1", "__dashboardUid__": "abcd", "__panelId__": "efgh"},
+			},
+		},
+	}
+
+	cases := []struct {
+		name         string
+		settings     string
+		alerts       []*types.Alert
+		expOpts      mqtt.Options
+		expTopic     string
+		expMsg       interface{}
+		expQoS       mqtt.QoS
+		expRetain    bool
+		expInitError string
+	}{
+		{
+			name: "Default config with one alert",
+			settings: `{
+				"brokerUrl": "tcp://localhost:1883",
+				"topic": "grafana/alerts"
+			}`,
+			alerts:   alerts,
+			expOpts:  mqtt.Options{BrokerURL: "tcp://localhost:1883"},
+			expTopic: "grafana/alerts",
+			expMsg: &webhookMessage{
+				ExtendedData: &ExtendedData{
+					Receiver: "my_receiver",
+					Status:   "firing",
+					Alerts: ExtendedAlerts{
+						{
+							Status: "firing",
+							Labels: template.KV{
+								"alertname": "alert1",
+								"lbl1":      "val1",
+							},
+							Annotations: template.KV{
+								"ann1": "annv1",
+							},
+							Fingerprint:  "fac0861a85de433a",
+							DashboardURL: "http://localhost/d/abcd",
+							PanelURL:     "http://localhost/d/abcd?viewPanel=efgh",
+							SilenceURL:   "http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1",
+						},
+					},
+					GroupLabels: template.KV{
+						"alertname": "",
+					},
+					CommonLabels: template.KV{
+						"alertname": "alert1",
+						"lbl1":      "val1",
+					},
+					CommonAnnotations: template.KV{
+						"ann1": "annv1",
+					},
+					ExternalURL: "http://localhost",
+				},
+				Version:  "1",
+				GroupKey: "alertname",
+				Title:    "[FIRING:1]  (val1)",
+				State:    "alerting",
+				Message:  "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1\nDashboard: http://localhost/d/abcd\nPanel: http://localhost/d/abcd?viewPanel=efgh\n",
+				OrgID:    1,
+			},
+		}, {
+			name: "Custom config with text message",
+			settings: `{
+				"brokerUrl": "ssl://localhost:8883",
+				"clientId": "grafana-alerts",
+				"topic": "grafana/{{ .CommonLabels.alertname }}",
+				"messageFormat": "text",
+				"message": "{{ len .Alerts.Firing }} alerts are firing",
+				"qos": "2",
+				"retain": true,
+				"username": "user",
+				"password": "pass"
+			}`,
+			alerts: alerts,
+			expOpts: mqtt.Options{
+				BrokerURL: "ssl://localhost:8883",
+				ClientID:  "grafana-alerts",
+				Username:  "user",
+				Password:  "pass",
+			},
+			expTopic:  "grafana/alert1",
+			expMsg:    "1 alerts are firing",
+			expQoS:    mqtt.ExactlyOnce,
+			expRetain: true,
+		}, {
+			name:         "Broker URL missing",
+			settings:     `{"topic": "grafana/alerts"}`,
+			expInitError: `could not find broker URL property in settings`,
+		}, {
+			name:         "Topic missing",
+			settings:     `{"brokerUrl": "tcp://localhost:1883"}`,
+			expInitError: `could not find topic property in settings`,
+		}, {
+			name:         "Invalid message format",
+			settings:     `{"brokerUrl": "tcp://localhost:1883", "topic": "grafana/alerts", "messageFormat": "xml"}`,
+			expInitError: `invalid message format "xml", expected "json" or "text"`,
+		}, {
+			name:         "Invalid QoS",
+			settings:     `{"brokerUrl": "tcp://localhost:1883", "topic": "grafana/alerts", "qos": 3}`,
+			expInitError: mqtt.ErrInvalidQoS.Error(),
+		}, {
+			name:         "Invalid client certificate",
+			settings:     `{"brokerUrl": "ssl://localhost:8883", "topic": "grafana/alerts", "tlsClientCertificate": "cert", "tlsClientKey": "key"}`,
+			expInitError: `failed to load the client certificate: tls: failed to find any PEM data in certificate input`,
+		}, {
+			name:         "Invalid CA certificate",
+			settings:     `{"brokerUrl": "ssl://localhost:8883", "topic": "grafana/alerts", "tlsCACertificate": "ca"}`,
+			expInitError: `failed to parse the CA certificate`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settingsJSON, err := simplejson.NewJson([]byte(c.settings))
+			require.NoError(t, err)
+
+			m := &NotificationChannelConfig{
+				OrgID:          1,
+				Name:           "mqtt_testing",
+				Type:           "mqtt",
+				Settings:       settingsJSON,
+				SecureSettings: map[string][]byte{},
+			}
+
+			secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+			cfg, err := NewMQTTConfig(m, secretsService.GetDecryptedValue)
+			if c.expInitError != "" {
+				require.Error(t, err)
+				require.Equal(t, c.expInitError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			client := &fakeMQTTClient{}
+			origConnectMQTT := connectMQTT
+			connectMQTT = func(_ context.Context, opts mqtt.Options) (mqttClient, error) {
+				client.opts = opts
+				return client, nil
+			}
+			t.Cleanup(func() {
+				connectMQTT = origConnectMQTT
+			})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ctx = notify.WithReceiverName(ctx, "my_receiver")
+			mn := NewMQTTNotifier(cfg, tmpl)
+			ok, err := mn.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.NotNil(t, client.opts.TLSConfig)
+			client.opts.TLSConfig = nil
+			if c.expOpts.ClientID != "" {
+				require.True(t, strings.HasPrefix(client.opts.ClientID, c.expOpts.ClientID+"-"), client.opts.ClientID)
+				client.opts.ClientID = c.expOpts.ClientID
+			}
+			require.Equal(t, c.expOpts, client.opts)
+			require.Equal(t, c.expTopic, client.topic)
+			require.Equal(t, c.expQoS, client.qos)
+			require.Equal(t, c.expRetain, client.retain)
+			require.True(t, client.closed)
+
+			if msg, ok := c.expMsg.(string); ok {
+				require.Equal(t, msg, client.payload)
+			} else {
+				expBody, err := json.Marshal(c.expMsg)
+				require.NoError(t, err)
+				require.JSONEq(t, string(expBody), client.payload)
+			}
+		})
+	}
+
+	t.Run("connects with a different client ID every time", func(t *testing.T) {
+		settingsJSON, err := simplejson.NewJson([]byte(`{"brokerUrl": "tcp://localhost:1883", "clientId": "grafana-alerts", "topic": "grafana/alerts"}`))
+		require.NoError(t, err)
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		cfg, err := NewMQTTConfig(&NotificationChannelConfig{
+			Name:           "mqtt_testing",
+			Type:           "mqtt",
+			Settings:       settingsJSON,
+			SecureSettings: map[string][]byte{},
+		}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		var clientIDs []string
+		origConnectMQTT := connectMQTT
+		connectMQTT = func(_ context.Context, opts mqtt.Options) (mqttClient, error) {
+			clientIDs = append(clientIDs, opts.ClientID)
+			return &fakeMQTTClient{}, nil
+		}
+		t.Cleanup(func() {
+			connectMQTT = origConnectMQTT
+		})
+
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		mn := NewMQTTNotifier(cfg, tmpl)
+		for i := 0; i < 2; i++ {
+			_, err := mn.Notify(ctx, alerts...)
+			require.NoError(t, err)
+		}
+		require.Len(t, clientIDs, 2)
+		require.NotEqual(t, clientIDs[0], clientIDs[1])
+	})
+
+	t.Run("truncates the client ID to the length every broker accepts", func(t *testing.T) {
+		clientID, err := mqttClientID("grafana-alerts-of-the-production-cluster")
+		require.NoError(t, err)
+		require.Len(t, clientID, mqttClientIDMaxLength)
+		require.True(t, strings.HasPrefix(clientID, "grafana-alerts-"), clientID)
+
+		clientID, err = mqttClientID("")
+		require.NoError(t, err)
+		require.Empty(t, clientID)
+	})
+
+	t.Run("returns the error of the connection", func(t *testing.T) {
+		settingsJSON, err := simplejson.NewJson([]byte(`{"brokerUrl": "tcp://localhost:1883", "topic": "grafana/alerts"}`))
+		require.NoError(t, err)
+		secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+		cfg, err := NewMQTTConfig(&NotificationChannelConfig{
+			Name:           "mqtt_testing",
+			Type:           "mqtt",
+			Settings:       settingsJSON,
+			SecureSettings: map[string][]byte{},
+		}, secretsService.GetDecryptedValue)
+		require.NoError(t, err)
+
+		expErr := mqtt.ConnectError{Code: 4}
+		origConnectMQTT := connectMQTT
+		connectMQTT = func(_ context.Context, _ mqtt.Options) (mqttClient, error) {
+			return nil, expErr
+		}
+		t.Cleanup(func() {
+			connectMQTT = origConnectMQTT
+		})
+
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		ok, err := NewMQTTNotifier(cfg, tmpl).Notify(ctx, alerts...)
+		require.False(t, ok)
+		require.ErrorIs(t, err, expErr)
+	})
+}
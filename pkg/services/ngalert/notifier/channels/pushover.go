@@ -5,11 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"path/filepath"
 	"strconv"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
@@ -38,6 +41,7 @@ type PushoverNotifier struct {
 	tmpl             *template.Template
 	log              log.Logger
 	ns               notifications.WebhookSender
+	images           ImageStore
 }
 
 type PushoverConfig struct {
@@ -63,7 +67,7 @@ func PushoverFactory(fc FactoryConfig) (NotificationChannel, error) {
 			Cfg:    *fc.Config,
 		}
 	}
-	return NewPushoverNotifier(cfg, fc.NotificationService, fc.Template), nil
+	return NewPushoverNotifier(cfg, fc.NotificationService, fc.ImageStore, fc.Template), nil
 }
 
 func NewPushoverConfig(config *NotificationChannelConfig, decryptFunc GetDecryptedValueFn) (*PushoverConfig, error) {
@@ -102,7 +106,7 @@ func NewPushoverConfig(config *NotificationChannelConfig, decryptFunc GetDecrypt
 }
 
 // NewSlackNotifier is the constructor for the Slack notifier
-func NewPushoverNotifier(config *PushoverConfig, ns notifications.WebhookSender, t *template.Template) *PushoverNotifier {
+func NewPushoverNotifier(config *PushoverConfig, ns notifications.WebhookSender, images ImageStore, t *template.Template) *PushoverNotifier {
 	return &PushoverNotifier{
 		Base: NewBase(&models.AlertNotification{
 			Uid:                   config.UID,
@@ -126,6 +130,7 @@ func NewPushoverNotifier(config *PushoverConfig, ns notifications.WebhookSender,
 		tmpl:             t,
 		log:              log.New("alerting.notifier.pushover"),
 		ns:               ns,
+		images:           images,
 	}
 }
 
@@ -260,6 +265,13 @@ func (pn *PushoverNotifier) genPushoverBody(ctx context.Context, as ...*types.Al
 	if err != nil {
 		return nil, b, err
 	}
+	// Pushover supports a single attachment, the first image that is available is attached
+	if pn.Upload {
+		if err := pn.attachImage(ctx, w, as...); err != nil {
+			return nil, b, err
+		}
+	}
+
 	if err := w.Close(); err != nil {
 		return nil, b, err
 	}
@@ -270,3 +282,30 @@ func (pn *PushoverNotifier) genPushoverBody(ctx context.Context, as ...*types.Al
 
 	return headers, b, nil
 }
+
+func (pn *PushoverNotifier) attachImage(ctx context.Context, w *multipart.Writer, as ...*types.Alert) error {
+	return withStoredImages(ctx, pn.log, pn.images,
+		func(_ int, image *ngmodels.Image) error {
+			f, err := openImage(image)
+			if err != nil {
+				if errors.Is(err, ngmodels.ErrImageNotFound) {
+					return nil
+				}
+				return err
+			}
+			defer func() {
+				if err := f.Close(); err != nil {
+					pn.log.Warn("failed to close the image", "err", err)
+				}
+			}()
+
+			fw, err := w.CreateFormFile("attachment", filepath.Base(image.Path))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(fw, f); err != nil {
+				return err
+			}
+			return errImagesDone
+		}, as...)
+}
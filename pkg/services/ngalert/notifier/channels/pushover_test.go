@@ -107,6 +107,34 @@ func TestPushoverNotifier(t *testing.T) {
 			},
 			expMsgError: nil,
 		},
+		{
+			name: "Correct config with an image",
+			settings: `{
+				"userKey": "<userKey>",
+				"apiToken": "<apiToken>"
+			}`,
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1", "__alertImageToken__": "test-image-2"},
+					},
+				},
+			},
+			expMsg: map[string]string{
+				"user":       "<userKey>",
+				"token":      "<apiToken>",
+				"priority":   "0",
+				"sound":      "",
+				"title":      "[FIRING:1]  (val1)",
+				"url":        "http://localhost/alerting/list",
+				"url_title":  "Show alert rule",
+				"message":    "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1\n",
+				"html":       "1",
+				"attachment": "image",
+			},
+			expMsgError: nil,
+		},
 		{
 			name: "Missing user key",
 			settings: `{
@@ -157,7 +185,7 @@ func TestPushoverNotifier(t *testing.T) {
 
 			ctx := notify.WithGroupKey(context.Background(), "alertname")
 			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
-			pn := NewPushoverNotifier(cfg, webhookSender, tmpl)
+			pn := NewPushoverNotifier(cfg, webhookSender, newFakeImageStore(t), tmpl)
 			ok, err := pn.Notify(ctx, c.alerts...)
 			if c.expMsgError != nil {
 				require.Error(t, err)
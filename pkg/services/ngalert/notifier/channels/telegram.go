@@ -5,17 +5,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"path/filepath"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 )
 
 var (
-	TelegramAPIURL = "https://api.telegram.org/bot%s/sendMessage"
+	TelegramAPIURL      = "https://api.telegram.org/bot%s/sendMessage"
+	TelegramPhotoAPIURL = "https://api.telegram.org/bot%s/sendPhoto"
 )
 
 // TelegramNotifier is responsible for sending
@@ -27,6 +31,7 @@ type TelegramNotifier struct {
 	Message  string
 	log      log.Logger
 	ns       notifications.WebhookSender
+	images   ImageStore
 	tmpl     *template.Template
 }
 
@@ -45,7 +50,7 @@ func TelegramFactory(fc FactoryConfig) (NotificationChannel, error) {
 			Cfg:    *fc.Config,
 		}
 	}
-	return NewTelegramNotifier(config, fc.NotificationService, fc.Template), nil
+	return NewTelegramNotifier(config, fc.NotificationService, fc.ImageStore, fc.Template), nil
 }
 
 func NewTelegramConfig(config *NotificationChannelConfig, fn GetDecryptedValueFn) (*TelegramConfig, error) {
@@ -66,7 +71,7 @@ func NewTelegramConfig(config *NotificationChannelConfig, fn GetDecryptedValueFn
 }
 
 // NewTelegramNotifier is the constructor for the Telegram notifier
-func NewTelegramNotifier(config *TelegramConfig, ns notifications.WebhookSender, t *template.Template) *TelegramNotifier {
+func NewTelegramNotifier(config *TelegramConfig, ns notifications.WebhookSender, images ImageStore, t *template.Template) *TelegramNotifier {
 	return &TelegramNotifier{
 		Base: NewBase(&models.AlertNotification{
 			Uid:                   config.UID,
@@ -81,6 +86,7 @@ func NewTelegramNotifier(config *TelegramConfig, ns notifications.WebhookSender,
 		tmpl:     t,
 		log:      log.New("alerting.notifier.telegram"),
 		ns:       ns,
+		images:   images,
 	}
 }
 
@@ -133,9 +139,74 @@ func (tn *TelegramNotifier) Notify(ctx context.Context, as ...*types.Alert) (boo
 		return false, err
 	}
 
+	// the message is delivered, it is not sent again when the image cannot be.
+	if err := tn.sendImage(ctx, msg["chat_id"], as...); err != nil {
+		tn.log.Warn("Failed to send the image", "error", err, "webhook", tn.Name)
+	}
+
 	return true, nil
 }
 
+// sendImage sends the first image of the alerts as a photo after the message.
+func (tn *TelegramNotifier) sendImage(ctx context.Context, chatID string, as ...*types.Alert) error {
+	return withStoredImages(ctx, tn.log, tn.images,
+		func(_ int, image *ngmodels.Image) error {
+			var body bytes.Buffer
+			w := multipart.NewWriter(&body)
+			boundary := GetBoundary()
+			if boundary != "" {
+				if err := w.SetBoundary(boundary); err != nil {
+					return err
+				}
+			}
+			if err := writeField(w, "chat_id", chatID); err != nil {
+				return err
+			}
+
+			if image.HasURL() {
+				if err := writeField(w, "photo", image.URL); err != nil {
+					return err
+				}
+			} else {
+				f, err := openImage(image)
+				if err != nil {
+					if errors.Is(err, ngmodels.ErrImageNotFound) {
+						return nil
+					}
+					return err
+				}
+				defer func() {
+					if err := f.Close(); err != nil {
+						tn.log.Warn("Failed to close the image", "err", err)
+					}
+				}()
+				fw, err := w.CreateFormFile("photo", filepath.Base(image.Path))
+				if err != nil {
+					return err
+				}
+				if _, err := io.Copy(fw, f); err != nil {
+					return err
+				}
+			}
+			if err := w.Close(); err != nil {
+				return err
+			}
+
+			cmd := &models.SendWebhookSync{
+				Url:        fmt.Sprintf(TelegramPhotoAPIURL, tn.BotToken),
+				Body:       body.String(),
+				HttpMethod: "POST",
+				HttpHeader: map[string]string{
+					"Content-Type": w.FormDataContentType(),
+				},
+			}
+			if err := tn.ns.SendWebhookSync(ctx, cmd); err != nil {
+				return err
+			}
+			return errImagesDone
+		}, as...)
+}
+
 func (tn *TelegramNotifier) buildTelegramMessage(ctx context.Context, as []*types.Alert) (map[string]string, error) {
 	var tmplErr error
 	tmpl, _ := TmplText(ctx, tn.tmpl, as, tn.log, &tmplErr)
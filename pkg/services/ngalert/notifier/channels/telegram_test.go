@@ -2,10 +2,14 @@ package channels
 
 import (
 	"context"
+	"io"
+	"mime/multipart"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/secrets/fakes"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
 
@@ -110,7 +114,7 @@ func TestTelegramNotifier(t *testing.T) {
 			require.NoError(t, err)
 			ctx := notify.WithGroupKey(context.Background(), "alertname")
 			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
-			pn := NewTelegramNotifier(cfg, webhookSender, tmpl)
+			pn := NewTelegramNotifier(cfg, webhookSender, &UnavailableImageStore{}, tmpl)
 			msg, err := pn.buildTelegramMessage(ctx, c.alerts)
 			if c.expMsgError != nil {
 				require.Error(t, err)
@@ -123,3 +127,97 @@ func TestTelegramNotifier(t *testing.T) {
 		})
 	}
 }
+
+type recordingWebhookSender struct {
+	webhooks []models.SendWebhookSync
+}
+
+func (s *recordingWebhookSender) SendWebhookSync(_ context.Context, cmd *models.SendWebhookSync) error {
+	s.webhooks = append(s.webhooks, *cmd)
+	return nil
+}
+
+func TestTelegramNotifier_SendsImage(t *testing.T) {
+	tmpl := templateForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	origGetBoundary := GetBoundary
+	GetBoundary = func() string { return "abcd" }
+	t.Cleanup(func() { GetBoundary = origGetBoundary })
+
+	settingsJSON, err := simplejson.NewJson([]byte(`{"bottoken": "abcdefgh0123456789", "chatid": "someid"}`))
+	require.NoError(t, err)
+	secretsService := secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore())
+	cfg, err := NewTelegramConfig(&NotificationChannelConfig{
+		Name:           "telegram_testing",
+		Type:           "telegram",
+		Settings:       settingsJSON,
+		SecureSettings: map[string][]byte{},
+	}, secretsService.GetDecryptedValue)
+	require.NoError(t, err)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	sender := &recordingWebhookSender{}
+	tn := NewTelegramNotifier(cfg, sender, newFakeImageStore(t), tmpl)
+
+	readPhoto := func(t *testing.T, cmd models.SendWebhookSync) map[string]string {
+		t.Helper()
+		require.Equal(t, "https://api.telegram.org/botabcdefgh0123456789/sendPhoto", cmd.Url)
+		fields := map[string]string{}
+		r := multipart.NewReader(strings.NewReader(cmd.Body), "abcd")
+		for {
+			part, err := r.NextPart()
+			if err == io.EOF {
+				return fields
+			}
+			require.NoError(t, err)
+			b, err := io.ReadAll(part)
+			require.NoError(t, err)
+			fields[part.FormName()] = string(b)
+		}
+	}
+
+	t.Run("sends the uploaded image by URL", func(t *testing.T) {
+		sender.webhooks = nil
+		ok, err := tn.Notify(ctx, &types.Alert{Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{"__alertImageToken__": "test-image-1"},
+		}})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sender.webhooks, 2)
+		require.Equal(t, map[string]string{
+			"chat_id": "someid",
+			"photo":   "https://www.example.com/test-image-1.png",
+		}, readPhoto(t, sender.webhooks[1]))
+	})
+
+	t.Run("sends the image file", func(t *testing.T) {
+		sender.webhooks = nil
+		ok, err := tn.Notify(ctx, &types.Alert{Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{"__alertImageToken__": "test-image-2"},
+		}})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sender.webhooks, 2)
+		require.Equal(t, map[string]string{
+			"chat_id": "someid",
+			"photo":   "image",
+		}, readPhoto(t, sender.webhooks[1]))
+	})
+
+	t.Run("does not send a photo without image", func(t *testing.T) {
+		sender.webhooks = nil
+		ok, err := tn.Notify(ctx, &types.Alert{Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert1"},
+		}})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sender.webhooks, 1)
+	})
+}
@@ -1,13 +1,19 @@
 package channels
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"io/ioutil"
 	"net/url"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
+	tmpltext "text/template"
 	"time"
 
+	"github.com/prometheus/alertmanager/asset"
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
@@ -17,6 +23,78 @@ import (
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 )
 
+// templateFuncs are the functions that Grafana adds to the functions of the Alertmanager in the requests of the HTTP notifier.
+var templateFuncs = tmpltext.FuncMap{
+	"toJson": toJSON,
+}
+
+// TextTemplateFromGlobs parses the text templates like template.FromGlobs, with a copy of the functions of the
+// Alertmanager to which the functions of Grafana are added, so the functions of the Alertmanager are not changed.
+func TextTemplateFromGlobs(paths ...string) (*tmpltext.Template, error) {
+	funcs := make(tmpltext.FuncMap, len(template.DefaultFuncs)+len(templateFuncs))
+	for name, fn := range template.DefaultFuncs {
+		funcs[name] = fn
+	}
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	t := tmpltext.New("").Option("missingkey=zero").Funcs(funcs)
+
+	f, err := asset.Assets.Open("/templates/default.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if t, err = t.Parse(string(b)); err != nil {
+		return nil, err
+	}
+
+	for _, tp := range paths {
+		// like the Alertmanager, the globs that do not match any file are allowed
+		p, err := filepath.Glob(tp)
+		if err != nil {
+			return nil, err
+		}
+		if len(p) > 0 {
+			if t, err = t.ParseGlob(tp); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
+
+// executeTextString executes the text with the templates, like template.Template.ExecuteTextString.
+func executeTextString(t *tmpltext.Template, text string, data interface{}) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := t.Clone()
+	if err != nil {
+		return "", err
+	}
+	tmpl, err = tmpl.New("").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	return buf.String(), err
+}
+
+// toJSON returns the JSON encoding of the value, e.g. to write a string quoted and escaped in a JSON body.
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
 type ExtendedAlert struct {
 	Status       string      `json:"status"`
 	Labels       template.KV `json:"labels"`
@@ -29,6 +107,9 @@ type ExtendedAlert struct {
 	DashboardURL string      `json:"dashboardURL"`
 	PanelURL     string      `json:"panelURL"`
 	ValueString  string      `json:"valueString"`
+	// ImageURL and EmbeddedImage are the image of the alert, set by the notifiers which attach it.
+	ImageURL      string `json:"imageURL,omitempty"`
+	EmbeddedImage string `json:"-"`
 }
 
 type ExtendedAlerts []ExtendedAlert
@@ -0,0 +1,106 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	gokitlog "github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+var timeNow = time.Now
+
+var (
+	// deliveryErrorURL matches the URLs in the errors of the integrations, they may contain webhook tokens or credentials.
+	deliveryErrorURL = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"']+`)
+	// deliveryErrorSecret matches the secrets that are passed as parameters, e.g. token=... or api_key: ...
+	deliveryErrorSecret = regexp.MustCompile(`(?i)((?:token|api_?key|secret|password)\s*[=:]\s*)[^\s&"',]+`)
+)
+
+// sanitizeDeliveryError returns the message of the error of a delivery without the URLs and secrets it may contain.
+// The status code of a failed webhook request is stored separately.
+func sanitizeDeliveryError(err error) string {
+	msg := deliveryErrorURL.ReplaceAllString(err.Error(), "[redacted url]")
+	return deliveryErrorSecret.ReplaceAllString(msg, "${1}[redacted]")
+}
+
+// DeliveryRecorder records the attempts to deliver notifications through the integrations of the contact points.
+// RecordDelivery is called by the notification pipeline, it must not block on I/O.
+type DeliveryRecorder interface {
+	RecordDelivery(ctx context.Context, d *ngmodels.NotificationDelivery) error
+}
+
+type deliveryAttemptsKey struct{}
+
+// withDeliveryAttempts counts the attempts to deliver a notification. The retry stage notifies
+// the integration with the same context on every attempt.
+func withDeliveryAttempts(ctx context.Context) context.Context {
+	attempts := 0
+	return context.WithValue(ctx, deliveryAttemptsKey{}, &attempts)
+}
+
+// nextDeliveryAttempt returns the number of the attempt that starts.
+func nextDeliveryAttempt(ctx context.Context) int {
+	attempts, ok := ctx.Value(deliveryAttemptsKey{}).(*int)
+	if !ok {
+		return 1
+	}
+	*attempts++
+	return *attempts
+}
+
+// deliveryAttemptsStage adds the counter of the delivery attempts to the context of the following stages.
+func deliveryAttemptsStage(ctx context.Context, _ gokitlog.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	return withDeliveryAttempts(ctx), alerts, nil
+}
+
+// deliveryRecordingNotifier records every call to the notifier of an integration.
+type deliveryRecordingNotifier struct {
+	notifier    notify.Notifier
+	recorder    DeliveryRecorder
+	orgID       int64
+	receiver    string
+	integration string
+	index       int
+	logger      log.Logger
+}
+
+func (n *deliveryRecordingNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	attempt := nextDeliveryAttempt(ctx)
+	start := timeNow()
+	retry, err := n.notifier.Notify(ctx, alerts...)
+
+	groupKey, _ := notify.GroupKey(ctx)
+	d := &ngmodels.NotificationDelivery{
+		OrgID:            n.orgID,
+		Receiver:         n.receiver,
+		Integration:      n.integration,
+		IntegrationIndex: n.index,
+		GroupKey:         groupKey,
+		Status:           ngmodels.NotificationDeliverySuccess,
+		Attempt:          attempt,
+		Alerts:           len(alerts),
+		Duration:         timeNow().Sub(start),
+		SentAt:           start,
+	}
+	if err != nil {
+		d.Status = ngmodels.NotificationDeliveryFailure
+		d.Error = sanitizeDeliveryError(err)
+		var respErr models.WebhookResponseError
+		if errors.As(err, &respErr) {
+			d.StatusCode = respErr.StatusCode
+		}
+	}
+
+	if recordErr := n.recorder.RecordDelivery(ctx, d); recordErr != nil {
+		n.logger.Error("failed to record the notification delivery", "receiver", n.receiver, "integration", n.integration, "err", recordErr)
+	}
+	return retry, err
+}
@@ -0,0 +1,92 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type fakeDeliveryRecorder struct {
+	deliveries []*ngmodels.NotificationDelivery
+}
+
+func (r *fakeDeliveryRecorder) RecordDelivery(_ context.Context, d *ngmodels.NotificationDelivery) error {
+	r.deliveries = append(r.deliveries, d)
+	return nil
+}
+
+type fakeNotifier struct {
+	errs []error
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, _ ...*types.Alert) (bool, error) {
+	if len(n.errs) == 0 {
+		return false, nil
+	}
+	err := n.errs[0]
+	n.errs = n.errs[1:]
+	return true, err
+}
+
+func TestDeliveryRecordingNotifier(t *testing.T) {
+	now := time.Date(2022, 3, 10, 14, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	recorder := &fakeDeliveryRecorder{}
+	n := &deliveryRecordingNotifier{
+		notifier: &fakeNotifier{errs: []error{
+			fmt.Errorf("request to Slack API failed: %w", models.WebhookResponseError{StatusCode: 503, Status: "503 Service Unavailable"}),
+			errors.New("connection refused"),
+		}},
+		recorder:    recorder,
+		orgID:       1,
+		receiver:    "oncall",
+		integration: "slack",
+		index:       2,
+		logger:      log.New("test"),
+	}
+
+	ctx := notify.WithGroupKey(withDeliveryAttempts(context.Background()), "{}:{alertname=\"a\"}")
+	alerts := []*types.Alert{{}, {}}
+	for i := 0; i < 3; i++ {
+		_, _ = n.Notify(ctx, alerts...)
+	}
+
+	require.Equal(t, []*ngmodels.NotificationDelivery{
+		{OrgID: 1, Receiver: "oncall", Integration: "slack", IntegrationIndex: 2, GroupKey: "{}:{alertname=\"a\"}", Status: ngmodels.NotificationDeliveryFailure, StatusCode: 503, Error: "request to Slack API failed: Webhook response status 503 Service Unavailable", Attempt: 1, Alerts: 2, SentAt: now},
+		{OrgID: 1, Receiver: "oncall", Integration: "slack", IntegrationIndex: 2, GroupKey: "{}:{alertname=\"a\"}", Status: ngmodels.NotificationDeliveryFailure, Error: "connection refused", Attempt: 2, Alerts: 2, SentAt: now},
+		{OrgID: 1, Receiver: "oncall", Integration: "slack", IntegrationIndex: 2, GroupKey: "{}:{alertname=\"a\"}", Status: ngmodels.NotificationDeliverySuccess, Attempt: 3, Alerts: 2, SentAt: now},
+	}, recorder.deliveries)
+
+	t.Run("the URLs and secrets of the errors are not recorded", func(t *testing.T) {
+		recorder.deliveries = nil
+		n.notifier = &fakeNotifier{errs: []error{
+			&url.Error{Op: "Post", URL: "https://hooks.slack.com/services/T000/B000/XXXX?token=secret", Err: errors.New("dial tcp: i/o timeout")},
+			errors.New("failed to send: api_key=abc123, retrying"),
+		}}
+		_, _ = n.Notify(withDeliveryAttempts(context.Background()))
+		_, _ = n.Notify(withDeliveryAttempts(context.Background()))
+		require.Len(t, recorder.deliveries, 2)
+		require.Equal(t, `Post "[redacted url]": dial tcp: i/o timeout`, recorder.deliveries[0].Error)
+		require.Equal(t, "failed to send: api_key=[redacted], retrying", recorder.deliveries[1].Error)
+	})
+
+	t.Run("every notification counts its own attempts", func(t *testing.T) {
+		recorder.deliveries = nil
+		_, _ = n.Notify(withDeliveryAttempts(context.Background()))
+		require.Len(t, recorder.deliveries, 1)
+		require.Equal(t, 1, recorder.deliveries[0].Attempt)
+	})
+}
@@ -0,0 +1,339 @@
+// Package deliverylog keeps the attempts to deliver notifications through the integrations of the contact points.
+package deliverylog
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// cleanupInterval is how often the attempts older than the retention are deleted.
+	cleanupInterval = time.Hour
+	// flushInterval is how often the queued attempts are saved.
+	flushInterval = time.Second
+	// queueSize is the number of attempts that can wait to be saved, the attempts recorded when the queue is full are dropped.
+	queueSize = 1000
+	// batchSize is the number of attempts saved by an insert.
+	batchSize = 50
+)
+
+// ErrQueueFull is returned when an attempt is recorded faster than the attempts are saved.
+var ErrQueueFull = errors.New("the queue of notification deliveries is full")
+
+var timeNow = time.Now
+
+type deliveryEntry struct {
+	ID               int64  `xorm:"pk autoincr 'id'"`
+	OrgID            int64  `xorm:"org_id"`
+	Receiver         string `xorm:"receiver"`
+	Integration      string `xorm:"integration"`
+	IntegrationIndex int    `xorm:"integration_index"`
+	GroupKey         string `xorm:"group_key"`
+	Status           string `xorm:"status"`
+	StatusCode       int    `xorm:"status_code"`
+	Error            string `xorm:"error"`
+	Attempt          int    `xorm:"attempt"`
+	Alerts           int    `xorm:"alerts"`
+	// Duration is in milliseconds.
+	Duration int64 `xorm:"duration"`
+	// SentAt is the time of the attempt in milliseconds.
+	SentAt int64 `xorm:"sent_at"`
+}
+
+func (deliveryEntry) TableName() string {
+	return "alert_notification_delivery"
+}
+
+// SQLLog keeps the delivery attempts in the Grafana database. The attempts are saved in batches by Run,
+// so that recording an attempt does not delay the notifications.
+type SQLLog struct {
+	store     *sqlstore.SQLStore
+	retention time.Duration
+	queue     chan *deliveryEntry
+	log       log.Logger
+}
+
+// New returns the delivery log configured by the settings, nil when it is disabled.
+func New(cfg setting.UnifiedAlertingDeliveryLogSettings, store *sqlstore.SQLStore, logger log.Logger) *SQLLog {
+	if !cfg.Enabled {
+		return nil
+	}
+	return NewSQLLog(store, cfg.Retention, logger)
+}
+
+// NewSQLLog creates a log that deletes attempts older than the retention, 0 keeps them forever.
+func NewSQLLog(store *sqlstore.SQLStore, retention time.Duration, logger log.Logger) *SQLLog {
+	return &SQLLog{
+		store:     store,
+		retention: retention,
+		queue:     make(chan *deliveryEntry, queueSize),
+		log:       logger,
+	}
+}
+
+// RecordDelivery queues the attempt to be saved by Run. It does not block, the attempt is dropped
+// and ErrQueueFull is returned when the queue is full.
+func (l *SQLLog) RecordDelivery(_ context.Context, d *models.NotificationDelivery) error {
+	select {
+	case l.queue <- toDeliveryEntry(d):
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (l *SQLLog) ListDeliveries(ctx context.Context, query *models.ListNotificationDeliveriesQuery) error {
+	return l.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		s := strings.Builder{}
+		params := make([]interface{}, 0)
+
+		addToQuery := func(stmt string, p ...interface{}) {
+			s.WriteString(stmt)
+			params = append(params, p...)
+		}
+
+		addToQuery("SELECT * FROM alert_notification_delivery WHERE org_id = ?", query.OrgID)
+		if query.Receiver != "" {
+			addToQuery(" AND receiver = ?", query.Receiver)
+		}
+		if query.Integration != "" {
+			addToQuery(" AND integration = ?", query.Integration)
+		}
+		if query.Status != "" {
+			addToQuery(" AND status = ?", string(query.Status))
+		}
+		if query.GroupKey != "" {
+			addToQuery(" AND group_key = ?", query.GroupKey)
+		}
+		if !query.From.IsZero() {
+			addToQuery(" AND sent_at >= ?", toMillis(query.From))
+		}
+		if !query.To.IsZero() {
+			addToQuery(" AND sent_at <= ?", toMillis(query.To))
+		}
+		addToQuery(" ORDER BY sent_at DESC, id DESC")
+		if query.Limit > 0 {
+			addToQuery(" " + l.store.Dialect.Limit(int64(query.Limit)))
+		}
+
+		entries := make([]*deliveryEntry, 0)
+		if err := sess.SQL(s.String(), params...).Find(&entries); err != nil {
+			return err
+		}
+		result := make([]*models.NotificationDelivery, 0, len(entries))
+		for _, entry := range entries {
+			result = append(result, entry.toNotificationDelivery())
+		}
+		query.Result = result
+		return nil
+	})
+}
+
+// GetDeliveryStatus returns the last attempt and the last successful attempt of every integration.
+func (l *SQLLog) GetDeliveryStatus(ctx context.Context, query *models.GetNotificationDeliveryStatusQuery) error {
+	return l.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		lastAttempts, err := l.findLatest(sess, query, "")
+		if err != nil {
+			return err
+		}
+		lastSuccesses, err := l.findLatest(sess, query, models.NotificationDeliverySuccess)
+		if err != nil {
+			return err
+		}
+
+		successes := make(map[integrationKey]*models.NotificationDelivery, len(lastSuccesses))
+		for _, d := range lastSuccesses {
+			successes[keyOf(d)] = d
+		}
+		result := make([]*models.IntegrationDeliveryStatus, 0, len(lastAttempts))
+		for _, d := range lastAttempts {
+			result = append(result, &models.IntegrationDeliveryStatus{
+				Receiver:         d.Receiver,
+				Integration:      d.Integration,
+				IntegrationIndex: d.IntegrationIndex,
+				LastAttempt:      d,
+				LastSuccess:      successes[keyOf(d)],
+			})
+		}
+		sort.Slice(result, func(i, j int) bool {
+			if result[i].Receiver != result[j].Receiver {
+				return result[i].Receiver < result[j].Receiver
+			}
+			return result[i].IntegrationIndex < result[j].IntegrationIndex
+		})
+		query.Result = result
+		return nil
+	})
+}
+
+// findLatest returns the latest attempt of every integration, optionally only those with the status. The attempts
+// are not always recorded in the order they were sent, therefore the latest is the one with the highest sent_at,
+// the id only breaks the ties.
+func (l *SQLLog) findLatest(sess *sqlstore.DBSession, query *models.GetNotificationDeliveryStatusQuery, status models.NotificationDeliveryStatus) ([]*models.NotificationDelivery, error) {
+	s := strings.Builder{}
+	params := make([]interface{}, 0)
+	filter := func(table string) {
+		s.WriteString(" " + table + ".org_id = ?")
+		params = append(params, query.OrgID)
+		if query.Receiver != "" {
+			s.WriteString(" AND " + table + ".receiver = ?")
+			params = append(params, query.Receiver)
+		}
+		if status != "" {
+			s.WriteString(" AND " + table + ".status = ?")
+			params = append(params, string(status))
+		}
+	}
+
+	s.WriteString("SELECT d.* FROM alert_notification_delivery AS d WHERE")
+	filter("d")
+	s.WriteString(" AND NOT EXISTS (SELECT 1 FROM alert_notification_delivery AS n WHERE")
+	filter("n")
+	s.WriteString(" AND n.receiver = d.receiver AND n.integration = d.integration AND n.integration_index = d.integration_index" +
+		" AND (n.sent_at > d.sent_at OR (n.sent_at = d.sent_at AND n.id > d.id)))")
+
+	entries := make([]*deliveryEntry, 0)
+	if err := sess.SQL(s.String(), params...).Find(&entries); err != nil {
+		return nil, err
+	}
+	result := make([]*models.NotificationDelivery, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry.toNotificationDelivery())
+	}
+	return result, nil
+}
+
+// Run saves the queued attempts and deletes the attempts older than the retention until the context is cancelled.
+// The attempts that are still queued are saved before it returns.
+func (l *SQLLog) Run(ctx context.Context) error {
+	flushTicker := time.NewTicker(flushInterval)
+	defer flushTicker.Stop()
+
+	var cleanup <-chan time.Time
+	if l.retention > 0 {
+		cleanupTicker := time.NewTicker(cleanupInterval)
+		defer cleanupTicker.Stop()
+		cleanup = cleanupTicker.C
+		l.cleanup(ctx)
+	}
+
+	for {
+		select {
+		case <-flushTicker.C:
+			l.flush(ctx)
+		case <-cleanup:
+			l.cleanup(ctx)
+		case <-ctx.Done():
+			l.flush(context.Background())
+			return nil
+		}
+	}
+}
+
+// flush saves the queued attempts in batches. A batch that cannot be saved is dropped.
+func (l *SQLLog) flush(ctx context.Context) {
+	for {
+		batch := l.nextBatch()
+		if len(batch) == 0 {
+			return
+		}
+		err := l.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			_, err := sess.Insert(&batch)
+			return err
+		})
+		if err != nil {
+			l.log.Error("failed to save notification deliveries", "count", len(batch), "error", err)
+		}
+	}
+}
+
+func (l *SQLLog) nextBatch() []*deliveryEntry {
+	batch := make([]*deliveryEntry, 0, batchSize)
+	for len(batch) < batchSize {
+		select {
+		case entry := <-l.queue:
+			batch = append(batch, entry)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+func (l *SQLLog) cleanup(ctx context.Context) {
+	if _, err := l.deleteExpired(ctx); err != nil {
+		l.log.Error("failed to delete expired notification deliveries", "error", err)
+	}
+}
+
+func (l *SQLLog) deleteExpired(ctx context.Context) (int64, error) {
+	var affected int64
+	err := l.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM alert_notification_delivery WHERE sent_at < ?", toMillis(timeNow().Add(-l.retention)))
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	if err == nil && affected > 0 {
+		l.log.Debug("deleted expired notification deliveries", "count", affected)
+	}
+	return affected, err
+}
+
+type integrationKey struct {
+	receiver    string
+	integration string
+	index       int
+}
+
+func keyOf(d *models.NotificationDelivery) integrationKey {
+	return integrationKey{receiver: d.Receiver, integration: d.Integration, index: d.IntegrationIndex}
+}
+
+func toDeliveryEntry(d *models.NotificationDelivery) *deliveryEntry {
+	return &deliveryEntry{
+		OrgID:            d.OrgID,
+		Receiver:         d.Receiver,
+		Integration:      d.Integration,
+		IntegrationIndex: d.IntegrationIndex,
+		GroupKey:         d.GroupKey,
+		Status:           string(d.Status),
+		StatusCode:       d.StatusCode,
+		Error:            d.Error,
+		Attempt:          d.Attempt,
+		Alerts:           d.Alerts,
+		Duration:         d.Duration.Milliseconds(),
+		SentAt:           toMillis(d.SentAt),
+	}
+}
+
+func (e *deliveryEntry) toNotificationDelivery() *models.NotificationDelivery {
+	return &models.NotificationDelivery{
+		OrgID:            e.OrgID,
+		Receiver:         e.Receiver,
+		Integration:      e.Integration,
+		IntegrationIndex: e.IntegrationIndex,
+		GroupKey:         e.GroupKey,
+		Status:           models.NotificationDeliveryStatus(e.Status),
+		StatusCode:       e.StatusCode,
+		Error:            e.Error,
+		Attempt:          e.Attempt,
+		Alerts:           e.Alerts,
+		Duration:         time.Duration(e.Duration) * time.Millisecond,
+		SentAt:           time.Unix(0, e.SentAt*int64(time.Millisecond)).UTC(),
+	}
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,113 @@
+package deliverylog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestSQLLog(t *testing.T) {
+	ctx := context.Background()
+	l := NewSQLLog(sqlstore.InitTestDB(t), 24*time.Hour, log.New("test"))
+
+	now := time.Date(2022, 3, 10, 14, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	for _, d := range []*models.NotificationDelivery{
+		{OrgID: 1, Receiver: "oncall", Integration: "slack", GroupKey: "{}:{alertname=\"a\"}", Status: models.NotificationDeliverySuccess, StatusCode: 200, Attempt: 1, Alerts: 2, Duration: 150 * time.Millisecond, SentAt: now.Add(-3 * time.Minute)},
+		{OrgID: 1, Receiver: "oncall", Integration: "slack", GroupKey: "{}:{alertname=\"b\"}", Status: models.NotificationDeliveryFailure, StatusCode: 500, Error: "request to Slack API failed", Attempt: 1, Alerts: 1, SentAt: now.Add(-2 * time.Minute)},
+		{OrgID: 1, Receiver: "oncall", Integration: "webhook", IntegrationIndex: 1, GroupKey: "{}:{alertname=\"b\"}", Status: models.NotificationDeliveryFailure, Error: "connection refused", Attempt: 2, Alerts: 1, SentAt: now.Add(-time.Minute)},
+		{OrgID: 1, Receiver: "email", Integration: "email", GroupKey: "{}:{alertname=\"b\"}", Status: models.NotificationDeliverySuccess, Attempt: 1, Alerts: 1, SentAt: now},
+		{OrgID: 2, Receiver: "oncall", Integration: "slack", Status: models.NotificationDeliverySuccess, Attempt: 1, SentAt: now},
+		{OrgID: 1, Receiver: "oncall", Integration: "slack", Status: models.NotificationDeliverySuccess, Attempt: 1, SentAt: now.Add(-48 * time.Hour)},
+	} {
+		require.NoError(t, l.RecordDelivery(ctx, d))
+	}
+	l.flush(ctx)
+
+	t.Run("returns the attempts of the organization newest first", func(t *testing.T) {
+		q := &models.ListNotificationDeliveriesQuery{OrgID: 1}
+		require.NoError(t, l.ListDeliveries(ctx, q))
+		require.Len(t, q.Result, 5)
+		require.Equal(t, "email", q.Result[0].Receiver)
+		require.Equal(t, now, q.Result[0].SentAt)
+		require.Equal(t, 150*time.Millisecond, q.Result[3].Duration)
+		require.Equal(t, 2, q.Result[3].Alerts)
+	})
+
+	t.Run("filters by contact point, status and time range", func(t *testing.T) {
+		q := &models.ListNotificationDeliveriesQuery{
+			OrgID:    1,
+			Receiver: "oncall",
+			Status:   models.NotificationDeliveryFailure,
+			From:     now.Add(-time.Hour),
+			To:       now,
+		}
+		require.NoError(t, l.ListDeliveries(ctx, q))
+		require.Len(t, q.Result, 2)
+		require.Equal(t, "webhook", q.Result[0].Integration)
+		require.Equal(t, 2, q.Result[0].Attempt)
+		require.Equal(t, 500, q.Result[1].StatusCode)
+
+		q.Limit = 1
+		require.NoError(t, l.ListDeliveries(ctx, q))
+		require.Len(t, q.Result, 1)
+		require.Equal(t, "webhook", q.Result[0].Integration)
+	})
+
+	t.Run("returns the last attempt and success of every integration", func(t *testing.T) {
+		q := &models.GetNotificationDeliveryStatusQuery{OrgID: 1}
+		require.NoError(t, l.GetDeliveryStatus(ctx, q))
+		require.Len(t, q.Result, 3)
+
+		require.Equal(t, "email", q.Result[0].Receiver)
+		require.Equal(t, q.Result[0].LastAttempt, q.Result[0].LastSuccess)
+
+		slack := q.Result[1]
+		require.Equal(t, "slack", slack.Integration)
+		require.Equal(t, models.NotificationDeliveryFailure, slack.LastAttempt.Status)
+		require.Equal(t, now.Add(-3*time.Minute), slack.LastSuccess.SentAt)
+
+		webhook := q.Result[2]
+		require.Equal(t, "webhook", webhook.Integration)
+		require.Nil(t, webhook.LastSuccess)
+	})
+
+	t.Run("deletes the attempts older than the retention", func(t *testing.T) {
+		deleted, err := l.deleteExpired(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+
+		q := &models.ListNotificationDeliveriesQuery{OrgID: 1, Receiver: "oncall", Integration: "slack"}
+		require.NoError(t, l.ListDeliveries(ctx, q))
+		require.Len(t, q.Result, 2)
+	})
+}
+
+func TestSQLLogQueue(t *testing.T) {
+	l := NewSQLLog(sqlstore.InitTestDB(t), 0, log.New("test"))
+	l.queue = make(chan *deliveryEntry, batchSize+1)
+
+	d := &models.NotificationDelivery{OrgID: 1, Receiver: "oncall", Integration: "slack", Status: models.NotificationDeliverySuccess, Attempt: 1, SentAt: time.Now()}
+	for i := 0; i < batchSize+1; i++ {
+		require.NoError(t, l.RecordDelivery(context.Background(), d))
+	}
+	require.ErrorIs(t, l.RecordDelivery(context.Background(), d), ErrQueueFull)
+
+	// the queued attempts are saved when the log stops
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, l.Run(ctx))
+
+	q := &models.ListNotificationDeliveriesQuery{OrgID: 1}
+	require.NoError(t, l.ListDeliveries(context.Background(), q))
+	require.Len(t, q.Result, batchSize+1)
+	require.NoError(t, l.RecordDelivery(context.Background(), d))
+}
@@ -33,6 +33,12 @@ var (
 type MultiOrgAlertmanager struct {
 	Crypto    Crypto
 	ProvStore provisioning.ProvisioningStore
+	// DeliveryRecorder records the attempts to deliver notifications of the Alertmanagers created after it is set.
+	// It is optional.
+	DeliveryRecorder DeliveryRecorder
+	// ImageStore gets the images attached to the notifications of the Alertmanagers created after it is set.
+	// It is optional, the notifications have no image without it.
+	ImageStore channels.ImageStore
 
 	alertmanagersMtx sync.RWMutex
 	alertmanagers    map[int64]*Alertmanager
@@ -182,7 +188,7 @@ func (moa *MultiOrgAlertmanager) SyncAlertmanagersForOrgs(ctx context.Context, o
 			// To export them, we need to translate the metrics from each individual registry and,
 			// then aggregate them on the main registry.
 			m := metrics.NewAlertmanagerMetrics(moa.metrics.GetOrCreateOrgRegistry(orgID))
-			am, err := newAlertmanager(ctx, orgID, moa.settings, moa.configStore, moa.kvStore, moa.peer, moa.decryptFn, moa.ns, m)
+			am, err := newAlertmanager(ctx, orgID, moa.settings, moa.configStore, moa.kvStore, moa.peer, moa.decryptFn, moa.ns, m, moa.DeliveryRecorder, moa.ImageStore)
 			if err != nil {
 				moa.logger.Error("unable to create Alertmanager for org", "org", orgID, "err", err)
 			}
@@ -328,6 +334,11 @@ func (moa *MultiOrgAlertmanager) AlertmanagerFor(orgID int64) (*Alertmanager, er
 	return orgAM, nil
 }
 
+// Peer returns the clustering peer of the Alertmanagers, a *NilPeer when high availability is not configured.
+func (moa *MultiOrgAlertmanager) Peer() ClusterPeer {
+	return moa.peer
+}
+
 // NilPeer and NilChannel implements the Alertmanager clustering interface.
 type NilPeer struct{}
 
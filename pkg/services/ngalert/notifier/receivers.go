@@ -67,7 +67,7 @@ func (am *Alertmanager) TestReceivers(ctx context.Context, c apimodels.TestRecei
 	// we must set a group key that is unique per test as some receivers use this key to deduplicate alerts
 	ctx = notify.WithGroupKey(ctx, testAlert.Labels.String()+now.String())
 
-	tmpl, err := am.getTemplate()
+	tmpl, textTmpl, err := am.getTemplate()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get template: %w", err)
 	}
@@ -133,7 +133,7 @@ func (am *Alertmanager) TestReceivers(ctx context.Context, c apimodels.TestRecei
 
 	for _, receiver := range c.Receivers {
 		for _, next := range receiver.GrafanaManagedReceivers {
-			n, err := am.buildReceiverIntegration(next, tmpl)
+			n, err := am.buildReceiverIntegration(next, tmpl, textTmpl)
 			if err != nil {
 				invalid = append(invalid, result{
 					Config:       next,
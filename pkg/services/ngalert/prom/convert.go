@@ -0,0 +1,418 @@
+// Package prom converts Prometheus and Loki rule files to Grafana managed alert rules.
+package prom
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/rulefmt"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+const (
+	DatasourceTypePrometheus = "prometheus"
+	DatasourceTypeLoki       = "loki"
+
+	// queryRefID is the reference of the query of the converted rules.
+	queryRefID = "A"
+	// conditionRefID is the reference of the expression that is the condition of the converted alerting rules.
+	conditionRefID = "B"
+	// queryTimeRange is the time range of the query. Prometheus evaluates the expression of a rule
+	// as an instant query, the range only bounds the lookback of the query.
+	queryTimeRange = 10 * time.Minute
+
+	// RuleKeyAnnotation is the annotation of the converted rules that identifies the rule of the file they are
+	// converted from, so that importing the file again updates the rules it created.
+	RuleKeyAnnotation = "__prometheusRuleKey__"
+)
+
+var (
+	ErrUnsupportedDatasource = errors.New("only Prometheus and Loki data sources are supported")
+	ErrInvalidRuleFile       = errors.New("invalid Prometheus rule file")
+)
+
+// Config describes how the Prometheus rules are converted.
+type Config struct {
+	// DatasourceUID is the UID of the data source the expressions of the rules are queried on.
+	DatasourceUID string
+	// DatasourceType is the type of the data source, "prometheus" or "loki".
+	DatasourceType string
+	// BaseInterval is the base interval of the scheduler, the interval of the groups must be a multiple of it.
+	BaseInterval time.Duration
+	// DefaultInterval is the evaluation interval of the groups that do not set one.
+	DefaultInterval time.Duration
+	// RecordingRules converts the recording rules to Grafana recording rules. They are reported
+	// as unsupported otherwise.
+	RecordingRules bool
+}
+
+// RuleGroup is a converted rule group, its rules are ready to be saved in the folder they were converted for.
+type RuleGroup struct {
+	Name     string
+	Interval time.Duration
+	Rules    []*models.AlertRule
+}
+
+// SkippedRule is a rule of the file that is not converted.
+type SkippedRule struct {
+	Group  string
+	Name   string
+	Reason string
+}
+
+// Result is the result of the conversion of a rule file.
+type Result struct {
+	Groups  []RuleGroup
+	Skipped []SkippedRule
+}
+
+// Converter converts Prometheus or Loki rule groups to Grafana managed alert rules.
+type Converter struct {
+	cfg Config
+}
+
+func NewConverter(cfg Config) (*Converter, error) {
+	if cfg.DatasourceUID == "" {
+		return nil, errors.New("data source UID is required")
+	}
+	if cfg.DatasourceType != DatasourceTypePrometheus && cfg.DatasourceType != DatasourceTypeLoki {
+		return nil, fmt.Errorf("%w: data source %s has type %s", ErrUnsupportedDatasource, cfg.DatasourceUID, cfg.DatasourceType)
+	}
+	if cfg.BaseInterval <= 0 {
+		return nil, errors.New("base interval must be positive")
+	}
+	if cfg.DefaultInterval <= 0 {
+		cfg.DefaultInterval = cfg.BaseInterval
+	}
+	return &Converter{cfg: cfg}, nil
+}
+
+// Parse parses and validates a rule file of the data source type, in YAML or JSON. The expressions of
+// a Loki rule file are LogQL, they are checked by the data source when the rules are evaluated.
+func (c *Converter) Parse(content []byte) (*rulefmt.RuleGroups, error) {
+	var groups *rulefmt.RuleGroups
+	var errs []error
+	if c.cfg.DatasourceType == DatasourceTypeLoki {
+		groups, errs = parseLoki(content)
+	} else {
+		groups, errs = rulefmt.Parse(content)
+	}
+	if len(errs) > 0 {
+		msgs := make([]string, 0, len(errs))
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRuleFile, strings.Join(msgs, "; "))
+	}
+	return groups, nil
+}
+
+// parseLoki applies the checks of rulefmt.Parse, but the PromQL parsing of the expressions.
+func parseLoki(content []byte) (*rulefmt.RuleGroups, []error) {
+	var groups rulefmt.RuleGroups
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	// Ignore io.EOF which happens with empty input.
+	if err := decoder.Decode(&groups); err != nil && err != io.EOF {
+		return nil, []error{err}
+	}
+
+	var errs []error
+	names := make(map[string]struct{}, len(groups.Groups))
+	for _, g := range groups.Groups {
+		if g.Name == "" {
+			errs = append(errs, errors.New("group name must not be empty"))
+		}
+		if _, ok := names[g.Name]; ok {
+			errs = append(errs, fmt.Errorf("group name %q is repeated in the same file", g.Name))
+		}
+		names[g.Name] = struct{}{}
+
+		for i, r := range g.Rules {
+			for _, err := range validateLokiRule(r) {
+				errs = append(errs, fmt.Errorf("group %q, rule %d: %w", g.Name, i+1, err))
+			}
+		}
+	}
+	return &groups, errs
+}
+
+func validateLokiRule(r rulefmt.RuleNode) []error {
+	var errs []error
+	if r.Record.Value != "" && r.Alert.Value != "" {
+		errs = append(errs, errors.New("only one of 'record' and 'alert' must be set"))
+	}
+	if r.Record.Value == "" && r.Alert.Value == "" {
+		errs = append(errs, errors.New("one of 'record' or 'alert' must be set"))
+	}
+	if r.Expr.Value == "" {
+		errs = append(errs, errors.New("field 'expr' must be set in rule"))
+	}
+	if r.Record.Value != "" {
+		if len(r.Annotations) > 0 {
+			errs = append(errs, errors.New("invalid field 'annotations' in recording rule"))
+		}
+		if r.For != 0 {
+			errs = append(errs, errors.New("invalid field 'for' in recording rule"))
+		}
+		if !model.IsValidMetricName(model.LabelValue(r.Record.Value)) {
+			errs = append(errs, fmt.Errorf("invalid recording rule name: %s", r.Record.Value))
+		}
+	}
+	for k := range r.Labels {
+		if !model.LabelName(k).IsValid() || k == model.MetricNameLabel {
+			errs = append(errs, fmt.Errorf("invalid label name: %s", k))
+		}
+	}
+	for k := range r.Annotations {
+		if !model.LabelName(k).IsValid() {
+			errs = append(errs, fmt.Errorf("invalid annotation name: %s", k))
+		}
+	}
+	return errs
+}
+
+// Convert converts the rule groups to alert rules of the organization and folder. Every converted rule has
+// a key derived from its group, name and labels. The titles of the rules of a folder are unique, so a rule
+// with the name of a previous rule of the file gets its labels, then its group, in its title.
+func (c *Converter) Convert(orgID int64, namespaceUID string, groups *rulefmt.RuleGroups) (*Result, error) {
+	result := &Result{}
+	titles := make(map[string]struct{})
+	keys := make(map[string]int)
+	for _, g := range groups.Groups {
+		interval := time.Duration(g.Interval)
+		if interval == 0 {
+			interval = c.cfg.DefaultInterval
+		}
+		if interval%c.cfg.BaseInterval != 0 {
+			return nil, fmt.Errorf("evaluation interval %s of group %s should be a multiple of the base interval %s", interval, g.Name, c.cfg.BaseInterval)
+		}
+
+		group := RuleGroup{Name: g.Name, Interval: interval}
+		for _, node := range g.Rules {
+			rule := rulefmt.Rule{
+				Record:      node.Record.Value,
+				Alert:       node.Alert.Value,
+				Expr:        node.Expr.Value,
+				For:         node.For,
+				Labels:      node.Labels,
+				Annotations: node.Annotations,
+			}
+			name := rule.Alert
+			if rule.Record != "" {
+				name = rule.Record
+			}
+
+			if rule.Record != "" && !c.cfg.RecordingRules {
+				result.Skipped = append(result.Skipped, SkippedRule{
+					Group:  g.Name,
+					Name:   name,
+					Reason: "recording rules are not enabled",
+				})
+				continue
+			}
+
+			r, err := c.convertRule(rule)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert rule %s of group %s: %w", name, g.Name, err)
+			}
+			r.Title = uniqueTitle(titles, name, g.Name, rule.Labels)
+			titles[r.Title] = struct{}{}
+			// identical rules of a group are told apart by their position
+			key := ruleKey(g.Name, name, rule.Labels)
+			keys[key]++
+			if n := keys[key]; n > 1 {
+				key = fmt.Sprintf("%s-%d", key, n)
+			}
+			if r.Annotations == nil {
+				r.Annotations = make(map[string]string, 1)
+			}
+			r.Annotations[RuleKeyAnnotation] = key
+			r.OrgID = orgID
+			r.NamespaceUID = namespaceUID
+			r.RuleGroup = g.Name
+			r.IntervalSeconds = int64(interval.Seconds())
+			group.Rules = append(group.Rules, r)
+		}
+		result.Groups = append(result.Groups, group)
+	}
+	return result, nil
+}
+
+// ruleKey returns a hash of the group, the name and the labels of a rule of the file.
+func ruleKey(group string, name string, labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s", group, name)
+	for _, k := range names {
+		fmt.Fprintf(h, "\x00%s\x00%s", k, labels[k])
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// uniqueTitle returns the name of the rule if no previous rule of the file has it as title. Otherwise the title
+// has the labels of the rule, then its group, then a number.
+func uniqueTitle(titles map[string]struct{}, name string, group string, labels map[string]string) string {
+	candidates := []string{name}
+	if len(labels) > 0 {
+		ls := make(model.LabelSet, len(labels))
+		for k, v := range labels {
+			ls[model.LabelName(k)] = model.LabelValue(v)
+		}
+		name = fmt.Sprintf("%s %s", name, ls)
+		candidates = append(candidates, name)
+	}
+	candidates = append(candidates, fmt.Sprintf("%s (%s)", name, group))
+	for _, title := range candidates {
+		if _, ok := titles[title]; !ok {
+			return title
+		}
+	}
+	last := candidates[len(candidates)-1]
+	for n := 2; ; n++ {
+		title := fmt.Sprintf("%s #%d", last, n)
+		if _, ok := titles[title]; !ok {
+			return title
+		}
+	}
+}
+
+func (c *Converter) convertRule(rule rulefmt.Rule) (*models.AlertRule, error) {
+	query, err := c.query(rule.Expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if rule.Record != "" {
+		if !model.IsValidMetricName(model.LabelValue(rule.Record)) {
+			return nil, fmt.Errorf("invalid metric name %q", rule.Record)
+		}
+		return &models.AlertRule{
+			Title:        rule.Record,
+			Condition:    queryRefID,
+			Data:         []models.AlertQuery{query},
+			NoDataState:  models.OK,
+			ExecErrState: models.ErrorErrState,
+			Labels:       rule.Labels,
+			Record:       &models.Record{Metric: rule.Record},
+		}, nil
+	}
+
+	// every series returned by the expression of a Prometheus alerting rule is an alert,
+	// whatever its value is.
+	condition, err := json.Marshal(map[string]interface{}{
+		"refId":      conditionRefID,
+		"type":       "math",
+		"expression": fmt.Sprintf("is_number($%[1]s) || is_nan($%[1]s) || is_inf($%[1]s)", queryRefID),
+		"datasource": map[string]string{
+			"type": expr.DatasourceType,
+			"uid":  expr.DatasourceUID,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AlertRule{
+		Title:     rule.Alert,
+		Condition: conditionRefID,
+		Data: []models.AlertQuery{query, {
+			RefID:         conditionRefID,
+			DatasourceUID: expr.DatasourceUID,
+			Model:         condition,
+		}},
+		// Prometheus does not fire when the expression returns nothing.
+		NoDataState:  models.OK,
+		ExecErrState: models.ErrorErrState,
+		For:          time.Duration(rule.For),
+		Labels:       rule.Labels,
+		Annotations:  rule.Annotations,
+	}, nil
+}
+
+func (c *Converter) query(expression string) (models.AlertQuery, error) {
+	query := map[string]interface{}{
+		"refId": queryRefID,
+		"expr":  expression,
+		"datasource": map[string]string{
+			"type": c.cfg.DatasourceType,
+			"uid":  c.cfg.DatasourceUID,
+		},
+	}
+	if c.cfg.DatasourceType == DatasourceTypeLoki {
+		query["queryType"] = "instant"
+	} else {
+		query["instant"] = true
+		query["range"] = false
+	}
+	queryModel, err := json.Marshal(query)
+	if err != nil {
+		return models.AlertQuery{}, err
+	}
+	return models.AlertQuery{
+		RefID: queryRefID,
+		RelativeTimeRange: models.RelativeTimeRange{
+			From: models.Duration(queryTimeRange),
+		},
+		DatasourceUID: c.cfg.DatasourceUID,
+		Model:         queryModel,
+	}, nil
+}
+
+// MatchExisting gives the converted rules the UID of the rule of the folder that was imported from the same rule
+// of a file, so that importing a file again updates the rules imported before. Rules that were not created by an
+// import are never matched. It returns the UIDs of the matched rules.
+func (r *Result) MatchExisting(existing []*models.AlertRule) map[string]struct{} {
+	byKey := make(map[string]*models.AlertRule, len(existing))
+	for _, rule := range existing {
+		if key := rule.Annotations[RuleKeyAnnotation]; key != "" {
+			byKey[key] = rule
+		}
+	}
+	matched := make(map[string]struct{})
+	for _, group := range r.Groups {
+		for _, rule := range group.Rules {
+			if e, ok := byKey[rule.Annotations[RuleKeyAnnotation]]; ok {
+				rule.UID = e.UID
+				matched[e.UID] = struct{}{}
+			}
+		}
+	}
+	return matched
+}
+
+// Validate applies to the converted rules the validation of the rules that are posted to the ruler API. The
+// condition of every rule is checked by validateCondition, which verifies the data sources of the queries.
+func (r *Result) Validate(validateCondition func(models.Condition) error) error {
+	for _, group := range r.Groups {
+		if len(group.Name) > store.AlertRuleMaxRuleGroupNameLength {
+			return fmt.Errorf("rule group name %s is too long. Max length is %d", group.Name, store.AlertRuleMaxRuleGroupNameLength)
+		}
+		for _, rule := range group.Rules {
+			if len(rule.Title) > store.AlertRuleMaxTitleLength {
+				return fmt.Errorf("rule name %s is too long. Max length is %d", rule.Title, store.AlertRuleMaxTitleLength)
+			}
+			if err := validateCondition(models.Condition{Condition: rule.Condition, OrgID: rule.OrgID, Data: rule.Data}); err != nil {
+				return fmt.Errorf("failed to validate condition of alert rule %s: %w", rule.Title, err)
+			}
+		}
+	}
+	return nil
+}
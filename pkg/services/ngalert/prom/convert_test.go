@@ -0,0 +1,334 @@
+package prom
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/rulefmt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+const ruleFile = `
+groups:
+  - name: node
+    interval: 1m
+    rules:
+      - alert: HighCPU
+        expr: avg by (instance) (rate(node_cpu_seconds_total{mode!="idle"}[5m])) > 0.9
+        for: 5m
+        labels:
+          severity: critical
+        annotations:
+          summary: CPU of {{ $labels.instance }} is high
+      - record: instance:node_cpu:rate5m
+        expr: avg by (instance) (rate(node_cpu_seconds_total[5m]))
+        labels:
+          source: node
+  - name: api
+    rules:
+      - alert: HighCPU
+        expr: process_cpu_seconds_total > 1
+`
+
+const lokiRuleFile = `
+groups:
+  - name: nginx
+    interval: 1m
+    rules:
+      - alert: HighErrorRate
+        expr: sum by (host) (rate({job="nginx"} |= "error" [5m])) > 10
+        for: 2m
+        labels:
+          severity: warning
+      - record: nginx:requests:rate5m
+        expr: sum by (host) (rate({job="nginx"}[5m]))
+`
+
+func TestParse(t *testing.T) {
+	converter, err := NewConverter(Config{DatasourceUID: "prom", DatasourceType: "prometheus", BaseInterval: 10 * time.Second})
+	require.NoError(t, err)
+	lokiConverter, err := NewConverter(Config{DatasourceUID: "loki", DatasourceType: "loki", BaseInterval: 10 * time.Second})
+	require.NoError(t, err)
+
+	t.Run("should parse a valid rule file", func(t *testing.T) {
+		groups, err := converter.Parse([]byte(ruleFile))
+		require.NoError(t, err)
+		require.Len(t, groups.Groups, 2)
+	})
+
+	t.Run("should fail on invalid expressions", func(t *testing.T) {
+		_, err := converter.Parse([]byte("groups:\n  - name: a\n    rules:\n      - alert: b\n        expr: sum(\n"))
+		require.ErrorIs(t, err, ErrInvalidRuleFile)
+	})
+
+	t.Run("should fail when a rule is both alerting and recording", func(t *testing.T) {
+		_, err := converter.Parse([]byte("groups:\n  - name: a\n    rules:\n      - alert: b\n        record: c\n        expr: up\n"))
+		require.ErrorIs(t, err, ErrInvalidRuleFile)
+	})
+
+	t.Run("should parse the LogQL expressions of a Loki rule file", func(t *testing.T) {
+		_, err := converter.Parse([]byte(lokiRuleFile))
+		require.ErrorIs(t, err, ErrInvalidRuleFile)
+
+		groups, err := lokiConverter.Parse([]byte(lokiRuleFile))
+		require.NoError(t, err)
+		require.Len(t, groups.Groups, 1)
+		require.Len(t, groups.Groups[0].Rules, 2)
+	})
+
+	t.Run("should validate the rules of a Loki rule file", func(t *testing.T) {
+		for _, content := range []string{
+			"groups:\n  - name: a\n    rules:\n      - alert: b\n        record: c\n        expr: count_over_time({job=\"a\"}[1m])\n",
+			"groups:\n  - name: a\n    rules:\n      - alert: b\n",
+			"groups:\n  - name: a\n    rules:\n      - record: not a metric\n        expr: count_over_time({job=\"a\"}[1m])\n",
+			"groups:\n  - name: a\n    rules: []\n  - name: a\n    rules: []\n",
+			"groups:\n  - name: a\n    unknown: b\n",
+		} {
+			_, err := lokiConverter.Parse([]byte(content))
+			require.ErrorIs(t, err, ErrInvalidRuleFile, content)
+		}
+	})
+}
+
+func TestConverter(t *testing.T) {
+	cfg := Config{
+		DatasourceUID:   "prom",
+		DatasourceType:  "prometheus",
+		BaseInterval:    10 * time.Second,
+		DefaultInterval: 30 * time.Second,
+	}
+	groups, err := rulefmt.Parse([]byte(ruleFile))
+	require.Empty(t, err)
+
+	t.Run("should reject data sources that are not Prometheus or Loki", func(t *testing.T) {
+		c := cfg
+		c.DatasourceType = "elasticsearch"
+		_, err := NewConverter(c)
+		require.ErrorIs(t, err, ErrUnsupportedDatasource)
+	})
+
+	t.Run("should convert alerting rules", func(t *testing.T) {
+		converter, err := NewConverter(cfg)
+		require.NoError(t, err)
+
+		result, err := converter.Convert(1, "folder", groups)
+		require.NoError(t, err)
+		require.Len(t, result.Groups, 2)
+
+		group := result.Groups[0]
+		require.Equal(t, "node", group.Name)
+		require.Equal(t, time.Minute, group.Interval)
+		require.Len(t, group.Rules, 1)
+
+		rule := group.Rules[0]
+		require.Equal(t, "HighCPU", rule.Title)
+		require.Equal(t, int64(1), rule.OrgID)
+		require.Equal(t, "folder", rule.NamespaceUID)
+		require.Equal(t, "node", rule.RuleGroup)
+		require.Equal(t, int64(60), rule.IntervalSeconds)
+		require.Equal(t, 5*time.Minute, rule.For)
+		require.Equal(t, models.OK, rule.NoDataState)
+		require.Equal(t, map[string]string{"severity": "critical"}, rule.Labels)
+		require.Equal(t, "CPU of {{ $labels.instance }} is high", rule.Annotations["summary"])
+		require.NotEmpty(t, rule.Annotations[RuleKeyAnnotation])
+		require.Nil(t, rule.Record)
+
+		require.Equal(t, "B", rule.Condition)
+		require.Len(t, rule.Data, 2)
+		require.Equal(t, "prom", rule.Data[0].DatasourceUID)
+		var query map[string]interface{}
+		require.NoError(t, json.Unmarshal(rule.Data[0].Model, &query))
+		require.Equal(t, `avg by (instance) (rate(node_cpu_seconds_total{mode!="idle"}[5m])) > 0.9`, query["expr"])
+		require.Equal(t, true, query["instant"])
+		require.Equal(t, expr.DatasourceUID, rule.Data[1].DatasourceUID)
+		isExpression, err := rule.Data[1].IsExpression()
+		require.NoError(t, err)
+		require.True(t, isExpression)
+
+		require.Equal(t, "api", result.Groups[1].Name)
+		require.Equal(t, 30*time.Second, result.Groups[1].Interval)
+		require.Len(t, result.Groups[1].Rules, 1)
+		// the titles of the rules of a folder are unique
+		require.Equal(t, "HighCPU (api)", result.Groups[1].Rules[0].Title)
+		require.NotEqual(t, rule.Annotations[RuleKeyAnnotation], result.Groups[1].Rules[0].Annotations[RuleKeyAnnotation])
+	})
+
+	t.Run("should report recording rules as skipped", func(t *testing.T) {
+		converter, err := NewConverter(cfg)
+		require.NoError(t, err)
+
+		result, err := converter.Convert(1, "folder", groups)
+		require.NoError(t, err)
+		require.Equal(t, []SkippedRule{
+			{Group: "node", Name: "instance:node_cpu:rate5m", Reason: "recording rules are not enabled"},
+		}, result.Skipped)
+	})
+
+	t.Run("should convert the rules of a group that have the same name", func(t *testing.T) {
+		converter, err := NewConverter(cfg)
+		require.NoError(t, err)
+		severities, errs := rulefmt.Parse([]byte(`
+groups:
+  - name: node
+    rules:
+      - alert: HighCPU
+        expr: cpu > 0.8
+        labels:
+          severity: warning
+      - alert: HighCPU
+        expr: cpu > 0.95
+        labels:
+          severity: critical
+      - alert: HighCPU
+        expr: cpu > 0.99
+        labels:
+          severity: critical
+`))
+		require.Empty(t, errs)
+
+		result, err := converter.Convert(1, "folder", severities)
+		require.NoError(t, err)
+		require.Empty(t, result.Skipped)
+		rules := result.Groups[0].Rules
+		require.Len(t, rules, 3)
+		require.Equal(t, "HighCPU", rules[0].Title)
+		require.Equal(t, `HighCPU {severity="critical"}`, rules[1].Title)
+		require.Equal(t, `HighCPU {severity="critical"} (node)`, rules[2].Title)
+
+		keys := make(map[string]struct{})
+		for _, r := range rules {
+			keys[r.Annotations[RuleKeyAnnotation]] = struct{}{}
+		}
+		require.Len(t, keys, 3)
+
+		// the keys do not change when the file is converted again
+		again, err := converter.Convert(1, "folder", severities)
+		require.NoError(t, err)
+		for i, r := range again.Groups[0].Rules {
+			require.Equal(t, rules[i].Annotations[RuleKeyAnnotation], r.Annotations[RuleKeyAnnotation])
+		}
+	})
+
+	t.Run("should convert recording rules when they are enabled", func(t *testing.T) {
+		c := cfg
+		c.RecordingRules = true
+		converter, err := NewConverter(c)
+		require.NoError(t, err)
+
+		result, err := converter.Convert(1, "folder", groups)
+		require.NoError(t, err)
+		require.Len(t, result.Groups[0].Rules, 2)
+
+		rule := result.Groups[0].Rules[1]
+		require.Equal(t, "instance:node_cpu:rate5m", rule.Title)
+		require.Equal(t, &models.Record{Metric: "instance:node_cpu:rate5m"}, rule.Record)
+		require.Equal(t, "A", rule.Condition)
+		require.Len(t, rule.Data, 1)
+		require.Equal(t, map[string]string{"source": "node"}, rule.Labels)
+	})
+
+	t.Run("should convert a Loki rule group to instant queries of Loki", func(t *testing.T) {
+		c := cfg
+		c.DatasourceUID = "loki"
+		c.DatasourceType = "loki"
+		c.RecordingRules = true
+		converter, err := NewConverter(c)
+		require.NoError(t, err)
+		groups, err := converter.Parse([]byte(lokiRuleFile))
+		require.NoError(t, err)
+
+		result, err := converter.Convert(1, "folder", groups)
+		require.NoError(t, err)
+		require.Len(t, result.Groups, 1)
+		require.Len(t, result.Groups[0].Rules, 2)
+
+		rule := result.Groups[0].Rules[0]
+		require.Equal(t, "HighErrorRate", rule.Title)
+		require.Equal(t, 2*time.Minute, rule.For)
+		require.Equal(t, "B", rule.Condition)
+		require.Len(t, rule.Data, 2)
+		require.Equal(t, "loki", rule.Data[0].DatasourceUID)
+		var query map[string]interface{}
+		require.NoError(t, json.Unmarshal(rule.Data[0].Model, &query))
+		require.Equal(t, map[string]interface{}{
+			"refId":      "A",
+			"expr":       `sum by (host) (rate({job="nginx"} |= "error" [5m])) > 10`,
+			"queryType":  "instant",
+			"datasource": map[string]interface{}{"type": "loki", "uid": "loki"},
+		}, query)
+
+		record := result.Groups[0].Rules[1]
+		require.Equal(t, &models.Record{Metric: "nginx:requests:rate5m"}, record.Record)
+		require.NoError(t, json.Unmarshal(record.Data[0].Model, &query))
+		require.Equal(t, `sum by (host) (rate({job="nginx"}[5m]))`, query["expr"])
+	})
+
+	t.Run("should fail when the interval is not a multiple of the base interval", func(t *testing.T) {
+		c := cfg
+		c.BaseInterval = 7 * time.Second
+		converter, err := NewConverter(c)
+		require.NoError(t, err)
+
+		_, err = converter.Convert(1, "folder", groups)
+		require.Error(t, err)
+	})
+}
+
+func TestMatchExisting(t *testing.T) {
+	imported := func(title string, key string) *models.AlertRule {
+		return &models.AlertRule{Title: title, Annotations: map[string]string{RuleKeyAnnotation: key}}
+	}
+	result := &Result{Groups: []RuleGroup{
+		{Name: "a", Rules: []*models.AlertRule{imported("HighCPU", "cpu-key"), imported("HighMemory", "memory-key")}},
+		{Name: "b", Rules: []*models.AlertRule{imported("DiskFull", "disk-key")}},
+	}}
+
+	matched := result.MatchExisting([]*models.AlertRule{
+		{UID: "cpu", Title: "HighCPU", RuleGroup: "a", Annotations: map[string]string{RuleKeyAnnotation: "cpu-key"}},
+		{UID: "disk", Title: "DiskFull", RuleGroup: "other", Annotations: map[string]string{RuleKeyAnnotation: "disk-key"}},
+		// rules that were not imported are not matched by their title
+		{UID: "memory", Title: "HighMemory", RuleGroup: "a"},
+		{UID: "net", Title: "NetworkDown", RuleGroup: "a"},
+	})
+
+	require.Equal(t, map[string]struct{}{"cpu": {}, "disk": {}}, matched)
+	require.Equal(t, "cpu", result.Groups[0].Rules[0].UID)
+	require.Empty(t, result.Groups[0].Rules[1].UID)
+	require.Equal(t, "disk", result.Groups[1].Rules[0].UID)
+}
+
+func TestResultValidate(t *testing.T) {
+	valid := func(models.Condition) error { return nil }
+	rule := func(title string) *models.AlertRule {
+		return &models.AlertRule{Title: title, Condition: conditionRefID}
+	}
+
+	t.Run("should accept valid rules", func(t *testing.T) {
+		result := &Result{Groups: []RuleGroup{{Name: "node", Rules: []*models.AlertRule{rule("HighCPU")}}}}
+		require.NoError(t, result.Validate(valid))
+	})
+
+	t.Run("should reject long group names and titles", func(t *testing.T) {
+		result := &Result{Groups: []RuleGroup{{Name: strings.Repeat("a", store.AlertRuleMaxRuleGroupNameLength+1), Rules: []*models.AlertRule{rule("HighCPU")}}}}
+		require.ErrorContains(t, result.Validate(valid), "rule group name")
+
+		result = &Result{Groups: []RuleGroup{{Name: "node", Rules: []*models.AlertRule{rule(strings.Repeat("a", store.AlertRuleMaxTitleLength+1))}}}}
+		require.ErrorContains(t, result.Validate(valid), "rule name")
+	})
+
+	t.Run("should reject invalid conditions", func(t *testing.T) {
+		result := &Result{Groups: []RuleGroup{{Name: "node", Rules: []*models.AlertRule{rule("HighCPU")}}}}
+		err := result.Validate(func(c models.Condition) error {
+			require.Equal(t, conditionRefID, c.Condition)
+			return errors.New("data source not found")
+		})
+		require.EqualError(t, err, "failed to validate condition of alert rule HighCPU: data source not found")
+	})
+}
@@ -0,0 +1,126 @@
+package prom
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+// GroupChanges are the changes an import makes to a rule group of the folder.
+type GroupChanges struct {
+	Group  string
+	New    []*models.AlertRule
+	Update []store.UpdateRule
+	Delete []*models.AlertRule
+}
+
+// ImportOptions are the checks of the caller applied to the changes of an import.
+type ImportOptions struct {
+	// Authorize returns the changes of the group that can be made, or an error when a change is not allowed.
+	// All changes are made when it is nil.
+	Authorize func(ch *GroupChanges) (*GroupChanges, error)
+	// CheckQuota returns an error when adding count rules to the organization exceeds its quota.
+	// It is called before the rules are inserted. The quota is not checked when it is nil.
+	CheckQuota func(ctx context.Context, count int) error
+}
+
+// Import saves the converted rules in the folder and returns the changes made to every group of the result. The rules of the folder
+// that were created by an import are matched with the converted rules by their key, and a group of the result replaces the group of
+// the folder that has the same name: the rules of the group that are not in the result are deleted, unless they are moved to another
+// group of the result. The import fails when it changes rules that were provisioned. It must be called in a transaction.
+func Import(ctx context.Context, ruleStore store.RuleStore, provenanceStore provisioning.ProvisioningStore, orgID int64, folderUID string, result *Result, opts ImportOptions) ([]*GroupChanges, error) {
+	q := models.ListAlertRulesQuery{OrgID: orgID, NamespaceUIDs: []string{folderUID}}
+	if err := ruleStore.ListAlertRules(ctx, &q); err != nil {
+		return nil, fmt.Errorf("failed to get the rules of the folder: %w", err)
+	}
+	existing := make(map[string]*models.AlertRule, len(q.Result))
+	for _, rule := range q.Result {
+		existing[rule.UID] = rule
+	}
+	matched := result.MatchExisting(q.Result)
+
+	var provenances map[string]models.Provenance
+	checkProvenance := func(rule *models.AlertRule) error {
+		if provenances == nil {
+			var err error
+			if provenances, err = provenanceStore.GetProvenances(ctx, orgID, rule.ResourceType()); err != nil {
+				return err
+			}
+		}
+		if p := provenances[rule.UID]; !models.CanUpdateProvenance(p, models.ProvenanceNone) {
+			return fmt.Errorf("%w: rule %s was provisioned with provenance '%s'", provisioning.ErrProvenanceMismatch, rule.UID, p)
+		}
+		return nil
+	}
+
+	imported := make([]*GroupChanges, 0, len(result.Groups))
+	for _, group := range result.Groups {
+		ch := &GroupChanges{Group: group.Name}
+		for _, rule := range group.Rules {
+			e, ok := existing[rule.UID]
+			if !ok {
+				ch.New = append(ch.New, rule)
+				continue
+			}
+			if len(e.Diff(rule, "ID", "Version", "Updated")) == 0 {
+				continue
+			}
+			ch.Update = append(ch.Update, store.UpdateRule{Existing: e, New: *rule})
+		}
+		for _, e := range q.Result {
+			if _, ok := matched[e.UID]; ok || e.RuleGroup != group.Name {
+				continue
+			}
+			ch.Delete = append(ch.Delete, e)
+		}
+
+		if opts.Authorize != nil {
+			var err error
+			if ch, err = opts.Authorize(ch); err != nil {
+				return nil, err
+			}
+		}
+		for _, update := range ch.Update {
+			if err := checkProvenance(update.Existing); err != nil {
+				return nil, err
+			}
+		}
+		for _, rule := range ch.Delete {
+			if err := checkProvenance(rule); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := ruleStore.UpdateAlertRules(ctx, ch.Update); err != nil {
+			return nil, fmt.Errorf("failed to update rules: %w", err)
+		}
+		if len(ch.Delete) > 0 {
+			uids := make([]string, 0, len(ch.Delete))
+			for _, rule := range ch.Delete {
+				uids = append(uids, rule.UID)
+			}
+			if err := ruleStore.DeleteAlertRulesByUID(ctx, orgID, uids...); err != nil {
+				return nil, fmt.Errorf("failed to delete rules: %w", err)
+			}
+		}
+		if len(ch.New) > 0 {
+			if opts.CheckQuota != nil {
+				if err := opts.CheckQuota(ctx, len(ch.New)); err != nil {
+					return nil, err
+				}
+			}
+			inserts := make([]models.AlertRule, 0, len(ch.New))
+			for _, rule := range ch.New {
+				inserts = append(inserts, *rule)
+			}
+			if err := ruleStore.InsertAlertRules(ctx, inserts); err != nil {
+				return nil, fmt.Errorf("failed to add rules: %w", err)
+			}
+		}
+		imported = append(imported, ch)
+	}
+	return imported, nil
+}
@@ -0,0 +1,112 @@
+package prom
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	rule := func(uid string, group string, title string, key string) *models.AlertRule {
+		r := &models.AlertRule{OrgID: 1, UID: uid, NamespaceUID: "folder", RuleGroup: group, Title: title, Annotations: map[string]string{}}
+		if key != "" {
+			r.Annotations[RuleKeyAnnotation] = key
+		}
+		return r
+	}
+	setup := func() (*store.FakeRuleStore, *Result) {
+		ruleStore := store.NewFakeRuleStore(t)
+		ruleStore.PutRule(ctx,
+			rule("changed", "node", "HighCPU", "node/HighCPU"),
+			rule("moved", "node", "HighMemory", "node/HighMemory"),
+			rule("unchanged", "node", "DiskFull", "node/DiskFull"),
+			rule("removed", "node", "Created in Grafana", ""),
+			rule("other", "other", "Other group", ""),
+		)
+		result := &Result{Groups: []RuleGroup{
+			{Name: "node", Rules: []*models.AlertRule{
+				rule("", "node", "HighCPU usage", "node/HighCPU"),
+				rule("", "node", "DiskFull", "node/DiskFull"),
+				rule("", "node", "HighLoad", "node/HighLoad"),
+			}},
+			{Name: "memory", Rules: []*models.AlertRule{
+				rule("", "memory", "HighMemory", "node/HighMemory"),
+			}},
+		}}
+		return ruleStore, result
+	}
+	uids := func(rules []*models.AlertRule) []string {
+		result := make([]string, 0, len(rules))
+		for _, r := range rules {
+			result = append(result, r.UID)
+		}
+		return result
+	}
+
+	t.Run("replaces the groups of the folder", func(t *testing.T) {
+		ruleStore, result := setup()
+		imported, err := Import(ctx, ruleStore, provisioning.NewFakeProvisioningStore(), 1, "folder", result, ImportOptions{})
+		require.NoError(t, err)
+		require.Len(t, imported, 2)
+
+		require.Equal(t, "node", imported[0].Group)
+		require.Len(t, imported[0].New, 1)
+		require.Equal(t, "HighLoad", imported[0].New[0].Title)
+		require.Len(t, imported[0].Update, 1)
+		require.Equal(t, "changed", imported[0].Update[0].Existing.UID)
+		require.Equal(t, "HighCPU usage", imported[0].Update[0].New.Title)
+		// the rule moved to the other group of the file is kept
+		require.Equal(t, []string{"removed"}, uids(imported[0].Delete))
+
+		require.Equal(t, "memory", imported[1].Group)
+		require.Empty(t, imported[1].New)
+		require.Len(t, imported[1].Update, 1)
+		require.Equal(t, "moved", imported[1].Update[0].Existing.UID)
+		require.Empty(t, imported[1].Delete)
+
+		q := &models.ListAlertRulesQuery{OrgID: 1}
+		require.NoError(t, ruleStore.ListAlertRules(ctx, q))
+		require.ElementsMatch(t, []string{"changed", "moved", "unchanged", "other"}, uids(q.Result))
+	})
+
+	t.Run("applies the checks of the caller", func(t *testing.T) {
+		ruleStore, result := setup()
+		var quotaChecked int
+		imported, err := Import(ctx, ruleStore, provisioning.NewFakeProvisioningStore(), 1, "folder", result, ImportOptions{
+			Authorize: func(ch *GroupChanges) (*GroupChanges, error) {
+				ch.Delete = nil
+				return ch, nil
+			},
+			CheckQuota: func(_ context.Context, count int) error {
+				quotaChecked += count
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		require.Empty(t, imported[0].Delete)
+		require.Equal(t, 1, quotaChecked)
+
+		errQuota := errors.New("quota reached")
+		_, err = Import(ctx, ruleStore, provisioning.NewFakeProvisioningStore(), 1, "folder", result, ImportOptions{
+			CheckQuota: func(context.Context, int) error {
+				return errQuota
+			},
+		})
+		require.ErrorIs(t, err, errQuota)
+	})
+
+	t.Run("fails when a provisioned rule is changed", func(t *testing.T) {
+		ruleStore, result := setup()
+		provenanceStore := provisioning.NewFakeProvisioningStore()
+		require.NoError(t, provenanceStore.SetProvenance(ctx, rule("removed", "node", "", ""), 1, models.ProvenanceFile))
+		_, err := Import(ctx, ruleStore, provenanceStore, 1, "folder", result, ImportOptions{})
+		require.ErrorIs(t, err, provisioning.ErrProvenanceMismatch)
+	})
+}
@@ -0,0 +1,215 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type AlertRuleService struct {
+	defaultInterval time.Duration
+	ruleStore       RuleStore
+	provenanceStore ProvisioningStore
+	xact            TransactionManager
+	log             log.Logger
+}
+
+func NewAlertRuleService(ruleStore RuleStore, provenanceStore ProvisioningStore, xact TransactionManager, defaultInterval time.Duration, log log.Logger) *AlertRuleService {
+	return &AlertRuleService{
+		defaultInterval: defaultInterval,
+		ruleStore:       ruleStore,
+		provenanceStore: provenanceStore,
+		xact:            xact,
+		log:             log,
+	}
+}
+
+// GetAlertRule returns the alert rule with its provenance.
+func (service *AlertRuleService) GetAlertRule(ctx context.Context, orgID int64, ruleUID string) (models.AlertRule, models.Provenance, error) {
+	query := &models.GetAlertRuleByUIDQuery{
+		OrgID: orgID,
+		UID:   ruleUID,
+	}
+	if err := service.ruleStore.GetAlertRuleByUID(ctx, query); err != nil {
+		return models.AlertRule{}, models.ProvenanceNone, err
+	}
+	provenance, err := service.provenanceStore.GetProvenance(ctx, query.Result, orgID)
+	if err != nil {
+		return models.AlertRule{}, models.ProvenanceNone, err
+	}
+	return *query.Result, provenance, nil
+}
+
+// CreateAlertRule creates the rule in its group. The rule gets the interval of the group, or the default
+// evaluation interval if the group does not exist yet. A UID is generated if the rule does not have one.
+func (service *AlertRuleService) CreateAlertRule(ctx context.Context, rule models.AlertRule, provenance models.Provenance) (models.AlertRule, error) {
+	if err := validateAlertRule(rule); err != nil {
+		return models.AlertRule{}, err
+	}
+	if rule.UID == "" {
+		rule.UID = util.GenerateShortUID()
+	}
+	err := service.xact.InTransaction(ctx, func(ctx context.Context) error {
+		interval, err := service.getRuleGroupInterval(ctx, rule.OrgID, rule.NamespaceUID, rule.RuleGroup)
+		if err != nil {
+			return err
+		}
+		rule.IntervalSeconds = interval
+		if err := service.ruleStore.InsertAlertRules(ctx, []models.AlertRule{rule}); err != nil {
+			return err
+		}
+		query := &models.GetAlertRuleByUIDQuery{OrgID: rule.OrgID, UID: rule.UID}
+		if err := service.ruleStore.GetAlertRuleByUID(ctx, query); err != nil {
+			return err
+		}
+		rule = *query.Result
+		return service.provenanceStore.SetProvenance(ctx, &rule, rule.OrgID, provenance)
+	})
+	if err != nil {
+		return models.AlertRule{}, err
+	}
+	return rule, nil
+}
+
+// UpdateAlertRule replaces the stored rule with the same UID. The rule keeps the interval of its group.
+func (service *AlertRuleService) UpdateAlertRule(ctx context.Context, rule models.AlertRule, provenance models.Provenance) (models.AlertRule, error) {
+	if err := validateAlertRule(rule); err != nil {
+		return models.AlertRule{}, err
+	}
+	err := service.xact.InTransaction(ctx, func(ctx context.Context) error {
+		existing, storedProvenance, err := service.GetAlertRule(ctx, rule.OrgID, rule.UID)
+		if err != nil {
+			return err
+		}
+		if !models.CanUpdateProvenance(storedProvenance, provenance) {
+			return fmt.Errorf("%w: cannot change provenance from '%s' to '%s'", ErrProvenanceMismatch, storedProvenance, provenance)
+		}
+		if existing.NamespaceUID == rule.NamespaceUID && existing.RuleGroup == rule.RuleGroup {
+			rule.IntervalSeconds = existing.IntervalSeconds
+		} else {
+			interval, err := service.getRuleGroupInterval(ctx, rule.OrgID, rule.NamespaceUID, rule.RuleGroup)
+			if err != nil {
+				return err
+			}
+			rule.IntervalSeconds = interval
+		}
+		rule.ID = existing.ID
+		if err := service.ruleStore.UpdateAlertRules(ctx, []store.UpdateRule{{Existing: &existing, New: rule}}); err != nil {
+			return err
+		}
+		rule.Version = existing.Version + 1
+		return service.provenanceStore.SetProvenance(ctx, &rule, rule.OrgID, provenance)
+	})
+	if err != nil {
+		return models.AlertRule{}, err
+	}
+	return rule, nil
+}
+
+// DeleteAlertRule deletes the rule and its provenance.
+func (service *AlertRuleService) DeleteAlertRule(ctx context.Context, orgID int64, ruleUID string, provenance models.Provenance) error {
+	rule := &models.AlertRule{OrgID: orgID, UID: ruleUID}
+	return service.xact.InTransaction(ctx, func(ctx context.Context) error {
+		storedProvenance, err := service.provenanceStore.GetProvenance(ctx, rule, orgID)
+		if err != nil {
+			return err
+		}
+		if !models.CanUpdateProvenance(storedProvenance, provenance) {
+			return fmt.Errorf("%w: cannot delete a rule with provenance '%s' with provenance '%s'", ErrProvenanceMismatch, storedProvenance, provenance)
+		}
+		if err := service.ruleStore.DeleteAlertRulesByUID(ctx, orgID, ruleUID); err != nil {
+			return err
+		}
+		return service.provenanceStore.DeleteProvenance(ctx, rule, orgID)
+	})
+}
+
+// UpdateRuleGroup sets the evaluation interval of all the rules of the group. The group is not changed if
+// the provenance of any of its rules can not be updated with the given provenance.
+func (service *AlertRuleService) UpdateRuleGroup(ctx context.Context, orgID int64, namespaceUID string, ruleGroup string, intervalSeconds int64, provenance models.Provenance) error {
+	return service.xact.InTransaction(ctx, func(ctx context.Context) error {
+		query := &models.ListAlertRulesQuery{
+			OrgID:         orgID,
+			NamespaceUIDs: []string{namespaceUID},
+			RuleGroup:     ruleGroup,
+		}
+		if err := service.ruleStore.ListAlertRules(ctx, query); err != nil {
+			return err
+		}
+		if len(query.Result) == 0 {
+			return models.ErrAlertRuleNotFound
+		}
+		provenances, err := service.provenanceStore.GetProvenances(ctx, orgID, (&models.AlertRule{}).ResourceType())
+		if err != nil {
+			return err
+		}
+		updates := make([]store.UpdateRule, 0, len(query.Result))
+		for _, rule := range query.Result {
+			storedProvenance, ok := provenances[rule.ResourceID()]
+			if !ok {
+				storedProvenance = models.ProvenanceNone
+			}
+			if !models.CanUpdateProvenance(storedProvenance, provenance) {
+				return fmt.Errorf("%w: cannot update the group of rule '%s' with provenance '%s' with provenance '%s'", ErrProvenanceMismatch, rule.UID, storedProvenance, provenance)
+			}
+			if rule.IntervalSeconds == intervalSeconds {
+				continue
+			}
+			newRule := *rule
+			newRule.IntervalSeconds = intervalSeconds
+			updates = append(updates, store.UpdateRule{Existing: rule, New: newRule})
+		}
+		return service.ruleStore.UpdateAlertRules(ctx, updates)
+	})
+}
+
+// getRuleGroupInterval returns the interval of the rules of the group, or the default interval if it has no rules.
+func (service *AlertRuleService) getRuleGroupInterval(ctx context.Context, orgID int64, namespaceUID string, ruleGroup string) (int64, error) {
+	query := &models.ListAlertRulesQuery{
+		OrgID:         orgID,
+		NamespaceUIDs: []string{namespaceUID},
+		RuleGroup:     ruleGroup,
+	}
+	if err := service.ruleStore.ListAlertRules(ctx, query); err != nil {
+		return 0, err
+	}
+	if len(query.Result) == 0 {
+		return int64(service.defaultInterval.Seconds()), nil
+	}
+	return query.Result[0].IntervalSeconds, nil
+}
+
+// validateAlertRule checks the fields that the store does not validate.
+func validateAlertRule(rule models.AlertRule) error {
+	if rule.NamespaceUID == "" {
+		return fmt.Errorf("%w: missing folder UID", ErrValidation)
+	}
+	if rule.RuleGroup == "" {
+		return fmt.Errorf("%w: missing rule group", ErrValidation)
+	}
+	if rule.Title == "" {
+		return fmt.Errorf("%w: missing title", ErrValidation)
+	}
+	found := false
+	for _, q := range rule.Data {
+		if q.RefID == rule.Condition {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: condition %q does not refer to any query or expression", ErrValidation, rule.Condition)
+	}
+	if _, err := models.NoDataStateFromString(string(rule.NoDataState)); err != nil {
+		return fmt.Errorf("%w: %s", ErrValidation, err.Error())
+	}
+	if _, err := models.ErrStateFromString(string(rule.ExecErrState)); err != nil {
+		return fmt.Errorf("%w: %s", ErrValidation, err.Error())
+	}
+	return nil
+}
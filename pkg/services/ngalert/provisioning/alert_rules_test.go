@@ -0,0 +1,102 @@
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestAlertRuleService(t *testing.T) {
+	t.Run("service creates rules with the interval of their group", func(t *testing.T) {
+		sut := createAlertRuleServiceSut()
+
+		first, err := sut.CreateAlertRule(context.Background(), createTestRule("first"), models.ProvenanceAPI)
+		require.NoError(t, err)
+		require.NotEmpty(t, first.UID)
+		require.Equal(t, int64(60), first.IntervalSeconds)
+
+		require.NoError(t, sut.UpdateRuleGroup(context.Background(), 1, "folder", "group", 120, models.ProvenanceAPI))
+
+		second, err := sut.CreateAlertRule(context.Background(), createTestRule("second"), models.ProvenanceAPI)
+		require.NoError(t, err)
+		require.Equal(t, int64(120), second.IntervalSeconds)
+
+		_, provenance, err := sut.GetAlertRule(context.Background(), 1, second.UID)
+		require.NoError(t, err)
+		require.Equal(t, models.ProvenanceAPI, provenance)
+	})
+
+	t.Run("service keeps the UID of provisioned rules", func(t *testing.T) {
+		sut := createAlertRuleServiceSut()
+		rule := createTestRule("rule")
+		rule.UID = "provisioned"
+
+		created, err := sut.CreateAlertRule(context.Background(), rule, models.ProvenanceFile)
+		require.NoError(t, err)
+		require.Equal(t, "provisioned", created.UID)
+	})
+
+	t.Run("service rejects invalid rules", func(t *testing.T) {
+		sut := createAlertRuleServiceSut()
+		rule := createTestRule("rule")
+		rule.Condition = "B"
+
+		_, err := sut.CreateAlertRule(context.Background(), rule, models.ProvenanceAPI)
+		require.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("service does not change rules with another provenance", func(t *testing.T) {
+		sut := createAlertRuleServiceSut()
+		rule, err := sut.CreateAlertRule(context.Background(), createTestRule("rule"), models.ProvenanceFile)
+		require.NoError(t, err)
+
+		rule.Title = "updated"
+		_, err = sut.UpdateAlertRule(context.Background(), rule, models.ProvenanceAPI)
+		require.ErrorIs(t, err, ErrProvenanceMismatch)
+
+		err = sut.DeleteAlertRule(context.Background(), 1, rule.UID, models.ProvenanceAPI)
+		require.ErrorIs(t, err, ErrProvenanceMismatch)
+
+		updated, err := sut.UpdateAlertRule(context.Background(), rule, models.ProvenanceFile)
+		require.NoError(t, err)
+		require.Equal(t, "updated", updated.Title)
+		require.Equal(t, int64(2), updated.Version)
+
+		err = sut.UpdateRuleGroup(context.Background(), 1, "folder", "group", 120, models.ProvenanceAPI)
+		require.ErrorIs(t, err, ErrProvenanceMismatch)
+		require.NoError(t, sut.UpdateRuleGroup(context.Background(), 1, "folder", "group", 120, models.ProvenanceFile))
+
+		require.NoError(t, sut.DeleteAlertRule(context.Background(), 1, rule.UID, models.ProvenanceFile))
+		_, _, err = sut.GetAlertRule(context.Background(), 1, rule.UID)
+		require.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+	})
+}
+
+func createAlertRuleServiceSut() *AlertRuleService {
+	return NewAlertRuleService(newFakeRuleStore(), NewFakeProvisioningStore(), newNopTransactionManager(), time.Minute, log.NewNopLogger())
+}
+
+func createTestRule(title string) models.AlertRule {
+	return models.AlertRule{
+		OrgID:        1,
+		Title:        title,
+		Condition:    "A",
+		NamespaceUID: "folder",
+		RuleGroup:    "group",
+		NoDataState:  models.NoData,
+		ExecErrState: models.AlertingErrState,
+		Data: []models.AlertQuery{
+			{
+				RefID:         "A",
+				DatasourceUID: "-100",
+				Model:         json.RawMessage(`{"type": "math", "expression": "2 + 2 > 1"}`),
+			},
+		},
+	}
+}
@@ -132,7 +132,16 @@ func (ecp *ContactPointService) CreateContactPoint(ctx context.Context, orgID in
 		extractedSecrets[k] = encryptedValue
 	}
 
-	contactPoint.UID = util.GenerateShortUID()
+	if contactPoint.UID == "" {
+		contactPoint.UID = util.GenerateShortUID()
+	}
+	for _, receiver := range cfg.AlertmanagerConfig.Receivers {
+		for _, grafanaReceiver := range receiver.GrafanaManagedReceivers {
+			if grafanaReceiver.UID == contactPoint.UID {
+				return apimodels.EmbeddedContactPoint{}, fmt.Errorf("%w: contact point with uid '%s' already exists", ErrValidation, contactPoint.UID)
+			}
+		}
+	}
 	grafanaReceiver := &apimodels.PostableGrafanaReceiver{
 		UID:                   contactPoint.UID,
 		Name:                  contactPoint.Name,
@@ -218,7 +227,7 @@ func (ecp *ContactPointService) UpdateContactPoint(ctx context.Context, orgID in
 	if err != nil {
 		return err
 	}
-	if storedProvenance != provenance && storedProvenance != models.ProvenanceNone {
+	if !models.CanUpdateProvenance(storedProvenance, provenance) {
 		return fmt.Errorf("cannot changed provenance from '%s' to '%s'", storedProvenance, provenance)
 	}
 	// transform to internal model
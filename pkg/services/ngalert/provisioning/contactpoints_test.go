@@ -42,6 +42,19 @@ func TestContactPointService(t *testing.T) {
 		require.Equal(t, "slack", cps[1].Type)
 	})
 
+	t.Run("service keeps the uid of a new contact point", func(t *testing.T) {
+		sut := createContactPointServiceSut(secretsService)
+		newCp := createTestContactPoint()
+		newCp.UID = "provisioned-uid"
+
+		created, err := sut.CreateContactPoint(context.Background(), 1, newCp, models.ProvenanceFile)
+		require.NoError(t, err)
+		require.Equal(t, "provisioned-uid", created.UID)
+
+		_, err = sut.CreateContactPoint(context.Background(), 1, newCp, models.ProvenanceFile)
+		require.ErrorIs(t, err, ErrValidation)
+	})
+
 	t.Run("default provenance of contact points is none", func(t *testing.T) {
 		sut := createContactPointServiceSut(secretsService)
 
@@ -0,0 +1,207 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// ErrMuteTimingNotFound is returned when there is no mute timing with the requested name.
+var ErrMuteTimingNotFound = fmt.Errorf("mute timing not found")
+
+type MuteTimingService struct {
+	config AMConfigStore
+	prov   ProvisioningStore
+	xact   TransactionManager
+	log    log.Logger
+}
+
+func NewMuteTimingService(config AMConfigStore, prov ProvisioningStore, xact TransactionManager, log log.Logger) *MuteTimingService {
+	return &MuteTimingService{
+		config: config,
+		prov:   prov,
+		xact:   xact,
+		log:    log,
+	}
+}
+
+// GetMuteTimings returns the mute timings of the organization with their provenance.
+func (svc *MuteTimingService) GetMuteTimings(ctx context.Context, orgID int64) ([]definitions.MuteTimeInterval, error) {
+	revision, err := getLastConfiguration(ctx, orgID, svc.config)
+	if err != nil {
+		return nil, err
+	}
+	provenances, err := svc.prov.GetProvenances(ctx, orgID, (&definitions.MuteTimeInterval{}).ResourceType())
+	if err != nil {
+		return nil, err
+	}
+	result := make([]definitions.MuteTimeInterval, 0, len(revision.cfg.AlertmanagerConfig.MuteTimeIntervals))
+	for _, interval := range revision.cfg.AlertmanagerConfig.MuteTimeIntervals {
+		result = append(result, definitions.MuteTimeInterval{
+			MuteTimeInterval: interval,
+			Provenance:       provenances[interval.Name],
+		})
+	}
+	return result, nil
+}
+
+// GetMuteTiming returns the mute timing with the name.
+func (svc *MuteTimingService) GetMuteTiming(ctx context.Context, orgID int64, name string) (definitions.MuteTimeInterval, error) {
+	timings, err := svc.GetMuteTimings(ctx, orgID)
+	if err != nil {
+		return definitions.MuteTimeInterval{}, err
+	}
+	for _, timing := range timings {
+		if timing.Name == name {
+			return timing, nil
+		}
+	}
+	return definitions.MuteTimeInterval{}, ErrMuteTimingNotFound
+}
+
+// CreateMuteTiming adds a mute timing, its name must not be used by another mute timing.
+func (svc *MuteTimingService) CreateMuteTiming(ctx context.Context, orgID int64, mt definitions.MuteTimeInterval, p models.Provenance) (definitions.MuteTimeInterval, error) {
+	if err := validateMuteTiming(mt); err != nil {
+		return definitions.MuteTimeInterval{}, err
+	}
+
+	revision, err := getLastConfiguration(ctx, orgID, svc.config)
+	if err != nil {
+		return definitions.MuteTimeInterval{}, err
+	}
+	for _, existing := range revision.cfg.AlertmanagerConfig.MuteTimeIntervals {
+		if existing.Name == mt.Name {
+			return definitions.MuteTimeInterval{}, fmt.Errorf("%w: a mute timing with the name '%s' already exists", ErrValidation, mt.Name)
+		}
+	}
+	revision.cfg.AlertmanagerConfig.MuteTimeIntervals = append(revision.cfg.AlertmanagerConfig.MuteTimeIntervals, mt.MuteTimeInterval)
+
+	err = svc.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if err := svc.saveConfiguration(ctx, orgID, revision); err != nil {
+			return err
+		}
+		return svc.prov.SetProvenance(ctx, &mt, orgID, p)
+	})
+	if err != nil {
+		return definitions.MuteTimeInterval{}, err
+	}
+	mt.Provenance = p
+	return mt, nil
+}
+
+// UpdateMuteTiming replaces the mute timing with the same name.
+func (svc *MuteTimingService) UpdateMuteTiming(ctx context.Context, orgID int64, mt definitions.MuteTimeInterval, p models.Provenance) (definitions.MuteTimeInterval, error) {
+	if err := validateMuteTiming(mt); err != nil {
+		return definitions.MuteTimeInterval{}, err
+	}
+
+	revision, err := getLastConfiguration(ctx, orgID, svc.config)
+	if err != nil {
+		return definitions.MuteTimeInterval{}, err
+	}
+	found := false
+	for i, existing := range revision.cfg.AlertmanagerConfig.MuteTimeIntervals {
+		if existing.Name == mt.Name {
+			revision.cfg.AlertmanagerConfig.MuteTimeIntervals[i] = mt.MuteTimeInterval
+			found = true
+			break
+		}
+	}
+	if !found {
+		return definitions.MuteTimeInterval{}, ErrMuteTimingNotFound
+	}
+
+	err = svc.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if err := svc.checkProvenance(ctx, orgID, &mt, p); err != nil {
+			return err
+		}
+		if err := svc.saveConfiguration(ctx, orgID, revision); err != nil {
+			return err
+		}
+		return svc.prov.SetProvenance(ctx, &mt, orgID, p)
+	})
+	if err != nil {
+		return definitions.MuteTimeInterval{}, err
+	}
+	mt.Provenance = p
+	return mt, nil
+}
+
+// DeleteMuteTiming deletes the mute timing. Mute timings used by notification policies cannot be deleted.
+func (svc *MuteTimingService) DeleteMuteTiming(ctx context.Context, orgID int64, name string, p models.Provenance) error {
+	revision, err := getLastConfiguration(ctx, orgID, svc.config)
+	if err != nil {
+		return err
+	}
+	if revision.cfg.AlertmanagerConfig.Route != nil && isMuteTimingInUse(name, []*definitions.Route{revision.cfg.AlertmanagerConfig.Route}) {
+		return fmt.Errorf("%w: mute timing '%s' is currently used by a notification policy", ErrValidation, name)
+	}
+	intervals := revision.cfg.AlertmanagerConfig.MuteTimeIntervals
+	for i, existing := range intervals {
+		if existing.Name == name {
+			revision.cfg.AlertmanagerConfig.MuteTimeIntervals = append(intervals[:i], intervals[i+1:]...)
+			break
+		}
+	}
+
+	target := &definitions.MuteTimeInterval{}
+	target.Name = name
+	return svc.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if err := svc.checkProvenance(ctx, orgID, target, p); err != nil {
+			return err
+		}
+		if err := svc.saveConfiguration(ctx, orgID, revision); err != nil {
+			return err
+		}
+		return svc.prov.DeleteProvenance(ctx, target, orgID)
+	})
+}
+
+func (svc *MuteTimingService) checkProvenance(ctx context.Context, orgID int64, mt *definitions.MuteTimeInterval, p models.Provenance) error {
+	stored, err := svc.prov.GetProvenance(ctx, mt, orgID)
+	if err != nil {
+		return err
+	}
+	if !models.CanUpdateProvenance(stored, p) {
+		return fmt.Errorf("%w: cannot change provenance from '%s' to '%s'", ErrProvenanceMismatch, stored, p)
+	}
+	return nil
+}
+
+func (svc *MuteTimingService) saveConfiguration(ctx context.Context, orgID int64, revision *cfgRevision) error {
+	serialized, err := SerializeAlertmanagerConfig(*revision.cfg)
+	if err != nil {
+		return err
+	}
+	return svc.config.UpdateAlertmanagerConfiguration(ctx, &models.SaveAlertmanagerConfigurationCmd{
+		AlertmanagerConfiguration: string(serialized),
+		FetchedConfigurationHash:  revision.concurrencyToken,
+		ConfigurationVersion:      revision.version,
+		Default:                   false,
+		OrgID:                     orgID,
+	})
+}
+
+func validateMuteTiming(mt definitions.MuteTimeInterval) error {
+	if mt.Name == "" {
+		return fmt.Errorf("%w: missing name in mute timing", ErrValidation)
+	}
+	return nil
+}
+
+func isMuteTimingInUse(name string, routes []*definitions.Route) bool {
+	for _, route := range routes {
+		for _, mt := range route.MuteTimeIntervals {
+			if mt == name {
+				return true
+			}
+		}
+		if isMuteTimingInUse(name, route.Routes) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package provisioning
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestMuteTimingService(t *testing.T) {
+	t.Run("service creates and returns mute timings", func(t *testing.T) {
+		sut := createMuteTimingServiceSut()
+
+		_, err := sut.CreateMuteTiming(context.Background(), 1, createMuteTiming("weekends"), models.ProvenanceAPI)
+		require.NoError(t, err)
+
+		timings, err := sut.GetMuteTimings(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, timings, 1)
+		require.Equal(t, "weekends", timings[0].Name)
+		require.Equal(t, models.ProvenanceAPI, timings[0].Provenance)
+	})
+
+	t.Run("service rejects mute timings with the same name", func(t *testing.T) {
+		sut := createMuteTimingServiceSut()
+
+		_, err := sut.CreateMuteTiming(context.Background(), 1, createMuteTiming("weekends"), models.ProvenanceAPI)
+		require.NoError(t, err)
+		_, err = sut.CreateMuteTiming(context.Background(), 1, createMuteTiming("weekends"), models.ProvenanceAPI)
+		require.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("service updates mute timings", func(t *testing.T) {
+		sut := createMuteTimingServiceSut()
+		_, err := sut.CreateMuteTiming(context.Background(), 1, createMuteTiming("weekends"), models.ProvenanceAPI)
+		require.NoError(t, err)
+
+		mt := createMuteTiming("weekends")
+		mt.TimeIntervals = append(mt.TimeIntervals, mt.TimeIntervals...)
+		_, err = sut.UpdateMuteTiming(context.Background(), 1, mt, models.ProvenanceAPI)
+		require.NoError(t, err)
+
+		updated, err := sut.GetMuteTiming(context.Background(), 1, "weekends")
+		require.NoError(t, err)
+		require.Len(t, updated.TimeIntervals, 2)
+
+		_, err = sut.UpdateMuteTiming(context.Background(), 1, createMuteTiming("unknown"), models.ProvenanceAPI)
+		require.ErrorIs(t, err, ErrMuteTimingNotFound)
+	})
+
+	t.Run("service does not change mute timings with another provenance", func(t *testing.T) {
+		sut := createMuteTimingServiceSut()
+		_, err := sut.CreateMuteTiming(context.Background(), 1, createMuteTiming("weekends"), models.ProvenanceFile)
+		require.NoError(t, err)
+
+		_, err = sut.UpdateMuteTiming(context.Background(), 1, createMuteTiming("weekends"), models.ProvenanceAPI)
+		require.ErrorIs(t, err, ErrProvenanceMismatch)
+		err = sut.DeleteMuteTiming(context.Background(), 1, "weekends", models.ProvenanceAPI)
+		require.ErrorIs(t, err, ErrProvenanceMismatch)
+
+		require.NoError(t, sut.DeleteMuteTiming(context.Background(), 1, "weekends", models.ProvenanceFile))
+		_, err = sut.GetMuteTiming(context.Background(), 1, "weekends")
+		require.ErrorIs(t, err, ErrMuteTimingNotFound)
+	})
+
+	t.Run("service does not delete mute timings used by a policy", func(t *testing.T) {
+		sut := createMuteTimingServiceSut()
+		_, err := sut.CreateMuteTiming(context.Background(), 1, createMuteTiming("weekends"), models.ProvenanceAPI)
+		require.NoError(t, err)
+
+		revision, err := getLastConfiguration(context.Background(), 1, sut.config)
+		require.NoError(t, err)
+		revision.cfg.AlertmanagerConfig.Route.Routes[0].MuteTimeIntervals = []string{"weekends"}
+		require.NoError(t, sut.saveConfiguration(context.Background(), 1, revision))
+
+		err = sut.DeleteMuteTiming(context.Background(), 1, "weekends", models.ProvenanceAPI)
+		require.ErrorIs(t, err, ErrValidation)
+	})
+}
+
+func createMuteTimingServiceSut() *MuteTimingService {
+	return NewMuteTimingService(newFakeAMConfigStore(), NewFakeProvisioningStore(), newNopTransactionManager(), log.NewNopLogger())
+}
+
+func createMuteTiming(name string) definitions.MuteTimeInterval {
+	return definitions.MuteTimeInterval{
+		MuteTimeInterval: config.MuteTimeInterval{
+			Name: name,
+			TimeIntervals: []timeinterval.TimeInterval{
+				{
+					Weekdays: []timeinterval.WeekdayRange{
+						{InclusiveRange: timeinterval.InclusiveRange{Begin: 0, End: 0}},
+						{InclusiveRange: timeinterval.InclusiveRange{Begin: 6, End: 6}},
+					},
+				},
+			},
+		},
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
 )
 
 // AMStore is a store of Alertmanager configurations.
@@ -25,3 +26,12 @@ type ProvisioningStore interface {
 type TransactionManager interface {
 	InTransaction(ctx context.Context, work func(ctx context.Context) error) error
 }
+
+// RuleStore is a store of alert rules.
+type RuleStore interface {
+	GetAlertRuleByUID(ctx context.Context, query *models.GetAlertRuleByUIDQuery) error
+	ListAlertRules(ctx context.Context, query *models.ListAlertRulesQuery) error
+	InsertAlertRules(ctx context.Context, rule []models.AlertRule) error
+	UpdateAlertRules(ctx context.Context, rule []store.UpdateRule) error
+	DeleteAlertRulesByUID(ctx context.Context, orgID int64, ruleUID ...string) error
+}
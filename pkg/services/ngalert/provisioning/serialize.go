@@ -1,10 +1,12 @@
 package provisioning
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
 )
 
 func DeserializeAlertmanagerConfig(config []byte) (*definitions.PostableUserConfig, error) {
@@ -18,3 +20,31 @@ func DeserializeAlertmanagerConfig(config []byte) (*definitions.PostableUserConf
 func SerializeAlertmanagerConfig(config definitions.PostableUserConfig) ([]byte, error) {
 	return json.Marshal(config)
 }
+
+type cfgRevision struct {
+	cfg              *definitions.PostableUserConfig
+	concurrencyToken string
+	version          string
+}
+
+// getLastConfiguration returns the latest Alertmanager configuration of the organization with the hash used to detect concurrent changes.
+func getLastConfiguration(ctx context.Context, orgID int64, store AMConfigStore) (*cfgRevision, error) {
+	q := models.GetLatestAlertmanagerConfigurationQuery{
+		OrgID: orgID,
+	}
+	if err := store.GetLatestAlertmanagerConfiguration(ctx, &q); err != nil {
+		return nil, err
+	}
+	if q.Result == nil {
+		return nil, fmt.Errorf("no alertmanager configuration present in this org")
+	}
+	cfg, err := DeserializeAlertmanagerConfig([]byte(q.Result.AlertmanagerConfiguration))
+	if err != nil {
+		return nil, err
+	}
+	return &cfgRevision{
+		cfg:              cfg,
+		concurrencyToken: q.Result.ConfigurationHash,
+		version:          q.Result.ConfigurationVersion,
+	}, nil
+}
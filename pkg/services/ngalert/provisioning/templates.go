@@ -3,8 +3,12 @@ package provisioning
 import (
 	"context"
 	"fmt"
+	tmpltext "text/template"
+
+	"github.com/prometheus/alertmanager/template"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 )
 
@@ -46,3 +50,82 @@ func (t *TemplateService) GetTemplates(ctx context.Context, orgID int64) (map[st
 
 	return cfg.TemplateFiles, nil
 }
+
+// SetTemplate creates or replaces the template with the same name.
+func (t *TemplateService) SetTemplate(ctx context.Context, orgID int64, tmpl definitions.MessageTemplate, p models.Provenance) (definitions.MessageTemplate, error) {
+	if tmpl.Name == "" {
+		return definitions.MessageTemplate{}, fmt.Errorf("%w: missing name in template", ErrValidation)
+	}
+	if _, err := tmpltext.New(tmpl.Name).Funcs(tmpltext.FuncMap(template.DefaultFuncs)).Parse(tmpl.Template); err != nil {
+		return definitions.MessageTemplate{}, fmt.Errorf("%w: %s", ErrValidation, err.Error())
+	}
+
+	revision, err := getLastConfiguration(ctx, orgID, t.config)
+	if err != nil {
+		return definitions.MessageTemplate{}, err
+	}
+	if revision.cfg.TemplateFiles == nil {
+		revision.cfg.TemplateFiles = map[string]string{}
+	}
+	revision.cfg.TemplateFiles[tmpl.Name] = tmpl.Template
+
+	err = t.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if err := t.checkProvenance(ctx, orgID, &tmpl, p); err != nil {
+			return err
+		}
+		if err := t.saveConfiguration(ctx, orgID, revision); err != nil {
+			return err
+		}
+		return t.prov.SetProvenance(ctx, &tmpl, orgID, p)
+	})
+	if err != nil {
+		return definitions.MessageTemplate{}, err
+	}
+	tmpl.Provenance = p
+	return tmpl, nil
+}
+
+// DeleteTemplate deletes the template with the name.
+func (t *TemplateService) DeleteTemplate(ctx context.Context, orgID int64, name string, p models.Provenance) error {
+	revision, err := getLastConfiguration(ctx, orgID, t.config)
+	if err != nil {
+		return err
+	}
+	delete(revision.cfg.TemplateFiles, name)
+
+	target := &definitions.MessageTemplate{Name: name}
+	return t.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if err := t.checkProvenance(ctx, orgID, target, p); err != nil {
+			return err
+		}
+		if err := t.saveConfiguration(ctx, orgID, revision); err != nil {
+			return err
+		}
+		return t.prov.DeleteProvenance(ctx, target, orgID)
+	})
+}
+
+func (t *TemplateService) checkProvenance(ctx context.Context, orgID int64, tmpl *definitions.MessageTemplate, p models.Provenance) error {
+	stored, err := t.prov.GetProvenance(ctx, tmpl, orgID)
+	if err != nil {
+		return err
+	}
+	if !models.CanUpdateProvenance(stored, p) {
+		return fmt.Errorf("%w: cannot change provenance from '%s' to '%s'", ErrProvenanceMismatch, stored, p)
+	}
+	return nil
+}
+
+func (t *TemplateService) saveConfiguration(ctx context.Context, orgID int64, revision *cfgRevision) error {
+	serialized, err := SerializeAlertmanagerConfig(*revision.cfg)
+	if err != nil {
+		return err
+	}
+	return t.config.UpdateAlertmanagerConfiguration(ctx, &models.SaveAlertmanagerConfigurationCmd{
+		AlertmanagerConfiguration: string(serialized),
+		FetchedConfigurationHash:  revision.concurrencyToken,
+		ConfigurationVersion:      revision.version,
+		Default:                   false,
+		OrgID:                     orgID,
+	})
+}
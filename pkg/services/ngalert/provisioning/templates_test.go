@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/setting"
 	mock "github.com/stretchr/testify/mock"
@@ -76,6 +77,34 @@ func TestTemplateService(t *testing.T) {
 	})
 }
 
+func TestTemplateServiceWrites(t *testing.T) {
+	t.Run("service sets and deletes templates", func(t *testing.T) {
+		sut := NewTemplateService(newFakeAMConfigStore(), NewFakeProvisioningStore(), newNopTransactionManager(), log.NewNopLogger())
+
+		_, err := sut.SetTemplate(context.Background(), 1, definitions.MessageTemplate{Name: "a", Template: `{{ define "a" }}{{ .Status | toUpper }}{{ end }}`}, models.ProvenanceFile)
+		require.NoError(t, err)
+
+		result, err := sut.GetTemplates(context.Background(), 1)
+		require.NoError(t, err)
+		require.Contains(t, result, "a")
+
+		err = sut.DeleteTemplate(context.Background(), 1, "a", models.ProvenanceAPI)
+		require.ErrorIs(t, err, ErrProvenanceMismatch)
+
+		require.NoError(t, sut.DeleteTemplate(context.Background(), 1, "a", models.ProvenanceFile))
+		result, err = sut.GetTemplates(context.Background(), 1)
+		require.NoError(t, err)
+		require.NotContains(t, result, "a")
+	})
+
+	t.Run("service rejects invalid templates", func(t *testing.T) {
+		sut := NewTemplateService(newFakeAMConfigStore(), NewFakeProvisioningStore(), newNopTransactionManager(), log.NewNopLogger())
+
+		_, err := sut.SetTemplate(context.Background(), 1, definitions.MessageTemplate{Name: "a", Template: `{{ define "a" }}`}, models.ProvenanceAPI)
+		require.ErrorIs(t, err, ErrValidation)
+	})
+}
+
 func createTemplateServiceSut() *TemplateService {
 	return &TemplateService{
 		config: &MockAMConfigStore{},
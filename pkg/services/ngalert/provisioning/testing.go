@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
 )
 
 const defaultAlertmanagerConfigJSON = `
@@ -144,3 +145,60 @@ func newNopTransactionManager() *nopTransactionManager {
 func (n *nopTransactionManager) InTransaction(ctx context.Context, work func(ctx context.Context) error) error {
 	return work(ctx)
 }
+
+type fakeRuleStore struct {
+	rules map[string]models.AlertRule
+}
+
+func newFakeRuleStore() *fakeRuleStore {
+	return &fakeRuleStore{
+		rules: map[string]models.AlertRule{},
+	}
+}
+
+func (f *fakeRuleStore) GetAlertRuleByUID(ctx context.Context, query *models.GetAlertRuleByUIDQuery) error {
+	rule, ok := f.rules[query.UID]
+	if !ok || rule.OrgID != query.OrgID {
+		return models.ErrAlertRuleNotFound
+	}
+	query.Result = &rule
+	return nil
+}
+
+func (f *fakeRuleStore) ListAlertRules(ctx context.Context, query *models.ListAlertRulesQuery) error {
+	for _, rule := range f.rules {
+		rule := rule
+		if rule.OrgID != query.OrgID || rule.RuleGroup != query.RuleGroup {
+			continue
+		}
+		if len(query.NamespaceUIDs) > 0 && rule.NamespaceUID != query.NamespaceUIDs[0] {
+			continue
+		}
+		query.Result = append(query.Result, &rule)
+	}
+	return nil
+}
+
+func (f *fakeRuleStore) InsertAlertRules(ctx context.Context, rules []models.AlertRule) error {
+	for _, rule := range rules {
+		rule.Version = 1
+		f.rules[rule.UID] = rule
+	}
+	return nil
+}
+
+func (f *fakeRuleStore) UpdateAlertRules(ctx context.Context, rules []store.UpdateRule) error {
+	for _, update := range rules {
+		rule := update.New
+		rule.Version = update.Existing.Version + 1
+		f.rules[rule.UID] = rule
+	}
+	return nil
+}
+
+func (f *fakeRuleStore) DeleteAlertRulesByUID(ctx context.Context, orgID int64, ruleUID ...string) error {
+	for _, uid := range ruleUID {
+		delete(f.rules, uid)
+	}
+	return nil
+}
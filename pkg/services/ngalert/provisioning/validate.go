@@ -3,3 +3,6 @@ package provisioning
 import "fmt"
 
 var ErrValidation = fmt.Errorf("invalid object specification")
+
+// ErrProvenanceMismatch is returned when a provisioned object is changed through another mechanism than the one that provisioned it.
+var ErrProvenanceMismatch = fmt.Errorf("the object is provisioned and cannot be changed with this provenance")
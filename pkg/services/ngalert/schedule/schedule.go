@@ -19,6 +19,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/sender"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/writer"
 
 	"github.com/benbjohnson/clock"
 	"golang.org/x/sync/errgroup"
@@ -97,6 +98,13 @@ type schedule struct {
 	adminConfigPollInterval time.Duration
 	disabledOrgs            map[int64]struct{}
 	minRuleInterval         time.Duration
+
+	// sharder splits the evaluation of the rules between the replicas, nil when every replica evaluates all rules.
+	sharder *ruleSharder
+	// handedOff holds the keys of the rules handed off to another replica whose routines are stopping.
+	handedOff sync.Map
+
+	recordingWriter writer.Writer
 }
 
 // SchedulerCfg is the scheduler configuration.
@@ -117,6 +125,10 @@ type SchedulerCfg struct {
 	AdminConfigPollInterval time.Duration
 	DisabledOrgs            map[int64]struct{}
 	MinRuleInterval         time.Duration
+	// Membership enables the sharding of the rule evaluation between the replicas it lists.
+	Membership Membership
+	// RecordingWriter writes the results of the recording rules, they are not evaluated when it is nil.
+	RecordingWriter writer.Writer
 }
 
 // NewScheduler returns a new schedule.
@@ -148,6 +160,10 @@ func NewScheduler(cfg SchedulerCfg, expressionService *expr.Service, appURL *url
 		adminConfigPollInterval: cfg.AdminConfigPollInterval,
 		disabledOrgs:            cfg.DisabledOrgs,
 		minRuleInterval:         cfg.MinRuleInterval,
+		recordingWriter:         cfg.RecordingWriter,
+	}
+	if cfg.Membership != nil {
+		sch.sharder = newRuleSharder(cfg.Membership, cfg.Logger)
 	}
 	return &sch
 }
@@ -323,6 +339,55 @@ func (sch *schedule) DeleteAlertRule(key models.AlertRuleKey) {
 	ruleInfo.stop()
 }
 
+// handOffAlertRule stops the evaluation of a rule that is now evaluated by another replica.
+// Unlike DeleteAlertRule, the alerts of the rule are not resolved.
+func (sch *schedule) handOffAlertRule(key models.AlertRuleKey) bool {
+	ruleInfo, ok := sch.registry.del(key)
+	if !ok {
+		return false
+	}
+	sch.log.Info("alert rule handed off to another replica", "uid", key.UID, "org_id", key.OrgID)
+	sch.handedOff.Store(key, struct{}{})
+	ruleInfo.stop()
+	return true
+}
+
+// refreshReadOnlyStates reads the states of the rules evaluated by other replicas from the database, so that
+// they are served by the API of this replica too, and removes the states of the rules that no longer exist.
+func (sch *schedule) refreshReadOnlyStates(ctx context.Context, group *errgroup.Group, keys []models.AlertRuleKey, stale map[models.AlertRuleKey]struct{}) {
+	for key := range stale {
+		// a rule evaluated by this replica now is restored by its routine.
+		if !sch.registry.exists(key) {
+			sch.stateManager.RemoveByRuleUID(key.OrgID, key.UID)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	group.Go(func() error {
+		for _, key := range keys {
+			if ctx.Err() != nil {
+				return nil
+			}
+			sch.stateManager.WarmRule(ctx, key)
+		}
+		return nil
+	})
+}
+
+// keepStatesOnStop returns true when the rule routine stops because the rule is handed off to another replica,
+// or because the scheduler stops and the other replicas take over its rules.
+func (sch *schedule) keepStatesOnStop(key models.AlertRuleKey) bool {
+	if sch.sharder == nil {
+		return false
+	}
+	if _, ok := sch.handedOff.LoadAndDelete(key); ok {
+		return true
+	}
+	// the rule is still registered when the routine is stopped by the scheduler stopping.
+	return sch.registry.exists(key)
+}
+
 func (sch *schedule) adminConfigSync(ctx context.Context) error {
 	for {
 		select {
@@ -346,6 +411,8 @@ func (sch *schedule) adminConfigSync(ctx context.Context) error {
 
 func (sch *schedule) schedulePeriodic(ctx context.Context) error {
 	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+	// readOnlyRules are the rules evaluated by other replicas, their states are only read from the database.
+	readOnlyRules := make(map[models.AlertRuleKey]struct{})
 	for {
 		select {
 		case tick := <-sch.ticker.C:
@@ -365,6 +432,12 @@ func (sch *schedule) schedulePeriodic(ctx context.Context) error {
 			alertRules := sch.getAlertRules(ctx, disabledOrgs)
 			sch.log.Debug("alert rules fetched", "count", len(alertRules), "disabled_orgs", disabledOrgs)
 
+			var ring *hashRing
+			if sch.sharder != nil {
+				ring = sch.sharder.refresh()
+				sch.metrics.ShardReplicas.Set(float64(ring.replicas))
+			}
+
 			// registeredDefinitions is a map used for finding deleted alert rules
 			// initially it is assigned to all known alert rules from the previous cycle
 			// each alert rule found also in this cycle is removed
@@ -378,9 +451,30 @@ func (sch *schedule) schedulePeriodic(ctx context.Context) error {
 			}
 
 			readyToRun := make([]readyToRunItem, 0)
+			ownedRules := 0
+			staleRules := readOnlyRules
+			readOnlyRules = make(map[models.AlertRuleKey]struct{})
+			toRefresh := make([]models.AlertRuleKey, 0)
 			for _, item := range alertRules {
 				key := item.GetKey()
 				itemVersion := item.Version
+
+				if ring != nil && !ring.owns(key) {
+					// the rule is evaluated by another replica, stop evaluating it here if it was until now.
+					handedOff := sch.handOffAlertRule(key)
+					delete(registeredDefinitions, key)
+					delete(staleRules, key)
+					readOnlyRules[key] = struct{}{}
+					// the states saved by the other replica are read at the interval of the rule. The states of a rule
+					// that was just handed off are removed by its routine and read at the next interval.
+					itemFrequency := item.IntervalSeconds / int64(sch.baseInterval.Seconds())
+					if !handedOff && itemFrequency > 0 && tickNum%itemFrequency == 0 {
+						toRefresh = append(toRefresh, key)
+					}
+					continue
+				}
+				ownedRules++
+
 				ruleInfo, newRoutine := sch.registry.getOrCreateInfo(ctx, key)
 
 				// enforce minimum evaluation interval
@@ -393,6 +487,10 @@ func (sch *schedule) schedulePeriodic(ctx context.Context) error {
 
 				if newRoutine && !invalidInterval {
 					dispatcherGroup.Go(func() error {
+						if sch.sharder != nil {
+							// the rule may have been evaluated by another replica, resume from the states it saved.
+							sch.stateManager.WarmRule(ruleInfo.ctx, key)
+						}
 						return sch.ruleRoutine(ruleInfo.ctx, key, ruleInfo.evalCh, ruleInfo.updateCh)
 					})
 				}
@@ -413,6 +511,11 @@ func (sch *schedule) schedulePeriodic(ctx context.Context) error {
 				delete(registeredDefinitions, key)
 			}
 
+			if ring != nil {
+				sch.metrics.ShardOwnedRules.Set(float64(ownedRules))
+				sch.refreshReadOnlyStates(ctx, dispatcherGroup, toRefresh, staleRules)
+			}
+
 			var step int64 = 0
 			if len(readyToRun) > 0 {
 				step = sch.baseInterval.Nanoseconds() / int64(len(readyToRun))
@@ -438,13 +541,17 @@ func (sch *schedule) schedulePeriodic(ctx context.Context) error {
 		case <-ctx.Done():
 			waitErr := dispatcherGroup.Wait()
 
-			orgIds, err := sch.instanceStore.FetchOrgIds(ctx)
-			if err != nil {
-				sch.log.Error("unable to fetch orgIds", "msg", err.Error())
-			}
+			// when the evaluation is sharded, the states are saved after each evaluation and the cache only holds
+			// the states of the rules evaluated by other replicas, which must not overwrite theirs.
+			if sch.sharder == nil {
+				orgIds, err := sch.instanceStore.FetchOrgIds(ctx)
+				if err != nil {
+					sch.log.Error("unable to fetch orgIds", "msg", err.Error())
+				}
 
-			for _, v := range orgIds {
-				sch.saveAlertStates(ctx, sch.stateManager.GetAll(v))
+				for _, v := range orgIds {
+					sch.saveAlertStates(ctx, sch.stateManager.GetAll(v))
+				}
 			}
 
 			sch.stateManager.Close()
@@ -526,7 +633,44 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 		return q.Result, nil
 	}
 
+	record := func(ctx context.Context, r *models.AlertRule, attempt int64, e *evaluation) error {
+		logger := logger.New("version", r.Version, "attempt", attempt, "now", e.scheduledAt, "metric", r.Record.Metric)
+		if sch.recordingWriter == nil {
+			logger.Debug("skipping the evaluation of the recording rule because recording rules are disabled")
+			return nil
+		}
+		start := sch.clock.Now()
+
+		err := func() error {
+			resp, err := sch.evaluator.QueriesAndExpressionsEval(r.OrgID, r.Data, e.scheduledAt, sch.expressionService)
+			if err != nil {
+				return err
+			}
+			result, ok := resp.Responses[r.Condition]
+			if !ok {
+				return fmt.Errorf("no result for the query or expression %s", r.Condition)
+			}
+			if result.Error != nil {
+				return result.Error
+			}
+			return sch.recordingWriter.Write(ctx, r.Record.Metric, e.scheduledAt, result.Frames, r.Labels)
+		}()
+		dur := sch.clock.Now().Sub(start)
+		evalTotal.Inc()
+		evalDuration.Observe(dur.Seconds())
+		if err != nil {
+			evalTotalFailures.Inc()
+			logger.Error("failed to evaluate recording rule", "duration", dur, "err", err)
+			return err
+		}
+		logger.Debug("recording rule evaluated", "duration", dur)
+		return nil
+	}
+
 	evaluate := func(ctx context.Context, r *models.AlertRule, attempt int64, e *evaluation) error {
+		if r.IsRecording() {
+			return record(ctx, r, attempt, e)
+		}
 		logger := logger.New("version", r.Version, "attempt", attempt, "now", e.scheduledAt)
 		start := sch.clock.Now()
 
@@ -621,7 +765,13 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 				}
 			}()
 		case <-grafanaCtx.Done():
-			clearState()
+			if sch.keepStatesOnStop(key) {
+				// another replica takes over the rule and resumes from the states saved in the database,
+				// the alerts must not be resolved.
+				sch.stateManager.RemoveByRuleUID(key.OrgID, key.UID)
+			} else {
+				clearState()
+			}
 			logger.Debug("stopping alert rule routine")
 			return nil
 		}
@@ -106,7 +106,7 @@ func TestWarmStateCache(t *testing.T) {
 		Metrics:                 testMetrics.GetSchedulerMetrics(),
 		AdminConfigPollInterval: 10 * time.Minute, // do not poll in unit tests.
 	}
-	st := state.NewManager(schedCfg.Logger, testMetrics.GetStateMetrics(), nil, dbstore, dbstore, ng.SQLStore)
+	st := state.NewManager(schedCfg.Logger, testMetrics.GetStateMetrics(), nil, dbstore, dbstore, ng.SQLStore, nil, nil)
 	st.Warm(ctx)
 
 	t.Run("instance cache has expected entries", func(t *testing.T) {
@@ -122,6 +122,50 @@ func TestWarmStateCache(t *testing.T) {
 	})
 }
 
+func TestWarmRuleStateCache(t *testing.T) {
+	evaluationTime, err := time.Parse("2006-01-02", "2021-03-25")
+	require.NoError(t, err)
+	ctx := context.Background()
+	ng, dbstore := tests.SetupTestEnv(t, 1)
+
+	const mainOrgID int64 = 1
+	rule := tests.CreateTestAlertRule(t, ctx, dbstore, 600, mainOrgID)
+	otherRule := tests.CreateTestAlertRule(t, ctx, dbstore, 600, mainOrgID)
+
+	for _, r := range []*models.AlertRule{rule, otherRule} {
+		err := dbstore.SaveAlertInstance(ctx, &models.SaveAlertInstanceCommand{
+			RuleOrgID:         r.OrgID,
+			RuleUID:           r.UID,
+			Labels:            models.InstanceLabels{"test1": "testValue1"},
+			State:             models.InstanceStateFiring,
+			LastEvalTime:      evaluationTime,
+			CurrentStateSince: evaluationTime.Add(-1 * time.Minute),
+			CurrentStateEnd:   evaluationTime.Add(1 * time.Minute),
+		})
+		require.NoError(t, err)
+	}
+
+	st := state.NewManager(log.New("ngalert cache warming test"), testMetrics.GetStateMetrics(), nil, dbstore, dbstore, ng.SQLStore, nil, nil)
+	// a state left by a previous evaluation of the rule on this replica.
+	st.Put([]*state.State{{
+		AlertRuleUID: rule.UID,
+		OrgID:        rule.OrgID,
+		CacheId:      `[["test2","testValue2"]]`,
+		Labels:       data.Labels{"test2": "testValue2"},
+		State:        eval.Normal,
+	}})
+
+	st.WarmRule(ctx, rule.GetKey())
+
+	states := st.GetStatesForRuleUID(rule.OrgID, rule.UID)
+	require.Len(t, states, 1)
+	require.Equal(t, `[["test1","testValue1"]]`, states[0].CacheId)
+	require.Equal(t, eval.Alerting, states[0].State)
+	require.Equal(t, evaluationTime, states[0].LastEvaluationTime)
+	require.Equal(t, map[string]string{"testAnnoKey": "testAnnoValue"}, states[0].Annotations)
+	require.Empty(t, st.GetStatesForRuleUID(otherRule.OrgID, otherRule.UID))
+}
+
 func TestAlertingTicker(t *testing.T) {
 	ctx := context.Background()
 	ng, dbstore := tests.SetupTestEnv(t, 1)
@@ -158,7 +202,7 @@ func TestAlertingTicker(t *testing.T) {
 			disabledOrgID: {},
 		},
 	}
-	st := state.NewManager(schedCfg.Logger, testMetrics.GetStateMetrics(), nil, dbstore, dbstore, ng.SQLStore)
+	st := state.NewManager(schedCfg.Logger, testMetrics.GetStateMetrics(), nil, dbstore, dbstore, ng.SQLStore, nil, nil)
 	appUrl := &url.URL{
 		Scheme: "http",
 		Host:   "localhost",
@@ -815,6 +815,72 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 		// TODO needs some mocking/stubbing for Alertmanager and Sender to make sure it was not called
 		t.Skip()
 	})
+
+	t.Run("when the rule is a recording rule", func(t *testing.T) {
+		evalChan := make(chan *evaluation)
+		evalAppliedChan := make(chan time.Time)
+		sch, ruleStore, instanceStore, _, _ := createSchedule(evalAppliedChan)
+		recordingWriter := &fakeWriter{}
+		sch.recordingWriter = recordingWriter
+
+		rule := CreateTestAlertRule(t, ruleStore, 10, rand.Int63(), eval.Alerting)
+		rule.Record = &models.Record{Metric: "test:recorded"}
+		rule.Labels = map[string]string{"team": "x"}
+
+		go func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan struct{}))
+		}()
+
+		expectedTime := time.UnixMicro(rand.Int63())
+		evalChan <- &evaluation{
+			scheduledAt: expectedTime,
+			version:     rule.Version,
+		}
+		waitForTimeChannel(t, evalAppliedChan)
+
+		t.Run("it should write the result", func(t *testing.T) {
+			writes := recordingWriter.getWrites()
+			require.Len(t, writes, 1)
+			require.Equal(t, "test:recorded", writes[0].name)
+			require.Equal(t, expectedTime, writes[0].t)
+			require.Equal(t, rule.Labels, writes[0].labels)
+			require.Len(t, writes[0].frames, 1)
+			value, ok := writes[0].frames[0].Fields[0].ConcreteAt(0)
+			require.True(t, ok)
+			require.Equal(t, 1.0, value)
+		})
+		t.Run("it should not create alerts", func(t *testing.T) {
+			require.Empty(t, sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID))
+			require.Empty(t, instanceStore.RecordedOps)
+		})
+	})
+}
+
+type fakeWrite struct {
+	name   string
+	t      time.Time
+	frames data.Frames
+	labels map[string]string
+}
+
+type fakeWriter struct {
+	mtx    sync.Mutex
+	writes []fakeWrite
+}
+
+func (w *fakeWriter) Write(_ context.Context, name string, t time.Time, frames data.Frames, extraLabels map[string]string) error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.writes = append(w.writes, fakeWrite{name: name, t: t, frames: frames, labels: extraLabels})
+	return nil
+}
+
+func (w *fakeWriter) getWrites() []fakeWrite {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.writes
 }
 
 func TestSchedule_alertRuleInfo(t *testing.T) {
@@ -1032,7 +1098,7 @@ func setupScheduler(t *testing.T, rs store.RuleStore, is store.InstanceStore, ac
 		Metrics:                 m.GetSchedulerMetrics(),
 		AdminConfigPollInterval: 10 * time.Minute, // do not poll in unit tests.
 	}
-	st := state.NewManager(schedCfg.Logger, m.GetStateMetrics(), nil, rs, is, mockstore.NewSQLStoreMock())
+	st := state.NewManager(schedCfg.Logger, m.GetStateMetrics(), nil, rs, is, mockstore.NewSQLStoreMock(), nil, nil)
 	appUrl := &url.URL{
 		Scheme: "http",
 		Host:   "localhost",
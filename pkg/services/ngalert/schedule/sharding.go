@@ -0,0 +1,217 @@
+package schedule
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/cluster"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+// shardTokensPerReplica is the number of positions of each replica on the hash ring.
+// More positions spread the rules more evenly between the replicas.
+const shardTokensPerReplica = 128
+
+// Membership lists the Grafana replicas that share the evaluation of the alert rules.
+type Membership interface {
+	// Self returns the name of this replica.
+	Self() string
+	// Members returns the names of the live replicas.
+	Members() []string
+}
+
+// PeerMembership discovers the replicas through the gossip cluster of the Alertmanagers.
+type PeerMembership struct {
+	peer *cluster.Peer
+}
+
+func NewPeerMembership(peer *cluster.Peer) *PeerMembership {
+	return &PeerMembership{peer: peer}
+}
+
+func (m *PeerMembership) Self() string {
+	return m.peer.Name()
+}
+
+func (m *PeerMembership) Members() []string {
+	peers := m.peer.Peers()
+	members := make([]string, 0, len(peers))
+	for _, p := range peers {
+		members = append(members, p.Name())
+	}
+	return members
+}
+
+// DBMembership discovers the replicas through leases in the database, for the setups
+// without a gossip cluster. Every replica renews its lease on each heartbeat, and the
+// replicas which did not renew it within the timeout are considered gone.
+type DBMembership struct {
+	store     store.ReplicaStore
+	id        string
+	heartbeat time.Duration
+	timeout   time.Duration
+	log       log.Logger
+
+	mtx     sync.RWMutex
+	members []string
+}
+
+func NewDBMembership(replicaStore store.ReplicaStore, id string, heartbeat, timeout time.Duration, logger log.Logger) *DBMembership {
+	return &DBMembership{
+		store:     replicaStore,
+		id:        id,
+		heartbeat: heartbeat,
+		timeout:   timeout,
+		log:       logger,
+		members:   []string{id},
+	}
+}
+
+func (m *DBMembership) Self() string {
+	return m.id
+}
+
+func (m *DBMembership) Members() []string {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	return m.members
+}
+
+// Run renews the lease of the replica until the context is cancelled, then deletes it
+// so that the other replicas take over the rules right away.
+func (m *DBMembership) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.heartbeat)
+	defer ticker.Stop()
+	for {
+		if err := m.refresh(ctx); err != nil {
+			m.log.Error("failed to renew the lease of the replica", "replica", m.id, "err", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			// the context is cancelled, use a new one to release the lease.
+			releaseCtx, cancel := context.WithTimeout(context.Background(), m.heartbeat)
+			defer cancel()
+			if err := m.store.DeleteReplicaLease(releaseCtx, m.id); err != nil {
+				m.log.Warn("failed to release the lease of the replica", "replica", m.id, "err", err)
+			}
+			return nil
+		}
+	}
+}
+
+func (m *DBMembership) refresh(ctx context.Context) error {
+	if err := m.store.RenewReplicaLease(ctx, m.id); err != nil {
+		return err
+	}
+	expiry := store.TimeNow().Add(-m.timeout)
+	members, err := m.store.ListActiveReplicas(ctx, expiry)
+	if err != nil {
+		return err
+	}
+	if err := m.store.DeleteExpiredReplicaLeases(ctx, expiry); err != nil {
+		m.log.Warn("failed to delete the expired leases of the replicas", "err", err)
+	}
+	m.mtx.Lock()
+	m.members = members
+	m.mtx.Unlock()
+	return nil
+}
+
+// hashRing assigns the alert rules to the replicas with consistent hashing, so that
+// only the rules of a joining or leaving replica move to other replicas.
+type hashRing struct {
+	self     string
+	replicas int
+	tokens   []uint64
+	owners   []string
+}
+
+// newHashRing creates the ring of the members, this replica is always part of it.
+func newHashRing(self string, members []string) *hashRing {
+	unique := map[string]struct{}{self: {}}
+	for _, m := range members {
+		unique[m] = struct{}{}
+	}
+
+	r := &hashRing{
+		self:     self,
+		replicas: len(unique),
+		tokens:   make([]uint64, 0, len(unique)*shardTokensPerReplica),
+	}
+	tokenOwners := make(map[uint64]string, len(unique)*shardTokensPerReplica)
+	for m := range unique {
+		for i := 0; i < shardTokensPerReplica; i++ {
+			token := hashString(m + "/" + strconv.Itoa(i))
+			// in the unlikely case of a collision, the smallest name wins on every replica.
+			if owner, ok := tokenOwners[token]; ok && owner < m {
+				continue
+			}
+			tokenOwners[token] = m
+		}
+	}
+	for token := range tokenOwners {
+		r.tokens = append(r.tokens, token)
+	}
+	sort.Slice(r.tokens, func(i, j int) bool { return r.tokens[i] < r.tokens[j] })
+	r.owners = make([]string, 0, len(r.tokens))
+	for _, token := range r.tokens {
+		r.owners = append(r.owners, tokenOwners[token])
+	}
+	return r
+}
+
+// owner returns the replica that evaluates the rule, the one with the first token after the hash of the rule.
+func (r *hashRing) owner(key models.AlertRuleKey) string {
+	h := hashString(strconv.FormatInt(key.OrgID, 10) + "/" + key.UID)
+	i := sort.Search(len(r.tokens), func(i int) bool { return r.tokens[i] >= h })
+	if i == len(r.tokens) {
+		i = 0
+	}
+	return r.owners[i]
+}
+
+func (r *hashRing) owns(key models.AlertRuleKey) bool {
+	return r.owner(key) == r.self
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// ruleSharder keeps the hash ring up to date with the members of the cluster.
+// It is only used by the scheduling loop and is not safe for concurrent use.
+type ruleSharder struct {
+	membership Membership
+	log        log.Logger
+
+	members string
+	ring    *hashRing
+}
+
+func newRuleSharder(membership Membership, logger log.Logger) *ruleSharder {
+	return &ruleSharder{membership: membership, log: logger}
+}
+
+// refresh returns the hash ring of the current members, rebuilding it when they changed.
+func (s *ruleSharder) refresh() *hashRing {
+	members := append([]string{}, s.membership.Members()...)
+	sort.Strings(members)
+	key := strings.Join(members, ",")
+	if s.ring == nil || key != s.members {
+		s.log.Info("rebalancing alert rules between replicas", "self", s.membership.Self(), "replicas", members)
+		s.ring = newHashRing(s.membership.Self(), members)
+		s.members = key
+	}
+	return s.ring
+}
@@ -0,0 +1,260 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+func TestHashRing(t *testing.T) {
+	replicas := []string{"grafana-0", "grafana-1", "grafana-2"}
+	keys := make([]models.AlertRuleKey, 0, 3000)
+	for i := 0; i < cap(keys); i++ {
+		keys = append(keys, models.AlertRuleKey{OrgID: int64(i%5 + 1), UID: fmt.Sprintf("rule-%d", i)})
+	}
+
+	t.Run("every rule is owned by exactly one replica", func(t *testing.T) {
+		rings := make([]*hashRing, 0, len(replicas))
+		for _, r := range replicas {
+			rings = append(rings, newHashRing(r, replicas))
+		}
+		owned := make(map[string]int)
+		for _, key := range keys {
+			owners := 0
+			for _, ring := range rings {
+				if ring.owns(key) {
+					owners++
+					owned[ring.self]++
+				}
+			}
+			require.Equal(t, 1, owners, "rule %v", key)
+		}
+		for _, r := range replicas {
+			require.InDeltaf(t, len(keys)/len(replicas), owned[r], float64(len(keys))*0.1, "replica %s owns %d rules", r, owned[r])
+		}
+	})
+
+	t.Run("only the rules of a joining replica move", func(t *testing.T) {
+		before := newHashRing("grafana-0", replicas)
+		after := newHashRing("grafana-0", append(replicas, "grafana-3"))
+		require.Equal(t, 4, after.replicas)
+		moved := 0
+		for _, key := range keys {
+			if before.owner(key) != after.owner(key) {
+				require.Equal(t, "grafana-3", after.owner(key))
+				moved++
+			}
+		}
+		require.InDelta(t, len(keys)/4, moved, float64(len(keys))*0.1)
+	})
+
+	t.Run("this replica is always part of the ring", func(t *testing.T) {
+		ring := newHashRing("grafana-0", nil)
+		require.Equal(t, 1, ring.replicas)
+		for _, key := range keys[:10] {
+			require.True(t, ring.owns(key))
+		}
+	})
+}
+
+type fakeMembership struct {
+	mtx     sync.Mutex
+	self    string
+	members []string
+}
+
+func (m *fakeMembership) Self() string {
+	return m.self
+}
+
+func (m *fakeMembership) Members() []string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.members
+}
+
+func (m *fakeMembership) set(members ...string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.members = members
+}
+
+func TestRuleSharder(t *testing.T) {
+	membership := &fakeMembership{self: "a", members: []string{"a", "b"}}
+	sharder := newRuleSharder(membership, log.New("test"))
+
+	ring := sharder.refresh()
+	require.Equal(t, 2, ring.replicas)
+
+	membership.set("b", "a")
+	require.Same(t, ring, sharder.refresh(), "the ring should not change when the order of the members does")
+
+	membership.set("a", "b", "c")
+	require.Equal(t, 3, sharder.refresh().replicas)
+}
+
+type fakeReplicaStore struct {
+	mtx      sync.Mutex
+	leases   map[string]time.Time
+	deleted  []string
+	expireAt time.Time
+}
+
+func (f *fakeReplicaStore) RenewReplicaLease(_ context.Context, replicaID string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.leases[replicaID] = store.TimeNow()
+	return nil
+}
+
+func (f *fakeReplicaStore) ListActiveReplicas(_ context.Context, since time.Time) ([]string, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	result := make([]string, 0)
+	for id, heartbeat := range f.leases {
+		if !heartbeat.Before(since) {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeReplicaStore) DeleteReplicaLease(_ context.Context, replicaID string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	delete(f.leases, replicaID)
+	f.deleted = append(f.deleted, replicaID)
+	return nil
+}
+
+func (f *fakeReplicaStore) DeleteExpiredReplicaLeases(_ context.Context, before time.Time) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.expireAt = before
+	return nil
+}
+
+func TestDBMembership(t *testing.T) {
+	now := time.Date(2022, 3, 10, 14, 0, 0, 0, time.UTC)
+	store.TimeNow = func() time.Time { return now }
+	t.Cleanup(func() { store.TimeNow = time.Now })
+
+	replicaStore := &fakeReplicaStore{leases: map[string]time.Time{
+		"b": now.Add(-30 * time.Second),
+		"c": now.Add(-2 * time.Minute),
+	}}
+	m := NewDBMembership(replicaStore, "a", time.Hour, time.Minute, log.New("test"))
+	require.Equal(t, "a", m.Self())
+	require.Equal(t, []string{"a"}, m.Members())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- m.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(m.Members()) == 2
+	}, time.Second, 10*time.Millisecond)
+	require.ElementsMatch(t, []string{"a", "b"}, m.Members())
+
+	cancel()
+	require.NoError(t, waitForErrChannel(t, done))
+	replicaStore.mtx.Lock()
+	defer replicaStore.mtx.Unlock()
+	require.Equal(t, []string{"a"}, replicaStore.deleted)
+	require.Equal(t, now.Add(-time.Minute), replicaStore.expireAt)
+}
+
+func TestSchedule_handOffAlertRule(t *testing.T) {
+	runRoutine := func(t *testing.T, sch *schedule, key models.AlertRuleKey) (*alertRuleInfo, chan error) {
+		sch.stateManager.Put([]*state.State{{AlertRuleUID: key.UID, OrgID: key.OrgID, CacheId: "1", State: eval.Alerting}})
+		info, _ := sch.registry.getOrCreateInfo(context.Background(), key)
+		stopped := make(chan error)
+		go func() {
+			stopped <- sch.ruleRoutine(info.ctx, key, info.evalCh, info.updateCh)
+		}()
+		return info, stopped
+	}
+
+	t.Run("should stop the routine and keep the alerts firing", func(t *testing.T) {
+		sch := setupSchedulerWithFakeStores(t)
+		sch.sharder = newRuleSharder(&fakeMembership{self: "a"}, sch.log)
+		key := generateRuleKey()
+		_, stopped := runRoutine(t, sch, key)
+
+		require.True(t, sch.handOffAlertRule(key))
+
+		require.NoError(t, waitForErrChannel(t, stopped))
+		require.False(t, sch.registry.exists(key))
+		require.Empty(t, sch.stateManager.GetStatesForRuleUID(key.OrgID, key.UID))
+		_, handedOff := sch.handedOff.Load(key)
+		require.False(t, handedOff)
+	})
+
+	t.Run("should keep the states when the scheduler stops", func(t *testing.T) {
+		sch := setupSchedulerWithFakeStores(t)
+		sch.sharder = newRuleSharder(&fakeMembership{self: "a"}, sch.log)
+		key := generateRuleKey()
+		info, stopped := runRoutine(t, sch, key)
+
+		info.stop()
+
+		require.NoError(t, waitForErrChannel(t, stopped))
+		require.True(t, sch.keepStatesOnStop(key))
+	})
+
+	t.Run("should resolve the alerts when the rule is deleted", func(t *testing.T) {
+		sch := setupSchedulerWithFakeStores(t)
+		sch.sharder = newRuleSharder(&fakeMembership{self: "a"}, sch.log)
+		key := generateRuleKey()
+		_, stopped := runRoutine(t, sch, key)
+
+		sch.DeleteAlertRule(key)
+
+		require.NoError(t, waitForErrChannel(t, stopped))
+		require.False(t, sch.keepStatesOnStop(key))
+	})
+
+	t.Run("should do nothing when the rule is not evaluated by this replica", func(t *testing.T) {
+		sch := setupSchedulerWithFakeStores(t)
+		key := generateRuleKey()
+		require.False(t, sch.handOffAlertRule(key))
+		_, handedOff := sch.handedOff.Load(key)
+		require.False(t, handedOff)
+	})
+}
+
+func TestSchedule_refreshReadOnlyStates(t *testing.T) {
+	sch := setupSchedulerWithFakeStores(t)
+	sch.sharder = newRuleSharder(&fakeMembership{self: "a"}, sch.log)
+	rule := models.AlertRuleGen()()
+	sch.ruleStore.(*store.FakeRuleStore).PutRule(context.Background(), rule)
+
+	deleted := generateRuleKey()
+	taken := generateRuleKey()
+	sch.registry.getOrCreateInfo(context.Background(), taken)
+	for _, key := range []models.AlertRuleKey{deleted, taken} {
+		sch.stateManager.Put([]*state.State{{AlertRuleUID: key.UID, OrgID: key.OrgID, CacheId: "1", State: eval.Alerting}})
+	}
+
+	group, ctx := errgroup.WithContext(context.Background())
+	sch.refreshReadOnlyStates(ctx, group, []models.AlertRuleKey{rule.GetKey()}, map[models.AlertRuleKey]struct{}{deleted: {}, taken: {}})
+	require.NoError(t, group.Wait())
+
+	require.Empty(t, sch.stateManager.GetStatesForRuleUID(deleted.OrgID, deleted.UID))
+	require.Len(t, sch.stateManager.GetStatesForRuleUID(taken.OrgID, taken.UID), 1)
+	instanceStore := sch.instanceStore.(*store.FakeInstanceStore)
+	require.Contains(t, instanceStore.RecordedOps, models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID})
+}
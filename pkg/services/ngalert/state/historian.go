@@ -0,0 +1,73 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	// historyQueueSize is the number of evaluations whose transitions wait to be recorded at most,
+	// the transitions of further evaluations are dropped until the historian catches up.
+	historyQueueSize = 1000
+	// historyRecordTimeout is the time the historian has to record the transitions of an evaluation.
+	historyRecordTimeout = 10 * time.Second
+)
+
+// Historian keeps the state transitions of the alert instances.
+type Historian interface {
+	RecordStates(ctx context.Context, transitions []*ngModels.StateTransition) error
+	QueryStates(ctx context.Context, query *ngModels.ListStateHistoryQuery) error
+}
+
+func newStateTransition(alertRule *ngModels.AlertRule, s *State, previousState eval.State, evaluatedAt time.Time) *ngModels.StateTransition {
+	var values map[string]*float64
+	if len(s.Results) > 0 {
+		values = s.Results[len(s.Results)-1].Values
+	}
+	return &ngModels.StateTransition{
+		OrgID:         alertRule.OrgID,
+		RuleUID:       alertRule.UID,
+		RuleTitle:     alertRule.Title,
+		Labels:        removePrivateLabels(s.Labels),
+		Values:        values,
+		PreviousState: previousState.String(),
+		State:         s.State.String(),
+		EvaluatedAt:   evaluatedAt,
+	}
+}
+
+// historyRecord is the transitions of an evaluation of a rule.
+type historyRecord struct {
+	ruleUID     string
+	transitions []*ngModels.StateTransition
+}
+
+// queueStates queues the transitions to be recorded by recordHistory,
+// they are dropped when the queue is full.
+func (st *Manager) queueStates(alertRule *ngModels.AlertRule, transitions []*ngModels.StateTransition) {
+	select {
+	case st.historyQueue <- historyRecord{ruleUID: alertRule.UID, transitions: transitions}:
+	default:
+		st.log.Warn("dropping alert state history, the historian is not keeping up", "alertRuleUID", alertRule.UID, "count", len(transitions))
+	}
+}
+
+// recordHistory records the queued transitions until the manager is closed. The transitions are recorded
+// with their own context, so that they are not lost when the evaluation of the rule is cancelled.
+func (st *Manager) recordHistory() {
+	for {
+		select {
+		case record := <-st.historyQueue:
+			ctx, cancel := context.WithTimeout(context.Background(), historyRecordTimeout)
+			if err := st.historian.RecordStates(ctx, record.transitions); err != nil {
+				st.log.Error("error recording alert state history", "alertRuleUID", record.ruleUID, "count", len(record.transitions), "error", err.Error())
+			}
+			cancel()
+		case <-st.historyStop:
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package historian
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Backend is a state.Historian which runs in the background as long as the alerting service does.
+type Backend interface {
+	state.Historian
+	Run(ctx context.Context) error
+}
+
+var (
+	_ Backend = (*SQLBackend)(nil)
+	_ Backend = (*LokiBackend)(nil)
+)
+
+// New returns the backend selected by the settings, nil when the state history is disabled.
+func New(cfg setting.UnifiedAlertingStateHistorySettings, store *sqlstore.SQLStore, logger log.Logger) (Backend, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	switch cfg.Backend {
+	case setting.StateHistoryBackendLoki:
+		return NewLokiBackend(LokiConfig{
+			URL:               cfg.LokiURL,
+			TenantID:          cfg.LokiTenantID,
+			BasicAuthUser:     cfg.LokiBasicAuthUser,
+			BasicAuthPassword: cfg.LokiBasicAuthPass,
+		}, logger)
+	case setting.StateHistoryBackendSQL, "":
+		return NewSQLBackend(store, cfg.Retention, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown state history backend %q", cfg.Backend)
+	}
+}
+
+// encodeValues formats the values as strings, JSON has no representation of NaN and infinities.
+func encodeValues(values map[string]*float64) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(values))
+	for k, v := range values {
+		if v == nil {
+			result[k] = ""
+			continue
+		}
+		result[k] = strconv.FormatFloat(*v, 'g', -1, 64)
+	}
+	return result
+}
+
+func decodeValues(values map[string]string) (map[string]*float64, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]*float64, len(values))
+	for k, v := range values {
+		if v == "" {
+			result[k] = nil
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %w", k, err)
+		}
+		result[k] = &f
+	}
+	return result, nil
+}
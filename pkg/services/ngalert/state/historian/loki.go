@@ -0,0 +1,322 @@
+package historian
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/pkg/labels"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	lokiPushPath  = "/loki/api/v1/push"
+	lokiQueryPath = "/loki/api/v1/query_range"
+
+	// lokiStreamSource is the value of the "from" label of the streams written by Grafana.
+	lokiStreamSource = "grafana-alerting-state-history"
+	// lokiDefaultQueryLimit is used when the query has no limit, Loki requires one.
+	lokiDefaultQueryLimit = 1000
+	// lokiDefaultQueryRange is used when the query has no start, Loki would only look back one hour.
+	lokiDefaultQueryRange = 30 * 24 * time.Hour
+	lokiRequestTimeout    = 30 * time.Second
+)
+
+type LokiConfig struct {
+	URL               string
+	TenantID          string
+	BasicAuthUser     string
+	BasicAuthPassword string
+}
+
+// LokiBackend pushes the state history to Loki, one stream per rule.
+// The transitions are kept as long as the retention of the Loki instance allows.
+type LokiBackend struct {
+	cfg    LokiConfig
+	url    *url.URL
+	client *http.Client
+	log    log.Logger
+}
+
+func NewLokiBackend(cfg LokiConfig, logger log.Logger) (*LokiBackend, error) {
+	u, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Loki URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid Loki URL %q: the scheme must be http or https", cfg.URL)
+	}
+	return &LokiBackend{
+		cfg:    cfg,
+		url:    u,
+		client: &http.Client{Timeout: lokiRequestTimeout},
+		log:    logger,
+	}, nil
+}
+
+// lokiLine is the log line of a transition, the organization and the rule are stream labels.
+type lokiLine struct {
+	RuleTitle     string            `json:"ruleTitle"`
+	PreviousState string            `json:"previous"`
+	State         string            `json:"current"`
+	Labels        map[string]string `json:"labels"`
+	Values        map[string]string `json:"values,omitempty"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	// Values are pairs of the timestamp in nanoseconds and the log line.
+	Values [][2]string `json:"values"`
+}
+
+type lokiPushRequest struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+type lokiQueryResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string        `json:"resultType"`
+		Result     []*lokiStream `json:"result"`
+	} `json:"data"`
+}
+
+func (h *LokiBackend) RecordStates(ctx context.Context, transitions []*models.StateTransition) error {
+	streams := make(map[string]*lokiStream)
+	req := lokiPushRequest{}
+	for _, t := range transitions {
+		line, err := json.Marshal(lokiLine{
+			RuleTitle:     t.RuleTitle,
+			PreviousState: t.PreviousState,
+			State:         t.State,
+			Labels:        t.Labels,
+			Values:        encodeValues(t.Values),
+		})
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("%d/%s", t.OrgID, t.RuleUID)
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: streamLabels(t.OrgID, t.RuleUID)}
+			streams[key] = stream
+			req.Streams = append(req.Streams, stream)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(t.EvaluatedAt.UnixNano(), 10), string(line)})
+	}
+	if len(req.Streams) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	resp, err := h.do(ctx, http.MethodPost, lokiPushPath, nil, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return checkLokiResponse(resp)
+}
+
+func (h *LokiBackend) QueryStates(ctx context.Context, query *models.ListStateHistoryQuery) error {
+	to := query.To
+	if to.IsZero() {
+		to = timeNow()
+	}
+	from := query.From
+	if from.IsZero() {
+		from = to.Add(-lokiDefaultQueryRange)
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = lokiDefaultQueryLimit
+	}
+
+	params := url.Values{}
+	params.Set("query", buildLogQuery(query))
+	params.Set("start", strconv.FormatInt(from.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(to.UnixNano(), 10))
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("direction", "backward")
+
+	resp, err := h.do(ctx, http.MethodGet, lokiQueryPath, params, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if err := checkLokiResponse(resp); err != nil {
+		return err
+	}
+
+	res := lokiQueryResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("failed to decode Loki response: %w", err)
+	}
+	if res.Status != "success" {
+		return fmt.Errorf("unexpected Loki response status %q", res.Status)
+	}
+
+	result := make([]*models.StateTransition, 0)
+	for _, stream := range res.Data.Result {
+		for _, value := range stream.Values {
+			t, err := fromLokiEntry(stream.Stream, value)
+			if err != nil {
+				return err
+			}
+			// The log query already filters the labels, this only guards against sanitized label names.
+			if query.Matches(t) {
+				result = append(result, t)
+			}
+		}
+	}
+	// Loki sorts within the streams only.
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].EvaluatedAt.After(result[j].EvaluatedAt)
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	query.Result = result
+	return nil
+}
+
+// Run does nothing, Loki applies the retention.
+func (h *LokiBackend) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (h *LokiBackend) do(ctx context.Context, method string, path string, params url.Values, body io.Reader) (*http.Response, error) {
+	u := *h.url
+	u.Path += path
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if h.cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", h.cfg.TenantID)
+	}
+	if h.cfg.BasicAuthUser != "" || h.cfg.BasicAuthPassword != "" {
+		req.SetBasicAuth(h.cfg.BasicAuthUser, h.cfg.BasicAuthPassword)
+	}
+	return h.client.Do(req)
+}
+
+func checkLokiResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if len(msg) == 0 {
+		return fmt.Errorf("unexpected Loki response status code %d", resp.StatusCode)
+	}
+	return fmt.Errorf("unexpected Loki response status code %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+func streamLabels(orgID int64, ruleUID string) map[string]string {
+	return map[string]string{
+		"from":    lokiStreamSource,
+		"orgID":   strconv.FormatInt(orgID, 10),
+		"ruleUID": ruleUID,
+	}
+}
+
+// buildLogQuery selects the streams of the organization and filters the labels of the parsed log lines.
+func buildLogQuery(query *models.ListStateHistoryQuery) string {
+	b := strings.Builder{}
+	b.WriteString(fmt.Sprintf(`{from=%q,orgID="%d"`, lokiStreamSource, query.OrgID))
+	if query.RuleUID != "" {
+		b.WriteString(fmt.Sprintf(`,ruleUID=%q`, query.RuleUID))
+	}
+	if query.RuleUIDs != nil {
+		uids := query.SortedRuleUIDs()
+		for i, uid := range uids {
+			uids[i] = regexp.QuoteMeta(uid)
+		}
+		b.WriteString(fmt.Sprintf(`,ruleUID=~%q`, strings.Join(uids, "|")))
+	}
+	b.WriteString("}")
+	if len(query.Matchers) == 0 {
+		return b.String()
+	}
+	b.WriteString(" | json")
+	for _, m := range query.Matchers {
+		b.WriteString(fmt.Sprintf(" | labels_%s%s%q", sanitizeLabelName(m.Name), lokiMatchOperator(m.Type), m.Value))
+	}
+	return b.String()
+}
+
+func lokiMatchOperator(t labels.MatchType) string {
+	switch t {
+	case labels.MatchNotEqual:
+		return "!="
+	case labels.MatchRegexp:
+		return "=~"
+	case labels.MatchNotRegexp:
+		return "!~"
+	default:
+		return "="
+	}
+}
+
+// sanitizeLabelName replaces the characters the json parser of Loki does not keep in label names.
+func sanitizeLabelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func fromLokiEntry(stream map[string]string, value [2]string) (*models.StateTransition, error) {
+	orgID, err := strconv.ParseInt(stream["orgID"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid orgID label of Loki stream: %w", err)
+	}
+	nanos, err := strconv.ParseInt(value[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp of Loki entry: %w", err)
+	}
+	line := lokiLine{}
+	if err := json.Unmarshal([]byte(value[1]), &line); err != nil {
+		return nil, fmt.Errorf("invalid Loki entry: %w", err)
+	}
+	values, err := decodeValues(line.Values)
+	if err != nil {
+		return nil, err
+	}
+	if line.Labels == nil {
+		line.Labels = map[string]string{}
+	}
+	return &models.StateTransition{
+		OrgID:         orgID,
+		RuleUID:       stream["ruleUID"],
+		RuleTitle:     line.RuleTitle,
+		Labels:        data.Labels(line.Labels),
+		Values:        values,
+		PreviousState: line.PreviousState,
+		State:         line.State,
+		EvaluatedAt:   time.Unix(0, nanos).UTC(),
+	}, nil
+}
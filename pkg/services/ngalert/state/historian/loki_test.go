@@ -0,0 +1,137 @@
+package historian
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestLokiBackend(t *testing.T) {
+	var pushed lokiPushRequest
+	var query map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "tenant", r.Header.Get("X-Scope-OrgID"))
+		user, pass, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", user)
+		require.Equal(t, "pass", pass)
+
+		switch r.URL.Path {
+		case "/loki" + lokiPushPath:
+			require.Equal(t, http.MethodPost, r.Method)
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&pushed))
+			w.WriteHeader(http.StatusNoContent)
+		case "/loki" + lokiQueryPath:
+			query = map[string]string{}
+			for k := range r.URL.Query() {
+				query[k] = r.URL.Query().Get(k)
+			}
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[
+				{"stream":{"from":"grafana-alerting-state-history","orgID":"1","ruleUID":"a"},"values":[
+					["1646920860000000000","{\"ruleTitle\":\"A\",\"previous\":\"Normal\",\"current\":\"Alerting\",\"labels\":{\"team\":\"x\"},\"values\":{\"B\":\"NaN\"}}"]
+				]},
+				{"stream":{"from":"grafana-alerting-state-history","orgID":"1","ruleUID":"b"},"values":[
+					["1646920920000000000","{\"ruleTitle\":\"B\",\"previous\":\"Alerting\",\"current\":\"Normal\",\"labels\":{\"team\":\"x\"}}"],
+					["1646920800000000000","{\"ruleTitle\":\"B\",\"previous\":\"Normal\",\"current\":\"Alerting\",\"labels\":{\"team\":\"x\"}}"]
+				]}
+			]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	h, err := NewLokiBackend(LokiConfig{
+		URL:               server.URL + "/loki/",
+		TenantID:          "tenant",
+		BasicAuthUser:     "user",
+		BasicAuthPassword: "pass",
+	}, log.New("test"))
+	require.NoError(t, err)
+
+	t.Run("pushes a stream per rule", func(t *testing.T) {
+		value := math.Inf(1)
+		evaluatedAt := time.Unix(1646920800, 0)
+		err := h.RecordStates(context.Background(), []*models.StateTransition{
+			{OrgID: 1, RuleUID: "a", RuleTitle: "A", Labels: data.Labels{"team": "x"}, Values: map[string]*float64{"B": &value}, PreviousState: "Normal", State: "Alerting", EvaluatedAt: evaluatedAt},
+			{OrgID: 1, RuleUID: "a", RuleTitle: "A", Labels: data.Labels{"team": "y"}, PreviousState: "Normal", State: "Pending", EvaluatedAt: evaluatedAt},
+			{OrgID: 1, RuleUID: "b", RuleTitle: "B", Labels: data.Labels{}, PreviousState: "Alerting", State: "Normal", EvaluatedAt: evaluatedAt},
+		})
+		require.NoError(t, err)
+		require.Len(t, pushed.Streams, 2)
+		require.Equal(t, map[string]string{"from": lokiStreamSource, "orgID": "1", "ruleUID": "a"}, pushed.Streams[0].Stream)
+		require.Len(t, pushed.Streams[0].Values, 2)
+		require.Equal(t, "1646920800000000000", pushed.Streams[0].Values[0][0])
+		require.JSONEq(t, `{"ruleTitle":"A","previous":"Normal","current":"Alerting","labels":{"team":"x"},"values":{"B":"+Inf"}}`, pushed.Streams[0].Values[0][1])
+		require.Len(t, pushed.Streams[1].Values, 1)
+	})
+
+	t.Run("queries the streams of the organization", func(t *testing.T) {
+		matcher, err := labels.NewMatcher(labels.MatchRegexp, "team", "x|y")
+		require.NoError(t, err)
+		q := &models.ListStateHistoryQuery{
+			OrgID:    1,
+			Matchers: labels.Matchers{matcher},
+			From:     time.Unix(1646920000, 0),
+			To:       time.Unix(1646921000, 0),
+			Limit:    2,
+		}
+		require.NoError(t, h.QueryStates(context.Background(), q))
+		require.Equal(t, map[string]string{
+			"query":     `{from="grafana-alerting-state-history",orgID="1"} | json | labels_team=~"x|y"`,
+			"start":     "1646920000000000000",
+			"end":       "1646921000000000000",
+			"limit":     "2",
+			"direction": "backward",
+		}, query)
+
+		require.Len(t, q.Result, 2)
+		require.Equal(t, "b", q.Result[0].RuleUID)
+		require.Equal(t, "Normal", q.Result[0].State)
+		require.Equal(t, time.Unix(1646920920, 0).UTC(), q.Result[0].EvaluatedAt)
+		require.Nil(t, q.Result[0].Values)
+		require.Equal(t, "a", q.Result[1].RuleUID)
+		require.Equal(t, "A", q.Result[1].RuleTitle)
+		require.Equal(t, int64(1), q.Result[1].OrgID)
+		require.Equal(t, data.Labels{"team": "x"}, q.Result[1].Labels)
+		require.True(t, math.IsNaN(*q.Result[1].Values["B"]))
+	})
+}
+
+func TestBuildLogQuery(t *testing.T) {
+	eq, err := labels.NewMatcher(labels.MatchEqual, "alert.name", "cpu")
+	require.NoError(t, err)
+	neq, err := labels.NewMatcher(labels.MatchNotRegexp, "team", "a.*")
+	require.NoError(t, err)
+
+	require.Equal(t, `{from="grafana-alerting-state-history",orgID="2",ruleUID="abc"}`, buildLogQuery(&models.ListStateHistoryQuery{
+		OrgID:   2,
+		RuleUID: "abc",
+	}))
+	require.Equal(t, `{from="grafana-alerting-state-history",orgID="2",ruleUID=~"a\\.b|c"}`, buildLogQuery(&models.ListStateHistoryQuery{
+		OrgID:    2,
+		RuleUIDs: map[string]struct{}{"c": {}, "a.b": {}},
+	}))
+	require.Equal(t, `{from="grafana-alerting-state-history",orgID="2"} | json | labels_alert_name="cpu" | labels_team!~"a.*"`, buildLogQuery(&models.ListStateHistoryQuery{
+		OrgID:    2,
+		Matchers: labels.Matchers{eq, neq},
+	}))
+}
+
+func TestNewLokiBackend(t *testing.T) {
+	_, err := NewLokiBackend(LokiConfig{URL: "localhost:3100"}, log.New("test"))
+	require.Error(t, err)
+	_, err = NewLokiBackend(LokiConfig{URL: "http://localhost:3100"}, log.New("test"))
+	require.NoError(t, err)
+}
@@ -0,0 +1,262 @@
+package historian
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+const (
+	// sqlCleanupInterval is how often the transitions older than the retention are deleted.
+	sqlCleanupInterval = time.Hour
+	// sqlQueryBatchSize is the number of rows read at once when the transitions are filtered by labels.
+	sqlQueryBatchSize = 1000
+	// sqlInsertBatchSize is the number of transitions inserted at once, it keeps the
+	// number of parameters of the statements below the limits of the databases.
+	sqlInsertBatchSize = 100
+	// sqlMaxRuleUIDs is the largest number of rules filtered in the query, transitions of more rules are
+	// filtered in the rows read in batches.
+	sqlMaxRuleUIDs = 500
+	// sqlMaxScannedRows is the largest number of rows read by a query, the result is
+	// truncated when the transitions matching the query do not fill the limit before.
+	sqlMaxScannedRows = 10 * sqlQueryBatchSize
+)
+
+var timeNow = time.Now
+
+type stateHistoryEntry struct {
+	ID               int64  `xorm:"pk autoincr 'id'"`
+	OrgID            int64  `xorm:"org_id"`
+	RuleUID          string `xorm:"rule_uid"`
+	RuleTitle        string `xorm:"rule_title"`
+	Labels           string `xorm:"labels"`
+	EvaluationValues string `xorm:"evaluation_values"`
+	PreviousState    string `xorm:"previous_state"`
+	State            string `xorm:"state"`
+	// EvaluatedAt is the evaluation time in milliseconds.
+	EvaluatedAt int64 `xorm:"evaluated_at"`
+}
+
+func (stateHistoryEntry) TableName() string {
+	return "alert_state_history"
+}
+
+// SQLBackend keeps the state history in the Grafana database.
+type SQLBackend struct {
+	store     *sqlstore.SQLStore
+	retention time.Duration
+	log       log.Logger
+}
+
+// NewSQLBackend creates a backend that deletes transitions older than the retention, 0 keeps them forever.
+func NewSQLBackend(store *sqlstore.SQLStore, retention time.Duration, logger log.Logger) *SQLBackend {
+	return &SQLBackend{
+		store:     store,
+		retention: retention,
+		log:       logger,
+	}
+}
+
+func (h *SQLBackend) RecordStates(ctx context.Context, transitions []*models.StateTransition) error {
+	entries := make([]*stateHistoryEntry, 0, len(transitions))
+	for _, t := range transitions {
+		entry, err := toStateHistoryEntry(t)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	return h.store.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		for start := 0; start < len(entries); start += sqlInsertBatchSize {
+			end := start + sqlInsertBatchSize
+			if end > len(entries) {
+				end = len(entries)
+			}
+			batch := entries[start:end]
+			if _, err := sess.Insert(&batch); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (h *SQLBackend) QueryStates(ctx context.Context, query *models.ListStateHistoryQuery) error {
+	return h.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		s := strings.Builder{}
+		params := make([]interface{}, 0)
+
+		addToQuery := func(stmt string, p ...interface{}) {
+			s.WriteString(stmt)
+			params = append(params, p...)
+		}
+
+		addToQuery("SELECT * FROM alert_state_history WHERE org_id = ?", query.OrgID)
+		if query.RuleUID != "" {
+			addToQuery(" AND rule_uid = ?", query.RuleUID)
+		}
+		filterRules := query.RuleUIDs != nil && len(query.RuleUIDs) > sqlMaxRuleUIDs
+		if query.RuleUIDs != nil && !filterRules {
+			if len(query.RuleUIDs) == 0 {
+				query.Result = []*models.StateTransition{}
+				return nil
+			}
+			uids := query.SortedRuleUIDs()
+			addToQuery(" AND rule_uid IN (?"+strings.Repeat(",?", len(uids)-1)+")", toParams(uids)...)
+		}
+		if !query.From.IsZero() {
+			addToQuery(" AND evaluated_at >= ?", toMillis(query.From))
+		}
+		if !query.To.IsZero() {
+			addToQuery(" AND evaluated_at <= ?", toMillis(query.To))
+		}
+		addToQuery(" ORDER BY evaluated_at DESC, id DESC")
+
+		// The labels are stored as JSON, the matchers are applied to the rows read in batches.
+		batchSize := sqlQueryBatchSize
+		if len(query.Matchers) == 0 && !filterRules && query.Limit > 0 {
+			batchSize = query.Limit
+		}
+
+		result := make([]*models.StateTransition, 0)
+		query.Truncated = false
+		for offset := 0; ; offset += batchSize {
+			if offset >= sqlMaxScannedRows {
+				query.Truncated = true
+				break
+			}
+			entries := make([]*stateHistoryEntry, 0)
+			stmt := s.String() + " " + h.store.Dialect.LimitOffset(int64(batchSize), int64(offset))
+			if err := sess.SQL(stmt, params...).Find(&entries); err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				t, err := entry.toStateTransition()
+				if err != nil {
+					return err
+				}
+				if !query.Matches(t) {
+					continue
+				}
+				result = append(result, t)
+				if query.Limit > 0 && len(result) >= query.Limit {
+					query.Result = result
+					return nil
+				}
+			}
+			if len(entries) < batchSize {
+				break
+			}
+		}
+		query.Result = result
+		return nil
+	})
+}
+
+// Run deletes the transitions older than the retention until the context is cancelled.
+func (h *SQLBackend) Run(ctx context.Context) error {
+	if h.retention <= 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(sqlCleanupInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := h.deleteExpired(ctx); err != nil {
+			h.log.Error("failed to delete expired alert state history", "error", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (h *SQLBackend) deleteExpired(ctx context.Context) (int64, error) {
+	var affected int64
+	err := h.store.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM alert_state_history WHERE evaluated_at < ?", toMillis(timeNow().Add(-h.retention)))
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	if err == nil && affected > 0 {
+		h.log.Debug("deleted expired alert state history", "count", affected)
+	}
+	return affected, err
+}
+
+func toStateHistoryEntry(t *models.StateTransition) (*stateHistoryEntry, error) {
+	labels, err := json.Marshal(t.Labels)
+	if err != nil {
+		return nil, err
+	}
+	entry := &stateHistoryEntry{
+		OrgID:         t.OrgID,
+		RuleUID:       t.RuleUID,
+		RuleTitle:     t.RuleTitle,
+		Labels:        string(labels),
+		PreviousState: t.PreviousState,
+		State:         t.State,
+		EvaluatedAt:   toMillis(t.EvaluatedAt),
+	}
+	if len(t.Values) > 0 {
+		values, err := json.Marshal(encodeValues(t.Values))
+		if err != nil {
+			return nil, err
+		}
+		entry.EvaluationValues = string(values)
+	}
+	return entry, nil
+}
+
+func (e *stateHistoryEntry) toStateTransition() (*models.StateTransition, error) {
+	t := &models.StateTransition{
+		OrgID:         e.OrgID,
+		RuleUID:       e.RuleUID,
+		RuleTitle:     e.RuleTitle,
+		Labels:        data.Labels{},
+		PreviousState: e.PreviousState,
+		State:         e.State,
+		EvaluatedAt:   time.Unix(0, e.EvaluatedAt*int64(time.Millisecond)).UTC(),
+	}
+	if e.Labels != "" {
+		if err := json.Unmarshal([]byte(e.Labels), &t.Labels); err != nil {
+			return nil, err
+		}
+	}
+	if e.EvaluationValues != "" {
+		values := map[string]string{}
+		if err := json.Unmarshal([]byte(e.EvaluationValues), &values); err != nil {
+			return nil, err
+		}
+		var err error
+		if t.Values, err = decodeValues(values); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func toParams(vals []string) []interface{} {
+	params := make([]interface{}, 0, len(vals))
+	for _, v := range vals {
+		params = append(params, v)
+	}
+	return params
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
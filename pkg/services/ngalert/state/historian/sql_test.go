@@ -0,0 +1,125 @@
+package historian
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestSQLBackend(t *testing.T) {
+	ctx := context.Background()
+	h := NewSQLBackend(sqlstore.InitTestDB(t), 24*time.Hour, log.New("test"))
+
+	now := time.Date(2022, 3, 10, 14, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	value := 2.0
+	nan := math.NaN()
+	require.NoError(t, h.RecordStates(ctx, []*models.StateTransition{
+		{OrgID: 1, RuleUID: "a", RuleTitle: "A", Labels: data.Labels{"team": "x"}, Values: map[string]*float64{"B": &value, "C": &nan}, PreviousState: "Normal", State: "Pending", EvaluatedAt: now.Add(-3 * time.Minute)},
+		{OrgID: 1, RuleUID: "a", RuleTitle: "A", Labels: data.Labels{"team": "x"}, PreviousState: "Pending", State: "Alerting", EvaluatedAt: now.Add(-2 * time.Minute)},
+		{OrgID: 1, RuleUID: "a", RuleTitle: "A", Labels: data.Labels{"team": "y"}, PreviousState: "Normal", State: "Alerting", EvaluatedAt: now.Add(-time.Minute)},
+		{OrgID: 1, RuleUID: "b", RuleTitle: "B", Labels: data.Labels{"team": "x"}, PreviousState: "Normal", State: "Error", EvaluatedAt: now},
+		{OrgID: 2, RuleUID: "c", RuleTitle: "C", Labels: data.Labels{"team": "x"}, PreviousState: "Normal", State: "Alerting", EvaluatedAt: now},
+		{OrgID: 1, RuleUID: "a", RuleTitle: "A", Labels: data.Labels{"team": "x"}, PreviousState: "Normal", State: "Alerting", EvaluatedAt: now.Add(-48 * time.Hour)},
+	}))
+
+	t.Run("returns the transitions of the organization newest first", func(t *testing.T) {
+		q := &models.ListStateHistoryQuery{OrgID: 1}
+		require.NoError(t, h.QueryStates(ctx, q))
+		require.Len(t, q.Result, 5)
+		require.Equal(t, "b", q.Result[0].RuleUID)
+		require.Equal(t, now, q.Result[0].EvaluatedAt)
+		require.Equal(t, "Pending", q.Result[3].State)
+		require.Equal(t, 2.0, *q.Result[3].Values["B"])
+		require.True(t, math.IsNaN(*q.Result[3].Values["C"]))
+	})
+
+	t.Run("filters by rule, labels and time range", func(t *testing.T) {
+		matcher, err := labels.NewMatcher(labels.MatchEqual, "team", "x")
+		require.NoError(t, err)
+		q := &models.ListStateHistoryQuery{
+			OrgID:    1,
+			RuleUID:  "a",
+			Matchers: labels.Matchers{matcher},
+			From:     now.Add(-time.Hour),
+			To:       now,
+		}
+		require.NoError(t, h.QueryStates(ctx, q))
+		require.Len(t, q.Result, 2)
+		require.Equal(t, "Alerting", q.Result[0].State)
+		require.Equal(t, "Pending", q.Result[1].State)
+		require.Equal(t, data.Labels{"team": "x"}, q.Result[1].Labels)
+
+		q.Limit = 1
+		require.NoError(t, h.QueryStates(ctx, q))
+		require.Len(t, q.Result, 1)
+		require.Equal(t, "Alerting", q.Result[0].State)
+	})
+
+	t.Run("filters by the rules before the limit", func(t *testing.T) {
+		q := &models.ListStateHistoryQuery{OrgID: 1, RuleUIDs: map[string]struct{}{"a": {}}, Limit: 2}
+		require.NoError(t, h.QueryStates(ctx, q))
+		require.Len(t, q.Result, 2)
+		require.Equal(t, "a", q.Result[0].RuleUID)
+		require.Equal(t, "a", q.Result[1].RuleUID)
+
+		q.RuleUIDs = map[string]struct{}{}
+		require.NoError(t, h.QueryStates(ctx, q))
+		require.Empty(t, q.Result)
+
+		// too many rules are filtered in the rows read
+		q.RuleUIDs = map[string]struct{}{"b": {}}
+		for i := 0; i < sqlMaxRuleUIDs; i++ {
+			q.RuleUIDs[fmt.Sprintf("unknown-%d", i)] = struct{}{}
+		}
+		q.Limit = 1
+		require.NoError(t, h.QueryStates(ctx, q))
+		require.Len(t, q.Result, 1)
+		require.Equal(t, "b", q.Result[0].RuleUID)
+	})
+
+	t.Run("truncates the result when too many rows are read", func(t *testing.T) {
+		transitions := make([]*models.StateTransition, 0, sqlMaxScannedRows)
+		for i := 0; i < sqlMaxScannedRows; i++ {
+			transitions = append(transitions, &models.StateTransition{OrgID: 3, RuleUID: "d", Labels: data.Labels{"team": "y"}, PreviousState: "Normal", State: "Alerting", EvaluatedAt: now})
+		}
+		require.NoError(t, h.RecordStates(ctx, transitions))
+		require.NoError(t, h.RecordStates(ctx, []*models.StateTransition{
+			{OrgID: 3, RuleUID: "d", Labels: data.Labels{"team": "x"}, PreviousState: "Normal", State: "Alerting", EvaluatedAt: now.Add(-time.Minute)},
+		}))
+
+		matcher, err := labels.NewMatcher(labels.MatchEqual, "team", "x")
+		require.NoError(t, err)
+		q := &models.ListStateHistoryQuery{OrgID: 3, Matchers: labels.Matchers{matcher}, Limit: 10}
+		require.NoError(t, h.QueryStates(ctx, q))
+		require.Empty(t, q.Result)
+		require.True(t, q.Truncated)
+
+		q = &models.ListStateHistoryQuery{OrgID: 3, Limit: 10}
+		require.NoError(t, h.QueryStates(ctx, q))
+		require.Len(t, q.Result, 10)
+		require.False(t, q.Truncated)
+	})
+
+	t.Run("deletes the transitions older than the retention", func(t *testing.T) {
+		deleted, err := h.deleteExpired(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+
+		q := &models.ListStateHistoryQuery{OrgID: 1, RuleUID: "a"}
+		require.NoError(t, h.QueryStates(ctx, q))
+		require.Len(t, q.Result, 3)
+	})
+}
@@ -0,0 +1,34 @@
+package state
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// ImageCapturer captures the image of the panel of an alert rule, which is attached to the notifications of its alerts.
+type ImageCapturer interface {
+	NewImage(ctx context.Context, r *ngModels.AlertRule) (*ngModels.Image, error)
+}
+
+// shouldTakeImage returns true when the alert starts firing, or fires without an image, e.g. after a restart
+// or when the previous screenshot failed.
+func shouldTakeImage(alertRule *ngModels.AlertRule, s *State, previousState eval.State) bool {
+	if alertRule.DashboardUID == nil || alertRule.PanelID == nil {
+		return false
+	}
+	return s.State == eval.Alerting && (previousState != eval.Alerting || s.Image == nil)
+}
+
+// takeImage sets the image of the state, the state is kept without image when the screenshot fails. The capturer
+// bounds the time it waits for the screenshot and caches the failures, so a failing renderer delays the evaluation
+// only once per panel.
+func (st *Manager) takeImage(ctx context.Context, alertRule *ngModels.AlertRule, s *State) {
+	img, err := st.images.NewImage(ctx, alertRule)
+	if err != nil {
+		st.log.Warn("failed to take the image of the alert", "uid", alertRule.UID, "dashboard", *alertRule.DashboardUID, "panel", *alertRule.PanelID, "err", err)
+		return
+	}
+	s.Image = img
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -39,10 +40,20 @@ type Manager struct {
 	ruleStore     store.RuleStore
 	instanceStore store.InstanceStore
 	sqlStore      sqlstore.Store
+	historian     Historian
+	images        ImageCapturer
+	// historyQueue has the transitions waiting to be recorded by the historian.
+	historyQueue chan historyRecord
+	historyStop  chan struct{}
+
+	clock clock.Clock
+	// sandbox is true when the states are only kept in memory, see NewSandboxManager.
+	sandbox bool
 }
 
+// NewManager creates the state manager, the historian and the image capturer are optional.
 func NewManager(logger log.Logger, metrics *metrics.State, externalURL *url.URL, ruleStore store.RuleStore,
-	instanceStore store.InstanceStore, sqlStore sqlstore.Store) *Manager {
+	instanceStore store.InstanceStore, sqlStore sqlstore.Store, historian Historian, images ImageCapturer) *Manager {
 	manager := &Manager{
 		cache:         newCache(logger, metrics, externalURL),
 		quit:          make(chan struct{}),
@@ -52,13 +63,41 @@ func NewManager(logger log.Logger, metrics *metrics.State, externalURL *url.URL,
 		ruleStore:     ruleStore,
 		instanceStore: instanceStore,
 		sqlStore:      sqlStore,
+		historian:     historian,
+		images:        images,
+		clock:         clock.New(),
 	}
 	go manager.recordMetrics()
+	if historian != nil {
+		manager.historyQueue = make(chan historyRecord, historyQueueSize)
+		manager.historyStop = make(chan struct{})
+		go manager.recordHistory()
+	}
 	return manager
 }
 
+// NewSandboxManager creates a state manager that keeps the states in memory only: it does not annotate
+// the state changes, delete stale instances from the database or record metrics. The staleness of the
+// instances is measured with the clock, so that past evaluations can be replayed, e.g. to backtest a rule.
+func NewSandboxManager(logger log.Logger, externalURL *url.URL, clk clock.Clock) *Manager {
+	return &Manager{
+		cache:       newCache(logger, nil, externalURL),
+		quit:        make(chan struct{}),
+		ResendDelay: ResendDelay,
+		log:         logger,
+		clock:       clk,
+		sandbox:     true,
+	}
+}
+
 func (st *Manager) Close() {
+	if st.sandbox {
+		return
+	}
 	st.quit <- struct{}{}
+	if st.historyStop != nil {
+		close(st.historyStop)
+	}
 }
 
 func (st *Manager) Warm(ctx context.Context) {
@@ -99,25 +138,7 @@ func (st *Manager) Warm(ctx context.Context) {
 				st.log.Error("rule not found for instance, ignoring", "rule", entry.RuleUID)
 				continue
 			}
-
-			lbs := map[string]string(entry.Labels)
-			cacheId, err := entry.Labels.StringKey()
-			if err != nil {
-				st.log.Error("error getting cacheId for entry", "msg", err.Error())
-			}
-			stateForEntry := &State{
-				AlertRuleUID:         entry.RuleUID,
-				OrgID:                entry.RuleOrgID,
-				CacheId:              cacheId,
-				Labels:               lbs,
-				State:                translateInstanceState(entry.CurrentState),
-				LastEvaluationString: "",
-				StartsAt:             entry.CurrentStateSince,
-				EndsAt:               entry.CurrentStateEnd,
-				LastEvaluationTime:   entry.LastEvalTime,
-				Annotations:          ruleForEntry.Annotations,
-			}
-			states = append(states, stateForEntry)
+			states = append(states, st.stateFromInstance(entry, ruleForEntry))
 		}
 	}
 
@@ -126,6 +147,50 @@ func (st *Manager) Warm(ctx context.Context) {
 	}
 }
 
+// WarmRule replaces the states of the rule in the cache with the ones saved in the database.
+// It is used when the rule is taken over from another replica, which saved the states after each evaluation.
+func (st *Manager) WarmRule(ctx context.Context, key ngModels.AlertRuleKey) {
+	ruleCmd := ngModels.GetAlertRuleByUIDQuery{OrgID: key.OrgID, UID: key.UID}
+	if err := st.ruleStore.GetAlertRuleByUID(ctx, &ruleCmd); err != nil {
+		st.log.Error("unable to fetch rule to restore its state", "uid", key.UID, "org", key.OrgID, "msg", err.Error())
+		return
+	}
+
+	cmd := ngModels.ListAlertInstancesQuery{
+		RuleOrgID: key.OrgID,
+		RuleUID:   key.UID,
+	}
+	if err := st.instanceStore.ListAlertInstances(ctx, &cmd); err != nil {
+		st.log.Error("unable to fetch previous state", "uid", key.UID, "org", key.OrgID, "msg", err.Error())
+		return
+	}
+
+	st.RemoveByRuleUID(key.OrgID, key.UID)
+	for _, entry := range cmd.Result {
+		st.set(st.stateFromInstance(entry, ruleCmd.Result))
+	}
+}
+
+func (st *Manager) stateFromInstance(entry *ngModels.ListAlertInstancesQueryResult, alertRule *ngModels.AlertRule) *State {
+	lbs := map[string]string(entry.Labels)
+	cacheId, err := entry.Labels.StringKey()
+	if err != nil {
+		st.log.Error("error getting cacheId for entry", "msg", err.Error())
+	}
+	return &State{
+		AlertRuleUID:         entry.RuleUID,
+		OrgID:                entry.RuleOrgID,
+		CacheId:              cacheId,
+		Labels:               lbs,
+		State:                translateInstanceState(entry.CurrentState),
+		LastEvaluationString: "",
+		StartsAt:             entry.CurrentStateSince,
+		EndsAt:               entry.CurrentStateEnd,
+		LastEvaluationTime:   entry.LastEvalTime,
+		Annotations:          alertRule.Annotations,
+	}
+}
+
 func (st *Manager) getOrCreate(ctx context.Context, alertRule *ngModels.AlertRule, result eval.Result) *State {
 	return st.cache.getOrCreate(ctx, alertRule, result)
 }
@@ -151,18 +216,25 @@ func (st *Manager) RemoveByRuleUID(orgID int64, ruleUID string) {
 func (st *Manager) ProcessEvalResults(ctx context.Context, alertRule *ngModels.AlertRule, results eval.Results) []*State {
 	st.log.Debug("state manager processing evaluation results", "uid", alertRule.UID, "resultCount", len(results))
 	var states []*State
+	var transitions []*ngModels.StateTransition
 	processedResults := make(map[string]*State, len(results))
 	for _, result := range results {
-		s := st.setNextState(ctx, alertRule, result)
+		s, previousState := st.setNextState(ctx, alertRule, result)
 		states = append(states, s)
 		processedResults[s.CacheId] = s
+		if previousState != s.State {
+			transitions = append(transitions, newStateTransition(alertRule, s, previousState, result.EvaluatedAt))
+		}
+	}
+	transitions = append(transitions, st.staleResultsHandler(ctx, alertRule, processedResults)...)
+	if st.historian != nil && len(transitions) > 0 {
+		st.queueStates(alertRule, transitions)
 	}
-	st.staleResultsHandler(ctx, alertRule, processedResults)
 	return states
 }
 
-// Set the current state based on evaluation results
-func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRule, result eval.Result) *State {
+// Set the current state based on evaluation results, the state before the evaluation is returned as well
+func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRule, result eval.Result) (*State, eval.State) {
 	currentState := st.getOrCreate(ctx, alertRule, result)
 
 	currentState.LastEvaluationTime = result.EvaluatedAt
@@ -190,15 +262,22 @@ func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRu
 	case eval.Pending: // we do not emit results with this state
 	}
 
+	switch {
+	case currentState.State == eval.Normal:
+		currentState.Image = nil
+	case st.images != nil && shouldTakeImage(alertRule, currentState, oldState):
+		st.takeImage(ctx, alertRule, currentState)
+	}
+
 	// Set Resolved property so the scheduler knows to send a postable alert
 	// to Alertmanager.
 	currentState.Resolved = oldState == eval.Alerting && currentState.State == eval.Normal
 
 	st.set(currentState)
-	if oldState != currentState.State {
+	if oldState != currentState.State && !st.sandbox {
 		go st.annotateState(ctx, alertRule, currentState.Labels, result.EvaluatedAt, currentState.State, oldState)
 	}
-	return currentState
+	return currentState, oldState
 }
 
 func (st *Manager) GetAll(orgID int64) []*State {
@@ -291,32 +370,48 @@ func (st *Manager) annotateState(ctx context.Context, alertRule *ngModels.AlertR
 	}
 }
 
-func (st *Manager) staleResultsHandler(ctx context.Context, alertRule *ngModels.AlertRule, states map[string]*State) {
+// staleResultsHandler returns the transitions of the stale instances that were resolved
+func (st *Manager) staleResultsHandler(ctx context.Context, alertRule *ngModels.AlertRule, states map[string]*State) []*ngModels.StateTransition {
+	var transitions []*ngModels.StateTransition
 	allStates := st.GetStatesForRuleUID(alertRule.OrgID, alertRule.UID)
 	for _, s := range allStates {
 		_, ok := states[s.CacheId]
-		if !ok && isItStale(s.LastEvaluationTime, alertRule.IntervalSeconds) {
+		if !ok && isItStale(st.clock.Now(), s.LastEvaluationTime, alertRule.IntervalSeconds) {
 			st.log.Debug("removing stale state entry", "orgID", s.OrgID, "alertRuleUID", s.AlertRuleUID, "cacheID", s.CacheId)
 			st.cache.deleteEntry(s.OrgID, s.AlertRuleUID, s.CacheId)
-			ilbs := ngModels.InstanceLabels(s.Labels)
-			_, labelsHash, err := ilbs.StringAndHash()
-			if err != nil {
-				st.log.Error("unable to get labelsHash", "error", err.Error(), "orgID", s.OrgID, "alertRuleUID", s.AlertRuleUID)
-			}
-
-			if err = st.instanceStore.DeleteAlertInstance(ctx, s.OrgID, s.AlertRuleUID, labelsHash); err != nil {
-				st.log.Error("unable to delete stale instance from database", "error", err.Error(), "orgID", s.OrgID, "alertRuleUID", s.AlertRuleUID, "cacheID", s.CacheId)
+			if !st.sandbox {
+				st.deleteAlertInstance(ctx, s)
 			}
 
 			if s.State == eval.Alerting {
-				st.annotateState(ctx, alertRule, s.Labels, time.Now(), eval.Normal, s.State)
+				now := st.clock.Now()
+				if !st.sandbox {
+					st.annotateState(ctx, alertRule, s.Labels, now, eval.Normal, s.State)
+				}
+				transition := newStateTransition(alertRule, s, s.State, now)
+				transition.State = eval.Normal.String()
+				transition.Values = nil // resolved without an evaluation
+				transitions = append(transitions, transition)
 			}
 		}
 	}
+	return transitions
+}
+
+func (st *Manager) deleteAlertInstance(ctx context.Context, s *State) {
+	ilbs := ngModels.InstanceLabels(s.Labels)
+	_, labelsHash, err := ilbs.StringAndHash()
+	if err != nil {
+		st.log.Error("unable to get labelsHash", "error", err.Error(), "orgID", s.OrgID, "alertRuleUID", s.AlertRuleUID)
+	}
+
+	if err = st.instanceStore.DeleteAlertInstance(ctx, s.OrgID, s.AlertRuleUID, labelsHash); err != nil {
+		st.log.Error("unable to delete stale instance from database", "error", err.Error(), "orgID", s.OrgID, "alertRuleUID", s.AlertRuleUID, "cacheID", s.CacheId)
+	}
 }
 
-func isItStale(lastEval time.Time, intervalSeconds int64) bool {
-	return lastEval.Add(2 * time.Duration(intervalSeconds) * time.Second).Before(time.Now())
+func isItStale(now time.Time, lastEval time.Time, intervalSeconds int64) bool {
+	return lastEval.Add(2 * time.Duration(intervalSeconds) * time.Second).Before(now)
 }
 
 func removePrivateLabels(labels data.Labels) data.Labels {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+	"github.com/grafana/grafana/pkg/util"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
@@ -37,7 +39,7 @@ func TestDashboardAnnotations(t *testing.T) {
 	_, dbstore := tests.SetupTestEnv(t, 1)
 
 	sqlStore := mockstore.NewSQLStoreMock()
-	st := state.NewManager(log.New("test_stale_results_handler"), testMetrics.GetStateMetrics(), nil, dbstore, dbstore, sqlStore)
+	st := state.NewManager(log.New("test_stale_results_handler"), testMetrics.GetStateMetrics(), nil, dbstore, dbstore, sqlStore, nil, nil)
 
 	fakeAnnoRepo := store.NewFakeAnnotationsRepo()
 	annotations.SetRepository(fakeAnnoRepo)
@@ -1770,7 +1772,7 @@ func TestProcessEvalResults(t *testing.T) {
 
 	for _, tc := range testCases {
 		ss := mockstore.NewSQLStoreMock()
-		st := state.NewManager(log.New("test_state_manager"), testMetrics.GetStateMetrics(), nil, nil, &store.FakeInstanceStore{}, ss)
+		st := state.NewManager(log.New("test_state_manager"), testMetrics.GetStateMetrics(), nil, nil, &store.FakeInstanceStore{}, ss, nil, nil)
 		t.Run(tc.desc, func(t *testing.T) {
 			fakeAnnoRepo := store.NewFakeAnnotationsRepo()
 			annotations.SetRepository(fakeAnnoRepo)
@@ -1881,7 +1883,7 @@ func TestStaleResultsHandler(t *testing.T) {
 	for _, tc := range testCases {
 		ctx := context.Background()
 		sqlStore := mockstore.NewSQLStoreMock()
-		st := state.NewManager(log.New("test_stale_results_handler"), testMetrics.GetStateMetrics(), nil, dbstore, dbstore, sqlStore)
+		st := state.NewManager(log.New("test_stale_results_handler"), testMetrics.GetStateMetrics(), nil, dbstore, dbstore, sqlStore, nil, nil)
 		st.Warm(ctx)
 		existingStatesForRule := st.GetStatesForRuleUID(rule.OrgID, rule.UID)
 
@@ -1901,3 +1903,169 @@ func TestStaleResultsHandler(t *testing.T) {
 		assert.Equal(t, tc.finalStateCount, len(existingStatesForRule))
 	}
 }
+
+type fakeHistorian struct {
+	mtx         sync.Mutex
+	transitions []*models.StateTransition
+}
+
+func (h *fakeHistorian) RecordStates(_ context.Context, transitions []*models.StateTransition) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.transitions = append(h.transitions, transitions...)
+	return nil
+}
+
+func (h *fakeHistorian) QueryStates(_ context.Context, _ *models.ListStateHistoryQuery) error {
+	return nil
+}
+
+func (h *fakeHistorian) Transitions() []*models.StateTransition {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return append([]*models.StateTransition{}, h.transitions...)
+}
+
+func TestStateHistory(t *testing.T) {
+	evaluationTime, err := time.Parse("2006-01-02", "2022-01-01")
+	require.NoError(t, err)
+
+	annotations.SetRepository(store.NewFakeAnnotationsRepo())
+	historian := &fakeHistorian{}
+	st := state.NewManager(log.New("test_state_history"), testMetrics.GetStateMetrics(), nil, nil, &store.FakeInstanceStore{}, mockstore.NewSQLStoreMock(), historian, nil)
+
+	rule := &models.AlertRule{
+		OrgID:           1,
+		Title:           "test_title",
+		UID:             "test_alert_rule_uid",
+		NamespaceUID:    "test_namespace_uid",
+		Labels:          map[string]string{"label": "test"},
+		IntervalSeconds: 10,
+	}
+	value := 42.0
+	for i, s := range []eval.State{eval.Alerting, eval.Alerting, eval.Normal} {
+		_ = st.ProcessEvalResults(context.Background(), rule, eval.Results{
+			eval.Result{
+				Instance:    data.Labels{"instance_label": "test"},
+				State:       s,
+				EvaluatedAt: evaluationTime.Add(time.Duration(i) * 10 * time.Second),
+				Values: map[string]eval.NumberValueCapture{
+					"A": {Var: "A", Value: &value},
+				},
+			},
+		})
+	}
+
+	require.Eventually(t, func() bool {
+		return len(historian.Transitions()) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	transitions := historian.Transitions()
+	sort.Slice(transitions, func(i, j int) bool {
+		return transitions[i].EvaluatedAt.Before(transitions[j].EvaluatedAt)
+	})
+	require.Equal(t, &models.StateTransition{
+		OrgID:     1,
+		RuleUID:   "test_alert_rule_uid",
+		RuleTitle: "test_title",
+		Labels: data.Labels{
+			"alertname":      "test_title",
+			"label":          "test",
+			"instance_label": "test",
+		},
+		Values:        map[string]*float64{"A": &value},
+		PreviousState: "Normal",
+		State:         "Alerting",
+		EvaluatedAt:   evaluationTime,
+	}, transitions[0])
+	require.Equal(t, "Alerting", transitions[1].PreviousState)
+	require.Equal(t, "Normal", transitions[1].State)
+	require.Equal(t, evaluationTime.Add(20*time.Second), transitions[1].EvaluatedAt)
+}
+
+type fakeImageCapturer struct {
+	mtx    sync.Mutex
+	images int
+	err    error
+}
+
+func (c *fakeImageCapturer) NewImage(_ context.Context, _ *models.AlertRule) (*models.Image, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.images++
+	return &models.Image{Token: fmt.Sprintf("token-%d", c.images)}, nil
+}
+
+func TestProcessEvalResults_Images(t *testing.T) {
+	evaluationTime, err := time.Parse("2006-01-02", "2022-01-01")
+	require.NoError(t, err)
+	annotations.SetRepository(store.NewFakeAnnotationsRepo())
+
+	dashboardUID := "dashboard"
+	panelID := int64(2)
+	newRule := func() *models.AlertRule {
+		return &models.AlertRule{
+			OrgID:           1,
+			Title:           "test_title",
+			UID:             util.GenerateShortUID(),
+			NamespaceUID:    "test_namespace_uid",
+			IntervalSeconds: 10,
+			DashboardUID:    &dashboardUID,
+			PanelID:         &panelID,
+		}
+	}
+	process := func(st *state.Manager, rule *models.AlertRule, i int, s eval.State) *state.State {
+		states := st.ProcessEvalResults(context.Background(), rule, eval.Results{
+			eval.Result{
+				Instance:    data.Labels{"instance_label": "test"},
+				State:       s,
+				EvaluatedAt: evaluationTime.Add(time.Duration(i) * 10 * time.Second),
+			},
+		})
+		require.Len(t, states, 1)
+		return states[0]
+	}
+
+	t.Run("takes an image when the alert starts firing", func(t *testing.T) {
+		images := &fakeImageCapturer{}
+		st := state.NewManager(log.New("test_images"), testMetrics.GetStateMetrics(), nil, nil, &store.FakeInstanceStore{}, mockstore.NewSQLStoreMock(), nil, images)
+		rule := newRule()
+
+		require.Nil(t, process(st, rule, 0, eval.Normal).Image)
+		s := process(st, rule, 1, eval.Alerting)
+		require.NotNil(t, s.Image)
+		require.Equal(t, "token-1", s.Image.Token)
+
+		// the image is kept while the alert fires
+		s = process(st, rule, 2, eval.Alerting)
+		require.Equal(t, "token-1", s.Image.Token)
+
+		require.Nil(t, process(st, rule, 3, eval.Normal).Image)
+		require.Equal(t, "token-2", process(st, rule, 4, eval.Alerting).Image.Token)
+	})
+
+	t.Run("retries when the screenshot failed", func(t *testing.T) {
+		images := &fakeImageCapturer{err: errors.New("failed")}
+		st := state.NewManager(log.New("test_images"), testMetrics.GetStateMetrics(), nil, nil, &store.FakeInstanceStore{}, mockstore.NewSQLStoreMock(), nil, images)
+		rule := newRule()
+
+		require.Nil(t, process(st, rule, 0, eval.Alerting).Image)
+		images.mtx.Lock()
+		images.err = nil
+		images.mtx.Unlock()
+		require.Equal(t, "token-1", process(st, rule, 1, eval.Alerting).Image.Token)
+	})
+
+	t.Run("does not take an image when the rule has no panel", func(t *testing.T) {
+		images := &fakeImageCapturer{}
+		st := state.NewManager(log.New("test_images"), testMetrics.GetStateMetrics(), nil, nil, &store.FakeInstanceStore{}, mockstore.NewSQLStoreMock(), nil, images)
+		rule := newRule()
+		rule.PanelID = nil
+
+		require.Nil(t, process(st, rule, 0, eval.Alerting).Image)
+		require.Equal(t, 0, images.images)
+	})
+}
@@ -30,6 +30,8 @@ type State struct {
 	Annotations          map[string]string
 	Labels               data.Labels
 	Error                error
+	// Image is the screenshot of the panel of the rule taken when the alert started firing, nil when there is none.
+	Image *ngModels.Image
 }
 
 type Evaluation struct {
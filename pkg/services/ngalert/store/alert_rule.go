@@ -21,6 +21,9 @@ const AlertRuleMaxTitleLength = 190
 // AlertRuleMaxRuleGroupNameLength is the maximum length of the alert rule group name
 const AlertRuleMaxRuleGroupNameLength = 190
 
+// AlertRuleMaxUIDLength is the maximum length of the alert rule UID
+const AlertRuleMaxUIDLength = 40
+
 type UpdateRuleGroupCmd struct {
 	OrgID           int64
 	NamespaceUID    string
@@ -43,6 +46,7 @@ type RuleStore interface {
 	GetRuleGroups(ctx context.Context, query *ngmodels.ListRuleGroupsQuery) error
 	GetUserVisibleNamespaces(context.Context, int64, *models.SignedInUser) (map[string]*models.Folder, error)
 	GetNamespaceByTitle(context.Context, string, int64, *models.SignedInUser, bool) (*models.Folder, error)
+	GetNamespaceByUID(context.Context, string, int64, *models.SignedInUser, bool) (*models.Folder, error)
 	InsertAlertRules(ctx context.Context, rule []ngmodels.AlertRule) error
 	UpdateAlertRules(ctx context.Context, rule []UpdateRule) error
 }
@@ -116,11 +120,16 @@ func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRu
 		ruleVersions := make([]ngmodels.AlertRuleVersion, 0, len(rules))
 		for i := range rules {
 			r := rules[i]
-			uid, err := GenerateNewAlertRuleUID(sess, r.OrgID, r.Title)
-			if err != nil {
-				return fmt.Errorf("failed to generate UID for alert rule %q: %w", r.Title, err)
+			// provisioned rules keep the UID they were given.
+			if r.UID == "" {
+				uid, err := GenerateNewAlertRuleUID(sess, r.OrgID, r.Title)
+				if err != nil {
+					return fmt.Errorf("failed to generate UID for alert rule %q: %w", r.Title, err)
+				}
+				r.UID = uid
+			} else if err := validateAlertRuleUID(r.UID); err != nil {
+				return err
 			}
-			r.UID = uid
 			r.Version = 1
 			if err := st.validateAlertRule(r); err != nil {
 				return err
@@ -146,6 +155,7 @@ func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRu
 				For:              r.For,
 				Annotations:      r.Annotations,
 				Labels:           r.Labels,
+				Record:           r.Record,
 			})
 		}
 		if len(newRules) > 0 {
@@ -207,6 +217,7 @@ func (st DBstore) UpdateAlertRules(ctx context.Context, rules []UpdateRule) erro
 				For:              r.New.For,
 				Annotations:      r.New.Annotations,
 				Labels:           r.New.Labels,
+				Record:           r.New.Record,
 			})
 		}
 		if len(newRules) > 0 {
@@ -329,21 +340,44 @@ func (st DBstore) GetNamespaceByTitle(ctx context.Context, namespace string, org
 	if err != nil {
 		return nil, err
 	}
-
-	// if access control is disabled, check that the user is allowed to save in the folder.
-	if withCanSave && st.AccessControl.IsDisabled() {
-		g := guardian.New(ctx, folder.Id, orgID, user)
-		if canSave, err := g.CanSave(); err != nil || !canSave {
-			if err != nil {
-				st.Logger.Error("checking can save permission has failed", "userId", user.UserId, "username", user.Login, "namespace", namespace, "orgId", orgID, "error", err)
-			}
-			return nil, ngmodels.ErrCannotEditNamespace
+	if withCanSave {
+		if err := st.checkCanSaveNamespace(ctx, folder, orgID, user); err != nil {
+			return nil, err
 		}
 	}
+	return folder, nil
+}
 
+// GetNamespaceByUID is a handler for retrieving a namespace by its UID. The namespace must be visible to the user.
+func (st DBstore) GetNamespaceByUID(ctx context.Context, uid string, orgID int64, user *models.SignedInUser, withCanSave bool) (*models.Folder, error) {
+	folder, err := st.FolderService.GetFolderByUID(ctx, user, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+	if withCanSave {
+		if err := st.checkCanSaveNamespace(ctx, folder, orgID, user); err != nil {
+			return nil, err
+		}
+	}
 	return folder, nil
 }
 
+// checkCanSaveNamespace checks that the user is allowed to save in the folder when access control is disabled,
+// otherwise the permissions of the user are checked by the handlers.
+func (st DBstore) checkCanSaveNamespace(ctx context.Context, folder *models.Folder, orgID int64, user *models.SignedInUser) error {
+	if !st.AccessControl.IsDisabled() {
+		return nil
+	}
+	g := guardian.New(ctx, folder.Id, orgID, user)
+	if canSave, err := g.CanSave(); err != nil || !canSave {
+		if err != nil {
+			st.Logger.Error("checking can save permission has failed", "userId", user.UserId, "username", user.Login, "namespace", folder.Title, "orgId", orgID, "error", err)
+		}
+		return ngmodels.ErrCannotEditNamespace
+	}
+	return nil
+}
+
 // GetAlertRulesForScheduling returns alert rule info (identifier, interval, version state)
 // that is useful for it's scheduling.
 func (st DBstore) GetAlertRulesForScheduling(ctx context.Context, query *ngmodels.ListAlertRulesQuery) error {
@@ -381,6 +415,28 @@ var GenerateNewAlertRuleUID = func(sess *sqlstore.DBSession, orgID int64, ruleTi
 	return "", ngmodels.ErrAlertRuleFailedGenerateUniqueUID
 }
 
+// validateAlertRuleUID checks that a UID given to an alert rule instead of a generated one is a valid short UID.
+func validateAlertRuleUID(uid string) error {
+	if !util.IsValidShortUID(uid) {
+		return fmt.Errorf("%w: uid %q contains invalid characters", ngmodels.ErrAlertRuleFailedValidation, uid)
+	}
+	if len(uid) > AlertRuleMaxUIDLength {
+		return fmt.Errorf("%w: uid length should not be greater than %d", ngmodels.ErrAlertRuleFailedValidation, AlertRuleMaxUIDLength)
+	}
+	return nil
+}
+
+// ValidateAlertRule applies the validation of InsertAlertRules and UpdateAlertRules, so that a rule can be checked
+// before it is saved.
+func (st DBstore) ValidateAlertRule(alertRule ngmodels.AlertRule) error {
+	if alertRule.UID != "" {
+		if err := validateAlertRuleUID(alertRule.UID); err != nil {
+			return err
+		}
+	}
+	return st.validateAlertRule(alertRule)
+}
+
 // validateAlertRule validates the alert rule interval and organisation.
 func (st DBstore) validateAlertRule(alertRule ngmodels.AlertRule) error {
 	if len(alertRule.Data) == 0 {
@@ -0,0 +1,55 @@
+//go:build integration
+// +build integration
+
+package store_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+)
+
+func TestInsertAlertRules(t *testing.T) {
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	rule := func(uid string) models.AlertRule {
+		return models.AlertRule{
+			UID:       uid,
+			OrgID:     1,
+			Title:     "rule " + uid,
+			Condition: "A",
+			Data: []models.AlertQuery{{
+				RefID:             "A",
+				RelativeTimeRange: models.RelativeTimeRange{From: models.Duration(time.Hour)},
+				Model:             json.RawMessage(`{"datasourceUid": "-100", "type": "math", "expression": "2 + 2 > 1"}`),
+			}},
+			IntervalSeconds: baseIntervalSeconds,
+			NamespaceUID:    "namespace",
+			RuleGroup:       "group",
+			NoDataState:     models.NoData,
+			ExecErrState:    models.AlertingErrState,
+		}
+	}
+
+	t.Run("keeps the given UID", func(t *testing.T) {
+		require.NoError(t, dbstore.InsertAlertRules(ctx, []models.AlertRule{rule("provisioned-rule_1")}))
+		q := models.GetAlertRuleByUIDQuery{OrgID: 1, UID: "provisioned-rule_1"}
+		require.NoError(t, dbstore.GetAlertRuleByUID(ctx, &q))
+		require.Equal(t, "rule provisioned-rule_1", q.Result.Title)
+	})
+
+	t.Run("rejects invalid UIDs", func(t *testing.T) {
+		for _, uid := range []string{"with space", "../rule", strings.Repeat("a", 41)} {
+			err := dbstore.InsertAlertRules(ctx, []models.AlertRule{rule(uid)})
+			require.ErrorIsf(t, err, models.ErrAlertRuleFailedValidation, "uid %s", uid)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+type ImageStore interface {
+	// GetImage returns the image with the token, models.ErrImageNotFound when it does not exist or expired.
+	GetImage(ctx context.Context, token string) (*models.Image, error)
+	// SaveImage creates the image when it has no ID, a token is assigned to it. Otherwise, the image is updated.
+	SaveImage(ctx context.Context, img *models.Image) error
+	// DeleteExpiredImages deletes the images that expired and returns their number.
+	DeleteExpiredImages(ctx context.Context) (int64, error)
+}
+
+func (st DBstore) GetImage(ctx context.Context, token string) (*models.Image, error) {
+	var img models.Image
+	err := st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("token = ? AND expires_at > ?", token, TimeNow().UTC()).Get(&img)
+		if err != nil {
+			return fmt.Errorf("failed to get image: %w", err)
+		}
+		if !exists {
+			return models.ErrImageNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+func (st DBstore) SaveImage(ctx context.Context, img *models.Image) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if img.ID == 0 {
+			token, err := uuid.NewRandom()
+			if err != nil {
+				return fmt.Errorf("failed to create token: %w", err)
+			}
+			img.Token = token.String()
+			img.CreatedAt = TimeNow().UTC()
+			if _, err := sess.Insert(img); err != nil {
+				return fmt.Errorf("failed to insert image: %w", err)
+			}
+			return nil
+		}
+		affected, err := sess.ID(img.ID).Update(img)
+		if err != nil {
+			return fmt.Errorf("failed to update image: %w", err)
+		}
+		if affected == 0 {
+			return models.ErrImageNotFound
+		}
+		return nil
+	})
+}
+
+func (st DBstore) DeleteExpiredImages(ctx context.Context) (int64, error) {
+	var affected int64
+	err := st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		n, err := sess.Where("expires_at < ?", TimeNow().UTC()).Delete(&models.Image{})
+		if err != nil {
+			return fmt.Errorf("failed to delete expired images: %w", err)
+		}
+		affected = n
+		return nil
+	})
+	return affected, err
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// ReplicaStore keeps the leases of the Grafana replicas that share the evaluation of the alert rules.
+type ReplicaStore interface {
+	RenewReplicaLease(ctx context.Context, replicaID string) error
+	ListActiveReplicas(ctx context.Context, since time.Time) ([]string, error)
+	DeleteReplicaLease(ctx context.Context, replicaID string) error
+	DeleteExpiredReplicaLeases(ctx context.Context, before time.Time) error
+}
+
+type schedulerReplica struct {
+	ID        int64  `xorm:"pk autoincr 'id'"`
+	ReplicaID string `xorm:"replica_id"`
+	// LastHeartbeat is the time of the last renewal in Unix seconds.
+	LastHeartbeat int64 `xorm:"last_heartbeat"`
+}
+
+func (schedulerReplica) TableName() string {
+	return "alert_scheduler_replica"
+}
+
+// RenewReplicaLease records that the replica is alive, creating its lease if it does not exist.
+func (st DBstore) RenewReplicaLease(ctx context.Context, replicaID string) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		now := TimeNow().Unix()
+		affected, err := sess.Table("alert_scheduler_replica").Where("replica_id = ?", replicaID).Update(map[string]interface{}{"last_heartbeat": now})
+		if err != nil {
+			return err
+		}
+		if affected > 0 {
+			return nil
+		}
+		_, err = sess.Insert(&schedulerReplica{ReplicaID: replicaID, LastHeartbeat: now})
+		return err
+	})
+}
+
+// ListActiveReplicas returns the replicas which renewed their lease after since.
+func (st DBstore) ListActiveReplicas(ctx context.Context, since time.Time) ([]string, error) {
+	var replicas []string
+	err := st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rows := make([]*schedulerReplica, 0)
+		if err := sess.Where("last_heartbeat >= ?", since.Unix()).Asc("replica_id").Find(&rows); err != nil {
+			return err
+		}
+		replicas = make([]string, 0, len(rows))
+		for _, r := range rows {
+			replicas = append(replicas, r.ReplicaID)
+		}
+		return nil
+	})
+	return replicas, err
+}
+
+// DeleteReplicaLease removes the lease of a replica that is shutting down, so that
+// the other replicas take over its alert rules without waiting for the lease to expire.
+func (st DBstore) DeleteReplicaLease(ctx context.Context, replicaID string) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("DELETE FROM alert_scheduler_replica WHERE replica_id = ?", replicaID)
+		return err
+	})
+}
+
+// DeleteExpiredReplicaLeases removes the leases of the replicas that stopped without deleting theirs.
+func (st DBstore) DeleteExpiredReplicaLeases(ctx context.Context, before time.Time) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("DELETE FROM alert_scheduler_replica WHERE last_heartbeat < ?", before.Unix())
+		return err
+	})
+}
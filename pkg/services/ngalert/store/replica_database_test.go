@@ -0,0 +1,48 @@
+//go:build integration
+// +build integration
+
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+)
+
+func TestReplicaLeases(t *testing.T) {
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	now := time.Unix(1646920800, 0)
+	store.TimeNow = func() time.Time { return now }
+	t.Cleanup(func() { store.TimeNow = time.Now })
+
+	require.NoError(t, dbstore.RenewReplicaLease(ctx, "a"))
+	now = now.Add(time.Minute)
+	require.NoError(t, dbstore.RenewReplicaLease(ctx, "b"))
+
+	replicas, err := dbstore.ListActiveReplicas(ctx, now.Add(-30*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, replicas)
+
+	// renewing an existing lease does not create a new one.
+	require.NoError(t, dbstore.RenewReplicaLease(ctx, "a"))
+	replicas, err = dbstore.ListActiveReplicas(ctx, now.Add(-30*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, replicas)
+
+	require.NoError(t, dbstore.DeleteReplicaLease(ctx, "a"))
+	replicas, err = dbstore.ListActiveReplicas(ctx, time.Unix(0, 0))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, replicas)
+
+	require.NoError(t, dbstore.DeleteExpiredReplicaLeases(ctx, now.Add(time.Second)))
+	replicas, err = dbstore.ListActiveReplicas(ctx, time.Unix(0, 0))
+	require.NoError(t, err)
+	require.Empty(t, replicas)
+}
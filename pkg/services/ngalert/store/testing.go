@@ -267,6 +267,17 @@ func (f *FakeRuleStore) GetNamespaceByTitle(_ context.Context, title string, org
 	return nil, fmt.Errorf("not found")
 }
 
+func (f *FakeRuleStore) GetNamespaceByUID(_ context.Context, uid string, orgID int64, _ *models2.SignedInUser, _ bool) (*models2.Folder, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, folder := range f.Folders[orgID] {
+		if folder.Uid == uid {
+			return folder, nil
+		}
+	}
+	return nil, models2.ErrFolderNotFound
+}
+
 func (f *FakeRuleStore) UpdateAlertRules(_ context.Context, q []UpdateRule) error {
 	f.mtx.Lock()
 	defer f.mtx.Unlock()
@@ -320,6 +331,41 @@ func (f *FakeInstanceStore) DeleteAlertInstance(_ context.Context, _ int64, _, _
 	return nil
 }
 
+// FakeImageStore keeps the images in memory, the tokens are their IDs.
+type FakeImageStore struct {
+	mtx    sync.Mutex
+	Images map[string]*models.Image
+}
+
+func NewFakeImageStore(t *testing.T) *FakeImageStore {
+	t.Helper()
+	return &FakeImageStore{Images: map[string]*models.Image{}}
+}
+
+func (f *FakeImageStore) GetImage(_ context.Context, token string) (*models.Image, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if img, ok := f.Images[token]; ok {
+		return img, nil
+	}
+	return nil, models.ErrImageNotFound
+}
+
+func (f *FakeImageStore) SaveImage(_ context.Context, img *models.Image) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if img.ID == 0 {
+		img.ID = int64(len(f.Images) + 1)
+		img.Token = fmt.Sprintf("%d", img.ID)
+	}
+	f.Images[img.Token] = img
+	return nil
+}
+
+func (f *FakeImageStore) DeleteExpiredImages(_ context.Context) (int64, error) {
+	return 0, nil
+}
+
 func NewFakeAdminConfigStore(t *testing.T) *FakeAdminConfigStore {
 	t.Helper()
 	return &FakeAdminConfigStore{Configs: map[int64]*models.AdminConfiguration{}}
@@ -0,0 +1,87 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/remotewrite"
+)
+
+// Writer writes the results of the recording rules as new series.
+type Writer interface {
+	// Write writes the frames as series of the metric name at time t, with the extra labels added to the labels of the frames.
+	Write(ctx context.Context, name string, t time.Time, frames data.Frames, extraLabels map[string]string) error
+}
+
+type PrometheusConfig struct {
+	URL               string
+	BasicAuthUser     string
+	BasicAuthPassword string
+	Timeout           time.Duration
+}
+
+// PrometheusWriter writes the series to a Prometheus remote write endpoint.
+type PrometheusWriter struct {
+	cfg    PrometheusConfig
+	client *http.Client
+	log    log.Logger
+}
+
+func NewPrometheusWriter(cfg PrometheusConfig, logger log.Logger) (*PrometheusWriter, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote write URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid remote write URL %q: the scheme must be http or https", cfg.URL)
+	}
+	return &PrometheusWriter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		log:    logger,
+	}, nil
+}
+
+func (w *PrometheusWriter) Write(ctx context.Context, name string, t time.Time, frames data.Frames, extraLabels map[string]string) error {
+	series := remotewrite.TimeSeriesFromFramesAt(name, t, extraLabels, frames...)
+	if len(series) == 0 {
+		w.log.Debug("no series to write", "metric", name)
+		return nil
+	}
+
+	body, err := remotewrite.TimeSeriesToBytes(series)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create remote write request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if w.cfg.BasicAuthUser != "" {
+		req.SetBasicAuth(w.cfg.BasicAuthUser, w.cfg.BasicAuthPassword)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send remote write request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		// the error message of the endpoint is usually short, only read the beginning of it.
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected response code %d from remote write endpoint: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	w.log.Debug("wrote series to remote write endpoint", "metric", name, "series", len(series))
+	return nil
+}
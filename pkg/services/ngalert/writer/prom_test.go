@@ -0,0 +1,81 @@
+package writer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestPrometheusWriter(t *testing.T) {
+	now := time.Date(2022, 3, 10, 14, 0, 0, 0, time.UTC)
+	value := 3.0
+	frames := data.Frames{
+		data.NewFrame("", data.NewField("B", data.Labels{"instance": "a"}, []*float64{&value})),
+	}
+
+	t.Run("writes the series to the endpoint", func(t *testing.T) {
+		var written prompb.WriteRequest
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+			require.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+			user, pass, ok := r.BasicAuth()
+			require.True(t, ok)
+			require.Equal(t, "user", user)
+			require.Equal(t, "pass", pass)
+
+			compressed, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			body, err := snappy.Decode(nil, compressed)
+			require.NoError(t, err)
+			require.NoError(t, proto.Unmarshal(body, &written))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		w, err := NewPrometheusWriter(PrometheusConfig{URL: server.URL, BasicAuthUser: "user", BasicAuthPassword: "pass", Timeout: time.Second}, log.New("test"))
+		require.NoError(t, err)
+		require.NoError(t, w.Write(context.Background(), "instance:cpu:avg", now, frames, map[string]string{"team": "x"}))
+
+		require.Len(t, written.Timeseries, 1)
+		require.Equal(t, []prompb.Label{
+			{Name: "instance", Value: "a"},
+			{Name: "team", Value: "x"},
+			{Name: "__name__", Value: "instance:cpu:avg"},
+		}, written.Timeseries[0].Labels)
+		require.Equal(t, []prompb.Sample{{Timestamp: now.UnixNano() / int64(time.Millisecond), Value: 3.0}}, written.Timeseries[0].Samples)
+	})
+
+	t.Run("returns the error of the endpoint", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "out of order sample", http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		w, err := NewPrometheusWriter(PrometheusConfig{URL: server.URL, Timeout: time.Second}, log.New("test"))
+		require.NoError(t, err)
+		err = w.Write(context.Background(), "instance:cpu:avg", now, frames, nil)
+		require.EqualError(t, err, "unexpected response code 400 from remote write endpoint: out of order sample")
+	})
+
+	t.Run("does not send empty requests", func(t *testing.T) {
+		w, err := NewPrometheusWriter(PrometheusConfig{URL: "http://localhost:1", Timeout: time.Second}, log.New("test"))
+		require.NoError(t, err)
+		require.NoError(t, w.Write(context.Background(), "instance:cpu:avg", now, data.Frames{}, nil))
+	})
+
+	t.Run("rejects invalid URLs", func(t *testing.T) {
+		_, err := NewPrometheusWriter(PrometheusConfig{URL: "localhost:9090"}, log.New("test"))
+		require.Error(t, err)
+	})
+}
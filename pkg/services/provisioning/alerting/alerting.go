@@ -0,0 +1,416 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// pollInterval is how often the provisioning files are checked for changes.
+const pollInterval = 10 * time.Second
+
+type AlertRuleService interface {
+	GetAlertRule(ctx context.Context, orgID int64, ruleUID string) (ngmodels.AlertRule, ngmodels.Provenance, error)
+	CreateAlertRule(ctx context.Context, rule ngmodels.AlertRule, provenance ngmodels.Provenance) (ngmodels.AlertRule, error)
+	UpdateAlertRule(ctx context.Context, rule ngmodels.AlertRule, provenance ngmodels.Provenance) (ngmodels.AlertRule, error)
+	DeleteAlertRule(ctx context.Context, orgID int64, ruleUID string, provenance ngmodels.Provenance) error
+	UpdateRuleGroup(ctx context.Context, orgID int64, namespaceUID string, ruleGroup string, intervalSeconds int64, provenance ngmodels.Provenance) error
+}
+
+type ContactPointService interface {
+	GetContactPoints(ctx context.Context, orgID int64) ([]definitions.EmbeddedContactPoint, error)
+	CreateContactPoint(ctx context.Context, orgID int64, contactPoint definitions.EmbeddedContactPoint, provenance ngmodels.Provenance) (definitions.EmbeddedContactPoint, error)
+	UpdateContactPoint(ctx context.Context, orgID int64, contactPoint definitions.EmbeddedContactPoint, provenance ngmodels.Provenance) error
+	DeleteContactPoint(ctx context.Context, orgID int64, uid string) error
+}
+
+type NotificationPolicyService interface {
+	UpdatePolicyTree(ctx context.Context, orgID int64, tree definitions.Route, p ngmodels.Provenance) error
+}
+
+type TemplateService interface {
+	SetTemplate(ctx context.Context, orgID int64, tmpl definitions.MessageTemplate, p ngmodels.Provenance) (definitions.MessageTemplate, error)
+	DeleteTemplate(ctx context.Context, orgID int64, name string, p ngmodels.Provenance) error
+}
+
+type MuteTimingService interface {
+	GetMuteTimings(ctx context.Context, orgID int64) ([]definitions.MuteTimeInterval, error)
+	CreateMuteTiming(ctx context.Context, orgID int64, mt definitions.MuteTimeInterval, p ngmodels.Provenance) (definitions.MuteTimeInterval, error)
+	UpdateMuteTiming(ctx context.Context, orgID int64, mt definitions.MuteTimeInterval, p ngmodels.Provenance) (definitions.MuteTimeInterval, error)
+	DeleteMuteTiming(ctx context.Context, orgID int64, name string, p ngmodels.Provenance) error
+}
+
+type ProvenanceStore interface {
+	GetProvenances(ctx context.Context, orgID int64, resourceType string) (map[string]ngmodels.Provenance, error)
+	DeleteProvenance(ctx context.Context, o ngmodels.Provisionable, org int64) error
+}
+
+type OrgStore interface {
+	GetOrgs(ctx context.Context) ([]int64, error)
+}
+
+// AlertingProvisioner reconciles the alert rules, contact points, notification policies, templates and
+// mute timings of the organizations with the files of a directory. The resources it creates get the file
+// provenance, and the file provisioned resources that are removed from the files are deleted.
+type AlertingProvisioner struct {
+	log           log.Logger
+	path          string
+	cfgReader     *configReader
+	folders       dashboards.FolderService
+	orgs          OrgStore
+	provenance    ProvenanceStore
+	rules         AlertRuleService
+	contactPoints ContactPointService
+	policies      NotificationPolicyService
+	templates     TemplateService
+	muteTimings   MuteTimingService
+
+	checksum string
+}
+
+// New creates an AlertingProvisioner for the files of the directory, backed by the unified alerting store.
+func New(path string, cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, folderService dashboards.FolderService,
+	secretsService secrets.Service, ac accesscontrol.AccessControl) *AlertingProvisioner {
+	logger := log.New("provisioning.alerting")
+	st := &store.DBstore{
+		BaseInterval:    cfg.UnifiedAlerting.BaseInterval,
+		DefaultInterval: cfg.UnifiedAlerting.DefaultRuleEvaluationInterval,
+		SQLStore:        sqlStore,
+		Logger:          logger,
+		FolderService:   folderService,
+		AccessControl:   ac,
+	}
+	return &AlertingProvisioner{
+		log:           logger,
+		path:          path,
+		cfgReader:     &configReader{log: logger, orgStore: sqlStore},
+		folders:       folderService,
+		orgs:          st,
+		provenance:    st,
+		rules:         provisioning.NewAlertRuleService(st, st, st, cfg.UnifiedAlerting.DefaultRuleEvaluationInterval, logger),
+		contactPoints: provisioning.NewContactPointService(st, secretsService, st, st, logger),
+		policies:      provisioning.NewNotificationPolicyService(st, st, st, logger),
+		templates:     provisioning.NewTemplateService(st, st, st, logger),
+		muteTimings:   provisioning.NewMuteTimingService(st, st, st, logger),
+	}
+}
+
+// Provision reads the files and applies them. The errors of a file are logged, and the other files are
+// applied anyway.
+func (ap *AlertingProvisioner) Provision(ctx context.Context) error {
+	files, checksum, complete := ap.cfgReader.readConfig(ctx, ap.path)
+	if err := ap.apply(ctx, files, complete); err != nil {
+		return err
+	}
+	ap.checksum = checksum
+	return nil
+}
+
+// PollChanges applies the files again whenever their content changes, until the context is cancelled.
+func (ap *AlertingProvisioner) PollChanges(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			files, checksum, complete := ap.cfgReader.readConfig(ctx, ap.path)
+			if checksum == ap.checksum {
+				continue
+			}
+			ap.log.Info("Alerting provisioning files changed, applying them")
+			if err := ap.apply(ctx, files, complete); err != nil {
+				// the checksum is not updated, so that the files are applied again on the next tick.
+				ap.log.Error("Failed to apply alerting provisioning files", "error", err)
+				continue
+			}
+			ap.checksum = checksum
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// apply creates or updates the resources of the files, then deletes the file provisioned resources which
+// are not in the files anymore. Resources are created before the resources that reference them, and
+// deleted in the reverse order. The resources of a file that fail to be applied are logged and the other
+// resources are applied anyway, nothing is deleted when some files could not be read.
+func (ap *AlertingProvisioner) apply(ctx context.Context, files []*alertingFile, complete bool) error {
+	desired := newResourceSet()
+	failed := 0
+	logFailure := func(file *alertingFile, msg string, err error, ctx ...interface{}) {
+		failed++
+		ap.log.Error(msg, append([]interface{}{"file.Name", file.Filename, "error", err}, ctx...)...)
+	}
+	for _, file := range files {
+		for _, t := range file.Templates {
+			desired.add(t.OrgID, &t.Template)
+			if _, err := ap.templates.SetTemplate(ctx, t.OrgID, t.Template, ngmodels.ProvenanceFile); err != nil {
+				logFailure(file, "Failed to provision template", err, "org", t.OrgID, "name", t.Template.Name)
+			}
+		}
+	}
+	for _, file := range files {
+		for _, mt := range file.MuteTimes {
+			desired.add(mt.OrgID, &mt.MuteTime)
+			if err := ap.applyMuteTime(ctx, mt); err != nil {
+				logFailure(file, "Failed to provision mute time", err, "org", mt.OrgID, "name", mt.MuteTime.Name)
+			}
+		}
+	}
+	for _, file := range files {
+		for _, cp := range file.ContactPoints {
+			desired.add(cp.OrgID, &cp.ContactPoint)
+			if err := ap.applyContactPoint(ctx, cp); err != nil {
+				logFailure(file, "Failed to provision contact point", err, "org", cp.OrgID, "name", cp.ContactPoint.Name)
+			}
+		}
+	}
+	for _, file := range files {
+		for _, p := range file.Policies {
+			desired.add(p.OrgID, &p.Policy)
+			if err := ap.policies.UpdatePolicyTree(ctx, p.OrgID, p.Policy, ngmodels.ProvenanceFile); err != nil {
+				logFailure(file, "Failed to provision the notification policy", err, "org", p.OrgID)
+			}
+		}
+	}
+	for _, file := range files {
+		for _, group := range file.Groups {
+			for _, rule := range group.Rules {
+				desired.add(group.OrgID, &ngmodels.AlertRule{OrgID: group.OrgID, UID: rule.UID})
+			}
+			if err := ap.applyRuleGroup(ctx, group); err != nil {
+				logFailure(file, "Failed to provision rule group", err, "org", group.OrgID, "folder", group.Folder, "group", group.Name)
+			}
+		}
+	}
+
+	if !complete {
+		ap.log.Warn("Not deleting the resources removed from the alerting provisioning files, some files are invalid")
+	} else if err := ap.deleteRemoved(ctx, desired); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to provision %d alerting resources", failed)
+	}
+	return nil
+}
+
+func (ap *AlertingProvisioner) applyMuteTime(ctx context.Context, mt *muteTime) error {
+	existing, err := ap.muteTimings.GetMuteTimings(ctx, mt.OrgID)
+	if err != nil {
+		return err
+	}
+	for _, e := range existing {
+		if e.Name == mt.MuteTime.Name {
+			_, err := ap.muteTimings.UpdateMuteTiming(ctx, mt.OrgID, mt.MuteTime, ngmodels.ProvenanceFile)
+			return err
+		}
+	}
+	_, err = ap.muteTimings.CreateMuteTiming(ctx, mt.OrgID, mt.MuteTime, ngmodels.ProvenanceFile)
+	return err
+}
+
+func (ap *AlertingProvisioner) applyContactPoint(ctx context.Context, cp *contactPoint) error {
+	existing, err := ap.contactPoints.GetContactPoints(ctx, cp.OrgID)
+	if err != nil {
+		return err
+	}
+	for _, e := range existing {
+		if e.UID != cp.ContactPoint.UID {
+			continue
+		}
+		if e.Provenance != string(ngmodels.ProvenanceNone) && e.Provenance != string(ngmodels.ProvenanceFile) {
+			return fmt.Errorf("%w: the contact point is provisioned with provenance '%s'", provisioning.ErrProvenanceMismatch, e.Provenance)
+		}
+		if e.Name == cp.ContactPoint.Name {
+			return ap.contactPoints.UpdateContactPoint(ctx, cp.OrgID, cp.ContactPoint, ngmodels.ProvenanceFile)
+		}
+		// the contact points are stored by name, a renamed contact point is moved to its new name.
+		if err := ap.contactPoints.DeleteContactPoint(ctx, cp.OrgID, cp.ContactPoint.UID); err != nil {
+			return err
+		}
+		break
+	}
+	_, err = ap.contactPoints.CreateContactPoint(ctx, cp.OrgID, cp.ContactPoint, ngmodels.ProvenanceFile)
+	return err
+}
+
+func (ap *AlertingProvisioner) applyRuleGroup(ctx context.Context, group *ruleGroup) error {
+	folderUID, err := ap.getOrCreateFolder(ctx, group.OrgID, group.Folder)
+	if err != nil {
+		return err
+	}
+	for _, rule := range group.Rules {
+		rule.NamespaceUID = folderUID
+		existing, _, err := ap.rules.GetAlertRule(ctx, group.OrgID, rule.UID)
+		switch {
+		case errors.Is(err, ngmodels.ErrAlertRuleNotFound):
+			if _, err := ap.rules.CreateAlertRule(ctx, rule, ngmodels.ProvenanceFile); err != nil {
+				return fmt.Errorf("failed to create rule '%s': %w", rule.Title, err)
+			}
+		case err != nil:
+			return err
+		case ruleChanged(existing, rule):
+			if _, err := ap.rules.UpdateAlertRule(ctx, rule, ngmodels.ProvenanceFile); err != nil {
+				return fmt.Errorf("failed to update rule '%s': %w", rule.Title, err)
+			}
+		}
+	}
+	if group.Interval > 0 && len(group.Rules) > 0 {
+		return ap.rules.UpdateRuleGroup(ctx, group.OrgID, folderUID, group.Name, int64(group.Interval.Seconds()), ngmodels.ProvenanceFile)
+	}
+	return nil
+}
+
+// ruleChanged returns true if the rule of the file differs from the stored rule, so that
+// unchanged rules keep their version and are not restarted by the scheduler.
+func ruleChanged(existing, rule ngmodels.AlertRule) bool {
+	// the stored queries went through PreSave, which adds the default properties of the model.
+	data := make([]ngmodels.AlertQuery, 0, len(rule.Data))
+	for _, q := range rule.Data {
+		if err := q.PreSave(); err != nil {
+			return true
+		}
+		data = append(data, q)
+	}
+	rule.Data = data
+	if len(existing.Annotations) == 0 && len(rule.Annotations) == 0 {
+		rule.Annotations = existing.Annotations
+	}
+	if len(existing.Labels) == 0 && len(rule.Labels) == 0 {
+		rule.Labels = existing.Labels
+	}
+	diff := existing.Diff(&rule, "ID", "Version", "Updated", "IntervalSeconds", "DashboardUID", "PanelID", "Record")
+	return len(diff) > 0
+}
+
+func (ap *AlertingProvisioner) getOrCreateFolder(ctx context.Context, orgID int64, title string) (string, error) {
+	user := &models.SignedInUser{OrgId: orgID, OrgRole: models.ROLE_ADMIN}
+	folder, err := ap.folders.GetFolderByTitle(ctx, user, orgID, title)
+	if err == nil {
+		return folder.Uid, nil
+	}
+	if !errors.Is(err, models.ErrFolderNotFound) && !errors.Is(err, models.ErrDashboardNotFound) {
+		return "", err
+	}
+	folder, err = ap.folders.CreateFolder(ctx, user, orgID, title, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to create folder '%s': %w", title, err)
+	}
+	return folder.Uid, nil
+}
+
+// deleteRemoved deletes the resources with the file provenance that are not in the desired set.
+func (ap *AlertingProvisioner) deleteRemoved(ctx context.Context, desired *resourceSet) error {
+	orgs, err := ap.orgs.GetOrgs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, orgID := range orgs {
+		removed := func(resource ngmodels.Provisionable) ([]string, error) {
+			provenances, err := ap.provenance.GetProvenances(ctx, orgID, resource.ResourceType())
+			if err != nil {
+				return nil, err
+			}
+			var ids []string
+			for id, p := range provenances {
+				if p == ngmodels.ProvenanceFile && !desired.has(orgID, resource.ResourceType(), id) {
+					ids = append(ids, id)
+				}
+			}
+			return ids, nil
+		}
+
+		uids, err := removed(&ngmodels.AlertRule{})
+		if err != nil {
+			return err
+		}
+		for _, uid := range uids {
+			ap.log.Info("Deleting alert rule removed from the provisioning files", "org", orgID, "uid", uid)
+			if err := ap.rules.DeleteAlertRule(ctx, orgID, uid, ngmodels.ProvenanceFile); err != nil {
+				return fmt.Errorf("failed to delete rule '%s': %w", uid, err)
+			}
+		}
+
+		policies, err := removed(&definitions.Route{})
+		if err != nil {
+			return err
+		}
+		if len(policies) > 0 {
+			// the policy tree is kept, but it is not locked by the files anymore.
+			ap.log.Info("Releasing notification policy removed from the provisioning files", "org", orgID)
+			if err := ap.provenance.DeleteProvenance(ctx, &definitions.Route{}, orgID); err != nil {
+				return err
+			}
+		}
+
+		uids, err = removed(&definitions.EmbeddedContactPoint{})
+		if err != nil {
+			return err
+		}
+		for _, uid := range uids {
+			ap.log.Info("Deleting contact point removed from the provisioning files", "org", orgID, "uid", uid)
+			if err := ap.contactPoints.DeleteContactPoint(ctx, orgID, uid); err != nil {
+				return fmt.Errorf("failed to delete contact point '%s': %w", uid, err)
+			}
+		}
+
+		names, err := removed(&definitions.MuteTimeInterval{})
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			ap.log.Info("Deleting mute time removed from the provisioning files", "org", orgID, "name", name)
+			if err := ap.muteTimings.DeleteMuteTiming(ctx, orgID, name, ngmodels.ProvenanceFile); err != nil {
+				return fmt.Errorf("failed to delete mute time '%s': %w", name, err)
+			}
+		}
+
+		names, err = removed(&definitions.MessageTemplate{})
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			ap.log.Info("Deleting template removed from the provisioning files", "org", orgID, "name", name)
+			if err := ap.templates.DeleteTemplate(ctx, orgID, name, ngmodels.ProvenanceFile); err != nil {
+				return fmt.Errorf("failed to delete template '%s': %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// resourceSet holds the resources of the files by organization, type and ID.
+type resourceSet struct {
+	resources map[string]struct{}
+}
+
+func newResourceSet() *resourceSet {
+	return &resourceSet{resources: make(map[string]struct{})}
+}
+
+func (s *resourceSet) add(orgID int64, resource ngmodels.Provisionable) {
+	s.resources[resourceKey(orgID, resource.ResourceType(), resource.ResourceID())] = struct{}{}
+}
+
+func (s *resourceSet) has(orgID int64, resourceType, id string) bool {
+	_, ok := s.resources[resourceKey(orgID, resourceType, id)]
+	return ok
+}
+
+func resourceKey(orgID int64, resourceType, id string) string {
+	return fmt.Sprintf("%d/%s/%s", orgID, resourceType, id)
+}
@@ -0,0 +1,89 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type fakeTemplateService struct {
+	templates map[string]string
+	deleted   []string
+}
+
+func (f *fakeTemplateService) SetTemplate(_ context.Context, _ int64, tmpl definitions.MessageTemplate, _ ngmodels.Provenance) (definitions.MessageTemplate, error) {
+	if tmpl.Template == "" {
+		return definitions.MessageTemplate{}, errors.New("empty template")
+	}
+	f.templates[tmpl.Name] = tmpl.Template
+	return tmpl, nil
+}
+
+func (f *fakeTemplateService) DeleteTemplate(_ context.Context, _ int64, name string, _ ngmodels.Provenance) error {
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+type fakeProvenanceStore struct {
+	provenances map[string]ngmodels.Provenance
+}
+
+func (f *fakeProvenanceStore) GetProvenances(_ context.Context, _ int64, resourceType string) (map[string]ngmodels.Provenance, error) {
+	if resourceType != (&definitions.MessageTemplate{}).ResourceType() {
+		return nil, nil
+	}
+	return f.provenances, nil
+}
+
+func (f *fakeProvenanceStore) DeleteProvenance(_ context.Context, _ ngmodels.Provisionable, _ int64) error {
+	return nil
+}
+
+type fakeOrgs struct{}
+
+func (fakeOrgs) GetOrgs(_ context.Context) ([]int64, error) {
+	return []int64{1}, nil
+}
+
+func TestAlertingProvisionerApply(t *testing.T) {
+	newProvisioner := func() (*AlertingProvisioner, *fakeTemplateService) {
+		templates := &fakeTemplateService{templates: map[string]string{}}
+		return &AlertingProvisioner{
+			log:        log.New("test"),
+			orgs:       fakeOrgs{},
+			provenance: &fakeProvenanceStore{provenances: map[string]ngmodels.Provenance{"failing": ngmodels.ProvenanceFile, "removed": ngmodels.ProvenanceFile}},
+			templates:  templates,
+		}, templates
+	}
+	files := []*alertingFile{
+		{Filename: "failing.yaml", Templates: []*template{{OrgID: 1, Template: definitions.MessageTemplate{Name: "failing"}}}},
+		{Filename: "valid.yaml", Templates: []*template{{OrgID: 1, Template: definitions.MessageTemplate{Name: "valid", Template: "{{ . }}"}}}},
+	}
+
+	t.Run("applies the other files when a file fails", func(t *testing.T) {
+		ap, templates := newProvisioner()
+
+		err := ap.apply(context.Background(), files, true)
+
+		require.EqualError(t, err, "failed to provision 1 alerting resources")
+		require.Equal(t, map[string]string{"valid": "{{ . }}"}, templates.templates)
+		// the resource that failed is still in the files and is not deleted.
+		require.Equal(t, []string{"removed"}, templates.deleted)
+	})
+
+	t.Run("does not delete resources when some files are invalid", func(t *testing.T) {
+		ap, templates := newProvisioner()
+
+		err := ap.apply(context.Background(), files[1:], false)
+
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"valid": "{{ . }}"}, templates.templates)
+		require.Empty(t, templates.deleted)
+	})
+}
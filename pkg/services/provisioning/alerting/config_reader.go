@@ -0,0 +1,237 @@
+package alerting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+type configReader struct {
+	log      log.Logger
+	orgStore utils.OrgStore
+}
+
+// readConfig parses and validates the alerting provisioning files of the directory. It also returns
+// a checksum of their content, which changes when a file is added, changed or removed. A file that cannot
+// be read or is invalid is logged and skipped, complete is then false.
+func (cr *configReader) readConfig(ctx context.Context, path string) (files []*alertingFile, checksum string, complete bool) {
+	cr.log.Debug("Looking for alerting provisioning files", "path", path)
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Can't read alerting provisioning files from directory", "path", path, "error", err)
+		return files, "", true
+	}
+
+	complete = true
+	hash := sha256.New()
+	seen := make(map[string]struct{})
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".yaml") && !strings.HasSuffix(entry.Name(), ".yml") {
+			continue
+		}
+		cr.log.Debug("Parsing alerting provisioning file", "path", path, "file.Name", entry.Name())
+		content, err := readFile(path, entry)
+		if err != nil {
+			cr.log.Error("Failed to read alerting provisioning file", "path", path, "file.Name", entry.Name(), "error", err)
+			complete = false
+			continue
+		}
+		_, _ = hash.Write([]byte(entry.Name()))
+		_, _ = hash.Write(content)
+
+		file, err := cr.parseConfig(content)
+		if err == nil {
+			err = cr.validateFile(ctx, file, seen)
+		}
+		if err != nil {
+			cr.log.Error("Skipping invalid alerting provisioning file", "path", path, "file.Name", entry.Name(), "error", err)
+			complete = false
+			continue
+		}
+		file.Filename = entry.Name()
+		files = append(files, file)
+	}
+
+	return files, hex.EncodeToString(hash.Sum(nil)), complete
+}
+
+// validateFile checks the resources of the file, and that they are not provisioned by the files validated
+// before it. The keys of the resources are added to seen when the file is valid.
+func (cr *configReader) validateFile(ctx context.Context, file *alertingFile, seen map[string]struct{}) error {
+	if err := validateRequiredFields(file); err != nil {
+		return err
+	}
+	keys, err := resourceKeys(file, seen)
+	if err != nil {
+		return err
+	}
+	if err := cr.checkOrgsExist(ctx, file); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+func readFile(path string, file os.FileInfo) ([]byte, error) {
+	filename, _ := filepath.Abs(filepath.Join(path, file.Name()))
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	return ioutil.ReadFile(filename)
+}
+
+func (cr *configReader) parseConfig(content []byte) (*alertingFile, error) {
+	var apiVersion *configVersion
+	if err := yaml.Unmarshal(content, &apiVersion); err != nil {
+		return nil, err
+	}
+	if apiVersion == nil || apiVersion.APIVersion != 1 {
+		return nil, fmt.Errorf("unsupported apiVersion, the only supported version is 1")
+	}
+
+	var v1 *alertingFileV1
+	if err := yaml.Unmarshal(content, &v1); err != nil {
+		return nil, err
+	}
+	return v1.mapToAlertingFile()
+}
+
+func validateRequiredFields(file *alertingFile) error {
+	var errStrings []string
+	for _, group := range file.Groups {
+		if group.Name == "" {
+			errStrings = append(errStrings, "rule group doesn't contain required field name")
+		}
+		if group.Folder == "" {
+			errStrings = append(errStrings, fmt.Sprintf("rule group '%s' doesn't contain required field folder", group.Name))
+		}
+		for _, rule := range group.Rules {
+			if rule.UID == "" {
+				errStrings = append(errStrings, fmt.Sprintf("rule '%s' of group '%s' doesn't contain required field uid", rule.Title, group.Name))
+			}
+			if rule.Title == "" {
+				errStrings = append(errStrings, fmt.Sprintf("rule '%s' of group '%s' doesn't contain required field title", rule.UID, group.Name))
+			}
+		}
+	}
+	for _, cp := range file.ContactPoints {
+		if cp.ContactPoint.UID == "" || cp.ContactPoint.Name == "" || cp.ContactPoint.Type == "" {
+			errStrings = append(errStrings, fmt.Sprintf("contact point '%s' must have the fields uid, name and type", cp.ContactPoint.Name))
+		}
+	}
+	for _, t := range file.Templates {
+		if t.Template.Name == "" {
+			errStrings = append(errStrings, "template doesn't contain required field name")
+		}
+	}
+	for _, mt := range file.MuteTimes {
+		if mt.MuteTime.Name == "" {
+			errStrings = append(errStrings, "mute time doesn't contain required field name")
+		}
+	}
+
+	if len(errStrings) != 0 {
+		return fmt.Errorf(strings.Join(errStrings, "\n"))
+	}
+	return nil
+}
+
+// resourceKeys returns the keys of the resources of the file, and checks that a resource is not provisioned
+// twice in an organization, in the file or by the resources already seen.
+func resourceKeys(file *alertingFile, seen map[string]struct{}) ([]string, error) {
+	var keys []string
+	inFile := make(map[string]struct{})
+	check := func(orgID int64, kind, id string) error {
+		key := fmt.Sprintf("%d/%s/%s", orgID, kind, id)
+		_, inOtherFile := seen[key]
+		if _, ok := inFile[key]; ok || inOtherFile {
+			if id == "" {
+				return fmt.Errorf("%s is provisioned more than once in organization %d", kind, orgID)
+			}
+			return fmt.Errorf("%s '%s' is provisioned more than once in organization %d", kind, id, orgID)
+		}
+		inFile[key] = struct{}{}
+		keys = append(keys, key)
+		return nil
+	}
+
+	for _, group := range file.Groups {
+		if err := check(group.OrgID, "rule group", group.Folder+"/"+group.Name); err != nil {
+			return nil, err
+		}
+		for _, rule := range group.Rules {
+			if err := check(group.OrgID, "rule", rule.UID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, cp := range file.ContactPoints {
+		if err := check(cp.OrgID, "contact point", cp.ContactPoint.UID); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range file.Policies {
+		if err := check(p.OrgID, "notification policy", ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range file.Templates {
+		if err := check(t.OrgID, "template", t.Template.Name); err != nil {
+			return nil, err
+		}
+	}
+	for _, mt := range file.MuteTimes {
+		if err := check(mt.OrgID, "mute time", mt.MuteTime.Name); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func (cr *configReader) checkOrgsExist(ctx context.Context, file *alertingFile) error {
+	checked := make(map[int64]struct{})
+	for _, orgID := range orgsOf(file) {
+		if _, ok := checked[orgID]; ok {
+			continue
+		}
+		if err := utils.CheckOrgExists(ctx, cr.orgStore, orgID); err != nil {
+			return fmt.Errorf("failed to provision alerting resources of organization %d: %w", orgID, err)
+		}
+		checked[orgID] = struct{}{}
+	}
+	return nil
+}
+
+// orgsOf returns the organizations of all the resources of the file.
+func orgsOf(file *alertingFile) []int64 {
+	var orgs []int64
+	for _, group := range file.Groups {
+		orgs = append(orgs, group.OrgID)
+	}
+	for _, cp := range file.ContactPoints {
+		orgs = append(orgs, cp.OrgID)
+	}
+	for _, p := range file.Policies {
+		orgs = append(orgs, p.OrgID)
+	}
+	for _, t := range file.Templates {
+		orgs = append(orgs, t.OrgID)
+	}
+	for _, mt := range file.MuteTimes {
+		orgs = append(orgs, mt.OrgID)
+	}
+	return orgs
+}
@@ -0,0 +1,146 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+var (
+	correctProperties  = "./testdata/test-configs/correct-properties"
+	noRequiredFields   = "./testdata/test-configs/no-required-fields"
+	duplicateRules     = "./testdata/test-configs/duplicate-rules"
+	unsupportedVersion = "./testdata/test-configs/unsupported-version"
+	unknownOrg         = "./testdata/test-configs/unknown-org"
+)
+
+type fakeOrgStore struct {
+	orgs map[int64]struct{}
+}
+
+func (f *fakeOrgStore) GetOrgById(_ context.Context, q *models.GetOrgByIdQuery) error {
+	if _, ok := f.orgs[q.Id]; !ok {
+		return models.ErrOrgNotFound
+	}
+	q.Result = &models.Org{Id: q.Id}
+	return nil
+}
+
+func TestAlertingConfigReader(t *testing.T) {
+	reader := &configReader{
+		log:      log.New("test logger"),
+		orgStore: &fakeOrgStore{orgs: map[int64]struct{}{1: {}, 2: {}}},
+	}
+
+	t.Run("can read correct properties", func(t *testing.T) {
+		t.Setenv("TEST_VAR", "team@example.com")
+		files, checksum, complete := reader.readConfig(context.Background(), correctProperties)
+		require.True(t, complete)
+		require.NotEmpty(t, checksum)
+		require.Len(t, files, 2)
+		require.Equal(t, "notifications.yml", files[0].Filename)
+
+		notifications, rules := files[0], files[1]
+		require.Len(t, rules.Groups, 1)
+		group := rules.Groups[0]
+		require.Equal(t, int64(1), group.OrgID)
+		require.Equal(t, "cpu", group.Name)
+		require.Equal(t, "Infrastructure", group.Folder)
+		require.Equal(t, time.Minute, group.Interval)
+
+		require.Len(t, group.Rules, 1)
+		rule := group.Rules[0]
+		require.Equal(t, "cpu-usage", rule.UID)
+		require.Equal(t, "cpu", rule.RuleGroup)
+		require.Equal(t, 5*time.Minute, rule.For)
+		require.Equal(t, ngmodels.OK, rule.NoDataState)
+		require.Equal(t, ngmodels.AlertingErrState, rule.ExecErrState)
+		require.Equal(t, "CPU usage of {{ $labels.instance }} is high", rule.Annotations["summary"])
+		require.Len(t, rule.Data, 2)
+		require.Equal(t, ngmodels.Duration(10*time.Minute), rule.Data[0].RelativeTimeRange.From)
+		var model map[string]interface{}
+		require.NoError(t, json.Unmarshal(rule.Data[1].Model, &model))
+		require.Equal(t, "$A > 0.9", model["expression"], "the queries should not be interpolated")
+
+		require.Len(t, notifications.ContactPoints, 2)
+		require.Equal(t, int64(1), notifications.ContactPoints[0].OrgID)
+		require.Equal(t, "team@example.com", notifications.ContactPoints[0].ContactPoint.Settings.Get("addresses").MustString())
+		require.Equal(t, int64(2), notifications.ContactPoints[1].OrgID)
+		require.True(t, notifications.ContactPoints[1].ContactPoint.DisableResolveMessage)
+
+		require.Len(t, notifications.Policies, 1)
+		require.Equal(t, "team", notifications.Policies[0].Policy.Receiver)
+		require.Equal(t, []string{"weekends"}, notifications.Policies[0].Policy.Routes[0].MuteTimeIntervals)
+
+		require.Len(t, notifications.Templates, 1)
+		require.Equal(t, "team.title", notifications.Templates[0].Template.Name)
+
+		require.Len(t, notifications.MuteTimes, 1)
+		require.Equal(t, int64(1), notifications.MuteTimes[0].OrgID)
+		require.Equal(t, "weekends", notifications.MuteTimes[0].MuteTime.Name)
+		require.Len(t, notifications.MuteTimes[0].MuteTime.TimeIntervals, 1)
+	})
+
+	t.Run("checksum changes with the content of the files", func(t *testing.T) {
+		dir := t.TempDir()
+		write := func(content string) string {
+			require.NoError(t, os.WriteFile(dir+"/templates.yaml", []byte(content), 0600))
+			_, checksum, complete := reader.readConfig(context.Background(), dir)
+			require.True(t, complete)
+			return checksum
+		}
+		first := write("apiVersion: 1\ntemplates:\n  - name: a\n    template: a\n")
+		require.Equal(t, first, write("apiVersion: 1\ntemplates:\n  - name: a\n    template: a\n"))
+		require.NotEqual(t, first, write("apiVersion: 1\ntemplates:\n  - name: a\n    template: b\n"))
+	})
+
+	t.Run("files with missing required fields are skipped", func(t *testing.T) {
+		files, _, complete := reader.readConfig(context.Background(), noRequiredFields)
+		require.False(t, complete)
+		require.Empty(t, files)
+
+		content, err := os.ReadFile(noRequiredFields + "/rules.yaml")
+		require.NoError(t, err)
+		file, err := reader.parseConfig(content)
+		require.NoError(t, err)
+		err = validateRequiredFields(file)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "rule group 'cpu' doesn't contain required field folder")
+		require.Contains(t, err.Error(), "doesn't contain required field uid")
+		require.Contains(t, err.Error(), "contact point 'team' must have the fields uid, name and type")
+	})
+
+	t.Run("a rule in two files is only provisioned by the first file", func(t *testing.T) {
+		files, _, complete := reader.readConfig(context.Background(), duplicateRules)
+		require.False(t, complete)
+		require.Len(t, files, 1)
+		require.Equal(t, "first.yaml", files[0].Filename)
+
+	})
+
+	t.Run("files without apiVersion are skipped", func(t *testing.T) {
+		files, _, complete := reader.readConfig(context.Background(), unsupportedVersion)
+		require.False(t, complete)
+		require.Empty(t, files)
+	})
+
+	t.Run("files of an unknown organization are skipped", func(t *testing.T) {
+		files, _, complete := reader.readConfig(context.Background(), unknownOrg)
+		require.False(t, complete)
+		require.Empty(t, files)
+	})
+
+	t.Run("missing directory should not fail", func(t *testing.T) {
+		files, _, complete := reader.readConfig(context.Background(), "./testdata/test-configs/missing")
+		require.True(t, complete)
+		require.Empty(t, files)
+	})
+}
@@ -0,0 +1,283 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// alertingFile is the normalized content of an alerting provisioning file. Any version of the
+// file format should be mappable to this type.
+type alertingFile struct {
+	// Filename is the name of the file in the provisioning directory.
+	Filename      string
+	Groups        []*ruleGroup
+	ContactPoints []*contactPoint
+	Policies      []*policy
+	Templates     []*template
+	MuteTimes     []*muteTime
+}
+
+type ruleGroup struct {
+	OrgID    int64
+	Name     string
+	Folder   string
+	Interval time.Duration
+	Rules    []models.AlertRule
+}
+
+type contactPoint struct {
+	OrgID        int64
+	ContactPoint definitions.EmbeddedContactPoint
+}
+
+type policy struct {
+	OrgID  int64
+	Policy definitions.Route
+}
+
+type template struct {
+	OrgID    int64
+	Template definitions.MessageTemplate
+}
+
+type muteTime struct {
+	OrgID    int64
+	MuteTime definitions.MuteTimeInterval
+}
+
+// configVersion is used to figure out which API version a file uses.
+type configVersion struct {
+	APIVersion int64 `json:"apiVersion" yaml:"apiVersion"`
+}
+
+// alertingFileV1 is the mapping of the first version of the file format.
+type alertingFileV1 struct {
+	configVersion
+	Groups        []*ruleGroupV1    `json:"groups" yaml:"groups"`
+	ContactPoints []*contactPointV1 `json:"contactPoints" yaml:"contactPoints"`
+	Policies      []*policyV1       `json:"policies" yaml:"policies"`
+	Templates     []*templateV1     `json:"templates" yaml:"templates"`
+	MuteTimes     []*muteTimeV1     `json:"muteTimes" yaml:"muteTimes"`
+}
+
+type ruleGroupV1 struct {
+	OrgID    values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Name     values.StringValue `json:"name" yaml:"name"`
+	Folder   values.StringValue `json:"folder" yaml:"folder"`
+	Interval values.StringValue `json:"interval" yaml:"interval"`
+	Rules    []*alertRuleV1     `json:"rules" yaml:"rules"`
+}
+
+// alertRuleV1 is a rule of a group. Annotations and labels are not interpolated with environment
+// variables, as they usually contain template variables such as $labels.
+type alertRuleV1 struct {
+	UID          values.StringValue `json:"uid" yaml:"uid"`
+	Title        values.StringValue `json:"title" yaml:"title"`
+	Condition    values.StringValue `json:"condition" yaml:"condition"`
+	Data         []*alertQueryV1    `json:"data" yaml:"data"`
+	For          values.StringValue `json:"for" yaml:"for"`
+	NoDataState  values.StringValue `json:"noDataState" yaml:"noDataState"`
+	ExecErrState values.StringValue `json:"execErrState" yaml:"execErrState"`
+	Annotations  map[string]string  `json:"annotations" yaml:"annotations"`
+	Labels       map[string]string  `json:"labels" yaml:"labels"`
+}
+
+type alertQueryV1 struct {
+	RefID             values.StringValue  `json:"refId" yaml:"refId"`
+	QueryType         values.StringValue  `json:"queryType" yaml:"queryType"`
+	RelativeTimeRange relativeTimeRangeV1 `json:"relativeTimeRange" yaml:"relativeTimeRange"`
+	DatasourceUID     values.StringValue  `json:"datasourceUid" yaml:"datasourceUid"`
+	Model             values.JSONValue    `json:"model" yaml:"model"`
+}
+
+// relativeTimeRangeV1 is the time range of a query in seconds before the evaluation.
+type relativeTimeRangeV1 struct {
+	From values.Int64Value `json:"from" yaml:"from"`
+	To   values.Int64Value `json:"to" yaml:"to"`
+}
+
+type contactPointV1 struct {
+	OrgID                 values.Int64Value  `json:"orgId" yaml:"orgId"`
+	UID                   values.StringValue `json:"uid" yaml:"uid"`
+	Name                  values.StringValue `json:"name" yaml:"name"`
+	Type                  values.StringValue `json:"type" yaml:"type"`
+	DisableResolveMessage values.BoolValue   `json:"disableResolveMessage" yaml:"disableResolveMessage"`
+	Settings              values.JSONValue   `json:"settings" yaml:"settings"`
+}
+
+// policyV1 is the notification policy tree of an organization, written as the root route
+// next to the orgId field.
+type policyV1 struct {
+	OrgID  values.Int64Value
+	Policy definitions.Route
+}
+
+func (p *policyV1) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	org := struct {
+		OrgID values.Int64Value `yaml:"orgId"`
+	}{}
+	if err := unmarshal(&org); err != nil {
+		return err
+	}
+	p.OrgID = org.OrgID
+	return unmarshal(&p.Policy)
+}
+
+type templateV1 struct {
+	OrgID    values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Name     values.StringValue `json:"name" yaml:"name"`
+	Template string             `json:"template" yaml:"template"`
+}
+
+// muteTimeV1 is a mute timing, written as an Alertmanager mute time interval next to the orgId field.
+type muteTimeV1 struct {
+	OrgID    values.Int64Value
+	MuteTime definitions.MuteTimeInterval
+}
+
+func (mt *muteTimeV1) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	org := struct {
+		OrgID values.Int64Value `yaml:"orgId"`
+	}{}
+	if err := unmarshal(&org); err != nil {
+		return err
+	}
+	mt.OrgID = org.OrgID
+	return unmarshal(&mt.MuteTime.MuteTimeInterval)
+}
+
+// mapToAlertingFile maps the version 1 syntax to the normalized alertingFile.
+func (cfg *alertingFileV1) mapToAlertingFile() (*alertingFile, error) {
+	r := &alertingFile{}
+	if cfg == nil {
+		return r, nil
+	}
+
+	for _, g := range cfg.Groups {
+		group, err := g.mapToRuleGroup()
+		if err != nil {
+			return nil, fmt.Errorf("rule group '%s': %w", g.Name.Value(), err)
+		}
+		r.Groups = append(r.Groups, group)
+	}
+
+	for _, cp := range cfg.ContactPoints {
+		r.ContactPoints = append(r.ContactPoints, &contactPoint{
+			OrgID: orgID(cp.OrgID),
+			ContactPoint: definitions.EmbeddedContactPoint{
+				UID:                   cp.UID.Value(),
+				Name:                  cp.Name.Value(),
+				Type:                  cp.Type.Value(),
+				DisableResolveMessage: cp.DisableResolveMessage.Value(),
+				Settings:              simplejson.NewFromAny(cp.Settings.Value()),
+			},
+		})
+	}
+
+	for _, p := range cfg.Policies {
+		r.Policies = append(r.Policies, &policy{OrgID: orgID(p.OrgID), Policy: p.Policy})
+	}
+
+	for _, t := range cfg.Templates {
+		r.Templates = append(r.Templates, &template{
+			OrgID: orgID(t.OrgID),
+			Template: definitions.MessageTemplate{
+				Name:     t.Name.Value(),
+				Template: t.Template,
+			},
+		})
+	}
+
+	for _, mt := range cfg.MuteTimes {
+		r.MuteTimes = append(r.MuteTimes, &muteTime{OrgID: orgID(mt.OrgID), MuteTime: mt.MuteTime})
+	}
+
+	return r, nil
+}
+
+func (g *ruleGroupV1) mapToRuleGroup() (*ruleGroup, error) {
+	group := &ruleGroup{
+		OrgID:  orgID(g.OrgID),
+		Name:   g.Name.Value(),
+		Folder: g.Folder.Value(),
+	}
+	if interval := g.Interval.Value(); interval != "" {
+		d, err := model.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval '%s': %w", interval, err)
+		}
+		group.Interval = time.Duration(d)
+	}
+
+	for _, rule := range g.Rules {
+		r, err := rule.mapToAlertRule()
+		if err != nil {
+			return nil, fmt.Errorf("rule '%s': %w", rule.Title.Value(), err)
+		}
+		r.OrgID = group.OrgID
+		r.RuleGroup = group.Name
+		group.Rules = append(group.Rules, r)
+	}
+	return group, nil
+}
+
+func (rule *alertRuleV1) mapToAlertRule() (models.AlertRule, error) {
+	r := models.AlertRule{
+		UID:          rule.UID.Value(),
+		Title:        rule.Title.Value(),
+		Condition:    rule.Condition.Value(),
+		NoDataState:  models.NoData,
+		ExecErrState: models.AlertingErrState,
+		Annotations:  rule.Annotations,
+		Labels:       rule.Labels,
+	}
+	if forValue := rule.For.Value(); forValue != "" {
+		d, err := model.ParseDuration(forValue)
+		if err != nil {
+			return models.AlertRule{}, fmt.Errorf("invalid for '%s': %w", forValue, err)
+		}
+		r.For = time.Duration(d)
+	}
+	if state := rule.NoDataState.Value(); state != "" {
+		r.NoDataState = models.NoDataState(state)
+	}
+	if state := rule.ExecErrState.Value(); state != "" {
+		r.ExecErrState = models.ExecutionErrorState(state)
+	}
+
+	for _, q := range rule.Data {
+		// the raw model is used because queries contain variables such as $__interval
+		// that must not be interpolated with environment variables.
+		queryModel, err := json.Marshal(q.Model.Raw)
+		if err != nil {
+			return models.AlertRule{}, fmt.Errorf("invalid model of query '%s': %w", q.RefID.Value(), err)
+		}
+		r.Data = append(r.Data, models.AlertQuery{
+			RefID:     q.RefID.Value(),
+			QueryType: q.QueryType.Value(),
+			RelativeTimeRange: models.RelativeTimeRange{
+				From: models.Duration(time.Duration(q.RelativeTimeRange.From.Value()) * time.Second),
+				To:   models.Duration(time.Duration(q.RelativeTimeRange.To.Value()) * time.Second),
+			},
+			DatasourceUID: q.DatasourceUID.Value(),
+			Model:         queryModel,
+		})
+	}
+	return r, nil
+}
+
+// orgID returns the organization of a resource, resources without orgId belong to the main organization.
+func orgID(v values.Int64Value) int64 {
+	if id := v.Value(); id > 0 {
+		return id
+	}
+	return 1
+}
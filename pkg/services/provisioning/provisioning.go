@@ -8,17 +8,20 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	plugifaces "github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/alerting"
 	dashboardservice "github.com/grafana/grafana/pkg/services/dashboards"
 	datasourceservice "github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/encryption"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/pluginsettings"
+	alertingprovisioning "github.com/grafana/grafana/pkg/services/provisioning/alerting"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
 	"github.com/grafana/grafana/pkg/services/provisioning/notifiers"
 	"github.com/grafana/grafana/pkg/services/provisioning/plugins"
 	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -29,6 +32,7 @@ func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, pluginStore p
 	dashboardService dashboardservice.DashboardProvisioningService,
 	datasourceService datasourceservice.DataSourceService,
 	alertingService *alerting.AlertNotificationService, pluginSettings pluginsettings.Service,
+	folderService dashboardservice.FolderService, secretsService secrets.Service, ac accesscontrol.AccessControl,
 ) (*ProvisioningServiceImpl, error) {
 	s := &ProvisioningServiceImpl{
 		Cfg:                     cfg,
@@ -46,6 +50,10 @@ func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, pluginStore p
 		alertingService:         alertingService,
 		pluginsSettings:         pluginSettings,
 	}
+	if cfg.UnifiedAlerting.IsEnabled() {
+		alertingPath := filepath.Join(cfg.ProvisioningPath, "alerting")
+		s.alertingProvisioner = alertingprovisioning.New(alertingPath, cfg, sqlStore, folderService, secretsService, ac)
+	}
 	return s, nil
 }
 
@@ -105,6 +113,7 @@ type ProvisioningServiceImpl struct {
 	datasourceService       datasourceservice.DataSourceService
 	alertingService         *alerting.AlertNotificationService
 	pluginsSettings         pluginsettings.Service
+	alertingProvisioner     *alertingprovisioning.AlertingProvisioner
 }
 
 func (ps *ProvisioningServiceImpl) RunInitProvisioners(ctx context.Context) error {
@@ -133,6 +142,12 @@ func (ps *ProvisioningServiceImpl) Run(ctx context.Context) error {
 		return err
 	}
 
+	if ps.alertingProvisioner != nil {
+		// the errors are logged, PollChanges applies the files again until they succeed.
+		_ = ps.ProvisionAlerting(ctx)
+		go ps.alertingProvisioner.PollChanges(ctx)
+	}
+
 	for {
 		// Wait for unlock. This is tied to new dashboardProvisioner to be instantiated before we start polling.
 		ps.mutex.Lock()
@@ -208,6 +223,16 @@ func (ps *ProvisioningServiceImpl) ProvisionDashboards(ctx context.Context) erro
 	return nil
 }
 
+// ProvisionAlerting reconciles the unified alerting resources with the files of the alerting directory.
+func (ps *ProvisioningServiceImpl) ProvisionAlerting(ctx context.Context) error {
+	if err := ps.alertingProvisioner.Provision(ctx); err != nil {
+		err = errutil.Wrap("Alerting provisioning error", err)
+		ps.log.Error("Failed to provision alerting", "error", err)
+		return err
+	}
+	return nil
+}
+
 func (ps *ProvisioningServiceImpl) GetDashboardProvisionerResolvedPath(name string) string {
 	return ps.dashboardProvisioner.GetProvisionerResolvedPath(name)
 }
@@ -0,0 +1,602 @@
+package query
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
+)
+
+var cacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "grafana",
+	Name:      "query_cache_requests_total",
+	Help:      "Number of data source queries looked up in the query result cache, by result: hit, partial (the start of the time range is reused) or miss",
+}, []string{"result"})
+
+// volatileQueryFields are the fields of a query model that do not change its result.
+var volatileQueryFields = []string{"refId", "requestId", "datasource", "datasourceId", "intervalMs", "maxDataPoints"}
+
+// rangeVariable is in the name of the template variables of the time range duration, such as $__range,
+// $__range_s and ${__range}. The result of a query that uses them depends on its whole time range,
+// the start of an earlier result can not be reused.
+var rangeVariable = []byte("__range")
+
+// partialRangeDataSources are the types of the data sources whose results are reused for a part of the time range
+// by default, since their queries return time series which are not limited to a number of rows. The
+// queryCachingPartialRanges option of a data source overrides it.
+var partialRangeDataSources = map[string]bool{
+	models.DS_PROMETHEUS: true,
+	models.DS_GRAPHITE:   true,
+}
+
+// sharedQueryTimeout bounds the queries shared by concurrent identical requests, which are not
+// cancelled with the request that started them.
+const sharedQueryTimeout = 5 * time.Minute
+
+var errNotTimeSeries = errors.New("frame has no time field")
+
+// ResultCacheStorage keeps the encoded results of data source queries.
+type ResultCacheStorage interface {
+	// Get returns the value of a key, false when there is none.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set sets the value of a key, it expires after the TTL.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// MemoryResultCacheStorage keeps the results in the memory of the Grafana instance. The least
+// recently used results are evicted when the size of the results exceeds the maximum size.
+type MemoryResultCacheStorage struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	entries map[string]*list.Element
+	// lru has the most recently used entry at the front
+	lru *list.List
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func (e *memoryCacheEntry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+func NewMemoryResultCacheStorage(maxBytes int64) *MemoryResultCacheStorage {
+	return &MemoryResultCacheStorage{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (s *MemoryResultCacheStorage) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expires) {
+		s.remove(el)
+		return nil, false, nil
+	}
+	s.lru.MoveToFront(el)
+	return entry.value, true, nil
+}
+
+func (s *MemoryResultCacheStorage) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+	entry := &memoryCacheEntry{key: key, value: value, expires: time.Now().Add(ttl)}
+	if entry.size() > s.maxBytes {
+		return nil // would evict everything else
+	}
+	s.entries[key] = s.lru.PushFront(entry)
+	s.size += entry.size()
+	for s.size > s.maxBytes {
+		s.remove(s.lru.Back())
+	}
+	return nil
+}
+
+func (s *MemoryResultCacheStorage) remove(el *list.Element) {
+	entry := s.lru.Remove(el).(*memoryCacheEntry)
+	delete(s.entries, entry.key)
+	s.size -= entry.size()
+}
+
+// RemoteResultCacheStorage keeps the results in the remote cache, shared by Grafana instances.
+type RemoteResultCacheStorage struct {
+	cache *remotecache.RemoteCache
+}
+
+func NewRemoteResultCacheStorage(cache *remotecache.RemoteCache) *RemoteResultCacheStorage {
+	return &RemoteResultCacheStorage{cache: cache}
+}
+
+func (s *RemoteResultCacheStorage) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	v, err := s.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	b, ok := v.([]byte)
+	return b, ok, nil
+}
+
+func (s *RemoteResultCacheStorage) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.cache.Set(ctx, key, value, ttl)
+}
+
+// resultCache caches the results of data source queries by data source, normalized query model
+// and time range aligned to the query interval. The latest result of a query is also kept for any
+// time range, so that a later query of a time series only asks the data source for the end of its
+// range. Concurrent identical requests are sent once to the data source, every caller stops waiting
+// for the shared query when its own context is done.
+type resultCache struct {
+	storage    ResultCacheStorage
+	defaultTTL time.Duration
+	group      singleflight.Group
+	log        log.Logger
+}
+
+func newResultCache(storage ResultCacheStorage, defaultTTL time.Duration) *resultCache {
+	return &resultCache{
+		storage:    storage,
+		defaultTTL: defaultTTL,
+		log:        log.New("query_data.cache"),
+	}
+}
+
+// cachedResult is the result of a query for a time range.
+type cachedResult struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Frames [][]byte  `json:"frames"`
+	// TimeSeries is true when all the frames are time series, only then the start of the result is reused.
+	TimeSeries bool `json:"timeSeries,omitempty"`
+}
+
+type pendingQuery struct {
+	query     backend.DataQuery
+	key       string
+	latestKey string
+	// latest is the result of the query for an earlier time range, whose start is reused.
+	latest *cachedResult
+}
+
+type queryFunc func(context.Context, *backend.QueryDataRequest) (*backend.QueryDataResponse, error)
+
+// queryData answers the queries from the cache and sends the other ones to the data source with their
+// time range unchanged. With skipCache the cache is not read, but it is updated.
+func (c *resultCache) queryData(ctx context.Context, req *backend.QueryDataRequest, ds *models.DataSource, skipCache bool, query queryFunc) (*backend.QueryDataResponse, error) {
+	ttl := c.ttl(ds)
+	if ttl <= 0 {
+		return query(ctx, req)
+	}
+	partialRanges := c.partialRanges(ds)
+
+	resp := backend.NewQueryDataResponse()
+	pending := make([]pendingQuery, 0, len(req.Queries))
+	var groupKey strings.Builder
+	for _, q := range req.Queries {
+		key, latestKey, err := queryKeys(ds, q)
+		if err != nil {
+			return nil, err
+		}
+		if !partialRanges {
+			latestKey = ""
+		}
+		p := pendingQuery{query: q, key: key, latestKey: latestKey}
+
+		if !skipCache {
+			if result, ok := c.get(ctx, key); ok {
+				if frames, err := result.frames(q.RefID); err == nil {
+					cacheRequestsTotal.WithLabelValues("hit").Inc()
+					resp.Responses[q.RefID] = backend.DataResponse{Frames: frames}
+					continue
+				}
+			}
+			if latestKey != "" {
+				if latest, ok := c.get(ctx, latestKey); ok && latest.extends(q) {
+					p.latest = latest
+				}
+			}
+		}
+
+		if p.latest != nil {
+			cacheRequestsTotal.WithLabelValues("partial").Inc()
+		} else {
+			cacheRequestsTotal.WithLabelValues("miss").Inc()
+		}
+		pending = append(pending, p)
+		fmt.Fprintf(&groupKey, "%s=%s:%t;", q.RefID, key, p.latest != nil)
+	}
+	if len(pending) == 0 {
+		return resp, nil
+	}
+
+	shared := c.group.DoChan(groupKey.String(), func() (interface{}, error) {
+		sharedCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, sharedQueryTimeout)
+		defer cancel()
+		return c.queryPending(sharedCtx, req, pending, ttl, query)
+	})
+	var result singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result = <-shared:
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	for refID, r := range result.Val.(backend.Responses) {
+		resp.Responses[refID] = r
+	}
+	return resp, nil
+}
+
+// detachedContext keeps the values of its parent, such as the tracing span, but neither its deadline
+// nor its cancellation, so that a shared query is not cancelled with the request that started it.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// queryPending sends the queries to the data source and caches the results without error. The queries
+// with a latest result only ask for the end of their time range, the result is merged with the latest one.
+func (c *resultCache) queryPending(ctx context.Context, req *backend.QueryDataRequest, pending []pendingQuery, ttl time.Duration, query queryFunc) (backend.Responses, error) {
+	sent := *req
+	sent.Queries = make([]backend.DataQuery, 0, len(pending))
+	for _, p := range pending {
+		q := p.query
+		if p.latest != nil {
+			q.TimeRange.From = overlapStart(p.latest, q)
+		}
+		sent.Queries = append(sent.Queries, q)
+	}
+
+	resp, err := query(ctx, &sent)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := backend.Responses{}
+	var retry []pendingQuery
+	for _, p := range pending {
+		r, ok := resp.Responses[p.query.RefID]
+		if !ok {
+			continue
+		}
+		if r.Error != nil {
+			responses[p.query.RefID] = r
+			continue
+		}
+		if p.latest != nil {
+			frames, err := p.latest.merge(r.Frames, p.query)
+			if err != nil {
+				// the result is not a time series, it is queried again for the whole time range
+				retry = append(retry, pendingQuery{query: p.query, key: p.key, latestKey: p.latestKey})
+				continue
+			}
+			r.Frames = frames
+		}
+		responses[p.query.RefID] = r
+		c.set(ctx, p, r.Frames, ttl)
+	}
+
+	if len(retry) > 0 {
+		retried, err := c.queryPending(ctx, req, retry, ttl, query)
+		if err != nil {
+			return nil, err
+		}
+		for refID, r := range retried {
+			responses[refID] = r
+		}
+	}
+	return responses, nil
+}
+
+// ttl returns the time the results of a data source are cached, the queryCachingTTL
+// option of the data source overrides the default one. Results are not cached when 0.
+func (c *resultCache) ttl(ds *models.DataSource) time.Duration {
+	if ds.Uid == grafanads.DatasourceUID {
+		return 0
+	}
+	if ds.JsonData == nil {
+		return c.defaultTTL
+	}
+	option := ds.JsonData.Get("queryCachingTTL").MustString()
+	if option == "" {
+		return c.defaultTTL
+	}
+	ttl, err := gtime.ParseDuration(option)
+	if err != nil {
+		c.log.Warn("Invalid queryCachingTTL of data source", "uid", ds.Uid, "error", err)
+		return c.defaultTTL
+	}
+	return ttl
+}
+
+// partialRanges returns true when the start of the earlier results of the queries of a data source is reused.
+func (c *resultCache) partialRanges(ds *models.DataSource) bool {
+	if ds.JsonData == nil {
+		return partialRangeDataSources[ds.Type]
+	}
+	return ds.JsonData.Get("queryCachingPartialRanges").MustBool(partialRangeDataSources[ds.Type])
+}
+
+func (c *resultCache) get(ctx context.Context, key string) (*cachedResult, bool) {
+	value, ok, err := c.storage.Get(ctx, key)
+	if err != nil {
+		c.log.Error("Failed to get query result from the cache", "error", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	var result cachedResult
+	if err := json.Unmarshal(value, &result); err != nil {
+		c.log.Error("Failed to decode cached query result", "error", err)
+		return nil, false
+	}
+	return &result, true
+}
+
+func (c *resultCache) set(ctx context.Context, p pendingQuery, frames data.Frames, ttl time.Duration) {
+	encoded, err := frames.MarshalArrow()
+	if err != nil {
+		c.log.Error("Failed to encode query result", "error", err)
+		return
+	}
+	timeSeries := true
+	for _, f := range frames {
+		if !isTimeSeries(f) {
+			timeSeries = false
+			break
+		}
+	}
+	value, err := json.Marshal(cachedResult{From: p.query.TimeRange.From, To: p.query.TimeRange.To, Frames: encoded, TimeSeries: timeSeries})
+	if err != nil {
+		c.log.Error("Failed to encode query result", "error", err)
+		return
+	}
+	for _, key := range []string{p.key, p.latestKey} {
+		if key == "" {
+			continue
+		}
+		if err := c.storage.Set(ctx, key, value, ttl); err != nil {
+			c.log.Error("Failed to cache query result", "error", err)
+			return
+		}
+	}
+}
+
+// frames returns the decoded frames with the ref ID of the query.
+func (r *cachedResult) frames(refID string) (data.Frames, error) {
+	frames, err := data.UnmarshalArrowFrames(r.Frames)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range frames {
+		f.RefID = refID
+	}
+	return frames, nil
+}
+
+// extends returns true when the latest result of a query can be extended to the time range
+// of the query, by querying the data source from the overlap start.
+func (r *cachedResult) extends(q backend.DataQuery) bool {
+	return r.TimeSeries && !r.From.After(q.TimeRange.From) && !r.To.After(q.TimeRange.To) && overlapStart(r, q).After(q.TimeRange.From)
+}
+
+// merge returns the frames of a time series for the time range of the query: the rows of the
+// latest result before the overlap start followed by the rows of the frames queried from the
+// overlap start. Frames are matched by name and fields.
+func (r *cachedResult) merge(tail data.Frames, q backend.DataQuery) (data.Frames, error) {
+	latest, err := r.frames(q.RefID)
+	if err != nil {
+		return nil, err
+	}
+	from, overlap := q.TimeRange.From, overlapStart(r, q)
+	beforeOverlap := func(t time.Time) bool { return !t.Before(from) && t.Before(overlap) }
+	afterOverlap := func(t time.Time) bool { return !t.Before(overlap) }
+
+	latestByKey := make(map[string]*data.Frame, len(latest))
+	for _, f := range latest {
+		latestByKey[frameKey(f)] = f
+	}
+
+	merged := make(data.Frames, 0, len(tail))
+	for _, f := range tail {
+		if !isTimeSeries(f) {
+			return nil, errNotTimeSeries
+		}
+		key := frameKey(f)
+		frame := emptyCopy(f)
+		if l, ok := latestByKey[key]; ok {
+			delete(latestByKey, key)
+			if err := appendRows(frame, l, beforeOverlap); err != nil {
+				return nil, err
+			}
+		}
+		if err := appendRows(frame, f, afterOverlap); err != nil {
+			return nil, err
+		}
+		merged = append(merged, frame)
+	}
+	// series which ended before the overlap start
+	for _, l := range latest {
+		if _, ok := latestByKey[frameKey(l)]; !ok {
+			continue
+		}
+		frame := emptyCopy(l)
+		if err := appendRows(frame, l, beforeOverlap); err != nil {
+			return nil, err
+		}
+		if frame.Rows() > 0 {
+			merged = append(merged, frame)
+		}
+	}
+	return merged, nil
+}
+
+// isTimeSeries returns true when the frame is a time series, whose rows can be split by time. Logs and
+// tables are not, even when they have a time field.
+func isTimeSeries(f *data.Frame) bool {
+	if f.Meta != nil {
+		if f.Meta.PreferredVisualization == data.VisTypeLogs {
+			return false
+		}
+		switch f.Meta.Type {
+		case data.FrameTypeTimeSeriesWide, data.FrameTypeTimeSeriesLong, data.FrameTypeTimeSeriesMany:
+			return true
+		case data.FrameTypeUnknown:
+		default:
+			return false
+		}
+	}
+	return f.TimeSeriesSchema().Type != data.TimeSeriesTypeNot
+}
+
+// emptyCopy returns a copy of a frame without rows, unlike data.Frame.EmptyCopy
+// it keeps the metadata of the frame and the config of the fields.
+func emptyCopy(f *data.Frame) *data.Frame {
+	frame := f.EmptyCopy()
+	frame.Meta = f.Meta
+	for i, field := range f.Fields {
+		frame.Fields[i].Config = field.Config
+	}
+	return frame
+}
+
+// appendRows appends the rows of src, whose time is kept, to dst which has the same fields.
+func appendRows(dst, src *data.Frame, keep func(time.Time) bool) error {
+	timeIndex := -1
+	for i, f := range src.Fields {
+		if f.Type() == data.FieldTypeTime || f.Type() == data.FieldTypeNullableTime {
+			timeIndex = i
+			break
+		}
+	}
+	if timeIndex < 0 {
+		return errNotTimeSeries
+	}
+	timeField := src.Fields[timeIndex]
+	for row := 0; row < src.Rows(); row++ {
+		t, ok := timeField.ConcreteAt(row)
+		if !ok || !keep(t.(time.Time)) {
+			continue
+		}
+		for i, f := range src.Fields {
+			dst.Fields[i].Append(f.CopyAt(row))
+		}
+	}
+	return nil
+}
+
+// frameKey identifies the frames of the same series in the results of a query.
+func frameKey(f *data.Frame) string {
+	var sb strings.Builder
+	sb.WriteString(f.Name)
+	for _, field := range f.Fields {
+		fmt.Fprintf(&sb, "\x00%s\x00%s\x00%s", field.Name, field.Labels.String(), field.Type().ItemTypeString())
+	}
+	return sb.String()
+}
+
+// overlapStart is where the latest result stops being reused, its last interval is queried again
+// since it may have been incomplete.
+func overlapStart(latest *cachedResult, q backend.DataQuery) time.Time {
+	return latest.To.Add(-alignmentStep(q))
+}
+
+func alignmentStep(q backend.DataQuery) time.Duration {
+	if q.Interval < time.Second {
+		return time.Second
+	}
+	return q.Interval
+}
+
+// alignTimeRange aligns the time range of a query to its interval, so that the queries of a dashboard
+// refreshed by several viewers at about the same time have the same cache key. Only the key uses it,
+// the data source is queried for the time range of the caller.
+func alignTimeRange(q backend.DataQuery) backend.TimeRange {
+	step := alignmentStep(q)
+	from, to := q.TimeRange.From.Truncate(step), q.TimeRange.To.Truncate(step)
+	if !to.After(from) {
+		return q.TimeRange
+	}
+	return backend.TimeRange{From: from, To: to}
+}
+
+// queryKeys returns the cache key of the result of a query, for its time range aligned to its
+// interval, and the key of its latest result for any time range. The latest key is empty when
+// the result of the query depends on the duration of its time range.
+func queryKeys(ds *models.DataSource, q backend.DataQuery) (string, string, error) {
+	model, err := normalizeQueryModel(q.JSON)
+	if err != nil {
+		return "", "", err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%d\x00%s\x00%d\x00%d\x00", ds.OrgId, ds.Uid, ds.Version, q.QueryType, q.MaxDataPoints, q.Interval)
+	_, _ = h.Write(model)
+	latestKey := ""
+	if !bytes.Contains(model, rangeVariable) {
+		latestKey = "query-cache-latest-" + hex.EncodeToString(h.Sum(nil))
+	}
+	tr := alignTimeRange(q)
+	fmt.Fprintf(h, "\x00%d\x00%d", tr.From.UnixNano(), tr.To.UnixNano())
+	return "query-cache-" + hex.EncodeToString(h.Sum(nil)), latestKey, nil
+}
+
+// normalizeQueryModel returns the query model without the fields which do not change
+// its result, with sorted keys.
+func normalizeQueryModel(model json.RawMessage) ([]byte, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(model, &fields); err != nil {
+		return nil, err
+	}
+	for _, f := range volatileQueryFields {
+		delete(fields, f)
+	}
+	return json.Marshal(fields)
+}
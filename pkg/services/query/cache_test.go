@@ -0,0 +1,342 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// fakeDataSource returns a point per minute of the time range of the queries, with the minute as value.
+type fakeDataSource struct {
+	queries []backend.DataQuery
+	err     error
+	frame   func(q backend.DataQuery) *data.Frame
+}
+
+func (f *fakeDataSource) QueryData(_ context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	for _, q := range req.Queries {
+		f.queries = append(f.queries, q)
+		if f.err != nil {
+			resp.Responses[q.RefID] = backend.DataResponse{Error: f.err}
+			continue
+		}
+		frame := timeSeriesFrame(q.TimeRange)
+		if f.frame != nil {
+			frame = f.frame(q)
+		}
+		frame.RefID = q.RefID
+		resp.Responses[q.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+	}
+	return resp, nil
+}
+
+func timeSeriesFrame(tr backend.TimeRange) *data.Frame {
+	var times []time.Time
+	var values []float64
+	for t := tr.From.Truncate(time.Minute); !t.After(tr.To); t = t.Add(time.Minute) {
+		if t.Before(tr.From) {
+			continue
+		}
+		times = append(times, t)
+		values = append(values, float64(t.Minute()))
+	}
+	return data.NewFrame("cpu",
+		data.NewField("time", nil, times),
+		data.NewField("value", data.Labels{"host": "a"}, values),
+	)
+}
+
+func cacheTestRequest(from, to time.Time, refIDs ...string) *backend.QueryDataRequest {
+	req := &backend.QueryDataRequest{}
+	for _, refID := range refIDs {
+		req.Queries = append(req.Queries, backend.DataQuery{
+			RefID:     refID,
+			TimeRange: backend.TimeRange{From: from, To: to},
+			Interval:  time.Minute,
+			JSON:      json.RawMessage(`{"refId":"` + refID + `","expr":"cpu"}`),
+		})
+	}
+	return req
+}
+
+func TestResultCache(t *testing.T) {
+	ctx := context.Background()
+	ds := &models.DataSource{OrgId: 1, Uid: "prometheus", Type: models.DS_PROMETHEUS}
+	start := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("answers identical queries from the cache", func(t *testing.T) {
+		c := newResultCache(NewMemoryResultCacheStorage(1024*1024), time.Minute)
+		fake := &fakeDataSource{}
+
+		resp, err := c.queryData(ctx, cacheTestRequest(start, start.Add(10*time.Minute+20*time.Second), "A"), ds, false, fake.QueryData)
+		require.NoError(t, err)
+		require.Len(t, fake.queries, 1)
+		// the data source is queried for the time range of the caller, only the cache key is aligned to the interval
+		require.Equal(t, backend.TimeRange{From: start, To: start.Add(10*time.Minute + 20*time.Second)}, fake.queries[0].TimeRange)
+		require.Equal(t, 11, resp.Responses["A"].Frames[0].Rows())
+
+		resp, err = c.queryData(ctx, cacheTestRequest(start.Add(30*time.Second), start.Add(10*time.Minute+40*time.Second), "B"), ds, false, fake.QueryData)
+		require.NoError(t, err)
+		require.Len(t, fake.queries, 1)
+		frame := resp.Responses["B"].Frames[0]
+		require.Equal(t, "B", frame.RefID)
+		require.Equal(t, 11, frame.Rows())
+		require.Equal(t, data.Labels{"host": "a"}, frame.Fields[1].Labels)
+
+		_, err = c.queryData(ctx, cacheTestRequest(start, start.Add(10*time.Minute), "A"), ds, true, fake.QueryData)
+		require.NoError(t, err)
+		require.Len(t, fake.queries, 2)
+	})
+
+	t.Run("queries the end of the time range of a time series", func(t *testing.T) {
+		c := newResultCache(NewMemoryResultCacheStorage(1024*1024), time.Minute)
+		fake := &fakeDataSource{}
+
+		_, err := c.queryData(ctx, cacheTestRequest(start, start.Add(10*time.Minute), "A"), ds, false, fake.QueryData)
+		require.NoError(t, err)
+
+		resp, err := c.queryData(ctx, cacheTestRequest(start.Add(2*time.Minute), start.Add(12*time.Minute), "A"), ds, false, fake.QueryData)
+		require.NoError(t, err)
+		require.Len(t, fake.queries, 2)
+		// the last interval of the cached result is queried again
+		require.Equal(t, backend.TimeRange{From: start.Add(9 * time.Minute), To: start.Add(12 * time.Minute)}, fake.queries[1].TimeRange)
+
+		frame := resp.Responses["A"].Frames[0]
+		require.Equal(t, 11, frame.Rows())
+		for i := 0; i < frame.Rows(); i++ {
+			require.True(t, start.Add(time.Duration(i+2)*time.Minute).Equal(frame.Fields[0].At(i).(time.Time)))
+			require.Equal(t, float64(i+2), frame.Fields[1].At(i))
+		}
+
+		// the merged result is cached
+		_, err = c.queryData(ctx, cacheTestRequest(start.Add(2*time.Minute), start.Add(12*time.Minute), "A"), ds, false, fake.QueryData)
+		require.NoError(t, err)
+		require.Len(t, fake.queries, 2)
+	})
+
+	t.Run("queries the whole time range when the query uses its duration", func(t *testing.T) {
+		c := newResultCache(NewMemoryResultCacheStorage(1024*1024), time.Minute)
+		fake := &fakeDataSource{}
+		rangeRequest := func(from, to time.Time) *backend.QueryDataRequest {
+			req := cacheTestRequest(from, to, "A")
+			req.Queries[0].JSON = json.RawMessage(`{"refId":"A","expr":"increase(cpu[${__range}])"}`)
+			return req
+		}
+		_, err := c.queryData(ctx, rangeRequest(start, start.Add(10*time.Minute)), ds, false, fake.QueryData)
+		require.NoError(t, err)
+
+		_, err = c.queryData(ctx, rangeRequest(start.Add(2*time.Minute), start.Add(12*time.Minute)), ds, false, fake.QueryData)
+		require.NoError(t, err)
+		require.Len(t, fake.queries, 2)
+		require.Equal(t, backend.TimeRange{From: start.Add(2 * time.Minute), To: start.Add(12 * time.Minute)}, fake.queries[1].TimeRange)
+	})
+
+	t.Run("queries the whole time range when the result is not a time series", func(t *testing.T) {
+		c := newResultCache(NewMemoryResultCacheStorage(1024*1024), time.Minute)
+		fake := &fakeDataSource{}
+		_, err := c.queryData(ctx, cacheTestRequest(start, start.Add(10*time.Minute), "A"), ds, false, fake.QueryData)
+		require.NoError(t, err)
+
+		fake.frame = func(q backend.DataQuery) *data.Frame {
+			return data.NewFrame("table", data.NewField("value", nil, []float64{1}))
+		}
+		resp, err := c.queryData(ctx, cacheTestRequest(start.Add(2*time.Minute), start.Add(12*time.Minute), "A"), ds, false, fake.QueryData)
+		require.NoError(t, err)
+		require.Len(t, fake.queries, 3)
+		require.Equal(t, backend.TimeRange{From: start.Add(2 * time.Minute), To: start.Add(12 * time.Minute)}, fake.queries[2].TimeRange)
+		require.Equal(t, "table", resp.Responses["A"].Frames[0].Name)
+	})
+
+	t.Run("queries the whole time range of logs", func(t *testing.T) {
+		c := newResultCache(NewMemoryResultCacheStorage(1024*1024), time.Minute)
+		fake := &fakeDataSource{frame: func(q backend.DataQuery) *data.Frame {
+			frame := timeSeriesFrame(q.TimeRange)
+			frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeLogs}
+			return frame
+		}}
+		_, err := c.queryData(ctx, cacheTestRequest(start, start.Add(10*time.Minute), "A"), ds, false, fake.QueryData)
+		require.NoError(t, err)
+
+		_, err = c.queryData(ctx, cacheTestRequest(start.Add(2*time.Minute), start.Add(12*time.Minute), "A"), ds, false, fake.QueryData)
+		require.NoError(t, err)
+		require.Len(t, fake.queries, 2)
+		require.Equal(t, backend.TimeRange{From: start.Add(2 * time.Minute), To: start.Add(12 * time.Minute)}, fake.queries[1].TimeRange)
+	})
+
+	t.Run("reuses the start of the results of the data sources that opt in", func(t *testing.T) {
+		partialRange := func(ds *models.DataSource) backend.TimeRange {
+			c := newResultCache(NewMemoryResultCacheStorage(1024*1024), time.Minute)
+			fake := &fakeDataSource{}
+			_, err := c.queryData(ctx, cacheTestRequest(start, start.Add(10*time.Minute), "A"), ds, false, fake.QueryData)
+			require.NoError(t, err)
+			_, err = c.queryData(ctx, cacheTestRequest(start.Add(2*time.Minute), start.Add(12*time.Minute), "A"), ds, false, fake.QueryData)
+			require.NoError(t, err)
+			return fake.queries[1].TimeRange
+		}
+		// the rows of a SQL query may be limited, its time range is queried again unless the data source opts in
+		sqlDS := &models.DataSource{OrgId: 1, Uid: "mysql", Type: models.DS_MYSQL}
+		require.Equal(t, start.Add(2*time.Minute), partialRange(sqlDS).From)
+		sqlDS.JsonData = simplejson.NewFromAny(map[string]interface{}{"queryCachingPartialRanges": true})
+		require.Equal(t, start.Add(9*time.Minute), partialRange(sqlDS).From)
+
+		promDS := &models.DataSource{OrgId: 1, Uid: "prometheus", Type: models.DS_PROMETHEUS, JsonData: simplejson.NewFromAny(map[string]interface{}{"queryCachingPartialRanges": false})}
+		require.Equal(t, start.Add(2*time.Minute), partialRange(promDS).From)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		c := newResultCache(NewMemoryResultCacheStorage(1024*1024), time.Minute)
+		fake := &fakeDataSource{err: errors.New("query failed")}
+		for i := 0; i < 2; i++ {
+			resp, err := c.queryData(ctx, cacheTestRequest(start, start.Add(10*time.Minute), "A"), ds, false, fake.QueryData)
+			require.NoError(t, err)
+			require.EqualError(t, resp.Responses["A"].Error, "query failed")
+		}
+		require.Len(t, fake.queries, 2)
+	})
+
+	t.Run("uses the TTL of the data source", func(t *testing.T) {
+		c := newResultCache(NewMemoryResultCacheStorage(1024*1024), time.Minute)
+		fake := &fakeDataSource{}
+		noCacheDS := &models.DataSource{OrgId: 1, Uid: "mysql", JsonData: simplejson.NewFromAny(map[string]interface{}{"queryCachingTTL": "0"})}
+		for i := 0; i < 2; i++ {
+			_, err := c.queryData(ctx, cacheTestRequest(start, start.Add(10*time.Minute), "A"), noCacheDS, false, fake.QueryData)
+			require.NoError(t, err)
+		}
+		require.Len(t, fake.queries, 2)
+		require.Equal(t, start.Add(10*time.Minute), fake.queries[1].TimeRange.To)
+
+		require.Equal(t, 5*time.Minute, c.ttl(&models.DataSource{JsonData: simplejson.NewFromAny(map[string]interface{}{"queryCachingTTL": "5m"})}))
+		require.Equal(t, time.Minute, c.ttl(&models.DataSource{JsonData: simplejson.New()}))
+	})
+}
+
+// blockingDataSource answers the queries once release is closed, or fails when the context of the query is done.
+type blockingDataSource struct {
+	started chan struct{}
+	release chan struct{}
+	calls   int32
+}
+
+func (f *blockingDataSource) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	atomic.AddInt32(&f.calls, 1)
+	close(f.started)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-f.release:
+	}
+	return (&fakeDataSource{}).QueryData(ctx, req)
+}
+
+func TestResultCacheSharedQuery(t *testing.T) {
+	ds := &models.DataSource{OrgId: 1, Uid: "prometheus"}
+	start := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newResultCache(NewMemoryResultCacheStorage(1024*1024), time.Minute)
+	fake := &blockingDataSource{started: make(chan struct{}), release: make(chan struct{})}
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.queryData(firstCtx, cacheTestRequest(start, start.Add(10*time.Minute), "A"), ds, false, fake.QueryData)
+		firstErr <- err
+	}()
+	<-fake.started
+
+	second := make(chan *backend.QueryDataResponse, 1)
+	go func() {
+		resp, err := c.queryData(context.Background(), cacheTestRequest(start, start.Add(10*time.Minute), "A"), ds, false, fake.QueryData)
+		require.NoError(t, err)
+		second <- resp
+	}()
+
+	// the first caller stops waiting, the shared query goes on for the second one
+	cancelFirst()
+	require.ErrorIs(t, <-firstErr, context.Canceled)
+	close(fake.release)
+	resp := <-second
+	require.Equal(t, 11, resp.Responses["A"].Frames[0].Rows())
+	require.Equal(t, int32(1), atomic.LoadInt32(&fake.calls))
+}
+
+func TestMemoryResultCacheStorage(t *testing.T) {
+	ctx := context.Background()
+	value := make([]byte, 92)
+	// the size of an entry is the size of its key and its value: 100 bytes
+	s := NewMemoryResultCacheStorage(250)
+
+	require.NoError(t, s.Set(ctx, "key-000a", value, time.Minute))
+	require.NoError(t, s.Set(ctx, "key-000b", value, time.Minute))
+	_, ok, err := s.Get(ctx, "key-000a")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// the least recently used entry is evicted
+	require.NoError(t, s.Set(ctx, "key-000c", value, time.Minute))
+	_, ok, _ = s.Get(ctx, "key-000b")
+	require.False(t, ok)
+	_, ok, _ = s.Get(ctx, "key-000a")
+	require.True(t, ok)
+	require.EqualValues(t, 200, s.size)
+
+	// values larger than the cache are not kept
+	require.NoError(t, s.Set(ctx, "key-000d", make([]byte, 300), time.Minute))
+	_, ok, _ = s.Get(ctx, "key-000d")
+	require.False(t, ok)
+
+	require.NoError(t, s.Set(ctx, "key-000e", value, -time.Second))
+	_, ok, _ = s.Get(ctx, "key-000e")
+	require.False(t, ok)
+}
+
+func TestQueryKeys(t *testing.T) {
+	ds := &models.DataSource{OrgId: 1, Uid: "prometheus"}
+	query := func(model string) backend.DataQuery {
+		return backend.DataQuery{
+			TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(60, 0)},
+			Interval:  time.Second,
+			JSON:      json.RawMessage(model),
+		}
+	}
+
+	key, latestKey, err := queryKeys(ds, query(`{"refId":"A","expr":"cpu","legendFormat":"{{host}}","requestId":"1"}`))
+	require.NoError(t, err)
+	sameKey, sameLatestKey, err := queryKeys(ds, query(`{"legendFormat":"{{host}}","expr":"cpu","refId":"B","requestId":"2"}`))
+	require.NoError(t, err)
+	require.Equal(t, key, sameKey)
+	require.Equal(t, latestKey, sameLatestKey)
+	require.NotEqual(t, key, latestKey)
+
+	otherKey, _, err := queryKeys(ds, query(`{"refId":"A","expr":"memory","legendFormat":"{{host}}"}`))
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherKey)
+
+	q := query(`{"refId":"A","expr":"cpu","legendFormat":"{{host}}"}`)
+	q.TimeRange.To = time.Unix(120, 0)
+	otherRangeKey, otherRangeLatestKey, err := queryKeys(ds, q)
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherRangeKey)
+	require.Equal(t, latestKey, otherRangeLatestKey)
+
+	for _, variable := range []string{"$__range", "$__range_s", "${__range}"} {
+		_, rangeLatestKey, err := queryKeys(ds, query(`{"refId":"A","expr":"increase(cpu[`+variable+`])"}`))
+		require.NoError(t, err)
+		require.Empty(t, rangeLatestKey, variable)
+	}
+
+	// the key is the same for the time ranges in the same interval
+	q.TimeRange.To = q.TimeRange.To.Add(500 * time.Millisecond)
+	alignedRangeKey, _, err := queryKeys(ds, q)
+	require.NoError(t, err)
+	require.Equal(t, otherRangeKey, alignedRangeKey)
+}
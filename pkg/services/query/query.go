@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
@@ -35,6 +36,7 @@ func ProvideService(
 	dataSourceService datasources.DataSourceService,
 	pluginClient plugins.Client,
 	oAuthTokenService oauthtoken.OAuthTokenService,
+	remoteCache *remotecache.RemoteCache,
 ) *Service {
 	g := &Service{
 		cfg:                    cfg,
@@ -47,6 +49,14 @@ func ProvideService(
 		log:                    log.New("query_data"),
 	}
 	g.log.Info("Query Service initialization")
+
+	if cfg != nil && cfg.QueryCaching.Enabled {
+		var storage ResultCacheStorage = NewMemoryResultCacheStorage(cfg.QueryCaching.MaxMemoryBytes)
+		if cfg.QueryCaching.Backend == setting.QueryCachingBackendRemote {
+			storage = NewRemoteResultCacheStorage(remoteCache)
+		}
+		g.resultCache = newResultCache(storage, cfg.QueryCaching.TTL)
+	}
 	return g
 }
 
@@ -58,6 +68,7 @@ type Service struct {
 	dataSourceService      datasources.DataSourceService
 	pluginClient           plugins.Client
 	oAuthTokenService      oauthtoken.OAuthTokenService
+	resultCache            *resultCache
 	log                    log.Logger
 }
 
@@ -76,7 +87,7 @@ func (s *Service) QueryData(ctx context.Context, user *models.SignedInUser, skip
 	if handleExpressions && parsedReq.hasExpression {
 		return s.handleExpressions(ctx, user, parsedReq)
 	}
-	return s.handleQueryData(ctx, user, parsedReq)
+	return s.handleQueryData(ctx, user, skipCache, parsedReq)
 }
 
 // handleExpressions handles POST /api/ds/query when there is an expression.
@@ -112,7 +123,7 @@ func (s *Service) handleExpressions(ctx context.Context, user *models.SignedInUs
 	return qdr, nil
 }
 
-func (s *Service) handleQueryData(ctx context.Context, user *models.SignedInUser, parsedReq *parsedRequest) (*backend.QueryDataResponse, error) {
+func (s *Service) handleQueryData(ctx context.Context, user *models.SignedInUser, skipCache bool, parsedReq *parsedRequest) (*backend.QueryDataResponse, error) {
 	ds := parsedReq.parsedQueries[0].datasource
 	if err := s.pluginRequestValidator.Validate(ds.Url, nil); err != nil {
 		return nil, models.ErrDataSourceAccessDenied
@@ -134,7 +145,8 @@ func (s *Service) handleQueryData(ctx context.Context, user *models.SignedInUser
 		Queries: []backend.DataQuery{},
 	}
 
-	if s.oAuthTokenService.IsOAuthPassThruEnabled(ds) {
+	oAuthPassThru := s.oAuthTokenService.IsOAuthPassThruEnabled(ds)
+	if oAuthPassThru {
 		if token := s.oAuthTokenService.GetCurrentOAuthToken(ctx, user); token != nil {
 			req.Headers["Authorization"] = fmt.Sprintf("%s %s", token.Type(), token.AccessToken)
 
@@ -153,6 +165,10 @@ func (s *Service) handleQueryData(ctx context.Context, user *models.SignedInUser
 		req.Queries = append(req.Queries, q.query)
 	}
 
+	// the results of queries with the token of the user are not shared
+	if s.resultCache != nil && !oAuthPassThru {
+		return s.resultCache.queryData(ctx, req, ds, skipCache, s.pluginClient.QueryData)
+	}
 	return s.pluginClient.QueryData(ctx, req)
 }
 
@@ -60,6 +60,12 @@ func (qs *QuotaService) QuotaReached(c *models.ReqContext, target string) (bool,
 
 // CheckQuotaReached check that quota is reached for a target. If ScopeParameters are not defined, only global scope is checked
 func (qs *QuotaService) CheckQuotaReached(ctx context.Context, target string, scopeParams *ScopeParameters) (bool, error) {
+	return qs.CheckQuotaReachedFor(ctx, target, scopeParams, 1)
+}
+
+// CheckQuotaReachedFor checks that the quota of a target does not leave room for count more items, e.g. before
+// they are created together. If ScopeParameters are not defined, only global scope is checked
+func (qs *QuotaService) CheckQuotaReachedFor(ctx context.Context, target string, scopeParams *ScopeParameters, count int64) (bool, error) {
 	if !qs.Cfg.Quota.Enabled {
 		return false, nil
 	}
@@ -95,7 +101,7 @@ func (qs *QuotaService) CheckQuotaReached(ctx context.Context, target string, sc
 			if err := qs.SQLStore.GetGlobalQuotaByTarget(ctx, &query); err != nil {
 				return true, err
 			}
-			if query.Result.Used >= scope.DefaultLimit {
+			if query.Result.Used+count > scope.DefaultLimit {
 				return true, nil
 			}
 		case "org":
@@ -118,7 +124,7 @@ func (qs *QuotaService) CheckQuotaReached(ctx context.Context, target string, sc
 				return true, nil
 			}
 
-			if query.Result.Used >= query.Result.Limit {
+			if query.Result.Used+count > query.Result.Limit {
 				return true, nil
 			}
 		case "user":
@@ -136,7 +142,7 @@ func (qs *QuotaService) CheckQuotaReached(ctx context.Context, target string, sc
 				return true, nil
 			}
 
-			if query.Result.Used >= query.Result.Limit {
+			if query.Result.Used+count > query.Result.Limit {
 				return true, nil
 			}
 		}
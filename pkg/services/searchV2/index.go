@@ -32,9 +32,25 @@ type eventStore interface {
 type dashboardIndex struct {
 	mu         sync.RWMutex
 	loader     dashboardLoader
-	dashboards map[int64][]dashboard // orgId -> []dashboards
+	dashboards map[int64][]dashboard  // orgId -> []dashboards
+	search     map[int64]*searchIndex // orgId -> full text index, built on first search
 	eventStore eventStore
 	logger     log.Logger
+	opts       indexOptions
+
+	// Persistence state, updated with mu.
+	dirty            bool // dashboards changed since the last save
+	persistedEventID int64
+	persistedAt      time.Time
+}
+
+type indexOptions struct {
+	// store persists the index between restarts, nil keeps it in memory only.
+	store *fileIndexStore
+	// fullReindexInterval of 0 disables the periodic rebuild from the database.
+	fullReindexInterval time.Duration
+	// rebuildOnStartup ignores the persisted index.
+	rebuildOnStartup bool
 }
 
 type dashboard struct {
@@ -48,53 +64,164 @@ type dashboard struct {
 	info     *extract.DashboardInfo
 }
 
-func newDashboardIndex(dashLoader dashboardLoader, evStore eventStore) *dashboardIndex {
+func newDashboardIndex(dashLoader dashboardLoader, evStore eventStore, opts indexOptions) *dashboardIndex {
 	return &dashboardIndex{
 		loader:     dashLoader,
 		eventStore: evStore,
 		dashboards: map[int64][]dashboard{},
+		search:     map[int64]*searchIndex{},
 		logger:     log.New("dashboardIndex"),
+		opts:       opts,
 	}
 }
 
 func (i *dashboardIndex) run(ctx context.Context) error {
-	fullReIndexTicker := time.NewTicker(5 * time.Minute)
-	defer fullReIndexTicker.Stop()
-
 	partialUpdateTicker := time.NewTicker(5 * time.Second)
 	defer partialUpdateTicker.Stop()
 
-	var lastEventID int64
-	lastEvent, err := i.eventStore.GetLastEvent(ctx)
-	if err != nil {
-		return err
-	}
-	if lastEvent != nil {
-		lastEventID = lastEvent.Id
+	persistTicker := time.NewTicker(time.Minute)
+	defer persistTicker.Stop()
+
+	var fullReIndexC <-chan time.Time
+	if i.opts.fullReindexInterval > 0 {
+		fullReIndexTicker := time.NewTicker(i.opts.fullReindexInterval)
+		defer fullReIndexTicker.Stop()
+		fullReIndexC = fullReIndexTicker.C
 	}
 
-	// Build on start for orgID 1 but keep lazy for others.
-	started := time.Now()
-	dashboards, err := i.getDashboards(ctx, 1)
+	lastEventID, err := i.loadOrBuild(ctx)
 	if err != nil {
-		return fmt.Errorf("can't build dashboard search index for org ID 1: %w", err)
+		return err
 	}
-	i.logger.Info("Indexing for main org finished", "mainOrgIndexElapsed", time.Since(started), "numDashboards", len(dashboards))
 
 	for {
 		select {
 		case <-partialUpdateTicker.C:
 			lastEventID = i.applyIndexUpdates(ctx, lastEventID)
-		case <-fullReIndexTicker.C:
+		case <-persistTicker.C:
+			i.persist(lastEventID)
+		case <-fullReIndexC:
 			started := time.Now()
 			i.reIndexFromScratch(ctx)
 			i.logger.Info("Full re-indexing finished", "fullReIndexElapsed", time.Since(started))
 		case <-ctx.Done():
+			i.persist(lastEventID)
 			return ctx.Err()
 		}
 	}
 }
 
+// loadOrBuild loads the persisted index and catches up with the events saved
+// after its checkpoint. Without a usable persisted index it builds the index
+// for the main org and keeps it lazy for the others. It returns the ID of the
+// last applied event.
+func (i *dashboardIndex) loadOrBuild(ctx context.Context) (int64, error) {
+	if i.opts.store != nil && !i.opts.rebuildOnStartup {
+		lastEventID, ok, err := i.loadPersisted(ctx)
+		if err != nil {
+			i.logger.Warn("Can't load persisted search index, rebuilding", "error", err)
+		} else if ok {
+			return lastEventID, nil
+		}
+	}
+
+	var lastEventID int64
+	lastEvent, err := i.eventStore.GetLastEvent(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if lastEvent != nil {
+		lastEventID = lastEvent.Id
+	}
+
+	// Build on start for orgID 1 but keep lazy for others.
+	started := time.Now()
+	dashboards, err := i.getDashboards(ctx, 1)
+	if err != nil {
+		return 0, fmt.Errorf("can't build dashboard search index for org ID 1: %w", err)
+	}
+	i.logger.Info("Indexing for main org finished", "mainOrgIndexElapsed", time.Since(started), "numDashboards", len(dashboards))
+	return lastEventID, nil
+}
+
+func (i *dashboardIndex) loadPersisted(ctx context.Context) (int64, bool, error) {
+	started := time.Now()
+	persisted, err := i.opts.store.load()
+	if err != nil || persisted == nil {
+		return 0, false, err
+	}
+	if persisted.Version != persistedIndexVersion {
+		i.logger.Info("Persisted search index has an old version, rebuilding", "version", persisted.Version)
+		return 0, false, nil
+	}
+	if time.Since(persisted.Saved) > maxCheckpointAge {
+		i.logger.Info("Persisted search index is too old to catch up, rebuilding", "saved", persisted.Saved)
+		return 0, false, nil
+	}
+
+	lastEvent, err := i.eventStore.GetLastEvent(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if persisted.LastEventID > 0 && (lastEvent == nil || lastEvent.Id < persisted.LastEventID) {
+		i.logger.Info("Persisted search index is ahead of the events, rebuilding", "lastEventId", persisted.LastEventID)
+		return 0, false, nil
+	}
+
+	i.mu.Lock()
+	for orgID, dashboards := range persisted.Orgs {
+		i.dashboards[orgID] = fromPersisted(dashboards)
+	}
+	i.persistedEventID = persisted.LastEventID
+	i.persistedAt = persisted.Saved
+	i.mu.Unlock()
+
+	lastEventID := i.applyIndexUpdates(ctx, persisted.LastEventID)
+	i.logger.Info("Loaded persisted search index", "elapsed", time.Since(started), "numOrgs", len(persisted.Orgs),
+		"checkpointEventId", persisted.LastEventID, "lastEventId", lastEventID)
+	return lastEventID, true, nil
+}
+
+// persist saves the index with the last applied event as checkpoint. It is
+// also saved without changes once in a while to keep the checkpoint recent.
+func (i *dashboardIndex) persist(lastEventID int64) {
+	if i.opts.store == nil {
+		return
+	}
+
+	i.mu.Lock()
+	if !i.dirty && lastEventID == i.persistedEventID && time.Since(i.persistedAt) < time.Hour {
+		i.mu.Unlock()
+		return
+	}
+	persisted := &persistedIndex{
+		Version:     persistedIndexVersion,
+		LastEventID: lastEventID,
+		Saved:       time.Now(),
+		Orgs:        make(map[int64][]persistedDashboard, len(i.dashboards)),
+	}
+	for orgID, dashboards := range i.dashboards {
+		persisted.Orgs[orgID] = toPersisted(dashboards)
+	}
+	i.dirty = false
+	i.mu.Unlock()
+
+	started := time.Now()
+	if err := i.opts.store.save(persisted); err != nil {
+		i.logger.Error("Can't persist search index", "error", err)
+		i.mu.Lock()
+		i.dirty = true
+		i.mu.Unlock()
+		return
+	}
+
+	i.mu.Lock()
+	i.persistedEventID = lastEventID
+	i.persistedAt = persisted.Saved
+	i.mu.Unlock()
+	i.logger.Debug("Persisted search index", "elapsed", time.Since(started), "lastEventId", lastEventID)
+}
+
 func (i *dashboardIndex) reIndexFromScratch(ctx context.Context) {
 	i.mu.RLock()
 	orgIDs := make([]int64, 0, len(i.dashboards))
@@ -113,9 +240,23 @@ func (i *dashboardIndex) reIndexFromScratch(ctx context.Context) {
 			continue
 		}
 		cancel()
+
+		// The full text index is rebuilt here rather than on the next search
+		i.mu.RLock()
+		_, searched := i.search[orgID]
+		i.mu.RUnlock()
+		var index *searchIndex
+		if searched {
+			index = newSearchIndex(dashboards)
+		}
+
 		i.logger.Info("Re-indexed dashboards for organization", "orgId", orgID, "orgReIndexElapsed", time.Since(started))
 		i.mu.Lock()
 		i.dashboards[orgID] = dashboards
+		if index != nil {
+			i.search[orgID] = index
+		}
+		i.dirty = true
 		i.mu.Unlock()
 	}
 }
@@ -192,11 +333,22 @@ func (i *dashboardIndex) applyDashboardEvent(ctx context.Context, orgID int64, d
 		return nil
 	}
 
+	i.dirty = true
+
 	// In the future we can rely on operation types to reduce work here.
 	if len(dbDashboards) == 0 {
 		// Delete.
 		i.dashboards[orgID] = removeDashboard(dashboards, dashboardUID)
+		if index, ok := i.search[orgID]; ok {
+			index.remove(dashboardUID)
+			if index.needsCompaction() {
+				i.search[orgID] = newSearchIndex(i.dashboards[orgID])
+			}
+		}
 	} else {
+		if index, ok := i.search[orgID]; ok {
+			index.update(dbDashboards[0])
+		}
 		updated := false
 		for i, d := range dashboards {
 			if d.uid == dashboardUID {
@@ -242,10 +394,30 @@ func (i *dashboardIndex) getDashboards(ctx context.Context, orgId int64) ([]dash
 			return nil, err
 		}
 		i.dashboards[orgId] = dashboards
+		i.dirty = true
 	}
 	return dashboards, nil
 }
 
+func (i *dashboardIndex) getSearchIndex(ctx context.Context, orgId int64) (*searchIndex, error) {
+	// Loads the dashboards when the org was not indexed yet.
+	if _, err := i.getDashboards(ctx, orgId); err != nil {
+		return nil, err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	index, ok := i.search[orgId]
+	if !ok {
+		started := time.Now()
+		index = newSearchIndex(i.dashboards[orgId])
+		i.search[orgId] = index
+		i.logger.Info("Built full text index", "orgId", orgId, "elapsed", time.Since(started), "numTerms", len(index.terms))
+	}
+	return index, nil
+}
+
 type sqlDashboardLoader struct {
 	sql    *sqlstore.SQLStore
 	logger log.Logger
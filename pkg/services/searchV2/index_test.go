@@ -3,7 +3,9 @@ package searchV2
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana/pkg/services/searchV2/extract"
 	"github.com/grafana/grafana/pkg/services/store"
 	"github.com/stretchr/testify/require"
 )
@@ -16,6 +18,27 @@ func (t *testDashboardLoader) LoadDashboards(ctx context.Context, orgID int64, d
 	return t.dashboards, nil
 }
 
+type testEventStore struct {
+	events []*store.EntityEvent
+}
+
+func (t *testEventStore) GetLastEvent(_ context.Context) (*store.EntityEvent, error) {
+	if len(t.events) == 0 {
+		return nil, nil
+	}
+	return t.events[len(t.events)-1], nil
+}
+
+func (t *testEventStore) GetAllEventsAfter(_ context.Context, id int64) ([]*store.EntityEvent, error) {
+	var events []*store.EntityEvent
+	for _, e := range t.events {
+		if e.Id > id {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
 func TestDashboardIndexCreate(t *testing.T) {
 	dashboardLoader := &testDashboardLoader{
 		dashboards: []dashboard{
@@ -24,7 +47,7 @@ func TestDashboardIndexCreate(t *testing.T) {
 			},
 		},
 	}
-	index := newDashboardIndex(dashboardLoader, &store.MockEntityEventsService{})
+	index := newDashboardIndex(dashboardLoader, &store.MockEntityEventsService{}, indexOptions{})
 	require.NotNil(t, index)
 	dashboards, err := index.getDashboards(context.Background(), 1)
 	require.NoError(t, err)
@@ -51,7 +74,7 @@ func TestDashboardIndexUpdate(t *testing.T) {
 			},
 		},
 	}
-	index := newDashboardIndex(dashboardLoader, nil)
+	index := newDashboardIndex(dashboardLoader, nil, indexOptions{})
 	require.NotNil(t, index)
 	dashboards, err := index.getDashboards(context.Background(), 1)
 	require.NoError(t, err)
@@ -79,7 +102,7 @@ func TestDashboardIndexDelete(t *testing.T) {
 			},
 		},
 	}
-	index := newDashboardIndex(dashboardLoader, nil)
+	index := newDashboardIndex(dashboardLoader, nil, indexOptions{})
 	require.NotNil(t, index)
 	dashboards, err := index.getDashboards(context.Background(), 1)
 	require.NoError(t, err)
@@ -92,3 +115,107 @@ func TestDashboardIndexDelete(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, dashboards, 0)
 }
+
+func TestDashboardIndexUpdatesSearchIndex(t *testing.T) {
+	dashboardLoader := &testDashboardLoader{
+		dashboards: []dashboard{
+			{uid: "1", info: &extract.DashboardInfo{Title: "CPU"}},
+			{uid: "2", info: &extract.DashboardInfo{Title: "Memory"}},
+		},
+	}
+	index := newDashboardIndex(dashboardLoader, nil, indexOptions{})
+	searchIndex, err := index.getSearchIndex(context.Background(), 1)
+	require.NoError(t, err)
+	search := func(query string) []string {
+		res, err := searchIndex.search(DashboardQuery{Query: query}, allowAll)
+		require.NoError(t, err)
+		return uidsOf(res.hits)
+	}
+	require.Equal(t, []string{"1"}, search("cpu"))
+
+	dashboardLoader.dashboards = []dashboard{{uid: "1", info: &extract.DashboardInfo{Title: "CPU usage"}}}
+	require.NoError(t, index.applyDashboardEvent(context.Background(), 1, "1", ""))
+	dashboardLoader.dashboards = []dashboard{{uid: "3", info: &extract.DashboardInfo{Title: "Disk usage"}}}
+	require.NoError(t, index.applyDashboardEvent(context.Background(), 1, "3", ""))
+	dashboardLoader.dashboards = []dashboard{}
+	require.NoError(t, index.applyDashboardEvent(context.Background(), 1, "2", ""))
+
+	// the full text index is updated, not rebuilt
+	updated, err := index.getSearchIndex(context.Background(), 1)
+	require.NoError(t, err)
+	require.Same(t, searchIndex, updated)
+	require.Equal(t, []string{"1", "3"}, search("usage"))
+	require.Empty(t, search("memory"))
+}
+
+func TestDashboardIndexPersist(t *testing.T) {
+	dir := t.TempDir()
+	dashboardLoader := &testDashboardLoader{
+		dashboards: []dashboard{
+			{
+				uid:  "1",
+				slug: "test",
+				info: &extract.DashboardInfo{Title: "Test"},
+			},
+		},
+	}
+	index := newDashboardIndex(dashboardLoader, nil, indexOptions{store: newFileIndexStore(dir)})
+	_, err := index.getDashboards(context.Background(), 1)
+	require.NoError(t, err)
+	index.persist(5)
+
+	persisted, err := newFileIndexStore(dir).load()
+	require.NoError(t, err)
+	require.NotNil(t, persisted)
+	require.Equal(t, int64(5), persisted.LastEventID)
+	require.Len(t, persisted.Orgs[1], 1)
+	require.Equal(t, "Test", persisted.Orgs[1][0].Info.Title)
+
+	t.Run("loads and applies events after the checkpoint", func(t *testing.T) {
+		events := &testEventStore{events: []*store.EntityEvent{
+			{Id: 5, EventType: store.EntityEventTypeCreate, EntityId: "database/1/dashboard/1"},
+			{Id: 6, EventType: store.EntityEventTypeCreate, EntityId: "database/1/dashboard/2"},
+		}}
+		loader := &testDashboardLoader{dashboards: []dashboard{{uid: "2"}}}
+
+		index := newDashboardIndex(loader, events, indexOptions{store: newFileIndexStore(dir)})
+		lastEventID, err := index.loadOrBuild(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(6), lastEventID)
+		dashboards, err := index.getDashboards(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, dashboards, 2)
+		require.Equal(t, "Test", dashboards[0].info.Title)
+	})
+
+	t.Run("rebuilds when the checkpoint is ahead of the events", func(t *testing.T) {
+		events := &testEventStore{events: []*store.EntityEvent{{Id: 2}}}
+		loader := &testDashboardLoader{dashboards: []dashboard{{uid: "3"}}}
+
+		index := newDashboardIndex(loader, events, indexOptions{store: newFileIndexStore(dir)})
+		lastEventID, err := index.loadOrBuild(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), lastEventID)
+		dashboards, err := index.getDashboards(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, dashboards, 1)
+		require.Equal(t, "3", dashboards[0].uid)
+	})
+
+	t.Run("rebuilds when the checkpoint is too old", func(t *testing.T) {
+		persisted.Saved = time.Now().Add(-2 * maxCheckpointAge)
+		require.NoError(t, newFileIndexStore(dir).save(persisted))
+
+		events := &testEventStore{events: []*store.EntityEvent{{Id: 10}}}
+		loader := &testDashboardLoader{dashboards: []dashboard{{uid: "3"}}}
+
+		index := newDashboardIndex(loader, events, indexOptions{store: newFileIndexStore(dir)})
+		lastEventID, err := index.loadOrBuild(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(10), lastEventID)
+		dashboards, err := index.getDashboards(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, dashboards, 1)
+		require.Equal(t, "3", dashboards[0].uid)
+	})
+}
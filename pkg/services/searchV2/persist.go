@@ -0,0 +1,131 @@
+package searchV2
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/searchV2/extract"
+)
+
+// Bump when the persisted format or the extracted dashboard info changes,
+// older files are ignored and the index is rebuilt from the database.
+const persistedIndexVersion = 1
+
+const persistedIndexFile = "dashboards.json.gz"
+
+// Entity events are deleted after a day, an older checkpoint may have missed changes.
+const maxCheckpointAge = 23 * time.Hour
+
+type persistedIndex struct {
+	Version     int                            `json:"version"`
+	LastEventID int64                          `json:"lastEventId"`
+	Saved       time.Time                      `json:"saved"`
+	Orgs        map[int64][]persistedDashboard `json:"orgs"`
+}
+
+type persistedDashboard struct {
+	ID       int64                  `json:"id"`
+	UID      string                 `json:"uid"`
+	IsFolder bool                   `json:"isFolder,omitempty"`
+	FolderID int64                  `json:"folderId,omitempty"`
+	Slug     string                 `json:"slug"`
+	Created  time.Time              `json:"created"`
+	Updated  time.Time              `json:"updated"`
+	Info     *extract.DashboardInfo `json:"info"`
+}
+
+func toPersisted(dashboards []dashboard) []persistedDashboard {
+	res := make([]persistedDashboard, 0, len(dashboards))
+	for _, d := range dashboards {
+		res = append(res, persistedDashboard{
+			ID:       d.id,
+			UID:      d.uid,
+			IsFolder: d.isFolder,
+			FolderID: d.folderID,
+			Slug:     d.slug,
+			Created:  d.created,
+			Updated:  d.updated,
+			Info:     d.info,
+		})
+	}
+	return res
+}
+
+func fromPersisted(dashboards []persistedDashboard) []dashboard {
+	res := make([]dashboard, 0, len(dashboards))
+	for _, d := range dashboards {
+		res = append(res, dashboard{
+			id:       d.ID,
+			uid:      d.UID,
+			isFolder: d.IsFolder,
+			folderID: d.FolderID,
+			slug:     d.Slug,
+			created:  d.Created,
+			updated:  d.Updated,
+			info:     d.Info,
+		})
+	}
+	return res
+}
+
+// fileIndexStore keeps the dashboard index in a single compressed file
+type fileIndexStore struct {
+	dir string
+}
+
+func newFileIndexStore(dir string) *fileIndexStore {
+	return &fileIndexStore{dir: dir}
+}
+
+// load returns nil when the index was never saved
+func (s *fileIndexStore) load() (*persistedIndex, error) {
+	f, err := os.Open(filepath.Join(s.dir, persistedIndexFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	index := &persistedIndex{}
+	if err := json.NewDecoder(r).Decode(index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// save writes to a temporary file first, so a crash never leaves a partial index
+func (s *fileIndexStore) save(index *persistedIndex) error {
+	if err := os.MkdirAll(s.dir, 0750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, persistedIndexFile+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	w := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(w).Encode(index); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, persistedIndexFile))
+}
@@ -0,0 +1,688 @@
+package searchV2
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/grafana/grafana/pkg/services/searchV2/extract"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 1000
+)
+
+type searchField uint8
+
+const (
+	fieldTitle searchField = iota
+	fieldDescription
+	fieldTag
+	fieldPanelTitle
+	fieldPanelDescription
+	fieldDatasource
+	fieldPanelType
+	numSearchFields
+)
+
+// anyField matches a term in all fields
+const anyField = numSearchFields
+
+// Names used for field:term in the query text
+var searchFieldNames = map[string]searchField{
+	"title":             fieldTitle,
+	"description":       fieldDescription,
+	"tag":               fieldTag,
+	"panel":             fieldPanelTitle,
+	"panel_description": fieldPanelDescription,
+	"datasource":        fieldDatasource,
+	"panel_type":        fieldPanelType,
+}
+
+// Matches in the title rank higher than matches in a panel description
+var searchFieldWeights = [numSearchFields]float64{
+	fieldTitle:            10,
+	fieldDescription:      3,
+	fieldTag:              6,
+	fieldPanelTitle:       4,
+	fieldPanelDescription: 2,
+	fieldDatasource:       2,
+	fieldPanelType:        2,
+}
+
+// Facets that can be requested with DashboardQuery.Facets
+const (
+	facetTag        = "tag"
+	facetFolder     = "folder"
+	facetDatasource = "datasource"
+	facetPanelType  = "panelType"
+)
+
+type posting struct {
+	doc   int32
+	field searchField
+	freq  uint16
+}
+
+// searchDocument is a dashboard with the values used for filters and facets,
+// it is not modified once indexed
+type searchDocument struct {
+	dash        dashboard
+	folderUID   string
+	tags        []string // lower case
+	datasources []string // UIDs and types
+	panelTypes  []string
+	terms       []string // indexed terms, used to remove the document
+}
+
+// searchIndex is an inverted index over the dashboards of one org. It is
+// updated in place when a dashboard changes, removed dashboards leave an
+// empty document until the index is compacted.
+type searchIndex struct {
+	mu         sync.RWMutex
+	docs       []*searchDocument // nil for removed dashboards
+	byUID      map[string]int32  // dashboard UID -> doc
+	removed    int
+	folders    map[string]string // folder UID -> title
+	folderUIDs map[int64]string  // folder ID -> UID
+	terms      map[string][]posting
+	sorted     []string // all terms, used for prefix matches
+}
+
+func newSearchIndex(dashboards []dashboard) *searchIndex {
+	idx := &searchIndex{
+		byUID:      make(map[string]int32),
+		folders:    make(map[string]string),
+		folderUIDs: make(map[int64]string),
+		terms:      make(map[string][]posting),
+	}
+
+	for _, d := range dashboards {
+		if d.isFolder {
+			idx.setFolder(d)
+		}
+	}
+	for _, d := range dashboards {
+		if !d.isFolder {
+			idx.addDocument(d)
+		}
+	}
+
+	idx.sorted = make([]string, 0, len(idx.terms))
+	for term := range idx.terms {
+		idx.sorted = append(idx.sorted, term)
+	}
+	sort.Strings(idx.sorted)
+	return idx
+}
+
+// update indexes a created or updated dashboard or folder
+func (idx *searchIndex) update(d dashboard) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if d.isFolder {
+		idx.setFolder(d)
+		return
+	}
+	idx.removeDocument(d.uid)
+	for _, term := range idx.addDocument(d) {
+		i := sort.SearchStrings(idx.sorted, term)
+		idx.sorted = append(idx.sorted, "")
+		copy(idx.sorted[i+1:], idx.sorted[i:])
+		idx.sorted[i] = term
+	}
+}
+
+// remove removes a deleted dashboard or folder from the index
+func (idx *searchIndex) remove(uid string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for id, folderUID := range idx.folderUIDs {
+		if folderUID == uid {
+			delete(idx.folderUIDs, id)
+			delete(idx.folders, uid)
+		}
+	}
+	if idx.removeDocument(uid) {
+		delete(idx.byUID, uid)
+		idx.removed++
+	}
+}
+
+// needsCompaction is true when most documents were removed
+func (idx *searchIndex) needsCompaction() bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.removed > 100 && idx.removed > len(idx.docs)/2
+}
+
+func (idx *searchIndex) setFolder(d dashboard) {
+	title := ""
+	if d.info != nil {
+		title = d.info.Title
+	}
+	idx.folders[d.uid] = title
+	idx.folderUIDs[d.id] = d.uid
+}
+
+// addDocument indexes a dashboard, reusing the document of a removed version
+// of the same dashboard. It returns the terms that were not in the index yet.
+func (idx *searchIndex) addDocument(d dashboard) []string {
+	doc := &searchDocument{
+		dash:      d,
+		folderUID: idx.folderUIDs[d.folderID],
+	}
+	freqs := make(map[string]*[numSearchFields]uint16)
+	add := func(field searchField, text string) {
+		for _, term := range tokenize(text) {
+			f, ok := freqs[term]
+			if !ok {
+				f = &[numSearchFields]uint16{}
+				freqs[term] = f
+			}
+			if f[field] < math.MaxUint16 {
+				f[field]++
+			}
+		}
+	}
+
+	if info := d.info; info != nil {
+		add(fieldTitle, info.Title)
+		add(fieldDescription, info.Description)
+		for _, tag := range info.Tags {
+			add(fieldTag, tag)
+			doc.tags = appendUnique(doc.tags, strings.ToLower(tag))
+		}
+		for _, ds := range info.Datasource {
+			add(fieldDatasource, ds.UID+" "+ds.Type)
+			doc.datasources = appendUnique(doc.datasources, ds.UID, ds.Type)
+		}
+		for _, panel := range info.Panels {
+			addPanel(doc, add, panel)
+			for _, collapsed := range panel.Collapsed {
+				addPanel(doc, add, collapsed)
+			}
+		}
+	}
+
+	id, ok := idx.byUID[d.uid]
+	if !ok {
+		id = int32(len(idx.docs))
+		idx.docs = append(idx.docs, nil)
+		idx.byUID[d.uid] = id
+	}
+
+	var newTerms []string
+	doc.terms = make([]string, 0, len(freqs))
+	for term, f := range freqs {
+		doc.terms = append(doc.terms, term)
+		if _, ok := idx.terms[term]; !ok {
+			newTerms = append(newTerms, term)
+		}
+		for field, freq := range f {
+			if freq > 0 {
+				idx.terms[term] = append(idx.terms[term], posting{doc: id, field: searchField(field), freq: freq})
+			}
+		}
+	}
+	// Documents are replaced, never modified, so that search results stay valid
+	idx.docs[id] = doc
+	return newTerms
+}
+
+// removeDocument removes the postings of a dashboard and keeps its empty document
+// for a new version of the dashboard. It returns false when it was not indexed.
+func (idx *searchIndex) removeDocument(uid string) bool {
+	id, ok := idx.byUID[uid]
+	if !ok || idx.docs[id] == nil {
+		return false
+	}
+	for _, term := range idx.docs[id].terms {
+		postings := idx.terms[term]
+		k := 0
+		for _, p := range postings {
+			if p.doc != id {
+				postings[k] = p
+				k++
+			}
+		}
+		if k > 0 {
+			idx.terms[term] = postings[:k]
+			continue
+		}
+		delete(idx.terms, term)
+		if i := sort.SearchStrings(idx.sorted, term); i < len(idx.sorted) && idx.sorted[i] == term {
+			idx.sorted = append(idx.sorted[:i], idx.sorted[i+1:]...)
+		}
+	}
+	idx.docs[id] = nil
+	return true
+}
+
+func addPanel(doc *searchDocument, add func(field searchField, text string), panel extract.PanelInfo) {
+	add(fieldPanelTitle, panel.Title)
+	add(fieldPanelDescription, panel.Description)
+	if panel.Type != "" {
+		add(fieldPanelType, panel.Type)
+		doc.panelTypes = appendUnique(doc.panelTypes, panel.Type)
+	}
+	for _, ds := range panel.Datasource {
+		add(fieldDatasource, ds.UID+" "+ds.Type)
+		doc.datasources = appendUnique(doc.datasources, ds.UID, ds.Type)
+	}
+}
+
+func appendUnique(vals []string, add ...string) []string {
+	for _, v := range add {
+		if v == "" {
+			continue
+		}
+		found := false
+		for _, existing := range vals {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vals = append(vals, v)
+		}
+	}
+	return vals
+}
+
+// tokenize splits the text in lower case words
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+type queryTerm struct {
+	field  searchField
+	text   string
+	prefix bool
+}
+
+// parseSearchQuery reads the query text. Terms may be limited to a field with
+// field:term, quoted with "..." and end with * to match as a prefix.
+func parseSearchQuery(query string) ([]queryTerm, error) {
+	terms := make([]queryTerm, 0)
+	rest := strings.TrimSpace(query)
+	for rest != "" {
+		field := anyField
+		if i := strings.IndexAny(rest, ": \""); i > 0 && rest[i] == ':' {
+			f, ok := searchFieldNames[strings.ToLower(rest[:i])]
+			if !ok {
+				return nil, fmt.Errorf("unknown search field %q", rest[:i])
+			}
+			field = f
+			rest = rest[i+1:]
+		}
+
+		var value string
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.Index(rest[1:], "\"")
+			if end < 0 {
+				return nil, fmt.Errorf("missing closing quote in query")
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		rest = strings.TrimSpace(rest)
+
+		prefix := strings.HasSuffix(value, "*")
+		tokens := tokenize(value)
+		for i, token := range tokens {
+			terms = append(terms, queryTerm{
+				field:  field,
+				text:   token,
+				prefix: prefix && i == len(tokens)-1,
+			})
+		}
+	}
+	return terms, nil
+}
+
+// match returns the score of every document that contains the term
+func (idx *searchIndex) match(term queryTerm) map[int32]float64 {
+	matches := []string{term.text}
+	if term.prefix {
+		matches = matches[:0]
+		for i := sort.SearchStrings(idx.sorted, term.text); i < len(idx.sorted) && strings.HasPrefix(idx.sorted[i], term.text); i++ {
+			matches = append(matches, idx.sorted[i])
+		}
+	}
+
+	scores := make(map[int32]float64)
+	for _, t := range matches {
+		postings := idx.terms[t]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := math.Log(1 + float64(len(idx.byUID))/float64(len(postings)))
+		termScores := make(map[int32]float64)
+		for _, p := range postings {
+			if term.field != anyField && p.field != term.field {
+				continue
+			}
+			termScores[p.doc] += searchFieldWeights[p.field] * (1 + math.Log(float64(p.freq))) * idf
+		}
+		// With a prefix the best matching expansion counts
+		for doc, s := range termScores {
+			if s > scores[doc] {
+				scores[doc] = s
+			}
+		}
+	}
+	return scores
+}
+
+type searchHit struct {
+	doc   *searchDocument
+	score float64
+}
+
+type searchResult struct {
+	total  int
+	hits   []searchHit // the requested page
+	facets map[string][]facetCount
+}
+
+type facetCount struct {
+	value string
+	label string
+	count int64
+}
+
+// search finds the dashboards that match every term and filter of the query,
+// allowed decides if the user can see a dashboard by UID
+func (idx *searchIndex) search(q DashboardQuery, allowed func(uid string) bool) (*searchResult, error) {
+	terms, err := parseSearchQuery(q.Query)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range q.Facets {
+		switch name {
+		case facetTag, facetFolder, facetDatasource, facetPanelType:
+		default:
+			return nil, fmt.Errorf("unknown facet %q", name)
+		}
+	}
+
+	var scores map[int32]float64
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	for _, term := range terms {
+		matched := idx.match(term)
+		if scores == nil {
+			scores = matched
+			continue
+		}
+		for doc, s := range scores {
+			if m, ok := matched[doc]; ok {
+				scores[doc] = s + m
+			} else {
+				delete(scores, doc)
+			}
+		}
+	}
+
+	hits := make([]searchHit, 0)
+	for i, doc := range idx.docs {
+		if doc == nil {
+			continue
+		}
+		score := 0.0
+		if scores != nil {
+			s, ok := scores[int32(i)]
+			if !ok {
+				continue
+			}
+			score = s
+		}
+		if !doc.matchesFilters(q) || !allowed(doc.dash.uid) {
+			continue
+		}
+		hits = append(hits, searchHit{doc: doc, score: score})
+	}
+
+	res := &searchResult{
+		total:  len(hits),
+		facets: idx.facets(q.Facets, hits),
+	}
+	if err := sortHits(hits, q.Sort); err != nil {
+		return nil, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	from := q.From
+	if from < 0 {
+		from = 0
+	}
+	if from > len(hits) {
+		from = len(hits)
+	}
+	to := from + limit
+	if to > len(hits) {
+		to = len(hits)
+	}
+	res.hits = hits[from:to]
+	return res, nil
+}
+
+// matchesFilters is true when the dashboard has all the tags and one of the
+// folders, datasources and panel types of each filter
+func (doc *searchDocument) matchesFilters(q DashboardQuery) bool {
+	for _, tag := range q.Tags {
+		if !containsFold(doc.tags, tag) {
+			return false
+		}
+	}
+	if len(q.Folder) > 0 && !containsFold(q.Folder, doc.folderUID) {
+		return false
+	}
+	if len(q.Datasource) > 0 && !containsAny(doc.datasources, q.Datasource) {
+		return false
+	}
+	if len(q.PanelType) > 0 && !containsAny(doc.panelTypes, q.PanelType) {
+		return false
+	}
+	return true
+}
+
+func containsFold(vals []string, v string) bool {
+	for _, s := range vals {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(vals []string, want []string) bool {
+	for _, w := range want {
+		if containsFold(vals, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (idx *searchIndex) facets(names []string, hits []searchHit) map[string][]facetCount {
+	facets := make(map[string][]facetCount, len(names))
+	for _, name := range names {
+		counts := make(map[string]int64)
+		for _, hit := range hits {
+			switch name {
+			case facetTag:
+				for _, tag := range hit.doc.tags {
+					counts[tag]++
+				}
+			case facetFolder:
+				counts[hit.doc.folderUID]++
+			case facetDatasource:
+				for _, ds := range hit.doc.datasources {
+					counts[ds]++
+				}
+			case facetPanelType:
+				for _, t := range hit.doc.panelTypes {
+					counts[t]++
+				}
+			}
+		}
+
+		values := make([]facetCount, 0, len(counts))
+		for value, count := range counts {
+			label := value
+			if name == facetFolder {
+				label = idx.folders[value]
+				if value == "" {
+					label = "General"
+				}
+			}
+			values = append(values, facetCount{value: value, label: label, count: count})
+		}
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].count != values[j].count {
+				return values[i].count > values[j].count
+			}
+			return values[i].value < values[j].value
+		})
+		facets[name] = values
+	}
+	return facets
+}
+
+// sortHits orders by score unless the sort is one of name, updated or created,
+// a leading - sorts descending
+func sortHits(hits []searchHit, by string) error {
+	desc := strings.HasPrefix(by, "-")
+	var less func(a, b *searchHit) bool
+	switch strings.TrimPrefix(by, "-") {
+	case "", "score":
+		desc = !desc
+		less = func(a, b *searchHit) bool { return a.score < b.score }
+	case "name":
+		less = func(a, b *searchHit) bool {
+			return strings.ToLower(a.doc.title()) < strings.ToLower(b.doc.title())
+		}
+	case "updated":
+		less = func(a, b *searchHit) bool { return a.doc.dash.updated.Before(b.doc.dash.updated) }
+	case "created":
+		less = func(a, b *searchHit) bool { return a.doc.dash.created.Before(b.doc.dash.created) }
+	default:
+		return fmt.Errorf("unknown sort %q", by)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := &hits[i], &hits[j]
+		if desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		// Ties are always ordered by name
+		return strings.ToLower(hits[i].doc.title()) < strings.ToLower(hits[j].doc.title())
+	})
+	return nil
+}
+
+func (doc *searchDocument) title() string {
+	if doc.dash.info == nil {
+		return ""
+	}
+	return doc.dash.info.Title
+}
+
+func (r *searchResult) toFrames() data.Frames {
+	uid := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+	name := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+	url := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+	folder := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+	tags := data.NewFieldFromFieldType(data.FieldTypeNullableString, 0)
+	panelTypes := data.NewFieldFromFieldType(data.FieldTypeNullableString, 0)
+	datasources := data.NewFieldFromFieldType(data.FieldTypeNullableString, 0)
+	updated := data.NewFieldFromFieldType(data.FieldTypeTime, 0)
+	score := data.NewFieldFromFieldType(data.FieldTypeFloat64, 0)
+
+	uid.Name = "uid"
+	name.Name = "name"
+	url.Name = "url"
+	folder.Name = "folder"
+	tags.Name = "tags"
+	panelTypes.Name = "panelTypes"
+	datasources.Name = "datasource"
+	updated.Name = "updated"
+	score.Name = "score"
+
+	for _, hit := range r.hits {
+		d := hit.doc.dash
+		uid.Append(d.uid)
+		name.Append(hit.doc.title())
+		url.Append(fmt.Sprintf("/d/%s/%s", d.uid, d.slug))
+		folder.Append(hit.doc.folderUID)
+		if d.info != nil {
+			tags.Append(toJSONString(d.info.Tags))
+		} else {
+			tags.Append(nil)
+		}
+		panelTypes.Append(toJSONString(hit.doc.panelTypes))
+		datasources.Append(toJSONString(hit.doc.datasources))
+		updated.Append(d.updated)
+		score.Append(hit.score)
+	}
+
+	results := data.NewFrame("search-results", uid, name, url, folder, tags, panelTypes, datasources, updated, score)
+	results.SetMeta(&data.FrameMeta{
+		Custom: map[string]interface{}{
+			"count": r.total,
+		},
+	})
+	frames := data.Frames{results}
+
+	names := make([]string, 0, len(r.facets))
+	for n := range r.facets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		value := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+		label := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+		count := data.NewFieldFromFieldType(data.FieldTypeInt64, 0)
+		value.Name = "value"
+		label.Name = "label"
+		count.Name = "count"
+		for _, f := range r.facets[n] {
+			value.Append(f.value)
+			label.Append(f.label)
+			count.Append(f.count)
+		}
+		frames = append(frames, data.NewFrame("facet-"+n, value, label, count))
+	}
+	return frames
+}
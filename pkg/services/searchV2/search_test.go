@@ -0,0 +1,256 @@
+package searchV2
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/searchV2/extract"
+	"github.com/stretchr/testify/require"
+)
+
+var testSearchDashboards = []dashboard{
+	{
+		id:       10,
+		uid:      "folder-a",
+		isFolder: true,
+		info:     &extract.DashboardInfo{Title: "Team A"},
+	},
+	{
+		id:       1,
+		uid:      "api",
+		folderID: 10,
+		slug:     "api-latency",
+		updated:  time.Unix(300, 0),
+		info: &extract.DashboardInfo{
+			Title: "API latency",
+			Tags:  []string{"prod", "api"},
+			Panels: []extract.PanelInfo{
+				{ID: 1, Title: "Latency p99", Type: "timeseries", Datasource: []extract.DataSourceRef{{UID: "prom", Type: "prometheus"}}},
+				{ID: 2, Title: "Errors", Type: "stat", Datasource: []extract.DataSourceRef{{UID: "prom", Type: "prometheus"}}},
+			},
+		},
+	},
+	{
+		id:      2,
+		uid:     "db",
+		slug:    "database",
+		updated: time.Unix(200, 0),
+		info: &extract.DashboardInfo{
+			Title:       "Database",
+			Description: "Query latency of the database",
+			Tags:        []string{"prod"},
+			Panels: []extract.PanelInfo{
+				{ID: 1, Title: "Connections", Type: "timeseries", Datasource: []extract.DataSourceRef{{UID: "mysql", Type: "mysql"}}},
+			},
+		},
+	},
+	{
+		id:      3,
+		uid:     "logs",
+		slug:    "logs",
+		updated: time.Unix(100, 0),
+		info: &extract.DashboardInfo{
+			Title: "Logs",
+			Panels: []extract.PanelInfo{
+				{ID: 1, Title: "Row", Type: "row", Collapsed: []extract.PanelInfo{
+					{ID: 2, Title: "Latency logs", Type: "logs", Datasource: []extract.DataSourceRef{{UID: "loki", Type: "loki"}}},
+				}},
+			},
+		},
+	},
+}
+
+func allowAll(string) bool { return true }
+
+func searchUIDs(t *testing.T, q DashboardQuery) []string {
+	t.Helper()
+	res, err := newSearchIndex(testSearchDashboards).search(q, allowAll)
+	require.NoError(t, err)
+	uids := make([]string, 0, len(res.hits))
+	for _, hit := range res.hits {
+		uids = append(uids, hit.doc.dash.uid)
+	}
+	return uids
+}
+
+func TestSearchIndexQuery(t *testing.T) {
+	t.Run("ranks title matches over panels and descriptions", func(t *testing.T) {
+		require.Equal(t, []string{"api", "logs", "db"}, searchUIDs(t, DashboardQuery{Query: "latency"}))
+	})
+
+	t.Run("field query only matches the field", func(t *testing.T) {
+		require.Equal(t, []string{"api", "logs"}, searchUIDs(t, DashboardQuery{Query: "panel:latency"}))
+		require.Equal(t, []string{"db"}, searchUIDs(t, DashboardQuery{Query: "description:latency"}))
+	})
+
+	t.Run("all terms must match", func(t *testing.T) {
+		require.Equal(t, []string{"api"}, searchUIDs(t, DashboardQuery{Query: `latency "p99"`}))
+		require.Empty(t, searchUIDs(t, DashboardQuery{Query: "latency missing"}))
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		require.Equal(t, []string{"db"}, searchUIDs(t, DashboardQuery{Query: "datab*"}))
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		q := DashboardQuery{Query: "panel:latency", Datasource: []string{"prom"}, PanelType: []string{"timeseries"}}
+		require.Equal(t, []string{"api"}, searchUIDs(t, q))
+		require.Equal(t, []string{"api", "db"}, searchUIDs(t, DashboardQuery{Tags: []string{"PROD"}, Sort: "name"}))
+		require.Equal(t, []string{"api"}, searchUIDs(t, DashboardQuery{Folder: []string{"folder-a"}}))
+		require.Equal(t, []string{"logs"}, searchUIDs(t, DashboardQuery{Datasource: []string{"loki"}}))
+	})
+
+	t.Run("sort and pagination", func(t *testing.T) {
+		require.Equal(t, []string{"logs", "db", "api"}, searchUIDs(t, DashboardQuery{Sort: "updated"}))
+		require.Equal(t, []string{"db"}, searchUIDs(t, DashboardQuery{Sort: "-updated", From: 1, Limit: 1}))
+		require.Empty(t, searchUIDs(t, DashboardQuery{From: 10}))
+	})
+
+	t.Run("auth filter", func(t *testing.T) {
+		res, err := newSearchIndex(testSearchDashboards).search(DashboardQuery{Query: "latency"}, func(uid string) bool {
+			return uid != "api"
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, res.total)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := newSearchIndex(testSearchDashboards).search(DashboardQuery{Query: "owner:me"}, allowAll)
+		require.Error(t, err)
+		_, err = newSearchIndex(testSearchDashboards).search(DashboardQuery{Query: `"latency`}, allowAll)
+		require.Error(t, err)
+		_, err = newSearchIndex(testSearchDashboards).search(DashboardQuery{Sort: "views"}, allowAll)
+		require.Error(t, err)
+		_, err = newSearchIndex(testSearchDashboards).search(DashboardQuery{Facets: []string{"owner"}}, allowAll)
+		require.Error(t, err)
+	})
+}
+
+func TestSearchIndexFacets(t *testing.T) {
+	res, err := newSearchIndex(testSearchDashboards).search(DashboardQuery{
+		Facets: []string{facetTag, facetFolder, facetPanelType},
+		Limit:  1,
+	}, allowAll)
+	require.NoError(t, err)
+	require.Equal(t, 3, res.total)
+	require.Len(t, res.hits, 1)
+
+	require.Equal(t, []facetCount{
+		{value: "prod", label: "prod", count: 2},
+		{value: "api", label: "api", count: 1},
+	}, res.facets[facetTag])
+	require.Equal(t, []facetCount{
+		{value: "", label: "General", count: 2},
+		{value: "folder-a", label: "Team A", count: 1},
+	}, res.facets[facetFolder])
+	require.Equal(t, []facetCount{
+		{value: "timeseries", label: "timeseries", count: 2},
+		{value: "logs", label: "logs", count: 1},
+		{value: "row", label: "row", count: 1},
+		{value: "stat", label: "stat", count: 1},
+	}, res.facets[facetPanelType])
+
+	frames := res.toFrames()
+	require.Len(t, frames, 4)
+	require.Equal(t, "search-results", frames[0].Name)
+	require.Equal(t, "facet-folder", frames[1].Name)
+}
+
+func TestSearchIndexUpdate(t *testing.T) {
+	dashboards := append([]dashboard{}, testSearchDashboards...)
+	idx := newSearchIndex(dashboards)
+	search := func(idx *searchIndex, q DashboardQuery) []searchHit {
+		res, err := idx.search(q, allowAll)
+		require.NoError(t, err)
+		return res.hits
+	}
+	// requireSameResults compares the updated index with an index built from scratch
+	requireSameResults := func(t *testing.T, dashboards []dashboard) {
+		t.Helper()
+		built := newSearchIndex(dashboards)
+		for _, q := range []string{"latency", "datab*", "storage", "prod", "errors", "p*"} {
+			expected, actual := search(built, DashboardQuery{Query: q}), search(idx, DashboardQuery{Query: q})
+			require.Len(t, actual, len(expected), q)
+			for i := range expected {
+				require.Equal(t, expected[i].doc.dash.uid, actual[i].doc.dash.uid, q)
+				require.InDelta(t, expected[i].score, actual[i].score, 1e-9, q)
+			}
+		}
+		require.Equal(t, built.sorted, idx.sorted)
+	}
+
+	t.Run("updates a dashboard", func(t *testing.T) {
+		before := search(idx, DashboardQuery{Query: "datab*"})
+		require.Len(t, before, 1)
+
+		db := dashboards[2]
+		db.info = &extract.DashboardInfo{Title: "Storage", Description: "Query latency of the disks"}
+		dashboards[2] = db
+		idx.update(db)
+
+		require.Empty(t, search(idx, DashboardQuery{Query: "datab*"}))
+		require.Equal(t, "db", search(idx, DashboardQuery{Query: "storage"})[0].doc.dash.uid)
+		// earlier results are not modified
+		require.Equal(t, "Database", before[0].doc.title())
+		requireSameResults(t, dashboards)
+	})
+
+	t.Run("adds and removes dashboards", func(t *testing.T) {
+		created := dashboard{id: 4, uid: "errors", folderID: 10, info: &extract.DashboardInfo{Title: "Errors", Tags: []string{"prod"}}}
+		dashboards = append(dashboards, created)
+		idx.update(created)
+		require.Equal(t, []string{"api", "errors"}, uidsOf(search(idx, DashboardQuery{Folder: []string{"folder-a"}, Sort: "name"})))
+
+		dashboards = removeDashboard(dashboards, "api")
+		idx.remove("api")
+		require.Equal(t, []string{"errors"}, uidsOf(search(idx, DashboardQuery{Folder: []string{"folder-a"}})))
+		requireSameResults(t, dashboards)
+	})
+
+	t.Run("updates folders", func(t *testing.T) {
+		folder := dashboards[0]
+		folder.info = &extract.DashboardInfo{Title: "Team B"}
+		idx.update(folder)
+
+		res, err := idx.search(DashboardQuery{Facets: []string{facetFolder}}, allowAll)
+		require.NoError(t, err)
+		require.Contains(t, res.facets[facetFolder], facetCount{value: "folder-a", label: "Team B", count: 1})
+	})
+
+	t.Run("needs compaction when most dashboards are removed", func(t *testing.T) {
+		many := make([]dashboard, 0, 300)
+		for i := 0; i < 300; i++ {
+			many = append(many, dashboard{id: int64(i + 1), uid: fmt.Sprintf("dash-%d", i), info: &extract.DashboardInfo{Title: fmt.Sprintf("Dashboard %d", i)}})
+		}
+		idx := newSearchIndex(many)
+		for i := 0; i < 150; i++ {
+			idx.update(many[i])
+			idx.remove(many[i].uid)
+		}
+		require.False(t, idx.needsCompaction())
+		idx.remove(many[150].uid)
+		require.True(t, idx.needsCompaction())
+		require.Len(t, search(idx, DashboardQuery{Limit: maxSearchLimit}), 149)
+	})
+}
+
+func uidsOf(hits []searchHit) []string {
+	uids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		uids = append(uids, hit.doc.dash.uid)
+	}
+	return uids
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	terms, err := parseSearchQuery(`panel:"cpu usage" node_exp* Title:api`)
+	require.NoError(t, err)
+	require.Equal(t, []queryTerm{
+		{field: fieldPanelTitle, text: "cpu"},
+		{field: fieldPanelTitle, text: "usage"},
+		{field: anyField, text: "node"},
+		{field: anyField, text: "exp", prefix: true},
+		{field: fieldTitle, text: "api"},
+	}, terms)
+}
@@ -34,6 +34,14 @@ type StandardSearchService struct {
 }
 
 func ProvideService(cfg *setting.Cfg, sql *sqlstore.SQLStore, entityEventStore store.EntityEventsService, ac accesscontrol.AccessControl) SearchService {
+	opts := indexOptions{}
+	if cfg != nil && cfg.Search.IndexPath != "" {
+		opts = indexOptions{
+			store:               newFileIndexStore(cfg.Search.IndexPath),
+			fullReindexInterval: cfg.Search.FullReindexInterval,
+			rebuildOnStartup:    cfg.Search.RebuildIndexOnStartup,
+		}
+	}
 	return &StandardSearchService{
 		cfg: cfg,
 		sql: sql,
@@ -42,7 +50,7 @@ func ProvideService(cfg *setting.Cfg, sql *sqlstore.SQLStore, entityEventStore s
 			sql: sql,
 			ac:  ac,
 		},
-		dashboardIndex: newDashboardIndex(newSQLDashboardLoader(sql), entityEventStore),
+		dashboardIndex: newDashboardIndex(newSQLDashboardLoader(sql), entityEventStore, opts),
 		logger:         log.New("searchV2"),
 	}
 }
@@ -105,7 +113,11 @@ func (s *StandardSearchService) getUser(ctx context.Context, backendUser *backen
 	return user, nil
 }
 
-func (s *StandardSearchService) DoDashboardQuery(ctx context.Context, user *backend.User, orgId int64, _ DashboardQuery) *backend.DataResponse {
+func (s *StandardSearchService) DoDashboardQuery(ctx context.Context, user *backend.User, orgId int64, query DashboardQuery) *backend.DataResponse {
+	if query.isSearch() {
+		return s.doSearch(ctx, user, orgId, query)
+	}
+
 	rsp := &backend.DataResponse{}
 
 	dashboards, err := s.dashboardIndex.getDashboards(ctx, orgId)
@@ -131,6 +143,37 @@ func (s *StandardSearchService) DoDashboardQuery(ctx context.Context, user *back
 	return rsp
 }
 
+func (s *StandardSearchService) doSearch(ctx context.Context, user *backend.User, orgId int64, query DashboardQuery) *backend.DataResponse {
+	rsp := &backend.DataResponse{}
+
+	index, err := s.dashboardIndex.getSearchIndex(ctx, orgId)
+	if err != nil {
+		rsp.Error = err
+		return rsp
+	}
+
+	signedInUser, err := s.getUser(ctx, user, orgId)
+	if err != nil {
+		rsp.Error = err
+		return rsp
+	}
+
+	filter, err := s.auth.GetDashboardReadFilter(signedInUser)
+	if err != nil {
+		rsp.Error = err
+		return rsp
+	}
+
+	result, err := index.search(query, filter)
+	if err != nil {
+		rsp.Error = err
+		return rsp
+	}
+
+	rsp.Frames = result.toFrames()
+	return rsp
+}
+
 func (s *StandardSearchService) applyAuthFilter(user *models.SignedInUser, dashboards []dashboard) ([]dashboard, error) {
 	filter, err := s.auth.GetDashboardReadFilter(user)
 	if err != nil {
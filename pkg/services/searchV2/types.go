@@ -9,7 +9,28 @@ import (
 )
 
 type DashboardQuery struct {
-	Query string
+	// Full text query, terms can be limited to a field like `panel:latency`
+	Query string `json:"query"`
+
+	// Filters, a dashboard must have all tags and match one value of the others
+	Tags       []string `json:"tags,omitempty"`
+	Folder     []string `json:"folder,omitempty"`      // folder UIDs, empty for General
+	Datasource []string `json:"datasources,omitempty"` // UIDs or types
+	PanelType  []string `json:"panelType,omitempty"`
+
+	// Facets to count: tag, folder, datasource and panelType
+	Facets []string `json:"facets,omitempty"`
+
+	// score (default), name, updated or created, prefix with - for descending
+	Sort  string `json:"sort,omitempty"`
+	From  int    `json:"from,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// isSearch is false for the empty query that lists everything
+func (q DashboardQuery) isSearch() bool {
+	return q.Query != "" || len(q.Tags) > 0 || len(q.Folder) > 0 || len(q.Datasource) > 0 ||
+		len(q.PanelType) > 0 || len(q.Facets) > 0 || q.Sort != "" || q.From > 0 || q.Limit > 0
 }
 
 type SearchService interface {
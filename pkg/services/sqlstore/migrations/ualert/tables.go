@@ -25,6 +25,18 @@ func AddTablesMigrations(mg *migrator.Migrator) {
 
 	// Create provisioning data table
 	AddProvisioningMigrations(mg)
+
+	// Create state history table
+	AddStateHistoryMigrations(mg)
+
+	// Create scheduler replica leases table
+	AddSchedulerReplicaMigrations(mg)
+
+	// Create notification delivery log table
+	AddNotificationDeliveryMigrations(mg)
+
+	// Create alert notification images table
+	AddAlertImageMigrations(mg)
 }
 
 // AddAlertDefinitionMigrations should not be modified.
@@ -234,6 +246,9 @@ func AddAlertRuleMigrations(mg *migrator.Migrator, defaultIntervalSeconds int64)
 			Cols: []string{"org_id", "dashboard_uid", "panel_id"},
 		},
 	))
+
+	// add record column for recording rules
+	mg.AddMigration("add column record to alert_rule", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_rule"}, &migrator.Column{Name: "record", Type: migrator.DB_Text, Nullable: true}))
 }
 
 func AddAlertRuleVersionMigrations(mg *migrator.Migrator) {
@@ -277,6 +292,9 @@ func AddAlertRuleVersionMigrations(mg *migrator.Migrator) {
 
 	// add labels column
 	mg.AddMigration("add column labels to alert_rule_version", migrator.NewAddColumnMigration(alertRuleVersion, &migrator.Column{Name: "labels", Type: migrator.DB_Text, Nullable: true}))
+
+	// add record column for recording rules
+	mg.AddMigration("add column record to alert_rule_version", migrator.NewAddColumnMigration(alertRuleVersion, &migrator.Column{Name: "record", Type: migrator.DB_Text, Nullable: true}))
 }
 
 func AddAlertmanagerConfigMigrations(mg *migrator.Migrator) {
@@ -353,3 +371,100 @@ func AddProvisioningMigrations(mg *migrator.Migrator) {
 	mg.AddMigration("create provenance_type table", migrator.NewAddTableMigration(provisioningTable))
 	mg.AddMigration("add index to uniquify (record_key, record_type, org_id) columns", migrator.NewAddIndexMigration(provisioningTable, provisioningTable.Indices[0]))
 }
+
+func AddStateHistoryMigrations(mg *migrator.Migrator) {
+	stateHistoryTable := migrator.Table{
+		Name: "alert_state_history",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "rule_title", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "labels", Type: migrator.DB_Text, Nullable: false},
+			{Name: "evaluation_values", Type: migrator.DB_Text, Nullable: true},
+			{Name: "previous_state", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "state", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "evaluated_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "rule_uid", "evaluated_at"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "evaluated_at"}, Type: migrator.IndexType},
+			{Cols: []string{"evaluated_at"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_state_history table", migrator.NewAddTableMigration(stateHistoryTable))
+	mg.AddMigration("add index in alert_state_history on org_id, rule_uid and evaluated_at columns", migrator.NewAddIndexMigration(stateHistoryTable, stateHistoryTable.Indices[0]))
+	mg.AddMigration("add index in alert_state_history on org_id and evaluated_at columns", migrator.NewAddIndexMigration(stateHistoryTable, stateHistoryTable.Indices[1]))
+	mg.AddMigration("add index in alert_state_history on evaluated_at column", migrator.NewAddIndexMigration(stateHistoryTable, stateHistoryTable.Indices[2]))
+}
+
+func AddSchedulerReplicaMigrations(mg *migrator.Migrator) {
+	replicaTable := migrator.Table{
+		Name: "alert_scheduler_replica",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "replica_id", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "last_heartbeat", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"replica_id"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create alert_scheduler_replica table", migrator.NewAddTableMigration(replicaTable))
+	mg.AddMigration("add unique index in alert_scheduler_replica on replica_id column", migrator.NewAddIndexMigration(replicaTable, replicaTable.Indices[0]))
+}
+
+func AddNotificationDeliveryMigrations(mg *migrator.Migrator) {
+	deliveryTable := migrator.Table{
+		Name: "alert_notification_delivery",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "receiver", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "integration", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "integration_index", Type: migrator.DB_Int, Nullable: false},
+			{Name: "group_key", Type: migrator.DB_Text, Nullable: false},
+			{Name: "status", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "status_code", Type: migrator.DB_Int, Nullable: false},
+			{Name: "error", Type: migrator.DB_Text, Nullable: true},
+			{Name: "attempt", Type: migrator.DB_Int, Nullable: false},
+			{Name: "alerts", Type: migrator.DB_Int, Nullable: false},
+			{Name: "duration", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "sent_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "receiver", "sent_at"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "sent_at"}, Type: migrator.IndexType},
+			{Cols: []string{"sent_at"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_notification_delivery table", migrator.NewAddTableMigration(deliveryTable))
+	mg.AddMigration("add index in alert_notification_delivery on org_id, receiver and sent_at columns", migrator.NewAddIndexMigration(deliveryTable, deliveryTable.Indices[0]))
+	mg.AddMigration("add index in alert_notification_delivery on org_id and sent_at columns", migrator.NewAddIndexMigration(deliveryTable, deliveryTable.Indices[1]))
+	mg.AddMigration("add index in alert_notification_delivery on sent_at column", migrator.NewAddIndexMigration(deliveryTable, deliveryTable.Indices[2]))
+}
+
+func AddAlertImageMigrations(mg *migrator.Migrator) {
+	imageTable := migrator.Table{
+		Name: "alert_image",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "token", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "path", Type: migrator.DB_NVarchar, Length: 2048, Nullable: false},
+			{Name: "url", Type: migrator.DB_NVarchar, Length: 2048, Nullable: false},
+			{Name: "created_at", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "expires_at", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"token"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"expires_at"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_image table", migrator.NewAddTableMigration(imageTable))
+	mg.AddMigration("add unique index on token to alert_image table", migrator.NewAddIndexMigration(imageTable, imageTable.Indices[0]))
+	mg.AddMigration("add index in alert_image on expires_at column", migrator.NewAddIndexMigration(imageTable, imageTable.Indices[1]))
+}
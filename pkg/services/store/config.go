@@ -1,10 +1,25 @@
 package store
 
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// StorageConfig is read from storage.json in the storage folder of the data path
+type StorageConfig struct {
+	Roots []RootStorageConfig `json:"roots"`
+}
+
 type RootStorageConfig struct {
 	Type   string `json:"type"`
 	Prefix string `json:"prefix"`
 	Name   string `json:"name"`
 
+	// Keep the revisions of the files, git roots have their own history
+	Versioning *StorageVersioningConfig `json:"versioning,omitempty"`
+
 	// Depending on type, these will be configured
 	Disk *StorageLocalDiskConfig `json:"disk,omitempty"`
 	Git  *StorageGitConfig       `json:"git,omitempty"`
@@ -13,6 +28,11 @@ type RootStorageConfig struct {
 	GCS  *StorageGCSConfig       `json:"gcs,omitempty"`
 }
 
+type StorageVersioningConfig struct {
+	MaxVersions int    `json:"maxVersions,omitempty"` // 0 keeps all revisions
+	MaxAge      string `json:"maxAge,omitempty"`      // duration, empty keeps revisions forever
+}
+
 type StorageLocalDiskConfig struct {
 	Path  string   `json:"path"`
 	Roots []string `json:"roots,omitempty"` // null is everything
@@ -28,7 +48,7 @@ type StorageGitConfig struct {
 	RequirePullRequest bool `json:"requirePullRequest"`
 
 	// SECURE JSON :grimicing:
-	AccessToken string `json:"accessToken,omitempty"` // Simplest auth method for github
+	AccessToken string `json:"accessToken,omitempty"` // Simplest auth method for github, requires git 2.31 or later
 }
 
 type StorageSQLConfig struct {
@@ -51,3 +71,20 @@ type StorageGCSConfig struct {
 
 	CredentialsFile string `json:"credentialsFile"`
 }
+
+// loadStorageConfig returns an empty config when the file does not exist
+func loadStorageConfig(fpath string) (*StorageConfig, error) {
+	cfg := &StorageConfig{}
+	// #nosec G304 -- the path is built from the data path setting
+	body, err := os.ReadFile(fpath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(body, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -1,10 +1,12 @@
 package store
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/filestorage"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -15,6 +17,10 @@ type HTTPStorageService interface {
 	Read(c *models.ReqContext) response.Response
 	Delete(c *models.ReqContext) response.Response
 	Upload(c *models.ReqContext) response.Response
+	History(c *models.ReqContext) response.Response
+	ReadVersion(c *models.ReqContext) response.Response
+	Diff(c *models.ReqContext) response.Response
+	Restore(c *models.ReqContext) response.Response
 }
 
 type httpStorage struct {
@@ -43,12 +49,16 @@ func (s *httpStorage) Upload(c *models.ReqContext) response.Response {
 		return response.Error(500, "Internal Server Error", err)
 	}
 
-	return response.JSON(res.statusCode, map[string]interface{}{
+	body := map[string]interface{}{
 		"message": res.message,
 		"path":    res.path,
 		"file":    res.fileName,
 		"err":     true,
-	})
+	}
+	if res.result != nil {
+		body["result"] = res.result
+	}
+	return response.JSON(res.statusCode, body)
 }
 
 func (s *httpStorage) Read(c *models.ReqContext) response.Response {
@@ -66,15 +76,25 @@ func (s *httpStorage) Read(c *models.ReqContext) response.Response {
 }
 
 func (s *httpStorage) Delete(c *models.ReqContext) response.Response {
-	// full path is api/storage/delete/upload/example.jpg, but we only want the part after upload
-	_, path := getPathAndScope(c)
-	err := s.store.Delete(c.Req.Context(), c.SignedInUser, "/"+path)
+	// full path is api/storage/delete/upload/example.jpg, but we only want the part after delete
+	scope, path := getPathAndScope(c)
+	res, err := s.store.Delete(c.Req.Context(), c.SignedInUser, scope+"/"+path)
 	if err != nil {
+		switch {
+		case errors.Is(err, ErrStorageNotFound):
+			return response.Error(404, err.Error(), nil)
+		case errors.Is(err, ErrStorageReadOnly), errors.Is(err, ErrAccessDenied):
+			return response.Error(403, err.Error(), nil)
+		}
 		return response.Error(400, "cannot call delete", err)
 	}
-	return response.JSON(200, map[string]string{
+	if res.Code != 200 {
+		return response.Error(res.Code, res.Message, nil)
+	}
+	return response.JSON(200, map[string]interface{}{
 		"message": "Removed file from storage",
 		"path":    path,
+		"result":  res,
 	})
 }
 
@@ -90,3 +110,68 @@ func (s *httpStorage) List(c *models.ReqContext) response.Response {
 	}
 	return response.JSONStreaming(http.StatusOK, frame)
 }
+
+func versionErrorResponse(err error) response.Response {
+	switch {
+	case errors.Is(err, ErrStorageNotFound), errors.Is(err, ErrVersioningDisabled), errors.Is(err, filestorage.ErrVersionNotFound):
+		return response.Error(404, err.Error(), nil)
+	case errors.Is(err, ErrStorageReadOnly), errors.Is(err, ErrAccessDenied):
+		return response.Error(403, err.Error(), nil)
+	}
+	return response.Error(500, "error reading file history", err)
+}
+
+func (s *httpStorage) History(c *models.ReqContext) response.Response {
+	// full path is api/storage/history/dashboards/example.json, but we only want the part after history
+	scope, path := getPathAndScope(c)
+	versions, err := s.store.ListVersions(c.Req.Context(), c.SignedInUser, scope+"/"+path)
+	if err != nil {
+		return versionErrorResponse(err)
+	}
+	return response.JSON(200, versions)
+}
+
+func (s *httpStorage) ReadVersion(c *models.ReqContext) response.Response {
+	scope, path := getPathAndScope(c)
+	version := c.QueryInt64("version")
+	file, err := s.store.ReadVersion(c.Req.Context(), c.SignedInUser, scope+"/"+path, version)
+	if err != nil {
+		return versionErrorResponse(err)
+	}
+	if file == nil {
+		return response.Error(404, "version not found", nil)
+	}
+	c.Resp.Header().Set("Content-Type", file.MimeType)
+	return response.Respond(200, file.Contents)
+}
+
+func (s *httpStorage) Diff(c *models.ReqContext) response.Response {
+	scope, path := getPathAndScope(c)
+	diff, err := s.store.DiffVersions(c.Req.Context(), c.SignedInUser, scope+"/"+path, c.QueryInt64("from"), c.QueryInt64("to"))
+	if err != nil {
+		return versionErrorResponse(err)
+	}
+	return response.JSON(200, diff)
+}
+
+type restoreVersionCmd struct {
+	Version int64  `json:"version"`
+	Message string `json:"message,omitempty"`
+}
+
+func (s *httpStorage) Restore(c *models.ReqContext) response.Response {
+	cmd := restoreVersionCmd{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	scope, path := getPathAndScope(c)
+	err := s.store.RestoreVersion(c.Req.Context(), c.SignedInUser, scope+"/"+path, cmd.Version, cmd.Message)
+	if err != nil {
+		return versionErrorResponse(err)
+	}
+	return response.JSON(200, map[string]interface{}{
+		"message": "Restored version",
+		"path":    scope + "/" + path,
+		"version": cmd.Version,
+	})
+}
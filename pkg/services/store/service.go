@@ -2,12 +2,15 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/infra/filestorage"
@@ -21,8 +24,18 @@ import (
 
 var grafanaStorageLogger = log.New("grafanaStorageLogger")
 
+var (
+	ErrStorageNotFound    = errors.New("storage not found")
+	ErrVersioningDisabled = errors.New("versioning is not enabled for the storage")
+	ErrStorageReadOnly    = errors.New("storage is read only")
+	ErrAccessDenied       = errors.New("access denied to the storage")
+)
+
 const RootPublicStatic = "public-static"
+const RootUpload = "upload"
 const MAX_UPLOAD_SIZE = 1024 * 1024 // 1MB
+const syncInterval = 5 * time.Minute
+
 type StorageService interface {
 	registry.BackgroundService
 
@@ -32,9 +45,19 @@ type StorageService interface {
 	// Read raw file contents out of the store
 	Read(ctx context.Context, user *models.SignedInUser, path string) (*filestorage.File, error)
 
+	// Upload writes the files of the form to the folder in the "folder" value, the upload root by default
 	Upload(ctx context.Context, user *models.SignedInUser, form *multipart.Form) (*Response, error)
 
-	Delete(ctx context.Context, user *models.SignedInUser, path string) error
+	Delete(ctx context.Context, user *models.SignedInUser, path string) (*WriteValueResponse, error)
+
+	// ListVersions returns the revisions of a file in a root with versioning, newest first
+	ListVersions(ctx context.Context, user *models.SignedInUser, path string) ([]*filestorage.FileVersion, error)
+
+	ReadVersion(ctx context.Context, user *models.SignedInUser, path string, version int64) (*filestorage.File, error)
+
+	DiffVersions(ctx context.Context, user *models.SignedInUser, path string, from int64, to int64) (*filestorage.FileDiff, error)
+
+	RestoreVersion(ctx context.Context, user *models.SignedInUser, path string, version int64, message string) error
 }
 
 type standardStorageService struct {
@@ -48,6 +71,7 @@ type Response struct {
 	message    string
 	fileName   string
 	err        bool
+	result     *WriteValueResponse // the write of the last file
 }
 
 func ProvideService(sql *sqlstore.SQLStore, features featuremgmt.FeatureToggles, cfg *setting.Cfg) StorageService {
@@ -69,15 +93,23 @@ func ProvideService(sql *sqlstore.SQLStore, features featuremgmt.FeatureToggles,
 	_ = os.MkdirAll(storage, 0700)
 
 	if features.IsEnabled(featuremgmt.FlagStorageLocalUpload) {
-		upload := filepath.Join(storage, "upload")
+		upload := filepath.Join(storage, RootUpload)
 		_ = os.MkdirAll(upload, 0700)
-		roots = append(roots, newDiskStorage("upload", "Local file upload", &StorageLocalDiskConfig{
+		roots = append(roots, newDiskStorage(RootUpload, "Local file upload", &StorageLocalDiskConfig{
 			Path: upload,
 			Roots: []string{
 				"/",
 			},
 		}).setBuiltin(true))
 	}
+
+	storageConfig, err := loadStorageConfig(filepath.Join(storage, "storage.json"))
+	if err != nil {
+		grafanaStorageLogger.Warn("error loading storage config", "err", err)
+	} else {
+		roots = appendConfiguredRoots(roots, storageConfig.Roots, storage)
+	}
+
 	s := newStandardStorageService(roots)
 	s.sql = sql
 	return s
@@ -93,9 +125,73 @@ func newStandardStorageService(roots []storageRuntime) *standardStorageService {
 	}
 }
 
+// appendConfiguredRoots skips roots that would hide one with the same prefix
+func appendConfiguredRoots(roots []storageRuntime, configs []RootStorageConfig, storage string) []storageRuntime {
+	prefixes := make(map[string]bool, len(roots)+len(configs))
+	for _, root := range roots {
+		prefixes[root.Meta().Config.Prefix] = true
+	}
+
+	for _, cfg := range configs {
+		if prefixes[cfg.Prefix] || strings.Contains(cfg.Prefix, "/") {
+			grafanaStorageLogger.Warn("skipping storage with invalid prefix", "prefix", cfg.Prefix)
+			continue
+		}
+		prefixes[cfg.Prefix] = true
+
+		switch cfg.Type {
+		case rootStorageTypeDisk:
+			root := newDiskStorage(cfg.Prefix, cfg.Name, cfg.Disk)
+			root.setVersioning(cfg.Versioning)
+			roots = append(roots, root)
+		case rootStorageTypeS3:
+			root := newS3Storage(cfg.Prefix, cfg.Name, cfg.S3)
+			root.setVersioning(cfg.Versioning)
+			roots = append(roots, root)
+		case rootStorageTypeGCS:
+			root := newGCSStorage(cfg.Prefix, cfg.Name, cfg.GCS)
+			root.setVersioning(cfg.Versioning)
+			roots = append(roots, root)
+		case rootStorageTypeGit:
+			roots = append(roots, newGitStorage(cfg.Prefix, cfg.Name, filepath.Join(storage, "git", cfg.Prefix), cfg.Git))
+		default:
+			roots = append(roots, &baseStorageRuntime{
+				meta: RootStorageMeta{
+					Config: cfg,
+					Notice: []data.Notice{{
+						Severity: data.NoticeSeverityError,
+						Text:     fmt.Sprintf("Unsupported storage type: %s", cfg.Type),
+					}},
+				},
+			})
+		}
+	}
+	return roots
+}
+
 func (s *standardStorageService) Run(ctx context.Context) error {
 	grafanaStorageLogger.Info("storage starting")
-	return nil
+
+	s.sync()
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.sync()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sync pulls the changes made outside of Grafana, for example in a git remote
+func (s *standardStorageService) sync() {
+	for _, root := range s.tree.roots {
+		if err := root.Sync(); err != nil {
+			grafanaStorageLogger.Warn("error syncing storage", "prefix", root.Meta().Config.Prefix, "err", err)
+		}
+	}
 }
 
 func (s *standardStorageService) List(ctx context.Context, user *models.SignedInUser, path string) (*data.Frame, error) {
@@ -115,18 +211,48 @@ func isFileTypeValid(filetype string) bool {
 	return false
 }
 
+// canWrite returns true if the user can change the files of the root. Every signed in user can write
+// to the upload root, the roots configured by the admin can only be changed by editors.
+func canWrite(user *models.SignedInUser, prefix string) bool {
+	return prefix == RootUpload || (user != nil && user.HasRole(models.ROLE_EDITOR))
+}
+
 func (s *standardStorageService) Upload(ctx context.Context, user *models.SignedInUser, form *multipart.Form) (*Response, error) {
+	folder := RootUpload
+	if values := form.Value["folder"]; len(values) > 0 && values[0] != "" {
+		folder = strings.Trim(values[0], filestorage.Delimiter)
+	}
 	response := Response{
-		path: "upload",
+		path: folder,
 	}
-	upload, _ := s.tree.getRoot("upload")
-	if upload == nil {
+	root, folderPath := s.tree.getRuntime(folder)
+	if root == nil {
 		response.statusCode = 404
-		response.message = "upload feature is not enabled"
 		response.err = true
-		return &response, fmt.Errorf("upload feature is not enabled")
+		if folder == RootUpload {
+			response.message = "upload feature is not enabled"
+			return &response, fmt.Errorf("upload feature is not enabled")
+		}
+		response.message = "storage not found"
+		return &response, nil
+	}
+	if root.Meta().ReadOnly {
+		response.statusCode = 403
+		response.message = ErrStorageReadOnly.Error()
+		response.err = true
+		return &response, nil
+	}
+	if !canWrite(user, root.Meta().Config.Prefix) {
+		response.statusCode = 403
+		response.message = ErrAccessDenied.Error()
+		response.err = true
+		return &response, nil
 	}
 
+	message := ""
+	if values := form.Value["message"]; len(values) > 0 {
+		message = values[0]
+	}
 	files := form.File["file"]
 	for _, fileHeader := range files {
 		// Restrict the size of each uploaded file to 1MB based on the header
@@ -142,17 +268,18 @@ func (s *standardStorageService) Upload(ctx context.Context, user *models.Signed
 		if err != nil {
 			return nil, err
 		}
-		err = file.Close()
+		data, err := ioutil.ReadAll(file)
 		if err != nil {
+			_ = file.Close()
 			return nil, err
 		}
-		data, err := ioutil.ReadAll(file)
+		err = file.Close()
 		if err != nil {
 			return nil, err
 		}
 
 		filetype := http.DetectContentType(data)
-		path := "/" + fileHeader.Filename
+		path := strings.TrimSuffix(folderPath, filestorage.Delimiter) + filestorage.Delimiter + fileHeader.Filename
 
 		grafanaStorageLogger.Info("uploading a file", "filetype", filetype, "path", path)
 		// only allow images to be uploaded
@@ -163,29 +290,102 @@ func (s *standardStorageService) Upload(ctx context.Context, user *models.Signed
 				err:        true,
 			}, nil
 		}
-		err = upload.Upsert(ctx, &filestorage.UpsertFileCommand{
-			Path:     path,
-			Contents: data,
+		res, err := root.Write(ctx, &WriteValueRequest{
+			Path:    path,
+			User:    user,
+			Body:    data,
+			Message: message,
 		})
 		if err != nil {
 			return nil, err
 		}
+		if res.Code != 200 {
+			response.statusCode = res.Code
+			response.message = res.Message
+			response.err = true
+			return &response, nil
+		}
 		response.message = "Uploaded successfully"
+		if res.Message != "" {
+			response.message = res.Message
+		}
 		response.statusCode = 200
 		response.fileName = fileHeader.Filename
-		response.path = "upload/" + fileHeader.Filename
+		response.path = folder + "/" + fileHeader.Filename
+		response.result = res
 	}
 	return &response, nil
 }
 
-func (s *standardStorageService) Delete(ctx context.Context, user *models.SignedInUser, path string) error {
-	upload, _ := s.tree.getRoot("upload")
-	if upload == nil {
-		return fmt.Errorf("upload feature is not enabled")
+// Delete removes the file through its root, so that the root keeps track of the change
+func (s *standardStorageService) Delete(ctx context.Context, user *models.SignedInUser, path string) (*WriteValueResponse, error) {
+	root, path := s.tree.getRuntime(path)
+	if root == nil {
+		return nil, ErrStorageNotFound
 	}
-	err := upload.Delete(ctx, path)
+	if root.Meta().ReadOnly {
+		return nil, ErrStorageReadOnly
+	}
+	if !canWrite(user, root.Meta().Config.Prefix) {
+		return nil, ErrAccessDenied
+	}
+	return root.Delete(ctx, &WriteValueRequest{
+		Path: path,
+		User: user,
+	})
+}
+
+// versionedRoot returns the root of the path and the path within it
+func (s *standardStorageService) versionedRoot(path string) (filestorage.VersionedFileStorage, string, error) {
+	root, path := s.tree.getRoot(path)
+	if root == nil {
+		return nil, "", ErrStorageNotFound
+	}
+	versioned, ok := root.(filestorage.VersionedFileStorage)
+	if !ok {
+		return nil, "", ErrVersioningDisabled
+	}
+	return versioned, path, nil
+}
+
+func (s *standardStorageService) ListVersions(ctx context.Context, user *models.SignedInUser, path string) ([]*filestorage.FileVersion, error) {
+	root, path, err := s.versionedRoot(path)
+	if err != nil {
+		return nil, err
+	}
+	return root.ListVersions(ctx, path)
+}
+
+func (s *standardStorageService) ReadVersion(ctx context.Context, user *models.SignedInUser, path string, version int64) (*filestorage.File, error) {
+	root, path, err := s.versionedRoot(path)
+	if err != nil {
+		return nil, err
+	}
+	return root.GetVersion(ctx, path, version)
+}
+
+func (s *standardStorageService) DiffVersions(ctx context.Context, user *models.SignedInUser, path string, from int64, to int64) (*filestorage.FileDiff, error) {
+	root, path, err := s.versionedRoot(path)
+	if err != nil {
+		return nil, err
+	}
+	return root.DiffVersions(ctx, path, from, to)
+}
+
+func (s *standardStorageService) RestoreVersion(ctx context.Context, user *models.SignedInUser, path string, version int64, message string) error {
+	prefix, _ := splitFirstSegment(path)
+	for _, r := range s.tree.roots {
+		if meta := r.Meta(); meta.Config.Prefix == prefix && meta.ReadOnly {
+			return ErrStorageReadOnly
+		}
+	}
+	if !canWrite(user, prefix) {
+		return ErrAccessDenied
+	}
+
+	root, path, err := s.versionedRoot(path)
 	if err != nil {
 		return err
 	}
-	return nil
+	return root.RestoreVersion(ctx, path, version, userLogin(user), message)
 }
@@ -3,18 +3,24 @@ package store
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
 	"testing"
 
 	"github.com/grafana/grafana-plugin-sdk-go/experimental"
+	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/testdatasource"
+	"github.com/grafana/grafana/pkg/web"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob/memblob"
 )
 
 func TestListFiles(t *testing.T) {
@@ -65,3 +71,141 @@ func TestUpload(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, res.path, "upload")
 }
+
+func TestFileVersions(t *testing.T) {
+	root := newDiskStorage("dashboards", "Dashboards", &StorageLocalDiskConfig{
+		Path: t.TempDir(),
+	})
+	root.setVersioning(&StorageVersioningConfig{MaxVersions: 10})
+	store := newStandardStorageService([]storageRuntime{root})
+	ctx := context.Background()
+	user := &models.SignedInUser{Login: "admin", OrgRole: models.ROLE_EDITOR}
+
+	for _, body := range []string{`{"v":1}`, `{"v":2}`} {
+		_, err := root.Write(ctx, &WriteValueRequest{Path: "a.json", User: user, Body: json.RawMessage(body), Message: "save"})
+		require.NoError(t, err)
+	}
+
+	versions, err := store.ListVersions(ctx, user, "dashboards/a.json")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.Equal(t, "admin", versions[0].Author)
+	require.Equal(t, "save", versions[0].Message)
+
+	require.NoError(t, store.RestoreVersion(ctx, user, "dashboards/a.json", 1, ""))
+	file, err := store.Read(ctx, user, "dashboards/a.json")
+	require.NoError(t, err)
+	require.Equal(t, `{"v":1}`, string(file.Contents))
+
+	_, err = store.ListVersions(ctx, user, "missing/a.json")
+	require.ErrorIs(t, err, ErrStorageNotFound)
+}
+
+// pngImage starts with the PNG signature, enough to be detected as an image
+var pngImage = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+func newStorageRequest(t *testing.T, method string, url string, params map[string]string, body *bytes.Buffer, contentType string) *models.ReqContext {
+	t.Helper()
+	req := httptest.NewRequest(method, url, body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req = web.SetURLParams(req, params)
+	return &models.ReqContext{
+		Context: &web.Context{
+			Req:  req,
+			Resp: web.NewResponseWriter(method, httptest.NewRecorder()),
+		},
+		SignedInUser: &models.SignedInUser{Login: "admin", Email: "admin@example.com", OrgRole: models.ROLE_EDITOR},
+	}
+}
+
+func uploadRequest(t *testing.T, folder string, name string, contents []byte) *models.ReqContext {
+	t.Helper()
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	require.NoError(t, w.WriteField("folder", folder))
+	require.NoError(t, w.WriteField("message", "add "+name))
+	part, err := w.CreateFormFile("file", name)
+	require.NoError(t, err)
+	_, err = part.Write(contents)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return newStorageRequest(t, http.MethodPost, "/api/storage/upload", nil, body, w.FormDataContentType())
+}
+
+func TestHTTPWritesThroughRoots(t *testing.T) {
+	remote := setupGitRemote(t)
+	git := newGitStorage("git", "Git", t.TempDir(), &StorageGitConfig{Remote: remote})
+	require.NoError(t, git.Sync())
+
+	blobRoot := &rootStorageBlob{}
+	meta := newBlobStorageMeta(RootStorageConfig{Type: rootStorageTypeS3, Prefix: "s3", Name: "S3"}, "bucket")
+	blobRoot.init(&meta, memblob.OpenBucket(nil), "", nil)
+	blobRoot.meta = meta
+
+	public := newDiskStorage("public", "Public", &StorageLocalDiskConfig{Path: t.TempDir(), Roots: []string{"/"}}).setReadOnly(true)
+	api := ProvideHTTPService(newStandardStorageService([]storageRuntime{git, blobRoot, public}))
+
+	t.Run("git commits the upload and the delete", func(t *testing.T) {
+		resp := api.Upload(uploadRequest(t, "git/img", "a.png", pngImage))
+		require.Equal(t, 200, resp.Status(), string(resp.Body()))
+		require.Equal(t, "admin <admin@example.com> add a.png", runGit(t, remote, "log", "-1", "--format=%an <%ae> %s", "main"))
+		require.Equal(t, string(pngImage), runGit(t, remote, "show", "main:img/a.png"))
+
+		var uploaded struct {
+			Path   string             `json:"path"`
+			Result WriteValueResponse `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body(), &uploaded))
+		require.Equal(t, "git/img/a.png", uploaded.Path)
+		require.Equal(t, runGit(t, remote, "rev-parse", "main"), uploaded.Result.Hash)
+
+		resp = api.Delete(newStorageRequest(t, http.MethodDelete, "/api/storage/delete/git/img/a.png", map[string]string{"*": "git/img/a.png"}, &bytes.Buffer{}, ""))
+		require.Equal(t, 200, resp.Status(), string(resp.Body()))
+		require.Equal(t, "admin <admin@example.com> Delete img/a.png", runGit(t, remote, "log", "-1", "--format=%an <%ae> %s", "main"))
+		require.Empty(t, runGit(t, remote, "ls-tree", "-r", "--name-only", "main"))
+
+		resp = api.Delete(newStorageRequest(t, http.MethodDelete, "/api/storage/delete/git/img/a.png", map[string]string{"*": "git/img/a.png"}, &bytes.Buffer{}, ""))
+		require.Equal(t, 404, resp.Status())
+	})
+
+	t.Run("blob writes the upload and the delete", func(t *testing.T) {
+		resp := api.Upload(uploadRequest(t, "s3", "b.png", pngImage))
+		require.Equal(t, 200, resp.Status(), string(resp.Body()))
+		file, err := blobRoot.Store().Get(context.Background(), "/b.png")
+		require.NoError(t, err)
+		require.NotNil(t, file)
+		require.Equal(t, pngImage, file.Contents)
+
+		resp = api.Delete(newStorageRequest(t, http.MethodDelete, "/api/storage/delete/s3/b.png", map[string]string{"*": "s3/b.png"}, &bytes.Buffer{}, ""))
+		require.Equal(t, 200, resp.Status(), string(resp.Body()))
+		file, err = blobRoot.Store().Get(context.Background(), "/b.png")
+		require.NoError(t, err)
+		require.Nil(t, file)
+	})
+
+	t.Run("rejects read only and missing roots", func(t *testing.T) {
+		resp := api.Upload(uploadRequest(t, "public", "c.png", pngImage))
+		require.Equal(t, 403, resp.Status())
+		resp = api.Delete(newStorageRequest(t, http.MethodDelete, "/api/storage/delete/public/c.png", map[string]string{"*": "public/c.png"}, &bytes.Buffer{}, ""))
+		require.Equal(t, 403, resp.Status())
+		resp = api.Upload(uploadRequest(t, "missing", "c.png", pngImage))
+		require.Equal(t, 404, resp.Status())
+	})
+
+	t.Run("rejects writes of viewers", func(t *testing.T) {
+		req := uploadRequest(t, "s3", "d.png", pngImage)
+		req.SignedInUser.OrgRole = models.ROLE_VIEWER
+		resp := api.Upload(req)
+		require.Equal(t, 403, resp.Status())
+		file, err := blobRoot.Store().Get(context.Background(), "/d.png")
+		require.NoError(t, err)
+		require.Nil(t, file)
+
+		req = newStorageRequest(t, http.MethodDelete, "/api/storage/delete/s3/d.png", map[string]string{"*": "s3/d.png"}, &bytes.Buffer{}, "")
+		req.SignedInUser.OrgRole = models.ROLE_VIEWER
+		resp = api.Delete(req)
+		require.Equal(t, 403, resp.Status())
+	})
+}
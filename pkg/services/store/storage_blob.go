@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/filestorage"
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/gcsblob"
+	"gocloud.dev/blob/s3blob"
+	"gocloud.dev/gcp"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	rootStorageTypeS3  = "s3"
+	rootStorageTypeGCS = "gcs"
+
+	gcsScopeReadWrite = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+// rootStorageBlob keeps the files in an object store bucket
+type rootStorageBlob struct {
+	baseStorageRuntime
+}
+
+func newS3Storage(prefix string, name string, cfg *StorageS3Config) *rootStorageBlob {
+	if cfg == nil {
+		cfg = &StorageS3Config{}
+	}
+
+	meta := newBlobStorageMeta(RootStorageConfig{
+		Type:   rootStorageTypeS3,
+		Prefix: prefix,
+		Name:   name,
+		S3:     cfg,
+	}, cfg.Bucket)
+
+	s := &rootStorageBlob{}
+	if meta.Notice == nil {
+		bucket, err := openS3Bucket(context.Background(), cfg)
+		s.init(&meta, bucket, cfg.Folder, err)
+	}
+	s.meta = meta
+	return s
+}
+
+func newGCSStorage(prefix string, name string, cfg *StorageGCSConfig) *rootStorageBlob {
+	if cfg == nil {
+		cfg = &StorageGCSConfig{}
+	}
+
+	meta := newBlobStorageMeta(RootStorageConfig{
+		Type:   rootStorageTypeGCS,
+		Prefix: prefix,
+		Name:   name,
+		GCS:    cfg,
+	}, cfg.Bucket)
+
+	s := &rootStorageBlob{}
+	if meta.Notice == nil {
+		bucket, err := openGCSBucket(context.Background(), cfg)
+		s.init(&meta, bucket, cfg.Folder, err)
+	}
+	s.meta = meta
+	return s
+}
+
+func newBlobStorageMeta(cfg RootStorageConfig, bucket string) RootStorageMeta {
+	meta := RootStorageMeta{
+		Config: cfg,
+	}
+	if cfg.Prefix == "" {
+		meta.Notice = append(meta.Notice, data.Notice{
+			Severity: data.NoticeSeverityError,
+			Text:     "Missing prefix",
+		})
+	}
+	if bucket == "" {
+		meta.Notice = append(meta.Notice, data.Notice{
+			Severity: data.NoticeSeverityError,
+			Text:     "Missing bucket configuration",
+		})
+	}
+	return meta
+}
+
+func (s *rootStorageBlob) init(meta *RootStorageMeta, bucket *blob.Bucket, folder string, err error) {
+	if err != nil {
+		grafanaStorageLogger.Warn("error loading storage", "prefix", meta.Config.Prefix, "err", err)
+		meta.Notice = append(meta.Notice, data.Notice{
+			Severity: data.NoticeSeverityError,
+			Text:     "Failed to initialize storage",
+		})
+		return
+	}
+
+	rootFolder := strings.Trim(folder, filestorage.Delimiter)
+	if rootFolder != "" {
+		rootFolder += filestorage.Delimiter
+	}
+	s.store = filestorage.NewCdkBlobStorage(grafanaStorageLogger, bucket, rootFolder, nil)
+	meta.Ready = true
+}
+
+// openS3Bucket uses the default AWS credential chain unless keys are configured
+func openS3Bucket(ctx context.Context, cfg *StorageS3Config) (*blob.Bucket, error) {
+	awsCfg := aws.Config{}
+	if cfg.Region != "" {
+		awsCfg.Region = aws.String(cfg.Region)
+	}
+	if cfg.AccessKey != "" || cfg.SecretKey != "" {
+		awsCfg.Credentials = credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, "")
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            awsCfg,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s3blob.OpenBucket(ctx, sess, cfg.Bucket, nil)
+}
+
+// openGCSBucket uses the application default credentials unless a credentials file is configured
+func openGCSBucket(ctx context.Context, cfg *StorageGCSConfig) (*blob.Bucket, error) {
+	var creds *google.Credentials
+	if cfg.CredentialsFile != "" {
+		// #nosec G304 -- the path is set by the server admin
+		jsonKey, err := os.ReadFile(cfg.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		creds, err = google.CredentialsFromJSON(ctx, jsonKey, gcsScopeReadWrite)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		creds, err = gcp.DefaultCredentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := gcp.NewHTTPClient(gcp.DefaultTransport(), gcp.CredentialsTokenSource(creds))
+	if err != nil {
+		return nil, err
+	}
+	return gcsblob.OpenBucket(ctx, client, cfg.Bucket, nil)
+}
+
+// with object stores user metadata and messages are lost
+func (s *rootStorageBlob) Write(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error) {
+	return upsertValue(ctx, s.store, cmd)
+}
+
+func (s *rootStorageBlob) Delete(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error) {
+	return deleteValue(ctx, s.store, cmd)
+}
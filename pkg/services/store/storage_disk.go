@@ -74,18 +74,41 @@ func (s *rootStorageDisk) Sync() error {
 
 // with local disk user metadata and messages are lost
 func (s *rootStorageDisk) Write(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error) {
+	return upsertValue(ctx, s.store, cmd)
+}
+
+func (s *rootStorageDisk) Delete(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error) {
+	return deleteValue(ctx, s.store, cmd)
+}
+
+// upsertValue writes the body as is, for storage that can not keep the message or the author
+func upsertValue(ctx context.Context, store filestorage.FileStorage, cmd *WriteValueRequest) (*WriteValueResponse, error) {
 	byteAray := []byte(cmd.Body)
 
 	path := cmd.Path
 	if !strings.HasPrefix(path, filestorage.Delimiter) {
 		path = filestorage.Delimiter + path
 	}
-	err := s.store.Upsert(ctx, &filestorage.UpsertFileCommand{
+	err := store.Upsert(ctx, &filestorage.UpsertFileCommand{
 		Path:     path,
 		Contents: byteAray,
+		Author:   userLogin(cmd.User),
+		Message:  cmd.Message,
 	})
 	if err != nil {
 		return nil, err
 	}
+	return &WriteValueResponse{Code: 200, Size: int64(len(byteAray))}, nil
+}
+
+// deleteValue removes the file, for storage that can not keep the message or the author
+func deleteValue(ctx context.Context, store filestorage.FileStorage, cmd *WriteValueRequest) (*WriteValueResponse, error) {
+	path := cmd.Path
+	if !strings.HasPrefix(path, filestorage.Delimiter) {
+		path = filestorage.Delimiter + path
+	}
+	if err := store.Delete(ctx, path); err != nil {
+		return nil, err
+	}
 	return &WriteValueResponse{Code: 200}, nil
 }
@@ -0,0 +1,455 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/filestorage"
+	"gocloud.dev/blob"
+)
+
+const (
+	rootStorageTypeGit = "git"
+
+	defaultGitBranch = "main"
+	githubAPIURL     = "https://api.github.com"
+)
+
+// githubClient opens the pull requests
+var githubClient = &http.Client{Timeout: 30 * time.Second}
+
+// gitBranchInvalidChars are replaced in the names of the pull request branches, so that any file name makes a valid ref
+var gitBranchInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// errGitPathOutsideRepo is returned for the writes that would follow a link out of the working copy
+var errGitPathOutsideRepo = errors.New("path is outside the repository")
+
+var githubRemotePattern = regexp.MustCompile(`^(?:https://(?:[^@/]+@)?github\.com/|git@github\.com:)([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// rootStorageGit reads from a local clone of the remote, writes are committed and pushed
+type rootStorageGit struct {
+	baseStorageRuntime
+
+	settings  *StorageGitConfig
+	localDir  string // the clone of the remote
+	githubAPI string
+
+	// Held while the working copy is changed.
+	mu     sync.Mutex
+	metaMu sync.RWMutex
+}
+
+func newGitStorage(prefix string, name string, localWorkCache string, cfg *StorageGitConfig) *rootStorageGit {
+	if cfg == nil {
+		cfg = &StorageGitConfig{}
+	}
+	if cfg.Branch == "" {
+		cfg.Branch = defaultGitBranch
+	}
+
+	meta := RootStorageMeta{
+		Config: RootStorageConfig{
+			Type:   rootStorageTypeGit,
+			Prefix: prefix,
+			Name:   name,
+			Git:    cfg,
+		},
+	}
+	if prefix == "" {
+		meta.Notice = append(meta.Notice, data.Notice{
+			Severity: data.NoticeSeverityError,
+			Text:     "Missing prefix",
+		})
+	}
+	if cfg.Remote == "" {
+		meta.Notice = append(meta.Notice, data.Notice{
+			Severity: data.NoticeSeverityError,
+			Text:     "Missing remote configuration",
+		})
+	}
+	s := &rootStorageGit{
+		settings:  cfg,
+		localDir:  localWorkCache,
+		githubAPI: githubAPIURL,
+	}
+
+	if meta.Notice == nil {
+		err := os.MkdirAll(localWorkCache, 0750)
+		var bucket *blob.Bucket
+		if err == nil {
+			bucket, err = blob.OpenBucket(context.Background(), fmt.Sprintf("file://%s", localWorkCache))
+		}
+		if err != nil {
+			grafanaStorageLogger.Warn("error loading storage", "prefix", prefix, "err", err)
+			meta.Notice = append(meta.Notice, data.Notice{
+				Severity: data.NoticeSeverityError,
+				Text:     "Failed to initialize storage",
+			})
+		} else {
+			rootFolder := strings.Trim(filepath.ToSlash(cfg.Root), filestorage.Delimiter)
+			if rootFolder != "" {
+				rootFolder += filestorage.Delimiter
+			}
+			s.store = filestorage.NewCdkBlobStorage(grafanaStorageLogger,
+				bucket, rootFolder,
+				filestorage.NewPathFilter([]string{filestorage.Delimiter}, nil, []string{"/.git/"}, []string{"/.git"}))
+		}
+	}
+
+	s.meta = meta
+	return s
+}
+
+func (s *rootStorageGit) Meta() RootStorageMeta {
+	s.metaMu.RLock()
+	defer s.metaMu.RUnlock()
+	return s.meta
+}
+
+// Sync pulls the remote branch, the storage is ready after the first successful pull
+func (s *rootStorageGit) Sync() error {
+	if s.store == nil {
+		return nil // not configured
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if err := s.pull(ctx); err != nil {
+		return err
+	}
+	s.metaMu.Lock()
+	s.meta.Ready = true
+	s.metaMu.Unlock()
+	return nil
+}
+
+// pull resets the working copy to the remote branch, the clone is created when missing
+func (s *rootStorageGit) pull(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(s.localDir, ".git")); err != nil {
+		if _, err := s.git(ctx, "init", "-q"); err != nil {
+			return err
+		}
+		if _, err := s.git(ctx, "remote", "add", "origin", s.settings.Remote); err != nil {
+			return err
+		}
+	}
+
+	branch := s.settings.Branch
+	if _, err := s.git(ctx, "fetch", "-q", "origin", "+refs/heads/"+branch+":refs/remotes/origin/"+branch); err != nil {
+		return err
+	}
+	if _, err := s.git(ctx, "checkout", "-q", "-f", "-B", branch, "origin/"+branch); err != nil {
+		return err
+	}
+	_, err := s.git(ctx, "clean", "-q", "-f", "-d")
+	return err
+}
+
+// Write commits the body to the configured branch, or to a new branch waiting for a pull request
+func (s *rootStorageGit) Write(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error) {
+	body := []byte(cmd.Body)
+	res, err := s.commit(ctx, cmd, "Update ", func(rel string) error {
+		fpath, err := s.localPath(rel)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(fpath), 0750); err != nil {
+			return err
+		}
+		if err := os.WriteFile(fpath, body, 0600); err != nil {
+			return err
+		}
+		_, err = s.git(ctx, "add", "--", rel)
+		return err
+	})
+	if err != nil || res.Code != 200 {
+		return res, err
+	}
+	res.Size = int64(len(body))
+	return res, nil
+}
+
+// Delete commits the removal of the file, like Write
+func (s *rootStorageGit) Delete(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error) {
+	return s.commit(ctx, cmd, "Delete ", func(rel string) error {
+		fpath, err := s.localPath(rel)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(fpath); err != nil {
+			return err
+		}
+		_, err = s.git(ctx, "rm", "-q", "--", rel)
+		return err
+	})
+}
+
+// commit applies the change to the working copy of the branch, then commits and pushes it
+func (s *rootStorageGit) commit(ctx context.Context, cmd *WriteValueRequest, action string, change func(rel string) error) (*WriteValueResponse, error) {
+	if s.store == nil {
+		return &WriteValueResponse{
+			Code:    500,
+			Message: "storage is not configured",
+		}, nil
+	}
+
+	rel, err := s.repoPath(cmd.Path)
+	if err != nil {
+		return &WriteValueResponse{
+			Code:    400,
+			Message: err.Error(),
+		}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Start from the latest remote state so the push is a fast-forward.
+	if err := s.pull(ctx); err != nil {
+		return nil, err
+	}
+	base := s.settings.Branch
+	branch := base
+	createPR := s.settings.RequirePullRequest || cmd.Action == "pr"
+	if createPR {
+		branch = gitBranchName(rel, time.Now())
+		if _, err := s.git(ctx, "checkout", "-q", "-b", branch); err != nil {
+			return nil, err
+		}
+	}
+	// Whatever happens, leave the working copy on the configured branch.
+	defer func() {
+		if _, err := s.git(context.Background(), "checkout", "-q", "-f", "-B", base, "origin/"+base); err != nil {
+			grafanaStorageLogger.Warn("error resetting git storage", "remote", s.settings.Remote, "err", err)
+		}
+		if createPR {
+			_, _ = s.git(context.Background(), "branch", "-q", "-D", branch)
+		}
+	}()
+
+	if err := change(rel); err != nil {
+		if errors.Is(err, errGitPathOutsideRepo) {
+			return &WriteValueResponse{
+				Code:    400,
+				Message: err.Error(),
+			}, nil
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			return &WriteValueResponse{
+				Code:    404,
+				Message: "file not found: " + cmd.Path,
+			}, nil
+		}
+		return nil, err
+	}
+
+	message := cmd.Message
+	if message == "" {
+		message = action + rel
+	}
+	if _, err := s.git(ctx, "commit", "-q", "--allow-empty", "--no-verify", "--author", gitAuthor(cmd), "-m", message); err != nil {
+		return nil, err
+	}
+	hash, err := s.git(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.git(ctx, "push", "-q", "origin", "HEAD:refs/heads/"+branch); err != nil {
+		return nil, err
+	}
+
+	res := &WriteValueResponse{
+		Code:   200,
+		Hash:   hash,
+		Branch: branch,
+	}
+	if !createPR {
+		return res, nil
+	}
+
+	res.Pending = true
+	title := cmd.Title
+	if title == "" {
+		title = message
+	}
+	url, err := s.openPullRequest(ctx, branch, title, message)
+	if err != nil {
+		grafanaStorageLogger.Warn("error opening pull request", "remote", s.settings.Remote, "branch", branch, "err", err)
+		res.Message = fmt.Sprintf("pushed to branch %s, failed to open a pull request: %s", branch, err.Error())
+		return res, nil
+	}
+	if url == "" {
+		res.Message = fmt.Sprintf("pushed to branch %s, open a pull request to merge it", branch)
+		return res, nil
+	}
+	res.URL = url
+	return res, nil
+}
+
+// repoPath returns the slash separated path of the file within the repository
+func (s *rootStorageGit) repoPath(p string) (string, error) {
+	clean := path.Clean(filestorage.Delimiter + p)
+	if clean == filestorage.Delimiter {
+		return "", fmt.Errorf("missing path")
+	}
+	rel := strings.TrimPrefix(path.Join(filestorage.Delimiter, filepath.ToSlash(s.settings.Root), clean), filestorage.Delimiter)
+	for _, part := range strings.Split(rel, "/") {
+		if strings.EqualFold(part, ".git") {
+			return "", fmt.Errorf("invalid path: %s", p)
+		}
+	}
+	return rel, nil
+}
+
+// localPath returns the path of the file in the working copy. The links committed to the remote are followed
+// by the file system, so the path is rejected when its existing parent resolves outside the working copy,
+// or when the file itself is a link.
+func (s *rootStorageGit) localPath(rel string) (string, error) {
+	root, err := filepath.EvalSymlinks(s.localDir)
+	if err != nil {
+		return "", err
+	}
+	fpath := filepath.Join(s.localDir, filepath.FromSlash(rel))
+
+	// the missing directories are created by the write, the closest existing one is resolved
+	dir := filepath.Dir(fpath)
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		dir = filepath.Dir(dir)
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", errGitPathOutsideRepo, rel)
+	}
+
+	if fi, err := os.Lstat(fpath); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		return "", fmt.Errorf("%w: %s is a link", errGitPathOutsideRepo, rel)
+	}
+	return fpath, nil
+}
+
+// openPullRequest returns an empty URL when pull requests can not be opened for the remote
+func (s *rootStorageGit) openPullRequest(ctx context.Context, branch string, title string, body string) (string, error) {
+	match := githubRemotePattern.FindStringSubmatch(s.settings.Remote)
+	if match == nil || s.settings.AccessToken == "" {
+		return "", nil
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"title": title,
+		"head":  branch,
+		"base":  s.settings.Branch,
+		"body":  body,
+	})
+	if err != nil {
+		return "", err
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls", s.githubAPI, match[1], match[2])
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "token "+s.settings.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := githubClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	pr := struct {
+		HTMLURL string `json:"html_url"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return "", err
+	}
+	return pr.HTMLURL, nil
+}
+
+// git runs a git command in the local clone
+func (s *rootStorageGit) git(ctx context.Context, args ...string) (string, error) {
+	// #nosec G204 -- the arguments are built by the storage, the remote is set by the server admin
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = s.localDir
+	cmd.Env = append(os.Environ(), s.gitEnv()...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w (%s)", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitEnv returns the environment of the git commands. The access token is passed as
+// configuration in the environment, it is neither visible in the process list nor
+// stored in the remote configuration of the clone.
+func (s *rootStorageGit) gitEnv() []string {
+	env := []string{
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_COMMITTER_NAME=Grafana",
+		"GIT_COMMITTER_EMAIL=grafana@localhost",
+	}
+	if s.settings.AccessToken != "" && strings.HasPrefix(s.settings.Remote, "https://") {
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + s.settings.AccessToken))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		)
+	}
+	return env
+}
+
+// gitBranchName returns the name of the pull request branch of a change to the file
+func gitBranchName(rel string, now time.Time) string {
+	name := strings.Trim(gitBranchInvalidChars.ReplaceAllString(strings.TrimSuffix(path.Base(rel), path.Ext(rel)), "-"), "-")
+	if name == "" {
+		name = "change"
+	}
+	return fmt.Sprintf("grafana/%s-%d", name, now.UnixNano())
+}
+
+func gitAuthor(cmd *WriteValueRequest) string {
+	if cmd.User == nil {
+		return "Grafana <grafana@localhost>"
+	}
+	name := cmd.User.Name
+	if name == "" {
+		name = cmd.User.Login
+	}
+	email := cmd.User.Email
+	if email == "" {
+		email = cmd.User.Login + "@localhost"
+	}
+	return fmt.Sprintf("%s <%s>", name, email)
+}
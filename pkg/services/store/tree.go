@@ -8,8 +8,9 @@ import (
 )
 
 type nestedTree struct {
-	roots  []storageRuntime
-	lookup map[string]filestorage.FileStorage
+	roots    []storageRuntime
+	lookup   map[string]filestorage.FileStorage
+	runtimes map[string]storageRuntime
 }
 
 var (
@@ -18,8 +19,10 @@ var (
 
 func (t *nestedTree) init() {
 	t.lookup = make(map[string]filestorage.FileStorage, len(t.roots))
+	t.runtimes = make(map[string]storageRuntime, len(t.roots))
 	for _, root := range t.roots {
 		t.lookup[root.Meta().Config.Prefix] = root.Store()
+		t.runtimes[root.Meta().Config.Prefix] = root
 	}
 }
 
@@ -36,6 +39,20 @@ func (t *nestedTree) getRoot(path string) (filestorage.FileStorage, string) {
 	return root, filestorage.Delimiter + path
 }
 
+// getRuntime returns the root that handles the writes of the path, and the path within it
+func (t *nestedTree) getRuntime(path string) (storageRuntime, string) {
+	if path == "" {
+		return nil, ""
+	}
+
+	rootKey, path := splitFirstSegment(path)
+	root, ok := t.runtimes[rootKey]
+	if !ok || root.Store() == nil {
+		return nil, path // not found or not ready
+	}
+	return root, filestorage.Delimiter + path
+}
+
 func (t *nestedTree) GetFile(ctx context.Context, path string) (*filestorage.File, error) {
 	if path == "" {
 		return nil, nil // not found
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/infra/filestorage"
@@ -46,6 +47,9 @@ type storageRuntime interface {
 
 	// Different storage knows how to handle comments and tracking
 	Write(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error)
+
+	// Delete removes the file at the path of the request, the body is ignored
+	Delete(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error)
 }
 
 type baseStorageRuntime struct {
@@ -72,6 +76,13 @@ func (t *baseStorageRuntime) Write(ctx context.Context, cmd *WriteValueRequest)
 	}, nil
 }
 
+func (t *baseStorageRuntime) Delete(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error) {
+	return &WriteValueResponse{
+		Code:    500,
+		Message: "unsupportted operation (base)",
+	}, nil
+}
+
 func (t *baseStorageRuntime) setReadOnly(val bool) *baseStorageRuntime {
 	t.meta.ReadOnly = val
 	return t
@@ -82,6 +93,30 @@ func (t *baseStorageRuntime) setBuiltin(val bool) *baseStorageRuntime {
 	return t
 }
 
+// setVersioning keeps the revisions of the files written to the root
+func (t *baseStorageRuntime) setVersioning(cfg *StorageVersioningConfig) {
+	if cfg == nil || t.store == nil {
+		return
+	}
+
+	retention := filestorage.RetentionPolicy{
+		MaxVersions: cfg.MaxVersions,
+	}
+	if cfg.MaxAge != "" {
+		maxAge, err := time.ParseDuration(cfg.MaxAge)
+		if err != nil {
+			t.meta.Notice = append(t.meta.Notice, data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     "Invalid max age of versions, versioning is disabled",
+			})
+			return
+		}
+		retention.MaxAge = maxAge
+	}
+	t.store = filestorage.NewVersionedStorage(grafanaStorageLogger, t.store, retention)
+	t.meta.Config.Versioning = cfg
+}
+
 type RootStorageMeta struct {
 	ReadOnly bool          `json:"editable,omitempty"`
 	Builtin  bool          `json:"builtin,omitempty"`
@@ -29,3 +29,11 @@ func getPathAndScope(c *models.ReqContext) (string, string) {
 	}
 	return splitFirstSegment(filepath.Clean(path))
 }
+
+// userLogin is kept as the author of file revisions
+func userLogin(user *models.SignedInUser) string {
+	if user == nil {
+		return ""
+	}
+	return user.Login
+}
@@ -428,6 +428,18 @@ type Cfg struct {
 	// LiveAllowedOrigins is a set of origins accepted by Live. If not provided
 	// then Live uses AppURL as the only allowed origin.
 	LiveAllowedOrigins []string
+	// LiveHistoryMaxPoints is a maximum number of points kept in the history of
+	// each managed stream channel. 0 disables the history.
+	LiveHistoryMaxPoints int
+	// LiveHistoryMaxAge is a maximum age of the points kept in the history of
+	// each managed stream channel.
+	LiveHistoryMaxAge time.Duration
+	// LiveMQTT configures the MQTT listener which pushes the messages of
+	// broker topics to Live stream channels.
+	LiveMQTT LiveMQTTSettings
+	// LiveStatsd configures the statsd listener which pushes the received
+	// metrics to Live stream channels.
+	LiveStatsd LiveStatsdSettings
 
 	// Grafana.com URL
 	GrafanaComURL string
@@ -442,7 +454,11 @@ type Cfg struct {
 	// Query history
 	QueryHistoryEnabled bool
 
+	QueryCaching QueryCachingSettings
+
 	DashboardPreviews DashboardPreviewsSettings
+
+	Search SearchSettings
 }
 
 type CommandLineArgs struct {
@@ -972,6 +988,12 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	queryHistory := iniFile.Section("query_history")
 	cfg.QueryHistoryEnabled = queryHistory.Key("enabled").MustBool(false)
 
+	queryCaching, err := readQueryCachingSettings(iniFile)
+	if err != nil {
+		return err
+	}
+	cfg.QueryCaching = queryCaching
+
 	panelsSection := iniFile.Section("panels")
 	cfg.DisableSanitizeHtml = panelsSection.Key("disable_sanitize_html").MustBool(false)
 
@@ -1007,6 +1029,7 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	cfg.readDataSourcesSettings()
 
 	cfg.DashboardPreviews = readDashboardPreviewsSettings(iniFile)
+	cfg.Search = readSearchSettings(iniFile, cfg.DataPath)
 
 	if VerifyEmailEnabled && !cfg.Smtp.Enabled {
 		cfg.Logger.Warn("require_email_validation is enabled but smtp is disabled")
@@ -1577,5 +1600,125 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 		return err
 	}
 	cfg.LiveAllowedOrigins = originPatterns
+
+	cfg.LiveHistoryMaxPoints = section.Key("history_max_points").MustInt(1000)
+	if cfg.LiveHistoryMaxPoints < 0 {
+		return fmt.Errorf("unexpected value %d for [live] history_max_points", cfg.LiveHistoryMaxPoints)
+	}
+	cfg.LiveHistoryMaxAge, err = gtime.ParseDuration(section.Key("history_max_age").MustString("1h"))
+	if err != nil {
+		return fmt.Errorf("invalid value for [live] history_max_age: %w", err)
+	}
+
+	if err := cfg.readLiveMQTTSettings(iniFile); err != nil {
+		return err
+	}
+	return cfg.readLiveStatsdSettings(iniFile)
+}
+
+// LiveMQTTSettings configures the MQTT listener of Live.
+type LiveMQTTSettings struct {
+	Enabled       bool
+	BrokerURL     string
+	Topics        []string
+	ClientID      string
+	Username      string
+	Password      string
+	QoS           int
+	TLSSkipVerify bool
+	// MaxPacketSize is the largest packet accepted from the broker in bytes,
+	// the connection is closed when the broker sends a larger one.
+	MaxPacketSize int
+	// OrgID is the organization of the stream channels.
+	OrgID int64
+	// Namespace of the stream channels, a message of topic sensors/temperature
+	// is pushed to channel stream/<namespace>/sensors/temperature.
+	Namespace string
+}
+
+// LiveStatsdSettings configures the statsd listener of Live.
+type LiveStatsdSettings struct {
+	Enabled bool
+	// Address is the UDP and TCP address of the listener.
+	Address       string
+	FlushInterval time.Duration
+	// MaxSeries is the number of series aggregated at most, samples
+	// of new series are dropped when it is reached.
+	MaxSeries int
+	// GaugeExpiry is the number of flushes after which a gauge
+	// that was not updated is deleted.
+	GaugeExpiry int
+	// OrgID is the organization of the stream channels.
+	OrgID int64
+	// Namespace of the stream channels, metric requests.count is
+	// pushed to channel stream/<namespace>/requests.count.
+	Namespace string
+}
+
+var liveNamespacePattern = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
+func (cfg *Cfg) readLiveMQTTSettings(iniFile *ini.File) error {
+	section := iniFile.Section("live.mqtt")
+	cfg.LiveMQTT = LiveMQTTSettings{
+		Enabled:       section.Key("enabled").MustBool(false),
+		BrokerURL:     section.Key("broker_url").MustString("tcp://localhost:1883"),
+		Topics:        util.SplitString(section.Key("topics").MustString("")),
+		ClientID:      section.Key("client_id").MustString(""),
+		Username:      section.Key("username").MustString(""),
+		Password:      section.Key("password").MustString(""),
+		QoS:           section.Key("qos").MustInt(0),
+		TLSSkipVerify: section.Key("tls_skip_verify_insecure").MustBool(false),
+		MaxPacketSize: section.Key("max_packet_size").MustInt(1048576),
+		OrgID:         section.Key("org_id").MustInt64(1),
+		Namespace:     section.Key("namespace").MustString("mqtt"),
+	}
+	if !cfg.LiveMQTT.Enabled {
+		return nil
+	}
+	if len(cfg.LiveMQTT.Topics) == 0 {
+		return errors.New("[live.mqtt] topics must be set when the MQTT listener is enabled")
+	}
+	if cfg.LiveMQTT.QoS < 0 || cfg.LiveMQTT.QoS > 2 {
+		return fmt.Errorf("unexpected value %d for [live.mqtt] qos", cfg.LiveMQTT.QoS)
+	}
+	if cfg.LiveMQTT.MaxPacketSize <= 0 {
+		return fmt.Errorf("unexpected value %d for [live.mqtt] max_packet_size", cfg.LiveMQTT.MaxPacketSize)
+	}
+	if !liveNamespacePattern.MatchString(cfg.LiveMQTT.Namespace) {
+		return fmt.Errorf("invalid value %q for [live.mqtt] namespace", cfg.LiveMQTT.Namespace)
+	}
+	return nil
+}
+
+func (cfg *Cfg) readLiveStatsdSettings(iniFile *ini.File) error {
+	section := iniFile.Section("live.statsd")
+	flushInterval, err := gtime.ParseDuration(section.Key("flush_interval").MustString("1s"))
+	if err != nil {
+		return fmt.Errorf("invalid value for [live.statsd] flush_interval: %w", err)
+	}
+	cfg.LiveStatsd = LiveStatsdSettings{
+		Enabled:       section.Key("enabled").MustBool(false),
+		Address:       section.Key("address").MustString(":8125"),
+		FlushInterval: flushInterval,
+		MaxSeries:     section.Key("max_series").MustInt(10000),
+		GaugeExpiry:   section.Key("gauge_expiry").MustInt(60),
+		OrgID:         section.Key("org_id").MustInt64(1),
+		Namespace:     section.Key("namespace").MustString("statsd"),
+	}
+	if !cfg.LiveStatsd.Enabled {
+		return nil
+	}
+	if cfg.LiveStatsd.FlushInterval <= 0 {
+		return fmt.Errorf("unexpected value %s for [live.statsd] flush_interval", cfg.LiveStatsd.FlushInterval)
+	}
+	if cfg.LiveStatsd.MaxSeries <= 0 {
+		return fmt.Errorf("unexpected value %d for [live.statsd] max_series", cfg.LiveStatsd.MaxSeries)
+	}
+	if cfg.LiveStatsd.GaugeExpiry <= 0 {
+		return fmt.Errorf("unexpected value %d for [live.statsd] gauge_expiry", cfg.LiveStatsd.GaugeExpiry)
+	}
+	if !liveNamespacePattern.MatchString(cfg.LiveStatsd.Namespace) {
+		return fmt.Errorf("invalid value %q for [live.statsd] namespace", cfg.LiveStatsd.Namespace)
+	}
 	return nil
 }
@@ -0,0 +1,49 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+const (
+	QueryCachingBackendMemory = "memory"
+	QueryCachingBackendRemote = "remote"
+
+	defaultQueryCachingMaxMemoryBytes = 100 * 1024 * 1024
+)
+
+type QueryCachingSettings struct {
+	// Cache the results of data source queries
+	Enabled bool
+	// Where the results are kept: memory of the instance or the remote cache of [remote_cache]
+	Backend string
+	// Time the results are cached, overridden by the queryCachingTTL option of a data source
+	TTL time.Duration
+	// Maximum size of the results kept by the memory backend, the least recently used ones are evicted
+	MaxMemoryBytes int64
+}
+
+func readQueryCachingSettings(iniFile *ini.File) (QueryCachingSettings, error) {
+	s := QueryCachingSettings{}
+
+	section := iniFile.Section("query_caching")
+	s.Enabled = section.Key("enabled").MustBool(false)
+	s.Backend = section.Key("backend").MustString(QueryCachingBackendMemory)
+	s.TTL = section.Key("ttl").MustDuration(time.Minute)
+	s.MaxMemoryBytes = section.Key("max_memory_bytes").MustInt64(defaultQueryCachingMaxMemoryBytes)
+
+	switch s.Backend {
+	case QueryCachingBackendMemory, QueryCachingBackendRemote:
+	default:
+		return s, fmt.Errorf("unsupported [query_caching] backend: %s", s.Backend)
+	}
+	if s.TTL < 0 {
+		return s, fmt.Errorf("unexpected value %s for [query_caching] ttl", s.TTL)
+	}
+	if s.MaxMemoryBytes <= 0 {
+		return s, fmt.Errorf("unexpected value %d for [query_caching] max_memory_bytes", s.MaxMemoryBytes)
+	}
+	return s, nil
+}
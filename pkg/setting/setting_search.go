@@ -0,0 +1,27 @@
+package setting
+
+import (
+	"path/filepath"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+type SearchSettings struct {
+	// Directory where the search index is persisted between restarts
+	IndexPath string
+	// Interval of the full re-index from the database, disabled when 0
+	FullReindexInterval time.Duration
+	// Ignore the persisted index and rebuild it from the database on startup
+	RebuildIndexOnStartup bool
+}
+
+func readSearchSettings(iniFile *ini.File, dataPath string) SearchSettings {
+	s := SearchSettings{}
+
+	searchSection := iniFile.Section("search")
+	s.IndexPath = searchSection.Key("index_path").MustString(filepath.Join(dataPath, "search"))
+	s.FullReindexInterval = searchSection.Key("full_reindex_interval").MustDuration(0)
+	s.RebuildIndexOnStartup = searchSection.Key("rebuild_index_on_startup").MustBool(false)
+	return s
+}
@@ -48,12 +48,26 @@ const (
 	schedulereDefaultExecuteAlerts          = true
 	schedulerDefaultMaxAttempts             = 3
 	schedulerDefaultLegacyMinInterval       = 1
+	schedulerDefaultReplicaHeartbeat        = 15 * time.Second
+	schedulerDefaultReplicaTimeout          = time.Minute
 	// SchedulerBaseInterval base interval of the scheduler. Controls how often the scheduler fetches database for new changes as well as schedules evaluation of a rule
 	// changing this value is discouraged because this could cause existing alert definition
 	// with intervals that are not exactly divided by this number not to be evaluated
 	SchedulerBaseInterval = 10 * time.Second
 	// DefaultRuleEvaluationInterval indicates a default interval of for how long a rule should be evaluated to change state from Pending to Alerting
 	DefaultRuleEvaluationInterval = SchedulerBaseInterval * 6 // == 60 seconds
+
+	StateHistoryBackendSQL       = "sql"
+	StateHistoryBackendLoki      = "loki"
+	stateHistoryDefaultRetention = "30d"
+
+	deliveryLogDefaultRetention = "7d"
+
+	screenshotsDefaultCaptureTimeout = 10 * time.Second
+	screenshotsDefaultMaxConcurrent  = 5
+	screenshotsMaxCaptureTimeout     = 30 * time.Second
+
+	recordingRulesDefaultTimeout = 10 * time.Second
 )
 
 type UnifiedAlertingSettings struct {
@@ -77,6 +91,53 @@ type UnifiedAlertingSettings struct {
 	BaseInterval time.Duration
 	// DefaultRuleEvaluationInterval default interval between evaluations of a rule.
 	DefaultRuleEvaluationInterval time.Duration
+	StateHistory                  UnifiedAlertingStateHistorySettings
+	// HAEvaluationSharding splits the evaluation of the alert rules between the replicas.
+	// The replicas are discovered from HAPeers, or from the database when no peers are configured.
+	HAEvaluationSharding bool
+	HAReplicaHeartbeat   time.Duration
+	HAReplicaTimeout     time.Duration
+	RecordingRules       UnifiedAlertingRecordingRulesSettings
+	DeliveryLog          UnifiedAlertingDeliveryLogSettings
+	Screenshots          UnifiedAlertingScreenshotSettings
+}
+
+// UnifiedAlertingScreenshotSettings configures the screenshots of the panels of the alert rules attached to notifications.
+type UnifiedAlertingScreenshotSettings struct {
+	Capture bool
+	// CaptureTimeout bounds the time the evaluation of a rule waits for a screenshot.
+	CaptureTimeout           time.Duration
+	MaxConcurrentScreenshots int
+	// UploadExternalImageStorage uploads the screenshots to the storage of the [external_image_storage] section.
+	UploadExternalImageStorage bool
+}
+
+// UnifiedAlertingDeliveryLogSettings configures the log of the attempts to deliver notifications through the contact points.
+type UnifiedAlertingDeliveryLogSettings struct {
+	Enabled bool
+	// Retention of the attempts kept in the database, 0 keeps them forever.
+	Retention time.Duration
+}
+
+// UnifiedAlertingRecordingRulesSettings configures the Prometheus remote write endpoint the recording rules write to.
+type UnifiedAlertingRecordingRulesSettings struct {
+	Enabled           bool
+	URL               string
+	BasicAuthUsername string
+	BasicAuthPassword string
+	Timeout           time.Duration
+}
+
+type UnifiedAlertingStateHistorySettings struct {
+	Enabled bool
+	// Backend is either "sql" or "loki".
+	Backend string
+	// Retention of the transitions kept in the database. Loki applies its own retention.
+	Retention         time.Duration
+	LokiURL           string
+	LokiTenantID      string
+	LokiBasicAuthUser string
+	LokiBasicAuthPass string
 }
 
 // IsEnabled returns true if UnifiedAlertingSettings.Enabled is either nil or true.
@@ -169,6 +230,18 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	if err != nil {
 		return err
 	}
+	uaCfg.HAEvaluationSharding = ua.Key("ha_evaluation_sharding").MustBool(false)
+	uaCfg.HAReplicaHeartbeat, err = gtime.ParseDuration(valueAsString(ua, "ha_replica_heartbeat_interval", schedulerDefaultReplicaHeartbeat.String()))
+	if err != nil {
+		return err
+	}
+	uaCfg.HAReplicaTimeout, err = gtime.ParseDuration(valueAsString(ua, "ha_replica_timeout", schedulerDefaultReplicaTimeout.String()))
+	if err != nil {
+		return err
+	}
+	if uaCfg.HAReplicaHeartbeat <= 0 || uaCfg.HAReplicaTimeout <= uaCfg.HAReplicaHeartbeat {
+		return fmt.Errorf("value of setting 'ha_replica_timeout' should be greater than 'ha_replica_heartbeat_interval' (%v)", uaCfg.HAReplicaHeartbeat)
+	}
 	uaCfg.HAListenAddr = ua.Key("ha_listen_address").MustString(alertmanagerDefaultClusterAddr)
 	uaCfg.HAAdvertiseAddr = ua.Key("ha_advertise_address").MustString("")
 	peers := ua.Key("ha_peers").MustString("")
@@ -244,10 +317,136 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		uaCfg.DefaultRuleEvaluationInterval = uaMinInterval
 	}
 
+	uaCfg.StateHistory, err = readUnifiedAlertingStateHistorySettings(iniFile.Section("unified_alerting.state_history"))
+	if err != nil {
+		return err
+	}
+
+	uaCfg.RecordingRules, err = readUnifiedAlertingRecordingRulesSettings(iniFile.Section("unified_alerting.recording_rules"))
+	if err != nil {
+		return err
+	}
+
+	uaCfg.DeliveryLog, err = readUnifiedAlertingDeliveryLogSettings(iniFile.Section("unified_alerting.delivery_log"))
+	if err != nil {
+		return err
+	}
+
+	uaCfg.Screenshots, err = readUnifiedAlertingScreenshotSettings(iniFile.Section("unified_alerting.screenshots"))
+	if err != nil {
+		return err
+	}
+
 	cfg.UnifiedAlerting = uaCfg
 	return nil
 }
 
+// childKey returns the key of a [unified_alerting.*] section without falling back to the key of the same name in
+// [unified_alerting], which go-ini returns for a child section and which reading a default would overwrite.
+func childKey(section *ini.Section, name string) *ini.Key {
+	if _, ok := section.KeysHash()[name]; ok {
+		return section.Key(name)
+	}
+	key, _ := section.NewKey(name, "")
+	return key
+}
+
+func childValueAsString(section *ini.Section, name string, defaultValue string) string {
+	return childKey(section, name).MustString(defaultValue)
+}
+
+func readUnifiedAlertingStateHistorySettings(section *ini.Section) (UnifiedAlertingStateHistorySettings, error) {
+	settings := UnifiedAlertingStateHistorySettings{
+		Enabled:           childKey(section, "enabled").MustBool(true),
+		Backend:           strings.ToLower(childValueAsString(section, "backend", StateHistoryBackendSQL)),
+		LokiURL:           childValueAsString(section, "loki_remote_url", ""),
+		LokiTenantID:      childValueAsString(section, "loki_tenant_id", ""),
+		LokiBasicAuthUser: childValueAsString(section, "loki_basic_auth_username", ""),
+		LokiBasicAuthPass: childValueAsString(section, "loki_basic_auth_password", ""),
+	}
+
+	retention, err := gtime.ParseDuration(childValueAsString(section, "retention", stateHistoryDefaultRetention))
+	if err != nil {
+		return settings, fmt.Errorf("invalid value of setting 'retention' in section [unified_alerting.state_history]: %w", err)
+	}
+	if retention < 0 {
+		return settings, errors.New("value of setting 'retention' in section [unified_alerting.state_history] should not be negative")
+	}
+	settings.Retention = retention
+
+	switch settings.Backend {
+	case StateHistoryBackendSQL:
+	case StateHistoryBackendLoki:
+		if settings.Enabled && settings.LokiURL == "" {
+			return settings, errors.New("setting 'loki_remote_url' in section [unified_alerting.state_history] is required by the loki backend")
+		}
+	default:
+		return settings, fmt.Errorf("unknown state history backend %q, expected %q or %q", settings.Backend, StateHistoryBackendSQL, StateHistoryBackendLoki)
+	}
+	return settings, nil
+}
+
+func readUnifiedAlertingDeliveryLogSettings(section *ini.Section) (UnifiedAlertingDeliveryLogSettings, error) {
+	settings := UnifiedAlertingDeliveryLogSettings{
+		Enabled: childKey(section, "enabled").MustBool(true),
+	}
+
+	retention, err := gtime.ParseDuration(childValueAsString(section, "retention", deliveryLogDefaultRetention))
+	if err != nil {
+		return settings, fmt.Errorf("invalid value of setting 'retention' in section [unified_alerting.delivery_log]: %w", err)
+	}
+	if retention < 0 {
+		return settings, errors.New("value of setting 'retention' in section [unified_alerting.delivery_log] should not be negative")
+	}
+	settings.Retention = retention
+	return settings, nil
+}
+
+func readUnifiedAlertingRecordingRulesSettings(section *ini.Section) (UnifiedAlertingRecordingRulesSettings, error) {
+	settings := UnifiedAlertingRecordingRulesSettings{
+		Enabled:           childKey(section, "enabled").MustBool(false),
+		URL:               childValueAsString(section, "url", ""),
+		BasicAuthUsername: childValueAsString(section, "basic_auth_username", ""),
+		BasicAuthPassword: childValueAsString(section, "basic_auth_password", ""),
+	}
+
+	timeout, err := gtime.ParseDuration(childValueAsString(section, "timeout", recordingRulesDefaultTimeout.String()))
+	if err != nil {
+		return settings, fmt.Errorf("invalid value of setting 'timeout' in section [unified_alerting.recording_rules]: %w", err)
+	}
+	if timeout <= 0 {
+		return settings, errors.New("value of setting 'timeout' in section [unified_alerting.recording_rules] should be positive")
+	}
+	settings.Timeout = timeout
+
+	if settings.Enabled && settings.URL == "" {
+		return settings, errors.New("setting 'url' in section [unified_alerting.recording_rules] is required when recording rules are enabled")
+	}
+	return settings, nil
+}
+
 func GetAlertmanagerDefaultConfiguration() string {
 	return alertmanagerDefaultConfiguration
 }
+
+func readUnifiedAlertingScreenshotSettings(section *ini.Section) (UnifiedAlertingScreenshotSettings, error) {
+	settings := UnifiedAlertingScreenshotSettings{
+		Capture:                    childKey(section, "capture").MustBool(false),
+		MaxConcurrentScreenshots:   childKey(section, "max_concurrent_screenshots").MustInt(screenshotsDefaultMaxConcurrent),
+		UploadExternalImageStorage: childKey(section, "upload_external_image_storage").MustBool(false),
+	}
+
+	timeout, err := gtime.ParseDuration(childValueAsString(section, "capture_timeout", screenshotsDefaultCaptureTimeout.String()))
+	if err != nil {
+		return settings, fmt.Errorf("invalid value of setting 'capture_timeout' in section [unified_alerting.screenshots]: %w", err)
+	}
+	if timeout <= 0 || timeout > screenshotsMaxCaptureTimeout {
+		return settings, fmt.Errorf("value of setting 'capture_timeout' in section [unified_alerting.screenshots] should be between 0 and %s", screenshotsMaxCaptureTimeout)
+	}
+	settings.CaptureTimeout = timeout
+
+	if settings.MaxConcurrentScreenshots <= 0 {
+		return settings, errors.New("value of setting 'max_concurrent_screenshots' in section [unified_alerting.screenshots] should be greater than 0")
+	}
+	return settings, nil
+}
@@ -266,3 +266,323 @@ func TestMinInterval(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluationShardingSettings(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		settings map[string]string
+		verify   func(t *testing.T, cfg *Cfg, err error)
+	}{
+		{
+			desc: "defaults",
+			verify: func(t *testing.T, cfg *Cfg, err error) {
+				require.NoError(t, err)
+				require.False(t, cfg.UnifiedAlerting.HAEvaluationSharding)
+				require.Equal(t, 15*time.Second, cfg.UnifiedAlerting.HAReplicaHeartbeat)
+				require.Equal(t, time.Minute, cfg.UnifiedAlerting.HAReplicaTimeout)
+			},
+		},
+		{
+			desc:     "enabled",
+			settings: map[string]string{"ha_evaluation_sharding": "true", "ha_replica_heartbeat_interval": "5s", "ha_replica_timeout": "20s"},
+			verify: func(t *testing.T, cfg *Cfg, err error) {
+				require.NoError(t, err)
+				require.True(t, cfg.UnifiedAlerting.HAEvaluationSharding)
+				require.Equal(t, 5*time.Second, cfg.UnifiedAlerting.HAReplicaHeartbeat)
+				require.Equal(t, 20*time.Second, cfg.UnifiedAlerting.HAReplicaTimeout)
+			},
+		},
+		{
+			desc:     "should fail if the timeout is not greater than the heartbeat interval",
+			settings: map[string]string{"ha_replica_heartbeat_interval": "1m", "ha_replica_timeout": "30s"},
+			verify: func(t *testing.T, cfg *Cfg, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "ha_replica_timeout")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := ini.Empty()
+			section, err := f.NewSection("unified_alerting")
+			require.NoError(t, err)
+			for k, v := range tc.settings {
+				_, err = section.NewKey(k, v)
+				require.NoError(t, err)
+			}
+			cfg := NewCfg()
+			cfg.IsFeatureToggleEnabled = func(key string) bool { return false }
+			err = cfg.ReadUnifiedAlertingSettings(f)
+			tc.verify(t, cfg, err)
+		})
+	}
+}
+
+func TestStateHistorySettings(t *testing.T) {
+	cfg := NewCfg()
+	err := cfg.Load(CommandLineArgs{HomePath: "../../", Config: "../../conf/defaults.ini"})
+	require.NoError(t, err)
+
+	// It sets the correct defaults.
+	require.True(t, cfg.UnifiedAlerting.StateHistory.Enabled)
+	require.Equal(t, StateHistoryBackendSQL, cfg.UnifiedAlerting.StateHistory.Backend)
+	require.Equal(t, 30*24*time.Hour, cfg.UnifiedAlerting.StateHistory.Retention)
+
+	testCases := []struct {
+		desc     string
+		settings map[string]string
+		verify   func(t *testing.T, s UnifiedAlertingStateHistorySettings, err error)
+	}{
+		{
+			desc:     "loki backend",
+			settings: map[string]string{"backend": "Loki", "loki_remote_url": "http://localhost:3100", "loki_tenant_id": "1"},
+			verify: func(t *testing.T, s UnifiedAlertingStateHistorySettings, err error) {
+				require.NoError(t, err)
+				require.Equal(t, StateHistoryBackendLoki, s.Backend)
+				require.Equal(t, "http://localhost:3100", s.LokiURL)
+				require.Equal(t, "1", s.LokiTenantID)
+			},
+		},
+		{
+			desc:     "loki backend without url",
+			settings: map[string]string{"backend": "loki"},
+			verify: func(t *testing.T, _ UnifiedAlertingStateHistorySettings, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			desc:     "unknown backend",
+			settings: map[string]string{"backend": "file"},
+			verify: func(t *testing.T, _ UnifiedAlertingStateHistorySettings, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			desc:     "invalid retention",
+			settings: map[string]string{"retention": "forever"},
+			verify: func(t *testing.T, _ UnifiedAlertingStateHistorySettings, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			desc:     "retention",
+			settings: map[string]string{"retention": "7d", "enabled": "false"},
+			verify: func(t *testing.T, s UnifiedAlertingStateHistorySettings, err error) {
+				require.NoError(t, err)
+				require.False(t, s.Enabled)
+				require.Equal(t, 7*24*time.Hour, s.Retention)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := ini.Empty()
+			section, err := f.NewSection("unified_alerting.state_history")
+			require.NoError(t, err)
+			for k, v := range tc.settings {
+				_, err := section.NewKey(k, v)
+				require.NoError(t, err)
+			}
+			s, err := readUnifiedAlertingStateHistorySettings(section)
+			tc.verify(t, s, err)
+		})
+	}
+}
+
+func TestRecordingRulesSettings(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		settings map[string]string
+		verify   func(t *testing.T, s UnifiedAlertingRecordingRulesSettings, err error)
+	}{
+		{
+			desc: "defaults",
+			verify: func(t *testing.T, s UnifiedAlertingRecordingRulesSettings, err error) {
+				require.NoError(t, err)
+				require.False(t, s.Enabled)
+				require.Equal(t, 10*time.Second, s.Timeout)
+			},
+		},
+		{
+			desc:     "enabled",
+			settings: map[string]string{"enabled": "true", "url": "http://localhost:9090/api/v1/write", "basic_auth_username": "user", "timeout": "30s"},
+			verify: func(t *testing.T, s UnifiedAlertingRecordingRulesSettings, err error) {
+				require.NoError(t, err)
+				require.True(t, s.Enabled)
+				require.Equal(t, "http://localhost:9090/api/v1/write", s.URL)
+				require.Equal(t, "user", s.BasicAuthUsername)
+				require.Equal(t, 30*time.Second, s.Timeout)
+			},
+		},
+		{
+			desc:     "enabled without url",
+			settings: map[string]string{"enabled": "true"},
+			verify: func(t *testing.T, _ UnifiedAlertingRecordingRulesSettings, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			desc:     "invalid timeout",
+			settings: map[string]string{"timeout": "0s"},
+			verify: func(t *testing.T, _ UnifiedAlertingRecordingRulesSettings, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := ini.Empty()
+			section, err := f.NewSection("unified_alerting.recording_rules")
+			require.NoError(t, err)
+			for k, v := range tc.settings {
+				_, err := section.NewKey(k, v)
+				require.NoError(t, err)
+			}
+			s, err := readUnifiedAlertingRecordingRulesSettings(section)
+			tc.verify(t, s, err)
+		})
+	}
+}
+
+func TestDeliveryLogSettings(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		settings map[string]string
+		verify   func(t *testing.T, s UnifiedAlertingDeliveryLogSettings, err error)
+	}{
+		{
+			desc: "defaults",
+			verify: func(t *testing.T, s UnifiedAlertingDeliveryLogSettings, err error) {
+				require.NoError(t, err)
+				require.True(t, s.Enabled)
+				require.Equal(t, 7*24*time.Hour, s.Retention)
+			},
+		},
+		{
+			desc:     "retention",
+			settings: map[string]string{"enabled": "false", "retention": "24h"},
+			verify: func(t *testing.T, s UnifiedAlertingDeliveryLogSettings, err error) {
+				require.NoError(t, err)
+				require.False(t, s.Enabled)
+				require.Equal(t, 24*time.Hour, s.Retention)
+			},
+		},
+		{
+			desc:     "invalid retention",
+			settings: map[string]string{"retention": "-1h"},
+			verify: func(t *testing.T, _ UnifiedAlertingDeliveryLogSettings, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := ini.Empty()
+			section, err := f.NewSection("unified_alerting.delivery_log")
+			require.NoError(t, err)
+			for k, v := range tc.settings {
+				_, err := section.NewKey(k, v)
+				require.NoError(t, err)
+			}
+			s, err := readUnifiedAlertingDeliveryLogSettings(section)
+			tc.verify(t, s, err)
+		})
+	}
+}
+
+func TestScreenshotSettings(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		settings map[string]string
+		verify   func(t *testing.T, s UnifiedAlertingScreenshotSettings, err error)
+	}{
+		{
+			desc: "defaults",
+			verify: func(t *testing.T, s UnifiedAlertingScreenshotSettings, err error) {
+				require.NoError(t, err)
+				require.False(t, s.Capture)
+				require.Equal(t, 10*time.Second, s.CaptureTimeout)
+				require.Equal(t, 5, s.MaxConcurrentScreenshots)
+				require.False(t, s.UploadExternalImageStorage)
+			},
+		},
+		{
+			desc: "capture",
+			settings: map[string]string{
+				"capture":                       "true",
+				"capture_timeout":               "5s",
+				"max_concurrent_screenshots":    "2",
+				"upload_external_image_storage": "true",
+			},
+			verify: func(t *testing.T, s UnifiedAlertingScreenshotSettings, err error) {
+				require.NoError(t, err)
+				require.True(t, s.Capture)
+				require.Equal(t, 5*time.Second, s.CaptureTimeout)
+				require.Equal(t, 2, s.MaxConcurrentScreenshots)
+				require.True(t, s.UploadExternalImageStorage)
+			},
+		},
+		{
+			desc:     "capture timeout too long",
+			settings: map[string]string{"capture_timeout": "1m"},
+			verify: func(t *testing.T, _ UnifiedAlertingScreenshotSettings, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			desc:     "invalid concurrency",
+			settings: map[string]string{"max_concurrent_screenshots": "0"},
+			verify: func(t *testing.T, _ UnifiedAlertingScreenshotSettings, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := ini.Empty()
+			section, err := f.NewSection("unified_alerting.screenshots")
+			require.NoError(t, err)
+			for k, v := range tc.settings {
+				_, err := section.NewKey(k, v)
+				require.NoError(t, err)
+			}
+			s, err := readUnifiedAlertingScreenshotSettings(section)
+			tc.verify(t, s, err)
+		})
+	}
+}
+
+func TestUnifiedAlertingChildSections(t *testing.T) {
+	f := ini.Empty()
+	parent, err := f.NewSection("unified_alerting")
+	require.NoError(t, err)
+	_, err = parent.NewKey("enabled", "")
+	require.NoError(t, err)
+	recording, err := f.NewSection("unified_alerting.recording_rules")
+	require.NoError(t, err)
+	_, err = f.NewSection("unified_alerting.state_history")
+	require.NoError(t, err)
+
+	// the keys of [unified_alerting] are neither read nor overwritten by the sections of its features.
+	stateHistory, err := readUnifiedAlertingStateHistorySettings(f.Section("unified_alerting.state_history"))
+	require.NoError(t, err)
+	require.True(t, stateHistory.Enabled)
+	recordingRules, err := readUnifiedAlertingRecordingRulesSettings(recording)
+	require.NoError(t, err)
+	require.False(t, recordingRules.Enabled)
+	require.Equal(t, "", parent.Key("enabled").String())
+
+	_, err = parent.NewKey("url", "http://localhost:9090/api/v1/write")
+	require.NoError(t, err)
+	_, err = parent.NewKey("enabled", "true")
+	require.NoError(t, err)
+	recordingRules, err = readUnifiedAlertingRecordingRulesSettings(recording)
+	require.NoError(t, err)
+	require.False(t, recordingRules.Enabled)
+	require.Equal(t, "", recordingRules.URL)
+}
@@ -35,6 +35,7 @@ type SearchRequest struct {
 	Interval    intervalv2.Interval
 	Size        int
 	Sort        map[string]interface{}
+	SortFields  []SortField
 	Query       *Query
 	Aggs        AggArray
 	CustomProps map[string]interface{}
@@ -45,7 +46,9 @@ func (r *SearchRequest) MarshalJSON() ([]byte, error) {
 	root := make(map[string]interface{})
 
 	root["size"] = r.Size
-	if len(r.Sort) > 0 {
+	if len(r.SortFields) > 0 {
+		root["sort"] = r.SortFields
+	} else if len(r.Sort) > 0 {
 		root["sort"] = r.Sort
 	}
 
@@ -62,6 +65,26 @@ func (r *SearchRequest) MarshalJSON() ([]byte, error) {
 	return json.Marshal(root)
 }
 
+// SortField represents a field of an ordered search request sort
+type SortField struct {
+	Field        string
+	Order        string
+	UnmappedType string
+}
+
+// MarshalJSON returns the JSON encoding of the sort field.
+func (f SortField) MarshalJSON() ([]byte, error) {
+	props := map[string]string{
+		"order": f.Order,
+	}
+
+	if f.UnmappedType != "" {
+		props["unmapped_type"] = f.UnmappedType
+	}
+
+	return json.Marshal(map[string]interface{}{f.Field: props})
+}
+
 // SearchResponseHits represents search response hits
 type SearchResponseHits struct {
 	Hits []map[string]interface{}
@@ -144,6 +167,12 @@ type RangeFilter struct {
 // DateFormatEpochMS represents a date format of epoch milliseconds (epoch_millis)
 const DateFormatEpochMS = "epoch_millis"
 
+// Highlight tags wrap the matches of the highlight of a search request, they are the same as the tags of the frontend
+const (
+	HighlightPreTag  = "@HIGHLIGHT@"
+	HighlightPostTag = "@/HIGHLIGHT@"
+)
+
 // MarshalJSON returns the JSON encoding of the query string filter.
 func (f *RangeFilter) MarshalJSON() ([]byte, error) {
 	root := map[string]map[string]map[string]interface{}{
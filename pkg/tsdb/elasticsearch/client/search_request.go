@@ -14,6 +14,7 @@ type SearchRequestBuilder struct {
 	index        string
 	size         int
 	sort         map[string]interface{}
+	sortFields   []SortField
 	queryBuilder *QueryBuilder
 	aggBuilders  []AggBuilder
 	customProps  map[string]interface{}
@@ -38,6 +39,7 @@ func (b *SearchRequestBuilder) Build() (*SearchRequest, error) {
 		Interval:    b.interval,
 		Size:        b.size,
 		Sort:        b.sort,
+		SortFields:  b.sortFields,
 		CustomProps: b.customProps,
 	}
 
@@ -85,6 +87,40 @@ func (b *SearchRequestBuilder) SortDesc(field, unmappedType string) *SearchReque
 	return b
 }
 
+// SortBy adds a field to the sort of the search request, the fields are sorted by in the order they are added
+func (b *SearchRequestBuilder) SortBy(field, order, unmappedType string) *SearchRequestBuilder {
+	b.sortFields = append(b.sortFields, SortField{
+		Field:        field,
+		Order:        order,
+		UnmappedType: unmappedType,
+	})
+
+	return b
+}
+
+// SearchAfter sets the sort values of the last hit of the previous page of the search request
+func (b *SearchRequestBuilder) SearchAfter(values []interface{}) *SearchRequestBuilder {
+	if len(values) > 0 {
+		b.customProps["search_after"] = values
+	}
+
+	return b
+}
+
+// AddHighlight adds the highlight of the matches of all the fields to the search request
+func (b *SearchRequestBuilder) AddHighlight() *SearchRequestBuilder {
+	b.customProps["highlight"] = map[string]interface{}{
+		"fields": map[string]interface{}{
+			"*": map[string]interface{}{},
+		},
+		"pre_tags":      []string{HighlightPreTag},
+		"post_tags":     []string{HighlightPostTag},
+		"fragment_size": 2147483647,
+	}
+
+	return b
+}
+
 // AddDocValueField adds a doc value field to the search request
 func (b *SearchRequestBuilder) AddDocValueField(field string) *SearchRequestBuilder {
 	// fields field not supported on version >= 5
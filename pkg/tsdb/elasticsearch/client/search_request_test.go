@@ -106,6 +106,39 @@ func TestSearchRequest(t *testing.T) {
 		})
 	})
 
+	t.Run("When adding sort fields, search after and highlight", func(t *testing.T) {
+		b := setup()
+		b.Size(100)
+		b.SortBy(timeField, "asc", "boolean")
+		b.SortBy("_doc", "asc", "")
+		b.SearchAfter([]interface{}{1526406600000, 8})
+		b.AddHighlight()
+
+		sr, err := b.Build()
+		require.Nil(t, err)
+
+		t.Run("When marshal to JSON should generate correct json", func(t *testing.T) {
+			body, err := json.Marshal(sr)
+			require.Nil(t, err)
+			json, err := simplejson.NewJson(body)
+			require.Nil(t, err)
+
+			sort := json.Get("sort").MustArray()
+			require.Len(t, sort, 2)
+			require.Equal(t, "asc", json.Get("sort").GetIndex(0).GetPath(timeField, "order").MustString())
+			require.Equal(t, "boolean", json.Get("sort").GetIndex(0).GetPath(timeField, "unmapped_type").MustString())
+			require.Equal(t, "asc", json.Get("sort").GetIndex(1).GetPath("_doc", "order").MustString())
+			require.Nil(t, json.Get("sort").GetIndex(1).GetPath("_doc", "unmapped_type").Interface())
+
+			require.Equal(t, int64(1526406600000), json.Get("search_after").GetIndex(0).MustInt64())
+			require.Equal(t, int64(8), json.Get("search_after").GetIndex(1).MustInt64())
+
+			require.Equal(t, HighlightPreTag, json.GetPath("highlight", "pre_tags").GetIndex(0).MustString())
+			require.Equal(t, HighlightPostTag, json.GetPath("highlight", "post_tags").GetIndex(0).MustString())
+			require.NotNil(t, json.GetPath("highlight", "fields", "*").Interface())
+		})
+	})
+
 	t.Run("When adding doc value field", func(t *testing.T) {
 		b := setup()
 		b.AddDocValueField(timeField)
@@ -1,6 +1,7 @@
 package elasticsearch
 
 import (
+	"encoding/json"
 	"errors"
 	"regexp"
 	"sort"
@@ -20,6 +21,9 @@ const (
 	percentilesType   = "percentiles"
 	extendedStatsType = "extended_stats"
 	topMetricsType    = "top_metrics"
+	rawDocumentType   = "raw_document"
+	rawDataType       = "raw_data"
+	logsType          = "logs"
 	// Bucket types
 	dateHistType    = "date_histogram"
 	histogramType   = "histogram"
@@ -29,16 +33,19 @@ const (
 )
 
 type responseParser struct {
-	Responses []*es.SearchResponse
-	Targets   []*Query
-	DebugInfo *es.SearchDebugInfo
+	Responses        []*es.SearchResponse
+	Targets          []*Query
+	DebugInfo        *es.SearchDebugInfo
+	ConfiguredFields es.ConfiguredFields
 }
 
-var newResponseParser = func(responses []*es.SearchResponse, targets []*Query, debugInfo *es.SearchDebugInfo) *responseParser {
+var newResponseParser = func(responses []*es.SearchResponse, targets []*Query, debugInfo *es.SearchDebugInfo,
+	configuredFields es.ConfiguredFields) *responseParser {
 	return &responseParser{
-		Responses: responses,
-		Targets:   targets,
-		DebugInfo: debugInfo,
+		Responses:        responses,
+		Targets:          targets,
+		DebugInfo:        debugInfo,
+		ConfiguredFields: configuredFields,
 	}
 }
 
@@ -72,6 +79,18 @@ func (rp *responseParser) getTimeSeries() (*backend.QueryDataResponse, error) {
 			continue
 		}
 
+		if isDocumentQuery(target) {
+			frame, err := rp.processDocuments(res, target)
+			if err != nil {
+				return &backend.QueryDataResponse{}, err
+			}
+			frame.Meta.Custom = debugInfo
+			result.Responses[target.RefID] = backend.DataResponse{
+				Frames: data.Frames{frame},
+			}
+			continue
+		}
+
 		queryRes := backend.DataResponse{}
 
 		props := make(map[string]string)
@@ -503,6 +522,236 @@ func (rp *responseParser) processAggregationDocs(esAgg *simplejson.Json, aggDef
 	return nil
 }
 
+// processDocuments returns a frame of the hits of a document query. The frame has the time field, the message and level
+// fields of logs queries, then a field per flattened field of the documents. The sort values of the documents are
+// kept so that the next page can be requested.
+func (rp *responseParser) processDocuments(res *es.SearchResponse, target *Query) (*data.Frame, error) {
+	isLogs := target.Metrics[0].Type == logsType
+	timeField := rp.ConfiguredFields.TimeField
+	if timeField == "" {
+		timeField = target.TimeField
+	}
+
+	var hits []map[string]interface{}
+	if res.Hits != nil {
+		hits = res.Hits.Hits
+	}
+
+	docs := make([]map[string]interface{}, 0, len(hits))
+	times := make([]*time.Time, 0, len(hits))
+	propNames := make(map[string]bool)
+	for _, hit := range hits {
+		source, _ := hit["_source"].(map[string]interface{})
+		flattened := flattenDocument(source)
+
+		doc := map[string]interface{}{
+			"_id":    hit["_id"],
+			"_type":  hit["_type"],
+			"_index": hit["_index"],
+		}
+		if sortValues, ok := hit["sort"]; ok {
+			doc["sort"] = sortValues
+		}
+		if highlight, ok := hit["highlight"]; ok && isLogs {
+			doc["highlight"] = highlight
+		}
+		if isLogs {
+			doc["_source"] = flattened
+		}
+		for k, v := range flattened {
+			doc[k] = v
+		}
+
+		for k := range doc {
+			propNames[k] = true
+		}
+		docs = append(docs, doc)
+		times = append(times, documentTime(hit, flattened, timeField))
+	}
+
+	fields := []*data.Field{data.NewField(timeField, nil, times)}
+	skip := map[string]bool{timeField: true}
+	if isLogs && rp.ConfiguredFields.LogMessageField != "" {
+		name := rp.ConfiguredFields.LogMessageField
+		fields = append(fields, newDocumentStringField(name, docs, name))
+		skip[name] = true
+	}
+	if isLogs && rp.ConfiguredFields.LogLevelField != "" {
+		// the level field is named level whatever its name in the documents, so that it is found by the logs view
+		fields = append(fields, newDocumentStringField("level", docs, rp.ConfiguredFields.LogLevelField))
+		skip["level"] = true
+	}
+
+	names := make([]string, 0, len(propNames))
+	for name := range propNames {
+		if !skip[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field, err := newDocumentField(name, docs)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+
+	frame := data.NewFrame("", fields...)
+	frame.RefID = target.RefID
+	frame.Meta = &data.FrameMeta{}
+	if isLogs {
+		frame.Meta.PreferredVisualization = data.VisTypeLogs
+	}
+	return frame, nil
+}
+
+// flattenDocument flattens the nested objects of the source of a document, the name of their fields is joined to
+// the name of the object by a dot, e.g. "kubernetes.pod.name".
+func flattenDocument(source map[string]interface{}) map[string]interface{} {
+	flattened := make(map[string]interface{})
+	flattenObject(flattened, "", source)
+	return flattened
+}
+
+func flattenObject(flattened map[string]interface{}, prefix string, object map[string]interface{}) {
+	for k, v := range object {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenObject(flattened, name, nested)
+			continue
+		}
+		flattened[name] = v
+	}
+}
+
+// documentTime returns the time of a document from its source, or from its doc value fields when the time field
+// isn't in the source.
+func documentTime(hit map[string]interface{}, source map[string]interface{}, timeField string) *time.Time {
+	value, ok := source[timeField]
+	if !ok {
+		if fields, ok := hit["fields"].(map[string]interface{}); ok {
+			if values, ok := fields[timeField].([]interface{}); ok && len(values) > 0 {
+				value = values[0]
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			t = t.UTC()
+			return &t
+		}
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			t := time.Unix(0, ms*int64(time.Millisecond)).UTC()
+			return &t
+		}
+	case float64:
+		t := time.Unix(0, int64(v)*int64(time.Millisecond)).UTC()
+		return &t
+	}
+	return nil
+}
+
+// newDocumentStringField returns a field with the values of a field of the documents as strings, the values that
+// aren't strings are encoded in JSON.
+func newDocumentStringField(name string, docs []map[string]interface{}, docField string) *data.Field {
+	values := make([]*string, 0, len(docs))
+	for _, doc := range docs {
+		var value *string
+		switch v := doc[docField].(type) {
+		case nil:
+		case string:
+			value = &v
+		default:
+			if b, err := json.Marshal(v); err == nil {
+				s := string(b)
+				value = &s
+			}
+		}
+		values = append(values, value)
+	}
+	return data.NewField(name, nil, values)
+}
+
+// newDocumentField returns a field with the values of a field of the documents. The type of the field is the type of
+// the values when they all have the same type, and JSON otherwise.
+func newDocumentField(name string, docs []map[string]interface{}) (*data.Field, error) {
+	var fieldType string
+	for _, doc := range docs {
+		var valueType string
+		switch doc[name].(type) {
+		case nil:
+			continue
+		case string:
+			valueType = "string"
+		case float64:
+			valueType = "number"
+		case bool:
+			valueType = "boolean"
+		default:
+			valueType = "json"
+		}
+		if fieldType == "" {
+			fieldType = valueType
+		} else if fieldType != valueType {
+			fieldType = "json"
+		}
+	}
+
+	switch fieldType {
+	case "string":
+		values := make([]*string, 0, len(docs))
+		for _, doc := range docs {
+			var value *string
+			if v, ok := doc[name].(string); ok {
+				value = &v
+			}
+			values = append(values, value)
+		}
+		return data.NewField(name, nil, values), nil
+	case "number":
+		values := make([]*float64, 0, len(docs))
+		for _, doc := range docs {
+			var value *float64
+			if v, ok := doc[name].(float64); ok {
+				value = &v
+			}
+			values = append(values, value)
+		}
+		return data.NewField(name, nil, values), nil
+	case "boolean":
+		values := make([]*bool, 0, len(docs))
+		for _, doc := range docs {
+			var value *bool
+			if v, ok := doc[name].(bool); ok {
+				value = &v
+			}
+			values = append(values, value)
+		}
+		return data.NewField(name, nil, values), nil
+	default:
+		values := make([]*json.RawMessage, 0, len(docs))
+		for _, doc := range docs {
+			var value *json.RawMessage
+			if v, ok := doc[name]; ok && v != nil {
+				b, err := json.Marshal(v)
+				if err != nil {
+					return nil, err
+				}
+				raw := json.RawMessage(b)
+				value = &raw
+			}
+			values = append(values, value)
+		}
+		return data.NewField(name, nil, values), nil
+	}
+}
+
 func extractDataField(name string, v interface{}) *data.Field {
 	switch v.(type) {
 	case *string:
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -1061,6 +1062,129 @@ func TestResponseParser(t *testing.T) {
 	})
 }
 
+func TestDocumentsResponseParser(t *testing.T) {
+	response := `{
+		"responses": [
+			{
+				"hits": {
+					"hits": [
+						{
+							"_id": "1",
+							"_index": "logs-2018.05.15",
+							"_source": {
+								"@timestamp": "2018-05-15T17:51:00.123Z",
+								"line": "connection refused",
+								"lvl": "error",
+								"kubernetes": { "pod": { "name": "api-1" } },
+								"bytes": 123,
+								"tags": ["a", "b"]
+							},
+							"highlight": { "line": ["@HIGHLIGHT@refused@/HIGHLIGHT@"] },
+							"sort": [1526406660123, 3]
+						},
+						{
+							"_id": "2",
+							"_index": "logs-2018.05.15",
+							"_source": {
+								"line": "connected",
+								"kubernetes": { "pod": { "name": "api-2" } },
+								"bytes": "unknown"
+							},
+							"fields": { "@timestamp": ["2018-05-15T17:50:00Z"] },
+							"sort": [1526406600000, 8]
+						}
+					]
+				}
+			}
+		]
+	}`
+
+	t.Run("Raw data query", func(t *testing.T) {
+		targets := map[string]string{
+			"A": `{
+				"timeField": "@timestamp",
+				"metrics": [{ "type": "raw_data", "id": "1" }],
+				"bucketAggs": []
+			}`,
+		}
+		rp, err := newResponseParserForTest(targets, response)
+		require.NoError(t, err)
+		result, err := rp.getTimeSeries()
+		require.NoError(t, err)
+
+		frames := result.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		require.Empty(t, frame.Meta.PreferredVisualization)
+
+		names := make([]string, 0, len(frame.Fields))
+		for _, f := range frame.Fields {
+			names = append(names, f.Name)
+		}
+		require.Equal(t, []string{"@timestamp", "_id", "_index", "_type", "bytes", "kubernetes.pod.name", "line", "lvl", "sort", "tags"}, names)
+
+		require.Equal(t, time.Date(2018, 5, 15, 17, 51, 0, 123000000, time.UTC), *frame.Fields[0].At(0).(*time.Time))
+		require.Equal(t, time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC), *frame.Fields[0].At(1).(*time.Time))
+		require.Equal(t, "api-2", *frame.Fields[5].At(1).(*string))
+		require.Nil(t, frame.Fields[7].At(1))
+
+		// the bytes are numbers and strings
+		require.Equal(t, json.RawMessage(`123`), *frame.Fields[4].At(0).(*json.RawMessage))
+		require.Equal(t, json.RawMessage(`"unknown"`), *frame.Fields[4].At(1).(*json.RawMessage))
+		require.Equal(t, json.RawMessage(`[1526406600000,8]`), *frame.Fields[8].At(1).(*json.RawMessage))
+	})
+
+	t.Run("Logs query", func(t *testing.T) {
+		targets := map[string]string{
+			"A": `{
+				"timeField": "@timestamp",
+				"metrics": [{ "type": "logs", "id": "1" }],
+				"bucketAggs": []
+			}`,
+		}
+		rp, err := newResponseParserForTest(targets, response)
+		require.NoError(t, err)
+		result, err := rp.getTimeSeries()
+		require.NoError(t, err)
+
+		frames := result.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		require.Equal(t, data.VisTypeLogs, string(frame.Meta.PreferredVisualization))
+
+		names := make([]string, 0, len(frame.Fields))
+		for _, f := range frame.Fields {
+			names = append(names, f.Name)
+		}
+		require.Equal(t, []string{"@timestamp", "line", "level", "_id", "_index", "_source", "_type", "bytes", "highlight", "kubernetes.pod.name", "lvl", "sort", "tags"}, names)
+
+		require.Equal(t, "connection refused", *frame.Fields[1].At(0).(*string))
+		require.Equal(t, "error", *frame.Fields[2].At(0).(*string))
+		require.Nil(t, frame.Fields[2].At(1))
+		require.JSONEq(t, `{"line": ["@HIGHLIGHT@refused@/HIGHLIGHT@"]}`, string(*frame.Fields[8].At(0).(*json.RawMessage)))
+		require.JSONEq(t, `{"line": "connected", "kubernetes.pod.name": "api-2", "bytes": "unknown"}`, string(*frame.Fields[5].At(1).(*json.RawMessage)))
+	})
+
+	t.Run("Query without hits", func(t *testing.T) {
+		targets := map[string]string{
+			"A": `{
+				"timeField": "@timestamp",
+				"metrics": [{ "type": "logs", "id": "1" }],
+				"bucketAggs": []
+			}`,
+		}
+		rp, err := newResponseParserForTest(targets, `{"responses": [{"hits": {"hits": []}}]}`)
+		require.NoError(t, err)
+		result, err := rp.getTimeSeries()
+		require.NoError(t, err)
+
+		frames := result.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Len(t, frames[0].Fields, 3)
+		require.Equal(t, 0, frames[0].Rows())
+	})
+}
+
 func newResponseParserForTest(tsdbQueries map[string]string, responseBody string) (*responseParser, error) {
 	from := time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC)
 	to := time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC)
@@ -1092,5 +1216,9 @@ func newResponseParserForTest(tsdbQueries map[string]string, responseBody string
 		return nil, err
 	}
 
-	return newResponseParser(response.Responses, queries, nil), nil
+	return newResponseParser(response.Responses, queries, nil, es.ConfiguredFields{
+		TimeField:       "@timestamp",
+		LogMessageField: "line",
+		LogLevelField:   "lvl",
+	}), nil
 }
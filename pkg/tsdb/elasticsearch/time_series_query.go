@@ -13,6 +13,8 @@ import (
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
 )
 
+const defaultDocumentQuerySize = 500
+
 type timeSeriesQuery struct {
 	client             es.Client
 	dataQueries        []backend.DataQuery
@@ -58,7 +60,7 @@ func (e *timeSeriesQuery) execute() (*backend.QueryDataResponse, error) {
 		return &backend.QueryDataResponse{}, err
 	}
 
-	rp := newResponseParser(res.Responses, queries, res.DebugInfo)
+	rp := newResponseParser(res.Responses, queries, res.DebugInfo, e.client.GetConfiguredFields())
 	return rp.getTimeSeries()
 }
 
@@ -79,17 +81,15 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 		filters.AddQueryStringFilter(q.RawQuery, true)
 	}
 
+	if isDocumentQuery(q) {
+		processDocumentQuery(q, b, e.client.GetTimeField())
+		return nil
+	}
+
 	if len(q.BucketAggs) == 0 {
-		if len(q.Metrics) == 0 || q.Metrics[0].Type != "raw_document" {
-			result.Responses[q.RefID] = backend.DataResponse{
-				Error: fmt.Errorf("invalid query, missing metrics and aggregations"),
-			}
-			return nil
+		result.Responses[q.RefID] = backend.DataResponse{
+			Error: fmt.Errorf("invalid query, missing metrics and aggregations"),
 		}
-		metric := q.Metrics[0]
-		b.Size(metric.Settings.Get("size").MustInt(500))
-		b.SortDesc("@timestamp", "boolean")
-		b.AddDocValueField("@timestamp")
 		return nil
 	}
 
@@ -182,6 +182,45 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 	return nil
 }
 
+// processDocumentQuery builds the search of the documents of raw document, raw data and logs queries. The documents
+// are sorted by time, then by index order, so that the next page can be searched after the sort values of the last
+// document of the previous one.
+func processDocumentQuery(q *Query, b *es.SearchRequestBuilder, timeField string) {
+	metric := q.Metrics[0]
+
+	// the size of the logs queries is their limit
+	sizeSetting := "size"
+	if metric.Type == logsType {
+		sizeSetting = "limit"
+	}
+	b.Size(documentQuerySize(metric.Settings, sizeSetting))
+
+	order := metric.Settings.Get("sortDirection").MustString("desc")
+	if order != "asc" {
+		order = "desc"
+	}
+	b.SortBy(timeField, order, "boolean")
+	b.SortBy("_doc", order, "")
+	b.SearchAfter(metric.Settings.Get("searchAfter").MustArray())
+	b.AddDocValueField(timeField)
+
+	if metric.Type == logsType {
+		b.AddHighlight()
+	}
+}
+
+// documentQuerySize returns the size of a document query, which is a string when it is set from the query editor
+func documentQuerySize(settings *simplejson.Json, key string) int {
+	size, err := settings.Get(key).Int()
+	if err != nil {
+		size, err = strconv.Atoi(settings.Get(key).MustString())
+	}
+	if err != nil || size <= 0 {
+		return defaultDocumentQuerySize
+	}
+	return size
+}
+
 func setFloatPath(settings *simplejson.Json, path ...string) {
 	if stringValue, err := settings.GetPath(path...).String(); err == nil {
 		if value, err := strconv.ParseFloat(stringValue, 64); err == nil {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/Masterminds/semver"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
 	"github.com/grafana/grafana/pkg/tsdb/intervalv2"
 	"github.com/stretchr/testify/assert"
@@ -377,6 +378,55 @@ func TestExecuteTimeSeriesQuery(t *testing.T) {
 			require.Equal(t, sr.Size, 1337)
 		})
 
+		t.Run("With raw data metric", func(t *testing.T) {
+			c := newFakeClient("7.10.0")
+			_, err := executeTsdbQuery(c, `{
+				"timeField": "@timestamp",
+				"bucketAggs": [],
+				"metrics": [{ "id": "1", "type": "raw_data", "settings": { "size": "1337" }	}]
+			}`, from, to, 15*time.Second)
+			require.NoError(t, err)
+			sr := c.multisearchRequests[0].Requests[0]
+
+			require.Equal(t, 1337, sr.Size)
+			require.Equal(t, []es.SortField{
+				{Field: "@timestamp", Order: "desc", UnmappedType: "boolean"},
+				{Field: "_doc", Order: "desc"},
+			}, sr.SortFields)
+			require.Equal(t, []string{"@timestamp"}, sr.CustomProps["docvalue_fields"])
+			require.Nil(t, sr.CustomProps["search_after"])
+			require.Nil(t, sr.CustomProps["highlight"])
+			require.Empty(t, sr.Aggs)
+		})
+
+		t.Run("With logs metric", func(t *testing.T) {
+			c := newFakeClient("7.10.0")
+			_, err := executeTsdbQuery(c, `{
+				"timeField": "@timestamp",
+				"bucketAggs": [{ "type": "date_histogram", "field": "@timestamp", "id": "2" }],
+				"metrics": [{ "id": "1", "type": "logs", "settings": { "limit": "100", "sortDirection": "asc", "searchAfter": [1526406600000, 12] } }]
+			}`, from, to, 15*time.Second)
+			require.NoError(t, err)
+			sr := c.multisearchRequests[0].Requests[0]
+
+			require.Equal(t, 100, sr.Size)
+			require.Equal(t, []es.SortField{
+				{Field: "@timestamp", Order: "asc", UnmappedType: "boolean"},
+				{Field: "_doc", Order: "asc"},
+			}, sr.SortFields)
+			require.Len(t, sr.CustomProps["search_after"], 2)
+			require.NotNil(t, sr.CustomProps["highlight"])
+			require.Empty(t, sr.Aggs)
+
+			body, err := json.Marshal(sr)
+			require.NoError(t, err)
+			j, err := simplejson.NewJson(body)
+			require.NoError(t, err)
+			require.Equal(t, "asc", j.Get("sort").GetIndex(0).GetPath("@timestamp", "order").MustString())
+			require.Equal(t, "asc", j.Get("sort").GetIndex(1).GetPath("_doc", "order").MustString())
+			require.Equal(t, []interface{}{json.Number("1526406600000"), json.Number("12")}, j.Get("search_after").MustArray())
+		})
+
 		t.Run("With date histogram agg", func(t *testing.T) {
 			c := newFakeClient("5.0.0")
 			_, err := executeTsdbQuery(c, `{
@@ -1212,6 +1262,14 @@ func (c *fakeClient) GetTimeField() string {
 	return c.timeField
 }
 
+func (c *fakeClient) GetConfiguredFields() es.ConfiguredFields {
+	return es.ConfiguredFields{
+		TimeField:       c.timeField,
+		LogMessageField: "line",
+		LogLevelField:   "lvl",
+	}
+}
+
 func (c *fakeClient) GetMinInterval(queryInterval string) (time.Duration, error) {
 	return 15 * time.Second, nil
 }
@@ -6,11 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
 	"github.com/grafana/grafana/pkg/services/searchV2"
 	"github.com/grafana/grafana/pkg/services/store"
 	"github.com/grafana/grafana/pkg/setting"
@@ -37,14 +40,15 @@ var (
 	_ backend.CheckHealthHandler = (*Service)(nil)
 )
 
-func ProvideService(cfg *setting.Cfg, search searchV2.SearchService, store store.StorageService) *Service {
-	return newService(cfg, search, store)
+func ProvideService(cfg *setting.Cfg, search searchV2.SearchService, store store.StorageService, frameCache managedstream.FrameCache) *Service {
+	return newService(cfg, search, store, frameCache)
 }
 
-func newService(cfg *setting.Cfg, search searchV2.SearchService, store store.StorageService) *Service {
+func newService(cfg *setting.Cfg, search searchV2.SearchService, store store.StorageService, frameCache managedstream.FrameCache) *Service {
 	s := &Service{
-		search: search,
-		store:  store,
+		search:     search,
+		store:      store,
+		frameCache: frameCache,
 	}
 
 	return s
@@ -52,8 +56,9 @@ func newService(cfg *setting.Cfg, search searchV2.SearchService, store store.Sto
 
 // Service exists regardless of user settings
 type Service struct {
-	search searchV2.SearchService
-	store  store.StorageService
+	search     searchV2.SearchService
+	store      store.StorageService
+	frameCache managedstream.FrameCache
 }
 
 func DataSourceModel(orgId int64) *models.DataSource {
@@ -81,6 +86,8 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 			response.Responses[q.RefID] = s.doReadQuery(ctx, q)
 		case queryTypeSearch:
 			response.Responses[q.RefID] = s.doSearchQuery(ctx, req, q)
+		case queryTypeLiveMeasurements:
+			response.Responses[q.RefID] = s.doLiveMeasurementsQuery(ctx, req, q)
 		default:
 			response.Responses[q.RefID] = backend.DataResponse{
 				Error: fmt.Errorf("unknown query type"),
@@ -166,3 +173,97 @@ func (s *Service) doSearchQuery(ctx context.Context, req *backend.QueryDataReque
 
 	return *s.search.DoDashboardQuery(ctx, req.PluginContext.User, req.PluginContext.OrgID, q)
 }
+
+// doLiveMeasurementsQuery returns the points of the history of a managed stream
+// channel in the time range of the query.
+func (s *Service) doLiveMeasurementsQuery(ctx context.Context, req *backend.QueryDataRequest, query backend.DataQuery) backend.DataResponse {
+	q := &liveMeasurementsQueryModel{}
+	response := backend.DataResponse{}
+	err := json.Unmarshal(query.JSON, &q)
+	if err != nil {
+		response.Error = err
+		return response
+	}
+
+	if s.frameCache == nil {
+		response.Error = fmt.Errorf("live is not available")
+		return response
+	}
+	addr, err := live.ParseChannel(q.Channel)
+	if err != nil {
+		response.Error = err
+		return response
+	}
+	if addr.Scope != live.ScopeStream {
+		response.Error = fmt.Errorf("only the history of the %s channels can be queried", live.ScopeStream)
+		return response
+	}
+
+	frameJSON, ok, err := s.frameCache.GetHistory(ctx, req.PluginContext.OrgID, addr.String())
+	if err != nil {
+		response.Error = err
+		return response
+	}
+	if !ok {
+		return response
+	}
+
+	frame := &data.Frame{}
+	if err := json.Unmarshal(frameJSON, frame); err != nil {
+		response.Error = err
+		return response
+	}
+	frame, err = filterLiveMeasurements(frame, q.Filter, query.TimeRange)
+	if err != nil {
+		response.Error = err
+		return response
+	}
+	response.Frames = data.Frames{frame}
+	return response
+}
+
+// filterLiveMeasurements keeps the rows of the frame in the time range, and the
+// fields of the filter. The time fields are always kept.
+func filterLiveMeasurements(frame *data.Frame, filter *liveDataFilter, timeRange backend.TimeRange) (*data.Frame, error) {
+	if filter != nil && len(filter.Fields) > 0 {
+		keep := make(map[string]bool, len(filter.Fields))
+		for _, name := range filter.Fields {
+			keep[name] = true
+		}
+		fields := make([]*data.Field, 0, len(frame.Fields))
+		for _, field := range frame.Fields {
+			if keep[field.Name] || field.Type().Time() {
+				fields = append(fields, field)
+			}
+		}
+		frame.Fields = fields
+	}
+
+	timeIndex := -1
+	for i, field := range frame.Fields {
+		if field.Type().Time() {
+			timeIndex = i
+			break
+		}
+	}
+	if timeIndex == -1 {
+		return frame, nil
+	}
+
+	timeField := frame.Fields[timeIndex]
+	return frame.FilterRowsByField(timeIndex, func(i interface{}) (bool, error) {
+		var t time.Time
+		switch v := i.(type) {
+		case time.Time:
+			t = v
+		case *time.Time:
+			if v == nil {
+				return false, nil
+			}
+			t = *v
+		default:
+			return false, fmt.Errorf("unexpected value of time field %q", timeField.Name)
+		}
+		return !t.Before(timeRange.From) && !t.After(timeRange.To), nil
+	})
+}
@@ -14,6 +14,9 @@ const (
 	// currently only .csv files are supported,
 	// other file types will eventually be supported (parquet, etc)
 	queryTypeRead = "read"
+
+	// QueryTypeLiveMeasurements will return the history of a managed stream channel
+	queryTypeLiveMeasurements = "measurements"
 )
 
 type listQueryModel struct {
@@ -22,3 +25,12 @@ type listQueryModel struct {
 type readQueryModel struct {
 	Path string `json:"path"`
 }
+
+type liveMeasurementsQueryModel struct {
+	Channel string          `json:"channel"`
+	Filter  *liveDataFilter `json:"filter,omitempty"`
+}
+
+type liveDataFilter struct {
+	Fields []string `json:"fields,omitempty"`
+}
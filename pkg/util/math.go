@@ -1,5 +1,10 @@
 package util
 
+import (
+	"math"
+	"sort"
+)
+
 // MinInt returns the smaller of x or y.
 func MinInt(x, y int) int {
 	if x > y {
@@ -7,3 +12,13 @@ func MinInt(x, y int) int {
 	}
 	return x
 }
+
+// Percentile returns the p-th percentile (0-100) of values with linear interpolation
+// between the closest ranks. values must not be empty, they are sorted in place.
+func Percentile(values []float64, p float64) float64 {
+	sort.Float64s(values)
+	rank := p / 100 * float64(len(values)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,15 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, 3.0, Percentile([]float64{3}, 90))
+	assert.Equal(t, 1.0, Percentile([]float64{4, 1, 3, 2}, 0))
+	assert.Equal(t, 4.0, Percentile([]float64{4, 1, 3, 2}, 100))
+	assert.Equal(t, 2.5, Percentile([]float64{4, 1, 3, 2}, 50))
+	assert.InDelta(t, 3.7, Percentile([]float64{4, 1, 3, 2}, 90), 1e-9)
+}
@@ -23,6 +23,17 @@ export const reducerTypes: Array<SelectableValue<string>> = [
   { value: ReducerID.sum, label: 'Sum', description: 'Get the sum of all values' },
   { value: ReducerID.count, label: 'Count', description: 'Get the number of values' },
   { value: ReducerID.last, label: 'Last', description: 'Get the last value' },
+  { value: ReducerID.first, label: 'First', description: 'Get the first value' },
+  { value: 'median', label: 'Median', description: 'Get the median value' },
+  { value: 'p90', label: 'P90', description: 'Get the 90th percentile' },
+  { value: 'p95', label: 'P95', description: 'Get the 95th percentile' },
+  { value: 'p99', label: 'P99', description: 'Get the 99th percentile' },
+  { value: 'stdDev', label: 'StdDev', description: 'Get the standard deviation' },
+  { value: 'variance', label: 'Variance', description: 'Get the variance' },
+  { value: ReducerID.range, label: 'Range', description: 'Get the difference between the maximum and minimum values' },
+  { value: ReducerID.diff, label: 'Difference', description: 'Get the difference between the last and first values' },
+  { value: 'rate', label: 'Rate', description: 'Get the per-second rate of increase of a counter' },
+  { value: 'count_non_null', label: 'Count non-null', description: 'Get the number of non-null values' },
 ];
 
 export enum ReducerMode {
@@ -69,6 +80,7 @@ export const upsamplingTypes: Array<SelectableValue<string>> = [
 export interface ExpressionQuery extends DataQuery {
   type: ExpressionQueryType;
   reducer?: string;
+  reducerParams?: number[];
   expression?: string;
   window?: string;
   downsampler?: string;
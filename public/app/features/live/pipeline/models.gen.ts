@@ -66,12 +66,31 @@ export interface KeepFieldsFrameProcessorConfig {
 export interface DropFieldsFrameProcessorConfig {
   fieldNames: string[];
 }
+export interface WindowAggregation {
+  fieldName: string;
+  reducer: string;
+  percentile?: number;
+}
+export interface WindowFrameProcessorConfig {
+  sizeMilliseconds: number;
+  slideMilliseconds?: number;
+  labelFields?: string[];
+  aggregations: WindowAggregation[];
+}
 export interface FrameProcessorConfig {
   type: Omit<keyof FrameProcessorConfig, 'type'>;
   dropFields?: DropFieldsFrameProcessorConfig;
   keepFields?: KeepFieldsFrameProcessorConfig;
   multiple?: MultipleFrameProcessorConfig;
+  window?: WindowFrameProcessorConfig;
+}
+export interface AutoOtlpConverterConfig {}
+export interface AutoCsvConverterConfig {
+  fieldNames?: string[];
+  timeField?: string;
+  delimiter?: string;
 }
+export interface AutoPrometheusConverterConfig {}
 export interface JsonFrameConverterConfig {}
 export interface AutoInfluxConverterConfig {
   frameFormat: string;
@@ -99,6 +118,9 @@ export interface ConverterConfig {
   jsonExact?: ExactJsonConverterConfig;
   influxAuto?: AutoInfluxConverterConfig;
   jsonFrame?: JsonFrameConverterConfig;
+  prometheusAuto?: AutoPrometheusConverterConfig;
+  csvAuto?: AutoCsvConverterConfig;
+  otlpAuto?: AutoOtlpConverterConfig;
 }
 export interface LokiOutputConfig {
   uid: string;